@@ -0,0 +1,73 @@
+// Command backfill imports existing autocherry/* PRs and work branches of a
+// repository into the state store (STATE_BACKEND), so activity from before the
+// store was enabled is tracked too. Safe to rerun.
+//
+//	go run ./cmd/backfill -installation 12345678 -repo owner/name
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/processor"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+
+	// State store drivers register themselves for STATE_BACKEND.
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/state/dynamodb"
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/state/sqlite"
+)
+
+func main() {
+	_ = godotenv.Load() // ok if no .env
+
+	instID := flag.Int64("installation", 0, "GitHub App installation ID for the repository")
+	repoFlag := flag.String("repo", "", "repository as owner/name (repeat with commas for several)")
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall time limit")
+	flag.Parse()
+	if *instID == 0 || *repoFlag == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	st, err := state.Open(context.Background(), processor.StateSettings(cfg))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if st == nil {
+		log.Fatal("STATE_BACKEND (or STATE_FILE) is required")
+	}
+	p := &processor.Processor{AppID: cfg.AppID, PrivateKeyPEM: cfg.PrivateKeyPEM, State: st}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	failed := false
+	for _, full := range strings.Split(*repoFlag, ",") {
+		owner, name, ok := strings.Cut(strings.TrimSpace(full), "/")
+		if !ok || owner == "" || name == "" {
+			log.Fatalf("invalid -repo %q, want owner/name", full)
+		}
+		res, err := p.Backfill(ctx, *instID, owner, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s/%s: %v\n", owner, name, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s/%s: imported %d, kept %d live records\n", owner, name, res.Imported, res.Skipped)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+)
+
+// sqsHandler processes an SQS-triggered batch. Records that should be retried
+// are reported as batch item failures; this requires ReportBatchItemFailures
+// on the event source mapping, otherwise Lambda retries the whole batch.
+type sqsHandler struct {
+	processor   ingest.Handler
+	filter      queue.Filter
+	deleteOn4xx bool
+}
+
+func (h *sqsHandler) handle(ctx context.Context, ev events.SQSEvent) (events.SQSEventResponse, error) {
+	var resp events.SQSEventResponse
+	for _, rec := range ev.Records {
+		code, err := ingest.Dispatch(ctx, h.processor, h.filter, []byte(rec.Body), rec.MessageId)
+		ack := ingest.ShouldAck(code, h.deleteOn4xx)
+		if err != nil {
+			slog.Warn("lambda.message.process_error", "status", code, "err", err, "ack", ack, "messageID", rec.MessageId)
+		} else {
+			slog.Info("lambda.message.processed", "status", code, "ack", ack, "messageID", rec.MessageId)
+		}
+		if !ack {
+			resp.BatchItemFailures = append(resp.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: rec.MessageId})
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type fakeHandler struct {
+	codes map[string]int // delivery -> status
+}
+
+func (f *fakeHandler) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	code := f.codes[delivery]
+	if code >= 500 {
+		return code, errors.New("boom")
+	}
+	return code, nil
+}
+
+func TestHandle_ReportsBatchItemFailures(t *testing.T) {
+	rec := func(id, body string) events.SQSMessage { return events.SQSMessage{MessageId: id, Body: body} }
+
+	h := &sqsHandler{processor: &fakeHandler{codes: map[string]int{"ok": 202, "fail": 500}}, deleteOn4xx: true}
+	resp, err := h.handle(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+		rec("m-ok", `{"headers":{"X-GitHub-Event":"pull_request","X-GitHub-Delivery":"ok"},"body":{"pull_request":{}}}`),
+		rec("m-fail", `{"headers":{"X-GitHub-Event":"pull_request","X-GitHub-Delivery":"fail"},"body":{"pull_request":{}}}`),
+		rec("m-bad", "{{not json"),
+	}})
+	if err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	if len(resp.BatchItemFailures) != 1 || resp.BatchItemFailures[0].ItemIdentifier != "m-fail" {
+		t.Fatalf("failures = %+v, want only m-fail (bad envelope dropped with deleteOn4xx)", resp.BatchItemFailures)
+	}
+
+	h.deleteOn4xx = false
+	resp, _ = h.handle(context.Background(), events.SQSEvent{Records: []events.SQSMessage{rec("m-bad", "{{not json")}})
+	if len(resp.BatchItemFailures) != 1 {
+		t.Fatalf("bad envelope should be retried without deleteOn4xx: %+v", resp.BatchItemFailures)
+	}
+}
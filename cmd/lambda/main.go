@@ -0,0 +1,43 @@
+// Command lambda runs the processor as an SQS-triggered AWS Lambda function
+// instead of the long-running poller in cmd/server.
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/processor"
+
+	// State store drivers register themselves for STATE_BACKEND; dynamodb is
+	// the one that survives across invocations.
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/state/dynamodb"
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/state/sqlite"
+)
+
+func main() {
+	// Load first: APP_PROFILE may set LOG_LEVEL.
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	slog.SetDefault(slog.New(config.LogHandler()))
+
+	// Mirrors on /tmp last while the execution environment stays warm; mount
+	// EFS to keep them across cold starts.
+	p, err := processor.FromConfig(context.Background(), cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// The sandbox freezes once the handler returns; finish work first.
+	p.Synchronous = true
+	// One invocation at a time: whatever is there was left by a killed one.
+	p.Workspace.Sweep()
+
+	h := &sqsHandler{processor: p, filter: cfg.EventFilter, deleteOn4xx: cfg.SQSDeleteOn4xx}
+	lambda.Start(h.handle)
+}
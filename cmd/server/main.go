@@ -7,75 +7,123 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 
-	awscfg "github.com/aws/aws-sdk-go-v2/config"
-	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
-
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/badge"
 	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
-	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest/sqs"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
 	"github.com/ealebed/gh-app-cherry-pick-poc/internal/processor"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/shard"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/support"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/usage"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/webhook"
+
+	// Ingest backends register themselves for INGEST_MODE.
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest/amqp"
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest/nats"
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest/redisstream"
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest/servicebus"
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest/sqs"
+
+	// State store drivers register themselves for STATE_BACKEND.
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/state/dynamodb"
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/state/sqlite"
 )
 
 func main() {
 	_ = godotenv.Load() // ok if no .env
 
-	// Structured JSON logs; control with LOG_LEVEL=debug|info|warn|error
-	level := slog.LevelInfo
-	switch strings.ToLower(os.Getenv("LOG_LEVEL")) {
-	case "debug":
-		level = slog.LevelDebug
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	}
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
-	slog.SetDefault(logger)
-
+	// Load first: APP_PROFILE may set LOG_LEVEL.
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	logHandler := config.LogHandler()
+	// Support bundles need recent history, including debug-level git/API lines.
+	var recorder *support.Recorder
+	if cfg.SupportBundleToken != "" {
+		recorder = support.NewRecorder(logHandler, cfg.SupportLogBuffer, 200)
+		logHandler = recorder
+	}
+	logger := slog.New(logHandler)
+	slog.SetDefault(logger)
+	if cfg.Profile != "" {
+		slog.Info("config.profile", "profile", cfg.Profile, "dry_run", cfg.DryRun)
+	}
+
 	// Build the GitHub processor.
-	p := &processor.Processor{
-		AppID:         cfg.AppID,
-		PrivateKeyPEM: cfg.PrivateKeyPEM,
-		WebhookSecret: cfg.WebhookSecret,
-		GitUserName:   cfg.GitUserName,
-		GitUserEmail:  cfg.GitUserEmail,
-		// Make the per-PR processing timeout configurable.
-		CherryTimeout: time.Duration(cfg.CherryTimeoutSeconds) * time.Second,
-	}
-
-	// AWS SDK v2 config + SQS client.
-	awsCfg, err := awscfg.LoadDefaultConfig(context.Background(),
-		awscfg.WithRegion(cfg.AWSRegion),
-	)
+	p, err := processor.FromConfig(context.Background(), cfg)
 	if err != nil {
-		log.Fatalf("load AWS config: %v", err)
+		log.Fatal(err)
 	}
-	sqsClient := awssqs.NewFromConfig(awsCfg)
+	// With visibility heartbeats or SQS_CONCURRENCY the SQS worker should
+	// only delete a message once its work is done, so process queue
+	// deliveries inline.
+	p.Synchronous = cfg.SQSSynchronous()
 
-	// SQS worker wiring — note: we pass *processor.Processor which implements the Worker’s Handler interface.
-	worker := &sqs.Worker{
-		Client:            sqsClient,
-		QueueURL:          cfg.SQSQueueURL,
-		MaxMessages:       cfg.SQSMaxMessages,
-		WaitTimeSeconds:   cfg.SQSWaitTimeSeconds,
-		VisibilityTimeout: cfg.SQSVisibilityTimeout,
-		DeleteOn4xx:       cfg.SQSDeleteOn4xx,
-		Processor:         p,
+	if recorder != nil {
+		p.Envelopes = recorder
 	}
 
-	// Health endpoint.
+	if cfg.UsageReportToken != "" || cfg.UsageFile != "" || cfg.UsageSlackWebhookURL != "" {
+		p.Usage, err = usage.Open(cfg.UsageFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Optional per-PR event lock shared by all replicas.
+	if cfg.PRLockDistributed {
+		if cfg.ShardRedisURL == "" {
+			log.Fatal("PR_LOCK_DISTRIBUTED requires SHARD_REDIS_URL")
+		}
+		opts, err := redis.ParseURL(cfg.ShardRedisURL)
+		if err != nil {
+			log.Fatalf("parse SHARD_REDIS_URL: %v", err)
+		}
+		p.PRLockStore, p.PRLockOwner = &shard.RedisLeases{Client: redis.NewClient(opts)}, cfg.ReplicaID
+	}
+
+	// `server redrive` replays the dead-letter queue with this processor and exits.
+	if len(os.Args) > 1 && os.Args[1] == "redrive" {
+		os.Exit(runRedrive(cfg, p, os.Args[2:]))
+	}
+
+	// Ingest backend selected by INGEST_MODE — *processor.Processor implements ingest.Handler.
+	var source ingest.Source
+	if cfg.RunsQueue() {
+		source, err = ingest.New(context.Background(), cfg.IngestMode, cfg, p)
+		if err != nil {
+			log.Fatalf("ingest %s: %v", cfg.IngestMode, err)
+		}
+	}
+
+	// Health + metrics endpoints.
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	mux.Handle("/metrics", metrics.Default.Handler())
+	if recorder != nil {
+		mux.Handle("/debug/support-bundle", &support.Handler{
+			Builder: &support.Builder{Recorder: recorder, State: p.State},
+			Token:   cfg.SupportBundleToken,
+		})
+	}
+	if p.Usage != nil && cfg.UsageReportToken != "" {
+		mux.Handle(usage.Path, &usage.Handler{Meter: p.Usage, Token: cfg.UsageReportToken})
+	}
+	if len(cfg.BadgeRepos) > 0 && p.State != nil {
+		mux.Handle(badge.Pattern, &badge.Handler{Store: p.State, Repos: cfg.BadgeRepos})
+	}
+	if cfg.RunsWebhook() {
+		// Direct GitHub deliveries; signatures are verified by the processor.
+		mux.Handle(cfg.WebhookPath, &webhook.Server{Processor: p})
+	}
 
 	srv := &http.Server{
 		Addr:              cfg.ListenPort,
@@ -87,11 +135,32 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	sourceDone := make(chan struct{})
+	if source != nil {
+		go func() {
+			defer close(sourceDone)
+			if err := source.Run(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("ingest.exit", "mode", cfg.IngestMode, "err", err)
+				_ = srv.Shutdown(context.Background())
+			}
+		}()
+	} else {
+		close(sourceDone)
+	}
+
+	if cfg.WorkspaceSweepSecs > 0 {
+		go p.Workspace.Run(ctx, time.Duration(cfg.WorkspaceSweepSecs)*time.Second)
+	}
+	// Usage outlives ingest so the final flush includes drained work.
+	usageCtx, usageCancel := context.WithCancel(context.Background())
+	usageDone := make(chan struct{})
 	go func() {
-		if err := worker.Run(ctx); err != nil && ctx.Err() == nil {
-			slog.Error("sqs.worker.exit", "err", err)
-			_ = srv.Shutdown(context.Background())
+		defer close(usageDone)
+		var notify *usage.Slack
+		if cfg.UsageSlackWebhookURL != "" {
+			notify = &usage.Slack{WebhookURL: cfg.UsageSlackWebhookURL}
 		}
+		p.Usage.Run(usageCtx, time.Minute, notify)
 	}()
 
 	// Handle SIGINT/SIGTERM for graceful shutdown.
@@ -100,7 +169,7 @@ func main() {
 
 	// Run HTTP (healthz) server.
 	go func() {
-		slog.Info("server.start", "addr", cfg.ListenPort)
+		slog.Info("server.start", "addr", cfg.ListenPort, "mode", cfg.Mode)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			slog.Error("server.error", "err", err)
 			stop <- syscall.SIGTERM
@@ -108,13 +177,27 @@ func main() {
 	}()
 
 	<-stop
-	slog.Info("shutdown.begin")
-	cancel()
+	drain := time.Duration(cfg.ShutdownDrainSeconds) * time.Second
+	slog.Info("shutdown.begin", "drain", drain)
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), drain)
+	defer drainCancel()
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer shutdownCancel()
-	if err := srv.Shutdown(shutdownCtx); err != nil {
+	// Stop taking work: no more receives, no new webhook deliveries. In-flight
+	// queue messages and HTTP requests finish (and are acked) meanwhile.
+	cancel()
+	if err := srv.Shutdown(drainCtx); err != nil {
 		slog.Warn("server.shutdown.error", "err", err)
 	}
+	select {
+	case <-sourceDone:
+	case <-drainCtx.Done():
+		slog.Warn("shutdown.drain_timeout", "stage", "ingest", "mode", cfg.IngestMode)
+	}
+	// Then let handed-off picks finish pushing and opening their PRs.
+	if left := p.Drain(drainCtx); left > 0 {
+		slog.Warn("shutdown.drain_timeout", "stage", "work", "abandoned", left)
+	}
+	usageCancel()
+	<-usageDone
 	slog.Info("shutdown.complete")
 }
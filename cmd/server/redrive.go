@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	awscfg "github.com/aws/aws-sdk-go-v2/config"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest/sqs"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/processor"
+)
+
+// runRedrive replays messages from the SQS dead-letter queue through p and
+// prints one line per message. It returns the process exit code: 1 when any
+// message still failed, 2 on usage or setup errors.
+//
+//	server redrive [-dlq URL] [-max N] [-timeout D]
+func runRedrive(cfg *config.Config, p *processor.Processor, args []string) int {
+	fs := flag.NewFlagSet("redrive", flag.ContinueOnError)
+	dlq := fs.String("dlq", cfg.SQSDLQURL, "dead-letter queue URL (default $SQS_DLQ_URL)")
+	limit := fs.Int("max", 100, "replay at most this many messages (0 = all)")
+	timeout := fs.Duration("timeout", 30*time.Minute, "overall time limit")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *dlq == "" {
+		fmt.Fprintln(os.Stderr, "redrive: -dlq or SQS_DLQ_URL is required")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	awsCfg, err := awscfg.LoadDefaultConfig(ctx, awscfg.WithRegion(cfg.AWSRegion))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "redrive: load AWS config: %v\n", err)
+		return 2
+	}
+
+	// Outcomes must reflect the finished work, not the 202 hand-off.
+	p.Synchronous = true
+	w := &sqs.Worker{
+		Client:            awssqs.NewFromConfig(awsCfg),
+		QueueURL:          *dlq,
+		VisibilityTimeout: cfg.SQSVisibilityTimeout,
+		DeleteOn4xx:       cfg.SQSDeleteOn4xx,
+		Quarantine:        sqs.NewQuarantine(awsCfg, cfg),
+		Filter:            cfg.EventFilter,
+		Processor:         p,
+	}
+	results, err := w.Redrive(ctx, *limit)
+
+	failed := 0
+	for _, r := range results {
+		outcome := "deleted"
+		if !r.Deleted {
+			outcome = "kept"
+			failed++
+		}
+		line := fmt.Sprintf("%s\tstatus=%d\t%s", r.MessageID, r.Status, outcome)
+		if r.Err != nil {
+			line += "\terr=" + r.Err.Error()
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("redrive: %d replayed, %d deleted, %d kept\n", len(results), len(results)-failed, failed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "redrive: %v\n", err)
+		return 1
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+// Package badge serves SVG status badges for release branches from the
+// state store: how many backports onto the branch landed and how many are
+// still pending, for embedding in READMEs and release dashboards.
+package badge
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+// Mount pattern; {branch...} keeps slashes (release/1.2).
+const Pattern = "GET /badge/{owner}/{repo}/{branch...}"
+
+// Handler serves Pattern. Only repositories in Repos are answered ("*" allows
+// every repository in the store), so private repository names and activity
+// are not exposed by default.
+type Handler struct {
+	Store state.Store
+	Repos []string
+}
+
+// Counts summarizes the backports onto one target branch.
+type Counts struct {
+	Done     int // merged, or nothing to pick
+	Open     int // PR open
+	Conflict int // manual backport requested
+	Orphaned int // work branch without a PR
+}
+
+// Pending is everything that still needs attention.
+func (c Counts) Pending() int { return c.Open + c.Conflict + c.Orphaned }
+
+// Tally counts recs targeting branch. Closed-without-merge backports were
+// declined and count neither way.
+func Tally(recs []state.Record, branch string) Counts {
+	var c Counts
+	for _, r := range recs {
+		if r.Target != branch {
+			continue
+		}
+		switch r.Status {
+		case state.StatusMerged, state.StatusNoop:
+			c.Done++
+		case state.StatusOpen:
+			c.Open++
+		case state.StatusConflict:
+			c.Conflict++
+		case state.StatusOrphaned:
+			c.Orphaned++
+		}
+	}
+	return c
+}
+
+func (h *Handler) allowed(repo string) bool {
+	return slices.Contains(h.Repos, "*") || slices.Contains(h.Repos, repo)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	repo := r.PathValue("owner") + "/" + r.PathValue("repo")
+	branch := r.PathValue("branch")
+	if h.Store == nil || !h.allowed(repo) || branch == "" {
+		http.NotFound(w, r)
+		return
+	}
+	recs, err := h.Store.List(r.Context(), repo)
+	if err != nil {
+		slog.Error("badge.state_error", "repo", repo, "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	// Image proxies (GitHub's camo) cache badges; keep them reasonably fresh.
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_, _ = w.Write(Render("backports "+branch, Tally(recs, branch)))
+}
+
+// Render draws a flat two-part badge: label on grey, status on a color for
+// the worst state (red for conflicts, yellow for anything pending).
+func Render(label string, c Counts) []byte {
+	total := c.Done + c.Pending()
+	msg, color := "none", "#9f9f9f"
+	switch {
+	case total == 0:
+	case c.Conflict > 0:
+		msg, color = fmt.Sprintf("%d/%d, %d conflict", c.Done, total, c.Conflict), "#e05d44"
+	case c.Pending() > 0:
+		msg, color = fmt.Sprintf("%d/%d, %d pending", c.Done, total, c.Pending()), "#dfb317"
+	default:
+		msg, color = fmt.Sprintf("%d/%d", c.Done, total), "#4c1"
+	}
+	lw, mw := textWidth(label), textWidth(msg)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`,
+		lw+mw, html.EscapeString(label), html.EscapeString(msg))
+	fmt.Fprintf(&sb, `<title>%s: %s</title>`, html.EscapeString(label), html.EscapeString(msg))
+	fmt.Fprintf(&sb, `<rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/>`, lw, lw, mw, color)
+	sb.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	fmt.Fprintf(&sb, `<text x="%d" y="14">%s</text><text x="%d" y="14">%s</text></g></svg>`,
+		lw/2, html.EscapeString(label), lw+mw/2, html.EscapeString(msg))
+	return []byte(sb.String())
+}
+
+// textWidth approximates Verdana 11px (about 7px per character) plus padding.
+func textWidth(s string) int { return len([]rune(s))*7 + 10 }
@@ -0,0 +1,61 @@
+package badge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+func TestHandler_RendersBranchCounts(t *testing.T) {
+	ctx := context.Background()
+	st := state.NewMemory()
+	for i, s := range []state.Status{state.StatusMerged, state.StatusNoop, state.StatusOpen, state.StatusClosed} {
+		_ = st.Put(ctx, state.Record{Repo: "o/r", WorkBranch: "autocherry/release-1.2/" + string(rune('a'+i)), Target: "release/1.2", Status: s})
+	}
+	_ = st.Put(ctx, state.Record{Repo: "o/r", WorkBranch: "autocherry/release-2/x", Target: "release/2", Status: state.StatusConflict})
+
+	mux := http.NewServeMux()
+	mux.Handle(Pattern, &Handler{Store: st, Repos: []string{"o/r"}})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/badge/o/r/release/1.2", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("status=%d type=%q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "backports release/1.2: 2/3, 1 pending") || !strings.Contains(body, "#dfb317") {
+		t.Fatalf("unexpected badge: %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/badge/o/r/release/2", nil))
+	if !strings.Contains(rec.Body.String(), "0/1, 1 conflict") || !strings.Contains(rec.Body.String(), "#e05d44") {
+		t.Fatalf("conflict badge: %s", rec.Body.String())
+	}
+}
+
+func TestHandler_OnlyListedRepos(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(Pattern, &Handler{Store: state.NewMemory(), Repos: []string{"o/public"}})
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/badge/o/private/main", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unlisted repo: status %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/badge/o/public/main", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), ": none") {
+		t.Fatalf("listed repo without records: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRender_EscapesBranch(t *testing.T) {
+	out := string(Render(`backports <x>&"`, Counts{Done: 1}))
+	if strings.Contains(out, "<x>") || !strings.Contains(out, "&lt;x&gt;&amp;") || !strings.Contains(out, "#4c1") {
+		t.Fatalf("unexpected: %s", out)
+	}
+}
@@ -0,0 +1,65 @@
+package cherry
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// reTrailer matches a git trailer line ("Signed-off-by: ...").
+var reTrailer = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: \S`)
+
+// WithFooter replaces the "(cherry picked from commit <sha>)" line that
+// `git cherry-pick -x` leaves in msg, if any, with footer as the message's
+// last paragraph. A footer of trailers joins the trailers msg ends with
+// (e.g. Signed-off-by), so git still reads them as one block.
+func WithFooter(msg, sha, footer string) string {
+	marker := "(cherry picked from commit " + sha + ")"
+	var kept []string
+	for _, l := range strings.Split(msg, "\n") {
+		if strings.TrimSpace(l) != marker {
+			kept = append(kept, l)
+		}
+	}
+	// Collapse the blank lines the marker line leaves behind.
+	paras := strings.Split(strings.TrimSpace(strings.Join(kept, "\n")), "\n\n")
+	out := paras[:0]
+	for _, p := range paras {
+		if p = strings.Trim(p, "\n"); p != "" {
+			out = append(out, p)
+		}
+	}
+	footer = strings.TrimSpace(footer)
+	if footer == "" {
+		return strings.Join(out, "\n\n") + "\n"
+	}
+	if n := len(out); n > 1 && trailers(out[n-1]) && trailers(footer) {
+		out[n-1] += "\n" + footer
+	} else {
+		out = append(out, footer)
+	}
+	return strings.Join(out, "\n\n") + "\n"
+}
+
+// trailers reports whether every line of paragraph p is a trailer.
+func trailers(p string) bool {
+	for _, l := range strings.Split(p, "\n") {
+		if !reTrailer.MatchString(l) {
+			return false
+		}
+	}
+	return true
+}
+
+// reword gives HEAD, the pick of sha, opts.Footer in place of git's -x line;
+// without a Footer it does nothing.
+func reword(ctx context.Context, r gitRunner, sha string, opts Options) error {
+	if opts.Footer == nil {
+		return nil
+	}
+	_, msg, err := r.CommitMessage(ctx, "HEAD")
+	if err != nil {
+		return err
+	}
+	return r.Reword(ctx, WithFooter(msg, sha, opts.Footer(sha)))
+}
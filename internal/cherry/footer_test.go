@@ -0,0 +1,72 @@
+package cherry
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithFooter(t *testing.T) {
+	cases := []struct {
+		name, msg, footer, want string
+	}{
+		{
+			name:   "replaces the -x line",
+			msg:    "fix: thing\n\nDetails.\n\n(cherry picked from commit abc123)\n",
+			footer: "Backport-of: #12",
+			want:   "fix: thing\n\nDetails.\n\nBackport-of: #12\n",
+		},
+		{
+			name:   "joins trailers",
+			msg:    "fix: thing\n\n(cherry picked from commit abc123)\nSigned-off-by: bot <bot@example.com>\n",
+			footer: "Backport-of: #12\nOriginal-commit: abc123",
+			want:   "fix: thing\n\nSigned-off-by: bot <bot@example.com>\nBackport-of: #12\nOriginal-commit: abc123\n",
+		},
+		{
+			name:   "prose footer is its own paragraph",
+			msg:    "fix: thing\n\nSigned-off-by: bot <bot@example.com>\n",
+			footer: "Backported from PR #12.",
+			want:   "fix: thing\n\nSigned-off-by: bot <bot@example.com>\n\nBackported from PR #12.\n",
+		},
+		{
+			name: "a subject is not a trailer block",
+			msg:  "fix: thing\n", footer: "Backport-of: #12",
+			want: "fix: thing\n\nBackport-of: #12\n",
+		},
+		{
+			name: "other commits' lines stay",
+			msg:  "fix\n\n(cherry picked from commit fff999)\n", footer: "",
+			want: "fix\n\n(cherry picked from commit fff999)\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := WithFooter(tc.msg, "abc123", tc.footer); got != tc.want {
+				t.Fatalf("WithFooter = %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPick_Footer(t *testing.T) {
+	footer := func(sha string) string { return "Backport-of: " + sha }
+
+	fr := &fakeRunner{}
+	defer withFakeRunner(t, fr)()
+	if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, GitActor{}, Options{Footer: footer}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fr.rewords) != 1 || fr.rewords[0] != "fix: thing\n\nBackport-of: abcdef123456\n" {
+		t.Fatalf("rewords = %q", fr.rewords)
+	}
+
+	// Each of a PR's commits gets its own.
+	fr = &fakeRunner{}
+	defer withFakeRunner(t, fr)()
+	opts := Options{Footer: footer, Commits: []string{"c1", "c2"}, CommitsRef: "refs/pull/1/head"}
+	if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, GitActor{}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(fr.rewords) != 2 || fr.rewords[1] != "fix: thing\n\nBackport-of: c2\n" {
+		t.Fatalf("rewords = %q", fr.rewords)
+	}
+}
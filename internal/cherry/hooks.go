@@ -0,0 +1,139 @@
+package cherry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Hooks are repository-configured shell commands run in the work tree after a
+// successful pick and before push (e.g. `make generate`, `go mod tidy`), so
+// backports stay buildable on branches with different generated code.
+type Hooks struct {
+	Commands []string
+	Timeout  time.Duration     // per command (default 5m)
+	Env      map[string]string // extra environment, on top of a minimal base
+}
+
+// HookRun is the outcome of one hook command, for the PR body.
+type HookRun struct {
+	Command  string
+	Output   string // combined stdout/stderr, truncated to maxHookOutput
+	Duration time.Duration
+}
+
+const (
+	defaultHookTimeout = 5 * time.Minute
+	maxHookOutput      = 64 << 10
+)
+
+// hookCommitMessage is used for the commit holding changes made by hooks.
+const hookCommitMessage = "Apply post-pick hooks\n\nChanges produced by the commands configured under post_pick in .github/cherry-pick.yml."
+
+// runHook executes one command via `sh -c` in dir (test seam).
+var runHook = func(ctx context.Context, dir, command string, env []string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) // #nosec G204 -- commands come from the repository's own config, opt-in via POST_PICK_HOOKS
+	cmd.Dir = dir
+	cmd.Env = env
+	killGroupOnCancel(cmd)
+	cmd.WaitDelay = 5 * time.Second // don't hang on orphaned children holding the pipes
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+// hookEnv builds a scrubbed environment: PATH from the app, a private HOME and
+// TMPDIR under .git (so neither is committed or shared between picks), and the
+// configured extras. App secrets are not passed through the environment, but
+// this is not a sandbox: hooks run as the app's user and can still read what
+// it can, including its process environment under /proc.
+func hookEnv(dir string, extra map[string]string) []string {
+	env := []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + hookHome(dir),
+		"TMPDIR=" + hookTmp(dir),
+		"LANG=C.UTF-8",
+		"CI=true",
+	}
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+extra[k])
+	}
+	return env
+}
+
+func hookHome(dir string) string { return filepath.Join(dir, ".git", "cherry-home") }
+
+func hookTmp(dir string) string { return filepath.Join(dir, ".git", "cherry-tmp") }
+
+// makeHookDirs creates the HOME and TMPDIR given by hookEnv.
+func makeHookDirs(dir string) {
+	_ = os.MkdirAll(hookHome(dir), 0o700)
+	_ = os.MkdirAll(hookTmp(dir), 0o700)
+}
+
+func restoreRemote(ctx context.Context, r gitRunner) {
+	if err := r.RestoreRemoteToken(ctx); err != nil {
+		slog.Error("cherry.hook_restore_remote_error", "err", err)
+	}
+}
+
+// runBounded runs one command with runHook under timeout, truncating its
+// output to maxHookOutput.
+func runBounded(ctx context.Context, dir, command string, env []string, timeout time.Duration) (HookRun, error) {
+	hctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	out, err := runHook(hctx, dir, command, env)
+	if len(out) > maxHookOutput {
+		out = "…(truncated)\n" + out[len(out)-maxHookOutput:]
+	}
+	return HookRun{Command: command, Output: out, Duration: time.Since(start).Round(time.Millisecond)}, err
+}
+
+// runHooks runs every command in order, stopping at the first failure. The
+// installation token is removed from the remote URL for the duration, and any
+// resulting changes are committed on top of the pick.
+func runHooks(ctx context.Context, r gitRunner, h Hooks, commitArgs ...string) ([]HookRun, error) {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	if err := r.StripRemoteToken(ctx); err != nil {
+		return nil, err
+	}
+	defer restoreRemote(ctx, r)
+
+	dir := r.Dir()
+	env := hookEnv(dir, h.Env)
+	makeHookDirs(dir)
+
+	runs := make([]HookRun, 0, len(h.Commands))
+	for _, c := range h.Commands {
+		run, err := runBounded(ctx, dir, c, env, timeout)
+		runs = append(runs, run)
+		if err != nil {
+			slog.Warn("cherry.hook_failed", "command", c, "err", err, "out", run.Output)
+			return runs, fmt.Errorf("post-pick hook %q failed: %w", c, err)
+		}
+		slog.Info("cherry.hook_ok", "command", c, "duration", run.Duration)
+	}
+
+	committed, err := r.CommitAll(ctx, hookCommitMessage, commitArgs...)
+	if err != nil {
+		return runs, fmt.Errorf("commit post-pick hook changes: %w", err)
+	}
+	slog.Info("cherry.hooks_done", "commands", len(h.Commands), "committed", committed)
+	return runs, nil
+}
@@ -0,0 +1,117 @@
+package cherry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func withFakeHook(t *testing.T, fn func(ctx context.Context, dir, command string, env []string) (string, error)) {
+	t.Helper()
+	orig := runHook
+	runHook = fn
+	t.Cleanup(func() { runHook = orig })
+}
+
+func TestPick_RunsHooksBeforePush(t *testing.T) {
+	fr := &fakeRunner{dirty: true}
+	defer withFakeRunner(t, fr)()
+
+	var ran []string
+	var gotEnv []string
+	withFakeHook(t, func(ctx context.Context, dir, command string, env []string) (string, error) {
+		if fr.pushBranch != "" {
+			t.Fatalf("hook %q ran after push", command)
+		}
+		ran = append(ran, command)
+		gotEnv = env
+		return "ok: " + command + "\n", nil
+	})
+
+	opts := Options{Hooks: Hooks{Commands: []string{"make generate", "go mod tidy"}, Env: map[string]string{"GOFLAGS": "-mod=mod"}}}
+	res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, GitActor{Name: "bot"}, opts)
+	if err != nil {
+		t.Fatalf("Pick error: %v", err)
+	}
+	if strings.Join(ran, ",") != "make generate,go mod tidy" {
+		t.Fatalf("hooks ran = %v", ran)
+	}
+	if len(res.HookRuns) != 2 || res.HookRuns[1].Output != "ok: go mod tidy\n" {
+		t.Fatalf("HookRuns = %+v", res.HookRuns)
+	}
+	if !fr.remoteStripped || !fr.remoteRestored {
+		t.Fatalf("token not hidden during hooks: stripped=%v restored=%v", fr.remoteStripped, fr.remoteRestored)
+	}
+	if fr.commitAllMsg != hookCommitMessage {
+		t.Fatalf("hook changes not committed: %q", fr.commitAllMsg)
+	}
+	if fr.pushBranch == "" {
+		t.Fatalf("expected push after hooks")
+	}
+	joined := strings.Join(gotEnv, "\n")
+	if !strings.Contains(joined, "GOFLAGS=-mod=mod") || !strings.Contains(joined, "HOME=/tmp/cherry-test/.git/cherry-home") {
+		t.Fatalf("unexpected hook env: %v", gotEnv)
+	}
+}
+
+func TestPick_HookFailureSkipsPush(t *testing.T) {
+	fr := &fakeRunner{}
+	defer withFakeRunner(t, fr)()
+
+	withFakeHook(t, func(ctx context.Context, dir, command string, env []string) (string, error) {
+		if command == "false" {
+			return "boom\n", errors.New("exit status 1")
+		}
+		t.Fatalf("hook %q should not run after a failure", command)
+		return "", nil
+	})
+
+	opts := Options{Hooks: Hooks{Commands: []string{"false", "echo never"}}}
+	res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, GitActor{Name: "bot"}, opts)
+	if err == nil || !strings.Contains(err.Error(), `post-pick hook "false" failed`) {
+		t.Fatalf("want hook failure, got %v", err)
+	}
+	if len(res.HookRuns) != 1 || res.HookRuns[0].Output != "boom\n" {
+		t.Fatalf("HookRuns = %+v", res.HookRuns)
+	}
+	if fr.pushBranch != "" {
+		t.Fatalf("pushed despite hook failure: %s", fr.pushBranch)
+	}
+	if !fr.remoteRestored {
+		t.Fatalf("remote URL not restored after failure")
+	}
+}
+
+func TestHookEnv_NoAppSecrets(t *testing.T) {
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "s3cret")
+	for _, kv := range hookEnv("/w", nil) {
+		if strings.HasPrefix(kv, "GITHUB_WEBHOOK_SECRET=") {
+			t.Fatalf("app secret leaked into hook env")
+		}
+	}
+}
+
+func TestHookEnv_PrivateTmp(t *testing.T) {
+	env := hookEnv("/w", nil)
+	if !slices.Contains(env, "TMPDIR="+filepath.Join("/w", ".git", "cherry-tmp")) {
+		t.Fatalf("env = %v; want TMPDIR under .git", env)
+	}
+}
+
+func TestRunBounded_KillsProcessGroup(t *testing.T) {
+	dir := t.TempDir()
+	// The background child outlives sh unless the whole group is killed.
+	_, err := runBounded(context.Background(), dir, "(sleep 0.3; touch late) & wait", hookEnv(dir, nil), 50*time.Millisecond)
+	if err == nil {
+		t.Fatal("want a timeout error")
+	}
+	time.Sleep(500 * time.Millisecond)
+	if _, err := os.Stat(filepath.Join(dir, "late")); err == nil {
+		t.Fatal("a hook's child survived the timeout")
+	}
+}
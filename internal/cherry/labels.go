@@ -48,6 +48,36 @@ func ParseTargetBranches(labels []*github.Label) []string {
 	return out
 }
 
+// ParseTrailerTargets extracts target branch names from "Cherry-pick-to:"
+// lines of a commit message, with the same splitting and cleanup as
+// ParseTargetBranches.
+func ParseTrailerTargets(message string) []string {
+	return ParseDirectiveTargets(message, "Cherry-pick-to")
+}
+
+// ParseDirectiveTargets extracts target branch names from "<keyword>:
+// <branches>" lines of text (a commit message, a PR description), matching
+// keyword case-insensitively.
+func ParseDirectiveTargets(text, keyword string) []string {
+	if keyword == "" {
+		return nil
+	}
+	re := regexp.MustCompile(`(?im)^[ \t]*` + regexp.QuoteMeta(keyword) + `:[ \t]*(.+?)[ \t]*\r?$`)
+	var out []string
+	seen := make(map[string]struct{})
+	for _, m := range re.FindAllStringSubmatch(text, -1) {
+		for _, br := range splitBranches(m[1]) {
+			br = strings.TrimPrefix(br, "refs/heads/")
+			if _, ok := seen[br]; ok || br == "" {
+				continue
+			}
+			seen[br] = struct{}{}
+			out = append(out, br)
+		}
+	}
+	return out
+}
+
 func splitBranches(s string) []string {
 	// First split by comma, then split any remaining by whitespace.
 	var res []string
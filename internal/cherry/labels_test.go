@@ -1,6 +1,7 @@
 package cherry
 
 import (
+	"strings"
 	"testing"
 
 	github "github.com/google/go-github/v75/github"
@@ -138,3 +139,54 @@ func Test_splitBranches(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTrailerTargets(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want []string
+	}{
+		{
+			name: "single trailer",
+			msg:  "Fix crash\n\nDetails.\n\nCherry-pick-to: devops-release/0021\n",
+			want: []string{"devops-release/0021"},
+		},
+		{
+			name: "several trailers and lists, deduped",
+			msg:  "Fix\n\ncherry-pick-to: refs/heads/a-release/0001, a-release/0002\r\nCherry-Pick-To: a-release/0001 b-release/0003\nSigned-off-by: x",
+			want: []string{"a-release/0001", "a-release/0002", "b-release/0003"},
+		},
+		{
+			name: "mentioned in prose only",
+			msg:  "Explain why we no longer Cherry-pick-to: old branches",
+		},
+		{
+			name: "empty trailer",
+			msg:  "Fix\n\nCherry-pick-to:   \n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ParseTrailerTargets(tc.msg)
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseDirectiveTargets(t *testing.T) {
+	body := "Fixes the crash.\r\n\r\nbackport-to: devops-release/0021, devops-release/0022\r\n"
+	if got := ParseDirectiveTargets(body, "Backport-to"); strings.Join(got, ",") != "devops-release/0021,devops-release/0022" {
+		t.Fatalf("got %v", got)
+	}
+	if got := ParseDirectiveTargets(body, "Cherry-pick-to"); len(got) != 0 {
+		t.Fatalf("other keyword matched: %v", got)
+	}
+	if got := ParseDirectiveTargets("BackportXto: a-release/0001", "Backport.to"); len(got) != 0 {
+		t.Fatalf("keyword not literal: %v", got)
+	}
+	if got := ParseDirectiveTargets("Backport-to: a-release/0001", ""); len(got) != 0 {
+		t.Fatalf("empty keyword matched: %v", got)
+	}
+}
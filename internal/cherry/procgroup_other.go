@@ -0,0 +1,9 @@
+//go:build !unix
+
+package cherry
+
+import "os/exec"
+
+// killGroupOnCancel is a no-op without process groups; only the shell itself
+// is killed when its context ends.
+func killGroupOnCancel(cmd *exec.Cmd) {}
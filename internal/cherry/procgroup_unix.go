@@ -0,0 +1,16 @@
+//go:build unix
+
+package cherry
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killGroupOnCancel starts cmd in its own process group and kills the whole
+// group when its context ends, so a timed-out hook can't leave children
+// running in (or outside) the work tree.
+func killGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+}
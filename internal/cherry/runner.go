@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/ealebed/gh-app-cherry-pick-poc/internal/gitexec"
@@ -18,23 +21,226 @@ type GitActor struct {
 // --- test seam: minimal interface our code needs ---
 type gitRunner interface {
 	Clean() // NOTE: no error return to match gitexec.Runner
+	UseMirror(c *gitexec.MirrorCache)
+	UseSharedClones(c *gitexec.SharedClones)
+	UseSSH(a gitexec.SSHAuth)
 	CloneWithToken(ctx context.Context, owner, repo, token string) error
 	ConfigUser(ctx context.Context, name, email string) error
 	Fetch(ctx context.Context, refs ...string) error
+	FetchFull(ctx context.Context, refs ...string) error
 	CheckoutBranchFrom(ctx context.Context, newBranch, fromRef string) error
-	CherryPick(ctx context.Context, sha string) error
-	CherryPickWithMainline(ctx context.Context, mainline int, sha string) error
+	CherryPick(ctx context.Context, sha string, args ...string) error
+	CherryPickWithMainline(ctx context.Context, mainline int, sha string, args ...string) error
+	CherryPickRange(ctx context.Context, fromSHA, toSHA string, args ...string) error
+	UsesLFS(ctx context.Context, ref string) bool
+	SetupLFS(ctx context.Context) error
+	PullLFS(ctx context.Context, paths []string) error
+	AbortCherryPick(ctx context.Context)
+	ApplyPatch(ctx context.Context, patch []byte) error
+	ApplyMailbox(ctx context.Context, mbox []byte, args ...string) error
+	AbortAm(ctx context.Context)
+	CommitMessage(ctx context.Context, sha string) (author, message string, err error)
+	Commit(ctx context.Context, message, author string, args ...string) error
+	Amend(ctx context.Context, author string, trailers []string) error
+	Reword(ctx context.Context, message string) error
+	ResetHard(ctx context.Context) error
+	Dir() string
+	StripRemoteToken(ctx context.Context) error
+	RestoreRemoteToken(ctx context.Context) error
+	CommitAll(ctx context.Context, message string, args ...string) (bool, error)
+	ChangedFiles(ctx context.Context, base string) ([]string, error)
+	ConflictedFiles(ctx context.Context) ([]string, error)
+	ConflictStatus(ctx context.Context) (status, diffstat string, err error)
 	Push(ctx context.Context, branch string) error
+	PushToRepo(ctx context.Context, owner, repo, token, branch string) error
+	FetchBranch(ctx context.Context, branch string) (string, error)
+	Rebase(ctx context.Context, upstream string) error
+	PushForceWithLease(ctx context.Context, branch, expect string) error
+}
+
+// Options tunes a single pick; the zero value is plain `git cherry-pick -x`.
+type Options struct {
+	// PatchFallback returns the commit's diff (e.g. from the GitHub API).
+	// When set and the cherry-pick conflicts, the diff is applied with
+	// `git apply --3way` instead, which copes better with renamed files.
+	PatchFallback func(ctx context.Context) ([]byte, error)
+	// MailboxFallback returns the change as a format-patch mailbox (e.g. a
+	// PR's .patch). When set, it is applied with `git am -3` if the
+	// cherry-pick conflicts (before PatchFallback) or sha cannot be fetched.
+	MailboxFallback func(ctx context.Context) ([]byte, error)
+	// Hooks run after a successful pick, before push.
+	Hooks Hooks
+	// Verify runs after the hooks, before push; its outcome is reported in
+	// Result.Verify and never blocks the push.
+	Verify Verify
+	// PrePush runs after Verify, like it, but fails the pick with
+	// ErrPrePushFailed (and its outcome in Result.PrePush) instead of
+	// pushing a branch it finds broken.
+	PrePush Verify
+	// Fetched, when set, receives the size in bytes of the git objects
+	// fetched for the pick (usage metering).
+	Fetched func(bytes int64)
+	// Fork, when set, receives the work branch if the repository refuses
+	// the push by policy (e.g. protected autocherry/* namespaces).
+	Fork *Fork
+	// CommitConflicts commits a conflicting pick with its conflict markers
+	// and pushes it anyway (see Result.Conflicts), so the conflicts can be
+	// resolved on the work branch instead of from scratch.
+	CommitConflicts bool
+	// Strategy selects the merge strategy and its option for the pick (zero
+	// value = git's defaults).
+	Strategy Strategy
+	// Commits, when set, are picked one by one in order instead of sha
+	// (which still names the work branch), e.g. a PR's own commits; they are
+	// fetched via CommitsRef. PatchFallback and CommitConflicts do not apply.
+	Commits    []string
+	CommitsRef string
+	// RangeFrom, when set, picks the series RangeFrom..sha (RangeFrom itself
+	// excluded, as in git) instead of sha alone. PatchFallback and
+	// CommitConflicts do not apply.
+	RangeFrom string
+	// Author ("Name <email>"), when set, becomes the picked commit's author,
+	// e.g. the PR author instead of whoever merged it; CoAuthors get
+	// Co-authored-by trailers. Neither applies to Commits, RangeFrom or git
+	// am picks, which keep each commit's own author.
+	Author    string
+	CoAuthors []string
+	// FullCloneRetry repeats a pick that conflicts with a complete
+	// (non-shallow, non-partial) fetch, since blob:none partial clones can
+	// miss renames across old blobs.
+	FullCloneRetry bool
+	// Signoff adds a Signed-off-by trailer for the bot (the committer) to
+	// every commit the pick creates, for DCO-enforcing branches.
+	Signoff bool
+	// AllowEmpty keeps commits that are empty to begin with (e.g. version
+	// bump markers) instead of treating them as no-ops; KeepRedundant also
+	// keeps those that become empty because the target already has their
+	// change.
+	AllowEmpty    bool
+	KeepRedundant bool
+	// Mirror, when set, keeps a local bare mirror of the repository that
+	// the pick fetches from instead of GitHub (see gitexec.MirrorCache).
+	Mirror *gitexec.MirrorCache
+	// SharedClones, when set, runs the pick in a worktree of a shared clone
+	// of the repository instead of a clone of its own, so concurrent picks
+	// of one repository share it (see gitexec.SharedClones). It takes
+	// precedence over Mirror.
+	SharedClones *gitexec.SharedClones
+	// LFS sets up git-lfs when the target branch uses Git LFS: pointers are
+	// picked as they are, and the LFS objects of the files the pick changed
+	// are downloaded for hooks and the pre-push upload.
+	LFS bool
+	// Workspace, when set, holds the work tree, within its disk quota.
+	Workspace *gitexec.Workspace
+	// PushRetry is what to do when the work branch push is rejected because
+	// the branch already exists with other commits (e.g. another replica
+	// pushed the same pick): PushRetryRebase or PushRetryForce; "" fails
+	// the pick.
+	PushRetry string
+	// Footer, when set, replaces the "(cherry picked from commit <sha>)"
+	// line of each picked commit with Footer(sha) (e.g. a Backport-of:
+	// trailer). It does not apply to RangeFrom or git am picks.
+	Footer func(sha string) string
+	// Mainline is the parent a merge commit is picked against (git's -m),
+	// for runners that take a merge flag rather than a mainline (0 = 1).
+	Mainline int
+	// SSH, when set, clones and pushes over SSH with its deploy key instead
+	// of the token (see gitexec.SSHAuth).
+	SSH *gitexec.SSHAuth
+	// WorkBranch, when set, names the work branch instead of
+	// autocherry/<target>/<short sha>, e.g. a release train shared by many
+	// picks; with Append the pick goes on top of that existing branch
+	// instead of the target.
+	WorkBranch string
+	Append     bool
+}
+
+// pickArgs renders the cherry-pick flags opts asks for.
+func (o Options) pickArgs() []string {
+	args := o.Strategy.args()
+	if o.AllowEmpty {
+		args = append(args, "--allow-empty")
+	}
+	if o.KeepRedundant {
+		args = append(args, "--keep-redundant-commits")
+	}
+	return append(args, o.commitArgs()...)
+}
+
+// commitArgs renders the flags for commits the pick creates.
+func (o Options) commitArgs() []string {
+	if o.Signoff {
+		return []string{"--signoff"}
+	}
+	return nil
+}
+
+// Strategy is a cherry-pick merge strategy (--strategy) and strategy option
+// (-X), e.g. Option "theirs" to take the picked side of conflicting hunks.
+// FindRenames sets the rename similarity threshold (-X find-renames, e.g.
+// "40%"), or turns rename detection off with "off".
+type Strategy struct {
+	Name        string
+	Option      string
+	FindRenames string
+}
+
+// args renders s as cherry-pick flags.
+func (s Strategy) args() []string {
+	var args []string
+	if s.Name != "" {
+		args = append(args, "--strategy="+s.Name)
+	}
+	if s.Option != "" {
+		args = append(args, "--strategy-option="+s.Option)
+	}
+	switch s.FindRenames {
+	case "":
+	case "off":
+		args = append(args, "--strategy-option=no-renames")
+	default:
+		args = append(args, "--strategy-option=find-renames="+s.FindRenames)
+	}
+	return args
+}
+
+// PushRetry policies for a rejected work branch push.
+const (
+	// PushRetryRebase rebases the pick onto the remote branch (dropping
+	// commits it already has) and pushes again.
+	PushRetryRebase = "rebase"
+	// PushRetryForce overwrites the remote branch, unless it moved again.
+	PushRetryForce = "force"
+)
+
+// Fork is a bot-owned fork and a token that can push to it.
+type Fork struct {
+	Owner, Repo, Token string
+}
+
+// Result describes a successful pick.
+type Result struct {
+	WorkBranch      string
+	AppliedViaPatch bool // the commit was applied from its diff, not cherry-picked
+	AppliedViaAm    bool // the change was applied from its mailbox with git am
+	HookRuns        []HookRun
+	Verify          *VerifyRun // nil when no verification was configured
+	PrePush         *VerifyRun // nil when no pre-push check was configured
+	HeadOwner       string     // owner of the fork holding WorkBranch ("" = the repository itself)
+	Conflicts       []string   // files committed with conflict markers (CommitConflicts)
 }
 
 // injectable constructor (overridden in tests)
-var newGitRunner = func(cwd string, env ...string) (gitRunner, error) {
-	return gitexec.NewRunner(cwd, env...)
+var newGitRunner = func(ws *gitexec.Workspace, env ...string) (gitRunner, error) {
+	return ws.NewRunner(env...)
 }
 
 // ErrNoopCherryPick signals the commit is already present / empty diff
 var ErrNoopCherryPick = errors.New("noop cherry-pick")
 
+// ErrPrePushFailed signals the pre-push check failed; nothing was pushed.
+var ErrPrePushFailed = errors.New("pre-push check failed")
+
 // isNoopCherryPickErr detects “empty” cherry-pick scenarios from git output.
 func isNoopCherryPickErr(err error) bool {
 	if err == nil {
@@ -48,35 +254,137 @@ func isNoopCherryPickErr(err error) bool {
 
 // DoCherryPick cherry-picks a single non-merge commit onto target branch and pushes a new work branch.
 func DoCherryPick(ctx context.Context, owner, repo, token, targetBranch, sha string, actor GitActor) (string, error) {
-	return doCherryPick(ctx, owner, repo, token, targetBranch, sha, actor, 0)
+	res, err := Pick(ctx, owner, repo, token, targetBranch, sha, 0, actor, Options{})
+	return res.WorkBranch, err
 }
 
 // DoCherryPickWithMainline cherry-picks a merge commit with -m <mainline>.
 func DoCherryPickWithMainline(ctx context.Context, owner, repo, token, targetBranch, sha string, mainline int, actor GitActor) (string, error) {
-	return doCherryPick(ctx, owner, repo, token, targetBranch, sha, actor, mainline)
+	res, err := Pick(ctx, owner, repo, token, targetBranch, sha, mainline, actor, Options{})
+	return res.WorkBranch, err
+}
+
+// DoCherryPickRange cherry-picks the non-merge commits fromSHA..toSHA onto
+// target branch in one work branch, named after toSHA.
+func DoCherryPickRange(ctx context.Context, owner, repo, token, targetBranch, fromSHA, toSHA string, actor GitActor) (string, error) {
+	res, err := Pick(ctx, owner, repo, token, targetBranch, toSHA, 0, actor, Options{RangeFrom: fromSHA})
+	return res.WorkBranch, err
+}
+
+// conflictError marks a pick that git could not apply (as opposed to clone,
+// fetch or push failures), which a full clone may still manage.
+type conflictError struct {
+	err    error
+	report ConflictReport
 }
 
-func doCherryPick(ctx context.Context, owner, repo, token, targetBranch, sha string, actor GitActor, mainline int) (string, error) {
-	r, err := newGitRunner("", "GIT_ASKPASS=true")
+func (e conflictError) Error() string { return e.err.Error() }
+func (e conflictError) Unwrap() error { return e.err }
+
+// ConflictReport is what a conflicting pick left in the work tree, captured
+// before the pick was aborted.
+type ConflictReport struct {
+	Files    []string // unmerged paths
+	Status   string   // git status --short
+	Diffstat string   // git diff --stat HEAD
+}
+
+// Conflict returns the report of a pick that err says git could not apply.
+func Conflict(err error) (ConflictReport, bool) {
+	var ce conflictError
+	if !errors.As(err, &ce) {
+		return ConflictReport{}, false
+	}
+	return ce.report, true
+}
+
+// conflictReport captures the conflicted state of r's work tree; what cannot
+// be read is left empty.
+func conflictReport(ctx context.Context, r gitRunner) ConflictReport {
+	var rep ConflictReport
+	rep.Files, _ = r.ConflictedFiles(ctx)
+	if status, diffstat, err := r.ConflictStatus(ctx); err == nil {
+		rep.Status, rep.Diffstat = status, diffstat
+	}
+	return rep
+}
+
+// Pick cherry-picks sha onto targetBranch (with -m mainline when > 0) and
+// pushes a new work branch, honoring opts.
+func Pick(ctx context.Context, owner, repo, token, targetBranch, sha string, mainline int, actor GitActor, opts Options) (Result, error) {
+	if !opts.FullCloneRetry {
+		return pick(ctx, owner, repo, token, targetBranch, sha, mainline, actor, opts, false)
+	}
+	// Conflicts are committed only once the full clone could not help either.
+	first := opts
+	first.CommitConflicts = false
+	res, err := pick(ctx, owner, repo, token, targetBranch, sha, mainline, actor, first, false)
+	var ce conflictError
+	if !errors.As(err, &ce) {
+		return res, err
+	}
+	slog.Info("cherry.full_clone_retry", "target", targetBranch, "sha", sha, "err", err)
+	return pick(ctx, owner, repo, token, targetBranch, sha, mainline, actor, opts, true)
+}
+
+// pick is one attempt of Pick, fetching full history and blobs when full.
+//
+//nolint:gocyclo,funlen // Sequential pipeline with an early exit per outcome
+func pick(ctx context.Context, owner, repo, token, targetBranch, sha string, mainline int, actor GitActor, opts Options, full bool) (Result, error) {
+	r, err := newGitRunner(opts.Workspace, "GIT_ASKPASS=true")
 	if err != nil {
-		return "", err
+		return Result{}, err
 	}
 	defer r.Clean()
 
+	if opts.Mirror != nil {
+		r.UseMirror(opts.Mirror)
+	}
+	if opts.SharedClones != nil {
+		r.UseSharedClones(opts.SharedClones)
+	}
+	if opts.SSH != nil {
+		r.UseSSH(*opts.SSH)
+	}
 	if err := r.CloneWithToken(ctx, owner, repo, token); err != nil {
-		return "", err
+		return Result{}, err
 	}
 	if err := r.ConfigUser(ctx, actor.Name, actor.Email); err != nil {
-		return "", err
+		return Result{}, err
 	}
 
 	// Fetch target branch and the specific commit (and also master as a common case)
-	if err := r.Fetch(ctx,
+	branches := []string{
 		"master:refs/remotes/origin/master",
 		fmt.Sprintf("refs/heads/%s:refs/remotes/origin/%s", targetBranch, targetBranch),
-		sha, // ensure the object exists locally
-	); err != nil {
-		return "", err
+	}
+	base := targetBranch
+	if opts.Append && opts.WorkBranch != "" {
+		base = opts.WorkBranch
+		branches = append(branches, fmt.Sprintf("refs/heads/%s:refs/remotes/origin/%s", base, base))
+	}
+	refs := append(slices.Clone(branches), sha) // ensure the object exists locally
+	if opts.CommitsRef != "" {
+		refs = append(refs, opts.CommitsRef)
+	}
+	if opts.RangeFrom != "" {
+		refs = append(refs, opts.RangeFrom)
+	}
+	// missing is set when sha cannot be fetched but the mailbox can stand in.
+	var missing error
+	fetch := r.Fetch
+	if full {
+		fetch = r.FetchFull
+	}
+	if err := fetch(ctx, refs...); err != nil {
+		if opts.MailboxFallback == nil || len(opts.Commits) > 0 || opts.RangeFrom != "" || fetch(ctx, branches...) != nil {
+			return Result{}, err
+		}
+		slog.Info("cherry.fetch_sha_failed", "target", targetBranch, "sha", sha, "err", err)
+		missing = err
+	}
+	if opts.Fetched != nil {
+		opts.Fetched(dirSize(filepath.Join(r.Dir(), ".git")))
 	}
 
 	short := sha
@@ -85,35 +393,329 @@ func doCherryPick(ctx context.Context, owner, repo, token, targetBranch, sha str
 	}
 	safeTarget := strings.ReplaceAll(targetBranch, "/", "-")
 	workBranch := fmt.Sprintf("autocherry/%s/%s", safeTarget, short)
+	if opts.WorkBranch != "" {
+		workBranch = opts.WorkBranch
+	}
+
+	// LFS goes in before the checkout, which would otherwise smudge.
+	lfs := opts.LFS && r.UsesLFS(ctx, "refs/remotes/origin/"+targetBranch)
+	if lfs {
+		if err := r.SetupLFS(ctx); err != nil {
+			// Without git-lfs the pointers are still picked and pushed as text.
+			slog.Warn("cherry.lfs_setup_failed", "target", targetBranch, "err", err)
+			lfs = false
+		}
+	}
 
 	// Base new branch on the target branch
-	if err := r.CheckoutBranchFrom(ctx, workBranch, "origin/"+targetBranch); err != nil {
-		return "", err
+	if err := r.CheckoutBranchFrom(ctx, workBranch, "origin/"+base); err != nil {
+		return Result{}, err
 	}
 
+	res := Result{WorkBranch: workBranch}
+
 	// Cherry-pick
-	if mainline > 0 {
-		slog.Debug("git.cherry_pick_mainline", "sha", sha, "mainline", mainline)
-		if err := r.CherryPickWithMainline(ctx, mainline, sha); err != nil {
+	pick := func(ctx context.Context) error {
+		if mainline > 0 {
+			slog.Debug("git.cherry_pick_mainline", "sha", sha, "mainline", mainline)
+			return r.CherryPickWithMainline(ctx, mainline, sha, opts.pickArgs()...)
+		}
+		return r.CherryPick(ctx, sha, opts.pickArgs()...)
+	}
+	if missing != nil {
+		if !applyMailboxFallback(ctx, r, sha, opts) {
+			return Result{}, fmt.Errorf("fetch %s: %w", sha, missing)
+		}
+		slog.Info("cherry.applied_via_am", "target", targetBranch, "sha", sha)
+		res.AppliedViaAm = true
+	} else if len(opts.Commits) > 0 {
+		if err := pickCommits(ctx, r, targetBranch, opts); err != nil {
+			return Result{}, err
+		}
+	} else if opts.RangeFrom != "" {
+		if err := r.CherryPickRange(ctx, opts.RangeFrom, sha, opts.pickArgs()...); err != nil {
+			report := conflictReport(ctx, r)
+			r.AbortCherryPick(ctx)
 			if isNoopCherryPickErr(err) {
-				slog.Info("cherry.noop", "target", targetBranch, "sha", sha)
-				return "", ErrNoopCherryPick
+				// git stops at the first commit that is already applied.
+				return Result{}, fmt.Errorf("cherry-picking %s..%s to %s: a commit in the range is already on the target: %w", opts.RangeFrom, sha, targetBranch, err)
 			}
-			return "", fmt.Errorf("conflict cherry-picking %s to %s (mainline %d): %w", sha, targetBranch, mainline, err)
+			return Result{}, conflictError{fmt.Errorf("conflict cherry-picking %s..%s to %s: %w", opts.RangeFrom, sha, targetBranch, err), report}
 		}
-	} else {
-		if err := r.CherryPick(ctx, sha); err != nil {
-			if isNoopCherryPickErr(err) {
-				slog.Info("cherry.noop", "target", targetBranch, "sha", sha)
-				return "", ErrNoopCherryPick
+	} else if pickErr := pick(ctx); pickErr != nil {
+		if isNoopCherryPickErr(pickErr) {
+			slog.Info("cherry.noop", "target", targetBranch, "sha", sha)
+			return Result{}, ErrNoopCherryPick
+		}
+		// The fallbacks below abort the pick, so its state is read first.
+		report := conflictReport(ctx, r)
+		if opts.MailboxFallback != nil && applyMailboxFallback(ctx, r, sha, opts) {
+			slog.Info("cherry.applied_via_am", "target", targetBranch, "sha", sha)
+			res.AppliedViaAm = true
+		} else if opts.PatchFallback == nil || !applyPatchFallback(ctx, r, sha, opts) {
+			if opts.CommitConflicts {
+				// A failed fallback reset the tree, so the pick is redone.
+				redo := pick
+				if opts.PatchFallback == nil && opts.MailboxFallback == nil {
+					redo = nil
+				}
+				if files := commitConflicts(ctx, r, sha, opts, redo); len(files) > 0 {
+					slog.Info("cherry.conflicts_committed", "target", targetBranch, "sha", sha, "files", len(files))
+					res.Conflicts = files
+					if err := reword(ctx, r, sha, opts); err != nil {
+						return Result{}, fmt.Errorf("reword: %w", err)
+					}
+					if err := credit(ctx, r, opts); err != nil {
+						return Result{}, fmt.Errorf("credit authors: %w", err)
+					}
+					if err := push(ctx, r, workBranch, targetBranch, opts, &res); err != nil {
+						return Result{}, err
+					}
+					return res, nil
+				}
 			}
-			return "", fmt.Errorf("conflict cherry-picking %s to %s: %w", sha, targetBranch, err)
+			if mainline > 0 {
+				return Result{}, conflictError{fmt.Errorf("conflict cherry-picking %s to %s (mainline %d): %w", sha, targetBranch, mainline, pickErr), report}
+			}
+			return Result{}, conflictError{fmt.Errorf("conflict cherry-picking %s to %s: %w", sha, targetBranch, pickErr), report}
+		} else {
+			slog.Info("cherry.applied_via_patch", "target", targetBranch, "sha", sha)
+			res.AppliedViaPatch = true
+		}
+	}
+	if missing == nil && len(opts.Commits) == 0 && opts.RangeFrom == "" && !res.AppliedViaAm {
+		if err := reword(ctx, r, sha, opts); err != nil {
+			return Result{}, fmt.Errorf("reword: %w", err)
+		}
+		if err := credit(ctx, r, opts); err != nil {
+			return Result{}, fmt.Errorf("credit authors: %w", err)
 		}
 	}
 
-	// Push work branch
-	if err := r.Push(ctx, workBranch); err != nil {
-		return "", err
+	if lfs {
+		pullLFS(ctx, r, targetBranch)
+	}
+
+	if len(opts.Hooks.Commands) > 0 {
+		runs, err := runHooks(ctx, r, opts.Hooks, opts.commitArgs()...)
+		res.HookRuns = runs
+		if err != nil {
+			return res, err
+		}
+	}
+
+	if opts.Verify.Command != "" {
+		res.Verify = runVerify(ctx, r, opts.Verify, "origin/"+targetBranch)
+	}
+	if opts.PrePush.Command != "" {
+		res.PrePush = runVerify(ctx, r, opts.PrePush, "origin/"+targetBranch)
+		if !res.PrePush.Passed && !res.PrePush.Skipped {
+			return res, fmt.Errorf("%w: %s", ErrPrePushFailed, res.PrePush.Command)
+		}
+	}
+
+	if err := push(ctx, r, workBranch, targetBranch, opts, &res); err != nil {
+		return Result{}, err
+	}
+	return res, nil
+}
+
+// pullLFS downloads the LFS objects of the files the pick changed; without
+// them only the pre-push upload of objects GitHub lacks would fail.
+func pullLFS(ctx context.Context, r gitRunner, targetBranch string) {
+	files, err := r.ChangedFiles(ctx, "origin/"+targetBranch)
+	if err == nil {
+		err = r.PullLFS(ctx, files)
+	}
+	if err != nil {
+		slog.Warn("cherry.lfs_pull_failed", "target", targetBranch, "err", err)
+	}
+}
+
+// pickCommits cherry-picks opts.Commits in order, keeping each commit's
+// author and message. Commits already on the target are skipped; when all
+// are, it returns ErrNoopCherryPick.
+func pickCommits(ctx context.Context, r gitRunner, targetBranch string, opts Options) error {
+	picked := 0
+	for i, c := range opts.Commits {
+		err := r.CherryPick(ctx, c, opts.pickArgs()...)
+		switch {
+		case err == nil:
+			if rerr := reword(ctx, r, c, opts); rerr != nil {
+				return fmt.Errorf("reword %s: %w", c, rerr)
+			}
+			picked++
+		case isNoopCherryPickErr(err):
+			slog.Info("cherry.commit_noop", "target", targetBranch, "sha", c)
+			r.AbortCherryPick(ctx)
+		default:
+			report := conflictReport(ctx, r)
+			r.AbortCherryPick(ctx)
+			return conflictError{fmt.Errorf("conflict cherry-picking %s (commit %d of %d) to %s: %w", c, i+1, len(opts.Commits), targetBranch, err), report}
+		}
+	}
+	if picked == 0 {
+		return ErrNoopCherryPick
+	}
+	slog.Info("cherry.commits_picked", "target", targetBranch, "commits", picked)
+	return nil
+}
+
+// push pushes the work branch, to opts.Fork when the repository refuses it
+// by policy (recorded in res.HeadOwner).
+func push(ctx context.Context, r gitRunner, workBranch, targetBranch string, opts Options, res *Result) error {
+	err := r.Push(ctx, workBranch)
+	if errors.Is(err, gitexec.ErrPushRejected) && opts.PushRetry != "" {
+		err = retryPush(ctx, r, workBranch, opts.PushRetry)
+	}
+	if err == nil {
+		return nil
+	}
+	if opts.Fork == nil || !errors.Is(err, gitexec.ErrPushForbidden) {
+		return err
+	}
+	slog.Info("cherry.push_fork", "target", targetBranch, "fork", opts.Fork.Owner+"/"+opts.Fork.Repo, "err", err)
+	if err := r.PushToRepo(ctx, opts.Fork.Owner, opts.Fork.Repo, opts.Fork.Token, workBranch); err != nil {
+		return fmt.Errorf("push to fork %s/%s: %w", opts.Fork.Owner, opts.Fork.Repo, err)
+	}
+	res.HeadOwner = opts.Fork.Owner
+	return nil
+}
+
+// retryPush pushes workBranch again after it was rejected because the
+// remote branch has other commits, as policy says.
+func retryPush(ctx context.Context, r gitRunner, workBranch, policy string) error {
+	tip, err := r.FetchBranch(ctx, workBranch)
+	if err != nil {
+		return fmt.Errorf("fetch rejected branch %s: %w", workBranch, err)
+	}
+	slog.Info("cherry.push_retry", "branch", workBranch, "policy", policy, "remote", tip)
+	switch policy {
+	case PushRetryRebase:
+		if err := r.Rebase(ctx, "origin/"+workBranch); err != nil {
+			return fmt.Errorf("rebase onto %s: %w", workBranch, err)
+		}
+		return r.Push(ctx, workBranch)
+	case PushRetryForce:
+		return r.PushForceWithLease(ctx, workBranch, tip)
+	}
+	return fmt.Errorf("unknown push retry policy %q", policy)
+}
+
+// commitConflicts commits the conflicted pick of sha as it stands (after
+// redo, when set), markers included, returning the conflicted files (none
+// when nothing was committed).
+func commitConflicts(ctx context.Context, r gitRunner, sha string, opts Options, redo func(context.Context) error) []string {
+	if redo != nil {
+		_ = redo(ctx)
+	}
+	files, err := r.ConflictedFiles(ctx)
+	if err != nil || len(files) == 0 {
+		r.AbortCherryPick(ctx)
+		return nil
+	}
+	author, msg, err := r.CommitMessage(ctx, sha)
+	if err != nil {
+		r.AbortCherryPick(ctx)
+		return nil
+	}
+	msg = fmt.Sprintf("%s\n\n(cherry picked from commit %s)\n\nConflicts:\n\t%s", strings.TrimSpace(msg), sha, strings.Join(files, "\n\t"))
+	if ok, err := r.CommitAll(ctx, msg, opts.commitArgs()...); err != nil || !ok {
+		r.AbortCherryPick(ctx)
+		return nil
+	}
+	// CommitAll commits as the bot; the change is still the original author's.
+	if err := r.Amend(ctx, author, nil); err != nil {
+		slog.Warn("cherry.conflicts_author_error", "sha", sha, "err", err)
+	}
+	return files
+}
+
+// credit makes opts.Author the author of the picked commit (HEAD), when set,
+// and adds Co-authored-by trailers for opts.CoAuthors it lacks.
+func credit(ctx context.Context, r gitRunner, opts Options) error {
+	if opts.Author == "" && len(opts.CoAuthors) == 0 {
+		return nil
+	}
+	author, msg, err := r.CommitMessage(ctx, "HEAD")
+	if err != nil {
+		return err
+	}
+	if opts.Author != "" {
+		author = opts.Author
+	}
+	var trailers []string
+	for _, c := range opts.CoAuthors {
+		t := "Co-authored-by: " + c
+		if strings.EqualFold(c, author) || strings.Contains(strings.ToLower(msg), strings.ToLower(t)) || slices.Contains(trailers, t) {
+			continue
+		}
+		trailers = append(trailers, t)
+	}
+	if opts.Author == "" && len(trailers) == 0 {
+		return nil
+	}
+	return r.Amend(ctx, opts.Author, trailers)
+}
+
+// dirSize sums the sizes of the regular files under dir; unreadable parts
+// are skipped.
+func dirSize(dir string) int64 {
+	var n int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil //nolint:nilerr // best effort
+		}
+		if info, err := d.Info(); err == nil {
+			n += info.Size()
+		}
+		return nil
+	})
+	return n
+}
+
+// applyMailboxFallback aborts the failed cherry-pick, if any, and applies the
+// change's mailbox with `git am -3`, which keeps each patch's author and
+// message and needs no history beyond the target branch. It reports whether
+// that worked; on failure the am is aborted.
+func applyMailboxFallback(ctx context.Context, r gitRunner, sha string, opts Options) bool {
+	r.AbortCherryPick(ctx)
+	mbox, err := opts.MailboxFallback(ctx)
+	if err != nil || len(mbox) == 0 {
+		slog.Warn("cherry.mailbox_fetch_error", "sha", sha, "err", err)
+		return false
+	}
+	if err := r.ApplyMailbox(ctx, mbox, opts.commitArgs()...); err != nil {
+		r.AbortAm(ctx)
+		return false
+	}
+	return true
+}
+
+// applyPatchFallback aborts the failed cherry-pick and applies the commit's
+// diff with a 3-way merge instead, committing with the original author and
+// the same provenance line `-x` would add. It reports whether that worked;
+// on failure the work tree is reset.
+func applyPatchFallback(ctx context.Context, r gitRunner, sha string, opts Options) bool {
+	r.AbortCherryPick(ctx)
+	patch, err := opts.PatchFallback(ctx)
+	if err != nil || len(patch) == 0 {
+		slog.Warn("cherry.patch_fetch_error", "sha", sha, "err", err)
+		return false
+	}
+	if err := r.ApplyPatch(ctx, patch); err != nil {
+		_ = r.ResetHard(ctx)
+		return false
+	}
+	author, msg, err := r.CommitMessage(ctx, sha)
+	if err != nil {
+		_ = r.ResetHard(ctx)
+		return false
+	}
+	msg = fmt.Sprintf("%s\n\n(cherry picked from commit %s)\nApplied-via: git apply --3way", msg, sha)
+	if err := r.Commit(ctx, msg, author, opts.commitArgs()...); err != nil {
+		_ = r.ResetHard(ctx)
+		return false
 	}
-	return workBranch, nil
+	return true
 }
@@ -3,8 +3,14 @@ package cherry
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	testing "testing"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/gitexec"
 )
 
 // ---- fake runner ----
@@ -24,19 +30,84 @@ type fakeRunner struct {
 
 	pickedSHA      string
 	pickedMainline int
+	pickArgs       []string
+	pickedSHAs     []string
+	pickedRange    string
 	pushBranch     string
+	forkPush       string // owner/repo:branch of PushToRepo
 
 	errClone bool
 	errCfg   bool
 	errFetch bool
-	errCO    bool
-	errPick  error
-	errPush  bool
+	missing  string // sha whose fetch fails (unreachable object)
+
+	fullFetches int
+	fullFixes   bool // FetchFull clears errPick
+	errCO       bool
+	errPick     error
+	pickErrs    map[string]error // per sha, overrides errPick
+	errPush     error
+
+	aborted     bool
+	applied     []byte
+	errApply    error
+	commitMsg   string
+	commitAuth  string
+	reset       bool
+	mailbox     []byte
+	mailboxArgs []string
+	errAm       error
+	amAborted   bool
+
+	amends        int
+	amendAuthor   string
+	amendTrailers []string
+
+	remoteStripped bool
+	remoteRestored bool
+	dirty          bool // CommitAll finds changes
+	commitAllMsg   string
+	commitAllArgs  []string
+	commitArgs     []string
+
+	dir        string   // work tree (default /tmp/cherry-test)
+	changed    []string // ChangedFiles result
+	conflicted []string // ConflictedFiles result
+	status     string   // ConflictStatus status
+	diffstat   string   // ConflictStatus diffstat
+	picks      int      // CherryPick calls
 
 	cleaned bool
+	mirror  *gitexec.MirrorCache
+	clones  *gitexec.SharedClones
+	ssh     *gitexec.SSHAuth
+
+	usesLFS   bool  // UsesLFS result
+	errLFS    error // SetupLFS error
+	lfsSetup  bool
+	lfsPulled []string
+
+	pushes      int    // Push calls; errPush fails only the first
+	rebasedOnto string // Rebase upstream
+	errRebase   error
+	forcedLease string // branch:expect of PushForceWithLease
+
+	rewords []string // Reword messages
 }
 
-func (f *fakeRunner) Clean() { f.cleaned = true }
+func (f *fakeRunner) Clean()                                       { f.cleaned = true }
+func (f *fakeRunner) UseMirror(c *gitexec.MirrorCache)             { f.mirror = c }
+func (f *fakeRunner) UseSharedClones(c *gitexec.SharedClones)      { f.clones = c }
+func (f *fakeRunner) UseSSH(a gitexec.SSHAuth)                     { f.ssh = &a }
+func (f *fakeRunner) UsesLFS(ctx context.Context, ref string) bool { return f.usesLFS }
+func (f *fakeRunner) SetupLFS(ctx context.Context) error {
+	f.lfsSetup = f.errLFS == nil
+	return f.errLFS
+}
+func (f *fakeRunner) PullLFS(ctx context.Context, paths []string) error {
+	f.lfsPulled = append(f.lfsPulled, paths...)
+	return nil
+}
 func (f *fakeRunner) CloneWithToken(ctx context.Context, owner, repo, token string) error {
 	f.clonedOwner, f.clonedRepo, f.token = owner, repo, token
 	if f.errClone {
@@ -56,6 +127,16 @@ func (f *fakeRunner) Fetch(ctx context.Context, refs ...string) error {
 	if f.errFetch {
 		return errors.New("fetch failed")
 	}
+	if f.missing != "" && slices.Contains(refs, f.missing) {
+		return errors.New("fatal: remote error: upload-pack: not our ref " + f.missing)
+	}
+	return nil
+}
+func (f *fakeRunner) FetchFull(ctx context.Context, refs ...string) error {
+	f.fullFetches++
+	if f.fullFixes {
+		f.errPick = nil
+	}
 	return nil
 }
 func (f *fakeRunner) CheckoutBranchFrom(ctx context.Context, newBranch, fromRef string) error {
@@ -65,27 +146,114 @@ func (f *fakeRunner) CheckoutBranchFrom(ctx context.Context, newBranch, fromRef
 	}
 	return nil
 }
-func (f *fakeRunner) CherryPick(ctx context.Context, sha string) error {
-	f.pickedSHA = sha
+func (f *fakeRunner) CherryPick(ctx context.Context, sha string, args ...string) error {
+	f.pickedSHA, f.pickArgs = sha, args
+	f.pickedSHAs = append(f.pickedSHAs, sha)
+	f.picks++
+	if err, ok := f.pickErrs[sha]; ok {
+		return err
+	}
+	return f.errPick
+}
+func (f *fakeRunner) CherryPickWithMainline(ctx context.Context, mainline int, sha string, args ...string) error {
+	f.pickedMainline, f.pickedSHA, f.pickArgs = mainline, sha, args
 	return f.errPick
 }
-func (f *fakeRunner) CherryPickWithMainline(ctx context.Context, mainline int, sha string) error {
-	f.pickedMainline, f.pickedSHA = mainline, sha
+func (f *fakeRunner) CherryPickRange(ctx context.Context, fromSHA, toSHA string, args ...string) error {
+	f.pickedRange = fromSHA + ".." + toSHA
 	return f.errPick
 }
 func (f *fakeRunner) Push(ctx context.Context, branch string) error {
 	f.pushBranch = branch
-	if f.errPush {
-		return errors.New("push failed")
+	f.pushes++
+	if f.pushes > 1 {
+		return nil
+	}
+	return f.errPush
+}
+func (f *fakeRunner) FetchBranch(ctx context.Context, branch string) (string, error) {
+	f.fetched = append(f.fetched, "branch:"+branch)
+	return "remote-tip", nil
+}
+func (f *fakeRunner) Rebase(ctx context.Context, upstream string) error {
+	f.rebasedOnto = upstream
+	return f.errRebase
+}
+func (f *fakeRunner) PushForceWithLease(ctx context.Context, branch, expect string) error {
+	f.forcedLease = branch + ":" + expect
+	return nil
+}
+func (f *fakeRunner) PushToRepo(ctx context.Context, owner, repo, token, branch string) error {
+	f.forkPush = owner + "/" + repo + ":" + branch
+	return nil
+}
+
+func (f *fakeRunner) AbortCherryPick(ctx context.Context) { f.aborted = true }
+func (f *fakeRunner) ApplyPatch(ctx context.Context, patch []byte) error {
+	f.applied = patch
+	return f.errApply
+}
+func (f *fakeRunner) ApplyMailbox(ctx context.Context, mbox []byte, args ...string) error {
+	f.mailbox, f.mailboxArgs = mbox, args
+	return f.errAm
+}
+func (f *fakeRunner) Amend(ctx context.Context, author string, trailers []string) error {
+	f.amendAuthor, f.amendTrailers = author, trailers
+	f.amends++
+	return nil
+}
+func (f *fakeRunner) Reword(ctx context.Context, message string) error {
+	f.rewords = append(f.rewords, message)
+	return nil
+}
+func (f *fakeRunner) AbortAm(ctx context.Context) { f.amAborted = true }
+func (f *fakeRunner) CommitMessage(ctx context.Context, sha string) (string, string, error) {
+	return "Alice <alice@example.com>", "fix: thing\n", nil
+}
+func (f *fakeRunner) Commit(ctx context.Context, message, author string, args ...string) error {
+	f.commitMsg, f.commitAuth, f.commitArgs = message, author, args
+	return nil
+}
+func (f *fakeRunner) ResetHard(ctx context.Context) error {
+	f.reset = true
+	return nil
+}
+
+func (f *fakeRunner) Dir() string {
+	if f.dir != "" {
+		return f.dir
 	}
+	return "/tmp/cherry-test"
+}
+func (f *fakeRunner) ConflictedFiles(ctx context.Context) ([]string, error) {
+	return f.conflicted, nil
+}
+func (f *fakeRunner) ConflictStatus(ctx context.Context) (string, string, error) {
+	return f.status, f.diffstat, nil
+}
+func (f *fakeRunner) ChangedFiles(ctx context.Context, base string) ([]string, error) {
+	return f.changed, nil
+}
+func (f *fakeRunner) StripRemoteToken(ctx context.Context) error {
+	f.remoteStripped = true
 	return nil
 }
+func (f *fakeRunner) RestoreRemoteToken(ctx context.Context) error {
+	f.remoteRestored = true
+	return nil
+}
+func (f *fakeRunner) CommitAll(ctx context.Context, message string, args ...string) (bool, error) {
+	if f.dirty {
+		f.commitAllMsg, f.commitAllArgs = message, args
+	}
+	return f.dirty, nil
+}
 
 // helper to install fake newGitRunner and restore after
 func withFakeRunner(t *testing.T, fr *fakeRunner) func() {
 	t.Helper()
 	orig := newGitRunner
-	newGitRunner = func(cwd string, env ...string) (gitRunner, error) { return fr, nil }
+	newGitRunner = func(ws *gitexec.Workspace, env ...string) (gitRunner, error) { return fr, nil }
 	return func() { newGitRunner = orig }
 }
 
@@ -163,6 +331,430 @@ func TestDoCherryPick_NoOpDetected(t *testing.T) {
 	}
 }
 
+func TestPick_PatchFallback(t *testing.T) {
+	patch := func(context.Context) ([]byte, error) { return []byte("diff --git a/x b/x\n"), nil }
+	actor := GitActor{Name: "bot", Email: "bot@noreply"}
+
+	t.Run("applies diff after conflict", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("CONFLICT (modify/delete)")}
+		defer withFakeRunner(t, fr)()
+
+		res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, Options{PatchFallback: patch})
+		if err != nil {
+			t.Fatalf("Pick error: %v", err)
+		}
+		if !res.AppliedViaPatch || res.WorkBranch != fr.pushBranch {
+			t.Fatalf("unexpected result: %+v (pushed %s)", res, fr.pushBranch)
+		}
+		if !fr.aborted || len(fr.applied) == 0 {
+			t.Fatalf("expected abort + apply; aborted=%v applied=%q", fr.aborted, fr.applied)
+		}
+		if fr.commitAuth != "Alice <alice@example.com>" {
+			t.Fatalf("author not preserved: %q", fr.commitAuth)
+		}
+		if !strings.Contains(fr.commitMsg, "(cherry picked from commit abcdef123456)") || !strings.Contains(fr.commitMsg, "Applied-via: git apply --3way") {
+			t.Fatalf("missing provenance in message: %q", fr.commitMsg)
+		}
+	})
+
+	t.Run("apply failure keeps conflict error", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("CONFLICT (content)"), errApply: errors.New("patch does not apply")}
+		defer withFakeRunner(t, fr)()
+
+		_, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, Options{PatchFallback: patch})
+		if err == nil || !strings.Contains(err.Error(), "conflict cherry-picking") {
+			t.Fatalf("want conflict error, got %v", err)
+		}
+		if !fr.reset || fr.pushBranch != "" {
+			t.Fatalf("expected reset and no push; reset=%v push=%q", fr.reset, fr.pushBranch)
+		}
+	})
+
+	t.Run("no fallback configured", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("CONFLICT (content)")}
+		defer withFakeRunner(t, fr)()
+
+		_, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, Options{})
+		if err == nil || fr.aborted {
+			t.Fatalf("want plain conflict error without abort; err=%v aborted=%v", err, fr.aborted)
+		}
+	})
+}
+
+func TestPick_Strategy(t *testing.T) {
+	actor := GitActor{Name: "bot", Email: "bot@noreply"}
+	fr := &fakeRunner{}
+	defer withFakeRunner(t, fr)()
+
+	opts := Options{Strategy: Strategy{Name: "ort", Option: "theirs", FindRenames: "40%"}}
+	if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 1, actor, opts); err != nil {
+		t.Fatalf("Pick error: %v", err)
+	}
+	if want := []string{"--strategy=ort", "--strategy-option=theirs", "--strategy-option=find-renames=40%"}; !slices.Equal(fr.pickArgs, want) || fr.pickedMainline != 1 {
+		t.Fatalf("pick args = %v (mainline %d), want %v", fr.pickArgs, fr.pickedMainline, want)
+	}
+	if got := (Strategy{FindRenames: "off"}).args(); !slices.Equal(got, []string{"--strategy-option=no-renames"}) {
+		t.Fatalf("find_renames off: args = %v", got)
+	}
+}
+
+func TestPick_EmptyCommits(t *testing.T) {
+	actor := GitActor{Name: "bot", Email: "bot@noreply"}
+	fr := &fakeRunner{}
+	defer withFakeRunner(t, fr)()
+
+	opts := Options{AllowEmpty: true, KeepRedundant: true, Signoff: true}
+	if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, opts); err != nil {
+		t.Fatalf("Pick error: %v", err)
+	}
+	if want := []string{"--allow-empty", "--keep-redundant-commits", "--signoff"}; !slices.Equal(fr.pickArgs, want) {
+		t.Fatalf("pick args = %v, want %v", fr.pickArgs, want)
+	}
+}
+
+func TestPick_Commits(t *testing.T) {
+	actor := GitActor{Name: "bot", Email: "bot@noreply"}
+	opts := Options{Commits: []string{"c1", "c2", "c3"}, CommitsRef: "refs/pull/7/head"}
+
+	t.Run("picks each commit in order", func(t *testing.T) {
+		fr := &fakeRunner{pickErrs: map[string]error{"c2": errors.New("nothing to commit, working tree clean")}}
+		defer withFakeRunner(t, fr)()
+
+		res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 1, actor, opts)
+		if err != nil {
+			t.Fatalf("Pick error: %v", err)
+		}
+		if !slices.Equal(fr.pickedSHAs, opts.Commits) || fr.pickedMainline != 0 || fr.pushBranch != res.WorkBranch {
+			t.Fatalf("picked %v (mainline %d), pushed %q", fr.pickedSHAs, fr.pickedMainline, fr.pushBranch)
+		}
+		if !slices.Contains(fr.fetched, "refs/pull/7/head") {
+			t.Fatalf("fetched %v", fr.fetched)
+		}
+	})
+
+	t.Run("stops at a conflict", func(t *testing.T) {
+		fr := &fakeRunner{pickErrs: map[string]error{"c2": errors.New("CONFLICT (content)")}}
+		defer withFakeRunner(t, fr)()
+
+		_, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, opts)
+		if err == nil || !strings.Contains(err.Error(), "c2 (commit 2 of 3)") || fr.pushBranch != "" || len(fr.pickedSHAs) != 2 {
+			t.Fatalf("err = %v, picked %v, pushed %q", err, fr.pickedSHAs, fr.pushBranch)
+		}
+	})
+
+	t.Run("all already applied", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("nothing to commit")}
+		defer withFakeRunner(t, fr)()
+
+		if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, opts); !errors.Is(err, ErrNoopCherryPick) {
+			t.Fatalf("err = %v, want ErrNoopCherryPick", err)
+		}
+	})
+}
+
+func TestDoCherryPickRange(t *testing.T) {
+	actor := GitActor{Name: "bot", Email: "bot@noreply"}
+
+	t.Run("picks the series", func(t *testing.T) {
+		fr := &fakeRunner{}
+		defer withFakeRunner(t, fr)()
+
+		wb, err := DoCherryPickRange(context.Background(), "o", "r", "tok", "release/1", "1111111aaa", "2222222bbb", actor)
+		if err != nil {
+			t.Fatalf("DoCherryPickRange error: %v", err)
+		}
+		if wb != "autocherry/release-1/2222222" || fr.pickedRange != "1111111aaa..2222222bbb" || fr.pickedSHA != "" || fr.pushBranch != wb {
+			t.Fatalf("work branch %q, range %q, picked %q, pushed %q", wb, fr.pickedRange, fr.pickedSHA, fr.pushBranch)
+		}
+		if !slices.Contains(fr.fetched, "1111111aaa") {
+			t.Fatalf("fetched %v", fr.fetched)
+		}
+	})
+
+	t.Run("conflict aborts", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("CONFLICT (content)")}
+		defer withFakeRunner(t, fr)()
+
+		_, err := DoCherryPickRange(context.Background(), "o", "r", "tok", "release/1", "1111111aaa", "2222222bbb", actor)
+		if err == nil || !strings.Contains(err.Error(), "1111111aaa..2222222bbb") || !fr.aborted || fr.pushBranch != "" {
+			t.Fatalf("err = %v, aborted %v, pushed %q", err, fr.aborted, fr.pushBranch)
+		}
+	})
+}
+
+func TestPick_MailboxFallback(t *testing.T) {
+	actor := GitActor{Name: "bot", Email: "bot@noreply"}
+	mbox := func(context.Context) ([]byte, error) { return []byte("From abc Mon Sep 17 00:00:00 2001\n"), nil }
+	diff := func(context.Context) ([]byte, error) { return []byte("diff --git a/x b/x\n"), nil }
+
+	t.Run("applies the mailbox on conflict", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("CONFLICT (content)")}
+		defer withFakeRunner(t, fr)()
+
+		res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, Options{MailboxFallback: mbox, PatchFallback: diff})
+		if err != nil {
+			t.Fatalf("Pick error: %v", err)
+		}
+		if !res.AppliedViaAm || res.AppliedViaPatch || fr.applied != nil || fr.pushBranch != res.WorkBranch {
+			t.Fatalf("res = %+v, applied %q, pushed %q", res, fr.applied, fr.pushBranch)
+		}
+	})
+
+	t.Run("falls through to the patch fallback", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("CONFLICT (content)"), errAm: errors.New("patch failed")}
+		defer withFakeRunner(t, fr)()
+
+		res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, Options{MailboxFallback: mbox, PatchFallback: diff})
+		if err != nil || res.AppliedViaAm || !res.AppliedViaPatch || !fr.amAborted {
+			t.Fatalf("res = %+v, err = %v, am aborted %v", res, err, fr.amAborted)
+		}
+	})
+
+	t.Run("stands in for an unreachable commit", func(t *testing.T) {
+		fr := &fakeRunner{missing: "abcdef123456"}
+		defer withFakeRunner(t, fr)()
+
+		res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, Options{MailboxFallback: mbox})
+		if err != nil || !res.AppliedViaAm || fr.picks != 0 || fr.pushBranch != res.WorkBranch {
+			t.Fatalf("res = %+v, err = %v, picks %d", res, err, fr.picks)
+		}
+	})
+
+	t.Run("unreachable without a mailbox", func(t *testing.T) {
+		fr := &fakeRunner{missing: "abcdef123456"}
+		defer withFakeRunner(t, fr)()
+
+		if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, Options{}); err == nil || !strings.Contains(err.Error(), "not our ref") {
+			t.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestPick_CreditsAuthors(t *testing.T) {
+	actor := GitActor{Name: "bot", Email: "bot@noreply"}
+	cases := []struct {
+		name     string
+		opts     Options
+		amends   int
+		author   string
+		trailers []string
+	}{
+		{
+			name:   "PR author and co-authors",
+			opts:   Options{Author: "Bob <bob@example.com>", CoAuthors: []string{"Carol <carol@example.com>", "Bob <bob@example.com>", "Carol <carol@example.com>"}},
+			amends: 1, author: "Bob <bob@example.com>", trailers: []string{"Co-authored-by: Carol <carol@example.com>"},
+		},
+		{name: "only the commit's own author", opts: Options{CoAuthors: []string{"alice <ALICE@example.com>"}}},
+		{name: "nothing to credit"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fr := &fakeRunner{}
+			defer withFakeRunner(t, fr)()
+
+			if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, c.opts); err != nil {
+				t.Fatalf("Pick error: %v", err)
+			}
+			if fr.amends != c.amends || fr.amendAuthor != c.author || !slices.Equal(fr.amendTrailers, c.trailers) {
+				t.Fatalf("amends %d: author %q, trailers %v", fr.amends, fr.amendAuthor, fr.amendTrailers)
+			}
+		})
+	}
+}
+
+func TestPick_Signoff(t *testing.T) {
+	actor := GitActor{Name: "bot", Email: "bot@noreply"}
+	sign := []string{"--signoff"}
+
+	t.Run("cherry-pick and hook commits", func(t *testing.T) {
+		fr := &fakeRunner{dirty: true}
+		defer withFakeRunner(t, fr)()
+
+		// A command of `true` keeps the hook itself out of it; only the commit matters.
+		opts := Options{Signoff: true, Hooks: Hooks{Commands: []string{"true"}}, Strategy: Strategy{Option: "theirs"}}
+		if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, opts); err != nil {
+			t.Fatalf("Pick error: %v", err)
+		}
+		if !slices.Equal(fr.pickArgs, []string{"--strategy-option=theirs", "--signoff"}) || !slices.Equal(fr.commitAllArgs, sign) {
+			t.Fatalf("pick args %v, hook commit args %v", fr.pickArgs, fr.commitAllArgs)
+		}
+	})
+
+	t.Run("fallback commits", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("CONFLICT (content)"), errAm: errors.New("patch failed")}
+		defer withFakeRunner(t, fr)()
+
+		opts := Options{
+			Signoff:         true,
+			MailboxFallback: func(context.Context) ([]byte, error) { return []byte("From x"), nil },
+			PatchFallback:   func(context.Context) ([]byte, error) { return []byte("diff"), nil },
+		}
+		if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, opts); err != nil {
+			t.Fatalf("Pick error: %v", err)
+		}
+		if !slices.Equal(fr.mailboxArgs, sign) || !slices.Equal(fr.commitArgs, sign) {
+			t.Fatalf("am args %v, commit args %v", fr.mailboxArgs, fr.commitArgs)
+		}
+	})
+}
+
+func TestPick_UsesRepoCaches(t *testing.T) {
+	fr := &fakeRunner{}
+	defer withFakeRunner(t, fr)()
+
+	cache, err := gitexec.NewMirrorCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	clones, err := gitexec.NewSharedClones(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, GitActor{}, Options{Mirror: cache, SharedClones: clones}); err != nil {
+		t.Fatalf("Pick error: %v", err)
+	}
+	if fr.mirror != cache || fr.clones != clones {
+		t.Fatalf("runner mirror = %v, shared clones = %v", fr.mirror, fr.clones)
+	}
+}
+
+func TestPick_SSH(t *testing.T) {
+	fr := &fakeRunner{}
+	defer withFakeRunner(t, fr)()
+
+	auth := gitexec.SSHAuth{Key: "/keys/42", KnownHosts: "/keys/known_hosts"}
+	if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, GitActor{}, Options{SSH: &auth}); err != nil {
+		t.Fatalf("Pick error: %v", err)
+	}
+	if fr.ssh == nil || *fr.ssh != auth {
+		t.Fatalf("runner ssh = %v; want %v", fr.ssh, auth)
+	}
+}
+
+func TestPick_LFS(t *testing.T) {
+	actor := GitActor{Name: "bot", Email: "bot@noreply"}
+	cases := []struct {
+		name     string
+		opt      bool
+		usesLFS  bool
+		errLFS   error
+		wantPull []string
+	}{
+		{name: "pulls changed files", opt: true, usesLFS: true, wantPull: []string{"assets/logo.psd"}},
+		{name: "repository without LFS", opt: true},
+		{name: "git-lfs missing", opt: true, usesLFS: true, errLFS: gitexec.ErrNoLFS},
+		{name: "disabled", usesLFS: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fr := &fakeRunner{usesLFS: c.usesLFS, errLFS: c.errLFS, changed: []string{"assets/logo.psd"}}
+			defer withFakeRunner(t, fr)()
+
+			if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, Options{LFS: c.opt}); err != nil {
+				t.Fatalf("Pick error: %v", err)
+			}
+			if !slices.Equal(fr.lfsPulled, c.wantPull) {
+				t.Fatalf("pulled %v, want %v", fr.lfsPulled, c.wantPull)
+			}
+		})
+	}
+}
+
+func TestPick_FullCloneRetry(t *testing.T) {
+	actor := GitActor{Name: "bot", Email: "bot@noreply"}
+
+	t.Run("full clone resolves the conflict", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("CONFLICT (modify/delete)"), fullFixes: true}
+		defer withFakeRunner(t, fr)()
+
+		res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, Options{FullCloneRetry: true})
+		if err != nil || fr.fullFetches != 1 || fr.picks != 2 || fr.pushBranch != res.WorkBranch {
+			t.Fatalf("err = %v, full fetches %d, picks %d, pushed %q", err, fr.fullFetches, fr.picks, fr.pushBranch)
+		}
+	})
+
+	t.Run("conflicts are committed only after the retry", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("CONFLICT (content)"), conflicted: []string{"a.go"}, dirty: true}
+		defer withFakeRunner(t, fr)()
+
+		res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, Options{FullCloneRetry: true, CommitConflicts: true})
+		if err != nil || fr.fullFetches != 1 || len(res.Conflicts) != 1 {
+			t.Fatalf("res = %+v, err = %v, full fetches %d", res, err, fr.fullFetches)
+		}
+	})
+
+	t.Run("other failures are not retried", func(t *testing.T) {
+		fr := &fakeRunner{errCO: true}
+		defer withFakeRunner(t, fr)()
+
+		if _, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, Options{FullCloneRetry: true}); err == nil || fr.fullFetches != 0 {
+			t.Fatalf("err = %v, full fetches %d", err, fr.fullFetches)
+		}
+	})
+}
+
+func TestPick_CommitConflicts(t *testing.T) {
+	actor := GitActor{Name: "bot", Email: "bot@noreply"}
+	opts := Options{CommitConflicts: true, Hooks: Hooks{Commands: []string{"make generate"}}}
+
+	t.Run("commits markers and pushes", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("CONFLICT (content)"), conflicted: []string{"a.go", "b.go"}, dirty: true}
+		defer withFakeRunner(t, fr)()
+
+		res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, opts)
+		if err != nil {
+			t.Fatalf("Pick error: %v", err)
+		}
+		if !slices.Equal(res.Conflicts, []string{"a.go", "b.go"}) || fr.pushBranch != res.WorkBranch || res.HookRuns != nil {
+			t.Fatalf("unexpected result: %+v (pushed %q)", res, fr.pushBranch)
+		}
+		if !strings.Contains(fr.commitAllMsg, "fix: thing\n\n(cherry picked from commit abcdef123456)\n\nConflicts:\n\ta.go\n\tb.go") {
+			t.Fatalf("commit message = %q", fr.commitAllMsg)
+		}
+		if fr.amendAuthor != "Alice <alice@example.com>" {
+			t.Fatalf("conflict commit author = %q, want the original author", fr.amendAuthor)
+		}
+	})
+
+	t.Run("redoes the pick after a failed patch fallback", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("CONFLICT (content)"), errApply: errors.New("patch does not apply"), conflicted: []string{"a.go"}, dirty: true}
+		defer withFakeRunner(t, fr)()
+
+		o := opts
+		o.PatchFallback = func(context.Context) ([]byte, error) { return []byte("diff"), nil }
+		res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, o)
+		if err != nil || len(res.Conflicts) != 1 || fr.picks != 2 {
+			t.Fatalf("res = %+v, err = %v, picks = %d", res, err, fr.picks)
+		}
+	})
+
+	t.Run("other failures stay errors", func(t *testing.T) {
+		fr := &fakeRunner{errPick: errors.New("fatal: bad object")}
+		defer withFakeRunner(t, fr)()
+
+		_, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, opts)
+		if err == nil || fr.pushBranch != "" || !fr.aborted {
+			t.Fatalf("err = %v, pushed %q, aborted %v", err, fr.pushBranch, fr.aborted)
+		}
+	})
+}
+
+func TestPick_ConflictReport(t *testing.T) {
+	actor := GitActor{Name: "bot", Email: "bot@noreply"}
+	fr := &fakeRunner{errPick: errors.New("CONFLICT (content)"), conflicted: []string{"a.go"}, status: "UU a.go", diffstat: " a.go | 4 ++--"}
+	defer withFakeRunner(t, fr)()
+
+	o := Options{PatchFallback: func(context.Context) ([]byte, error) { return nil, errors.New("no patch") }}
+	_, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, actor, o)
+	rep, ok := Conflict(err)
+	if !ok || !slices.Equal(rep.Files, []string{"a.go"}) || rep.Status != "UU a.go" || rep.Diffstat != " a.go | 4 ++--" {
+		t.Fatalf("Conflict(%v) = %+v, %v", err, rep, ok)
+	}
+	if _, ok := Conflict(errors.New("clone failed")); ok {
+		t.Fatal("Conflict reported a non-conflict error")
+	}
+}
+
 // small helper
 func containsAll(slice []string, want ...string) bool {
 	for _, w := range want {
@@ -179,3 +771,115 @@ func containsAll(slice []string, want ...string) bool {
 	}
 	return true
 }
+
+func TestPick_ForkFallback(t *testing.T) {
+	fork := &Fork{Owner: "cherry-bot", Repo: "r", Token: "pat"}
+	cases := []struct {
+		name      string
+		pushErr   error
+		fork      *Fork
+		wantOwner string
+		wantErr   bool
+	}{
+		{name: "forbidden pushes to the fork", pushErr: fmt.Errorf("%w: exit status 1", gitexec.ErrPushForbidden), fork: fork, wantOwner: "cherry-bot"},
+		{name: "other push errors do not", pushErr: errors.New("push failed"), fork: fork, wantErr: true},
+		{name: "no fork configured", pushErr: gitexec.ErrPushForbidden, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fr := &fakeRunner{errPush: c.pushErr}
+			restore := withFakeRunner(t, fr)
+			defer restore()
+
+			res, err := Pick(context.Background(), "o", "r", "tok", "devops-release/0021", "abcdef123456", 0, GitActor{}, Options{Fork: c.fork})
+			if (err != nil) != c.wantErr || res.HeadOwner != c.wantOwner {
+				t.Fatalf("res = %+v, err = %v", res, err)
+			}
+			if c.wantOwner != "" && fr.forkPush != "cherry-bot/r:autocherry/devops-release-0021/abcdef1" {
+				t.Fatalf("fork push = %q", fr.forkPush)
+			}
+		})
+	}
+}
+
+func TestPick_PushRetry(t *testing.T) {
+	rejected := fmt.Errorf("%w: exit status 1", gitexec.ErrPushRejected)
+	cases := []struct {
+		name      string
+		policy    string
+		errRebase error
+		wantErr   bool
+		check     func(t *testing.T, fr *fakeRunner)
+	}{
+		{name: "no policy fails", wantErr: true},
+		{name: "rebase", policy: PushRetryRebase, check: func(t *testing.T, fr *fakeRunner) {
+			if fr.rebasedOnto != "origin/autocherry/release-1/abcdef1" || fr.pushes != 2 {
+				t.Fatalf("rebased onto %q, pushes = %d", fr.rebasedOnto, fr.pushes)
+			}
+		}},
+		{name: "rebase conflict fails", policy: PushRetryRebase, errRebase: errors.New("conflict"), wantErr: true},
+		{name: "force", policy: PushRetryForce, check: func(t *testing.T, fr *fakeRunner) {
+			if fr.forcedLease != "autocherry/release-1/abcdef1:remote-tip" || fr.pushes != 1 {
+				t.Fatalf("lease = %q, pushes = %d", fr.forcedLease, fr.pushes)
+			}
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fr := &fakeRunner{errPush: rejected, errRebase: c.errRebase}
+			defer withFakeRunner(t, fr)()
+
+			_, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, GitActor{}, Options{PushRetry: c.policy})
+			if (err != nil) != c.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			if c.check != nil {
+				c.check(t, fr)
+			}
+		})
+	}
+}
+
+func TestPick_AppendsToWorkBranch(t *testing.T) {
+	const train = "autocherry/release-1/train-202610141200"
+	for _, appendTo := range []bool{false, true} {
+		fr := &fakeRunner{}
+		restore := withFakeRunner(t, fr)
+
+		res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, GitActor{}, Options{WorkBranch: train, Append: appendTo})
+		restore()
+		if err != nil || res.WorkBranch != train || fr.coNew != train || fr.pushBranch != train {
+			t.Fatalf("append=%v: res = %+v, err = %v, checkout %q, push %q", appendTo, res, err, fr.coNew, fr.pushBranch)
+		}
+		wantFrom, fetched := "origin/release/1", slices.Contains(fr.fetched, "refs/heads/"+train+":refs/remotes/origin/"+train)
+		if appendTo {
+			wantFrom = "origin/" + train
+		}
+		if fr.coFrom != wantFrom || fetched != appendTo {
+			t.Fatalf("append=%v: checked out from %q, fetched %v", appendTo, fr.coFrom, fr.fetched)
+		}
+	}
+}
+
+func TestPick_ReportsFetchedBytes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".git", "objects", "pack"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".git", "objects", "pack", "p.pack"), make([]byte, 1000), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fr := &fakeRunner{dir: dir}
+	defer withFakeRunner(t, fr)()
+
+	var got int64
+	_, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, GitActor{}, Options{
+		Fetched: func(n int64) { got = n },
+	})
+	if err != nil {
+		t.Fatalf("Pick error: %v", err)
+	}
+	if got != 1000 {
+		t.Fatalf("fetched bytes = %d, want 1000", got)
+	}
+}
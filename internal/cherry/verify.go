@@ -0,0 +1,109 @@
+package cherry
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/gitexec"
+)
+
+// Verify is a repository-configured quick check (e.g. `go build {packages}`)
+// run in the work tree after the pick and hooks, before push. A clean textual
+// pick can still break the build on a branch that has diverged; this surfaces
+// that on the PR instead of after merge.
+//
+// In Command, {packages} expands to the changed Go package directories
+// ("./a/b"), and {files} to the changed files still present in the tree,
+// each quoted as one shell word.
+type Verify struct {
+	Command string
+	Timeout time.Duration     // default 2m
+	Env     map[string]string // extra environment, as for Hooks
+}
+
+// VerifyRun is the outcome of the verification command.
+type VerifyRun struct {
+	HookRun
+	Passed  bool
+	Skipped bool // the command referenced {packages}/{files} but nothing relevant changed
+}
+
+const defaultVerifyTimeout = 2 * time.Minute
+
+// runVerify runs v against the changes since base. It never fails the pick;
+// the result is only reported.
+func runVerify(ctx context.Context, r gitRunner, v Verify, base string) *VerifyRun {
+	dir := r.Dir()
+	files, err := r.ChangedFiles(ctx, base)
+	if err != nil {
+		slog.Warn("cherry.verify_diff_error", "err", err)
+		return &VerifyRun{HookRun: HookRun{Command: v.Command, Output: err.Error()}}
+	}
+	present := make([]string, 0, len(files))
+	for _, f := range files {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(f))); err == nil {
+			present = append(present, f)
+		}
+	}
+	pkgs := goPackages(present)
+
+	command := v.Command
+	if (strings.Contains(command, "{packages}") && len(pkgs) == 0) ||
+		(strings.Contains(command, "{files}") && len(present) == 0) {
+		slog.Info("cherry.verify_skipped", "command", command)
+		return &VerifyRun{HookRun: HookRun{Command: command}, Skipped: true}
+	}
+	command = strings.ReplaceAll(command, "{packages}", quoteAll(pkgs))
+	command = strings.ReplaceAll(command, "{files}", quoteAll(present))
+
+	timeout := v.Timeout
+	if timeout <= 0 {
+		timeout = defaultVerifyTimeout
+	}
+	if err := r.StripRemoteToken(ctx); err != nil {
+		return &VerifyRun{HookRun: HookRun{Command: command, Output: err.Error()}}
+	}
+	defer restoreRemote(ctx, r)
+	env := hookEnv(dir, v.Env)
+	makeHookDirs(dir)
+
+	run, err := runBounded(ctx, dir, command, env, timeout)
+	if err != nil {
+		slog.Warn("cherry.verify_failed", "command", command, "err", err, "duration", run.Duration)
+		return &VerifyRun{HookRun: run}
+	}
+	slog.Info("cherry.verify_ok", "command", command, "duration", run.Duration)
+	return &VerifyRun{HookRun: run, Passed: true}
+}
+
+// goPackages returns the sorted directories ("./dir") holding changed .go files.
+func goPackages(files []string) []string {
+	seen := map[string]bool{}
+	for _, f := range files {
+		if strings.HasSuffix(f, ".go") {
+			seen["./"+path.Dir(f)] = true
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for d := range seen {
+		out = append(out, strings.TrimSuffix(d, "/."))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// quoteAll quotes each path for sh, so a name with spaces or shell syntax in
+// it stays one argument.
+func quoteAll(paths []string) string {
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = gitexec.ShellQuote(p)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,117 @@
+package cherry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoPackages(t *testing.T) {
+	got := goPackages([]string{"main.go", "internal/a/a.go", "internal/a/b.go", "docs/README.md", "cmd/x/main.go"})
+	if strings.Join(got, " ") != ". ./cmd/x ./internal/a" {
+		t.Fatalf("goPackages = %v", got)
+	}
+}
+
+func TestPick_VerifyRunsBeforePushAndReports(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"internal/a/a.go", "README.md"} {
+		p := filepath.Join(dir, f)
+		_ = os.MkdirAll(filepath.Dir(p), 0o755)
+		_ = os.WriteFile(p, nil, 0o600)
+	}
+	cases := []struct {
+		name        string
+		command     string
+		changed     []string
+		hookErr     error
+		wantCommand string
+		wantPassed  bool
+		wantSkipped bool
+	}{
+		{name: "passes", command: "go build {packages}", changed: []string{"internal/a/a.go", "gone/x.go"}, wantCommand: "go build './internal/a'", wantPassed: true},
+		{name: "fails but still pushes", command: "make check", changed: []string{"README.md"}, hookErr: errors.New("exit status 2"), wantCommand: "make check"},
+		{name: "no go changes", command: "go build {packages}", changed: []string{"README.md"}, wantCommand: "go build {packages}", wantSkipped: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fr := &fakeRunner{dir: dir, changed: tc.changed}
+			defer withFakeRunner(t, fr)()
+			var ran string
+			withFakeHook(t, func(ctx context.Context, d, command string, env []string) (string, error) {
+				if fr.pushBranch != "" {
+					t.Fatalf("verify ran after push")
+				}
+				ran = command
+				return "out\n", tc.hookErr
+			})
+
+			res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, GitActor{Name: "bot"},
+				Options{Verify: Verify{Command: tc.command}})
+			if err != nil {
+				t.Fatalf("Pick error: %v", err)
+			}
+			if fr.pushBranch == "" {
+				t.Fatalf("expected push regardless of verification")
+			}
+			v := res.Verify
+			if v == nil || v.Command != tc.wantCommand || v.Passed != tc.wantPassed || v.Skipped != tc.wantSkipped {
+				t.Fatalf("Verify = %+v", v)
+			}
+			if !tc.wantSkipped && (ran != tc.wantCommand || !fr.remoteStripped || !fr.remoteRestored) {
+				t.Fatalf("ran %q, stripped=%v restored=%v", ran, fr.remoteStripped, fr.remoteRestored)
+			}
+		})
+	}
+}
+
+func TestPick_PrePushFailureBlocksPush(t *testing.T) {
+	for _, fail := range []bool{false, true} {
+		fr := &fakeRunner{dir: t.TempDir(), changed: []string{"README.md"}}
+		restore := withFakeRunner(t, fr)
+		withFakeHook(t, func(ctx context.Context, d, command string, env []string) (string, error) {
+			if fail {
+				return "broken\n", errors.New("exit status 1")
+			}
+			return "", nil
+		})
+
+		res, err := Pick(context.Background(), "o", "r", "tok", "release/1", "abcdef123456", 0, GitActor{Name: "bot"},
+			Options{PrePush: Verify{Command: "make verify"}})
+		restore()
+		if fail {
+			if !errors.Is(err, ErrPrePushFailed) || fr.pushBranch != "" {
+				t.Fatalf("err = %v, pushed %q; want a failed pick and no push", err, fr.pushBranch)
+			}
+			if res.PrePush == nil || res.PrePush.Output != "broken\n" {
+				t.Fatalf("PrePush = %+v", res.PrePush)
+			}
+			continue
+		}
+		if err != nil || fr.pushBranch == "" || !res.PrePush.Passed {
+			t.Fatalf("err = %v, pushed %q, PrePush = %+v", err, fr.pushBranch, res.PrePush)
+		}
+	}
+}
+
+func TestRunVerify_QuotesPaths(t *testing.T) {
+	dir := t.TempDir()
+	odd := "odd dir/a b;touch pwned.go"
+	_ = os.MkdirAll(filepath.Join(dir, "odd dir"), 0o755)
+	_ = os.WriteFile(filepath.Join(dir, odd), nil, 0o600)
+	fr := &fakeRunner{dir: dir, changed: []string{odd}}
+
+	v := runVerify(context.Background(), fr, Verify{Command: "printf '%s\\n' {files} {packages}"}, "base")
+	if !v.Passed {
+		t.Fatalf("Verify = %+v", v)
+	}
+	if want := odd + "\n./odd dir\n"; v.Output != want {
+		t.Fatalf("output = %q; want %q", v.Output, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pwned.go")); err == nil {
+		t.Fatal("a changed file name ran as a command")
+	}
+}
@@ -0,0 +1,97 @@
+// Package codeowners matches paths against a repository's CODEOWNERS file,
+// the way GitHub does when it requests reviews on a pull request.
+package codeowners
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Paths are where GitHub looks for CODEOWNERS, in order; the first found
+// is used.
+var Paths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// File is a parsed CODEOWNERS file.
+type File struct {
+	rules []rule
+}
+
+type rule struct {
+	re     *regexp.Regexp
+	owners []string
+}
+
+// Parse reads a CODEOWNERS file. Lines GitHub would reject (e.g. patterns
+// with "!" or "[...]") are skipped, as GitHub skips them too.
+func Parse(data []byte) *File {
+	f := &File{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.ContainsAny(fields[0], "![]\\") {
+			continue
+		}
+		re, err := regexp.Compile(patternRegexp(fields[0]))
+		if err != nil {
+			continue
+		}
+		f.rules = append(f.rules, rule{re: re, owners: fields[1:]})
+	}
+	return f
+}
+
+// Owners returns the owners ("@user", "@org/team" or an email) of path,
+// relative to the repository root: those of the last matching rule, which
+// may have none.
+func (f *File) Owners(path string) []string {
+	for i := len(f.rules) - 1; i >= 0; i-- {
+		if f.rules[i].re.MatchString(path) {
+			return f.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// patternRegexp translates a gitignore-style CODEOWNERS pattern: "*" and "?"
+// stay within a path segment, "**" spans segments, a leading or inner "/"
+// anchors the pattern at the root, and a pattern also matches everything
+// beneath a directory it matches, except for a trailing "/*" (direct
+// children only).
+func patternRegexp(p string) string {
+	anchored := strings.Contains(strings.TrimSuffix(p, "/"), "/")
+	p = strings.TrimPrefix(p, "/")
+	dir := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+		case p[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	switch {
+	case dir:
+		b.WriteString("/.*")
+	case !strings.HasSuffix(p, "/*"):
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+	return b.String()
+}
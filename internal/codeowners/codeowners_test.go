@@ -0,0 +1,51 @@
+package codeowners
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestOwners(t *testing.T) {
+	f := Parse([]byte(`# Default owners
+*                @org/everyone
+*.js             @js-owner   # inline comment
+**/logs          @logs
+/build/logs/     @doctocat
+docs/*           docs@example.com
+apps/            @octocat
+/scripts/        @org/scripts
+/vendor/
+!ignored         @nobody
+`))
+	cases := map[string][]string{
+		"README.md":             {"@org/everyone"},
+		"src/app.js":            {"@js-owner"},
+		"build/logs/out.txt":    {"@doctocat"},
+		"build/logs/a/b.txt":    {"@doctocat"},
+		"docs/getting.md":       {"docs@example.com"},
+		"docs/build/intro.md":   {"@org/everyone"},
+		"apps/web/main.go":      {"@octocat"},
+		"x/apps/web/main.go":    {"@octocat"},
+		"scripts/run.sh":        {"@org/scripts"},
+		"x/scripts/run.sh":      {"@org/everyone"},
+		"deep/down/logs/x.log":  {"@logs"},
+		"vendor/lib/lib.go":     nil,
+		"ignored":               {"@org/everyone"},
+		"src/build/logs/x.txt":  {"@logs"},
+		"src/app.jsx":           {"@org/everyone"},
+		"scripts.sh":            {"@org/everyone"},
+		"apps":                  {"@org/everyone"},
+		"a/deeper/path/file.js": {"@js-owner"},
+	}
+	for path, want := range cases {
+		if got := f.Owners(path); !slices.Equal(got, want) {
+			t.Errorf("Owners(%q) = %v; want %v", path, got, want)
+		}
+	}
+}
+
+func TestOwners_Empty(t *testing.T) {
+	if got := Parse(nil).Owners("main.go"); got != nil {
+		t.Fatalf("Owners = %v; want none", got)
+	}
+}
@@ -5,34 +5,249 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
 )
 
 type Config struct {
+	// Profile is the APP_PROFILE applied from CONFIG_FILE ("" = none).
+	Profile string
+
 	AppID         int64
 	WebhookSecret []byte
 	PrivateKeyPEM []byte // decoded PEM
 	ListenPort    string // ":8080"
 
+	// ShutdownDrainSeconds bounds how long SIGTERM waits for in-flight work.
+	ShutdownDrainSeconds int
+
 	// Optional Git actor
 	GitUserName  string // "stabilization-bot"
 	GitUserEmail string // "stabilization-bot@users.noreply.github.com"
 
+	// Mode selects what the process runs: "webhook" (HTTP receiver only),
+	// "sqs" (queue worker, backend chosen by IngestMode) or "both".
+	Mode        string
+	WebhookPath string // where the webhook receiver is mounted
+
+	// Ingest backend, see internal/ingest (sqs, nats, amqp, servicebus, redis)
+	IngestMode          string
+	IngestDropOn4xx     bool // non-SQS backends: drop 4xx outcomes instead of redelivering
+	IngestMaxDeliveries int  // non-SQS backends: dead-letter after this many deliveries (0 = broker default)
+	// EventFilter drops deliveries outside an event/action allowlist before
+	// verification and decoding (nil = process everything).
+	EventFilter queue.Filter
+
+	// NATS JetStream
+	NATSURL     string
+	NATSStream  string
+	NATSDurable string
+	NATSSubject string
+
+	// RabbitMQ / AMQP 0-9-1
+	AMQPURL   string
+	AMQPQueue string
+
+	// Azure Service Bus
+	ServiceBusConnectionString string
+	ServiceBusQueue            string
+
+	// Redis Streams
+	RedisStreamURL   string
+	RedisStream      string
+	RedisStreamGroup string
+
 	// AWS/SQS
 	AWSRegion             string
 	SQSQueueURL           string
+	SQSQueueURLs          []string // every queue to poll: SQS_QUEUE_URL, then SQS_QUEUE_URLS
 	SQSMaxMessages        int32
 	SQSWaitTimeSeconds    int32
 	SQSVisibilityTimeout  int32
 	SQSDeleteOn4xx        bool
 	SQSExtendOnProcessing bool
+	SQSConcurrency        int    // messages processed to completion in parallel (0 = ack once accepted, work unbounded)
+	SQSWarnReceives       int    // warn when a kept message was received this often (0 = never)
+	SQSMaxReceives        int    // give up (notify + delete) at this receive count (0 = queue redrive only)
+	SQSDLQURL             string // dead-letter queue replayed by `server redrive`
+	SQSQuarantineBucket   string // S3 bucket for messages deleted unprocessed ("" = off)
+	SQSQuarantinePrefix   string // key prefix within SQSQuarantineBucket
+
+	// Receive-loop error handling: backoff ceiling, consecutive errors that
+	// pause polling (0 = never), and the wait between probes while paused.
+	SQSReceiveBackoffMaxSeconds int
+	SQSBreakerThreshold         int
+	SQSBreakerPauseSeconds      int
+	SQSDedupeTTLSeconds         int // ack recently handled delivery IDs without reprocessing (0 = off)
+	SQSDedupeSize               int
+	SQSAgeWarnSeconds           int // warn when received messages are older than this (0 = never)
+
+	// Backlog gauges (GetQueueAttributes sampling); 0 seconds disables.
+	SQSBacklogPollSeconds   int
+	SQSBacklogWarnThreshold int
 
 	// Processing
-	CherryTimeoutSeconds int // max time to process one merged PR (incl. git ops)
+	CherryTimeoutSeconds int    // max time to process one merged PR (incl. git ops)
+	PatchFallback        bool   // on conflict, retry with `git apply --3way` of the API diff
+	AmFallback           bool   // on conflict or unreachable commit, retry with `git am -3` of the PR's .patch
+	CreditAuthors        bool   // author picks as the PR author, with Co-authored-by trailers for co-authors
+	Signoff              bool   // add the bot's Signed-off-by trailer to picked commits (DCO)
+	PickEmpty            bool   // pick empty commits (--allow-empty) instead of reporting a no-op
+	PickRedundant        bool   // keep picks that end up empty on the target (--keep-redundant-commits)
+	CommitFooter         string // replaces the -x line of picked commits ({x}, {sha}, {short_sha}, {pr}, {author}, {target}; \n = newline)
+	PushRetry            string // on a non-fast-forward work branch push: "rebase", "force" or "" (fail)
+	SSHKeyDir            string // deploy keys (<installation id> or "default") to clone and push over SSH with ("" = HTTPS)
+	SSHKnownHosts        string // known_hosts file with GitHub's SSH host keys ("" = ssh's defaults)
+	APIPicks             bool   // pick via the Git Data API instead of git (no git binary or disk needed)
+	FullCloneFallback    bool   // retry conflicting picks with a complete, non-partial clone
+	GitMirrorDir         string // persistent volume for bare mirrors that picks fetch from ("" = off)
+	GitWorktreeDir       string // persistent volume for shared clones that picks use worktrees of ("" = off)
+	ParallelTargets      int    // targets of one PR picked at once
+	LFS                  bool   // set up git-lfs for repositories that use Git LFS
+	WorkspaceDir         string // parent of picks' temp work trees ("" = gh-app-cherry-pick under the OS temp dir)
+	WorkspaceQuotaMB     int    // refuse new work trees above this much disk use (0 = unlimited)
+	WorkspaceSweepSecs   int    // remove leftover work trees of crashed runs this often (0 = never)
+	ConflictPRs          bool   // on conflict, open a draft PR with the conflict markers committed
+	PickStrategies       bool   // apply per-repo pick_strategy (--strategy / -X) from .github/cherry-pick.yml
+	PickPRCommits        bool   // pick merged PRs' own commits one by one instead of the merge commit
+	PostPickHooks        bool   // run per-repo post_pick commands from .github/cherry-pick.yml
+	PickVerify           bool   // run the per-repo verify command before push and report it
+	PrePushChecks        bool   // run the per-repo pre_push command before push; failing it fails the pick
+	MergeBackDetector    bool   // open "forward-port needed" issues for direct pushes to release branches
+	TrailerBackports     bool   // pick commits pushed to the default branch with Cherry-pick-to: trailers
+	DryRun               bool   // log GitHub writes and skip pushes instead of performing them
+	SearchDedupe         bool   // skip targets already backported by hand (Search API)
+	LabelRecheck         bool   // re-read PR labels before each target; skip removed ones
+	LabelRecheckAdd      bool   // with LabelRecheck: also pick targets labeled meanwhile
+
+	AutoMergeApproved bool   // merge approved autocherry PRs once their checks pass
+	AutoMergeMethod   string // merge, squash or rebase
+	EnableAutoMerge   bool   // turn on GitHub auto-merge for autocherry PRs once checks start
+
+	// New repositories (repository created event) get these labels and,
+	// when set, this starter .github/cherry-pick.yml.
+	RepoBootstrapLabels []string
+	RepoBootstrapConfig []byte
+
+	// Backport PRs get BackportLabels, and the source PR's labels matching
+	// a CopyLabels glob (e.g. "bug", "area/*").
+	BackportLabels []string
+	CopyLabels     []string
+
+	// Backport PRs are assigned to (AssignAuthor) and reviewed by
+	// (ReviewAuthor) the source's author, and ReviewTeams are requested.
+	AssignAuthor bool
+	ReviewAuthor bool
+	ReviewTeams  []string
+	// CodeOwnerReviews requests reviews from the target branch's CODEOWNERS.
+	CodeOwnerReviews bool
+	// CopyMilestone sets the source's (or the repo config's) milestone.
+	CopyMilestone bool
+	// DraftPRs opens all backport PRs as drafts; RepoDrafts honors the repo
+	// config's drafts list.
+	DraftPRs   bool
+	RepoDrafts bool
+	// Backport PR title and body (intro) templates (text/template); the repo
+	// config's pr section overrides them when RepoPRTemplates is set.
+	PRTitleTemplate string
+	PRBodyTemplate  string
+	RepoPRTemplates bool
+	// LinkBackports lists sibling backport PRs in each one's description.
+	LinkBackports bool
+
+	// MilestoneTargetTemplate maps PR milestones onto release branches,
+	// e.g. "devops-release/{milestone}"; empty disables milestone targeting.
+	MilestoneTargetTemplate string
+
+	// BackportDirective is the keyword of "<keyword>: <branches>" lines in PR
+	// descriptions that request backports; empty when BACKPORT_DIRECTIVES=false.
+	BackportDirective string
+
+	MergeCommitTrailers bool // Cherry-pick-to: trailers in merge commit messages add targets
+	CommitStatuses      bool // autocherry/<target> statuses on picked commits
+	CheckRuns           bool // a "cherry-pick" check run with a row per target on picked commits
+	StatusComment       bool // one status comment per source PR, edited in place
+	DeleteWorkBranches  bool // delete work branches of merged or closed backport PRs
+	CloseSuperseded     bool // close older open backports of the same PR and target
+	TrainWindowMinutes  int  // 0 = off; picks onto a target within a window share one PR
+	RevertBackports     bool // merged reverts follow the reverted PR's backports
+
+	// ProjectID ("" = off) is the Projects (v2) board backport PRs are added
+	// to, in its ProjectStatus Status column ("" = the board's default).
+	ProjectID     string
+	ProjectStatus string
+
+	// ReleaseTagPattern (nil = off) selects tags that cut a release branch,
+	// named by ReleaseTagBranch with the pattern's groups expanded.
+	ReleaseTagPattern *regexp.Regexp
+	ReleaseTagBranch  string
+
+	// ForkPushToken pushes work branches to the forks repositories
+	// configure for namespaces they protect (a bot account's token).
+	ForkPushToken string
+
+	// Optional subsystems are disabled for a cooldown after too many errors.
+	DegradeAfterErrors     int // errors within 5 minutes; 0 = never disable
+	DegradeCooldownSeconds int
+
+	// Support bundles (GET /debug/support-bundle); empty token disables the endpoint.
+	SupportBundleToken string
+	SupportLogBuffer   int // log records kept in memory for bundles
+
+	// Usage reports per installation (GET /admin/usage, monthly Slack
+	// summary); metering is on when any of these is set.
+	UsageReportToken     string
+	UsageFile            string // JSON snapshot that survives restarts
+	UsageSlackWebhookURL string
+
+	// BadgeRepos lists owner/name repositories served by /badge/… ("*" = all,
+	// empty disables the endpoint).
+	BadgeRepos []string
+
+	// StateBackend selects the state store driver (memory, file, sqlite,
+	// dynamodb); empty disables it. Defaults to file when STATE_FILE is set.
+	StateBackend       string
+	StateFile          string // file: JSON file holding all records
+	StateSQLitePath    string // sqlite: database file
+	StateDynamoDBTable string // dynamodb: table keyed by repo (hash) and work_branch (range)
+
+	// Horizontal scaling: leases shared by replicas in Redis (empty URL = none)
+	ShardRedisURL     string
+	ReplicaID         string
+	PRLockDistributed bool // serialize per-PR events across replicas via SHARD_REDIS_URL
+}
+
+// Values of MODE.
+const (
+	ModeWebhook = "webhook"
+	ModeQueue   = "sqs"
+	ModeBoth    = "both"
+)
+
+// RunsQueue reports whether the queue ingest worker should run.
+func (c *Config) RunsQueue() bool { return c.Mode != ModeWebhook }
+
+// RunsWebhook reports whether the HTTP webhook receiver should be mounted.
+func (c *Config) RunsWebhook() bool { return c.Mode == ModeWebhook || c.Mode == ModeBoth }
+
+// SQSSynchronous reports whether SQS deliveries are processed to completion
+// before their message is deleted: with visibility heartbeats, and whenever
+// SQS_CONCURRENCY is set, as it only bounds picks that hold their slot.
+func (c *Config) SQSSynchronous() bool {
+	return c.IngestMode == "sqs" && (c.SQSExtendOnProcessing || c.SQSConcurrency > 0)
 }
 
 func Load() (*Config, error) {
+	profile, err := applyProfile()
+	if err != nil {
+		return nil, err
+	}
+
 	appIDStr := os.Getenv("GITHUB_APP_ID")
 	secret := os.Getenv("GITHUB_WEBHOOK_SECRET")
 	pemB64 := os.Getenv("GITHUB_APP_PRIVATE_KEY_PEM_BASE64")
@@ -42,7 +257,7 @@ func Load() (*Config, error) {
 		return nil, errors.New("GITHUB_APP_ID, GITHUB_WEBHOOK_SECRET, GITHUB_APP_PRIVATE_KEY_PEM_BASE64 are required")
 	}
 	var appID int64
-	_, err := fmt.Sscan(appIDStr, &appID)
+	_, err = fmt.Sscan(appIDStr, &appID)
 	if err != nil {
 		return nil, err
 	}
@@ -52,34 +267,249 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	mode := strings.ToLower(envOr("MODE", ModeQueue))
+	switch mode {
+	case ModeWebhook, ModeQueue, ModeBoth:
+	default:
+		return nil, fmt.Errorf("MODE must be %s, %s or %s; got %q", ModeWebhook, ModeQueue, ModeBoth, mode)
+	}
+	autoMergeMethod := strings.ToLower(envOr("AUTO_MERGE_METHOD", "squash"))
+	switch autoMergeMethod {
+	case "merge", "squash", "rebase":
+	default:
+		return nil, fmt.Errorf("AUTO_MERGE_METHOD must be merge, squash or rebase; got %q", autoMergeMethod)
+	}
+	pushRetry := strings.ToLower(os.Getenv("PUSH_RETRY"))
+	switch pushRetry {
+	case "", "rebase", "force":
+	default:
+		return nil, fmt.Errorf("PUSH_RETRY must be rebase or force; got %q", pushRetry)
+	}
+	prTitle := os.Getenv("PR_TITLE_TEMPLATE")
+	prBody := strings.ReplaceAll(os.Getenv("PR_BODY_TEMPLATE"), `\n`, "\n")
+	for name, text := range map[string]string{"PR_TITLE_TEMPLATE": prTitle, "PR_BODY_TEMPLATE": prBody} {
+		if _, terr := repocfg.ParseTemplate(name, text); terr != nil {
+			return nil, fmt.Errorf("%s: %w", name, terr)
+		}
+	}
+	var bootstrapConfig []byte
+	if b64 := os.Getenv("REPO_BOOTSTRAP_CONFIG_BASE64"); b64 != "" {
+		if bootstrapConfig, err = base64.StdEncoding.DecodeString(b64); err != nil {
+			return nil, fmt.Errorf("REPO_BOOTSTRAP_CONFIG_BASE64: %w", err)
+		}
+		if _, err := repocfg.Parse(bootstrapConfig); err != nil {
+			return nil, fmt.Errorf("REPO_BOOTSTRAP_CONFIG_BASE64: %w", err)
+		}
+	}
+	backportDirective := ""
+	if envOrBool("BACKPORT_DIRECTIVES", true) {
+		backportDirective = envOr("BACKPORT_DIRECTIVE_KEYWORD", "Backport-to")
+	}
+	milestoneTemplate := os.Getenv("MILESTONE_TARGET_TEMPLATE")
+	if milestoneTemplate != "" && !strings.Contains(milestoneTemplate, "{milestone}") {
+		return nil, fmt.Errorf("MILESTONE_TARGET_TEMPLATE must contain {milestone}; got %q", milestoneTemplate)
+	}
+	var releaseTagPattern *regexp.Regexp
+	releaseTagBranch := os.Getenv("RELEASE_TAG_BRANCH")
+	if v := os.Getenv("RELEASE_TAG_PATTERN"); v != "" {
+		if releaseTagPattern, err = regexp.Compile(v); err != nil {
+			return nil, fmt.Errorf("RELEASE_TAG_PATTERN: %w", err)
+		}
+		if releaseTagBranch == "" {
+			return nil, errors.New("RELEASE_TAG_BRANCH is required with RELEASE_TAG_PATTERN")
+		}
+	}
+	ingestMode := strings.ToLower(envOr("INGEST_MODE", "sqs"))
+	eventFilter, err := queue.ParseFilter(os.Getenv("EVENT_FILTER"))
+	if err != nil {
+		return nil, fmt.Errorf("EVENT_FILTER: %w", err)
+	}
+
+	backportLabels := []string{"autocherry"}
+	if _, ok := os.LookupEnv("BACKPORT_LABELS"); ok {
+		backportLabels = envList("BACKPORT_LABELS")
+	}
+
 	// AWS/SQS defaults suitable for PoC
 	awsRegion := envOr("AWS_REGION", "eu-north-1")
+	// Under Lambda the event source mapping delivers messages, so no queue URL.
 	queueURL := os.Getenv("SQS_QUEUE_URL")
-	if queueURL == "" {
-		return nil, errors.New("SQS_QUEUE_URL is required")
+	var queueURLs []string
+	for _, u := range append([]string{queueURL}, envList("SQS_QUEUE_URLS")...) {
+		if u != "" && !slices.Contains(queueURLs, u) {
+			queueURLs = append(queueURLs, u)
+		}
+	}
+	onLambda := os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != ""
+	if mode != ModeWebhook && ingestMode == "sqs" && len(queueURLs) == 0 && !onLambda {
+		return nil, errors.New("SQS_QUEUE_URL is required (or SQS_QUEUE_URLS for several queues)")
+	}
+
+	stateBackend := strings.ToLower(os.Getenv("STATE_BACKEND"))
+	if stateBackend == "" && os.Getenv("STATE_FILE") != "" {
+		stateBackend = "file"
 	}
 
 	return &Config{
+		Profile:       profile,
 		AppID:         appID,
 		WebhookSecret: []byte(secret),
 		PrivateKeyPEM: pem,
 		ListenPort:    listenPort,
-		GitUserName:   envOr("GIT_USER_NAME", "stabilization-bot"),
-		GitUserEmail:  envOr("GIT_USER_EMAIL", "stabilization-bot@users.noreply.github.com"),
+
+		ShutdownDrainSeconds: envOrInt("SHUTDOWN_DRAIN_SECONDS", 60),
+		GitUserName:          envOr("GIT_USER_NAME", "stabilization-bot"),
+		GitUserEmail:         envOr("GIT_USER_EMAIL", "stabilization-bot@users.noreply.github.com"),
+
+		Mode:        mode,
+		WebhookPath: envOr("WEBHOOK_PATH", "/webhook"),
+
+		IngestMode:          ingestMode,
+		IngestDropOn4xx:     envOrBool("INGEST_DROP_ON_4XX", true),
+		IngestMaxDeliveries: envOrInt("INGEST_MAX_DELIVERIES", 5),
+		EventFilter:         eventFilter,
+
+		NATSURL:     envOr("NATS_URL", "nats://127.0.0.1:4222"),
+		NATSStream:  os.Getenv("NATS_STREAM"),
+		NATSDurable: envOr("NATS_DURABLE", "gh-app-cherry-pick"),
+		NATSSubject: os.Getenv("NATS_SUBJECT"),
+
+		AMQPURL:   os.Getenv("AMQP_URL"),
+		AMQPQueue: os.Getenv("AMQP_QUEUE"),
+
+		ServiceBusConnectionString: os.Getenv("SERVICEBUS_CONNECTION_STRING"),
+		ServiceBusQueue:            os.Getenv("SERVICEBUS_QUEUE"),
+
+		RedisStreamURL:   os.Getenv("REDIS_STREAM_URL"),
+		RedisStream:      envOr("REDIS_STREAM", "github-webhooks"),
+		RedisStreamGroup: envOr("REDIS_STREAM_GROUP", "gh-app-cherry-pick"),
 
 		AWSRegion:             awsRegion,
 		SQSQueueURL:           queueURL,
+		SQSQueueURLs:          queueURLs,
 		SQSMaxMessages:        safeInt32(envOrInt("SQS_MAX_MESSAGES", 10)),
 		SQSWaitTimeSeconds:    safeInt32(envOrInt("SQS_WAIT_TIME_SECONDS", 10)),
 		SQSVisibilityTimeout:  safeInt32(envOrInt("SQS_VISIBILITY_TIMEOUT", 120)),
 		SQSDeleteOn4xx:        envOrBool("SQS_DELETE_ON_4XX", true),
 		SQSExtendOnProcessing: envOrBool("SQS_EXTEND_ON_PROCESSING", false),
+		SQSConcurrency:        envOrInt("SQS_CONCURRENCY", 0),
+		SQSWarnReceives:       envOrInt("SQS_WARN_RECEIVES", 3),
+		SQSMaxReceives:        envOrInt("SQS_MAX_RECEIVES", 0),
+		SQSDLQURL:             os.Getenv("SQS_DLQ_URL"),
+		SQSQuarantineBucket:   os.Getenv("SQS_QUARANTINE_BUCKET"),
+		SQSQuarantinePrefix:   envOr("SQS_QUARANTINE_PREFIX", "quarantine/"),
+
+		SQSReceiveBackoffMaxSeconds: envOrInt("SQS_RECEIVE_BACKOFF_MAX_SECONDS", 60),
+		SQSBreakerThreshold:         envOrInt("SQS_BREAKER_THRESHOLD", 10),
+		SQSBreakerPauseSeconds:      envOrInt("SQS_BREAKER_PAUSE_SECONDS", 60),
+		SQSDedupeTTLSeconds:         envOrInt("SQS_DEDUPE_TTL_SECONDS", 600),
+		SQSDedupeSize:               envOrInt("SQS_DEDUPE_SIZE", 10000),
+		SQSAgeWarnSeconds:           envOrInt("SQS_AGE_WARN_SECONDS", 300),
+
+		SQSBacklogPollSeconds:   envOrInt("SQS_BACKLOG_POLL_SECONDS", 60),
+		SQSBacklogWarnThreshold: envOrInt("SQS_BACKLOG_WARN_THRESHOLD", 100),
 
 		// Give slow repos enough time; make it easy to override
 		CherryTimeoutSeconds: envOrInt("CHERRY_TIMEOUT_SECONDS", 600),
+		PatchFallback:        envOrBool("PATCH_FALLBACK", true),
+		AmFallback:           envOrBool("AM_FALLBACK", true),
+		CreditAuthors:        envOrBool("CREDIT_AUTHORS", true),
+		Signoff:              envOrBool("SIGNOFF", false),
+		PickEmpty:            envOrBool("PICK_EMPTY", false),
+		PickRedundant:        envOrBool("PICK_REDUNDANT", false),
+		CommitFooter:         strings.ReplaceAll(os.Getenv("COMMIT_FOOTER_TEMPLATE"), `\n`, "\n"),
+		PushRetry:            pushRetry,
+		SSHKeyDir:            os.Getenv("SSH_KEY_DIR"),
+		SSHKnownHosts:        os.Getenv("SSH_KNOWN_HOSTS"),
+		APIPicks:             envOrBool("API_PICKS", false),
+		FullCloneFallback:    envOrBool("FULL_CLONE_FALLBACK", false),
+		GitMirrorDir:         os.Getenv("GIT_MIRROR_DIR"),
+		GitWorktreeDir:       os.Getenv("GIT_WORKTREE_DIR"),
+		ParallelTargets:      envOrInt("PARALLEL_TARGETS", 1),
+		LFS:                  envOrBool("GIT_LFS", true),
+		WorkspaceDir:         os.Getenv("WORKSPACE_DIR"),
+		WorkspaceQuotaMB:     envOrInt("WORKSPACE_QUOTA_MB", 0),
+		WorkspaceSweepSecs:   envOrInt("WORKSPACE_SWEEP_SECONDS", 600),
+		ConflictPRs:          envOrBool("CONFLICT_PRS", false),
+		PickStrategies:       envOrBool("PICK_STRATEGIES", false),
+		PickPRCommits:        envOrBool("PICK_PR_COMMITS", false),
+		PostPickHooks:        envOrBool("POST_PICK_HOOKS", false),
+		PickVerify:           envOrBool("PICK_VERIFY", false),
+		PrePushChecks:        envOrBool("PRE_PUSH_CHECKS", false),
+		MergeBackDetector:    envOrBool("MERGE_BACK_DETECTOR", false),
+		TrailerBackports:     envOrBool("TRAILER_BACKPORTS", false),
+		AutoMergeApproved:    envOrBool("AUTO_MERGE_APPROVED", false),
+		AutoMergeMethod:      autoMergeMethod,
+		EnableAutoMerge:      envOrBool("ENABLE_AUTO_MERGE", false),
+		RepoBootstrapLabels:  envList("REPO_BOOTSTRAP_LABELS"),
+		RepoBootstrapConfig:  bootstrapConfig,
+		BackportLabels:       backportLabels,
+		CopyLabels:           envList("COPY_LABELS"),
+		AssignAuthor:         envOrBool("ASSIGN_AUTHOR", true),
+		ReviewAuthor:         envOrBool("REVIEW_AUTHOR", true),
+		ReviewTeams:          envList("REVIEW_TEAMS"),
+		CodeOwnerReviews:     envOrBool("CODEOWNER_REVIEWS", false),
+		CopyMilestone:        envOrBool("COPY_MILESTONE", true),
+		DraftPRs:             envOrBool("DRAFT_PRS", false),
+		RepoDrafts:           envOrBool("REPO_DRAFTS", true),
+		PRTitleTemplate:      prTitle,
+		PRBodyTemplate:       prBody,
+		RepoPRTemplates:      envOrBool("REPO_PR_TEMPLATES", true),
+		LinkBackports:        envOrBool("LINK_BACKPORTS", true),
+		DryRun:               envOrBool("DRY_RUN", false),
+		SearchDedupe:         envOrBool("SEARCH_DEDUPE", true),
+		LabelRecheck:         envOrBool("LABEL_RECHECK", true),
+		LabelRecheckAdd:      envOrBool("LABEL_RECHECK_ADD", false),
+
+		MilestoneTargetTemplate: milestoneTemplate,
+		BackportDirective:       backportDirective,
+		MergeCommitTrailers:     envOrBool("MERGE_COMMIT_TRAILERS", true),
+		CommitStatuses:          envOrBool("COMMIT_STATUSES", false),
+		CheckRuns:               envOrBool("CHECK_RUNS", false),
+		StatusComment:           envOrBool("STATUS_COMMENT", false),
+		DeleteWorkBranches:      envOrBool("DELETE_WORK_BRANCHES", true),
+		CloseSuperseded:         envOrBool("CLOSE_SUPERSEDED", true),
+		TrainWindowMinutes:      envOrInt("TRAIN_WINDOW_MINUTES", 0),
+		ProjectID:               os.Getenv("BACKPORT_PROJECT_ID"),
+		ProjectStatus:           os.Getenv("BACKPORT_PROJECT_STATUS"),
+		RevertBackports:         envOrBool("REVERT_BACKPORTS", true),
+
+		ReleaseTagPattern: releaseTagPattern,
+		ReleaseTagBranch:  releaseTagBranch,
+
+		ForkPushToken: os.Getenv("FORK_PUSH_TOKEN"),
+
+		DegradeAfterErrors:     envOrInt("DEGRADE_AFTER_ERRORS", 5),
+		DegradeCooldownSeconds: envOrInt("DEGRADE_COOLDOWN_SECONDS", 300),
+
+		SupportBundleToken: os.Getenv("SUPPORT_BUNDLE_TOKEN"),
+		SupportLogBuffer:   envOrInt("SUPPORT_LOG_BUFFER", 5000),
+
+		UsageReportToken:     os.Getenv("USAGE_REPORT_TOKEN"),
+		UsageFile:            os.Getenv("USAGE_FILE"),
+		UsageSlackWebhookURL: os.Getenv("USAGE_SLACK_WEBHOOK_URL"),
+
+		BadgeRepos: envList("BADGE_REPOS"),
+
+		StateBackend:       stateBackend,
+		StateFile:          os.Getenv("STATE_FILE"),
+		StateSQLitePath:    os.Getenv("STATE_SQLITE_PATH"),
+		StateDynamoDBTable: os.Getenv("STATE_DYNAMODB_TABLE"),
+
+		ShardRedisURL:     os.Getenv("SHARD_REDIS_URL"),
+		ReplicaID:         envOr("REPLICA_ID", hostname()),
+		PRLockDistributed: envOrBool("PR_LOCK_DISTRIBUTED", false),
 	}, nil
 }
 
+func hostname() string {
+	h, err := os.Hostname()
+	if err != nil || h == "" {
+		return "replica"
+	}
+	return h
+}
+
 func envOr(k, def string) string {
 	if v := os.Getenv(k); v != "" {
 		return v
@@ -108,6 +538,17 @@ func envOrBool(k string, def bool) bool {
 	return def
 }
 
+// envList splits a comma-separated variable, dropping empty items.
+func envList(k string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(k), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 // safeInt32 converts int to int32 with bounds checking to avoid overflow.
 func safeInt32(n int) int32 {
 	const maxInt32 = int32(^uint32(0) >> 1)
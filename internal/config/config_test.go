@@ -6,6 +6,8 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
+	"log/slog"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -85,6 +87,28 @@ func TestLoad_Success_WithDefaultsAndSQS(t *testing.T) {
 	if cfg.SQSExtendOnProcessing != false {
 		t.Fatalf("SQSExtendOnProcessing = %v, want false", cfg.SQSExtendOnProcessing)
 	}
+	if cfg.SQSBacklogPollSeconds != 60 || cfg.SQSBacklogWarnThreshold != 100 {
+		t.Fatalf("backlog defaults = %d/%d, want 60/100", cfg.SQSBacklogPollSeconds, cfg.SQSBacklogWarnThreshold)
+	}
+	if cfg.Mode != ModeQueue || !cfg.RunsQueue() || cfg.RunsWebhook() || cfg.WebhookPath != "/webhook" {
+		t.Fatalf("mode defaults = %q/%q, want queue-only on /webhook", cfg.Mode, cfg.WebhookPath)
+	}
+	if cfg.IngestMode != "sqs" {
+		t.Fatalf("IngestMode = %q, want sqs by default", cfg.IngestMode)
+	}
+	if !cfg.PatchFallback {
+		t.Fatalf("PatchFallback = false, want true by default")
+	}
+	if cfg.PostPickHooks {
+		t.Fatalf("PostPickHooks = true, want false by default")
+	}
+	if cfg.SQSSynchronous() {
+		t.Fatalf("SQSSynchronous = true, want messages acked once accepted by default")
+	}
+	cfg.SQSConcurrency = 4
+	if !cfg.SQSSynchronous() {
+		t.Fatalf("SQSSynchronous = false with SQS_CONCURRENCY set")
+	}
 }
 
 func TestLoad_ErrorsForMissingRequired(t *testing.T) {
@@ -137,6 +161,135 @@ func TestLoad_ErrorsForMissingRequired(t *testing.T) {
 		if !strings.Contains(err.Error(), "SQS_QUEUE_URL is required") {
 			t.Fatalf("expected error to mention SQS_QUEUE_URL; got: %v", err)
 		}
+
+		// Nor does the Lambda entrypoint, which is fed by the event source mapping.
+		t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "cherry-picker")
+		if _, err := Load(); err != nil {
+			t.Fatalf("Load() under Lambda: %v", err)
+		}
+		t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "")
+
+		// Other ingest backends don't need it.
+		t.Setenv("INGEST_MODE", "NATS")
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() with INGEST_MODE=nats: %v", err)
+		}
+		if cfg.IngestMode != "nats" {
+			t.Fatalf("IngestMode = %q, want normalized %q", cfg.IngestMode, "nats")
+		}
+	})
+}
+
+func TestLoad_Mode(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "1")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "x")
+	t.Setenv("GITHUB_APP_PRIVATE_KEY_PEM_BASE64", base64.StdEncoding.EncodeToString(mkTestPEM(t)))
+	t.Setenv("SQS_QUEUE_URL", "")
+
+	t.Run("webhook needs no queue", func(t *testing.T) {
+		t.Setenv("MODE", "webhook")
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if cfg.RunsQueue() || !cfg.RunsWebhook() {
+			t.Fatalf("webhook mode: queue=%v webhook=%v", cfg.RunsQueue(), cfg.RunsWebhook())
+		}
+	})
+	t.Run("both still needs the queue", func(t *testing.T) {
+		t.Setenv("MODE", "both")
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "SQS_QUEUE_URL") {
+			t.Fatalf("want SQS_QUEUE_URL error, got %v", err)
+		}
+	})
+	t.Run("event filter", func(t *testing.T) {
+		t.Setenv("MODE", "webhook")
+		t.Setenv("EVENT_FILTER", "pull_request:closed|labeled,create")
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if !cfg.EventFilter.Allows("create", []byte(`{}`)) || cfg.EventFilter.Allows("push", []byte(`{}`)) {
+			t.Fatalf("EventFilter not applied: %#v", cfg.EventFilter)
+		}
+		t.Setenv("EVENT_FILTER", "pull_request:")
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "EVENT_FILTER") {
+			t.Fatalf("want EVENT_FILTER error, got %v", err)
+		}
+	})
+	t.Run("repo bootstrap config must parse", func(t *testing.T) {
+		t.Setenv("MODE", "webhook")
+		t.Setenv("REPO_BOOTSTRAP_CONFIG_BASE64", base64.StdEncoding.EncodeToString([]byte("post_pick: [")))
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "REPO_BOOTSTRAP_CONFIG_BASE64") {
+			t.Fatalf("want REPO_BOOTSTRAP_CONFIG_BASE64 error, got %v", err)
+		}
+	})
+	t.Run("milestone template needs the placeholder", func(t *testing.T) {
+		t.Setenv("MODE", "webhook")
+		t.Setenv("MILESTONE_TARGET_TEMPLATE", "devops-release/0031")
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "MILESTONE_TARGET_TEMPLATE") {
+			t.Fatalf("want MILESTONE_TARGET_TEMPLATE error, got %v", err)
+		}
+	})
+	t.Run("release tag pattern", func(t *testing.T) {
+		t.Setenv("MODE", "webhook")
+		t.Setenv("RELEASE_TAG_PATTERN", `^devops-v(\d{4})$`)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RELEASE_TAG_BRANCH") {
+			t.Fatalf("want RELEASE_TAG_BRANCH error, got %v", err)
+		}
+		t.Setenv("RELEASE_TAG_BRANCH", "devops-release/$1")
+		cfg, err := Load()
+		if err != nil || !cfg.ReleaseTagPattern.MatchString("devops-v0031") {
+			t.Fatalf("cfg = %+v, err = %v", cfg, err)
+		}
+		t.Setenv("RELEASE_TAG_PATTERN", "v(")
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RELEASE_TAG_PATTERN") {
+			t.Fatalf("want RELEASE_TAG_PATTERN error, got %v", err)
+		}
+	})
+	t.Run("push retry policy", func(t *testing.T) {
+		t.Setenv("MODE", "webhook")
+		t.Setenv("PUSH_RETRY", "Rebase")
+		cfg, err := Load()
+		if err != nil || cfg.PushRetry != "rebase" {
+			t.Fatalf("cfg = %+v, err = %v", cfg, err)
+		}
+		t.Setenv("PUSH_RETRY", "merge")
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "PUSH_RETRY") {
+			t.Fatalf("want PUSH_RETRY error, got %v", err)
+		}
+	})
+	t.Run("pr templates", func(t *testing.T) {
+		t.Setenv("MODE", "webhook")
+		t.Setenv("PR_BODY_TEMPLATE", `Backport of {{.What}}\n\nby @{{.Author}}`)
+		cfg, err := Load()
+		if err != nil || cfg.PRBodyTemplate != "Backport of {{.What}}\n\nby @{{.Author}}" {
+			t.Fatalf("cfg = %+v, err = %v", cfg, err)
+		}
+		t.Setenv("PR_TITLE_TEMPLATE", "{{.Title")
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "PR_TITLE_TEMPLATE") {
+			t.Fatalf("want PR_TITLE_TEMPLATE error, got %v", err)
+		}
+	})
+	t.Run("backport labels", func(t *testing.T) {
+		t.Setenv("MODE", "webhook")
+		cfg, err := Load()
+		if err != nil || !slices.Equal(cfg.BackportLabels, []string{"autocherry"}) {
+			t.Fatalf("default BackportLabels: cfg = %+v, err = %v", cfg, err)
+		}
+		t.Setenv("BACKPORT_LABELS", "")
+		t.Setenv("COPY_LABELS", "bug, area/*")
+		cfg, err = Load()
+		if err != nil || len(cfg.BackportLabels) != 0 || !slices.Equal(cfg.CopyLabels, []string{"bug", "area/*"}) {
+			t.Fatalf("cfg = %+v, err = %v", cfg, err)
+		}
+	})
+	t.Run("unknown mode", func(t *testing.T) {
+		t.Setenv("MODE", "lambda")
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "MODE") {
+			t.Fatalf("want MODE error, got %v", err)
+		}
 	})
 }
 
@@ -385,3 +538,38 @@ func Test_safeInt32(t *testing.T) {
 		})
 	}
 }
+
+func TestLoad_SQSQueueURLs(t *testing.T) {
+	t.Setenv("GITHUB_APP_ID", "1")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "x")
+	t.Setenv("GITHUB_APP_PRIVATE_KEY_PEM_BASE64", base64.StdEncoding.EncodeToString(mkTestPEM(t)))
+	t.Setenv("SQS_QUEUE_URL", "https://sqs/1/main")
+	t.Setenv("SQS_QUEUE_URLS", "https://sqs/1/org-a, https://sqs/1/main,,https://sqs/1/org-b")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []string{"https://sqs/1/main", "https://sqs/1/org-a", "https://sqs/1/org-b"}
+	if !slices.Equal(cfg.SQSQueueURLs, want) {
+		t.Fatalf("SQSQueueURLs = %q, want %q", cfg.SQSQueueURLs, want)
+	}
+
+	// SQS_QUEUE_URLS alone satisfies the requirement.
+	t.Setenv("SQS_QUEUE_URL", "")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load with only SQS_QUEUE_URLS: %v", err)
+	}
+	if len(cfg.SQSQueueURLs) != 3 {
+		t.Fatalf("SQSQueueURLs = %q", cfg.SQSQueueURLs)
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{"": slog.LevelInfo, "DEBUG": slog.LevelDebug, "warn": slog.LevelWarn, "error": slog.LevelError, "loud": slog.LevelInfo} {
+		if got := logLevel(in); got != want {
+			t.Errorf("logLevel(%q) = %v; want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// LogHandler is the structured JSON log handler on stdout, at LOG_LEVEL
+// (debug, info, warn or error; default info). Call it after Load, as
+// APP_PROFILE may set LOG_LEVEL.
+func LogHandler() slog.Handler {
+	return slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel(os.Getenv("LOG_LEVEL"))})
+}
+
+func logLevel(s string) slog.Level {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileFile is the schema of CONFIG_FILE: named environments whose values
+// become defaults for the environment variables Load reads. A variable set
+// in the real environment always wins, so one image can be promoted from dev
+// to prod by switching APP_PROFILE alone.
+//
+//	profiles:
+//	  staging:
+//	    app_id: 123456
+//	    sqs_queue_url: https://sqs.eu-north-1.amazonaws.com/1234/ghapp-staging
+//	    log_level: debug
+//	    dry_run: true
+//	    env:
+//	      CHERRY_TIMEOUT_SECONDS: "900"
+type ProfileFile struct {
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile is one environment. The typed fields cover what usually differs
+// between environments; Env sets any other variable.
+type Profile struct {
+	AppID       int64             `yaml:"app_id"`
+	SQSQueueURL string            `yaml:"sqs_queue_url"`
+	LogLevel    string            `yaml:"log_level"`
+	DryRun      *bool             `yaml:"dry_run"`
+	Env         map[string]string `yaml:"env"`
+}
+
+var reEnvName = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// secretVars must come from the environment (or a secret manager), never
+// from a file that gets committed next to the deployment.
+var secretVars = map[string]bool{
+	"GITHUB_WEBHOOK_SECRET":             true,
+	"GITHUB_APP_PRIVATE_KEY_PEM":        true,
+	"GITHUB_APP_PRIVATE_KEY_PEM_BASE64": true,
+}
+
+// ParseProfiles decodes and validates a profile file. Unknown keys are
+// rejected so typos surface instead of being silently ignored.
+func ParseProfiles(b []byte) (*ProfileFile, error) {
+	var f ProfileFile
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for name, p := range f.Profiles {
+		switch strings.ToLower(p.LogLevel) {
+		case "", "debug", "info", "warn", "error":
+		default:
+			return nil, fmt.Errorf("profile %q: log_level must be debug, info, warn or error; got %q", name, p.LogLevel)
+		}
+		if p.AppID < 0 {
+			return nil, fmt.Errorf("profile %q: app_id must be positive", name)
+		}
+		for k := range p.Env {
+			if !reEnvName.MatchString(k) {
+				return nil, fmt.Errorf("profile %q: env key %q is not an environment variable name", name, k)
+			}
+			if secretVars[k] {
+				return nil, fmt.Errorf("profile %q: %s must not be set in a profile; pass it via the environment", name, k)
+			}
+		}
+	}
+	return &f, nil
+}
+
+// vars flattens the profile into environment variable defaults.
+func (p Profile) vars() map[string]string {
+	out := make(map[string]string, len(p.Env)+4)
+	for k, v := range p.Env {
+		out[k] = v
+	}
+	if p.AppID != 0 {
+		out["GITHUB_APP_ID"] = strconv.FormatInt(p.AppID, 10)
+	}
+	if p.SQSQueueURL != "" {
+		out["SQS_QUEUE_URL"] = p.SQSQueueURL
+	}
+	if p.LogLevel != "" {
+		out["LOG_LEVEL"] = strings.ToLower(p.LogLevel)
+	}
+	if p.DryRun != nil {
+		out["DRY_RUN"] = strconv.FormatBool(*p.DryRun)
+	}
+	return out
+}
+
+// applyProfile exports the APP_PROFILE profile from CONFIG_FILE into the
+// process environment for variables that are not already set, so everything
+// reading the environment (LOG_LEVEL, the AWS SDK) sees the same values.
+// Without APP_PROFILE it does nothing.
+func applyProfile() (string, error) {
+	name := os.Getenv("APP_PROFILE")
+	if name == "" {
+		return "", nil
+	}
+	path := envOr("CONFIG_FILE", "config.yaml")
+	raw, err := os.ReadFile(path) // #nosec G304 -- path is operator configuration (CONFIG_FILE)
+	if err != nil {
+		return "", fmt.Errorf("APP_PROFILE=%s: %w", name, err)
+	}
+	f, err := ParseProfiles(raw)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	p, ok := f.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(f.Profiles))
+		for n := range f.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("APP_PROFILE %q not found in %s (available: %s)", name, path, strings.Join(names, ", "))
+	}
+	for k, v := range p.vars() {
+		if os.Getenv(k) == "" {
+			if err := os.Setenv(k, v); err != nil {
+				return "", err
+			}
+		}
+	}
+	return name, nil
+}
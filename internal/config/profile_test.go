@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testProfiles = `
+profiles:
+  prod:
+    app_id: 111
+    sqs_queue_url: https://sqs.eu-north-1.amazonaws.com/1/prod
+    log_level: info
+  staging:
+    app_id: 222
+    sqs_queue_url: https://sqs.eu-north-1.amazonaws.com/1/staging
+    log_level: DEBUG
+    dry_run: true
+    env:
+      CHERRY_TIMEOUT_SECONDS: "900"
+`
+
+func writeProfiles(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_AppProfile(t *testing.T) {
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "x")
+	t.Setenv("GITHUB_APP_PRIVATE_KEY_PEM_BASE64", base64.StdEncoding.EncodeToString(mkTestPEM(t)))
+	t.Setenv("CONFIG_FILE", writeProfiles(t, testProfiles))
+	for _, k := range []string{"GITHUB_APP_ID", "SQS_QUEUE_URL", "LOG_LEVEL", "DRY_RUN", "CHERRY_TIMEOUT_SECONDS"} {
+		t.Setenv(k, "") // restored after the test, including values set by the profile
+	}
+	t.Setenv("APP_PROFILE", "staging")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Profile != "staging" || cfg.AppID != 222 || !cfg.DryRun || cfg.CherryTimeoutSeconds != 900 {
+		t.Fatalf("cfg = profile %q app %d dry %v timeout %d", cfg.Profile, cfg.AppID, cfg.DryRun, cfg.CherryTimeoutSeconds)
+	}
+	if !strings.HasSuffix(cfg.SQSQueueURL, "/staging") || os.Getenv("LOG_LEVEL") != "debug" {
+		t.Fatalf("queue %q, LOG_LEVEL %q", cfg.SQSQueueURL, os.Getenv("LOG_LEVEL"))
+	}
+
+	// Real environment beats the profile.
+	t.Setenv("APP_PROFILE", "prod")
+	t.Setenv("GITHUB_APP_ID", "999")
+	t.Setenv("SQS_QUEUE_URL", "")
+	t.Setenv("DRY_RUN", "")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load(prod): %v", err)
+	}
+	if cfg.AppID != 999 || cfg.DryRun || !strings.HasSuffix(cfg.SQSQueueURL, "/prod") {
+		t.Fatalf("prod cfg = app %d dry %v queue %q", cfg.AppID, cfg.DryRun, cfg.SQSQueueURL)
+	}
+
+	t.Setenv("APP_PROFILE", "qa")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "available: prod, staging") {
+		t.Fatalf("unknown profile error = %v", err)
+	}
+}
+
+func TestParseProfiles_Validation(t *testing.T) {
+	cases := map[string]string{
+		"unknown key":     "profiles:\n  dev:\n    queue: x\n",
+		"bad log level":   "profiles:\n  dev:\n    log_level: loud\n",
+		"bad env name":    "profiles:\n  dev:\n    env:\n      lower: x\n",
+		"secret in file":  "profiles:\n  dev:\n    env:\n      GITHUB_WEBHOOK_SECRET: x\n",
+		"negative app id": "profiles:\n  dev:\n    app_id: -1\n",
+	}
+	for name, in := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseProfiles([]byte(in)); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+	if f, err := ParseProfiles([]byte(testProfiles)); err != nil || len(f.Profiles) != 2 {
+		t.Fatalf("valid file: %v, %+v", err, f)
+	}
+}
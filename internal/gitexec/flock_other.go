@@ -0,0 +1,11 @@
+//go:build !unix
+
+package gitexec
+
+import (
+	"errors"
+	"os"
+)
+
+// flockNB always fails without flock, so Sweep keeps every locked tree.
+func flockNB(f *os.File) error { return errors.ErrUnsupported }
@@ -3,18 +3,29 @@ package gitexec
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 )
 
 type Runner struct {
 	WorkDir string
 	Env     []string
+
+	remoteURL string        // authenticated origin URL, kept for RestoreRemoteToken
+	mirror    *MirrorCache  // see UseMirror
+	mirrored  bool          // origin fetches from mirror; remoteURL is its push URL
+	clones    *SharedClones // see UseSharedClones
+	wt        *worktree     // set by CloneWithToken when using clones
+	config    []string      // -c flags for every git command (credentials, identity)
+	ws        *Workspace    // releases WorkDir on Clean (Workspace.NewRunner)
+	ssh       *SSHAuth      // see UseSSH
 }
 
 func NewRunner(baseDir string, extraEnv ...string) (*Runner, error) {
@@ -29,7 +40,7 @@ func NewRunner(baseDir string, extraEnv ...string) (*Runner, error) {
 			clean = abs
 		}
 	}
-	td, err := os.MkdirTemp(clean, "cherry-*")
+	td, err := os.MkdirTemp(clean, workDirPrefix+"*")
 	if err != nil {
 		return nil, err
 	}
@@ -39,9 +50,16 @@ func NewRunner(baseDir string, extraEnv ...string) (*Runner, error) {
 var reToken = regexp.MustCompile(`x-access-token:[^@]+@`)
 
 func (r *Runner) run(ctx context.Context, _ string, args ...string) error {
-	cmd := exec.CommandContext(ctx, "git", args...) // #nosec G204 -- args are git subcommand args from internal callers (clone, checkout, etc.)
-	cmd.Dir = r.WorkDir
-	cmd.Env = r.Env
+	_, err := r.exec(ctx, nil, args...)
+	return err
+}
+
+// exec runs git with optional stdin and returns the combined output.
+func (r *Runner) exec(ctx context.Context, stdin []byte, args ...string) (string, error) {
+	cmd := r.command(ctx, args)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
 
 	var out bytes.Buffer
 	cmd.Stdout, cmd.Stderr = &out, &out
@@ -54,21 +72,59 @@ func (r *Runner) run(ctx context.Context, _ string, args ...string) error {
 
 	slog.Debug("git.exec", "cwd", r.WorkDir, "cmd", "git", "args", safeArgs)
 	err := cmd.Run()
+	s := out.String()
 	if err != nil {
-		s := out.String()
 		slog.Error("git.fail", "cmd", "git", "args", safeArgs, "err", err, "out", s)
-		return fmt.Errorf("git %s failed: %v", strings.Join(safeArgs, " "), err)
+		return s, fmt.Errorf("git %s failed: %v", strings.Join(safeArgs, " "), err)
 	}
-	if s := strings.TrimSpace(out.String()); s != "" {
-		slog.Debug("git.out", "cmd", "git", "out", s)
+	if t := strings.TrimSpace(s); t != "" {
+		slog.Debug("git.out", "cmd", "git", "out", t)
 	}
-	return nil
+	return s, nil
 }
 
-func (r *Runner) Clean() { _ = os.RemoveAll(r.WorkDir) }
+// command builds git args in the work tree (until its worktree exists, the
+// shared clone), with r.config first.
+func (r *Runner) command(ctx context.Context, args []string) *exec.Cmd {
+	dir := r.WorkDir
+	if r.wt != nil && !r.wt.attached {
+		dir = r.wt.shared
+	}
+	full := append(slices.Clone(r.config), args...)
+	cmd := exec.CommandContext(ctx, "git", full...) // #nosec G204 -- args are git subcommand args from internal callers (clone, checkout, etc.)
+	cmd.Dir = dir
+	cmd.Env = r.Env
+	return cmd
+}
+
+func (r *Runner) Clean() {
+	if r.wt != nil && r.wt.attached {
+		r.removeWorktree(context.Background())
+	}
+	_ = os.RemoveAll(r.WorkDir)
+	if r.ws != nil {
+		r.ws.release(r.WorkDir)
+	}
+}
+
+// Dir returns the work tree path.
+func (r *Runner) Dir() string { return r.WorkDir }
 
 func (r *Runner) CloneWithToken(ctx context.Context, owner, repo, token string) error {
 	url := fmt.Sprintf("https://x-access-token:%s@github.com/%s/%s.git", token, owner, repo)
+	if r.ssh != nil {
+		url = sshURL(owner, repo)
+		r.Env = append(r.Env, "GIT_SSH_COMMAND="+r.ssh.command())
+	}
+	r.remoteURL = url
+	if r.clones != nil {
+		if r.ssh != nil {
+			return r.cloneShared(ctx, owner, repo, url, nil)
+		}
+		// The token goes on each command line, never into the shared config.
+		auth := fmt.Sprintf("url.https://x-access-token:%s@github.com/.insteadOf=https://github.com/", token)
+		return r.cloneShared(ctx, owner, repo, fmt.Sprintf("https://github.com/%s/%s.git", owner, repo), []string{"-c", auth})
+	}
 	// Enable protocol v2 up-front; helps partial clone/fetch filters.
 	if err := r.run(ctx, "git", "-c", "protocol.version=2", "init"); err != nil {
 		return err
@@ -77,29 +133,164 @@ func (r *Runner) CloneWithToken(ctx context.Context, owner, repo, token string)
 		return err
 	}
 	_ = r.run(ctx, "git", "symbolic-ref", "HEAD", "refs/heads/master")
+	if r.mirror != nil {
+		r.useMirror(ctx, owner, repo)
+	}
+	return nil
+}
+
+// UseMirror makes CloneWithToken fetch from a local bare mirror kept in c
+// (refreshed from GitHub first); pushes still go to GitHub.
+func (r *Runner) UseMirror(c *MirrorCache) { r.mirror = c }
+
+// useMirror points origin's fetch URL at the refreshed mirror of owner/repo,
+// keeping GitHub as the push URL. A mirror that cannot be refreshed is
+// skipped: fetches then go to GitHub as usual.
+func (r *Runner) useMirror(ctx context.Context, owner, repo string) {
+	path, err := r.mirror.sync(ctx, r.Env, owner, repo, r.remoteURL)
+	if err != nil {
+		slog.Warn("git.mirror_error", "repo", owner+"/"+repo, "err", err)
+		return
+	}
+	if err := r.run(ctx, "git", "remote", "set-url", "--push", "origin", r.remoteURL); err != nil {
+		slog.Warn("git.mirror_error", "repo", owner+"/"+repo, "err", err)
+		return
+	}
+	if err := r.run(ctx, "git", "remote", "set-url", "origin", "file://"+path); err != nil {
+		slog.Warn("git.mirror_error", "repo", owner+"/"+repo, "err", err)
+		return
+	}
+	r.mirrored = true
+}
+
+// unmirror points origin back at GitHub, for objects the mirror lacks (e.g.
+// PR heads, which it does not track).
+func (r *Runner) unmirror(ctx context.Context) error {
+	if err := r.run(ctx, "git", "remote", "set-url", "origin", r.remoteURL); err != nil {
+		return err
+	}
+	if err := r.run(ctx, "git", "config", "--unset", "remote.origin.pushurl"); err != nil {
+		return err
+	}
+	r.mirrored = false
 	return nil
 }
 
+// fromGitHub retries a fetch that failed against the mirror from GitHub.
+func (r *Runner) fromGitHub(ctx context.Context, err error, fetch func() error) error {
+	if err == nil || !r.mirrored {
+		return err
+	}
+	slog.Info("git.mirror_miss", "err", err)
+	if uerr := r.unmirror(ctx); uerr != nil {
+		return errors.Join(err, uerr)
+	}
+	return fetch()
+}
+
 func (r *Runner) ConfigUser(ctx context.Context, name, email string) error {
+	if r.wt != nil {
+		// Concurrent worktrees would contend for the shared config.
+		r.config = append(r.config, "-c", "user.name="+name, "-c", "user.email="+email)
+		return nil
+	}
 	if err := r.run(ctx, "git", "config", "user.name", name); err != nil {
 		return err
 	}
 	return r.run(ctx, "git", "config", "user.email", email)
 }
 
+// fetchDepths are the shallow depths Fetch tries before fetching full history.
+var fetchDepths = []string{"200", "2000"}
+
+// deepenMarkers are git's complaints about objects a shallow fetch did not
+// bring in (or a SHA it cannot serve), which more history may fix.
+var deepenMarkers = []string{
+	"bad object",
+	"not our ref",
+	"unadvertised object",
+	"did not receive expected object",
+	"unknown revision",
+}
+
+// reBareSHA matches refspecs that name a commit rather than a ref.
+var reBareSHA = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
 // Fetch performs a shallow, partial fetch to keep it fast and memory-light.
-// Depth 200 is a pragmatic default; adjust here if you ever need more history.
+// Depth 200 is a pragmatic default. When git reports missing objects, it
+// retries deeper and then with full history; a commit named by SHA that the
+// server will not serve directly is then accepted if the fetched branches
+// contain it. With a mirror, a fetch it cannot serve is retried from GitHub.
 func (r *Runner) Fetch(ctx context.Context, refspec ...string) error {
-	args := []string{
-		"fetch",
-		"--prune",
-		"--no-tags",
-		"--depth", "200",
-		"--filter=blob:none",
-		"origin",
-	}
-	args = append(args, refspec...)
-	return r.run(ctx, "git", args...)
+	defer r.lockShared()()
+	fetch := func() error { return r.fetch(ctx, refspec) }
+	return r.fromGitHub(ctx, fetch(), fetch)
+}
+
+func (r *Runner) fetch(ctx context.Context, refspec []string) error {
+	var out string
+	var err error
+	for _, depth := range fetchDepths {
+		out, err = r.exec(ctx, nil, fetchArgs(depth, refspec)...)
+		if err == nil || !needsDeeperFetch(out) {
+			return err
+		}
+		slog.Info("git.fetch_deepen", "depth", depth)
+	}
+
+	// Full history of the refs; SHAs must then be among their commits.
+	var refs, shas []string
+	for _, s := range refspec {
+		if reBareSHA.MatchString(s) {
+			shas = append(shas, s)
+		} else {
+			refs = append(refs, s)
+		}
+	}
+	firstErr := err
+	if _, err := r.exec(ctx, nil, fetchArgs("", refs)...); err != nil {
+		return err
+	}
+	for _, sha := range shas {
+		if _, err := r.exec(ctx, nil, "cat-file", "-e", sha+"^{commit}"); err != nil {
+			return fmt.Errorf("commit %s not found after a full fetch: %w", sha, firstErr)
+		}
+	}
+	return nil
+}
+
+// FetchFull fetches refspec with complete history and all blobs, for picks
+// that the shallow, partial Fetch cannot handle.
+func (r *Runner) FetchFull(ctx context.Context, refspec ...string) error {
+	defer r.lockShared()()
+	fetch := func() error {
+		return r.run(ctx, "git", append([]string{"fetch", "--prune", "--no-tags", "origin"}, refspec...)...)
+	}
+	return r.fromGitHub(ctx, fetch(), fetch)
+}
+
+// fetchArgs builds the partial fetch of refspec from origin ("" depth = full
+// history).
+func fetchArgs(depth string, refspec []string) []string {
+	args := []string{"fetch", "--prune", "--no-tags"}
+	if depth != "" {
+		args = append(args, "--depth", depth)
+	} else {
+		// Un-shallow what earlier attempts (or an older fetch) cut off.
+		args = append(args, "--update-shallow", "--deepen=2147483647")
+	}
+	args = append(args, "--filter=blob:none", "origin")
+	return append(args, refspec...)
+}
+
+// needsDeeperFetch reports whether fetch output blames missing history.
+func needsDeeperFetch(out string) bool {
+	for _, m := range deepenMarkers {
+		if strings.Contains(out, m) {
+			return true
+		}
+	}
+	return false
 }
 
 func (r *Runner) CheckoutBranchFrom(ctx context.Context, newBranch, fromRef string) error {
@@ -107,25 +298,269 @@ func (r *Runner) CheckoutBranchFrom(ctx context.Context, newBranch, fromRef stri
 	if !strings.HasPrefix(fromRef, "refs/") && !strings.HasPrefix(fromRef, "origin/") {
 		fromRef = "refs/remotes/" + fromRef
 	}
-	return r.run(ctx, "git", "checkout", "-B", newBranch, fromRef)
+	var err error
+	if r.wt != nil {
+		err = r.addWorktree(ctx, newBranch, fromRef)
+	} else {
+		err = r.run(ctx, "git", "checkout", "-B", newBranch, fromRef)
+	}
+	if err == nil && r.ws != nil {
+		r.ws.measure(r.WorkDir)
+	}
+	return err
 }
 
-func (r *Runner) CherryPick(ctx context.Context, sha string) error {
-	return r.run(ctx, "git", "cherry-pick", "-x", sha)
+// CherryPick runs `git cherry-pick -x` with extra args (e.g. a strategy) before sha.
+func (r *Runner) CherryPick(ctx context.Context, sha string, args ...string) error {
+	return r.run(ctx, "git", append(append([]string{"cherry-pick", "-x"}, args...), sha)...)
 }
 
 func (r *Runner) CherryPickSkip(ctx context.Context) error {
 	return r.run(ctx, "git", "cherry-pick", "--skip")
 }
 
-func (r *Runner) CherryPickWithMainline(ctx context.Context, mainline int, sha string) error {
-	return r.run(ctx, "git", "cherry-pick", "-m", fmt.Sprint(mainline), "-x", sha)
+// CherryPickRange cherry-picks the commits after fromSHA up to and including
+// toSHA (git's fromSHA..toSHA), oldest first.
+func (r *Runner) CherryPickRange(ctx context.Context, fromSHA, toSHA string, args ...string) error {
+	return r.run(ctx, "git", append(append([]string{"cherry-pick", "-x"}, args...), fromSHA+".."+toSHA)...)
+}
+
+func (r *Runner) CherryPickWithMainline(ctx context.Context, mainline int, sha string, args ...string) error {
+	return r.run(ctx, "git", append(append([]string{"cherry-pick", "-m", fmt.Sprint(mainline), "-x"}, args...), sha)...)
 }
 
 func (r *Runner) AbortCherryPick(ctx context.Context) {
 	_ = r.run(ctx, "git", "cherry-pick", "--abort")
 }
 
+// ErrPushForbidden marks a push GitHub refused by policy (branch protection,
+// rulesets or restricted branch creation) rather than for a transient reason.
+var ErrPushForbidden = errors.New("push forbidden by repository rules")
+
+// forbiddenPushMarkers are what GitHub says when it refuses a push by policy.
+var forbiddenPushMarkers = []string{
+	"GH013",
+	"protected branch hook declined",
+	"push declined due to repository rule violations",
+	"creations being restricted",
+	"The requested URL returned error: 403",
+}
+
+// ErrPushRejected marks a push refused because the remote branch has
+// commits the local one lacks (non-fast-forward), e.g. another replica
+// pushed the same work branch first.
+var ErrPushRejected = errors.New("push rejected as non-fast-forward")
+
+// rejectedPushMarkers are what git says when the remote branch moved.
+var rejectedPushMarkers = []string{
+	"[rejected]",
+	"non-fast-forward",
+	"(fetch first)",
+	"(stale info)",
+}
+
 func (r *Runner) Push(ctx context.Context, branch string) error {
-	return r.run(ctx, "git", "push", "-u", "origin", branch)
+	if r.wt != nil {
+		// No upstream: setting it would write the shared config.
+		return r.push(ctx, "push", "origin", branch)
+	}
+	return r.push(ctx, "push", "-u", "origin", branch)
+}
+
+// PushToRepo pushes branch to owner/repo (e.g. a fork) with token instead
+// of to origin.
+func (r *Runner) PushToRepo(ctx context.Context, owner, repo, token, branch string) error {
+	url := fmt.Sprintf("https://x-access-token:%s@github.com/%s/%s.git", token, owner, repo)
+	return r.push(ctx, "push", url, branch)
+}
+
+// PushForceWithLease pushes branch to origin over whatever is there, as
+// long as that is still expect (the tip FetchBranch saw).
+func (r *Runner) PushForceWithLease(ctx context.Context, branch, expect string) error {
+	return r.push(ctx, "push", "--force-with-lease=refs/heads/"+branch+":"+expect, "origin", branch)
+}
+
+// push runs git push, returning ErrPushForbidden when GitHub refused it by
+// policy and ErrPushRejected when the remote branch moved.
+func (r *Runner) push(ctx context.Context, args ...string) error {
+	out, err := r.exec(ctx, nil, args...)
+	if err == nil {
+		return nil
+	}
+	for _, m := range forbiddenPushMarkers {
+		if strings.Contains(out, m) {
+			return fmt.Errorf("%w: %v", ErrPushForbidden, err)
+		}
+	}
+	for _, m := range rejectedPushMarkers {
+		if strings.Contains(out, m) {
+			return fmt.Errorf("%w: %v", ErrPushRejected, err)
+		}
+	}
+	return err
+}
+
+// FetchBranch fetches branch from GitHub (not the mirror, which may lag
+// behind) into origin/<branch> and returns its tip.
+func (r *Runner) FetchBranch(ctx context.Context, branch string) (string, error) {
+	defer r.lockShared()()
+	ref := "refs/remotes/origin/" + branch
+	if err := r.run(ctx, "git", "fetch", "--no-tags", "--filter=blob:none", r.remoteURL, "+refs/heads/"+branch+":"+ref); err != nil {
+		return "", err
+	}
+	out, err := r.exec(ctx, nil, "rev-parse", ref)
+	return strings.TrimSpace(out), err
+}
+
+// Rebase rebases the current branch onto upstream, dropping commits whose
+// change upstream already has; a failed rebase is aborted.
+func (r *Runner) Rebase(ctx context.Context, upstream string) error {
+	if err := r.run(ctx, "git", "rebase", upstream); err != nil {
+		_ = r.run(ctx, "git", "rebase", "--abort")
+		return err
+	}
+	return nil
+}
+
+// ApplyPatch applies a unified diff from GitHub with a 3-way merge fallback,
+// staging the result. Renames in the diff are applied as renames.
+func (r *Runner) ApplyPatch(ctx context.Context, patch []byte) error {
+	_, err := r.exec(ctx, patch, "apply", "--3way", "--index", "--whitespace=nowarn", "-")
+	return err
+}
+
+// ApplyMailbox applies a format-patch mailbox (e.g. GitHub's .patch of a PR)
+// with `git am -3`, committing each patch with its own author and message.
+func (r *Runner) ApplyMailbox(ctx context.Context, mbox []byte, args ...string) error {
+	_, err := r.exec(ctx, mbox, append([]string{"am", "-3", "--whitespace=nowarn"}, args...)...)
+	return err
+}
+
+// AbortAm drops a failed `git am`, restoring the branch as it was.
+func (r *Runner) AbortAm(ctx context.Context) {
+	_ = r.run(ctx, "git", "am", "--abort")
+}
+
+// CommitMessage returns the author ("Name <email>") and raw message of sha.
+func (r *Runner) CommitMessage(ctx context.Context, sha string) (author, message string, err error) {
+	out, err := r.exec(ctx, nil, "log", "-1", "--format=%an <%ae>%x00%B", sha)
+	if err != nil {
+		return "", "", err
+	}
+	author, message, _ = strings.Cut(out, "\x00")
+	return strings.TrimSpace(author), strings.TrimSpace(message), nil
+}
+
+// Commit records the staged changes with the given message and author, and
+// extra args (e.g. --signoff).
+func (r *Runner) Commit(ctx context.Context, message, author string, extra ...string) error {
+	args := append([]string{"commit", "-m", message}, extra...)
+	if author != "" {
+		args = append(args, "--author", author)
+	}
+	return r.run(ctx, "git", args...)
+}
+
+// Amend rewrites HEAD's author ("Name <email>"; "" keeps it) and appends
+// trailers (e.g. "Co-authored-by: Name <email>") to its message.
+func (r *Runner) Amend(ctx context.Context, author string, trailers []string) error {
+	args := []string{"commit", "--amend", "--no-edit", "--no-verify", "--allow-empty"}
+	if author != "" {
+		args = append(args, "--author", author)
+	}
+	for _, t := range trailers {
+		args = append(args, "--trailer", t)
+	}
+	return r.run(ctx, "git", args...)
+}
+
+// Reword replaces HEAD's commit message with message.
+func (r *Runner) Reword(ctx context.Context, message string) error {
+	_, err := r.exec(ctx, []byte(message), "commit", "--amend", "--no-verify", "--allow-empty", "-F", "-")
+	return err
+}
+
+// ResetHard discards any partial state left by a failed apply.
+func (r *Runner) ResetHard(ctx context.Context) error {
+	return r.run(ctx, "git", "reset", "--hard", "HEAD")
+}
+
+// StripRemoteToken removes the installation token from the origin URL so
+// commands run in the work tree cannot read it from .git/config.
+func (r *Runner) StripRemoteToken(ctx context.Context) error {
+	return r.setRemoteURL(ctx, reToken.ReplaceAllString(r.remoteURL, ""))
+}
+
+// RestoreRemoteToken puts the authenticated origin URL back (before push).
+func (r *Runner) RestoreRemoteToken(ctx context.Context) error {
+	return r.setRemoteURL(ctx, r.remoteURL)
+}
+
+// setRemoteURL sets the URL origin pushes to (and, without a mirror,
+// fetches from).
+func (r *Runner) setRemoteURL(ctx context.Context, url string) error {
+	if r.wt != nil {
+		return nil // the token is never in the shared config
+	}
+	if r.mirrored {
+		return r.run(ctx, "git", "remote", "set-url", "--push", "origin", url)
+	}
+	return r.run(ctx, "git", "remote", "set-url", "origin", url)
+}
+
+// ChangedFiles lists the paths that differ between base and HEAD.
+func (r *Runner) ChangedFiles(ctx context.Context, base string) ([]string, error) {
+	out, err := r.exec(ctx, nil, "diff", "--name-only", "-z", base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(out), nil
+}
+
+// ConflictedFiles lists the paths left unmerged by a failed cherry-pick.
+func (r *Runner) ConflictedFiles(ctx context.Context) ([]string, error) {
+	out, err := r.exec(ctx, nil, "diff", "--name-only", "-z", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(out), nil
+}
+
+// ConflictStatus is `git status --short` and `git diff --stat HEAD` of the
+// work tree, for reporting a pick that stopped on conflicts.
+func (r *Runner) ConflictStatus(ctx context.Context) (status, diffstat string, err error) {
+	if status, err = r.exec(ctx, nil, "status", "--short", "--untracked-files=no"); err != nil {
+		return "", "", err
+	}
+	if diffstat, err = r.exec(ctx, nil, "diff", "--stat", "HEAD"); err != nil {
+		return "", "", err
+	}
+	return strings.TrimRight(status, "\n"), strings.TrimRight(diffstat, "\n"), nil
+}
+
+func splitNUL(out string) []string {
+	var files []string
+	for _, f := range strings.Split(out, "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// CommitAll stages every change in the work tree and commits it with extra
+// args (e.g. --signoff). It reports false (and commits nothing) when the tree
+// is clean.
+func (r *Runner) CommitAll(ctx context.Context, message string, args ...string) (bool, error) {
+	if err := r.run(ctx, "git", "add", "-A"); err != nil {
+		return false, err
+	}
+	out, err := r.exec(ctx, nil, "status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	if strings.TrimSpace(out) == "" {
+		return false, nil
+	}
+	return true, r.run(ctx, "git", append([]string{"commit", "-m", message}, args...)...)
 }
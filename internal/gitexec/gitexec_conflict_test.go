@@ -0,0 +1,47 @@
+package gitexec
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestConflictStatus(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git(t, "", "init", "-q", "-b", "master", dir)
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(dir, "f"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("base\n")
+	git(t, dir, "add", "f")
+	git(t, dir, "commit", "-qm", "base")
+	git(t, dir, "checkout", "-qb", "other")
+	write("other\n")
+	git(t, dir, "commit", "-qam", "other")
+	git(t, dir, "checkout", "-q", "master")
+	write("master\n")
+	git(t, dir, "commit", "-qam", "master")
+
+	r := &Runner{WorkDir: dir, Env: os.Environ()}
+	ctx := context.Background()
+	if err := r.CherryPick(ctx, "other"); err == nil {
+		t.Fatal("cherry-pick did not conflict")
+	}
+	files, err := r.ConflictedFiles(ctx)
+	if err != nil || !slices.Equal(files, []string{"f"}) {
+		t.Fatalf("ConflictedFiles = %v, %v", files, err)
+	}
+	status, diffstat, err := r.ConflictStatus(ctx)
+	if err != nil || status != "UU f" || !strings.Contains(diffstat, "f |") {
+		t.Fatalf("ConflictStatus = %q, %q, %v", status, diffstat, err)
+	}
+}
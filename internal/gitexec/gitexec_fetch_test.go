@@ -0,0 +1,68 @@
+package gitexec
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// git runs a git command in dir for test setup.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@example.com")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestFetch_DeepensOnUnservableSHA(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	src := filepath.Join(t.TempDir(), "src")
+	git(t, "", "init", "-q", "-b", "master", src)
+	for _, f := range []string{"a", "b", "c"} {
+		if err := os.WriteFile(filepath.Join(src, f), []byte(f), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		git(t, src, "add", f)
+		git(t, src, "commit", "-qm", f)
+	}
+	old := git(t, src, "rev-parse", "HEAD~2")
+	git(t, src, "checkout", "-qb", "gone")
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "unreachable")
+	gone := git(t, src, "rev-parse", "HEAD")
+	git(t, src, "checkout", "-q", "master")
+	git(t, src, "branch", "-qD", "gone")
+
+	newRunner := func() *Runner {
+		dst := t.TempDir()
+		git(t, dst, "init", "-q")
+		git(t, dst, "remote", "add", "origin", "file://"+src)
+		// Protocol v0 refuses SHAs that no ref advertises, like a server
+		// without uploadpack.allowReachableSHA1InWant.
+		env := append(os.Environ(), "GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=protocol.version", "GIT_CONFIG_VALUE_0=0")
+		return &Runner{WorkDir: dst, Env: env}
+	}
+	ctx := context.Background()
+
+	r := newRunner()
+	if err := r.Fetch(ctx, "master:refs/remotes/origin/master", old); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if _, err := r.exec(ctx, nil, "cat-file", "-e", old+"^{commit}"); err != nil {
+		t.Fatalf("commit %s missing after Fetch: %v", old, err)
+	}
+
+	r = newRunner()
+	if err := r.Fetch(ctx, "master:refs/remotes/origin/master", gone); err == nil || !strings.Contains(err.Error(), "not found after a full fetch") {
+		t.Fatalf("Fetch of an unreachable commit: err = %v", err)
+	}
+}
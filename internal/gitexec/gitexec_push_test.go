@@ -0,0 +1,78 @@
+package gitexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestPush_RejectedThenRebasedOrForced(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	git(t, "", "init", "-q", "--bare", "-b", "master", remote)
+	seed := t.TempDir()
+	git(t, seed, "clone", "-q", remote, ".")
+	git(t, seed, "commit", "-q", "--allow-empty", "-m", "base")
+	git(t, seed, "push", "-q", "origin", "HEAD:master")
+	ctx := context.Background()
+
+	// Each runner commits content on the work branch, as a pick would (in
+	// its own commit, as picks at different times are).
+	n := 0
+	newRunner := func(content string) *Runner {
+		n++
+		dst := t.TempDir()
+		git(t, dst, "clone", "-q", remote, ".")
+		git(t, dst, "checkout", "-qb", "work")
+		if err := os.WriteFile(filepath.Join(dst, "f"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		git(t, dst, "add", "f")
+		git(t, dst, "commit", "-qm", fmt.Sprintf("pick %s (%d)", content, n))
+		env := append(os.Environ(), "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=b", "GIT_COMMITTER_EMAIL=b@example.com")
+		return &Runner{WorkDir: dst, Env: env, remoteURL: remote}
+	}
+
+	first := newRunner("same")
+	if err := first.Push(ctx, "work"); err != nil {
+		t.Fatalf("first push: %v", err)
+	}
+	pushed := git(t, remote, "rev-parse", "work")
+
+	// The same pick from another replica rebases onto it to nothing new.
+	second := newRunner("same")
+	if err := second.Push(ctx, "work"); !errors.Is(err, ErrPushRejected) {
+		t.Fatalf("second push: err = %v; want ErrPushRejected", err)
+	}
+	tip, err := second.FetchBranch(ctx, "work")
+	if err != nil || tip != pushed {
+		t.Fatalf("FetchBranch = %q, %v; want %s", tip, err, pushed)
+	}
+	if err := second.Rebase(ctx, "origin/work"); err != nil {
+		t.Fatalf("Rebase: %v", err)
+	}
+	if err := second.Push(ctx, "work"); err != nil {
+		t.Fatalf("push after rebase: %v", err)
+	}
+	if got := git(t, second.WorkDir, "rev-parse", "HEAD"); got != pushed {
+		t.Fatalf("HEAD after rebase = %s; want %s", got, pushed)
+	}
+
+	// A different pick overwrites it, but only while the lease holds.
+	third := newRunner("other")
+	if err := third.PushForceWithLease(ctx, "work", "0000000000000000000000000000000000000001"); !errors.Is(err, ErrPushRejected) {
+		t.Fatalf("stale lease: err = %v; want ErrPushRejected", err)
+	}
+	if err := third.PushForceWithLease(ctx, "work", pushed); err != nil {
+		t.Fatalf("PushForceWithLease: %v", err)
+	}
+	if got, want := git(t, remote, "rev-parse", "work"), git(t, third.WorkDir, "rev-parse", "HEAD"); got != want {
+		t.Fatalf("remote work = %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package gitexec
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// ErrNoLFS means the repository uses Git LFS but git-lfs is not installed.
+var ErrNoLFS = errors.New("git-lfs is not installed")
+
+// UsesLFS reports whether ref's tree configures Git LFS: an .lfsconfig, or
+// a .gitattributes (at any depth) with filter=lfs.
+func (r *Runner) UsesLFS(ctx context.Context, ref string) bool {
+	// git grep exits 1 for no match; that is the common case, not a failure.
+	cmd := r.command(ctx, []string{"grep", "-q", "-E", `filter=lfs|^\[lfs\]`, ref, "--", ".lfsconfig", ".gitattributes", "*/.gitattributes"})
+	return cmd.Run() == nil
+}
+
+// SetupLFS installs git-lfs for the work tree with smudging skipped, so
+// checkouts and picks handle LFS pointers as they are instead of
+// downloading every object (and failing without credentials), while the
+// pre-push hook still uploads objects GitHub lacks. LFS requests use the
+// authenticated URL, passed per command.
+func (r *Runner) SetupLFS(ctx context.Context) error {
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		return ErrNoLFS
+	}
+	// With a worktree, the install lands in the shared clone's config.
+	unlock := r.lockShared()
+	err := r.run(ctx, "git", "lfs", "install", "--local", "--skip-smudge")
+	unlock()
+	if err != nil {
+		return err
+	}
+	// The mirror cannot serve LFS; GitHub can (over SSH, git-lfs asks it
+	// for the HTTPS endpoint itself).
+	url := r.remoteURL + "/info/lfs"
+	if r.ssh != nil {
+		url = r.remoteURL
+	}
+	r.config = append(r.config, "-c", "lfs.url="+url)
+	return nil
+}
+
+// PullLFS downloads and checks out the LFS objects of paths (e.g. the files
+// a pick changed), for hooks that need their content and for the pre-push
+// upload. Paths that are not LFS files are ignored.
+func (r *Runner) PullLFS(ctx context.Context, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	return r.run(ctx, "git", "lfs", "pull", "--include="+strings.Join(paths, ","), "origin")
+}
@@ -0,0 +1,48 @@
+package gitexec
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestUsesLFS(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git(t, dir, "init", "-q", "-b", "master")
+	commit := func(path, content string) string {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		git(t, dir, "add", path)
+		git(t, dir, "commit", "-qm", path)
+		return git(t, dir, "rev-parse", "HEAD")
+	}
+	plain := commit(".gitattributes", "*.sh text eol=lf\n")
+	nested := commit("assets/.gitattributes", "*.psd filter=lfs diff=lfs merge=lfs -text\n")
+
+	r := &Runner{WorkDir: dir, Env: os.Environ()}
+	ctx := context.Background()
+	if r.UsesLFS(ctx, plain) {
+		t.Fatal("UsesLFS without LFS attributes")
+	}
+	if !r.UsesLFS(ctx, nested) {
+		t.Fatal("UsesLFS missed a nested filter=lfs")
+	}
+
+	if _, err := exec.LookPath("git-lfs"); err == nil {
+		return
+	}
+	if err := r.SetupLFS(ctx); !errors.Is(err, ErrNoLFS) {
+		t.Fatalf("SetupLFS without git-lfs: err = %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package gitexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// MirrorCache keeps a bare mirror of each repository under Dir (meant to be
+// a persistent volume). Runners using it refresh the mirror with an
+// incremental fetch from GitHub, then fetch their work tree from the local
+// mirror instead of GitHub; pushes still go to GitHub. Mirrors keep full
+// history and are never pruned, so size the volume for the repositories
+// served. In-process locking only: give each replica its own volume.
+type MirrorCache struct {
+	Dir string
+
+	locks pathLocks
+}
+
+// NewMirrorCache returns a cache rooted at dir, creating it if needed.
+func NewMirrorCache(dir string) (*MirrorCache, error) {
+	abs, err := cacheDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &MirrorCache{Dir: abs}, nil
+}
+
+// cacheDir makes dir (absolute) for a cache of repositories.
+func cacheDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return abs, os.MkdirAll(abs, 0o700)
+}
+
+// pathLocks serializes work on one repository of a cache.
+type pathLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// lock locks path, returning the unlock.
+func (l *pathLocks) lock(path string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*sync.Mutex{}
+	}
+	m, ok := l.locks[path]
+	if !ok {
+		m = &sync.Mutex{}
+		l.locks[path] = m
+	}
+	l.mu.Unlock()
+	m.Lock()
+	return m.Unlock
+}
+
+// sync creates or refreshes the mirror of owner/repo from url and returns its
+// path. The token in url is used for this fetch only, never stored.
+func (c *MirrorCache) sync(ctx context.Context, env []string, owner, repo, url string) (string, error) {
+	path := filepath.Join(c.Dir, filepath.Base(owner), filepath.Base(repo)+".git")
+	defer c.locks.lock(path)()
+
+	if _, err := os.Stat(filepath.Join(path, "HEAD")); err != nil {
+		if err := os.MkdirAll(path, 0o700); err != nil {
+			return "", err
+		}
+		if err := gitIn(ctx, env, path, "init", "--bare", "--quiet"); err != nil {
+			return "", err
+		}
+		// Serve work trees' partial and by-SHA fetches like GitHub does.
+		for _, kv := range [][2]string{{"uploadpack.allowFilter", "true"}, {"uploadpack.allowAnySHA1InWant", "true"}} {
+			if err := gitIn(ctx, env, path, "config", kv[0], kv[1]); err != nil {
+				return "", err
+			}
+		}
+	}
+	// Branches only: work trees fetch merge commits and branch tips; other
+	// objects (PR heads) are fetched from GitHub when the mirror lacks them.
+	if err := gitIn(ctx, env, path, "fetch", "--prune", "--no-tags", "--quiet", url, "+refs/heads/*:refs/heads/*"); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// mirrorGit runs git in a mirror, redacting tokens from errors.
+func gitIn(ctx context.Context, env []string, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...) // #nosec G204 -- fixed git subcommands from this package
+	cmd.Dir = dir
+	cmd.Env = env
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		safe := reToken.ReplaceAllString(strings.Join(args, " "), "x-access-token:***@")
+		return fmt.Errorf("git %s in %s failed: %v: %s", safe, dir, err, reToken.ReplaceAllString(strings.TrimSpace(out.String()), "x-access-token:***@"))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package gitexec
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestMirrorCache_FetchesFromMirror(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	src := filepath.Join(t.TempDir(), "src")
+	git(t, "", "init", "-q", "-b", "master", src)
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "one")
+	cache, err := NewMirrorCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	newRunner := func() *Runner {
+		dst := t.TempDir()
+		git(t, dst, "init", "-q")
+		git(t, dst, "remote", "add", "origin", "file://"+src)
+		r := &Runner{WorkDir: dst, Env: os.Environ(), mirror: cache, remoteURL: "file://" + src}
+		r.useMirror(ctx, "o", "r")
+		if !r.mirrored {
+			t.Fatal("runner did not use the mirror")
+		}
+		return r
+	}
+
+	r := newRunner()
+	mirror := "file://" + filepath.Join(cache.Dir, "o", "r.git")
+	if got := git(t, r.WorkDir, "remote", "get-url", "origin"); got != mirror {
+		t.Fatalf("fetch URL = %q, want %q", got, mirror)
+	}
+	if got := git(t, r.WorkDir, "remote", "get-url", "--push", "origin"); got != "file://"+src {
+		t.Fatalf("push URL = %q", got)
+	}
+	if err := r.Fetch(ctx, "master:refs/remotes/origin/master"); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if err := r.StripRemoteToken(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := git(t, r.WorkDir, "remote", "get-url", "origin"); got != mirror {
+		t.Fatalf("fetch URL after StripRemoteToken = %q", got)
+	}
+
+	// A later runner sees new commits; refs the mirror lacks come from origin.
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "two")
+	head := git(t, src, "rev-parse", "HEAD")
+	git(t, src, "update-ref", "refs/pull/1/head", head)
+	r = newRunner()
+	if err := r.Fetch(ctx, "master:refs/remotes/origin/master"); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if got := git(t, r.WorkDir, "rev-parse", "refs/remotes/origin/master"); got != head {
+		t.Fatalf("master = %s, want the refreshed %s", got, head)
+	}
+	if err := r.Fetch(ctx, "refs/pull/1/head:refs/remotes/origin/pr"); err != nil {
+		t.Fatalf("Fetch of a PR head: %v", err)
+	}
+	if r.mirrored {
+		t.Fatal("runner still fetches from the mirror after a miss")
+	}
+	if got := git(t, r.WorkDir, "remote", "get-url", "--push", "origin"); got != "file://"+src {
+		t.Fatalf("push URL after a miss = %q", got)
+	}
+}
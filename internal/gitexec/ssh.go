@@ -0,0 +1,39 @@
+package gitexec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SSHAuth makes a runner clone, fetch and push over SSH with a deploy key
+// instead of putting the installation token in HTTPS remote URLs, for
+// networks that forbid outbound HTTPS with embedded credentials. Pushes to
+// forks (PushToRepo) still use HTTPS.
+type SSHAuth struct {
+	Key        string // private key file of the deploy key
+	KnownHosts string // known_hosts with GitHub's host keys ("" = ssh's default files)
+}
+
+// UseSSH makes CloneWithToken reach origin over SSH with a; the token it is
+// given is then left unused.
+func (r *Runner) UseSSH(a SSHAuth) { r.ssh = &a }
+
+// sshURL is origin's URL for owner/repo over SSH.
+func sshURL(owner, repo string) string {
+	return fmt.Sprintf("ssh://git@github.com/%s/%s.git", owner, repo)
+}
+
+// command is the GIT_SSH_COMMAND for a: only its key, and GitHub's host
+// key checked strictly.
+func (a SSHAuth) command() string {
+	args := []string{"ssh", "-i", ShellQuote(a.Key), "-o", "IdentitiesOnly=yes", "-o", "BatchMode=yes", "-o", "StrictHostKeyChecking=yes"}
+	if a.KnownHosts != "" {
+		args = append(args, "-o", "UserKnownHostsFile="+ShellQuote(a.KnownHosts))
+	}
+	return strings.Join(args, " ")
+}
+
+// ShellQuote quotes s as one sh word, as for GIT_SSH_COMMAND.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
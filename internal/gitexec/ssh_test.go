@@ -0,0 +1,37 @@
+package gitexec
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"slices"
+	"testing"
+)
+
+func TestSSHAuth_Command(t *testing.T) {
+	got := SSHAuth{Key: "/keys/it's", KnownHosts: "/etc/gh hosts"}.command()
+	want := `ssh -i '/keys/it'\''s' -o IdentitiesOnly=yes -o BatchMode=yes -o StrictHostKeyChecking=yes -o UserKnownHostsFile='/etc/gh hosts'`
+	if got != want {
+		t.Fatalf("command = %s\nwant %s", got, want)
+	}
+	if got := (SSHAuth{Key: "/k"}).command(); got != "ssh -i '/k' -o IdentitiesOnly=yes -o BatchMode=yes -o StrictHostKeyChecking=yes" {
+		t.Fatalf("command without known_hosts = %s", got)
+	}
+}
+
+func TestCloneWithToken_SSH(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	r := &Runner{WorkDir: t.TempDir(), Env: os.Environ()}
+	r.UseSSH(SSHAuth{Key: "/keys/42"})
+	if err := r.CloneWithToken(context.Background(), "o", "r", "tok"); err != nil {
+		t.Fatalf("CloneWithToken: %v", err)
+	}
+	if got := git(t, r.WorkDir, "remote", "get-url", "origin"); got != "ssh://git@github.com/o/r.git" {
+		t.Fatalf("origin = %s", got)
+	}
+	if !slices.Contains(r.Env, "GIT_SSH_COMMAND="+(SSHAuth{Key: "/keys/42"}).command()) {
+		t.Fatal("GIT_SSH_COMMAND not set")
+	}
+}
@@ -0,0 +1,231 @@
+package gitexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrWorkspaceFull is returned by Workspace.NewRunner when the work trees
+// already use the quota.
+var ErrWorkspaceFull = errors.New("workspace disk quota exceeded")
+
+// workDirPrefix names the temp dirs runners clone into.
+const workDirPrefix = "cherry-"
+
+// sweepGrace spares young directories another process may have just made.
+const sweepGrace = time.Minute
+
+// privateDir is the default parent of the work trees under the OS temp dir;
+// Sweep is only safe in a directory this app has to itself.
+const privateDir = "gh-app-cherry-pick"
+
+// Workspace guards the temp dirs runners clone into: it refuses new ones
+// above a disk quota, and Sweep removes those no runner is using, which
+// crashed runs (panic, SIGKILL, a Lambda timeout) leave behind. Each live
+// work tree holds an flock on a sibling "<tree>.lock" file, so other
+// processes sharing Dir see it as in use; the kernel drops the lock when its
+// owner dies. A nil *Workspace creates runners in the OS temp dir with no
+// guard.
+type Workspace struct {
+	Dir   string // parent of the work trees ("" = gh-app-cherry-pick under os.TempDir())
+	Quota int64  // bytes all work trees may use (0 = unlimited)
+
+	mu      sync.Mutex
+	active  map[string]*os.File // work trees of live runners, and their held locks
+	sizes   map[string]int64    // last measured size of each work tree counted in used
+	used    int64               // running total of sizes
+	counted bool                // whether the trees left from before were measured
+}
+
+// NewRunner is NewRunner in w.Dir, refusing with ErrWorkspaceFull when the
+// work trees use the quota. The runner's Clean releases its work tree.
+func (w *Workspace) NewRunner(extraEnv ...string) (*Runner, error) {
+	if w == nil {
+		return NewRunner("", extraEnv...)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.countLocked()
+	if w.Quota > 0 && w.used >= w.Quota {
+		return nil, fmt.Errorf("%w: %d of %d bytes used", ErrWorkspaceFull, w.used, w.Quota)
+	}
+	if err := os.MkdirAll(w.dir(), 0o700); err != nil {
+		return nil, err
+	}
+	r, err := NewRunner(w.dir(), extraEnv...)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := os.OpenFile(r.WorkDir+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		_ = os.RemoveAll(r.WorkDir)
+		return nil, err
+	}
+	if err := flockNB(lock); err != nil {
+		slog.Warn("workspace.lock_error", "dir", r.WorkDir, "err", err)
+	}
+	if w.active == nil {
+		w.active = map[string]*os.File{}
+	}
+	w.active[r.WorkDir] = lock
+	r.ws = w
+	return r, nil
+}
+
+// measure counts dir's current size towards the quota; runners call it once
+// their work tree is checked out.
+func (w *Workspace) measure(dir string) {
+	n := dirSize(dir)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.sizes == nil {
+		w.sizes = map[string]int64{}
+	}
+	w.used += n - w.sizes[dir]
+	w.sizes[dir] = n
+}
+
+// release forgets a work tree removed by Clean, dropping its lock.
+func (w *Workspace) release(dir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if lock := w.active[dir]; lock != nil {
+		_ = os.Remove(lock.Name())
+		_ = lock.Close()
+	}
+	delete(w.active, dir)
+	w.forgetLocked(dir)
+}
+
+// forgetLocked drops dir from the running total. w.mu must be held.
+func (w *Workspace) forgetLocked(dir string) {
+	w.used -= w.sizes[dir]
+	delete(w.sizes, dir)
+}
+
+// countLocked measures, once, the work trees already under w (another
+// process's, or left by a crash). w.mu must be held.
+func (w *Workspace) countLocked() {
+	if w.counted {
+		return
+	}
+	w.counted = true
+	if w.sizes == nil {
+		w.sizes = map[string]int64{}
+	}
+	for _, d := range w.workDirs() {
+		n := dirSize(d)
+		w.sizes[d] = n
+		w.used += n
+	}
+}
+
+// dir is the parent of the work trees.
+func (w *Workspace) dir() string {
+	if w.Dir == "" {
+		return filepath.Join(os.TempDir(), privateDir)
+	}
+	return w.Dir
+}
+
+// workDirs lists the work trees under w, live or not.
+func (w *Workspace) workDirs() []string {
+	entries, err := os.ReadDir(w.dir())
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() && strings.HasPrefix(e.Name(), workDirPrefix) {
+			dirs = append(dirs, filepath.Join(w.dir(), e.Name()))
+		}
+	}
+	return dirs
+}
+
+// Usage is the disk space in bytes the work trees use, as last measured.
+func (w *Workspace) Usage() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.countLocked()
+	return w.used
+}
+
+// dirSize is the total size of the regular files under d.
+func dirSize(d string) int64 {
+	var n int64
+	_ = filepath.WalkDir(d, func(_ string, e fs.DirEntry, err error) error {
+		if err != nil || !e.Type().IsRegular() {
+			return nil //nolint:nilerr // best effort
+		}
+		if info, err := e.Info(); err == nil {
+			n += info.Size()
+		}
+		return nil
+	})
+	return n
+}
+
+// Sweep removes the work trees no runner uses, returning how many it
+// removed. A tree whose lock a runner holds, in this process or another, is
+// kept, and so is any made in the last minute, whose owner may not have
+// locked it yet.
+func (w *Workspace) Sweep() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	removed := 0
+	for _, d := range w.workDirs() {
+		if w.active[d] != nil || !sweepable(d) {
+			continue
+		}
+		if err := os.RemoveAll(d); err != nil {
+			slog.Warn("workspace.sweep_error", "dir", d, "err", err)
+			continue
+		}
+		_ = os.Remove(d + ".lock")
+		w.forgetLocked(d)
+		removed++
+	}
+	if removed > 0 {
+		slog.Info("workspace.swept", "dir", w.dir(), "removed", removed)
+	}
+	return removed
+}
+
+// sweepable reports whether work tree d is past sweepGrace and no live
+// runner holds its lock.
+func sweepable(d string) bool {
+	info, err := os.Stat(d)
+	if err != nil || time.Since(info.ModTime()) < sweepGrace {
+		return false
+	}
+	lock, err := os.OpenFile(d+".lock", os.O_RDWR, 0)
+	if err != nil {
+		return true // crashed before locking, or made by an older version
+	}
+	defer func() { _ = lock.Close() }()
+	return flockNB(lock) == nil
+}
+
+// Run sweeps now and then every interval until ctx is done.
+func (w *Workspace) Run(ctx context.Context, every time.Duration) {
+	w.Sweep()
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			w.Sweep()
+		}
+	}
+}
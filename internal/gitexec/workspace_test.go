@@ -0,0 +1,111 @@
+package gitexec
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWorkspace_Quota(t *testing.T) {
+	w := &Workspace{Dir: t.TempDir(), Quota: 1024}
+	r, err := w.NewRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(r.WorkDir, "blob"), make([]byte, 2048), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w.measure(r.WorkDir) // as after checkout
+	if _, err := w.NewRunner(); !errors.Is(err, ErrWorkspaceFull) {
+		t.Fatalf("NewRunner over quota: err = %v", err)
+	}
+	r.Clean()
+	if _, err := w.NewRunner(); err != nil {
+		t.Fatalf("NewRunner after Clean: %v", err)
+	}
+	if n := w.Usage(); n != 0 {
+		t.Fatalf("Usage after Clean = %d, want 0", n)
+	}
+}
+
+func TestWorkspace_QuotaCountsExistingTrees(t *testing.T) {
+	dir := t.TempDir()
+	left := filepath.Join(dir, "cherry-left")
+	if err := os.Mkdir(left, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(left, "blob"), make([]byte, 2048), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w := &Workspace{Dir: dir, Quota: 1024}
+	if _, err := w.NewRunner(); !errors.Is(err, ErrWorkspaceFull) {
+		t.Fatalf("NewRunner over quota: err = %v", err)
+	}
+}
+
+func TestWorkspace_DefaultDirIsPrivate(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	r, err := (&Workspace{}).NewRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Clean()
+	if want := filepath.Join(os.TempDir(), privateDir); filepath.Dir(r.WorkDir) != want {
+		t.Fatalf("WorkDir = %s; want under %s", r.WorkDir, want)
+	}
+}
+
+func TestWorkspace_Sweep(t *testing.T) {
+	w := &Workspace{Dir: t.TempDir()}
+	old := time.Now().Add(-time.Hour)
+	mkdir := func(name string, mtime time.Time) string {
+		d := filepath.Join(w.Dir, name)
+		if err := os.Mkdir(d, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(d, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	leaked := mkdir("cherry-leaked", old)
+	young := mkdir("cherry-young", time.Now())
+	other := mkdir("not-ours", old)
+	abandoned := mkdir("cherry-abandoned", old)
+	if err := os.WriteFile(abandoned+".lock", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Another process's runner holds its tree's lock.
+	elsewhere := mkdir("cherry-elsewhere", old)
+	lock, err := os.OpenFile(elsewhere+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Close()
+	if err := flockNB(lock); err != nil {
+		t.Fatal(err)
+	}
+	live, err := w.NewRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(live.WorkDir, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := w.Sweep(); n != 2 {
+		t.Fatalf("Sweep removed %d, want 2", n)
+	}
+	for _, d := range []string{leaked, abandoned, abandoned + ".lock"} {
+		if _, err := os.Stat(d); !os.IsNotExist(err) {
+			t.Fatalf("%s survived the sweep", d)
+		}
+	}
+	for _, d := range []string{young, other, elsewhere, live.WorkDir} {
+		if _, err := os.Stat(d); err != nil {
+			t.Fatalf("%s was removed: %v", d, err)
+		}
+	}
+}
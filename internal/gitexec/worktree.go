@@ -0,0 +1,106 @@
+package gitexec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// SharedClones keeps one bare clone of each repository under Dir (meant to
+// be a persistent volume). A runner using it works in a `git worktree` of
+// that clone instead of a clone of its own, so concurrent picks of the same
+// repository (several targets of a PR, or several PRs) share its objects
+// and only fetch what is new. Fetches and worktree bookkeeping are
+// serialized per repository; picks, hooks and pushes run in parallel.
+// The token never lands in the shared config: it is passed per command.
+// In-process locking only: give each replica its own volume.
+type SharedClones struct {
+	Dir string
+
+	locks pathLocks
+}
+
+// NewSharedClones returns shared clones rooted at dir, creating it if needed.
+func NewSharedClones(dir string) (*SharedClones, error) {
+	abs, err := cacheDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &SharedClones{Dir: abs}, nil
+}
+
+// ensure creates the shared clone of owner/repo with origin at url, unless
+// it exists, and returns its path.
+func (c *SharedClones) ensure(ctx context.Context, env []string, owner, repo, url string) (string, error) {
+	path := filepath.Join(c.Dir, filepath.Base(owner), filepath.Base(repo)+".git")
+	defer c.locks.lock(path)()
+
+	if _, err := os.Stat(filepath.Join(path, "HEAD")); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return "", err
+	}
+	for _, args := range [][]string{
+		{"-c", "protocol.version=2", "init", "--bare", "--quiet"},
+		{"remote", "add", "origin", url},
+	} {
+		if err := gitIn(ctx, env, path, args...); err != nil {
+			_ = os.RemoveAll(path)
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+// worktree is a runner's share of a SharedClones clone.
+type worktree struct {
+	clones   *SharedClones
+	shared   string // path of the shared clone
+	attached bool   // WorkDir is a worktree of shared (after CheckoutBranchFrom)
+	branch   string // work branch checked out in WorkDir
+}
+
+// UseSharedClones makes CloneWithToken start from the shared clone of the
+// repository in c, and CheckoutBranchFrom add a worktree of it at WorkDir,
+// instead of a clone of its own. It takes precedence over UseMirror.
+func (r *Runner) UseSharedClones(c *SharedClones) { r.clones = c }
+
+// cloneShared sets r up to work from the shared clone of owner/repo, whose
+// origin is originURL; config is passed to every git command.
+func (r *Runner) cloneShared(ctx context.Context, owner, repo, originURL string, config []string) error {
+	path, err := r.clones.ensure(ctx, r.Env, owner, repo, originURL)
+	if err != nil {
+		return err
+	}
+	r.wt = &worktree{clones: r.clones, shared: path}
+	r.config = append(r.config, config...)
+	return nil
+}
+
+// lockShared serializes fetches and worktree changes in the shared clone;
+// without one it does nothing.
+func (r *Runner) lockShared() func() {
+	if r.wt == nil {
+		return func() {}
+	}
+	return r.wt.clones.locks.lock(r.wt.shared)
+}
+
+// addWorktree checks out newBranch from fromRef in a new worktree at WorkDir.
+func (r *Runner) addWorktree(ctx context.Context, newBranch, fromRef string) error {
+	defer r.lockShared()()
+	if err := r.run(ctx, "git", "worktree", "add", "--quiet", "-B", newBranch, r.WorkDir, fromRef); err != nil {
+		return err
+	}
+	r.wt.attached, r.wt.branch = true, newBranch
+	return nil
+}
+
+// removeWorktree drops WorkDir's worktree and its branch from the shared clone.
+func (r *Runner) removeWorktree(ctx context.Context) {
+	defer r.lockShared()()
+	r.wt.attached = false // run the rest in the shared clone
+	_ = r.run(ctx, "git", "worktree", "remove", "--force", r.WorkDir)
+	_ = r.run(ctx, "git", "branch", "-D", r.wt.branch)
+}
@@ -0,0 +1,100 @@
+package gitexec
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSharedClones_ConcurrentWorktrees(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	src := filepath.Join(t.TempDir(), "src")
+	git(t, "", "init", "-q", "-b", "master", src)
+	git(t, src, "config", "uploadpack.allowAnySHA1InWant", "true")
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "base")
+	for _, b := range []string{"release/1", "release/2"} {
+		git(t, src, "branch", b, "master")
+	}
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "fix")
+	fix := git(t, src, "rev-parse", "HEAD")
+	clones, err := NewSharedClones(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	pick := func(target string) error {
+		r, err := NewRunner(t.TempDir())
+		if err != nil {
+			return err
+		}
+		defer r.Clean()
+		r.UseSharedClones(clones)
+		if err := r.cloneShared(ctx, "o", "r", "file://"+src, nil); err != nil {
+			return err
+		}
+		if err := r.ConfigUser(ctx, "bot", "bot@example.com"); err != nil {
+			return err
+		}
+		if err := r.Fetch(ctx, "refs/heads/"+target+":refs/remotes/origin/"+target, fix); err != nil {
+			return err
+		}
+		branch := "autocherry/" + filepath.Base(target)
+		if err := r.CheckoutBranchFrom(ctx, branch, "origin/"+target); err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(r.WorkDir, ".git")); err != nil {
+			return err
+		}
+		if err := r.CherryPick(ctx, fix, "--allow-empty"); err != nil {
+			return err
+		}
+		return r.Push(ctx, branch)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, target := range []string{"release/1", "release/2"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = pick(target)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, b := range []string{"autocherry/1", "autocherry/2"} {
+		git(t, src, "rev-parse", "--verify", "refs/heads/"+b)
+	}
+	shared := filepath.Join(clones.Dir, "o", "r.git")
+	if out := git(t, shared, "worktree", "list", "--porcelain"); out != "worktree "+shared+"\nbare" {
+		t.Fatalf("worktrees left behind:\n%s", out)
+	}
+	if out := git(t, shared, "branch", "--list"); out != "" {
+		t.Fatalf("work branches left behind: %s", out)
+	}
+	if out := git(t, shared, "config", "--list", "--local"); containsAny(out, "user.name", "x-access-token") {
+		t.Fatalf("per-pick settings in the shared config:\n%s", out)
+	}
+}
+
+// containsAny reports whether s contains any of subs.
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,9 @@
 package githubapp
 
 import (
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v75/github"
@@ -14,7 +16,7 @@ type Clients struct {
 
 // NewClients creates an installation-scoped client for a given installation ID.
 func NewClients(appID, installationID int64, pem []byte) (*Clients, error) {
-	tr := http.DefaultTransport
+	tr := apiLogger{next: http.DefaultTransport}
 	itr, err := ghinstallation.New(tr, appID, installationID, pem)
 	if err != nil {
 		return nil, err
@@ -25,3 +27,22 @@ func NewClients(appID, installationID int64, pem []byte) (*Clients, error) {
 		HTTP: httpClient,
 	}, nil
 }
+
+// apiLogger logs each GitHub API call at debug level (method, path, status,
+// remaining rate limit) for troubleshooting and support bundles. The query
+// string and headers are left out; they may carry tokens.
+type apiLogger struct{ next http.RoundTripper }
+
+func (t apiLogger) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	attrs := []any{"method", req.Method, "path", req.URL.Path, "duration_ms", time.Since(start).Milliseconds()}
+	if resp != nil {
+		attrs = append(attrs, "status", resp.StatusCode, "rate_remaining", resp.Header.Get("X-RateLimit-Remaining"))
+	}
+	if err != nil {
+		attrs = append(attrs, "err", err)
+	}
+	slog.Debug("gh.api", attrs...)
+	return resp, err
+}
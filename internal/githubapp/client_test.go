@@ -1,10 +1,14 @@
 package githubapp
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"log/slog"
+	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -41,3 +45,29 @@ func TestNewClients_Success(t *testing.T) {
 	}
 	// No network calls happen here; we just ensure construction works.
 }
+
+type stubTransport struct{ status int }
+
+func (s stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: s.status, Header: http.Header{"X-Ratelimit-Remaining": {"4999"}}, Body: http.NoBody, Request: req}, nil
+}
+
+func TestAPILogger_LogsCallsWithoutQuery(t *testing.T) {
+	var buf bytes.Buffer
+	orig := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(orig) })
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/repos/o/r/pulls/1?access_token=secret", nil)
+	resp, err := apiLogger{next: stubTransport{status: 200}}.RoundTrip(req)
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("RoundTrip = %v, %v", resp, err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "msg=gh.api") || !strings.Contains(out, "path=/repos/o/r/pulls/1") || !strings.Contains(out, "rate_remaining=4999") {
+		t.Fatalf("log = %q", out)
+	}
+	if strings.Contains(out, "secret") {
+		t.Fatalf("query string leaked into log: %q", out)
+	}
+}
@@ -0,0 +1,43 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+)
+
+func init() { ingest.Register("amqp", NewSource) }
+
+type source struct {
+	worker *Worker
+	close  func()
+}
+
+// NewSource dials the broker and prepares a consumer for AMQP_QUEUE.
+func NewSource(_ context.Context, cfg *config.Config, h ingest.Handler) (ingest.Source, error) {
+	if cfg.AMQPQueue == "" {
+		return nil, errors.New("amqp: AMQP_QUEUE is required")
+	}
+	ch, closeFn, err := Dial(cfg.AMQPURL)
+	if err != nil {
+		return nil, err
+	}
+	return &source{
+		worker: &Worker{
+			Channel:         ch,
+			Queue:           cfg.AMQPQueue,
+			DropOn4xx:       cfg.IngestDropOn4xx,
+			MaxRedeliveries: int64(cfg.IngestMaxDeliveries),
+			Filter:          cfg.EventFilter,
+			Processor:       h,
+		},
+		close: closeFn,
+	}, nil
+}
+
+func (s *source) Run(ctx context.Context) error {
+	defer s.close()
+	return s.worker.Run(ctx)
+}
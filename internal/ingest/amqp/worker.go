@@ -0,0 +1,141 @@
+// Package amqp consumes webhook envelopes from a RabbitMQ (AMQP 0-9-1) queue
+// with manual acknowledgements and dispatches them to the processor.
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	amqp091 "github.com/rabbitmq/amqp091-go"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+)
+
+// channel is the subset of *amqp091.Channel the worker needs (test seam).
+type channel interface {
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp091.Table) (<-chan amqp091.Delivery, error)
+	Cancel(consumer string, noWait bool) error
+}
+
+// Dial opens a connection and a channel. The returned close func closes both.
+func Dial(url string) (*amqp091.Channel, func(), error) {
+	if url == "" {
+		return nil, nil, errors.New("amqp: URL is required")
+	}
+	conn, err := amqp091.Dial(url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("amqp dial: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("amqp channel: %w", err)
+	}
+	return ch, func() { _ = ch.Close(); _ = conn.Close() }, nil
+}
+
+// Worker consumes a queue, parses message envelopes (same formats as SQS, see
+// internal/queue) and dispatches to a Handler.
+type Worker struct {
+	Channel     channel
+	Queue       string
+	ConsumerTag string // default "gh-app-cherry-pick"
+	Prefetch    int    // unacked deliveries in flight (default 10)
+	DropOn4xx   bool   // reject 4xx without requeue (dead-letters if a DLX is set)
+	// MaxRedeliveries rejects without requeue once a quorum queue's
+	// x-delivery-count reaches it (0 = requeue forever / rely on the broker).
+	MaxRedeliveries int64
+
+	Filter    queue.Filter // ingest-time event/action allowlist (nil = all)
+	Processor ingest.Handler
+}
+
+// Run consumes deliveries until ctx is canceled or the channel closes.
+func (w *Worker) Run(ctx context.Context) error {
+	if w.Channel == nil || w.Queue == "" || w.Processor == nil {
+		return errors.New("amqp.Worker: missing Channel, Queue or Processor")
+	}
+	tag := w.ConsumerTag
+	if tag == "" {
+		tag = "gh-app-cherry-pick"
+	}
+	prefetch := w.Prefetch
+	if prefetch <= 0 {
+		prefetch = 10
+	}
+	if err := w.Channel.Qos(prefetch, 0, false); err != nil {
+		return fmt.Errorf("amqp qos: %w", err)
+	}
+	deliveries, err := w.Channel.Consume(w.Queue, tag, false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("amqp consume %s: %w", w.Queue, err)
+	}
+	slog.Info("amqp.worker.start", "queue", w.Queue, "prefetch", prefetch, "dropOn4xx", w.DropOn4xx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			_ = w.Channel.Cancel(tag, false)
+			slog.Info("amqp.worker.stop", "reason", "context_done")
+			return ctx.Err()
+		case d, ok := <-deliveries:
+			if !ok {
+				return errors.New("amqp: delivery channel closed")
+			}
+			w.handleDelivery(ctx, &d)
+		}
+	}
+}
+
+// handleDelivery dispatches one delivery and settles it: Ack on success,
+// Nack without requeue on dropped 4xx or exhausted redeliveries, Nack with
+// requeue otherwise.
+func (w *Worker) handleDelivery(ctx context.Context, d *amqp091.Delivery) {
+	msgID := d.MessageId
+	if msgID == "" {
+		msgID = fmt.Sprintf("%s/%d", w.Queue, d.DeliveryTag)
+	}
+
+	code, procErr := ingest.Dispatch(ctx, w.Processor, w.Filter, d.Body, msgID)
+	ack := ingest.ShouldAck(code, w.DropOn4xx)
+	count := deliveryCount(d)
+
+	var settleErr error
+	switch {
+	case ack && code >= 400:
+		settleErr = d.Nack(false, false)
+	case ack:
+		settleErr = d.Ack(false)
+	case w.MaxRedeliveries > 0 && count >= w.MaxRedeliveries:
+		slog.Error("amqp.message.max_redeliveries", "messageID", msgID, "deliveryCount", count, "status", code)
+		settleErr = d.Nack(false, false)
+	default:
+		settleErr = d.Nack(false, true)
+	}
+
+	if procErr != nil {
+		slog.Warn("amqp.message.process_error", "status", code, "err", procErr, "ack", ack, "redelivered", d.Redelivered, "messageID", msgID)
+	} else {
+		slog.Info("amqp.message.processed", "status", code, "ack", ack, "redelivered", d.Redelivered, "messageID", msgID)
+	}
+	if settleErr != nil {
+		slog.Error("amqp.message.settle_error", "err", settleErr, "messageID", msgID)
+	}
+}
+
+// deliveryCount reads the quorum-queue x-delivery-count header (0 if absent).
+func deliveryCount(d *amqp091.Delivery) int64 {
+	switch v := d.Headers["x-delivery-count"].(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	amqp091 "github.com/rabbitmq/amqp091-go"
+)
+
+// ---- fakes ----
+
+type fakeHandler struct {
+	code int
+	err  error
+}
+
+func (f *fakeHandler) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	return f.code, f.err
+}
+
+type fakeAck struct {
+	acked   bool
+	nacked  bool
+	requeue bool
+}
+
+func (f *fakeAck) Ack(tag uint64, multiple bool) error { f.acked = true; return nil }
+func (f *fakeAck) Nack(tag uint64, multiple, requeue bool) error {
+	f.nacked, f.requeue = true, requeue
+	return nil
+}
+func (f *fakeAck) Reject(tag uint64, requeue bool) error { return f.Nack(tag, false, requeue) }
+
+func delivery(ack *fakeAck, body string, headers amqp091.Table) *amqp091.Delivery {
+	return &amqp091.Delivery{Acknowledger: ack, DeliveryTag: 1, Body: []byte(body), Headers: headers}
+}
+
+const prBody = `{"action":"closed","pull_request":{"merged":true}}`
+
+// ---- tests ----
+
+func TestHandleDelivery_Outcomes(t *testing.T) {
+	cases := []struct {
+		name        string
+		handler     *fakeHandler
+		body        string
+		dropOn4xx   bool
+		maxRedeliv  int64
+		headers     amqp091.Table
+		wantAck     bool
+		wantRequeue bool
+	}{
+		{name: "success acks", handler: &fakeHandler{code: 202}, body: prBody, wantAck: true},
+		{name: "5xx requeues", handler: &fakeHandler{code: 500, err: errors.New("boom")}, body: prBody, wantRequeue: true},
+		{name: "bad envelope dropped", handler: &fakeHandler{}, body: "{{nope", dropOn4xx: true},
+		{name: "bad envelope kept", handler: &fakeHandler{}, body: "{{nope", wantRequeue: true},
+		{
+			name:       "max redeliveries dead-letters",
+			handler:    &fakeHandler{code: 500, err: errors.New("boom")},
+			body:       prBody,
+			maxRedeliv: 3,
+			headers:    amqp091.Table{"x-delivery-count": int64(3)},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ack := &fakeAck{}
+			w := &Worker{Queue: "q", Processor: tc.handler, DropOn4xx: tc.dropOn4xx, MaxRedeliveries: tc.maxRedeliv}
+			w.handleDelivery(context.Background(), delivery(ack, tc.body, tc.headers))
+
+			if ack.acked != tc.wantAck {
+				t.Fatalf("acked=%v, want %v", ack.acked, tc.wantAck)
+			}
+			if !tc.wantAck && (!ack.nacked || ack.requeue != tc.wantRequeue) {
+				t.Fatalf("nacked=%v requeue=%v, want requeue=%v", ack.nacked, ack.requeue, tc.wantRequeue)
+			}
+		})
+	}
+}
+
+func Test_deliveryCount(t *testing.T) {
+	if got := deliveryCount(&amqp091.Delivery{Headers: amqp091.Table{"x-delivery-count": int32(2)}}); got != 2 {
+		t.Fatalf("deliveryCount = %d, want 2", got)
+	}
+	if got := deliveryCount(&amqp091.Delivery{}); got != 0 {
+		t.Fatalf("deliveryCount without header = %d, want 0", got)
+	}
+}
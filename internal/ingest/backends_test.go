@@ -0,0 +1,45 @@
+package ingest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest/amqp"
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest/nats"
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest/redisstream"
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest/servicebus"
+	_ "github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest/sqs"
+)
+
+type nopHandler struct{}
+
+func (nopHandler) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	return 200, nil
+}
+
+// Each INGEST_MODE refuses to start without its required settings.
+func TestNew_BackendsRequireSettings(t *testing.T) {
+	cases := []struct {
+		mode string
+		cfg  config.Config
+		want string
+	}{
+		{mode: "amqp", cfg: config.Config{AMQPURL: "amqp://localhost"}, want: "AMQP_QUEUE"},
+		{mode: "nats", cfg: config.Config{NATSURL: "nats://127.0.0.1:4222"}, want: "Stream"},
+		{mode: "redis", cfg: config.Config{RedisStream: "s"}, want: "URL is required"},
+		{mode: "servicebus", cfg: config.Config{ServiceBusQueue: "q"}, want: "connection string"},
+		{mode: "sqs", cfg: config.Config{AWSRegion: "eu-north-1"}, want: "SQS_QUEUE_URL"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.mode, func(t *testing.T) {
+			_, err := ingest.New(context.Background(), tc.mode, &tc.cfg, nopHandler{})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("want error mentioning %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
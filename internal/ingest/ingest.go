@@ -0,0 +1,102 @@
+// Package ingest holds what every queue backend shares: the Handler contract
+// implemented by the processor and the envelope → handler dispatch.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
+	qparser "github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+)
+
+// Handler is implemented by the processor layer.
+// Return an HTTP-like status and an error (nil on success).
+type Handler interface {
+	HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error)
+}
+
+// GiveUpHandler is optionally implemented by a Handler to be told when a
+// backend stops retrying a delivery (e.g. after too many receives), so it can
+// tell the people involved instead of failing silently.
+type GiveUpHandler interface {
+	GiveUp(ctx context.Context, event, delivery string, payload []byte, attempts int, lastErr error)
+}
+
+var filteredEvents = metrics.Default.Counter("ingest_events_filtered_total",
+	"Deliveries dropped by the ingest event filter before processing.")
+
+// Dispatch parses a raw message body and hands it to h. msgID is used as the
+// delivery ID when the envelope does not carry one. Deliveries rejected by f
+// (nil allows all) are answered 204 without reaching h. It does not ack or
+// delete anything; callers decide based on the returned code (see ShouldAck).
+func Dispatch(ctx context.Context, h Handler, f qparser.Filter, msgBody []byte, msgID string) (int, error) {
+	event, delivery, payload, err := qparser.ParseSQSBody(msgBody)
+	if err != nil {
+		// Treat "unknown event" as a benign no-op (204, no error).
+		if errors.Is(err, qparser.ErrUnknownEvent) {
+			slog.Info("ingest.message.unknown_event", "messageID", msgID)
+			return 204, nil
+		}
+		// All other parse/shape errors are bad envelopes (400) so deletion
+		// policy can drop them to avoid poison loops.
+		slog.Error("ingest.message.bad_envelope", "err", err, "messageID", msgID)
+		return 400, err
+	}
+	if delivery == "" {
+		delivery = msgID
+	}
+	if len(payload) == 0 {
+		// Nothing useful to process.
+		return 204, nil
+	}
+	if !f.Allows(event, payload) {
+		filteredEvents.Inc("event", event)
+		slog.Debug("ingest.message.filtered", "event", event, "delivery", delivery)
+		return 204, nil
+	}
+
+	// Dispatch to the processor.
+	code, perr := h.HandleEvent(ctx, event, delivery, payload)
+	if code == 0 {
+		// Defensive default: success when processor forgot to set code.
+		if perr == nil {
+			code = 200
+		} else {
+			code = 500
+		}
+	}
+	return code, perr
+}
+
+// ShouldAck reports whether a message that produced code should be removed
+// from its queue: always on 2xx, on 4xx only when dropOn4xx is set.
+// 5xx/unknown are kept for redelivery.
+func ShouldAck(code int, dropOn4xx bool) bool {
+	switch {
+	case code >= 200 && code < 300:
+		return true
+	case code >= 400 && code < 500:
+		return dropOn4xx
+	default:
+		return false
+	}
+}
+
+// NotifyGiveUp parses msgBody like Dispatch and passes it to h's GiveUp when
+// h implements GiveUpHandler. Unparseable bodies are ignored.
+func NotifyGiveUp(ctx context.Context, h Handler, msgBody []byte, msgID string, attempts int, lastErr error) {
+	g, ok := h.(GiveUpHandler)
+	if !ok {
+		return
+	}
+	event, delivery, payload, err := qparser.ParseSQSBody(msgBody)
+	if err != nil || len(payload) == 0 {
+		return
+	}
+	if delivery == "" {
+		delivery = msgID
+	}
+	g.GiveUp(ctx, event, delivery, payload, attempts, lastErr)
+}
@@ -0,0 +1,105 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	qparser "github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+)
+
+type fakeHandler struct {
+	calls    int
+	delivery string
+	code     int
+	err      error
+}
+
+func (f *fakeHandler) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	f.calls++
+	f.delivery = delivery
+	return f.code, f.err
+}
+
+func TestDispatch_DefaultsCode(t *testing.T) {
+	body := []byte(`{"pull_request":{"number":1}}`)
+
+	h := &fakeHandler{}
+	code, err := Dispatch(context.Background(), h, nil, body, "m-1")
+	if err != nil || code != 200 {
+		t.Fatalf("got code=%d err=%v, want 200", code, err)
+	}
+	if h.delivery != "m-1" {
+		t.Fatalf("expected message id fallback for delivery, got %q", h.delivery)
+	}
+
+	h = &fakeHandler{err: errors.New("boom")}
+	if code, _ = Dispatch(context.Background(), h, nil, body, "m-2"); code != 500 {
+		t.Fatalf("got code=%d, want 500 when handler errs without code", code)
+	}
+}
+
+func TestDispatch_Filter(t *testing.T) {
+	f, err := qparser.ParseFilter("pull_request:closed|labeled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &fakeHandler{code: 202}
+
+	sync := []byte(`{"headers":{"X-GitHub-Event":"pull_request"},"body":{"action":"synchronize","pull_request":{}}}`)
+	before := filteredEvents.Value("event", "pull_request")
+	if code, err := Dispatch(context.Background(), h, f, sync, "m-1"); code != 204 || err != nil {
+		t.Fatalf("filtered delivery: code=%d err=%v, want 204", code, err)
+	}
+	if h.calls != 0 {
+		t.Fatalf("handler called for filtered delivery")
+	}
+	if got := filteredEvents.Value("event", "pull_request"); got != before+1 {
+		t.Fatalf("filtered counter = %v, want %v", got, before+1)
+	}
+
+	closed := []byte(`{"headers":{"X-GitHub-Event":"pull_request"},"body":{"action":"closed","pull_request":{}}}`)
+	if code, _ := Dispatch(context.Background(), h, f, closed, "m-2"); code != 202 || h.calls != 1 {
+		t.Fatalf("allowed delivery: code=%d calls=%d", code, h.calls)
+	}
+}
+
+func TestShouldAck(t *testing.T) {
+	tests := []struct {
+		code      int
+		dropOn4xx bool
+		want      bool
+	}{
+		{code: 200, want: true},
+		{code: 204, want: true},
+		{code: 400, dropOn4xx: true, want: true},
+		{code: 400, dropOn4xx: false, want: false},
+		{code: 500, dropOn4xx: true, want: false},
+		{code: 0, want: false},
+	}
+	for _, tt := range tests {
+		if got := ShouldAck(tt.code, tt.dropOn4xx); got != tt.want {
+			t.Errorf("ShouldAck(%d, %v) = %v, want %v", tt.code, tt.dropOn4xx, got, tt.want)
+		}
+	}
+}
+
+type giveUpHandler struct {
+	fakeHandler
+	event    string
+	attempts int
+}
+
+func (g *giveUpHandler) GiveUp(ctx context.Context, event, delivery string, payload []byte, attempts int, lastErr error) {
+	g.event, g.delivery, g.attempts = event, delivery, attempts
+}
+
+func TestNotifyGiveUp(t *testing.T) {
+	g := &giveUpHandler{}
+	NotifyGiveUp(context.Background(), g, []byte(`{"pull_request":{"number":1}}`), "m-1", 5, errors.New("boom"))
+	if g.event != "pull_request" || g.delivery != "m-1" || g.attempts != 5 {
+		t.Fatalf("GiveUp got event=%q delivery=%q attempts=%d", g.event, g.delivery, g.attempts)
+	}
+	// Handlers without GiveUp are left alone.
+	NotifyGiveUp(context.Background(), &fakeHandler{}, []byte(`{"pull_request":{}}`), "m-2", 5, nil)
+}
@@ -0,0 +1,43 @@
+package nats
+
+import (
+	"context"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+)
+
+func init() { ingest.Register("nats", NewSource) }
+
+type source struct {
+	worker *Worker
+	close  func()
+}
+
+// NewSource connects to JetStream and binds the durable consumer.
+func NewSource(ctx context.Context, cfg *config.Config, h ingest.Handler) (ingest.Source, error) {
+	cons, closeFn, err := Connect(ctx, ConsumerConfig{
+		URL:           cfg.NATSURL,
+		Stream:        cfg.NATSStream,
+		Durable:       cfg.NATSDurable,
+		FilterSubject: cfg.NATSSubject,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &source{
+		worker: &Worker{
+			Consumer:   cons,
+			DropOn4xx:  cfg.IngestDropOn4xx,
+			MaxDeliver: cfg.IngestMaxDeliveries,
+			Filter:     cfg.EventFilter,
+			Processor:  h,
+		},
+		close: closeFn,
+	}, nil
+}
+
+func (s *source) Run(ctx context.Context) error {
+	defer s.close()
+	return s.worker.Run(ctx)
+}
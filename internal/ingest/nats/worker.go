@@ -0,0 +1,152 @@
+// Package nats consumes webhook envelopes from a NATS JetStream durable
+// consumer and dispatches them to the processor, with no AWS dependencies.
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+)
+
+// ConsumerConfig describes the durable pull consumer the worker binds to.
+type ConsumerConfig struct {
+	URL           string        // nats://host:4222
+	Stream        string        // existing JetStream stream holding webhook envelopes
+	Durable       string        // durable consumer name (shared by all replicas)
+	FilterSubject string        // optional subject filter, e.g. "github.webhooks.>"
+	AckWait       time.Duration // redelivery timeout for unacked messages
+	MaxDeliver    int           // server-side delivery cap (-1 = unlimited)
+}
+
+// Connect dials NATS and creates (or updates) the durable consumer.
+// The returned close func drains the connection.
+func Connect(ctx context.Context, cfg ConsumerConfig) (jetstream.Consumer, func(), error) {
+	if cfg.URL == "" || cfg.Stream == "" || cfg.Durable == "" {
+		return nil, nil, errors.New("nats: URL, Stream and Durable are required")
+	}
+	nc, err := natsgo.Connect(cfg.URL, natsgo.Name("gh-app-cherry-pick"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("nats connect: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, nil, fmt.Errorf("jetstream: %w", err)
+	}
+	cons, err := js.CreateOrUpdateConsumer(ctx, cfg.Stream, jetstream.ConsumerConfig{
+		Durable:       cfg.Durable,
+		FilterSubject: cfg.FilterSubject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       cfg.AckWait,
+		MaxDeliver:    cfg.MaxDeliver,
+	})
+	if err != nil {
+		nc.Close()
+		return nil, nil, fmt.Errorf("jetstream consumer %s/%s: %w", cfg.Stream, cfg.Durable, err)
+	}
+	return cons, func() { _ = nc.Drain() }, nil
+}
+
+// Worker fetches batches from a JetStream consumer, parses message envelopes
+// (same formats as SQS, see internal/queue) and dispatches to a Handler.
+type Worker struct {
+	Consumer   jetstream.Consumer
+	BatchSize  int           // messages per fetch (default 10)
+	MaxWait    time.Duration // fetch long-poll (default 10s)
+	DropOn4xx  bool          // Term() 4xx outcomes instead of redelivering
+	MaxDeliver int           // Term() after this many deliveries (0 = rely on server)
+	RetryDelay time.Duration // NakWithDelay for 5xx (default 10s)
+	Filter     queue.Filter  // ingest-time event/action allowlist (nil = all)
+	Processor  ingest.Handler
+}
+
+// Run fetches and processes messages until ctx is canceled.
+func (w *Worker) Run(ctx context.Context) error {
+	if w.Consumer == nil || w.Processor == nil {
+		return errors.New("nats.Worker: missing Consumer or Processor")
+	}
+	batch := w.BatchSize
+	if batch <= 0 {
+		batch = 10
+	}
+	maxWait := w.MaxWait
+	if maxWait <= 0 {
+		maxWait = 10 * time.Second
+	}
+	slog.Info("nats.worker.start", "batch", batch, "maxWait", maxWait, "dropOn4xx", w.DropOn4xx, "maxDeliver", w.MaxDeliver)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("nats.worker.stop", "reason", "context_done")
+			return ctx.Err()
+		default:
+		}
+
+		msgs, err := w.Consumer.Fetch(batch, jetstream.FetchMaxWait(maxWait))
+		if err != nil {
+			slog.Error("nats.fetch.error", "err", err)
+			// small backoff to avoid a hot loop on persistent errors
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(2 * time.Second):
+			}
+			continue
+		}
+		for m := range msgs.Messages() {
+			w.handleMsg(ctx, m)
+		}
+		if err := msgs.Error(); err != nil && !errors.Is(err, jetstream.ErrNoMessages) && !errors.Is(err, natsgo.ErrTimeout) {
+			slog.Warn("nats.fetch.batch_error", "err", err)
+		}
+	}
+}
+
+// handleMsg dispatches one message and settles it: Ack on success, Term on
+// dropped 4xx or exhausted deliveries, NakWithDelay otherwise.
+func (w *Worker) handleMsg(ctx context.Context, m jetstream.Msg) {
+	msgID := m.Subject()
+	delivered := uint64(1)
+	if md, err := m.Metadata(); err == nil && md != nil {
+		msgID = fmt.Sprintf("%s/%d", md.Stream, md.Sequence.Stream)
+		delivered = md.NumDelivered
+	}
+
+	code, procErr := ingest.Dispatch(ctx, w.Processor, w.Filter, m.Data(), msgID)
+	ack := ingest.ShouldAck(code, w.DropOn4xx)
+
+	var settleErr error
+	switch {
+	case ack && code >= 400:
+		settleErr = m.TermWithReason(fmt.Sprintf("status %d", code))
+	case ack:
+		settleErr = m.Ack()
+	case w.MaxDeliver > 0 && delivered >= uint64(w.MaxDeliver):
+		slog.Error("nats.message.max_deliver_reached", "messageID", msgID, "delivered", delivered, "status", code)
+		settleErr = m.TermWithReason("max deliver reached")
+	default:
+		delay := w.RetryDelay
+		if delay <= 0 {
+			delay = 10 * time.Second
+		}
+		settleErr = m.NakWithDelay(delay)
+	}
+
+	if procErr != nil {
+		slog.Warn("nats.message.process_error", "status", code, "err", procErr, "ack", ack, "delivered", delivered, "messageID", msgID)
+	} else {
+		slog.Info("nats.message.processed", "status", code, "ack", ack, "delivered", delivered, "messageID", msgID)
+	}
+	if settleErr != nil {
+		slog.Error("nats.message.settle_error", "err", settleErr, "messageID", msgID)
+	}
+}
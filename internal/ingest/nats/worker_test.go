@@ -0,0 +1,126 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// ---- fakes ----
+
+type fakeHandler struct {
+	lastEvent    string
+	lastDelivery string
+
+	code int
+	err  error
+}
+
+func (f *fakeHandler) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	f.lastEvent, f.lastDelivery = event, delivery
+	return f.code, f.err
+}
+
+// fakeMsg implements the subset of jetstream.Msg the worker touches; the
+// embedded interface panics on anything else.
+type fakeMsg struct {
+	jetstream.Msg
+
+	data      []byte
+	delivered uint64
+
+	acked  bool
+	naked  bool
+	termed bool
+}
+
+func (m *fakeMsg) Data() []byte    { return m.data }
+func (m *fakeMsg) Subject() string { return "github.webhooks" }
+func (m *fakeMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{
+		Stream:       "GITHUB",
+		Sequence:     jetstream.SequencePair{Stream: 42},
+		NumDelivered: m.delivered,
+	}, nil
+}
+func (m *fakeMsg) Ack() error                         { m.acked = true; return nil }
+func (m *fakeMsg) NakWithDelay(_ time.Duration) error { m.naked = true; return nil }
+func (m *fakeMsg) TermWithReason(reason string) error { m.termed = true; return nil }
+
+func prPayload(t *testing.T) []byte {
+	t.Helper()
+	raw, err := json.Marshal(map[string]any{
+		"action":       "closed",
+		"pull_request": map[string]any{"merged": true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// ---- tests ----
+
+func TestHandleMsg_SuccessAcks(t *testing.T) {
+	fh := &fakeHandler{code: 202}
+	w := &Worker{Processor: fh}
+	m := &fakeMsg{data: prPayload(t), delivered: 1}
+
+	w.handleMsg(context.Background(), m)
+
+	if !m.acked || m.naked || m.termed {
+		t.Fatalf("expected ack only, got %+v", m)
+	}
+	if fh.lastEvent != "pull_request" || fh.lastDelivery != "GITHUB/42" {
+		t.Fatalf("unexpected dispatch: event=%q delivery=%q", fh.lastEvent, fh.lastDelivery)
+	}
+}
+
+func TestHandleMsg_ServerErrorNaks(t *testing.T) {
+	w := &Worker{Processor: &fakeHandler{code: 500, err: errors.New("boom")}}
+	m := &fakeMsg{data: prPayload(t), delivered: 1}
+
+	w.handleMsg(context.Background(), m)
+
+	if !m.naked || m.acked || m.termed {
+		t.Fatalf("expected nak for redelivery, got %+v", m)
+	}
+}
+
+func TestHandleMsg_MaxDeliverTerms(t *testing.T) {
+	w := &Worker{Processor: &fakeHandler{code: 500, err: errors.New("boom")}, MaxDeliver: 3}
+	m := &fakeMsg{data: prPayload(t), delivered: 3}
+
+	w.handleMsg(context.Background(), m)
+
+	if !m.termed || m.naked {
+		t.Fatalf("expected term after max deliveries, got %+v", m)
+	}
+}
+
+func TestHandleMsg_BadEnvelope(t *testing.T) {
+	cases := []struct {
+		name      string
+		dropOn4xx bool
+		wantTerm  bool
+	}{
+		{name: "drop", dropOn4xx: true, wantTerm: true},
+		{name: "keep", dropOn4xx: false, wantTerm: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &Worker{Processor: &fakeHandler{code: 200}, DropOn4xx: tc.dropOn4xx}
+			m := &fakeMsg{data: []byte("{{not json"), delivered: 1}
+
+			w.handleMsg(context.Background(), m)
+
+			if m.termed != tc.wantTerm || m.naked == tc.wantTerm {
+				t.Fatalf("unexpected settlement: %+v", m)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package ingest
+
+import (
+	"encoding/json"
+
+	qparser "github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+)
+
+// Priority classes for deliveries received together; lower is dispatched
+// first. Backends that receive batches order them by Priority so label
+// retention and cleanup never delay a cherry-pick somebody is waiting for.
+const (
+	PriorityUser         = iota // merged-PR events and slash commands
+	PriorityDefault             // everything else
+	PriorityHousekeeping        // label creation/retention, cleanup, scheduled tasks
+)
+
+var housekeepingEvents = map[string]bool{
+	"create":                   true,
+	"delete":                   true,
+	"label":                    true,
+	qparser.EventScheduledTask: true,
+}
+
+// Priority classifies a raw message body (any envelope ParseSQSBody
+// accepts). Unparseable bodies get PriorityDefault; Dispatch rejects them
+// anyway.
+func Priority(msgBody []byte) int {
+	event, _, payload, err := qparser.ParseSQSBody(msgBody)
+	if err != nil {
+		return PriorityDefault
+	}
+	switch {
+	case housekeepingEvents[event]:
+		return PriorityHousekeeping
+	case event == "issue_comment":
+		return PriorityUser
+	case event == "pull_request":
+		var pr struct {
+			PullRequest struct {
+				Merged bool `json:"merged"`
+			} `json:"pull_request"`
+		}
+		if json.Unmarshal(payload, &pr) == nil && pr.PullRequest.Merged {
+			return PriorityUser
+		}
+	}
+	return PriorityDefault
+}
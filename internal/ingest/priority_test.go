@@ -0,0 +1,26 @@
+package ingest
+
+import "testing"
+
+func TestPriority(t *testing.T) {
+	env := func(event, body string) []byte {
+		return []byte(`{"headers":{"X-GitHub-Event":"` + event + `"},"body":` + body + `}`)
+	}
+	for name, tc := range map[string]struct {
+		body []byte
+		want int
+	}{
+		"merged PR":     {env("pull_request", `{"action":"closed","pull_request":{"merged":true}}`), PriorityUser},
+		"open PR":       {env("pull_request", `{"action":"labeled","pull_request":{"merged":false}}`), PriorityDefault},
+		"slash command": {env("issue_comment", `{"action":"created","comment":{},"issue":{}}`), PriorityUser},
+		"branch create": {env("create", `{"ref":"team-release/0001","ref_type":"branch"}`), PriorityHousekeeping},
+		"label delete":  {env("label", `{"action":"deleted","label":{}}`), PriorityHousekeeping},
+		"scheduled":     {env("scheduled_task", `{"scheduled_task":"janitor"}`), PriorityHousekeeping},
+		"push":          {env("push", `{"ref":"refs/heads/main"}`), PriorityDefault},
+		"garbage":       {[]byte("{{"), PriorityDefault},
+	} {
+		if got := Priority(tc.body); got != tc.want {
+			t.Errorf("%s: Priority = %d, want %d", name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package redisstream
+
+import (
+	"context"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+)
+
+func init() { ingest.Register("redis", NewSource) }
+
+type source struct {
+	worker *Worker
+	close  func() error
+}
+
+// NewSource connects to REDIS_STREAM_URL and joins the consumer group as
+// this replica. Exhausted entries go to "<stream>:dead".
+func NewSource(ctx context.Context, cfg *config.Config, h ingest.Handler) (ingest.Source, error) {
+	c, err := Dial(ctx, cfg.RedisStreamURL)
+	if err != nil {
+		return nil, err
+	}
+	return &source{
+		worker: &Worker{
+			Client:           c,
+			Stream:           cfg.RedisStream,
+			Group:            cfg.RedisStreamGroup,
+			Consumer:         cfg.ReplicaID,
+			DropOn4xx:        cfg.IngestDropOn4xx,
+			MaxDeliveries:    int64(cfg.IngestMaxDeliveries),
+			DeadLetterStream: cfg.RedisStream + ":dead",
+			Filter:           cfg.EventFilter,
+			Processor:        h,
+		},
+		close: c.Close,
+	}, nil
+}
+
+func (s *source) Run(ctx context.Context) error {
+	defer func() { _ = s.close() }()
+	return s.worker.Run(ctx)
+}
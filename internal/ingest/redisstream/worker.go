@@ -0,0 +1,284 @@
+// Package redisstream consumes webhook envelopes from a Redis Stream through a
+// consumer group. It is the lightweight ingest path for small deployments that
+// already run Redis and do not want a cloud queue.
+package redisstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+)
+
+// streamClient is the subset of *redis.Client the worker needs (test seam).
+type streamClient interface {
+	XGroupCreateMkStream(ctx context.Context, stream, group, start string) *redis.StatusCmd
+	XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd
+	XAutoClaim(ctx context.Context, a *redis.XAutoClaimArgs) *redis.XAutoClaimCmd
+	XPendingExt(ctx context.Context, a *redis.XPendingExtArgs) *redis.XPendingExtCmd
+	XAck(ctx context.Context, stream, group string, ids ...string) *redis.IntCmd
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+}
+
+// Dial parses a redis:// URL and checks the server is reachable.
+func Dial(ctx context.Context, url string) (*redis.Client, error) {
+	if url == "" {
+		return nil, errors.New("redisstream: URL is required")
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("redis url: %w", err)
+	}
+	c := redis.NewClient(opts)
+	if err := c.Ping(ctx).Err(); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("redis ping: %w", err)
+	}
+	return c, nil
+}
+
+// Worker reads a stream as one consumer of a group, converts entries to
+// queue envelopes and dispatches them to a Handler.
+//
+// Entries that are not acknowledged (5xx, or 4xx without DropOn4xx) stay in
+// the group's pending list and are reclaimed with XAUTOCLAIM once idle for
+// MinIdle, by this or any other consumer. Producers should cap the stream
+// with XADD MAXLEN, since acknowledged entries are not deleted.
+type Worker struct {
+	Client    streamClient
+	Stream    string
+	Group     string
+	Consumer  string        // unique per replica, e.g. the hostname
+	BatchSize int64         // entries per read (default 10)
+	Block     time.Duration // XREADGROUP block time (default 5s)
+
+	MinIdle         time.Duration // reclaim pending entries idle this long (default 5m)
+	ReclaimInterval time.Duration // how often to look for them (default 30s)
+
+	DropOn4xx bool // acknowledge 4xx outcomes instead of leaving them pending
+	// MaxDeliveries dead-letters an entry instead of processing it once more
+	// after it has been delivered this many times (0 = retry forever).
+	MaxDeliveries int64
+	// DeadLetterStream receives dead-lettered entries; empty just drops them.
+	DeadLetterStream string
+
+	Filter    queue.Filter // ingest-time event/action allowlist (nil = all)
+	Processor ingest.Handler
+}
+
+// Run creates the group if needed and processes entries until ctx is canceled.
+func (w *Worker) Run(ctx context.Context) error {
+	if w.Client == nil || w.Stream == "" || w.Group == "" || w.Consumer == "" || w.Processor == nil {
+		return errors.New("redisstream.Worker: missing Client, Stream, Group, Consumer or Processor")
+	}
+	// Start at 0 so envelopes queued before the first worker ran are not lost.
+	if err := w.Client.XGroupCreateMkStream(ctx, w.Stream, w.Group, "0").Err(); err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("redis xgroup create %s/%s: %w", w.Stream, w.Group, err)
+	}
+	batch := w.BatchSize
+	if batch <= 0 {
+		batch = 10
+	}
+	block := w.Block
+	if block <= 0 {
+		block = 5 * time.Second
+	}
+	reclaimEvery := w.ReclaimInterval
+	if reclaimEvery <= 0 {
+		reclaimEvery = 30 * time.Second
+	}
+	slog.Info("redis.worker.start", "stream", w.Stream, "group", w.Group, "consumer", w.Consumer, "batch", batch, "maxDeliveries", w.MaxDeliveries)
+
+	var lastReclaim time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("redis.worker.stop", "reason", "context_done")
+			return ctx.Err()
+		default:
+		}
+
+		if time.Since(lastReclaim) >= reclaimEvery {
+			lastReclaim = time.Now()
+			w.reclaim(ctx, batch)
+		}
+
+		streams, err := w.Client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    w.Group,
+			Consumer: w.Consumer,
+			Streams:  []string{w.Stream, ">"},
+			Count:    batch,
+			Block:    block,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				continue
+			}
+			slog.Error("redis.read.error", "err", err)
+			// small backoff to avoid a hot loop on persistent errors
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(2 * time.Second):
+			}
+			continue
+		}
+		for _, s := range streams {
+			for _, m := range s.Messages {
+				w.handleMessage(ctx, m, 1)
+			}
+		}
+	}
+}
+
+// reclaim takes over entries that have been pending longer than MinIdle
+// (crashed consumer or failed processing) and processes them again.
+func (w *Worker) reclaim(ctx context.Context, batch int64) {
+	minIdle := w.MinIdle
+	if minIdle <= 0 {
+		minIdle = 5 * time.Minute
+	}
+	msgs, _, err := w.Client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   w.Stream,
+		Group:    w.Group,
+		Consumer: w.Consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    batch,
+	}).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("redis.reclaim.error", "stream", w.Stream, "err", err)
+		}
+		return
+	}
+	if len(msgs) == 0 {
+		return
+	}
+	counts := w.deliveryCounts(ctx, msgs)
+	slog.Info("redis.reclaim", "stream", w.Stream, "claimed", len(msgs))
+	for _, m := range msgs {
+		w.handleMessage(ctx, m, counts[m.ID])
+	}
+}
+
+// deliveryCounts looks up how often each claimed entry has been delivered.
+// Only needed (and only queried) when MaxDeliveries is enforced.
+func (w *Worker) deliveryCounts(ctx context.Context, msgs []redis.XMessage) map[string]int64 {
+	counts := make(map[string]int64, len(msgs))
+	if w.MaxDeliveries <= 0 {
+		return counts
+	}
+	pending, err := w.Client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   w.Stream,
+		Group:    w.Group,
+		Consumer: w.Consumer,
+		Start:    msgs[0].ID,
+		End:      msgs[len(msgs)-1].ID,
+		// Our own recently failed entries may sit in the same ID range.
+		Count: int64(2 * len(msgs)),
+	}).Result()
+	if err != nil {
+		slog.Warn("redis.pending.error", "stream", w.Stream, "err", err)
+		return counts
+	}
+	for _, p := range pending {
+		counts[p.ID] = p.RetryCount
+	}
+	return counts
+}
+
+// handleMessage dispatches one entry and settles it: XACK on success or a
+// dropped 4xx, dead-letter once MaxDeliveries is reached, otherwise leave it
+// pending for reclaim.
+func (w *Worker) handleMessage(ctx context.Context, m redis.XMessage, delivered int64) {
+	if w.MaxDeliveries > 0 && delivered > w.MaxDeliveries {
+		slog.Error("redis.message.max_deliveries_reached", "messageID", m.ID, "delivered", delivered)
+		w.deadLetter(ctx, m, "max_deliveries")
+		return
+	}
+
+	body, convErr := toEnvelope(m.Values)
+	code, procErr := 400, convErr
+	if convErr == nil {
+		code, procErr = ingest.Dispatch(ctx, w.Processor, w.Filter, body, m.ID)
+	}
+	ack := ingest.ShouldAck(code, w.DropOn4xx)
+
+	if procErr != nil {
+		slog.Warn("redis.message.process_error", "status", code, "err", procErr, "ack", ack, "delivered", delivered, "messageID", m.ID)
+	} else {
+		slog.Info("redis.message.processed", "status", code, "ack", ack, "delivered", delivered, "messageID", m.ID)
+	}
+	if !ack {
+		return
+	}
+	if err := w.Client.XAck(ctx, w.Stream, w.Group, m.ID).Err(); err != nil {
+		slog.Error("redis.message.ack_error", "err", err, "messageID", m.ID)
+	}
+}
+
+func (w *Worker) deadLetter(ctx context.Context, m redis.XMessage, reason string) {
+	if w.DeadLetterStream != "" {
+		values := make(map[string]any, len(m.Values)+2)
+		for k, v := range m.Values {
+			values[k] = v
+		}
+		values["dead_letter_reason"] = reason
+		values["source_id"] = m.ID
+		if err := w.Client.XAdd(ctx, &redis.XAddArgs{Stream: w.DeadLetterStream, Values: values}).Err(); err != nil {
+			// Keep it pending rather than losing it.
+			slog.Error("redis.message.dead_letter_error", "err", err, "messageID", m.ID)
+			return
+		}
+	}
+	if err := w.Client.XAck(ctx, w.Stream, w.Group, m.ID).Err(); err != nil {
+		slog.Error("redis.message.ack_error", "err", err, "messageID", m.ID)
+	}
+}
+
+// toEnvelope converts stream entry fields into a body internal/queue can parse.
+// Two layouts are accepted:
+//
+//   - "body": a complete message body, exactly as it would be sent to SQS
+//     (a queue.Envelope or raw GitHub JSON);
+//   - "payload" with optional "event", "delivery" and "signature" fields,
+//     mapped onto the corresponding X-GitHub-* headers of a queue.Envelope.
+func toEnvelope(values map[string]any) ([]byte, error) {
+	field := func(k string) string {
+		if v, ok := values[k].(string); ok {
+			return v
+		}
+		return ""
+	}
+	if b := field("body"); b != "" {
+		return []byte(b), nil
+	}
+	payload := field("payload")
+	if payload == "" {
+		return nil, errors.New("stream entry has neither body nor payload field")
+	}
+	headers := map[string]string{}
+	for k, h := range map[string]string{
+		"event":     "X-GitHub-Event",
+		"delivery":  "X-GitHub-Delivery",
+		"signature": "X-Hub-Signature-256",
+	} {
+		if v := field(k); v != "" {
+			headers[h] = v
+		}
+	}
+	b, err := json.Marshal(queue.Envelope{Headers: headers, Body: json.RawMessage(payload)})
+	if err != nil {
+		return nil, fmt.Errorf("stream entry payload: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,151 @@
+package redisstream
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ---- fakes ----
+
+type fakeHandler struct {
+	code     int
+	err      error
+	event    string
+	delivery string
+	calls    int
+}
+
+func (f *fakeHandler) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	f.calls++
+	f.event, f.delivery = event, delivery
+	return f.code, f.err
+}
+
+type fakeClient struct {
+	claimed []redis.XMessage
+	pending []redis.XPendingExt
+
+	acked      []string
+	deadLetter []*redis.XAddArgs
+}
+
+func (f *fakeClient) XGroupCreateMkStream(ctx context.Context, stream, group, start string) *redis.StatusCmd {
+	return redis.NewStatusResult("OK", nil)
+}
+func (f *fakeClient) XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd {
+	return redis.NewXStreamSliceCmdResult(nil, redis.Nil)
+}
+func (f *fakeClient) XAutoClaim(ctx context.Context, a *redis.XAutoClaimArgs) *redis.XAutoClaimCmd {
+	cmd := redis.NewXAutoClaimCmd(ctx)
+	cmd.SetVal(f.claimed, "0-0")
+	return cmd
+}
+func (f *fakeClient) XPendingExt(ctx context.Context, a *redis.XPendingExtArgs) *redis.XPendingExtCmd {
+	cmd := redis.NewXPendingExtCmd(ctx)
+	cmd.SetVal(f.pending)
+	return cmd
+}
+func (f *fakeClient) XAck(ctx context.Context, stream, group string, ids ...string) *redis.IntCmd {
+	f.acked = append(f.acked, ids...)
+	return redis.NewIntResult(int64(len(ids)), nil)
+}
+func (f *fakeClient) XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd {
+	f.deadLetter = append(f.deadLetter, a)
+	return redis.NewStringResult("1-1", nil)
+}
+
+const prPayload = `{"action":"closed","pull_request":{"merged":true}}`
+
+func entry(id string, values map[string]any) redis.XMessage {
+	return redis.XMessage{ID: id, Values: values}
+}
+
+// ---- tests ----
+
+func TestHandleMessage_Outcomes(t *testing.T) {
+	cases := []struct {
+		name      string
+		handler   *fakeHandler
+		values    map[string]any
+		dropOn4xx bool
+		wantAck   bool
+	}{
+		{name: "success acks", handler: &fakeHandler{code: 202}, values: map[string]any{"body": prPayload}, wantAck: true},
+		{name: "5xx stays pending", handler: &fakeHandler{code: 500, err: errors.New("boom")}, values: map[string]any{"body": prPayload}},
+		{name: "bad entry dropped", handler: &fakeHandler{}, values: map[string]any{"other": "x"}, dropOn4xx: true, wantAck: true},
+		{name: "bad entry kept", handler: &fakeHandler{}, values: map[string]any{"payload": "{{nope"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &fakeClient{}
+			w := &Worker{Client: c, Stream: "s", Group: "g", Consumer: "c1", Processor: tc.handler, DropOn4xx: tc.dropOn4xx}
+			w.handleMessage(context.Background(), entry("1-0", tc.values), 1)
+
+			if got := len(c.acked) == 1; got != tc.wantAck {
+				t.Fatalf("acked=%v, want %v", c.acked, tc.wantAck)
+			}
+		})
+	}
+}
+
+func TestHandleMessage_FieldsMapToEnvelope(t *testing.T) {
+	h := &fakeHandler{code: 202}
+	c := &fakeClient{}
+	w := &Worker{Client: c, Stream: "s", Group: "g", Consumer: "c1", Processor: h}
+
+	w.handleMessage(context.Background(), entry("7-0", map[string]any{
+		"event":    "pull_request",
+		"delivery": "d-123",
+		"payload":  prPayload,
+	}), 1)
+
+	if h.event != "pull_request" || h.delivery != "d-123" {
+		t.Fatalf("event/delivery = %q/%q", h.event, h.delivery)
+	}
+
+	// Without a delivery field the entry ID is used.
+	w.handleMessage(context.Background(), entry("8-0", map[string]any{"payload": prPayload}), 1)
+	if h.delivery != "8-0" {
+		t.Fatalf("delivery fallback = %q, want entry ID", h.delivery)
+	}
+}
+
+func TestReclaim_ProcessesAndDeadLetters(t *testing.T) {
+	h := &fakeHandler{code: 202}
+	c := &fakeClient{
+		claimed: []redis.XMessage{
+			entry("1-0", map[string]any{"body": prPayload}),
+			entry("2-0", map[string]any{"body": prPayload}),
+		},
+		pending: []redis.XPendingExt{
+			{ID: "1-0", RetryCount: 2},
+			{ID: "2-0", RetryCount: 4},
+		},
+	}
+	w := &Worker{Client: c, Stream: "s", Group: "g", Consumer: "c1", Processor: h, MaxDeliveries: 3, DeadLetterStream: "s:dead"}
+
+	w.reclaim(context.Background(), 10)
+
+	if h.calls != 1 {
+		t.Fatalf("handler calls = %d, want 1 (second entry exhausted)", h.calls)
+	}
+	if len(c.deadLetter) != 1 || c.deadLetter[0].Stream != "s:dead" {
+		t.Fatalf("dead letters = %+v", c.deadLetter)
+	}
+	if v := c.deadLetter[0].Values.(map[string]any); v["source_id"] != "2-0" || v["dead_letter_reason"] != "max_deliveries" {
+		t.Fatalf("dead letter values = %+v", v)
+	}
+	if len(c.acked) != 2 {
+		t.Fatalf("acked = %v, want both entries", c.acked)
+	}
+}
+
+func TestRun_RequiresFields(t *testing.T) {
+	w := &Worker{Client: &fakeClient{}, Stream: "s"}
+	if err := w.Run(context.Background()); err == nil {
+		t.Fatalf("expected error for missing Group/Consumer/Processor")
+	}
+}
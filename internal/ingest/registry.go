@@ -0,0 +1,62 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+)
+
+// Source is a running ingest backend: it pulls envelopes from its queue and
+// dispatches them to a Handler until ctx is canceled, then releases its
+// connections.
+type Source interface {
+	Run(ctx context.Context) error
+}
+
+// Factory builds a Source from the app config. It should validate its own
+// settings and connect, so misconfiguration fails at startup.
+type Factory func(ctx context.Context, cfg *config.Config, h Handler) (Source, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a backend selectable by name (INGEST_MODE). Backends call it
+// from init; registering the same name twice panics, as with database/sql.
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	name = strings.ToLower(name)
+	if _, dup := registry[name]; dup {
+		panic("ingest: Register called twice for " + name)
+	}
+	registry[name] = f
+}
+
+// New builds the backend registered under name.
+func New(ctx context.Context, name string, cfg *config.Config, h Handler) (Source, error) {
+	registryMu.RLock()
+	f, ok := registry[strings.ToLower(name)]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("ingest: unknown INGEST_MODE %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return f(ctx, cfg, h)
+}
+
+// Names lists registered backends, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]string, 0, len(registry))
+	for n := range registry {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
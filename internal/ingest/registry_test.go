@@ -0,0 +1,40 @@
+package ingest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+)
+
+type fakeSource struct{ h Handler }
+
+func (fakeSource) Run(ctx context.Context) error { return nil }
+
+func TestRegistry(t *testing.T) {
+	Register("test-registry", func(ctx context.Context, cfg *config.Config, h Handler) (Source, error) {
+		return fakeSource{h: h}, nil
+	})
+
+	h := &fakeHandler{}
+	src, err := New(context.Background(), "Test-Registry", &config.Config{}, h)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if fs, ok := src.(fakeSource); !ok || fs.h != h {
+		t.Fatalf("factory not used: %#v", src)
+	}
+
+	_, err = New(context.Background(), "kafka-nope", &config.Config{}, h)
+	if err == nil || !strings.Contains(err.Error(), "test-registry") {
+		t.Fatalf("want unknown-mode error listing backends, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("duplicate Register should panic")
+		}
+	}()
+	Register("test-registry", nil)
+}
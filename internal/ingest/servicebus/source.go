@@ -0,0 +1,43 @@
+package servicebus
+
+import (
+	"context"
+	"math"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+)
+
+func init() { ingest.Register("servicebus", NewSource) }
+
+type source struct {
+	worker *Worker
+	close  func()
+}
+
+// NewSource opens a peek-lock receiver for SERVICEBUS_QUEUE.
+func NewSource(ctx context.Context, cfg *config.Config, h ingest.Handler) (ingest.Source, error) {
+	r, closeFn, err := NewReceiver(ctx, cfg.ServiceBusConnectionString, cfg.ServiceBusQueue)
+	if err != nil {
+		return nil, err
+	}
+	var maxDeliveries uint32
+	if n := cfg.IngestMaxDeliveries; n > 0 && n <= math.MaxUint32 {
+		maxDeliveries = uint32(n)
+	}
+	return &source{
+		worker: &Worker{
+			Receiver:      r,
+			DropOn4xx:     cfg.IngestDropOn4xx,
+			MaxDeliveries: maxDeliveries,
+			Filter:        cfg.EventFilter,
+			Processor:     h,
+		},
+		close: closeFn,
+	}, nil
+}
+
+func (s *source) Run(ctx context.Context) error {
+	defer s.close()
+	return s.worker.Run(ctx)
+}
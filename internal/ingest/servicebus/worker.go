@@ -0,0 +1,175 @@
+// Package servicebus consumes webhook envelopes from an Azure Service Bus
+// queue in peek-lock mode and dispatches them to the processor.
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+)
+
+// receiver is the subset of *azservicebus.Receiver the worker needs (test seam).
+type receiver interface {
+	ReceiveMessages(ctx context.Context, maxMessages int, options *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error)
+	RenewMessageLock(ctx context.Context, msg *azservicebus.ReceivedMessage, options *azservicebus.RenewMessageLockOptions) error
+	CompleteMessage(ctx context.Context, msg *azservicebus.ReceivedMessage, options *azservicebus.CompleteMessageOptions) error
+	AbandonMessage(ctx context.Context, msg *azservicebus.ReceivedMessage, options *azservicebus.AbandonMessageOptions) error
+	DeadLetterMessage(ctx context.Context, msg *azservicebus.ReceivedMessage, options *azservicebus.DeadLetterOptions) error
+}
+
+// NewReceiver builds a peek-lock queue receiver from a connection string.
+// The returned close func closes the receiver and the client.
+func NewReceiver(ctx context.Context, connString, queue string) (*azservicebus.Receiver, func(), error) {
+	if connString == "" || queue == "" {
+		return nil, nil, errors.New("servicebus: connection string and queue are required")
+	}
+	client, err := azservicebus.NewClientFromConnectionString(connString, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("servicebus client: %w", err)
+	}
+	r, err := client.NewReceiverForQueue(queue, &azservicebus.ReceiverOptions{
+		ReceiveMode: azservicebus.ReceiveModePeekLock,
+	})
+	if err != nil {
+		_ = client.Close(ctx)
+		return nil, nil, fmt.Errorf("servicebus receiver %s: %w", queue, err)
+	}
+	return r, func() {
+		_ = r.Close(context.Background())
+		_ = client.Close(context.Background())
+	}, nil
+}
+
+// Worker receives peek-locked messages, parses envelopes (same formats as
+// SQS, see internal/queue) and dispatches to a Handler. Locks are renewed
+// while a message is being processed, mirroring SQS visibility extension.
+type Worker struct {
+	Receiver          receiver
+	MaxMessages       int           // per receive call (default 10)
+	LockRenewInterval time.Duration // default 30s; should be below the queue's lock duration
+	DropOn4xx         bool          // dead-letter 4xx outcomes instead of retrying
+	MaxDeliveries     uint32        // dead-letter after this many deliveries (0 = rely on the queue's MaxDeliveryCount)
+
+	Filter    queue.Filter // ingest-time event/action allowlist (nil = all)
+	Processor ingest.Handler
+}
+
+// Run receives and processes messages until ctx is canceled.
+func (w *Worker) Run(ctx context.Context) error {
+	if w.Receiver == nil || w.Processor == nil {
+		return errors.New("servicebus.Worker: missing Receiver or Processor")
+	}
+	batch := w.MaxMessages
+	if batch <= 0 {
+		batch = 10
+	}
+	slog.Info("servicebus.worker.start", "maxMessages", batch, "dropOn4xx", w.DropOn4xx, "maxDeliveries", w.MaxDeliveries)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("servicebus.worker.stop", "reason", "context_done")
+			return ctx.Err()
+		default:
+		}
+
+		msgs, err := w.Receiver.ReceiveMessages(ctx, batch, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.Error("servicebus.receive.error", "err", err)
+			// small backoff to avoid a hot loop on persistent errors
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(2 * time.Second):
+			}
+			continue
+		}
+		for _, m := range msgs {
+			w.handleMessage(ctx, m)
+		}
+	}
+}
+
+// handleMessage dispatches one message while renewing its lock, then
+// settles it: Complete on success, DeadLetter on dropped 4xx or exhausted
+// deliveries, Abandon otherwise (immediate redelivery).
+func (w *Worker) handleMessage(ctx context.Context, m *azservicebus.ReceivedMessage) {
+	msgID := m.MessageID
+
+	stopRenew := w.renewLock(ctx, m)
+	code, procErr := ingest.Dispatch(ctx, w.Processor, w.Filter, m.Body, msgID)
+	stopRenew()
+
+	ack := ingest.ShouldAck(code, w.DropOn4xx)
+
+	var settleErr error
+	switch {
+	case ack && code >= 400:
+		settleErr = w.Receiver.DeadLetterMessage(ctx, m, deadLetter("bad_envelope", code, procErr))
+	case ack:
+		settleErr = w.Receiver.CompleteMessage(ctx, m, nil)
+	case w.MaxDeliveries > 0 && m.DeliveryCount >= w.MaxDeliveries:
+		slog.Error("servicebus.message.max_deliveries", "messageID", msgID, "deliveryCount", m.DeliveryCount, "status", code)
+		settleErr = w.Receiver.DeadLetterMessage(ctx, m, deadLetter("max_deliveries", code, procErr))
+	default:
+		settleErr = w.Receiver.AbandonMessage(ctx, m, nil)
+	}
+
+	if procErr != nil {
+		slog.Warn("servicebus.message.process_error", "status", code, "err", procErr, "ack", ack, "deliveryCount", m.DeliveryCount, "messageID", msgID)
+	} else {
+		slog.Info("servicebus.message.processed", "status", code, "ack", ack, "deliveryCount", m.DeliveryCount, "messageID", msgID)
+	}
+	if settleErr != nil {
+		slog.Error("servicebus.message.settle_error", "err", settleErr, "messageID", msgID)
+	}
+}
+
+// renewLock keeps the message locked until the returned stop func is called.
+func (w *Worker) renewLock(ctx context.Context, m *azservicebus.ReceivedMessage) func() {
+	every := w.LockRenewInterval
+	if every <= 0 {
+		every = 30 * time.Second
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := w.Receiver.RenewMessageLock(ctx, m, nil); err != nil {
+					slog.Warn("servicebus.message.renew_lock_error", "err", err, "messageID", m.MessageID)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func deadLetter(reason string, code int, err error) *azservicebus.DeadLetterOptions {
+	desc := fmt.Sprintf("status %d", code)
+	if err != nil {
+		desc += ": " + err.Error()
+	}
+	return &azservicebus.DeadLetterOptions{Reason: &reason, ErrorDescription: &desc}
+}
@@ -0,0 +1,121 @@
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+)
+
+// ---- fakes ----
+
+type fakeHandler struct {
+	code  int
+	err   error
+	delay time.Duration
+}
+
+func (f *fakeHandler) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	time.Sleep(f.delay)
+	return f.code, f.err
+}
+
+type fakeReceiver struct {
+	mu         sync.Mutex
+	renewed    int
+	completed  bool
+	abandoned  bool
+	deadLetter string
+}
+
+func (f *fakeReceiver) ReceiveMessages(ctx context.Context, n int, _ *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error) {
+	return nil, nil
+}
+func (f *fakeReceiver) RenewMessageLock(ctx context.Context, m *azservicebus.ReceivedMessage, _ *azservicebus.RenewMessageLockOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.renewed++
+	return nil
+}
+func (f *fakeReceiver) CompleteMessage(ctx context.Context, m *azservicebus.ReceivedMessage, _ *azservicebus.CompleteMessageOptions) error {
+	f.completed = true
+	return nil
+}
+func (f *fakeReceiver) AbandonMessage(ctx context.Context, m *azservicebus.ReceivedMessage, _ *azservicebus.AbandonMessageOptions) error {
+	f.abandoned = true
+	return nil
+}
+func (f *fakeReceiver) DeadLetterMessage(ctx context.Context, m *azservicebus.ReceivedMessage, o *azservicebus.DeadLetterOptions) error {
+	f.deadLetter = *o.Reason
+	return nil
+}
+
+func msg(body string, deliveries uint32) *azservicebus.ReceivedMessage {
+	return &azservicebus.ReceivedMessage{MessageID: "m-1", Body: []byte(body), DeliveryCount: deliveries}
+}
+
+const prBody = `{"action":"closed","pull_request":{"merged":true}}`
+
+// ---- tests ----
+
+func TestHandleMessage_SuccessCompletesAndRenews(t *testing.T) {
+	fr := &fakeReceiver{}
+	w := &Worker{Receiver: fr, Processor: &fakeHandler{code: 200, delay: 30 * time.Millisecond}, LockRenewInterval: 5 * time.Millisecond}
+
+	w.handleMessage(context.Background(), msg(prBody, 1))
+
+	if !fr.completed {
+		t.Fatalf("expected message to be completed")
+	}
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	if fr.renewed == 0 {
+		t.Fatalf("expected lock renewal during slow processing")
+	}
+}
+
+func TestHandleMessage_FailureAbandons(t *testing.T) {
+	fr := &fakeReceiver{}
+	w := &Worker{Receiver: fr, Processor: &fakeHandler{code: 500, err: errors.New("boom")}}
+
+	w.handleMessage(context.Background(), msg(prBody, 1))
+
+	if !fr.abandoned || fr.completed || fr.deadLetter != "" {
+		t.Fatalf("expected abandon only, got %+v", fr)
+	}
+}
+
+func TestHandleMessage_DeadLetters(t *testing.T) {
+	cases := []struct {
+		name string
+		w    *Worker
+		m    *azservicebus.ReceivedMessage
+		want string
+	}{
+		{
+			name: "max deliveries",
+			w:    &Worker{Processor: &fakeHandler{code: 500, err: errors.New("boom")}, MaxDeliveries: 5},
+			m:    msg(prBody, 5),
+			want: "max_deliveries",
+		},
+		{
+			name: "bad envelope",
+			w:    &Worker{Processor: &fakeHandler{code: 200}, DropOn4xx: true},
+			m:    msg("{{nope", 1),
+			want: "bad_envelope",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fr := &fakeReceiver{}
+			tc.w.Receiver = fr
+			tc.w.handleMessage(context.Background(), tc.m)
+			if fr.deadLetter != tc.want {
+				t.Fatalf("dead-letter reason = %q, want %q", fr.deadLetter, tc.want)
+			}
+		})
+	}
+}
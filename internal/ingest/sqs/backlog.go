@@ -0,0 +1,87 @@
+package sqs
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	aws "github.com/aws/aws-sdk-go-v2/aws"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
+)
+
+// queueAttributesAPI is the subset of the SQS client the monitor needs.
+type queueAttributesAPI interface {
+	GetQueueAttributes(ctx context.Context, in *awssqs.GetQueueAttributesInput, optFns ...func(*awssqs.Options)) (*awssqs.GetQueueAttributesOutput, error)
+}
+
+var (
+	backlogVisible = metrics.Default.Gauge("sqs_queue_messages_visible",
+		"ApproximateNumberOfMessages: messages waiting to be received.")
+	backlogInFlight = metrics.Default.Gauge("sqs_queue_messages_not_visible",
+		"ApproximateNumberOfMessagesNotVisible: messages received but not yet deleted.")
+)
+
+// BacklogMonitor periodically samples queue depth into gauges so autoscalers
+// (HPA/KEDA) can scale on the app's own /metrics endpoint.
+type BacklogMonitor struct {
+	Client        queueAttributesAPI
+	QueueURL      string
+	Interval      time.Duration // default 60s
+	WarnThreshold int           // warn when visible messages exceed this; 0 disables
+}
+
+// Run samples until ctx is canceled.
+func (m *BacklogMonitor) Run(ctx context.Context) {
+	every := m.Interval
+	if every <= 0 {
+		every = time.Minute
+	}
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		m.sample(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (m *BacklogMonitor) sample(ctx context.Context) {
+	out, err := m.Client.GetQueueAttributes(ctx, &awssqs.GetQueueAttributesInput{
+		QueueUrl: aws.String(m.QueueURL),
+		AttributeNames: []types.QueueAttributeName{
+			types.QueueAttributeNameApproximateNumberOfMessages,
+			types.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
+		},
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("sqs.backlog.error", "queue", m.QueueURL, "err", err)
+		}
+		return
+	}
+	visible := attrInt(out.Attributes, types.QueueAttributeNameApproximateNumberOfMessages)
+	inFlight := attrInt(out.Attributes, types.QueueAttributeNameApproximateNumberOfMessagesNotVisible)
+	backlogVisible.Set(float64(visible), "queue", m.QueueURL)
+	backlogInFlight.Set(float64(inFlight), "queue", m.QueueURL)
+
+	if m.WarnThreshold > 0 && visible > m.WarnThreshold {
+		slog.Warn("sqs.backlog.high", "queue", m.QueueURL, "visible", visible, "notVisible", inFlight, "threshold", m.WarnThreshold)
+		return
+	}
+	slog.Debug("sqs.backlog", "queue", m.QueueURL, "visible", visible, "notVisible", inFlight)
+}
+
+func attrInt(attrs map[string]string, name types.QueueAttributeName) int {
+	n, err := strconv.Atoi(attrs[string(name)])
+	if err != nil {
+		return 0
+	}
+	return n
+}
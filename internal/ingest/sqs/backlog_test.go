@@ -0,0 +1,43 @@
+package sqs
+
+import (
+	"context"
+	"testing"
+
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+type fakeAttrs struct {
+	attrs map[string]string
+	calls int
+}
+
+func (f *fakeAttrs) GetQueueAttributes(ctx context.Context, in *awssqs.GetQueueAttributesInput, _ ...func(*awssqs.Options)) (*awssqs.GetQueueAttributesOutput, error) {
+	f.calls++
+	return &awssqs.GetQueueAttributesOutput{Attributes: f.attrs}, nil
+}
+
+func TestBacklogMonitor_SampleSetsGauges(t *testing.T) {
+	fa := &fakeAttrs{attrs: map[string]string{
+		"ApproximateNumberOfMessages":           "17",
+		"ApproximateNumberOfMessagesNotVisible": "3",
+	}}
+	m := &BacklogMonitor{Client: fa, QueueURL: "q-backlog", WarnThreshold: 10}
+	m.sample(context.Background())
+
+	if fa.calls != 1 {
+		t.Fatalf("expected 1 GetQueueAttributes call, got %d", fa.calls)
+	}
+	if got := backlogVisible.Value("queue", "q-backlog"); got != 17 {
+		t.Fatalf("visible gauge = %v, want 17", got)
+	}
+	if got := backlogInFlight.Value("queue", "q-backlog"); got != 3 {
+		t.Fatalf("not-visible gauge = %v, want 3", got)
+	}
+}
+
+func Test_attrInt(t *testing.T) {
+	if got := attrInt(map[string]string{"ApproximateNumberOfMessages": "oops"}, "ApproximateNumberOfMessages"); got != 0 {
+		t.Fatalf("attrInt invalid = %d, want 0", got)
+	}
+}
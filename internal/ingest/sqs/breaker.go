@@ -0,0 +1,90 @@
+package sqs
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
+)
+
+const (
+	receiveBackoffBase    = time.Second
+	defaultReceiveBackoff = time.Minute
+	defaultBreakerPause   = time.Minute
+)
+
+var circuitOpen = metrics.Default.Gauge("sqs_receive_circuit_open",
+	"1 while polling a queue is paused after sustained ReceiveMessage errors, else 0.")
+
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // polling normally
+	breakerHalfOpen                     // paused; the next receive is a probe
+)
+
+// receiveBreaker paces ReceiveMessage retries: exponential backoff with jitter
+// per consecutive error, and after threshold errors in a row a circuit that
+// pauses polling for pause between single probe receives until one succeeds.
+// Not safe for concurrent use; only the receive loop touches it.
+type receiveBreaker struct {
+	queue     string        // metric label
+	max       time.Duration // backoff ceiling
+	threshold int           // consecutive errors that open the circuit (0 = never)
+	pause     time.Duration // wait between probes while open
+
+	failures int
+	state    breakerState
+	jitter   func(time.Duration) time.Duration // test seam
+}
+
+func newReceiveBreaker(queue string, maxBackoff time.Duration, threshold int, pause time.Duration) *receiveBreaker {
+	if maxBackoff <= 0 {
+		maxBackoff = defaultReceiveBackoff
+	}
+	if pause <= 0 {
+		pause = defaultBreakerPause
+	}
+	return &receiveBreaker{queue: queue, max: maxBackoff, threshold: threshold, pause: pause, jitter: halfJitter}
+}
+
+// halfJitter picks a wait in [d/2, d], so replicas failing together spread out
+// without ever retrying much sooner than planned.
+func halfJitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2+1) // #nosec G404 -- jitter, not security
+}
+
+// failure records a failed receive and returns how long to wait before the
+// next one.
+func (b *receiveBreaker) failure(err error) time.Duration {
+	b.failures++
+	if b.state == breakerHalfOpen {
+		slog.Warn("sqs.receive.circuit_probe_failed", "queue", b.queue, "failures", b.failures, "err", err, "retryIn", b.pause)
+		return b.pause
+	}
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.state = breakerHalfOpen
+		circuitOpen.Set(1, "queue", b.queue)
+		slog.Error("sqs.receive.circuit_open", "queue", b.queue, "failures", b.failures, "err", err, "probeIn", b.pause)
+		return b.pause
+	}
+	d := b.max
+	if shift := b.failures - 1; shift < 30 {
+		d = min(receiveBackoffBase<<shift, b.max)
+	}
+	return b.jitter(d)
+}
+
+// success records a successful receive, closing the circuit if it was open.
+func (b *receiveBreaker) success() {
+	if b.state == breakerHalfOpen {
+		circuitOpen.Set(0, "queue", b.queue)
+		slog.Info("sqs.receive.circuit_closed", "queue", b.queue, "failures", b.failures)
+	}
+	b.failures = 0
+	b.state = breakerClosed
+}
@@ -0,0 +1,54 @@
+package sqs
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReceiveBreaker_BacksOffThenOpensAndRecovers(t *testing.T) {
+	b := newReceiveBreaker("q", 5*time.Second, 5, 30*time.Second)
+	b.jitter = func(d time.Duration) time.Duration { return d }
+	boom := errors.New("throttled")
+
+	var got []time.Duration
+	for range 4 {
+		got = append(got, b.failure(boom))
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("backoff = %v, want %v", got, want)
+		}
+	}
+
+	if d := b.failure(boom); d != 30*time.Second || b.state != breakerHalfOpen {
+		t.Fatalf("threshold reached: wait=%v state=%v; want open circuit", d, b.state)
+	}
+	if circuitOpen.Value("queue", "q") != 1 {
+		t.Fatalf("circuit gauge not set")
+	}
+	if d := b.failure(boom); d != 30*time.Second {
+		t.Fatalf("failed probe: wait=%v; want pause", d)
+	}
+
+	b.success()
+	if b.state != breakerClosed || b.failures != 0 || circuitOpen.Value("queue", "q") != 0 {
+		t.Fatalf("probe success did not close the circuit: %+v", b)
+	}
+	if d := b.failure(boom); d != time.Second {
+		t.Fatalf("after recovery: wait=%v; want base backoff", d)
+	}
+}
+
+func TestReceiveBreaker_NoThresholdNeverOpens(t *testing.T) {
+	b := newReceiveBreaker("q", 0, 0, 0)
+	for range 100 {
+		if d := b.failure(errors.New("x")); d > defaultReceiveBackoff {
+			t.Fatalf("wait %v out of range after %d failures", d, b.failures)
+		}
+	}
+	if b.state != breakerClosed {
+		t.Fatalf("circuit opened with threshold 0")
+	}
+}
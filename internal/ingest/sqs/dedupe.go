@@ -0,0 +1,104 @@
+package sqs
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
+)
+
+const defaultDedupeSize = 10000
+
+var duplicateDeliveries = metrics.Default.Counter("sqs_duplicate_deliveries_total",
+	"Messages acked without processing because their X-GitHub-Delivery was seen recently, by queue.")
+
+// deliveryCache remembers recently handled delivery IDs, bounded both by age
+// (ttl after handling) and by count (least recently used go first). Deliveries
+// being handled count as seen too, so a duplicate received concurrently by
+// another goroutine is skipped.
+type deliveryCache struct {
+	ttl  time.Duration
+	size int
+	now  func() time.Time // test seam
+
+	mu    sync.Mutex
+	order *list.List // of *deliveryEntry, most recent first
+	byID  map[string]*list.Element
+}
+
+type deliveryEntry struct {
+	id      string
+	done    bool      // handled; false while in flight
+	expires time.Time // when done
+}
+
+func newDeliveryCache(ttl time.Duration, size int) *deliveryCache {
+	if size <= 0 {
+		size = defaultDedupeSize
+	}
+	return &deliveryCache{ttl: ttl, size: size, now: time.Now, order: list.New(), byID: map[string]*list.Element{}}
+}
+
+// claim reports whether id is new (or its memory expired) and marks it in
+// flight; false means it is a duplicate.
+func (c *deliveryCache) claim(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.byID[id]; ok {
+		e := el.Value.(*deliveryEntry)
+		if !e.done || c.now().Before(e.expires) {
+			return false
+		}
+		c.order.Remove(el)
+		delete(c.byID, id)
+	}
+	c.byID[id] = c.order.PushFront(&deliveryEntry{id: id})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.byID, oldest.Value.(*deliveryEntry).id)
+	}
+	return true
+}
+
+// finish records the outcome of a claimed id: remembered for ttl when it was
+// handled, forgotten otherwise so its redelivery is processed.
+func (c *deliveryCache) finish(id string, handled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.byID[id]
+	if !ok {
+		return // evicted meanwhile
+	}
+	if !handled {
+		c.order.Remove(el)
+		delete(c.byID, id)
+		return
+	}
+	e := el.Value.(*deliveryEntry)
+	e.done, e.expires = true, c.now().Add(c.ttl)
+	c.order.MoveToFront(el)
+}
+
+// dedupeHandler skips deliveries the cache has seen, acking them. Only
+// successful (2xx) deliveries are remembered; anything else may be retried.
+type dedupeHandler struct {
+	next  Handler
+	cache *deliveryCache
+	queue string // metric label
+}
+
+func (d dedupeHandler) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	if !d.cache.claim(delivery) {
+		duplicateDeliveries.Inc("queue", d.queue)
+		slog.Info("sqs.message.duplicate", "queue", d.queue, "event", event, "delivery", delivery)
+		return http.StatusOK, nil
+	}
+	code, err := d.next.HandleEvent(ctx, event, delivery, payload)
+	d.cache.finish(delivery, code >= 200 && code < 300)
+	return code, err
+}
@@ -0,0 +1,83 @@
+package sqs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type countingHandler struct {
+	calls int
+	code  int
+}
+
+func (c *countingHandler) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	c.calls++
+	return c.code, nil
+}
+
+func envelopeFor(delivery string) []byte {
+	return []byte(`{"headers":{"X-GitHub-Event":"pull_request","X-GitHub-Delivery":"` + delivery + `"},"body":"{\"action\":\"closed\"}"}`)
+}
+
+func TestWorker_DedupesDeliveries(t *testing.T) {
+	h := &countingHandler{code: 202}
+	w := &Worker{Processor: h, dedupe: newDeliveryCache(time.Minute, 0)}
+
+	for i := range 3 {
+		code, err := w.handleSQSMessage(context.Background(), envelopeFor("d-1"), "m-"+strconv.Itoa(i))
+		if err != nil || code < 200 || code >= 300 {
+			t.Fatalf("delivery %d: %d, %v", i, code, err)
+		}
+	}
+	if h.calls != 1 {
+		t.Fatalf("handler calls = %d, want 1", h.calls)
+	}
+	_, _ = w.handleSQSMessage(context.Background(), envelopeFor("d-2"), "m-9")
+	if h.calls != 2 {
+		t.Fatalf("new delivery not processed, calls = %d", h.calls)
+	}
+}
+
+func TestWorker_DedupeForgetsFailures(t *testing.T) {
+	h := &countingHandler{code: 500}
+	w := &Worker{Processor: h, dedupe: newDeliveryCache(time.Minute, 0)}
+
+	_, _ = w.handleSQSMessage(context.Background(), envelopeFor("d-1"), "m-1")
+	h.code = 200
+	_, _ = w.handleSQSMessage(context.Background(), envelopeFor("d-1"), "m-1")
+	if h.calls != 2 {
+		t.Fatalf("retry after failure not processed, calls = %d", h.calls)
+	}
+}
+
+func TestDeliveryCache_TTLAndSize(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := newDeliveryCache(time.Minute, 2)
+	c.now = func() time.Time { return now }
+
+	if !c.claim("a") || c.claim("a") {
+		t.Fatal("in-flight delivery should be a duplicate")
+	}
+	c.finish("a", true)
+	if c.claim("a") {
+		t.Fatal("handled delivery should be a duplicate within ttl")
+	}
+	now = now.Add(2 * time.Minute)
+	if !c.claim("a") {
+		t.Fatal("expired delivery should be processed again")
+	}
+	c.finish("a", true)
+
+	// Size bound: "a" is the least recently used once b and c arrive.
+	c.claim("b")
+	c.finish("b", true)
+	c.claim("c")
+	if !c.claim("a") {
+		t.Fatal("evicted delivery should be processed again")
+	}
+	if len(c.byID) != 2 || c.order.Len() != 2 {
+		t.Fatalf("cache size = %d/%d, want 2", len(c.byID), c.order.Len())
+	}
+}
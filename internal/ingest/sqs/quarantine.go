@@ -0,0 +1,123 @@
+package sqs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	aws "github.com/aws/aws-sdk-go-v2/aws"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
+)
+
+// Reasons a message is quarantined instead of being retried.
+const (
+	quarantineRejected    = "rejected"     // 4xx deleted under DeleteOn4xx
+	quarantineMaxReceives = "max_receives" // given up at MaxReceives
+)
+
+var quarantined = metrics.Default.Counter("sqs_messages_quarantined_total",
+	"Messages uploaded to the S3 quarantine before deletion, by queue and reason.")
+
+// s3API is the subset of the S3 client the quarantine needs.
+type s3API interface {
+	PutObject(ctx context.Context, in *awss3.PutObjectInput, optFns ...func(*awss3.Options)) (*awss3.PutObjectOutput, error)
+}
+
+// Quarantine keeps a copy of messages the worker is about to delete without
+// having processed them (bad envelopes, rejected deliveries, give-ups), so
+// they can be inspected later. Each message is one JSON object at
+// <Prefix><yyyy/mm/dd>/<messageID>.json holding the raw body and why it was
+// dropped.
+type Quarantine struct {
+	Client s3API
+	Bucket string
+	Prefix string // e.g. "quarantine/"
+}
+
+// NewQuarantine returns the quarantine configured by SQS_QUARANTINE_BUCKET and
+// SQS_QUARANTINE_PREFIX, or nil when no bucket is set.
+func NewQuarantine(awsCfg aws.Config, cfg *config.Config) *Quarantine {
+	if cfg.SQSQuarantineBucket == "" {
+		return nil
+	}
+	return &Quarantine{
+		Client: awss3.NewFromConfig(awsCfg),
+		Bucket: cfg.SQSQuarantineBucket,
+		Prefix: cfg.SQSQuarantinePrefix,
+	}
+}
+
+// QuarantineRecord is the object written for one message.
+type QuarantineRecord struct {
+	MessageID     string            `json:"message_id"`
+	Queue         string            `json:"queue"`
+	Reason        string            `json:"reason"`
+	Status        int               `json:"status"`
+	Error         string            `json:"error,omitempty"`
+	ReceiveCount  int               `json:"receive_count"`
+	QuarantinedAt time.Time         `json:"quarantined_at"`
+	Attributes    map[string]string `json:"attributes,omitempty"`
+	Body          string            `json:"body"`
+}
+
+func (q *Quarantine) key(rec *QuarantineRecord) string {
+	return q.Prefix + path.Join(rec.QuarantinedAt.UTC().Format("2006/01/02"), rec.MessageID+".json")
+}
+
+// Put uploads rec and returns its object key.
+func (q *Quarantine) Put(ctx context.Context, rec *QuarantineRecord) (string, error) {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	key := q.key(rec)
+	_, err = q.Client.PutObject(ctx, &awss3.PutObjectInput{
+		Bucket:      aws.String(q.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(raw),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("put s3://%s/%s: %w", q.Bucket, key, err)
+	}
+	return key, nil
+}
+
+// quarantine copies m to the Quarantine before it is deleted unprocessed and
+// reports whether the deletion may go ahead. On upload failure the message is
+// kept so it is not lost; it comes back after the visibility timeout.
+func (w *Worker) quarantine(ctx context.Context, m types.Message, reason string, code int, procErr error) bool {
+	if w.Quarantine == nil {
+		return true
+	}
+	rec := &QuarantineRecord{
+		MessageID:     aws.ToString(m.MessageId),
+		Queue:         w.QueueURL,
+		Reason:        reason,
+		Status:        code,
+		ReceiveCount:  receiveCount(m),
+		QuarantinedAt: time.Now(),
+		Attributes:    m.Attributes,
+		Body:          aws.ToString(m.Body),
+	}
+	if procErr != nil {
+		rec.Error = procErr.Error()
+	}
+	// A message being deleted during shutdown still needs its copy.
+	key, err := w.Quarantine.Put(context.WithoutCancel(ctx), rec)
+	if err != nil {
+		slog.Error("sqs.message.quarantine_error", "reason", reason, "err", err, "messageID", rec.MessageID)
+		return false
+	}
+	quarantined.Inc("queue", w.QueueURL, "reason", reason)
+	slog.Warn("sqs.message.quarantined", "reason", reason, "status", code, "key", key, "messageID", rec.MessageID)
+	return true
+}
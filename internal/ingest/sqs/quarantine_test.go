@@ -0,0 +1,105 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	aws "github.com/aws/aws-sdk-go-v2/aws"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+type fakeS3 struct {
+	err  error
+	keys []string
+	recs []QuarantineRecord
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, in *awss3.PutObjectInput, _ ...func(*awss3.Options)) (*awss3.PutObjectOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	raw, _ := io.ReadAll(in.Body)
+	var rec QuarantineRecord
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, err
+	}
+	f.keys = append(f.keys, aws.ToString(in.Key))
+	f.recs = append(f.recs, rec)
+	return &awss3.PutObjectOutput{}, nil
+}
+
+func TestProcessMessage_QuarantinesBadEnvelopeBeforeDelete(t *testing.T) {
+	s3 := &fakeS3{}
+	w := &Worker{
+		Client: &fakeSQS{}, QueueURL: "q", DeleteOn4xx: true,
+		Quarantine: &Quarantine{Client: s3, Bucket: "b", Prefix: "quarantine/"},
+		Processor:  &fakeHandler{code: 200},
+	}
+	b := &deleteBatch{}
+	w.processMessage(context.Background(), types.Message{
+		MessageId: aws.String("m-1"), ReceiptHandle: aws.String("rh-1"), Body: aws.String("not json"),
+		Attributes: map[string]string{"ApproximateReceiveCount": "1"},
+	}, b)
+
+	if len(b.entries) != 1 {
+		t.Fatalf("bad envelope not deleted after quarantine: entries=%d", len(b.entries))
+	}
+	if len(s3.recs) != 1 {
+		t.Fatalf("want 1 quarantined object, got %d", len(s3.recs))
+	}
+	rec := s3.recs[0]
+	if rec.Body != "not json" || rec.Status != 400 || rec.Reason != quarantineRejected || rec.Error == "" || rec.ReceiveCount != 1 {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if !strings.HasPrefix(s3.keys[0], "quarantine/") || !strings.HasSuffix(s3.keys[0], "/m-1.json") {
+		t.Fatalf("unexpected key %q", s3.keys[0])
+	}
+}
+
+func TestProcessMessage_KeepsMessageWhenQuarantineFails(t *testing.T) {
+	msg := types.Message{
+		MessageId: aws.String("m-1"), ReceiptHandle: aws.String("rh-1"),
+		Body:       aws.String(`{"action":"closed","pull_request":{}}`),
+		Attributes: map[string]string{"ApproximateReceiveCount": "5"},
+	}
+	h := &giveUpSpy{fakeHandler: fakeHandler{code: 500, err: errors.New("boom")}}
+	w := &Worker{
+		Client: &fakeSQS{}, QueueURL: "q", MaxReceives: 5,
+		Quarantine: &Quarantine{Client: &fakeS3{err: errors.New("access denied")}, Bucket: "b"},
+		Processor:  h,
+	}
+	b := &deleteBatch{}
+	w.processMessage(context.Background(), msg, b)
+	if len(b.entries) != 0 || h.attempts != 0 {
+		t.Fatalf("gave up without a quarantined copy: entries=%d attempts=%d", len(b.entries), h.attempts)
+	}
+
+	s3 := &fakeS3{}
+	w.Quarantine.Client = s3
+	w.processMessage(context.Background(), msg, b)
+	if len(b.entries) != 1 || h.attempts != 5 || len(s3.recs) != 1 || s3.recs[0].Reason != quarantineMaxReceives {
+		t.Fatalf("want quarantine + give-up: entries=%d attempts=%d recs=%+v", len(b.entries), h.attempts, s3.recs)
+	}
+}
+
+func TestProcessMessage_SuccessIsNotQuarantined(t *testing.T) {
+	s3 := &fakeS3{}
+	w := &Worker{
+		Client: &fakeSQS{}, QueueURL: "q", DeleteOn4xx: true,
+		Quarantine: &Quarantine{Client: s3, Bucket: "b"},
+		Processor:  &fakeHandler{code: 200},
+	}
+	b := &deleteBatch{}
+	w.processMessage(context.Background(), types.Message{
+		MessageId: aws.String("m-1"), ReceiptHandle: aws.String("rh-1"),
+		Body: aws.String(`{"action":"closed","pull_request":{}}`),
+	}, b)
+	if len(b.entries) != 1 || len(s3.recs) != 0 {
+		t.Fatalf("entries=%d quarantined=%d", len(b.entries), len(s3.recs))
+	}
+}
@@ -0,0 +1,92 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	aws "github.com/aws/aws-sdk-go-v2/aws"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+)
+
+// RedriveResult is the outcome of replaying one dead-lettered message.
+type RedriveResult struct {
+	MessageID string
+	Status    int
+	Err       error
+	Deleted   bool // removed from the dead-letter queue
+}
+
+// Redrive replays up to limit messages (0 = all) from the worker's queue,
+// normally a dead-letter queue, through the Processor one at a time. Messages
+// the Processor accepts are deleted with the usual ShouldAck rules (rejected
+// ones are copied to the Quarantine first, when set); the rest stay in the
+// queue and become visible again after VisibilityTimeout. It stops when the queue is drained or only already-replayed messages come
+// back. The Processor should be synchronous so outcomes reflect the work.
+func (w *Worker) Redrive(ctx context.Context, limit int) ([]RedriveResult, error) {
+	if w.Client == nil || w.QueueURL == "" || w.Processor == nil {
+		return nil, errors.New("sqs.Worker: missing Client, QueueURL or Processor")
+	}
+	seen := map[string]bool{}
+	var results []RedriveResult
+	for limit <= 0 || len(results) < limit {
+		n := int32(10)
+		if rem := limit - len(results); limit > 0 && rem < 10 {
+			n = int32(rem) // #nosec G115 -- below 10
+		}
+		out, err := w.Client.ReceiveMessage(ctx, &awssqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(w.QueueURL),
+			MaxNumberOfMessages: n,
+			WaitTimeSeconds:     1,
+			VisibilityTimeout:   w.vOrDefault(w.VisibilityTimeout, 120),
+		})
+		if err != nil {
+			return results, err
+		}
+		if len(out.Messages) == 0 {
+			break
+		}
+
+		b := &deleteBatch{}
+		batch := make([]RedriveResult, 0, len(out.Messages))
+		for _, m := range out.Messages {
+			id := aws.ToString(m.MessageId)
+			if seen[id] || m.ReceiptHandle == nil {
+				continue
+			}
+			seen[id] = true
+			batch = append(batch, w.replay(ctx, m, b))
+		}
+		if len(batch) == 0 {
+			break
+		}
+		failed := w.flush(context.WithoutCancel(ctx), b)
+		for i := range batch {
+			if batch[i].Deleted && failed[batch[i].MessageID] {
+				batch[i].Deleted = false
+			}
+		}
+		results = append(results, batch...)
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+	}
+	return results, nil
+}
+
+func (w *Worker) replay(ctx context.Context, m types.Message, b *deleteBatch) RedriveResult {
+	id := aws.ToString(m.MessageId)
+	code, err := w.handleSQSMessage(ctx, []byte(aws.ToString(m.Body)), id)
+	del := ingest.ShouldAck(code, w.DeleteOn4xx)
+	if del && code >= 400 {
+		del = w.quarantine(ctx, m, quarantineRejected, code, err)
+	}
+	if del {
+		b.add(aws.ToString(m.ReceiptHandle), id, nil)
+	}
+	slog.Info("sqs.redrive.message", "messageID", id, "status", code, "err", err, "delete", del)
+	return RedriveResult{MessageID: id, Status: code, Err: err, Deleted: del}
+}
@@ -0,0 +1,93 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	aws "github.com/aws/aws-sdk-go-v2/aws"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// dlqSQS serves its messages on every receive until they are deleted, like
+// a queue whose visibility timeout keeps expiring.
+type dlqSQS struct {
+	fakeSQS
+	mu   sync.Mutex
+	msgs []types.Message
+}
+
+func (f *dlqSQS) ReceiveMessage(ctx context.Context, in *awssqs.ReceiveMessageInput, _ ...func(*awssqs.Options)) (*awssqs.ReceiveMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := min(int(in.MaxNumberOfMessages), len(f.msgs))
+	return &awssqs.ReceiveMessageOutput{Messages: append([]types.Message(nil), f.msgs[:n]...)}, nil
+}
+
+func (f *dlqSQS) DeleteMessageBatch(
+	ctx context.Context, in *awssqs.DeleteMessageBatchInput, _ ...func(*awssqs.Options),
+) (*awssqs.DeleteMessageBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	gone := map[string]bool{}
+	for _, e := range in.Entries {
+		gone[aws.ToString(e.ReceiptHandle)] = true
+	}
+	kept := f.msgs[:0]
+	for _, m := range f.msgs {
+		if !gone[aws.ToString(m.ReceiptHandle)] {
+			kept = append(kept, m)
+		}
+	}
+	f.msgs = kept
+	return &awssqs.DeleteMessageBatchOutput{}, nil
+}
+
+// byDelivery answers 500 for the "fail" delivery and 200 otherwise.
+type byDelivery struct{}
+
+func (byDelivery) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	if delivery == "fail" {
+		return 500, errors.New("still broken")
+	}
+	return 200, nil
+}
+
+func dlqMessage(id, delivery string) types.Message {
+	body := `{"headers":{"X-GitHub-Event":"pull_request","X-GitHub-Delivery":"` + delivery + `"},"body":{"pull_request":{}}}`
+	return types.Message{MessageId: aws.String(id), ReceiptHandle: aws.String("rh-" + id), Body: aws.String(body)}
+}
+
+func TestRedrive_ReplaysAndReportsOutcomes(t *testing.T) {
+	fs := &dlqSQS{msgs: []types.Message{dlqMessage("1", "a"), dlqMessage("2", "fail"), dlqMessage("3", "c")}}
+	w := &Worker{Client: fs, QueueURL: "dlq", Processor: byDelivery{}}
+
+	res, err := w.Redrive(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Redrive: %v", err)
+	}
+	if len(res) != 3 {
+		t.Fatalf("results = %+v, want one per message", res)
+	}
+	for _, r := range res {
+		wantDeleted := r.MessageID != "2"
+		if r.Deleted != wantDeleted || (r.Err != nil) == wantDeleted {
+			t.Fatalf("result %+v", r)
+		}
+	}
+	if len(fs.msgs) != 1 || aws.ToString(fs.msgs[0].MessageId) != "2" {
+		t.Fatalf("left in DLQ = %+v, want only the failing message", fs.msgs)
+	}
+}
+
+func TestRedrive_Limit(t *testing.T) {
+	fs := &dlqSQS{msgs: []types.Message{dlqMessage("1", "a"), dlqMessage("2", "b"), dlqMessage("3", "c")}}
+	w := &Worker{Client: fs, QueueURL: "dlq", Processor: byDelivery{}}
+
+	res, err := w.Redrive(context.Background(), 2)
+	if err != nil || len(res) != 2 || len(fs.msgs) != 1 {
+		t.Fatalf("res=%+v err=%v left=%d", res, err, len(fs.msgs))
+	}
+}
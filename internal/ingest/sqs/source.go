@@ -0,0 +1,104 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	awscfg "github.com/aws/aws-sdk-go-v2/config"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+)
+
+func init() { ingest.Register("sqs", NewSource) }
+
+type source struct {
+	workers  []*Worker
+	monitors []*BacklogMonitor // empty when SQS_BACKLOG_POLL_SECONDS=0
+}
+
+// NewSource wires one worker (and, unless disabled, backlog gauges) per
+// queue in SQS_QUEUE_URL and SQS_QUEUE_URLS. The workers share the delivery
+// dedupe cache, so a delivery fanned out to two queues is handled once.
+func NewSource(ctx context.Context, cfg *config.Config, h ingest.Handler) (ingest.Source, error) {
+	urls := cfg.SQSQueueURLs
+	if len(urls) == 0 && cfg.SQSQueueURL != "" {
+		urls = []string{cfg.SQSQueueURL}
+	}
+	if len(urls) == 0 {
+		return nil, errors.New("sqs: SQS_QUEUE_URL or SQS_QUEUE_URLS is required")
+	}
+	awsCfg, err := awscfg.LoadDefaultConfig(ctx, awscfg.WithRegion(cfg.AWSRegion))
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+	client := awssqs.NewFromConfig(awsCfg)
+	quarantine := NewQuarantine(awsCfg, cfg)
+	dedupeTTL := time.Duration(cfg.SQSDedupeTTLSeconds) * time.Second
+	var dedupe *deliveryCache
+	if dedupeTTL > 0 {
+		dedupe = newDeliveryCache(dedupeTTL, cfg.SQSDedupeSize)
+	}
+
+	s := &source{}
+	for _, url := range urls {
+		s.workers = append(s.workers, &Worker{
+			Client:            client,
+			QueueURL:          url,
+			MaxMessages:       cfg.SQSMaxMessages,
+			WaitTimeSeconds:   cfg.SQSWaitTimeSeconds,
+			VisibilityTimeout: cfg.SQSVisibilityTimeout,
+			DeleteOn4xx:       cfg.SQSDeleteOn4xx,
+			Concurrency:       cfg.SQSConcurrency,
+			// Needs a synchronous processor to span the actual work (see cmd/server).
+			ExtendOnProcessing: cfg.SQSExtendOnProcessing,
+			WarnReceives:       cfg.SQSWarnReceives,
+			MaxReceives:        cfg.SQSMaxReceives,
+			Quarantine:         quarantine,
+			ReceiveBackoffMax:  time.Duration(cfg.SQSReceiveBackoffMaxSeconds) * time.Second,
+			BreakerThreshold:   cfg.SQSBreakerThreshold,
+			BreakerPause:       time.Duration(cfg.SQSBreakerPauseSeconds) * time.Second,
+			DedupeTTL:          dedupeTTL,
+			DedupeSize:         cfg.SQSDedupeSize,
+			AgeWarnThreshold:   time.Duration(cfg.SQSAgeWarnSeconds) * time.Second,
+			dedupe:             dedupe,
+			Filter:             cfg.EventFilter,
+			Processor:          h,
+		})
+		// Backlog gauges for autoscaling (HPA/KEDA).
+		if cfg.SQSBacklogPollSeconds > 0 {
+			s.monitors = append(s.monitors, &BacklogMonitor{
+				Client:        client,
+				QueueURL:      url,
+				Interval:      time.Duration(cfg.SQSBacklogPollSeconds) * time.Second,
+				WarnThreshold: cfg.SQSBacklogWarnThreshold,
+			})
+		}
+	}
+	return s, nil
+}
+
+// Run polls every queue until ctx is canceled. A worker that fails stops the
+// others too, so the process exits instead of silently dropping a queue.
+func (s *source) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for _, m := range s.monitors {
+		go m.Run(ctx)
+	}
+	errs := make(chan error, len(s.workers))
+	for _, w := range s.workers {
+		go func() { errs <- w.Run(ctx) }()
+	}
+	var first error
+	for range s.workers {
+		if err := <-errs; first == nil {
+			first = err
+			cancel()
+		}
+	}
+	return first
+}
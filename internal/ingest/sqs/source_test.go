@@ -0,0 +1,90 @@
+package sqs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	aws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+)
+
+func TestNewSource_OneWorkerPerQueue(t *testing.T) {
+	cfg := &config.Config{
+		AWSRegion:             "eu-north-1",
+		SQSQueueURLs:          []string{"https://sqs/1/org-a", "https://sqs/1/org-b"},
+		SQSBacklogPollSeconds: 60,
+		SQSDedupeTTLSeconds:   60,
+	}
+	src, err := NewSource(context.Background(), cfg, &fakeHandler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := src.(*source)
+	if len(s.workers) != 2 || len(s.monitors) != 2 {
+		t.Fatalf("workers=%d monitors=%d, want 2 each", len(s.workers), len(s.monitors))
+	}
+	if s.workers[0].QueueURL != "https://sqs/1/org-a" || s.workers[1].QueueURL != "https://sqs/1/org-b" {
+		t.Fatalf("queues = %q, %q", s.workers[0].QueueURL, s.workers[1].QueueURL)
+	}
+	if s.workers[0].dedupe == nil || s.workers[0].dedupe != s.workers[1].dedupe {
+		t.Fatal("workers should share one dedupe cache")
+	}
+}
+
+func TestSource_RunPollsEveryQueue(t *testing.T) {
+	msg := func(id string) types.Message {
+		body := `{"headers":{"X-GitHub-Event":"pull_request","X-GitHub-Delivery":"d-` + id + `"},"body":{"pull_request":{}}}`
+		return types.Message{MessageId: aws.String(id), ReceiptHandle: aws.String("rh-" + id), Body: aws.String(body)}
+	}
+	a := &fakeSQS{batch: []types.Message{msg("a")}}
+	b := &fakeSQS{batch: []types.Message{msg("b")}}
+	s := &source{workers: []*Worker{
+		{Client: a, QueueURL: "qa", Processor: &fakeHandler{code: 202}},
+		{Client: b, QueueURL: "qb", Processor: &fakeHandler{code: 202}},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		a.mu.Lock()
+		b.mu.Lock()
+		ok := len(a.deleted) == 1 && len(b.deleted) == 1
+		b.mu.Unlock()
+		a.mu.Unlock()
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("both queues should be polled and their messages deleted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	if got := messagesHandled.Value("queue", "qa", "outcome", "deleted"); got < 1 {
+		t.Fatalf("per-queue counter = %v", got)
+	}
+}
+
+func TestSource_RunStopsAllWhenOneFails(t *testing.T) {
+	s := &source{workers: []*Worker{
+		{Client: &fakeSQS{}, QueueURL: "ok", Processor: &fakeHandler{}},
+		{QueueURL: "broken", Processor: &fakeHandler{}}, // no client
+	}}
+	done := make(chan error, 1)
+	go func() { done <- s.Run(context.Background()) }()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "missing Client") {
+			t.Fatalf("err = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run should return once a worker fails")
+	}
+}
@@ -4,29 +4,98 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
 	aws "github.com/aws/aws-sdk-go-v2/aws"
 	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 
-	qparser "github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
 )
 
-// Handler is implemented by the processor layer.
-// Return an HTTP-like status and an error (nil on success).
-type Handler interface {
-	HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error)
+// Handler is implemented by the processor layer (see ingest.Handler).
+type Handler = ingest.Handler
+
+// sqsAPI is the subset of the SQS client the worker needs.
+type sqsAPI interface {
+	ReceiveMessage(ctx context.Context, in *awssqs.ReceiveMessageInput, optFns ...func(*awssqs.Options)) (*awssqs.ReceiveMessageOutput, error)
+	DeleteMessageBatch(
+		ctx context.Context, in *awssqs.DeleteMessageBatchInput, optFns ...func(*awssqs.Options),
+	) (*awssqs.DeleteMessageBatchOutput, error)
+	ChangeMessageVisibility(
+		ctx context.Context, in *awssqs.ChangeMessageVisibilityInput, optFns ...func(*awssqs.Options),
+	) (*awssqs.ChangeMessageVisibilityOutput, error)
 }
 
+var (
+	messagesHandled = metrics.Default.Counter("sqs_messages_total",
+		"Messages handled by the worker, by queue and outcome (deleted, or retried after the visibility timeout).")
+	receivedAge = metrics.Default.Gauge("sqs_received_message_age_seconds",
+		"Age (since SentTimestamp) of the oldest message in the worker's latest receive; 0 when it was empty.")
+)
+
 // Worker polls SQS, parses message envelopes, and dispatches to a Handler.
 type Worker struct {
-	Client            *awssqs.Client
+	Client            sqsAPI
 	QueueURL          string
 	MaxMessages       int32 // 1..10
 	WaitTimeSeconds   int32 // 0..20
 	VisibilityTimeout int32 // seconds
 	DeleteOn4xx       bool
+	// Concurrency bounds how many messages are processed in parallel. Their
+	// picks count only when the Processor handles them synchronously (see
+	// config.SQSSynchronous); SQS_CONCURRENCY=0, the default, leaves it
+	// asynchronous, so a message is deleted once accepted and its work runs
+	// unbounded (values below 1 are one slot here). Deletion is still
+	// decided per message, but the messages of one receive are deleted
+	// together in a single DeleteMessageBatch call once all of them have
+	// finished.
+	Concurrency int
+	// ExtendOnProcessing keeps a message invisible while it is processed by
+	// re-arming its visibility timeout every half period, so long picks are
+	// not redelivered to another replica mid-flight.
+	ExtendOnProcessing bool
+	heartbeatEvery     time.Duration // test seam; default VisibilityTimeout/2
+
+	// WarnReceives logs a warning when a message that is not deleted has been
+	// received at least this many times (0 = never). MaxReceives gives up on
+	// such a message at that count instead of retrying until the queue's own
+	// redrive policy kicks in: the Processor is told (see ingest.GiveUpHandler)
+	// and the message is deleted. 0 keeps retrying.
+	WarnReceives int
+	MaxReceives  int
+
+	// Quarantine, when set, receives a copy of every message deleted without
+	// being processed (4xx under DeleteOn4xx, MaxReceives give-ups) before the
+	// delete; if the upload fails the message is kept instead.
+	Quarantine *Quarantine
 
+	// ReceiveMessage errors are retried with exponential backoff and jitter
+	// from 1s up to ReceiveBackoffMax (default 1m). After BreakerThreshold
+	// consecutive errors (0 = never) polling pauses: one probe receive every
+	// BreakerPause (default 1m) until one succeeds.
+	ReceiveBackoffMax time.Duration
+	BreakerThreshold  int
+	BreakerPause      time.Duration
+
+	// DedupeTTL acks messages whose X-GitHub-Delivery was handled
+	// successfully within that long (or is being handled) without processing
+	// them again: GitHub redeliveries and SQS at-least-once duplicates. The
+	// cache keeps at most DedupeSize IDs (default 10000). 0 disables.
+	DedupeTTL  time.Duration
+	DedupeSize int
+	dedupe     *deliveryCache
+
+	// AgeWarnThreshold logs a warning when a received message was sent
+	// longer ago than this (0 = never); see observeAge.
+	AgeWarnThreshold time.Duration
+
+	Filter    queue.Filter // ingest-time event/action allowlist (nil = all)
 	Processor Handler
 }
 
@@ -43,7 +112,22 @@ func (w *Worker) Run(ctx context.Context) error {
 		"waitSeconds", w.vOrDefault(w.WaitTimeSeconds, 10),
 		"visibility", w.vOrDefault(w.VisibilityTimeout, 120),
 		"deleteOn4xx", w.DeleteOn4xx,
+		"concurrency", max(w.Concurrency, 1),
+		"extendOnProcessing", w.ExtendOnProcessing,
+		"warnReceives", w.WarnReceives,
+		"maxReceives", w.MaxReceives,
+		"quarantine", w.Quarantine != nil,
+		"breakerThreshold", w.BreakerThreshold,
+		"dedupeTTL", w.DedupeTTL,
 	)
+	if w.DedupeTTL > 0 && w.dedupe == nil {
+		w.dedupe = newDeliveryCache(w.DedupeTTL, w.DedupeSize)
+	}
+
+	brk := newReceiveBreaker(w.QueueURL, w.ReceiveBackoffMax, w.BreakerThreshold, w.BreakerPause)
+	sem := make(chan struct{}, max(w.Concurrency, 1))
+	var wg sync.WaitGroup
+	defer wg.Wait() // let in-flight messages finish before returning
 
 	for {
 		select {
@@ -58,108 +142,295 @@ func (w *Worker) Run(ctx context.Context) error {
 			MaxNumberOfMessages: w.vOrDefault(w.MaxMessages, 10),
 			WaitTimeSeconds:     w.vOrDefault(w.WaitTimeSeconds, 10),
 			VisibilityTimeout:   w.vOrDefault(w.VisibilityTimeout, 120),
+			MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+				types.MessageSystemAttributeNameApproximateReceiveCount,
+				types.MessageSystemAttributeNameSentTimestamp,
+			},
 		})
 		if err != nil {
-			slog.Error("sqs.receive.error", "err", err)
-			// small backoff to avoid a hot loop on persistent errors
+			wait := brk.failure(err)
+			slog.Error("sqs.receive.error", "queue", w.QueueURL, "err", err, "failures", brk.failures, "retryIn", wait)
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
-			case <-time.After(2 * time.Second):
+			case <-time.After(wait):
 			}
 			continue
 		}
+		brk.success()
+		w.observeAge(out.Messages, time.Now())
 		if len(out.Messages) == 0 {
 			continue // long-poll timeout; loop again
 		}
 
-		for _, m := range out.Messages {
-			if m.ReceiptHandle == nil {
-				slog.Warn("sqs.message.missing_receipt_handle", "messageID", aws.ToString(m.MessageId))
-				continue
-			}
-			body := []byte(aws.ToString(m.Body))
-			msgID := aws.ToString(m.MessageId)
-
-			code, procErr := w.handleSQSMessage(ctx, body, msgID)
-
-			// Decide deletion based on status and policy.
-			shouldDelete := false
-			switch {
-			case code >= 200 && code < 300:
-				shouldDelete = true
-			case code >= 400 && code < 500:
-				shouldDelete = w.DeleteOn4xx
-			default:
-				// 5xx/unknown -> keep for retry (visibility will expire)
-			}
+		if err := w.dispatch(ctx, out.Messages, sem, &wg); err != nil {
+			slog.Info("sqs.worker.stop", "reason", "context_done")
+			return err
+		}
+	}
+}
 
-			if procErr != nil {
-				slog.Warn("sqs.message.process_error",
-					"status", code,
-					"err", procErr,
-					"delete", shouldDelete,
-					"messageID", msgID,
-				)
-			} else {
-				slog.Info("sqs.message.processed",
-					"status", code,
-					"delete", shouldDelete,
-					"messageID", msgID,
-				)
-			}
+// dispatch starts one goroutine per received message (bounded by sem) and a
+// final one that deletes the finished messages in a single batch. Messages
+// take slots in ingest.Priority order, so when the Processor handles them
+// synchronously a housekeeping message waits for the picks ahead of it
+// (asynchronous handling frees each slot at once). It returns early only
+// when ctx is canceled while waiting for a slot; messages already started
+// still finish and are deleted.
+func (w *Worker) dispatch(ctx context.Context, msgs []types.Message, sem chan struct{}, wg *sync.WaitGroup) error {
+	msgs = prioritize(msgs)
+	b := &deleteBatch{}
+	defer func() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.pending.Wait()
+			// Finished work is deleted even during shutdown.
+			w.flush(context.WithoutCancel(ctx), b)
+		}()
+	}()
+	for _, m := range msgs {
+		// Bounded pool: block receiving more until a slot frees up.
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		wg.Add(1)
+		b.pending.Add(1)
+		go func(m types.Message) {
+			defer func() { <-sem; b.pending.Done(); wg.Done() }()
+			w.processMessage(ctx, m, b)
+		}(m)
+	}
+	return nil
+}
 
-			if shouldDelete {
-				if derr := w.deleteMessage(ctx, aws.ToString(m.ReceiptHandle)); derr != nil {
-					slog.Error("sqs.message.delete_error", "err", derr, "messageID", msgID)
-				}
-			}
+// observeAge sets sqs_received_message_age_seconds to the age of the oldest
+// message in a receive (0 when the queue had nothing). SQS only publishes
+// ApproximateAgeOfOldestMessage to CloudWatch, not via GetQueueAttributes,
+// so this is measured from the SentTimestamp of what we actually receive:
+// how late the worker picks work up, retries included.
+func (w *Worker) observeAge(msgs []types.Message, now time.Time) {
+	var oldest time.Duration
+	for _, m := range msgs {
+		ms, err := strconv.ParseInt(m.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)], 10, 64)
+		if err != nil {
+			continue
 		}
+		oldest = max(oldest, now.Sub(time.UnixMilli(ms)))
+	}
+	receivedAge.Set(oldest.Seconds(), "queue", w.QueueURL)
+	if w.AgeWarnThreshold > 0 && oldest > w.AgeWarnThreshold {
+		slog.Warn("sqs.backlog.old_messages", "queue", w.QueueURL, "oldestAge", oldest.Round(time.Second), "threshold", w.AgeWarnThreshold)
 	}
 }
 
-// handleSQSMessage parses the envelope and dispatches to the Processor.
-// It does not touch SQS; the caller controls deletion based on the return code.
-func (w *Worker) handleSQSMessage(ctx context.Context, msgBody []byte, msgID string) (int, error) {
-	event, delivery, payload, err := qparser.ParseSQSBody(msgBody)
+// prioritize orders a batch by ingest.Priority, keeping the received order
+// within a class.
+func prioritize(msgs []types.Message) []types.Message {
+	type ranked struct {
+		m    types.Message
+		prio int
+	}
+	rs := make([]ranked, len(msgs))
+	for i, m := range msgs {
+		rs[i] = ranked{m, ingest.Priority([]byte(aws.ToString(m.Body)))}
+	}
+	slices.SortStableFunc(rs, func(a, b ranked) int { return a.prio - b.prio })
+	out := make([]types.Message, len(rs))
+	for i, r := range rs {
+		out[i] = r.m
+	}
+	return out
+}
+
+// deleteBatch collects the receipt handles of one receive cycle's messages
+// that should be deleted. With ExtendOnProcessing, each message's heartbeat
+// keeps running until the batch is flushed, so a message that finished early
+// does not become visible again while it waits for slower siblings.
+type deleteBatch struct {
+	pending sync.WaitGroup
+
+	mu      sync.Mutex
+	entries []types.DeleteMessageBatchRequestEntry
+	msgIDs  []string
+	holds   []func()
+}
+
+func (b *deleteBatch) add(receipt, msgID string, hold func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = append(b.entries, types.DeleteMessageBatchRequestEntry{
+		Id:            aws.String(strconv.Itoa(len(b.entries))), // unique within the batch
+		ReceiptHandle: aws.String(receipt),
+	})
+	b.msgIDs = append(b.msgIDs, msgID)
+	if hold != nil {
+		b.holds = append(b.holds, hold)
+	}
+}
+
+// flush deletes the collected messages (at most 10 per receive, the SQS batch
+// limit) and then releases their heartbeats. Entries SQS reports as failed
+// are logged, left to become visible again, and returned by message ID.
+func (w *Worker) flush(ctx context.Context, b *deleteBatch) (failed map[string]bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer func() {
+		for _, stop := range b.holds {
+			stop()
+		}
+	}()
+	if len(b.entries) == 0 {
+		return nil
+	}
+	out, err := w.Client.DeleteMessageBatch(ctx, &awssqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(w.QueueURL),
+		Entries:  b.entries,
+	})
 	if err != nil {
-		// Treat "unknown event" as a benign no-op (204, no error).
-		if errors.Is(err, qparser.ErrUnknownEvent) {
-			slog.Info("sqs.message.unknown_event", "messageID", msgID)
-			return 204, nil
+		slog.Error("sqs.message.delete_error", "err", err, "messages", len(b.entries))
+		failed = make(map[string]bool, len(b.msgIDs))
+		for _, id := range b.msgIDs {
+			failed[id] = true
 		}
-		// All other parse/shape errors are bad envelopes (400) so deletion
-		// policy can drop them to avoid poison loops.
-		slog.Error("sqs.message.bad_envelope", "err", err, "messageID", msgID)
-		return 400, err
-	}
-	if delivery == "" {
-		delivery = msgID
-	}
-	if len(payload) == 0 {
-		// Nothing useful to process.
-		return 204, nil
-	}
-
-	// Dispatch to the processor.
-	code, perr := w.Processor.HandleEvent(ctx, event, delivery, payload)
-	if code == 0 {
-		// Defensive default: success when processor forgot to set code.
-		if perr == nil {
-			code = 200
-		} else {
-			code = 500
+		return failed
+	}
+	failed = map[string]bool{}
+	for _, f := range out.Failed {
+		msgID := ""
+		if i, convErr := strconv.Atoi(aws.ToString(f.Id)); convErr == nil && i < len(b.msgIDs) {
+			msgID = b.msgIDs[i]
 		}
+		failed[msgID] = true
+		slog.Error("sqs.message.delete_error",
+			"code", aws.ToString(f.Code),
+			"err", aws.ToString(f.Message),
+			"senderFault", f.SenderFault,
+			"messageID", msgID,
+		)
 	}
-	return code, perr
+	slog.Debug("sqs.batch.deleted", "messages", len(b.entries)-len(out.Failed), "failed", len(out.Failed))
+	return failed
 }
 
-func (w *Worker) deleteMessage(ctx context.Context, receipt string) error {
-	_, err := w.Client.DeleteMessage(ctx, &awssqs.DeleteMessageInput{
-		QueueUrl:      aws.String(w.QueueURL),
-		ReceiptHandle: aws.String(receipt),
-	})
-	return err
+// processMessage dispatches one message and adds it to b when ShouldAck says
+// so; 5xx/unknown are kept for retry (visibility will expire).
+func (w *Worker) processMessage(ctx context.Context, m types.Message, b *deleteBatch) {
+	msgID := aws.ToString(m.MessageId)
+	if m.ReceiptHandle == nil {
+		slog.Warn("sqs.message.missing_receipt_handle", "messageID", msgID)
+		return
+	}
+	var stopHeartbeat func()
+	if w.ExtendOnProcessing {
+		stopHeartbeat = w.heartbeat(ctx, aws.ToString(m.ReceiptHandle), msgID)
+	}
+	code, procErr := w.handleSQSMessage(ctx, []byte(aws.ToString(m.Body)), msgID)
+	shouldDelete := ingest.ShouldAck(code, w.DeleteOn4xx)
+	switch {
+	case shouldDelete && code >= 400:
+		shouldDelete = w.quarantine(ctx, m, quarantineRejected, code, procErr)
+	case !shouldDelete:
+		shouldDelete = w.escalate(ctx, m, code, procErr)
+	}
+
+	if procErr != nil {
+		slog.Warn("sqs.message.process_error",
+			"status", code,
+			"err", procErr,
+			"delete", shouldDelete,
+			"messageID", msgID,
+		)
+	} else {
+		slog.Info("sqs.message.processed",
+			"status", code,
+			"delete", shouldDelete,
+			"messageID", msgID,
+		)
+	}
+
+	if shouldDelete {
+		messagesHandled.Inc("queue", w.QueueURL, "outcome", "deleted")
+		b.add(aws.ToString(m.ReceiptHandle), msgID, stopHeartbeat)
+		return
+	}
+	messagesHandled.Inc("queue", w.QueueURL, "outcome", "retried")
+	if stopHeartbeat != nil {
+		stopHeartbeat()
+	}
+}
+
+// escalate applies WarnReceives/MaxReceives to a message that would be kept
+// for retry, and reports whether it should be deleted after all.
+func (w *Worker) escalate(ctx context.Context, m types.Message, code int, procErr error) bool {
+	attempts := receiveCount(m)
+	msgID := aws.ToString(m.MessageId)
+	if w.MaxReceives > 0 && attempts >= w.MaxReceives {
+		if !w.quarantine(ctx, m, quarantineMaxReceives, code, procErr) {
+			return false // retried, and given up again once the copy is stored
+		}
+		slog.Error("sqs.message.gave_up", "status", code, "attempts", attempts, "err", procErr, "messageID", msgID)
+		// Deleting during shutdown still needs the notification to go out.
+		ingest.NotifyGiveUp(context.WithoutCancel(ctx), w.Processor, []byte(aws.ToString(m.Body)), msgID, attempts, procErr)
+		return true
+	}
+	if w.WarnReceives > 0 && attempts >= w.WarnReceives {
+		slog.Warn("sqs.message.retrying", "status", code, "attempts", attempts, "maxReceives", w.MaxReceives, "messageID", msgID)
+	}
+	return false
+}
+
+// receiveCount is the message's ApproximateReceiveCount (0 if absent).
+func receiveCount(m types.Message) int {
+	n, _ := strconv.Atoi(m.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+	return n
+}
+
+// handleSQSMessage parses the envelope and dispatches to the Processor.
+// It does not touch SQS; the caller controls deletion based on the return code.
+func (w *Worker) handleSQSMessage(ctx context.Context, msgBody []byte, msgID string) (int, error) {
+	var h Handler = w.Processor
+	if w.dedupe != nil {
+		h = dedupeHandler{next: h, cache: w.dedupe, queue: w.QueueURL}
+	}
+	return ingest.Dispatch(ctx, h, w.Filter, msgBody, msgID)
+}
+
+// heartbeat extends the message's visibility until the returned stop func is
+// called. It keeps going through shutdown: the message is still in flight.
+func (w *Worker) heartbeat(ctx context.Context, receipt, msgID string) (stop func()) {
+	vis := w.vOrDefault(w.VisibilityTimeout, 120)
+	every := w.heartbeatEvery
+	if every <= 0 {
+		every = time.Duration(vis) * time.Second / 2
+	}
+	hctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for {
+			select {
+			case <-hctx.Done():
+				return
+			case <-t.C:
+				_, err := w.Client.ChangeMessageVisibility(hctx, &awssqs.ChangeMessageVisibilityInput{
+					QueueUrl:          aws.String(w.QueueURL),
+					ReceiptHandle:     aws.String(receipt),
+					VisibilityTimeout: vis,
+				})
+				if err != nil && hctx.Err() == nil {
+					slog.Warn("sqs.message.extend_visibility_error", "err", err, "messageID", msgID)
+				} else if err == nil {
+					slog.Debug("sqs.message.visibility_extended", "messageID", msgID, "seconds", vis)
+				}
+			}
+		}
+	}()
+	return func() { cancel(); <-done }
 }
 
 func (w *Worker) vOrDefault(v, def int32) int32 {
@@ -3,7 +3,18 @@ package sqs
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	aws "github.com/aws/aws-sdk-go-v2/aws"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 )
 
 // ---- a tiny fake processor ----
@@ -116,3 +127,312 @@ func Test_vOrDefault(t *testing.T) {
 		t.Fatalf("vOrDefault(7,10) = %d, want 7", got)
 	}
 }
+
+// fakeSQS hands out one batch, then blocks receives until ctx is canceled.
+type fakeSQS struct {
+	mu      sync.Mutex
+	batch   []types.Message
+	served  bool
+	deleted []string
+	batches int
+}
+
+func (f *fakeSQS) ReceiveMessage(ctx context.Context, in *awssqs.ReceiveMessageInput, _ ...func(*awssqs.Options)) (*awssqs.ReceiveMessageOutput, error) {
+	f.mu.Lock()
+	if !f.served {
+		f.served = true
+		f.mu.Unlock()
+		return &awssqs.ReceiveMessageOutput{Messages: f.batch}, nil
+	}
+	f.mu.Unlock()
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f *fakeSQS) ChangeMessageVisibility(
+	ctx context.Context, in *awssqs.ChangeMessageVisibilityInput, _ ...func(*awssqs.Options),
+) (*awssqs.ChangeMessageVisibilityOutput, error) {
+	return &awssqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func (f *fakeSQS) DeleteMessageBatch(
+	ctx context.Context, in *awssqs.DeleteMessageBatchInput, _ ...func(*awssqs.Options),
+) (*awssqs.DeleteMessageBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches++
+	for _, e := range in.Entries {
+		f.deleted = append(f.deleted, aws.ToString(e.ReceiptHandle))
+	}
+	return &awssqs.DeleteMessageBatchOutput{}, nil
+}
+
+// blockingHandler records peak parallelism; deliveries "fail" answer 500.
+type blockingHandler struct {
+	mu            sync.Mutex
+	inFlight, max int
+	done          chan struct{}
+	release       chan struct{}
+}
+
+func (h *blockingHandler) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	h.mu.Lock()
+	h.inFlight++
+	h.max = max(h.max, h.inFlight)
+	h.mu.Unlock()
+	<-h.release
+	h.mu.Lock()
+	h.inFlight--
+	h.mu.Unlock()
+	h.done <- struct{}{}
+	if delivery == "fail" {
+		return 500, errors.New("boom")
+	}
+	return 202, nil
+}
+
+func TestRun_ProcessesConcurrentlyAndDeletesPerMessage(t *testing.T) {
+	msg := func(id, delivery string) types.Message {
+		body := `{"headers":{"X-GitHub-Event":"pull_request","X-GitHub-Delivery":"` + delivery + `"},"body":{"pull_request":{}}}`
+		return types.Message{MessageId: aws.String(id), ReceiptHandle: aws.String("rh-" + id), Body: aws.String(body)}
+	}
+	fs := &fakeSQS{batch: []types.Message{msg("1", "a"), msg("2", "fail"), msg("3", "c"), msg("4", "d")}}
+	h := &blockingHandler{done: make(chan struct{}, 4), release: make(chan struct{})}
+	w := &Worker{Client: fs, QueueURL: "q", Concurrency: 2, Processor: h}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- w.Run(ctx) }()
+
+	// Both slots fill before anything is released.
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(time.Millisecond) {
+		h.mu.Lock()
+		n := h.inFlight
+		h.mu.Unlock()
+		if n == 2 || time.Now().After(deadline) {
+			break
+		}
+	}
+	for range 4 {
+		h.release <- struct{}{}
+		<-h.done
+	}
+	cancel()
+	<-errc
+
+	if h.max != 2 {
+		t.Fatalf("peak parallelism = %d, want 2", h.max)
+	}
+	sort.Strings(fs.deleted)
+	if strings.Join(fs.deleted, ",") != "rh-1,rh-3,rh-4" {
+		t.Fatalf("deleted = %v, want all but the failed message", fs.deleted)
+	}
+	if fs.batches != 1 {
+		t.Fatalf("DeleteMessageBatch calls = %d, want 1 per receive", fs.batches)
+	}
+}
+
+type visibilitySQS struct {
+	fakeSQS
+	extended atomic.Int32
+}
+
+func (f *visibilitySQS) ChangeMessageVisibility(
+	ctx context.Context, in *awssqs.ChangeMessageVisibilityInput, _ ...func(*awssqs.Options),
+) (*awssqs.ChangeMessageVisibilityOutput, error) {
+	if aws.ToString(in.ReceiptHandle) == "rh-1" && in.VisibilityTimeout == 30 {
+		f.extended.Add(1)
+	}
+	return &awssqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+type slowHandler struct{ d time.Duration }
+
+func (h slowHandler) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	time.Sleep(h.d)
+	return 200, nil
+}
+
+func TestProcessMessage_ExtendsVisibilityWhileProcessing(t *testing.T) {
+	fs := &visibilitySQS{}
+	w := &Worker{Client: fs, QueueURL: "q", VisibilityTimeout: 30, ExtendOnProcessing: true,
+		heartbeatEvery: 10 * time.Millisecond, Processor: slowHandler{d: 60 * time.Millisecond}}
+
+	b := &deleteBatch{}
+	w.processMessage(context.Background(), types.Message{
+		MessageId: aws.String("1"), ReceiptHandle: aws.String("rh-1"),
+		Body: aws.String(`{"action":"closed","pull_request":{}}`),
+	}, b)
+	if n := fs.extended.Load(); n < 2 {
+		t.Fatalf("visibility extended %d times, want several during processing", n)
+	}
+	// Held until the batch is flushed so it cannot reappear while waiting.
+	time.Sleep(30 * time.Millisecond)
+	if len(b.holds) != 1 {
+		t.Fatalf("heartbeat not held for the pending delete")
+	}
+	w.flush(context.Background(), b)
+	n := fs.extended.Load()
+	time.Sleep(30 * time.Millisecond)
+	if fs.extended.Load() != n {
+		t.Fatalf("heartbeat kept running after the batch was deleted")
+	}
+	if len(fs.deleted) != 1 {
+		t.Fatalf("message not deleted after success")
+	}
+}
+
+type failingBatchSQS struct{ fakeSQS }
+
+func (f *failingBatchSQS) DeleteMessageBatch(
+	ctx context.Context, in *awssqs.DeleteMessageBatchInput, _ ...func(*awssqs.Options),
+) (*awssqs.DeleteMessageBatchOutput, error) {
+	return &awssqs.DeleteMessageBatchOutput{Failed: []types.BatchResultErrorEntry{
+		{Id: in.Entries[1].Id, Code: aws.String("ReceiptHandleIsInvalid")},
+	}}, nil
+}
+
+func TestFlush_SkipsEmptyAndToleratesPartialFailure(t *testing.T) {
+	fs := &failingBatchSQS{}
+	w := &Worker{Client: fs, QueueURL: "q"}
+	w.flush(context.Background(), &deleteBatch{}) // no call for an empty batch
+
+	b := &deleteBatch{}
+	b.add("rh-1", "1", nil)
+	b.add("rh-2", "2", nil)
+	if aws.ToString(b.entries[0].Id) == aws.ToString(b.entries[1].Id) {
+		t.Fatalf("batch entry IDs must be unique: %+v", b.entries)
+	}
+	w.flush(context.Background(), b)
+}
+
+type giveUpSpy struct {
+	fakeHandler
+	attempts int
+}
+
+func (g *giveUpSpy) GiveUp(ctx context.Context, event, delivery string, payload []byte, attempts int, lastErr error) {
+	g.attempts = attempts
+}
+
+func TestProcessMessage_GivesUpAtMaxReceives(t *testing.T) {
+	msg := func(count string) types.Message {
+		return types.Message{
+			MessageId: aws.String("1"), ReceiptHandle: aws.String("rh-1"),
+			Body:       aws.String(`{"action":"closed","pull_request":{}}`),
+			Attributes: map[string]string{"ApproximateReceiveCount": count},
+		}
+	}
+	h := &giveUpSpy{fakeHandler: fakeHandler{code: 500, err: errors.New("boom")}}
+	w := &Worker{Client: &fakeSQS{}, QueueURL: "q", WarnReceives: 2, MaxReceives: 4, Processor: h}
+
+	b := &deleteBatch{}
+	w.processMessage(context.Background(), msg("3"), b)
+	if len(b.entries) != 0 || h.attempts != 0 {
+		t.Fatalf("gave up before MaxReceives: entries=%d attempts=%d", len(b.entries), h.attempts)
+	}
+	w.processMessage(context.Background(), msg("4"), b)
+	if len(b.entries) != 1 || h.attempts != 4 {
+		t.Fatalf("want delete + GiveUp at MaxReceives: entries=%d attempts=%d", len(b.entries), h.attempts)
+	}
+
+	// Without MaxReceives the queue's redrive policy stays in charge.
+	w.MaxReceives = 0
+	b = &deleteBatch{}
+	w.processMessage(context.Background(), msg("40"), b)
+	if len(b.entries) != 0 {
+		t.Fatalf("deleted a failing message with MaxReceives=0")
+	}
+}
+
+func TestPrioritize_MergedPRsBeforeHousekeeping(t *testing.T) {
+	msg := func(id, event, body string) types.Message {
+		return types.Message{MessageId: aws.String(id), Body: aws.String(`{"headers":{"X-GitHub-Event":"` + event + `"},"body":` + body + `}`)}
+	}
+	got := prioritize([]types.Message{
+		msg("create", "create", `{"ref_type":"branch"}`),
+		msg("label", "label", `{"action":"deleted"}`),
+		msg("opened", "pull_request", `{"action":"opened","pull_request":{}}`),
+		msg("merged-1", "pull_request", `{"action":"closed","pull_request":{"merged":true}}`),
+		msg("merged-2", "pull_request", `{"action":"labeled","pull_request":{"merged":true}}`),
+	})
+	var ids []string
+	for _, m := range got {
+		ids = append(ids, aws.ToString(m.MessageId))
+	}
+	want := []string{"merged-1", "merged-2", "opened", "create", "label"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Fatalf("order = %v, want %v", ids, want)
+	}
+}
+
+// orderHandler blocks merged-PR picks until release and records when each
+// delivery starts and ends, as a synchronous Processor would.
+type orderHandler struct {
+	mu      sync.Mutex
+	events  []string
+	release chan struct{}
+	done    chan struct{}
+}
+
+func (h *orderHandler) HandleEvent(ctx context.Context, event, delivery string, payload []byte) (int, error) {
+	h.mu.Lock()
+	h.events = append(h.events, "start "+delivery)
+	h.mu.Unlock()
+	if delivery == "pick" {
+		<-h.release
+	}
+	h.mu.Lock()
+	h.events = append(h.events, "end "+delivery)
+	h.mu.Unlock()
+	h.done <- struct{}{}
+	return 200, nil
+}
+
+func TestRun_HousekeepingWaitsBehindPick(t *testing.T) {
+	msg := func(id, event, body string) types.Message {
+		return types.Message{MessageId: aws.String(id), ReceiptHandle: aws.String("rh-" + id),
+			Body: aws.String(`{"headers":{"X-GitHub-Event":"` + event + `","X-GitHub-Delivery":"` + id + `"},"body":` + body + `}`)}
+	}
+	fs := &fakeSQS{batch: []types.Message{
+		msg("retention", "label", `{"action":"deleted"}`),
+		msg("pick", "pull_request", `{"action":"closed","pull_request":{"merged":true}}`),
+	}}
+	h := &orderHandler{release: make(chan struct{}), done: make(chan struct{}, 2)}
+	w := &Worker{Client: fs, QueueURL: "q", Concurrency: 1, Processor: h}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- w.Run(ctx) }()
+
+	time.Sleep(20 * time.Millisecond) // give the housekeeping message a chance to jump ahead
+	h.release <- struct{}{}
+	<-h.done
+	<-h.done
+	cancel()
+	<-errc
+
+	want := "start pick,end pick,start retention,end retention"
+	if got := strings.Join(h.events, ","); got != want {
+		t.Fatalf("order = %s, want %s", got, want)
+	}
+}
+
+func TestObserveAge_OldestReceivedMessage(t *testing.T) {
+	w := &Worker{QueueURL: "age-q"}
+	now := time.UnixMilli(1_700_000_000_000)
+	sent := func(ago time.Duration) types.Message {
+		return types.Message{Attributes: map[string]string{
+			string(types.MessageSystemAttributeNameSentTimestamp): strconv.FormatInt(now.Add(-ago).UnixMilli(), 10),
+		}}
+	}
+
+	w.observeAge([]types.Message{sent(5 * time.Second), sent(90 * time.Second), {}}, now)
+	if got := receivedAge.Value("queue", "age-q"); got != 90 {
+		t.Fatalf("age = %v, want 90", got)
+	}
+	w.observeAge(nil, now)
+	if got := receivedAge.Value("queue", "age-q"); got != 0 {
+		t.Fatalf("age after empty receive = %v, want 0", got)
+	}
+}
@@ -0,0 +1,204 @@
+// Package metrics is a tiny, dependency-free Prometheus text-format registry.
+// It only supports what the app needs: counters and gauges with labels.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type kind string
+
+const (
+	kindCounter kind = "counter"
+	kindGauge   kind = "gauge"
+)
+
+// Registry holds metric families; safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]*family{}}
+}
+
+// Default is the process-wide registry served on /metrics.
+var Default = NewRegistry()
+
+type family struct {
+	name   string
+	help   string
+	kind   kind
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labels string // rendered {k="v",...} or ""
+	value  float64
+}
+
+// Counter is a monotonically increasing metric family.
+type Counter struct{ f *family }
+
+// Gauge is a metric family whose values can go up and down.
+type Gauge struct{ f *family }
+
+// Counter returns (registering on first use) the counter family name.
+func (r *Registry) Counter(name, help string) Counter {
+	return Counter{f: r.family(name, help, kindCounter)}
+}
+
+// Gauge returns (registering on first use) the gauge family name.
+func (r *Registry) Gauge(name, help string) Gauge {
+	return Gauge{f: r.family(name, help, kindGauge)}
+}
+
+// Inc adds 1. labels are alternating key/value pairs, as with slog.
+func (c Counter) Inc(labels ...string) { c.Add(1, labels...) }
+
+// Add adds delta (ignored if negative).
+func (c Counter) Add(delta float64, labels ...string) {
+	if delta < 0 {
+		return
+	}
+	c.f.update(labels, func(v float64) float64 { return v + delta })
+}
+
+// Set replaces the gauge value for the given labels.
+func (g Gauge) Set(value float64, labels ...string) {
+	g.f.update(labels, func(float64) float64 { return value })
+}
+
+// Add adjusts the gauge value for the given labels by delta.
+func (g Gauge) Add(delta float64, labels ...string) {
+	g.f.update(labels, func(v float64) float64 { return v + delta })
+}
+
+// Value returns the current value for the given labels (0 if unset).
+// Mostly useful in tests.
+func (c Counter) Value(labels ...string) float64 { return c.f.value(labels) }
+
+// Value returns the current value for the given labels (0 if unset).
+func (g Gauge) Value(labels ...string) float64 { return g.f.value(labels) }
+
+func (r *Registry) family(name, help string, k kind) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		return f
+	}
+	f := &family{name: name, help: help, kind: k, series: map[string]*series{}}
+	r.families[name] = f
+	return f
+}
+
+func (f *family) update(labels []string, fn func(float64) float64) {
+	key := renderLabels(labels)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: key}
+		f.series[key] = s
+	}
+	s.value = fn(s.value)
+}
+
+func (f *family) value(labels []string) float64 {
+	key := renderLabels(labels)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.series[key]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// renderLabels renders key/value pairs in sorted key order so the same label
+// set always maps to the same series. A trailing odd key is ignored.
+func renderLabels(kv []string) string {
+	if len(kv) < 2 {
+		return ""
+	}
+	type pair struct{ k, v string }
+	pairs := make([]pair, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		pairs = append(pairs, pair{kv[i], kv[i+1]})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].k < pairs[j].k })
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, p := range pairs {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(p.k)
+		sb.WriteString(`="`)
+		sb.WriteString(escape(p.v))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// WriteTo renders all families in Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for n := range r.families {
+		names = append(names, n)
+	}
+	fams := make([]*family, 0, len(names))
+	sort.Strings(names)
+	for _, n := range names {
+		fams = append(fams, r.families[n])
+	}
+	r.mu.Unlock()
+
+	var total int64
+	for _, f := range fams {
+		f.mu.Lock()
+		keys := make([]string, 0, len(f.series))
+		for k := range f.series {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		for _, k := range keys {
+			sb.WriteString(f.name)
+			sb.WriteString(k)
+			sb.WriteByte(' ')
+			sb.WriteString(strconv.FormatFloat(f.series[k].value, 'g', -1, 64))
+			sb.WriteByte('\n')
+		}
+		f.mu.Unlock()
+		n, err := io.WriteString(w, sb.String())
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Handler serves the registry on an HTTP endpoint (e.g. /metrics).
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("events_total", "Events seen.")
+	c.Inc("event", "pull_request")
+	c.Add(2, "event", "pull_request")
+	c.Add(-1, "event", "pull_request") // ignored
+	g := r.Gauge("backlog", "Queue backlog.")
+	g.Set(5, "queue", `a"b`)
+
+	var sb strings.Builder
+	if _, err := r.WriteTo(&sb); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"# TYPE backlog gauge",
+		`backlog{queue="a\"b"} 5`,
+		"# TYPE events_total counter",
+		`events_total{event="pull_request"} 3`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func Test_renderLabels_Sorted(t *testing.T) {
+	if got := renderLabels([]string{"b", "2", "a", "1"}); got != `{a="1",b="2"}` {
+		t.Fatalf("renderLabels = %s", got)
+	}
+	if got := renderLabels(nil); got != "" {
+		t.Fatalf("renderLabels(nil) = %q", got)
+	}
+}
@@ -0,0 +1,103 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+)
+
+// aliasLabelPrefix starts team-alias labels, e.g. "backport: devops", which
+// the repository config maps to branch sets. Such names cannot be branches,
+// so they can stand in for targets in a targetsOverride too.
+const aliasLabelPrefix = "backport: "
+
+// aliasLabels returns the alias labels among names.
+func aliasLabels(names []string) []string {
+	var out []string
+	for _, n := range names {
+		if strings.HasPrefix(n, aliasLabelPrefix) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// aliasTargets resolves alias labels to branches as they are now, by label.
+// Aliases the repository config does not define are reported on the PR.
+func (p *Processor) aliasTargets(ctx context.Context, gh GH, owner, repo string, prNum int, labels []string) map[string][]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	cfg := p.loadRepoConfig(ctx, gh, owner, repo, prNum)
+	out := map[string][]string{}
+	for _, label := range labels {
+		name := strings.TrimSpace(strings.TrimPrefix(label, aliasLabelPrefix))
+		alias, ok := cfg.Aliases[name]
+		if !ok {
+			_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, prNum, &github.IssueComment{
+				Body: github.Ptr(fmt.Sprintf("⚠️ Label `%s`: alias `%s` is not defined under `aliases` in `%s`; ignoring it.", label, name, repocfg.Path)),
+			})
+			continue
+		}
+		branches, err := resolveAlias(ctx, gh, owner, repo, alias)
+		if err != nil {
+			slog.Warn("alias.resolve_error", "repo", owner+"/"+repo, "pr", prNum, "alias", name, "err", safeErr(err))
+			continue
+		}
+		slog.Info("alias.resolved", "repo", owner+"/"+repo, "pr", prNum, "alias", name, "targets", branches)
+		out[label] = branches
+	}
+	return out
+}
+
+// resolveAlias expands the alias's globs against the repository's branches,
+// keeping the newest alias.Latest matches of each. Plain names are kept
+// as they are; missing targets are reported when picked.
+func resolveAlias(ctx context.Context, gh GH, owner, repo string, alias repocfg.Alias) ([]string, error) {
+	var out []string
+	for _, pattern := range alias.Branches {
+		i := strings.IndexAny(pattern, `*?[\`)
+		if i < 0 {
+			if !slices.Contains(out, pattern) {
+				out = append(out, pattern)
+			}
+			continue
+		}
+		var matches []string
+		opts := &github.ReferenceListOptions{Ref: "heads/" + pattern[:i], ListOptions: github.ListOptions{PerPage: 100}}
+		for {
+			refs, resp, err := gh.Git().ListMatchingRefs(ctx, owner, repo, opts)
+			if err != nil {
+				return nil, fmt.Errorf("list branches for %s: %w", pattern, err)
+			}
+			for _, ref := range refs {
+				b := strings.TrimPrefix(ref.GetRef(), "refs/heads/")
+				if ok, _ := path.Match(pattern, b); ok {
+					matches = append(matches, b)
+				}
+			}
+			if resp == nil || resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+		// Release numbers are zero-padded, so names sort by age.
+		slices.Sort(matches)
+		if alias.Latest > 0 && len(matches) > alias.Latest {
+			matches = matches[len(matches)-alias.Latest:]
+		}
+		for _, b := range matches {
+			if !slices.Contains(out, b) {
+				out = append(out, b)
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,75 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+)
+
+func TestResolveAlias(t *testing.T) {
+	fgit := &fakeGitFull{refs: map[string]bool{
+		"refs/heads/devops-release/0019": true,
+		"refs/heads/devops-release/0020": true,
+		"refs/heads/devops-release/0021": true,
+		"refs/heads/devops-release/x":    true,
+		"refs/heads/qa-release/0007":     true,
+	}}
+	gh := fakeGH{git: fgit}
+	cases := []struct {
+		name  string
+		alias repocfg.Alias
+		want  []string
+	}{
+		{name: "newest two", alias: repocfg.Alias{Branches: []string{"devops-release/[0-9]*"}, Latest: 2}, want: []string{"devops-release/0020", "devops-release/0021"}},
+		{name: "all matches", alias: repocfg.Alias{Branches: []string{"*-release/00??"}}, want: []string{"devops-release/0019", "devops-release/0020", "devops-release/0021", "qa-release/0007"}},
+		{name: "names and globs", alias: repocfg.Alias{Branches: []string{"main-lts", "qa-release/*", "main-lts"}}, want: []string{"main-lts", "qa-release/0007"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := resolveAlias(context.Background(), gh, "o", "r", c.alias)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, c.want) {
+				t.Fatalf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestProcessMergedPR_AliasLabel(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply"}
+	var picked []string
+	p.CherryRunner = recordingCherry{targets: &picked}
+
+	pr := mergedPR(12, "Fix", "abc1234def", "backport: devops", "backport: nope")
+	fiss := &fakeIssuesFull{}
+	fgit := &fakeGitFull{refs: map[string]bool{
+		"refs/heads/devops-release/0020": true,
+		"refs/heads/devops-release/0021": true,
+		"refs/heads/devops-release/0022": true,
+	}}
+	frepos := &fakeReposFull{files: map[string]string{
+		repocfg.Path: "aliases:\n  devops:\n    branches: [devops-release/*]\n    latest: 2\n",
+	}}
+	gh := fakeGH{pr: &fakePRFull{prGet: pr}, iss: fiss, git: fgit, repos: frepos}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 12, nil, "tok")
+
+	if want := []string{"devops-release/0021", "devops-release/0022"}; !slices.Equal(picked, want) {
+		t.Fatalf("picked %v, want %v", picked, want)
+	}
+	if len(fiss.comments) == 0 || !strings.Contains(fiss.comments[0].GetBody(), "alias `nope` is not defined") {
+		t.Fatalf("expected a comment about the unknown alias, got %v", fiss.comments)
+	}
+
+	// A label added after the merge picks just its alias's branches.
+	picked = nil
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 12, []string{"backport: devops"}, "tok")
+	if len(picked) != 2 {
+		t.Fatalf("picked %v", picked)
+	}
+}
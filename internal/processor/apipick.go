@@ -0,0 +1,259 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+)
+
+// errAPIPickConflict marks a change the API runner cannot apply because the
+// target changed the same files since the commit's parent.
+var errAPIPickConflict = errors.New("target changed the same files")
+
+// apiCherryRunner picks through the Git Data API alone, with no git binary
+// or disk: it replays each commit's changed files onto the target's tree.
+// Only changes to files the target has not touched since the commit's
+// parent apply; anything else is reported as a conflict for a manual pick.
+// Hooks, verification, pre-push checks and ranges need the git runner, and
+// fail a pick that asks for them; the fallbacks are not tried. With
+// opts.Append the picks go on top of opts.WorkBranch, which is then
+// fast-forwarded.
+type apiCherryRunner struct {
+	gh    GH
+	actor cherry.GitActor
+}
+
+// Pick implements CherryPickRunner; token is unused (gh is authenticated).
+func (r apiCherryRunner) Pick(ctx context.Context, owner, repo, _, target, sha string, _ bool, opts cherry.Options) (cherry.Result, error) {
+	if opts.RangeFrom != "" {
+		return cherry.Result{}, errors.New("API picks do not support commit ranges")
+	}
+	// A pick that was asked to be checked must not go out unchecked.
+	if len(opts.Hooks.Commands) > 0 || opts.Verify.Command != "" || opts.PrePush.Command != "" {
+		return cherry.Result{}, errors.New("API picks cannot run hooks, verification or pre-push checks")
+	}
+	from := target
+	if opts.Append && opts.WorkBranch != "" {
+		from = opts.WorkBranch
+	}
+	ref, _, err := r.gh.Git().GetRef(ctx, owner, repo, "refs/heads/"+from)
+	if err != nil {
+		return cherry.Result{}, fmt.Errorf("get %s: %w", from, err)
+	}
+	base := ref.GetObject().GetSHA()
+	tip, _, err := r.gh.Git().GetCommit(ctx, owner, repo, base)
+	if err != nil {
+		return cherry.Result{}, fmt.Errorf("get commit %s: %w", base, err)
+	}
+	head, tree := base, tip.GetTree().GetSHA()
+
+	commits := opts.Commits
+	if len(commits) == 0 {
+		commits = []string{sha}
+	}
+	for _, c := range commits {
+		next, nextTree, aerr := r.apply(ctx, owner, repo, c, head, tree, opts, len(opts.Commits) == 0)
+		if errors.Is(aerr, cherry.ErrNoopCherryPick) {
+			slog.Info("cherry.api_commit_noop", "target", target, "sha", c)
+			continue
+		}
+		if aerr != nil {
+			return cherry.Result{}, fmt.Errorf("cherry-picking %s to %s via the API: %w", c, target, aerr)
+		}
+		head, tree = next, nextTree
+	}
+	if head == base {
+		return cherry.Result{}, cherry.ErrNoopCherryPick
+	}
+
+	short := sha
+	if len(short) > 7 {
+		short = sha[:7]
+	}
+	workBranch := fmt.Sprintf("autocherry/%s/%s", strings.ReplaceAll(target, "/", "-"), short)
+	if opts.WorkBranch != "" {
+		workBranch = opts.WorkBranch
+	}
+	if from == workBranch {
+		if _, _, err := r.gh.Git().UpdateRef(ctx, owner, repo, "refs/heads/"+workBranch, github.UpdateRef{SHA: head}); err != nil {
+			return cherry.Result{}, fmt.Errorf("update branch %s: %w", workBranch, err)
+		}
+		return cherry.Result{WorkBranch: workBranch}, nil
+	}
+	if _, _, err := r.gh.Git().CreateRef(ctx, owner, repo, github.CreateRef{Ref: "refs/heads/" + workBranch, SHA: head}); err != nil {
+		return cherry.Result{}, fmt.Errorf("create branch %s: %w", workBranch, err)
+	}
+	return cherry.Result{WorkBranch: workBranch}, nil
+}
+
+// apply commits sha's change (against its first parent, or opts.Mainline)
+// on top of head, whose tree is tree, returning the new commit and tree.
+// single is a pick of sha alone, not of opts.Commits: only then do
+// opts.Mainline, Author and CoAuthors apply, as with git.
+func (r apiCherryRunner) apply(ctx context.Context, owner, repo, sha, head, tree string, opts cherry.Options, single bool) (string, string, error) {
+	src, _, err := r.gh.Git().GetCommit(ctx, owner, repo, sha)
+	if err != nil {
+		return "", "", fmt.Errorf("get commit: %w", err)
+	}
+	mainline := 1
+	if single && opts.Mainline > 0 {
+		mainline = opts.Mainline
+	}
+	if len(src.Parents) < mainline {
+		return "", "", fmt.Errorf("commit has no parent %d", mainline)
+	}
+	parent, _, err := r.gh.Git().GetCommit(ctx, owner, repo, src.Parents[mainline-1].GetSHA())
+	if err != nil {
+		return "", "", fmt.Errorf("get parent: %w", err)
+	}
+	before, err := r.files(ctx, owner, repo, parent.GetTree().GetSHA())
+	if err != nil {
+		return "", "", err
+	}
+	after, err := r.files(ctx, owner, repo, src.GetTree().GetSHA())
+	if err != nil {
+		return "", "", err
+	}
+	onTarget, err := r.files(ctx, owner, repo, tree)
+	if err != nil {
+		return "", "", err
+	}
+
+	entries, err := changedEntries(before, after, onTarget)
+	if err != nil {
+		return "", "", err
+	}
+	newTree := tree
+	switch {
+	case len(entries) > 0:
+		t, _, terr := r.gh.Git().CreateTree(ctx, owner, repo, tree, entries)
+		if terr != nil {
+			return "", "", fmt.Errorf("create tree: %w", terr)
+		}
+		newTree = t.GetSHA()
+	case opts.KeepRedundant, opts.AllowEmpty && src.GetTree().GetSHA() == parent.GetTree().GetSHA():
+	default:
+		return "", "", cherry.ErrNoopCherryPick
+	}
+
+	commit, _, err := r.gh.Git().CreateCommit(ctx, owner, repo, r.commit(src, sha, head, newTree, opts, single), nil)
+	if err != nil {
+		return "", "", fmt.Errorf("create commit: %w", err)
+	}
+	return commit.GetSHA(), newTree, nil
+}
+
+// commit is the pick of src (sha): its message with git's "cherry picked
+// from" line and the trailers opts asks for, on tree with parent head.
+func (r apiCherryRunner) commit(src *github.Commit, sha, head, tree string, opts cherry.Options, credit bool) github.Commit {
+	author := src.GetAuthor()
+	var trailers []string
+	if credit {
+		if name, email, ok := parseGitIdent(opts.Author); ok {
+			author = &github.CommitAuthor{Name: github.Ptr(name), Email: github.Ptr(email)}
+		}
+		for _, c := range opts.CoAuthors {
+			trailers = append(trailers, "Co-authored-by: "+c)
+		}
+	}
+	if opts.Signoff {
+		trailers = append(trailers, fmt.Sprintf("Signed-off-by: %s <%s>", r.actor.Name, r.actor.Email))
+	}
+	msg := strings.TrimRight(src.GetMessage(), "\n") + "\n\n(cherry picked from commit " + sha + ")"
+	if len(trailers) > 0 {
+		msg += "\n" + strings.Join(trailers, "\n")
+	}
+	if opts.Footer != nil {
+		msg = cherry.WithFooter(msg, sha, opts.Footer(sha))
+	}
+	return github.Commit{
+		Message:   github.Ptr(msg),
+		Tree:      &github.Tree{SHA: github.Ptr(tree)},
+		Parents:   []*github.Commit{{SHA: github.Ptr(head)}},
+		Author:    author,
+		Committer: &github.CommitAuthor{Name: github.Ptr(r.actor.Name), Email: github.Ptr(r.actor.Email)},
+	}
+}
+
+// files maps the paths of a tree's files (blobs, symlinks, submodules) to
+// their entries. A tree too large for one response is an error.
+func (r apiCherryRunner) files(ctx context.Context, owner, repo, sha string) (map[string]*github.TreeEntry, error) {
+	t, _, err := r.gh.Git().GetTree(ctx, owner, repo, sha, true)
+	if err != nil {
+		return nil, fmt.Errorf("get tree %s: %w", sha, err)
+	}
+	if t.GetTruncated() {
+		return nil, fmt.Errorf("tree %s is too large for API picks", sha)
+	}
+	out := make(map[string]*github.TreeEntry, len(t.Entries))
+	for _, e := range t.Entries {
+		if e.GetType() != "tree" {
+			out[e.GetPath()] = e
+		}
+	}
+	return out, nil
+}
+
+// changedEntries are the tree entries that replay the before→after change
+// onto onTarget: each changed path must be as it was before, or already as
+// it is after (then it is left alone).
+func changedEntries(before, after, onTarget map[string]*github.TreeEntry) ([]*github.TreeEntry, error) {
+	var entries []*github.TreeEntry
+	var conflicts []string
+	check := func(path string, was, now *github.TreeEntry) {
+		has := onTarget[path]
+		switch {
+		case sameEntry(has, now):
+			// Already there.
+		case !sameEntry(has, was):
+			conflicts = append(conflicts, path)
+		case now == nil:
+			entries = append(entries, &github.TreeEntry{Path: github.Ptr(path), Mode: was.Mode, Type: was.Type})
+		default:
+			entries = append(entries, &github.TreeEntry{Path: github.Ptr(path), Mode: now.Mode, Type: now.Type, SHA: now.SHA})
+		}
+	}
+	for path, now := range after {
+		if was := before[path]; !sameEntry(was, now) {
+			check(path, was, now)
+		}
+	}
+	for path, was := range before {
+		if after[path] == nil {
+			check(path, was, nil)
+		}
+	}
+	if len(conflicts) > 0 {
+		slices.Sort(conflicts)
+		return nil, fmt.Errorf("%w: %s", errAPIPickConflict, strings.Join(conflicts, ", "))
+	}
+	// Stable requests, whatever the map order.
+	sort.Slice(entries, func(i, j int) bool { return entries[i].GetPath() < entries[j].GetPath() })
+	return entries, nil
+}
+
+// sameEntry reports whether two entries (nil = absent) have the same
+// content and mode.
+func sameEntry(a, b *github.TreeEntry) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.GetSHA() == b.GetSHA() && a.GetMode() == b.GetMode()
+}
+
+// parseGitIdent splits "Name <email>".
+func parseGitIdent(s string) (name, email string, ok bool) {
+	name, rest, ok := strings.Cut(s, " <")
+	if !ok || !strings.HasSuffix(rest, ">") {
+		return "", "", false
+	}
+	return name, strings.TrimSuffix(rest, ">"), true
+}
@@ -0,0 +1,165 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+)
+
+// apiPickGit is a repository where commit abcdef0123 (parent p0) changes
+// a, deletes c and adds d, and the release branch's files are onTarget.
+func apiPickGit(onTarget map[string]string) *fakeGitFull {
+	tree := func(sha string, files map[string]string) *github.Tree {
+		t := &github.Tree{SHA: github.Ptr(sha)}
+		for path, blob := range files {
+			t.Entries = append(t.Entries, &github.TreeEntry{Path: github.Ptr(path), Mode: github.Ptr("100644"), Type: github.Ptr("blob"), SHA: github.Ptr(blob)})
+		}
+		return t
+	}
+	return &fakeGitFull{
+		refs: map[string]bool{"refs/heads/release": true},
+		commits: map[string]*github.Commit{
+			"tip:refs/heads/release": {SHA: github.Ptr("tip:refs/heads/release"), Tree: &github.Tree{SHA: github.Ptr("t-target")}},
+			"p0":                     {SHA: github.Ptr("p0"), Tree: &github.Tree{SHA: github.Ptr("t-p0")}},
+			"abcdef0123": {
+				SHA: github.Ptr("abcdef0123"), Tree: &github.Tree{SHA: github.Ptr("t-src")}, Parents: []*github.Commit{{SHA: github.Ptr("p0")}},
+				Message: github.Ptr("Fix a\n"), Author: &github.CommitAuthor{Name: github.Ptr("Dev"), Email: github.Ptr("dev@example.com")},
+			},
+		},
+		trees: map[string]*github.Tree{
+			"t-p0":     tree("t-p0", map[string]string{"a": "a1", "b": "b1", "c": "c1"}),
+			"t-src":    tree("t-src", map[string]string{"a": "a2", "b": "b1", "d": "d1"}),
+			"t-target": tree("t-target", onTarget),
+		},
+	}
+}
+
+// treeFiles maps a fake tree's paths to their blobs.
+func treeFiles(t *github.Tree) map[string]string {
+	out := map[string]string{}
+	for _, e := range t.Entries {
+		out[e.GetPath()] = e.GetSHA()
+	}
+	return out
+}
+
+func TestAPIPick(t *testing.T) {
+	// b changed on the target too, but the commit does not touch it.
+	fgit := apiPickGit(map[string]string{"a": "a1", "b": "b9", "c": "c1"})
+	r := apiCherryRunner{gh: fakeGH{git: fgit}, actor: cherry.GitActor{Name: "bot", Email: "bot@example.com"}}
+
+	res, err := r.Pick(context.Background(), "o", "r", "", "release", "abcdef0123", false, cherry.Options{Signoff: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.WorkBranch != "autocherry/release/abcdef0" {
+		t.Fatalf("work branch = %q", res.WorkBranch)
+	}
+	if len(fgit.createdCommits) != 1 {
+		t.Fatalf("created commits = %+v", fgit.createdCommits)
+	}
+	c := fgit.createdCommits[0]
+	if want := map[string]string{"a": "a2", "b": "b9", "d": "d1"}; !maps.Equal(treeFiles(fgit.trees[c.GetTree().GetSHA()]), want) {
+		t.Errorf("picked tree = %v; want %v", treeFiles(fgit.trees[c.GetTree().GetSHA()]), want)
+	}
+	if len(c.Parents) != 1 || c.Parents[0].GetSHA() != "tip:refs/heads/release" {
+		t.Errorf("parents = %+v", c.Parents)
+	}
+	if c.GetAuthor().GetName() != "Dev" || c.GetCommitter().GetName() != "bot" {
+		t.Errorf("author = %+v, committer = %+v", c.GetAuthor(), c.GetCommitter())
+	}
+	if want := "Fix a\n\n(cherry picked from commit abcdef0123)\nSigned-off-by: bot <bot@example.com>"; c.GetMessage() != want {
+		t.Errorf("message = %q; want %q", c.GetMessage(), want)
+	}
+	if len(fgit.createdRefs) != 1 || fgit.createdRefs[0] != (github.CreateRef{Ref: "refs/heads/" + res.WorkBranch, SHA: c.GetSHA()}) {
+		t.Errorf("created refs = %+v", fgit.createdRefs)
+	}
+}
+
+func TestAPIPick_Conflict(t *testing.T) {
+	fgit := apiPickGit(map[string]string{"a": "a9", "b": "b1", "c": "c1"})
+	r := apiCherryRunner{gh: fakeGH{git: fgit}}
+
+	_, err := r.Pick(context.Background(), "o", "r", "", "release", "abcdef0123", false, cherry.Options{})
+	if !errors.Is(err, errAPIPickConflict) || !strings.Contains(err.Error(), ": a") {
+		t.Fatalf("err = %v; want a conflict on a", err)
+	}
+	if len(fgit.createdCommits) != 0 || len(fgit.createdRefs) != 0 {
+		t.Errorf("commits = %+v, refs = %+v", fgit.createdCommits, fgit.createdRefs)
+	}
+}
+
+func TestAPIPick_AlreadyOnTarget(t *testing.T) {
+	fgit := apiPickGit(map[string]string{"a": "a2", "b": "b1", "d": "d1"})
+	r := apiCherryRunner{gh: fakeGH{git: fgit}}
+
+	if _, err := r.Pick(context.Background(), "o", "r", "", "release", "abcdef0123", false, cherry.Options{}); !errors.Is(err, cherry.ErrNoopCherryPick) {
+		t.Fatalf("err = %v; want a no-op", err)
+	}
+	// Unless redundant picks are kept.
+	res, err := r.Pick(context.Background(), "o", "r", "", "release", "abcdef0123", false, cherry.Options{KeepRedundant: true})
+	if err != nil || res.WorkBranch == "" || fgit.createdCommits[0].GetTree().GetSHA() != "t-target" {
+		t.Fatalf("res = %+v, err = %v, commits = %+v", res, err, fgit.createdCommits)
+	}
+}
+
+func TestAPIPick_NoRanges(t *testing.T) {
+	r := apiCherryRunner{gh: fakeGH{git: apiPickGit(nil)}}
+	if _, err := r.Pick(context.Background(), "o", "r", "", "release", "abcdef0123", false, cherry.Options{RangeFrom: "p0"}); err == nil {
+		t.Fatal("want an error for a range pick")
+	}
+}
+
+func TestAPIPick_NoChecks(t *testing.T) {
+	for name, opts := range map[string]cherry.Options{
+		"hooks":    {Hooks: cherry.Hooks{Commands: []string{"make generate"}}},
+		"verify":   {Verify: cherry.Verify{Command: "make test"}},
+		"pre-push": {PrePush: cherry.Verify{Command: "make lint"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fgit := apiPickGit(map[string]string{"a": "a1", "b": "b1", "c": "c1"})
+			r := apiCherryRunner{gh: fakeGH{git: fgit}}
+			if _, err := r.Pick(context.Background(), "o", "r", "", "release", "abcdef0123", false, opts); err == nil {
+				t.Fatal("want an error, not an unchecked pick")
+			}
+			if len(fgit.createdRefs) != 0 {
+				t.Fatalf("created refs = %+v", fgit.createdRefs)
+			}
+		})
+	}
+}
+
+func TestAPIPick_Train(t *testing.T) {
+	const train = "autocherry/release/train-202610141200"
+	fgit := apiPickGit(map[string]string{"a": "a1", "b": "b1", "c": "c1"})
+	r := apiCherryRunner{gh: fakeGH{git: fgit}}
+
+	// The window's first pick opens the train branch off the target.
+	res, err := r.Pick(context.Background(), "o", "r", "", "release", "abcdef0123", false, cherry.Options{WorkBranch: train})
+	if err != nil || res.WorkBranch != train {
+		t.Fatalf("res = %+v, err = %v", res, err)
+	}
+	if len(fgit.createdRefs) != 1 || fgit.createdRefs[0].Ref != "refs/heads/"+train {
+		t.Fatalf("created refs = %+v", fgit.createdRefs)
+	}
+
+	// Later ones go on top of it and fast-forward it.
+	fgit.commits["tip:refs/heads/"+train] = &github.Commit{SHA: github.Ptr("tip:refs/heads/" + train), Tree: &github.Tree{SHA: github.Ptr("t-target")}}
+	res, err = r.Pick(context.Background(), "o", "r", "", "release", "abcdef0123", false, cherry.Options{WorkBranch: train, Append: true})
+	if err != nil || res.WorkBranch != train {
+		t.Fatalf("res = %+v, err = %v", res, err)
+	}
+	c := fgit.createdCommits[1]
+	if len(c.Parents) != 1 || c.Parents[0].GetSHA() != "tip:refs/heads/"+train {
+		t.Errorf("parents = %+v; want the train's tip", c.Parents)
+	}
+	if len(fgit.createdRefs) != 1 || len(fgit.updatedRefs) != 1 || fgit.updatedRefs[0].SHA != c.GetSHA() {
+		t.Errorf("created refs = %+v, updated refs = %+v", fgit.createdRefs, fgit.updatedRefs)
+	}
+}
@@ -0,0 +1,37 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// assignBackport attaches people to the new backport PR number of src: its
+// original author as assignee (AssignAuthor) and reviewer (ReviewAuthor),
+// and ReviewTeams as team reviewers. Bots are neither assigned nor asked.
+// Failures (e.g. an author without access any more) are only logged.
+func (p *Processor) assignBackport(ctx context.Context, gh GH, owner, repo string, number int, src pickSource) {
+	author := src.author
+	if strings.HasSuffix(author, "[bot]") {
+		author = ""
+	}
+	if p.AssignAuthor && author != "" {
+		if _, _, err := gh.Issues().AddAssignees(ctx, owner, repo, number, []string{author}); err != nil {
+			slog.Warn("gh.assign_error", "repo", owner+"/"+repo, "pr", number, "assignee", author, "err", safeErr(err))
+		}
+	}
+	var req github.ReviewersRequest
+	if p.ReviewAuthor && author != "" {
+		req.Reviewers = []string{author}
+	}
+	req.TeamReviewers = p.ReviewTeams
+	if len(req.Reviewers) == 0 && len(req.TeamReviewers) == 0 {
+		return
+	}
+	if _, _, err := gh.PR().RequestReviewers(ctx, owner, repo, number, req); err != nil {
+		slog.Warn("gh.request_reviewers_error", "repo", owner+"/"+repo, "pr", number,
+			"reviewers", req.Reviewers, "teams", req.TeamReviewers, "err", safeErr(err))
+	}
+}
@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func TestProcessMergedPR_AssignsAuthor(t *testing.T) {
+	cases := []struct {
+		name      string
+		assign    bool
+		review    bool
+		reviewTo  []string
+		author    string
+		assignees []string
+		reviewers []string
+		teams     []string
+	}{
+		{name: "off", author: "alice"},
+		{name: "author", assign: true, review: true, author: "alice", assignees: []string{"alice"}, reviewers: []string{"alice"}},
+		{name: "teams only", reviewTo: []string{"release-team"}, author: "alice", teams: []string{"release-team"}},
+		{name: "bot author", assign: true, review: true, reviewTo: []string{"release-team"}, author: "renovate[bot]", teams: []string{"release-team"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", AssignAuthor: tc.assign, ReviewAuthor: tc.review, ReviewTeams: tc.reviewTo}
+			pr := mergedPR(11, "Fix", "cafef00d1234567", "cherry-pick to devops-release/0021")
+			pr.User = &github.User{Login: github.Ptr(tc.author)}
+			fpr := &fakePRFull{prGet: pr}
+			fiss := &fakeIssuesFull{}
+			gh := fakeGH{pr: fpr, iss: fiss, git: &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}, repos: &fakeReposFull{}}
+			p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/cafef00"}
+
+			p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+			if !slices.Equal(fiss.assignees, tc.assignees) {
+				t.Fatalf("assignees = %v; want %v", fiss.assignees, tc.assignees)
+			}
+			var reviewers, teams []string
+			for _, r := range fpr.reviewReq {
+				reviewers, teams = append(reviewers, r.Reviewers...), append(teams, r.TeamReviewers...)
+			}
+			if !slices.Equal(reviewers, tc.reviewers) || !slices.Equal(teams, tc.teams) {
+				t.Fatalf("review requests = %v / %v; want %v / %v", reviewers, teams, tc.reviewers, tc.teams)
+			}
+		})
+	}
+}
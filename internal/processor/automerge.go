@@ -0,0 +1,164 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
+)
+
+var autoMerges = metrics.Default.Counter("auto_merges_total",
+	"Auto-merge handling of autocherry PRs, by result (merged, waiting, enabled, error).")
+
+// handlePullRequestReview tries to merge an autocherry PR when it gets an
+// approving review. If checks are still running, the CI success event
+// (handleWorkflowRun/handleCheckSuite) tries again.
+func (p *Processor) handlePullRequestReview(ctx context.Context, deliveryID string, e *github.PullRequestReviewEvent) {
+	if e.GetAction() != "submitted" || !strings.EqualFold(e.GetReview().GetState(), "approved") ||
+		!reWorkBranch.MatchString(e.GetPullRequest().GetHead().GetRef()) {
+		return
+	}
+	num := e.GetPullRequest().GetNumber()
+	p.autoMergeFor(ctx, deliveryID, e.GetInstallation(), e.GetRepo(), func(GH, string, string) (int, error) { return num, nil }, p.autoMerge)
+}
+
+// autoMergeBranch retries autoMerge for the PR of a work branch whose checks
+// just passed.
+func (p *Processor) autoMergeBranch(ctx context.Context, deliveryID string, inst *github.Installation, repo *github.Repository, branch, sha string) {
+	p.autoMergeFor(ctx, deliveryID, inst, repo, p.branchPR(ctx, branch, sha), p.autoMerge)
+}
+
+// enableAutoMergeBranch turns on GitHub auto-merge for the PR of a work
+// branch that has started running checks.
+func (p *Processor) enableAutoMergeBranch(ctx context.Context, deliveryID string, inst *github.Installation, repo *github.Repository, branch, sha string) {
+	p.autoMergeFor(ctx, deliveryID, inst, repo, p.branchPR(ctx, branch, sha), p.enableAutoMerge)
+}
+
+// branchPR resolves the open PR of a work branch at sha.
+func (p *Processor) branchPR(ctx context.Context, branch, sha string) func(gh GH, owner, repo string) (int, error) {
+	return func(gh GH, owner, repo string) (int, error) {
+		pr, err := p.backportPR(ctx, gh, owner, repo, ciFailure{branch: branch, sha: sha})
+		return pr.GetNumber(), err
+	}
+}
+
+// autoMergeFor runs act on the PR prNum resolves (0 = none).
+func (p *Processor) autoMergeFor(
+	ctx context.Context, deliveryID string, inst *github.Installation, repo *github.Repository,
+	prNum func(gh GH, owner, repo string) (int, error), act func(ctx context.Context, gh GH, owner, repo string, prNum int) error,
+) {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	if inst == nil {
+		slog.Warn("auto_merge.no_installation", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name)
+		return
+	}
+	clients, err := p.buildClients(inst.GetID())
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	gh := p.ghFor(clients)
+	num, err := prNum(gh, owner, name)
+	if err == nil && num != 0 {
+		err = act(ctx, gh, owner, name, num)
+	}
+	if err != nil {
+		autoMerges.Inc("result", "error")
+		slog.Error("auto_merge.error", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name, "pr", num, "err", safeErr(err))
+	}
+}
+
+// autoMerge merges an open autocherry PR opened by the app when it is
+// approved (latest review of every reviewer, none requesting changes) and
+// GitHub reports it "clean": mergeable with all checks passing.
+func (p *Processor) autoMerge(ctx context.Context, gh GH, owner, repo string, prNum int) error {
+	pr, _, err := gh.PR().Get(ctx, owner, repo, prNum)
+	if err != nil {
+		return fmt.Errorf("get PR: %w", err)
+	}
+	if pr.GetState() != pullRequestStateOpen || pr.GetUser().GetType() != "Bot" || !reWorkBranch.MatchString(pr.GetHead().GetRef()) {
+		return nil
+	}
+	reviews, _, err := gh.PR().ListReviews(ctx, owner, repo, prNum, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return fmt.Errorf("list reviews: %w", err)
+	}
+	if !approved(reviews) {
+		return nil
+	}
+	if st := pr.GetMergeableState(); st != "clean" {
+		autoMerges.Inc("result", "waiting")
+		slog.Info("auto_merge.waiting", "repo", owner+"/"+repo, "pr", prNum, "mergeable_state", st)
+		return nil
+	}
+	method := p.AutoMergeMethod
+	if method == "" {
+		method = "squash"
+	}
+	res, _, err := gh.PR().Merge(ctx, owner, repo, prNum, "", &github.PullRequestOptions{MergeMethod: method, SHA: pr.GetHead().GetSHA()})
+	if err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+	if !res.GetMerged() {
+		return fmt.Errorf("merge: %s", res.GetMessage())
+	}
+	autoMerges.Inc("result", "merged")
+	slog.Info("auto_merge.merged", "repo", owner+"/"+repo, "pr", prNum, "method", method)
+	return nil
+}
+
+// approved reports whether the latest decisive review of each reviewer
+// leaves the PR approved; comments neither grant nor revoke approval.
+func approved(reviews []*github.PullRequestReview) bool {
+	latest := map[string]string{}
+	for _, r := range reviews {
+		switch st := strings.ToUpper(r.GetState()); st {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			latest[r.GetUser().GetLogin()] = st
+		}
+	}
+	ok := false
+	for _, st := range latest {
+		switch st {
+		case "CHANGES_REQUESTED":
+			return false
+		case "APPROVED":
+			ok = true
+		}
+	}
+	return ok
+}
+
+const enableAutoMergeMutation = `mutation($id: ID!, $method: PullRequestMergeMethod!) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) { clientMutationId }
+}`
+
+// enableAutoMerge turns on GitHub auto-merge for an open autocherry PR opened
+// by the app, so branch protection (required checks and reviews) decides when
+// it merges. PRs that already have it, or are mergeable already (GitHub
+// refuses to enable it then), are left alone.
+func (p *Processor) enableAutoMerge(ctx context.Context, gh GH, owner, repo string, prNum int) error {
+	pr, _, err := gh.PR().Get(ctx, owner, repo, prNum)
+	if err != nil {
+		return fmt.Errorf("get PR: %w", err)
+	}
+	if pr.GetState() != pullRequestStateOpen || pr.GetUser().GetType() != "Bot" || !reWorkBranch.MatchString(pr.GetHead().GetRef()) ||
+		pr.AutoMerge != nil || pr.GetMergeableState() == "clean" || pr.GetDraft() {
+		return nil
+	}
+	method := p.AutoMergeMethod
+	if method == "" {
+		method = "squash"
+	}
+	vars := map[string]any{"id": pr.GetNodeID(), "method": strings.ToUpper(method)}
+	if err := gh.GraphQL().Do(ctx, enableAutoMergeMutation, vars, nil); err != nil {
+		return fmt.Errorf("enable auto-merge: %w", err)
+	}
+	autoMerges.Inc("result", "enabled")
+	slog.Info("auto_merge.enabled", "repo", owner+"/"+repo, "pr", prNum, "method", method)
+	return nil
+}
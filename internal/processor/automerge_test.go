@@ -0,0 +1,105 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func review(login, state string) *github.PullRequestReview {
+	return &github.PullRequestReview{User: &github.User{Login: github.Ptr(login)}, State: github.Ptr(state)}
+}
+
+func TestApproved(t *testing.T) {
+	cases := []struct {
+		name    string
+		reviews []*github.PullRequestReview
+		want    bool
+	}{
+		{name: "none"},
+		{name: "approved", reviews: []*github.PullRequestReview{review("a", "APPROVED")}, want: true},
+		{name: "comment keeps approval", reviews: []*github.PullRequestReview{review("a", "APPROVED"), review("a", "COMMENTED")}, want: true},
+		{name: "changes requested by another", reviews: []*github.PullRequestReview{review("a", "APPROVED"), review("b", "CHANGES_REQUESTED")}},
+		{name: "re-approved", reviews: []*github.PullRequestReview{review("b", "CHANGES_REQUESTED"), review("b", "APPROVED")}, want: true},
+		{name: "dismissed", reviews: []*github.PullRequestReview{review("a", "APPROVED"), review("a", "DISMISSED")}},
+	}
+	for _, c := range cases {
+		if got := approved(c.reviews); got != c.want {
+			t.Errorf("%s: approved = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestAutoMerge(t *testing.T) {
+	backport := func(state, mergeable string, userType string) *github.PullRequest {
+		return &github.PullRequest{
+			Number: github.Ptr(31), State: github.Ptr(state), MergeableState: github.Ptr(mergeable),
+			User: &github.User{Type: github.Ptr(userType)},
+			Head: &github.PullRequestBranch{Ref: github.Ptr("autocherry/rel-1/abc1234"), SHA: github.Ptr("head1")},
+		}
+	}
+	approvedReviews := []*github.PullRequestReview{review("a", "APPROVED")}
+	cases := []struct {
+		name    string
+		pr      *github.PullRequest
+		reviews []*github.PullRequestReview
+		merged  bool
+	}{
+		{name: "approved and clean", pr: backport("open", "clean", "Bot"), reviews: approvedReviews, merged: true},
+		{name: "checks pending", pr: backport("open", "unstable", "Bot"), reviews: approvedReviews},
+		{name: "not approved", pr: backport("open", "clean", "Bot")},
+		{name: "human PR on work branch", pr: backport("open", "clean", "User"), reviews: approvedReviews},
+		{name: "already closed", pr: backport("closed", "clean", "Bot"), reviews: approvedReviews},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fpr := &fakePRFull{prGet: c.pr, reviews: c.reviews}
+			p := &Processor{AutoMergeMethod: "rebase"}
+			if err := p.autoMerge(context.Background(), fakeGH{pr: fpr}, "o", "r", 31); err != nil {
+				t.Fatal(err)
+			}
+			if c.merged != (len(fpr.merged) == 1) {
+				t.Fatalf("merged = %v", fpr.merged)
+			}
+			if c.merged && (fpr.mergeOpts.MergeMethod != "rebase" || fpr.mergeOpts.SHA != "head1") {
+				t.Fatalf("merge options = %+v", fpr.mergeOpts)
+			}
+		})
+	}
+}
+
+func TestEnableAutoMerge(t *testing.T) {
+	backport := func(mergeable string, auto *github.PullRequestAutoMerge) *github.PullRequest {
+		return &github.PullRequest{
+			Number: github.Ptr(31), NodeID: github.Ptr("PR_31"), State: github.Ptr("open"), MergeableState: github.Ptr(mergeable),
+			User: &github.User{Type: github.Ptr("Bot")}, AutoMerge: auto,
+			Head: &github.PullRequestBranch{Ref: github.Ptr("autocherry/rel-1/abc1234"), SHA: github.Ptr("head1")},
+		}
+	}
+	cases := []struct {
+		name    string
+		pr      *github.PullRequest
+		enabled bool
+	}{
+		{name: "checks running", pr: backport("blocked", nil), enabled: true},
+		{name: "already enabled", pr: backport("blocked", &github.PullRequestAutoMerge{MergeMethod: github.Ptr("squash")})},
+		{name: "already mergeable", pr: backport("clean", nil)},
+		{name: "draft", pr: func() *github.PullRequest { pr := backport("draft", nil); pr.Draft = github.Ptr(true); return pr }()},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gql := &fakeGraphQL{}
+			p := &Processor{AutoMergeMethod: "rebase"}
+			if err := p.enableAutoMerge(context.Background(), fakeGH{pr: &fakePRFull{prGet: c.pr}, gql: gql}, "o", "r", 31); err != nil {
+				t.Fatal(err)
+			}
+			if c.enabled != (len(gql.queries) == 1) {
+				t.Fatalf("mutations = %v", gql.queries)
+			}
+			if c.enabled && (gql.vars[0]["id"] != "PR_31" || gql.vars[0]["method"] != "REBASE") {
+				t.Fatalf("vars = %v", gql.vars[0])
+			}
+		})
+	}
+}
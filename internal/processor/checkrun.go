@@ -0,0 +1,131 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// checkRunName is the check run summarizing a commit's backports.
+const checkRunName = "cherry-pick"
+
+// checkRunMu serializes read-modify-write updates of check runs, so parallel
+// targets of one PR do not drop each other's rows.
+var checkRunMu sync.Mutex
+
+// reCheckRow parses a row of the check run's table back; see checkRow.render.
+var reCheckRow = regexp.MustCompile("^\\| `([^`]+)` \\| \\S+ (\\w+) \\| (.*) \\|$")
+
+// checkRow is one target's line in the check run: its commit status state
+// (pending, success, failure or error) and description.
+type checkRow struct {
+	target, state, desc, url string
+}
+
+func (r checkRow) render() string {
+	icon := map[string]string{"pending": "⏳", "success": "✅", "failure": "❌", "error": "⚠️"}[r.state]
+	desc := r.desc
+	if r.url != "" {
+		desc = fmt.Sprintf("[%s](%s)", desc, r.url)
+	}
+	return fmt.Sprintf("| `%s` | %s %s | %s |", r.target, icon, r.state, desc)
+}
+
+// parseCheckRows reads the rows render wrote into a check run's text.
+func parseCheckRows(text string) []checkRow {
+	var rows []checkRow
+	for _, l := range strings.Split(text, "\n") {
+		m := reCheckRow.FindStringSubmatch(l)
+		if m == nil {
+			continue
+		}
+		r := checkRow{target: m[1], state: m[2], desc: m[3]}
+		if d, u, ok := strings.Cut(strings.TrimPrefix(r.desc, "["), "]("); ok && strings.HasPrefix(r.desc, "[") && strings.HasSuffix(u, ")") {
+			r.desc, r.url = d, strings.TrimSuffix(u, ")")
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// updateCheckRun sets target's row in the "cherry-pick" check run on sha,
+// creating the run on first use. The run is in progress while a pick is
+// running, then fails if any target failed and succeeds otherwise (open
+// backport PRs included).
+func (p *Processor) updateCheckRun(ctx context.Context, gh GH, owner, repo, sha string, row checkRow) {
+	checkRunMu.Lock()
+	defer checkRunMu.Unlock()
+
+	opts := &github.ListCheckRunsOptions{CheckName: github.Ptr(checkRunName)}
+	if p.AppID != 0 {
+		opts.AppID = github.Ptr(p.AppID)
+	}
+	runs, _, err := gh.Checks().ListCheckRunsForRef(ctx, owner, repo, sha, opts)
+	if err != nil {
+		slog.Warn("gh.list_check_runs_error", "repo", owner+"/"+repo, "sha", sha, "err", safeErr(err))
+		return
+	}
+	var run *github.CheckRun
+	if runs != nil && len(runs.CheckRuns) > 0 {
+		run = runs.CheckRuns[0]
+	}
+	rows := parseCheckRows(run.GetOutput().GetText())
+	if i := slices.IndexFunc(rows, func(r checkRow) bool { return r.target == row.target }); i >= 0 {
+		rows[i] = row
+	} else {
+		rows = append(rows, row)
+	}
+	status, conclusion, output := checkRunState(rows)
+	update := github.UpdateCheckRunOptions{Name: checkRunName, Status: github.Ptr(status), Output: output}
+	if conclusion != "" {
+		update.Conclusion = github.Ptr(conclusion)
+		update.CompletedAt = &github.Timestamp{Time: time.Now()}
+	}
+	if run == nil {
+		_, _, err = gh.Checks().CreateCheckRun(ctx, owner, repo, github.CreateCheckRunOptions{
+			Name: checkRunName, HeadSHA: sha, Status: update.Status, Conclusion: update.Conclusion, CompletedAt: update.CompletedAt, Output: output,
+		})
+	} else {
+		_, _, err = gh.Checks().UpdateCheckRun(ctx, owner, repo, run.GetID(), update)
+	}
+	if err != nil {
+		slog.Warn("gh.check_run_error", "repo", owner+"/"+repo, "sha", sha, "target", row.target, "err", safeErr(err))
+	}
+}
+
+// checkRunState is the status, conclusion ("" while in progress) and output
+// of a check run with rows.
+func checkRunState(rows []checkRow) (string, string, *github.CheckRunOutput) {
+	slices.SortFunc(rows, func(a, b checkRow) int { return strings.Compare(a.target, b.target) })
+	running, failed := 0, 0
+	lines := []string{"| Target | State | Details |", "| --- | --- | --- |"}
+	for _, r := range rows {
+		lines = append(lines, r.render())
+		switch {
+		case r.state == "pending" && r.url == "":
+			running++
+		case r.state == "failure" || r.state == "error":
+			failed++
+		}
+	}
+	summary := fmt.Sprintf("%d of %d backports need attention.", failed, len(rows))
+	status, conclusion := "completed", "success"
+	switch {
+	case running > 0:
+		status, conclusion = "in_progress", ""
+		summary = fmt.Sprintf("Picking %d of %d targets.", running, len(rows))
+	case failed > 0:
+		conclusion = "failure"
+	default:
+		summary = fmt.Sprintf("All %d backports are open, merged or not needed.", len(rows))
+	}
+	title := fmt.Sprintf("Backports: %d targets", len(rows))
+	return status, conclusion, &github.CheckRunOutput{Title: github.Ptr(title), Summary: github.Ptr(summary), Text: github.Ptr(strings.Join(lines, "\n"))}
+}
@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckRows_RoundTrip(t *testing.T) {
+	rows := []checkRow{
+		{target: "release/2", state: "failure", desc: "Cherry-pick failed; pick manually"},
+		{target: "release/1", state: "pending", desc: "Backport #100 open", url: "https://example.com/pr/100"},
+	}
+	status, conclusion, out := checkRunState(rows)
+	if status != "completed" || conclusion != "failure" {
+		t.Fatalf("state = %s/%s; want completed/failure", status, conclusion)
+	}
+	got := parseCheckRows(out.GetText())
+	want := []checkRow{rows[0], rows[1]} // sorted by target
+	slices.SortFunc(want, func(a, b checkRow) int { return strings.Compare(a.target, b.target) })
+	if !slices.Equal(got, want) {
+		t.Fatalf("rows = %+v; want %+v", got, want)
+	}
+
+	if status, conclusion, _ := checkRunState([]checkRow{{target: "release/1", state: "pending", desc: "Cherry-picking onto release/1"}}); status != "in_progress" || conclusion != "" {
+		t.Fatalf("picking: state = %s/%s", status, conclusion)
+	}
+}
+
+func TestProcessMergedPR_CheckRun(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", CheckRuns: true}
+	fchecks := &fakeChecks{}
+	gh := fakeGH{
+		pr:     &fakePRFull{prGet: mergedPR(11, "Fix", "cafef00d1234567", "cherry-pick to release/1", "cherry-pick to release/2")},
+		iss:    &fakeIssuesFull{},
+		git:    &fakeGitFull{refs: map[string]bool{"refs/heads/release/1": true, "refs/heads/release/2": true}},
+		repos:  &fakeReposFull{},
+		checks: fchecks,
+	}
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/release-1/cafef00"}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+	if len(fchecks.runs) != 1 {
+		t.Fatalf("check runs = %d; want 1", len(fchecks.runs))
+	}
+	run := fchecks.runs[0]
+	if run.GetHeadSHA() != "cafef00d1234567" || run.GetStatus() != "completed" || run.GetConclusion() != "success" {
+		t.Fatalf("run = %s %s/%s", run.GetHeadSHA(), run.GetStatus(), run.GetConclusion())
+	}
+	rows := parseCheckRows(run.GetOutput().GetText())
+	if len(rows) != 2 || rows[0].target != "release/1" || rows[0].desc != "Backport #100 open" || rows[0].url == "" {
+		t.Fatalf("rows = %+v", rows)
+	}
+}
@@ -0,0 +1,145 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// ciFailure is a completed, failed CI run on a work branch, from a
+// workflow_run or check_suite event.
+type ciFailure struct {
+	branch     string
+	sha        string
+	conclusion string
+	name       string // workflow or app name
+	url        string // run page ("" = the PR's checks tab)
+}
+
+// failedConclusion reports whether a run conclusion means the backport is broken.
+func failedConclusion(c string) bool {
+	switch c {
+	case "failure", "timed_out", "startup_failure":
+		return true
+	}
+	return false
+}
+
+func (p *Processor) handleWorkflowRun(ctx context.Context, deliveryID string, e *github.WorkflowRunEvent) {
+	run := e.GetWorkflowRun()
+	if e.GetAction() != "completed" || !reWorkBranch.MatchString(run.GetHeadBranch()) {
+		return
+	}
+	if run.GetConclusion() == "success" && p.AutoMergeApproved {
+		p.autoMergeBranch(ctx, deliveryID, e.GetInstallation(), e.GetRepo(), run.GetHeadBranch(), run.GetHeadSHA())
+		return
+	}
+	if !failedConclusion(run.GetConclusion()) {
+		return
+	}
+	p.handleCIFailure(ctx, deliveryID, e.GetInstallation(), e.GetRepo(), ciFailure{
+		branch: run.GetHeadBranch(), sha: run.GetHeadSHA(), conclusion: run.GetConclusion(),
+		name: run.GetName(), url: run.GetHTMLURL(),
+	})
+}
+
+func (p *Processor) handleCheckSuite(ctx context.Context, deliveryID string, e *github.CheckSuiteEvent) {
+	cs := e.GetCheckSuite()
+	if !reWorkBranch.MatchString(cs.GetHeadBranch()) {
+		return
+	}
+	// Other apps' suites are only seen once completed, so any event counts.
+	if p.EnableAutoMerge {
+		p.enableAutoMergeBranch(ctx, deliveryID, e.GetInstallation(), e.GetRepo(), cs.GetHeadBranch(), cs.GetHeadSHA())
+	}
+	// Actions suites are handled by their workflow_run events, with a link.
+	if e.GetAction() != "completed" || cs.GetApp().GetSlug() == "github-actions" {
+		return
+	}
+	if cs.GetConclusion() == "success" && p.AutoMergeApproved {
+		p.autoMergeBranch(ctx, deliveryID, e.GetInstallation(), e.GetRepo(), cs.GetHeadBranch(), cs.GetHeadSHA())
+		return
+	}
+	if !failedConclusion(cs.GetConclusion()) {
+		return
+	}
+	p.handleCIFailure(ctx, deliveryID, e.GetInstallation(), e.GetRepo(), ciFailure{
+		branch: cs.GetHeadBranch(), sha: cs.GetHeadSHA(), conclusion: cs.GetConclusion(),
+		name: cs.GetApp().GetName(),
+	})
+}
+
+// handleStatus enables auto-merge for work branches getting commit statuses
+// (CI that reports through the Statuses API rather than check suites).
+func (p *Processor) handleStatus(ctx context.Context, deliveryID string, e *github.StatusEvent) {
+	for _, b := range e.Branches {
+		if reWorkBranch.MatchString(b.GetName()) {
+			p.enableAutoMergeBranch(ctx, deliveryID, e.GetInstallation(), e.GetRepo(), b.GetName(), e.GetSHA())
+		}
+	}
+}
+
+func (p *Processor) handleCIFailure(ctx context.Context, deliveryID string, inst *github.Installation, repo *github.Repository, f ciFailure) {
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	if inst == nil {
+		slog.Warn("ci.no_installation", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name)
+		return
+	}
+	clients, err := p.buildClients(inst.GetID())
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	if err := p.reportCIFailure(ctx, p.ghFor(clients), owner, name, f); err != nil {
+		slog.Error("ci.report_error", "delivery", sanitizeForLog(deliveryID), "branch", sanitizeForLog(f.branch), "err", safeErr(err))
+	}
+}
+
+// reportCIFailure comments on the source PR of the backport open from
+// f.branch. Backports of bare commits have no source PR and are skipped.
+func (p *Processor) reportCIFailure(ctx context.Context, gh GH, owner, repo string, f ciFailure) error {
+	backport, err := p.backportPR(ctx, gh, owner, repo, f)
+	if err != nil || backport == nil {
+		return err
+	}
+	source := p.sourcePR(ctx, owner, repo, backport)
+	if source == 0 {
+		slog.Debug("ci.no_source_pr", "repo", owner+"/"+repo, "branch", sanitizeForLog(f.branch))
+		return nil
+	}
+
+	url := f.url
+	if url == "" {
+		url = backport.GetHTMLURL() + "/checks"
+	}
+	body := fmt.Sprintf("⚠️ CI on the auto cherry-pick to `%s` (%s) ended with `%s`: [%s](%s)",
+		backport.GetBase().GetRef(), backport.GetHTMLURL(), f.conclusion, f.name, url)
+	if _, _, err := gh.Issues().CreateComment(ctx, owner, repo, source, &github.IssueComment{Body: github.Ptr(body)}); err != nil {
+		return fmt.Errorf("comment on PR #%d: %w", source, err)
+	}
+	slog.Info("ci.failure_reported", "repo", owner+"/"+repo, "pr", source, "backport", backport.GetNumber(), "conclusion", f.conclusion)
+	return nil
+}
+
+// backportPR finds the open PR for f.branch (nil when there is none, or the
+// run is for an older head). Run payloads only carry stubs of their PRs.
+func (p *Processor) backportPR(ctx context.Context, gh GH, owner, repo string, f ciFailure) (*github.PullRequest, error) {
+	prs, _, err := gh.PR().List(ctx, owner, repo, &github.PullRequestListOptions{
+		State:       pullRequestStateOpen,
+		Head:        owner + ":" + f.branch,
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list PRs for %s: %w", f.branch, err)
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	// A newer push to the branch makes this run stale.
+	if head := prs[0].GetHead().GetSHA(); head != "" && f.sha != "" && head != f.sha {
+		return nil, nil
+	}
+	return prs[0], nil
+}
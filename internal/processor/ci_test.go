@@ -0,0 +1,77 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/githubapp"
+)
+
+func TestReportCIFailure_CommentsOnSourcePR(t *testing.T) {
+	backport := &github.PullRequest{
+		Number:  github.Ptr(31),
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/31"),
+		Body:    github.Ptr("Automated cherry-pick of PR #12 into `devops-release/0021`."),
+		Base:    &github.PullRequestBranch{Ref: github.Ptr("devops-release/0021")},
+		Head:    &github.PullRequestBranch{SHA: github.Ptr("head1")},
+	}
+	cases := []struct {
+		name string
+		f    ciFailure
+		want string // substring of the comment on #12 ("" = no comment)
+	}{
+		{
+			name: "workflow run",
+			f:    ciFailure{branch: "autocherry/devops-release-0021/abc1234", sha: "head1", conclusion: "failure", name: "build", url: "https://github.com/o/r/actions/runs/9"},
+			want: "CI on the auto cherry-pick to `devops-release/0021` (https://github.com/o/r/pull/31) ended with `failure`: [build](https://github.com/o/r/actions/runs/9)",
+		},
+		{
+			name: "check suite links the checks tab",
+			f:    ciFailure{branch: "autocherry/devops-release-0021/abc1234", sha: "head1", conclusion: "timed_out", name: "Buildkite"},
+			want: "[Buildkite](https://github.com/o/r/pull/31/checks)",
+		},
+		{
+			name: "stale run",
+			f:    ciFailure{branch: "autocherry/devops-release-0021/abc1234", sha: "old", conclusion: "failure", name: "build"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fiss := &fakeIssuesFull{}
+			gh := fakeGH{pr: &fakePRFull{list: []*github.PullRequest{backport}}, iss: fiss}
+			if err := (&Processor{}).reportCIFailure(context.Background(), gh, "o", "r", c.f); err != nil {
+				t.Fatal(err)
+			}
+			if c.want == "" {
+				if len(fiss.comments) != 0 {
+					t.Fatalf("comments = %v", fiss.comments)
+				}
+				return
+			}
+			if len(fiss.comments) != 1 || !strings.Contains(fiss.comments[0].GetBody(), c.want) {
+				t.Fatalf("comments = %v", fiss.comments)
+			}
+		})
+	}
+}
+
+func TestCIEvents_IgnoreOtherRuns(t *testing.T) {
+	p := &Processor{NewClients: func(int64, int64, []byte) (*githubapp.Clients, error) {
+		t.Fatal("clients built for an ignored run")
+		return nil, nil
+	}}
+	inst := &github.Installation{ID: github.Ptr(int64(1))}
+	run := func(branch, conclusion string) *github.WorkflowRunEvent {
+		return &github.WorkflowRunEvent{Action: github.Ptr("completed"), Installation: inst, WorkflowRun: &github.WorkflowRun{
+			HeadBranch: github.Ptr(branch), Conclusion: github.Ptr(conclusion),
+		}}
+	}
+	p.handleWorkflowRun(context.Background(), "d1", run("feature/x", "failure"))
+	p.handleWorkflowRun(context.Background(), "d1", run("autocherry/rel-1/abc1234", "success"))
+	p.handleCheckSuite(context.Background(), "d1", &github.CheckSuiteEvent{Action: github.Ptr("completed"), Installation: inst, CheckSuite: &github.CheckSuite{
+		HeadBranch: github.Ptr("autocherry/rel-1/abc1234"), Conclusion: github.Ptr("failure"), App: &github.App{Slug: github.Ptr("github-actions")},
+	}})
+}
@@ -0,0 +1,104 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
+)
+
+// reWorkBranch is the naming template of branches this app pushes:
+// autocherry/<target with / replaced by ->/<short sha> (see cherry.Pick), or
+// .../train-<window start> for release trains (see trainFor).
+var reWorkBranch = regexp.MustCompile(`^autocherry/[A-Za-z0-9._-]+/(?:[0-9a-f]{7,40}|train-[0-9]{12})$`)
+
+// errNotOurBranch is returned by deleteWorkBranch when the guard refuses.
+var errNotOurBranch = errors.New("not a branch created by this app")
+
+var cleanupRefused = metrics.Default.Counter("cleanup_refused_total",
+	"Branch deletions refused by the cleanup guard, by reason.")
+
+// deleteWorkBranch deletes a work branch only when its name matches the
+// autocherry template and its tip was committed by the bot identity
+// (GitUserName/GitUserEmail). Anything else, e.g. a human branch reusing the
+// prefix or one left over from an older naming scheme, is kept and
+// errNotOurBranch returned. A branch that is already gone is not an error.
+func (p *Processor) deleteWorkBranch(ctx context.Context, gh GH, owner, repo, branch string) error {
+	if !reWorkBranch.MatchString(branch) {
+		return p.refuseCleanup(owner, repo, branch, "name")
+	}
+	ref, _, err := gh.Git().GetRef(ctx, owner, repo, "refs/heads/"+branch)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("get ref %s: %w", branch, err)
+	}
+	tip, _, err := gh.Repos().GetCommit(ctx, owner, repo, ref.GetObject().GetSHA(), nil)
+	if err != nil {
+		return fmt.Errorf("get tip of %s: %w", branch, err)
+	}
+	if !p.isBotCommitter(tip.GetCommit().GetCommitter().GetName(), tip.GetCommit().GetCommitter().GetEmail()) {
+		return p.refuseCleanup(owner, repo, branch, "committer")
+	}
+	if _, err := gh.Git().DeleteRef(ctx, owner, repo, "refs/heads/"+branch); err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// isBotCommitter compares against the configured git identity: by email when
+// one is set, else by name. Without any identity nothing matches.
+func (p *Processor) isBotCommitter(name, email string) bool {
+	if p.GitUserEmail != "" {
+		return strings.EqualFold(email, p.GitUserEmail)
+	}
+	return p.GitUserName != "" && name == p.GitUserName
+}
+
+func (p *Processor) refuseCleanup(owner, repo, branch, reason string) error {
+	cleanupRefused.Inc("reason", reason)
+	slog.Warn("cleanup.refused", "repo", owner+"/"+repo, "branch", sanitizeForLog(branch), "reason", reason)
+	return fmt.Errorf("delete %s: %w", branch, errNotOurBranch)
+}
+
+// backportBranchClosed deletes the work branch of a backport PR that was
+// merged or closed, so branches do not pile up once their PR is done. A
+// merged PR's branch is left to GitHub when the repository deletes head
+// branches on merge itself.
+func (p *Processor) backportBranchClosed(ctx context.Context, deliveryID string, instID int64, e *github.PullRequestEvent) {
+	repo := e.GetRepo()
+	pr := e.GetPullRequest()
+	if pr.GetMerged() && repo.GetDeleteBranchOnMerge() {
+		slog.Debug("cleanup.skip", "delivery", sanitizeForLog(deliveryID), "pr", pr.GetNumber(), "reason", "repo_deletes_on_merge")
+		return
+	}
+	clients, err := p.buildClients(instID)
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	p.deleteClosedBranch(ctx, deliveryID, p.ghFor(clients), repo.GetOwner().GetLogin(), repo.GetName(), pr)
+}
+
+// deleteClosedBranch deletes pr's work branch through the deleteWorkBranch
+// guard. Branches in forks (see Options.Fork) are not ours to delete.
+func (p *Processor) deleteClosedBranch(ctx context.Context, deliveryID string, gh GH, owner, repo string, pr *github.PullRequest) {
+	branch := pr.GetHead().GetRef()
+	if full := pr.GetHead().GetRepo().GetFullName(); full != "" && !strings.EqualFold(full, owner+"/"+repo) {
+		slog.Debug("cleanup.skip", "delivery", sanitizeForLog(deliveryID), "pr", pr.GetNumber(), "reason", "fork_branch")
+		return
+	}
+	if err := p.deleteWorkBranch(ctx, gh, owner, repo, branch); err != nil {
+		slog.Warn("cleanup.closed_branch_error", "delivery", sanitizeForLog(deliveryID), "pr", pr.GetNumber(), "branch", sanitizeForLog(branch), "err", safeErr(err))
+		return
+	}
+	slog.Info("cleanup.closed_branch_deleted",
+		"delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+repo, "pr", pr.GetNumber(), "branch", sanitizeForLog(branch))
+}
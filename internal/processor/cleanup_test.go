@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func TestDeleteWorkBranch_Guard(t *testing.T) {
+	const (
+		ours  = "autocherry/rel-1/abc1234"
+		human = "autocherry/rel-1/def5678"
+	)
+	cases := []struct {
+		name        string
+		branch      string
+		wantRefused bool
+		wantDeleted bool
+	}{
+		{name: "bot branch deleted", branch: ours, wantDeleted: true},
+		{name: "human commit on our prefix kept", branch: human, wantRefused: true},
+		{name: "name outside template kept", branch: "autocherry/my-experiment", wantRefused: true},
+		{name: "feature branch kept", branch: "feature/x", wantRefused: true},
+		{name: "already gone", branch: "autocherry/rel-1/0000000"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fgit := &fakeGitFull{refs: map[string]bool{
+				"refs/heads/" + ours: true, "refs/heads/" + human: true,
+				"refs/heads/autocherry/my-experiment": true, "refs/heads/feature/x": true,
+			}}
+			repos := &fakeReposFull{committers: map[string]string{
+				"tip:refs/heads/" + ours:  "Bot@NoReply",
+				"tip:refs/heads/" + human: "alice@example.com",
+			}}
+			p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply"}
+
+			err := p.deleteWorkBranch(context.Background(), fakeGH{git: fgit, repos: repos}, "o", "r", tc.branch)
+			if errors.Is(err, errNotOurBranch) != tc.wantRefused {
+				t.Fatalf("err = %v, refused want %v", err, tc.wantRefused)
+			}
+			if !tc.wantRefused && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (len(fgit.deletedRefs) == 1) != tc.wantDeleted {
+				t.Fatalf("deleted = %v, want deleted=%v", fgit.deletedRefs, tc.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestIsBotCommitter(t *testing.T) {
+	if (&Processor{}).isBotCommitter("", "") {
+		t.Fatalf("no identity configured must match nothing")
+	}
+	if !(&Processor{GitUserName: "bot"}).isBotCommitter("bot", "other@x") {
+		t.Fatalf("name match without email configured")
+	}
+	if (&Processor{GitUserName: "bot", GitUserEmail: "bot@x"}).isBotCommitter("bot", "human@x") {
+		t.Fatalf("email takes precedence over name")
+	}
+}
+
+func TestDeleteClosedBranch(t *testing.T) {
+	const branch = "autocherry/rel-1/abc1234"
+	pr := func(headRepo string) *github.PullRequest {
+		return &github.PullRequest{Number: github.Ptr(7), Head: &github.PullRequestBranch{
+			Ref: github.Ptr(branch), Repo: &github.Repository{FullName: github.Ptr(headRepo)},
+		}}
+	}
+	cases := []struct {
+		name        string
+		pr          *github.PullRequest
+		wantDeleted bool
+	}{
+		{name: "own branch deleted", pr: pr("o/r"), wantDeleted: true},
+		{name: "fork branch kept", pr: pr("cherry-bot/r")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/" + branch: true}}
+			repos := &fakeReposFull{committers: map[string]string{"tip:refs/heads/" + branch: "bot@noreply"}}
+			p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply"}
+
+			p.deleteClosedBranch(context.Background(), "d", fakeGH{git: fgit, repos: repos}, "o", "r", tc.pr)
+			if (len(fgit.deletedRefs) == 1) != tc.wantDeleted {
+				t.Fatalf("deleted = %v, want deleted=%v", fgit.deletedRefs, tc.wantDeleted)
+			}
+		})
+	}
+}
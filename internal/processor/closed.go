@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// processClosedUnmerged handles a labeled PR closed without merging: there is
+// nothing to pick, so it removes work branches (and closes their PRs) left by
+// picks of the PR's own commits, e.g. via /cherry-pick <sha> before the close,
+// and says why the labels were ignored.
+func (p *Processor) processClosedUnmerged(ctx context.Context, deliveryID string, gh GH, owner, repo string, pr *github.PullRequest) {
+	targets := p.prTargets(pr)
+	if len(targets) == 0 {
+		slog.Debug("pr.skip", "delivery", sanitizeForLog(deliveryID), "reason", "closed_unmerged_unlabeled")
+		return
+	}
+	prNum := pr.GetNumber()
+
+	shas := []string{pr.GetHead().GetSHA()}
+	commits, _, err := gh.PR().ListCommits(ctx, owner, repo, prNum, &github.ListOptions{PerPage: 250})
+	if err != nil {
+		slog.Warn("gh.list_commits_error", "delivery", sanitizeForLog(deliveryID), "pr", prNum, "err", safeErr(err))
+	}
+	for _, c := range commits {
+		shas = append(shas, c.GetSHA())
+	}
+
+	var removed []string
+	for _, target := range targets {
+		prefix := workBranchPrefix + strings.ReplaceAll(target, "/", "-") + "/"
+		refs, _, err := gh.Git().ListMatchingRefs(ctx, owner, repo, &github.ReferenceListOptions{
+			Ref:         "heads/" + prefix,
+			ListOptions: github.ListOptions{PerPage: 100},
+		})
+		if err != nil {
+			slog.Warn("closed.list_branches_error", "delivery", sanitizeForLog(deliveryID), "target", target, "err", safeErr(err))
+			continue
+		}
+		for _, ref := range refs {
+			branch := strings.TrimPrefix(ref.GetRef(), "refs/heads/")
+			if !pickedFrom(strings.TrimPrefix(branch, prefix), shas) {
+				continue
+			}
+			if err := p.processUnlabeled(ctx, gh, owner, repo, prNum, target, branch); err != nil {
+				slog.Warn("closed.cleanup_error", "delivery", sanitizeForLog(deliveryID), "branch", branch, "err", safeErr(err))
+				continue
+			}
+			removed = append(removed, branch)
+		}
+	}
+	slog.Info("pr.closed_unmerged", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+repo, "pr", prNum, "targets", targets, "removed", removed)
+
+	body := fmt.Sprintf("ℹ️ This PR was closed without merging, so nothing was cherry-picked to %s.", codeList(targets))
+	if len(removed) > 0 {
+		body += fmt.Sprintf(" Closed any open auto-cherry-pick PRs and deleted work branches %s.", codeList(removed))
+	}
+	_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, prNum, &github.IssueComment{Body: github.Ptr(body)})
+}
+
+// pickedFrom reports whether a work branch's short SHA names one of shas.
+func pickedFrom(short string, shas []string) bool {
+	for _, sha := range shas {
+		if short != "" && strings.HasPrefix(sha, short) {
+			return true
+		}
+	}
+	return false
+}
+
+// codeList renders names as "`a`, `b`".
+func codeList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = "`" + n + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,53 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func TestProcessClosedUnmerged_RemovesOwnWorkBranches(t *testing.T) {
+	const (
+		ours  = "autocherry/release-1.2/abc1234"
+		other = "autocherry/release-1.2/fff0000" // another PR's pick
+	)
+	fgit := &fakeGitFull{refs: map[string]bool{
+		"refs/heads/release/1.2": true, "refs/heads/" + ours: true, "refs/heads/" + other: true,
+	}}
+	fiss := &fakeIssuesFull{}
+	fpr := &fakePRFull{commits: []*github.RepositoryCommit{{SHA: github.Ptr("abc1234aaaa")}, {SHA: github.Ptr("def5678bbbb")}}}
+	gh := fakeGH{pr: fpr, iss: fiss, git: fgit, repos: &fakeReposFull{committers: map[string]string{
+		"tip:refs/heads/" + ours:  "bot@noreply",
+		"tip:refs/heads/" + other: "bot@noreply",
+	}}}
+	p := &Processor{GitUserEmail: "bot@noreply"}
+
+	pr := &github.PullRequest{
+		Number: github.Ptr(7),
+		Head:   &github.PullRequestBranch{SHA: github.Ptr("def5678bbbb")},
+		Labels: []*github.Label{{Name: github.Ptr("cherry-pick to release/1.2")}},
+	}
+	p.processClosedUnmerged(context.Background(), "d1", gh, "o", "r", pr)
+
+	if len(fgit.deletedRefs) != 1 || fgit.deletedRefs[0] != "refs/heads/"+ours {
+		t.Fatalf("deleted = %v", fgit.deletedRefs)
+	}
+	if len(fiss.comments) != 1 {
+		t.Fatalf("comments = %v", fiss.comments)
+	}
+	body := fiss.comments[0].GetBody()
+	if !strings.Contains(body, "closed without merging, so nothing was cherry-picked to `release/1.2`") || !strings.Contains(body, "`"+ours+"`") {
+		t.Fatalf("comment = %q", body)
+	}
+}
+
+func TestProcessClosedUnmerged_UnlabeledIsSilent(t *testing.T) {
+	fiss := &fakeIssuesFull{}
+	p := &Processor{}
+	p.processClosedUnmerged(context.Background(), "d1", fakeGH{pr: &fakePRFull{}, iss: fiss, git: &fakeGitFull{}}, "o", "r", &github.PullRequest{Number: github.Ptr(7)})
+	if len(fiss.comments) != 0 {
+		t.Fatalf("comments = %v", fiss.comments)
+	}
+}
@@ -0,0 +1,91 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/codeowners"
+)
+
+// maxCodeOwnerFiles bounds how many of a backport PR's files are matched
+// against CODEOWNERS (GitHub lists at most 3000 anyway).
+const maxCodeOwnerFiles = 3000
+
+// requestCodeOwners requests reviews on backport PR number from the owners
+// the target branch's CODEOWNERS gives its files, as GitHub does for PRs
+// people open. Teams of other organizations and email owners are skipped.
+func (p *Processor) requestCodeOwners(ctx context.Context, gh GH, owner, repo, target string, number int) {
+	f := loadCodeOwners(ctx, gh, owner, repo, target)
+	if f == nil {
+		return
+	}
+	var req github.ReviewersRequest
+	for _, path := range prFiles(ctx, gh, owner, repo, number) {
+		for _, o := range f.Owners(path) {
+			name, ok := strings.CutPrefix(o, "@")
+			if !ok {
+				continue // email
+			}
+			if org, team, isTeam := strings.Cut(name, "/"); isTeam {
+				if strings.EqualFold(org, owner) && !slices.Contains(req.TeamReviewers, team) {
+					req.TeamReviewers = append(req.TeamReviewers, team)
+				}
+			} else if !slices.Contains(req.Reviewers, name) {
+				req.Reviewers = append(req.Reviewers, name)
+			}
+		}
+	}
+	if len(req.Reviewers) == 0 && len(req.TeamReviewers) == 0 {
+		return
+	}
+	if _, _, err := gh.PR().RequestReviewers(ctx, owner, repo, number, req); err != nil {
+		slog.Warn("gh.codeowners_reviewers_error", "repo", owner+"/"+repo, "pr", number,
+			"reviewers", req.Reviewers, "teams", req.TeamReviewers, "err", safeErr(err))
+	}
+}
+
+// loadCodeOwners reads CODEOWNERS from the target branch (nil = none).
+func loadCodeOwners(ctx context.Context, gh GH, owner, repo, target string) *codeowners.File {
+	for _, path := range codeowners.Paths {
+		fc, _, _, err := gh.Repos().GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: target})
+		if err != nil {
+			if !isNotFound(err) {
+				slog.Warn("codeowners.fetch_error", "repo", owner+"/"+repo, "ref", target, "err", safeErr(err))
+				return nil
+			}
+			continue
+		}
+		raw, err := fc.GetContent()
+		if err != nil {
+			slog.Warn("codeowners.decode_error", "repo", owner+"/"+repo, "ref", target, "err", safeErr(err))
+			return nil
+		}
+		return codeowners.Parse([]byte(raw))
+	}
+	return nil
+}
+
+// prFiles lists the paths PR number changes, up to maxCodeOwnerFiles.
+func prFiles(ctx context.Context, gh GH, owner, repo string, number int) []string {
+	var paths []string
+	opts := &github.ListOptions{PerPage: 100}
+	for len(paths) < maxCodeOwnerFiles {
+		files, resp, err := gh.PR().ListFiles(ctx, owner, repo, number, opts)
+		if err != nil {
+			slog.Warn("gh.list_files_error", "repo", owner+"/"+repo, "pr", number, "err", safeErr(err))
+			break
+		}
+		for _, f := range files {
+			paths = append(paths, f.GetFilename())
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return paths
+}
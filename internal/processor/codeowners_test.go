@@ -0,0 +1,40 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func TestProcessMergedPR_RequestsCodeOwners(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", CodeOwnerReviews: true}
+	fpr := &fakePRFull{
+		prGet: mergedPR(11, "Fix", "cafef00d1234567", "cherry-pick to devops-release/0021"),
+		files: []*github.CommitFile{{Filename: github.Ptr("api/handler.go")}, {Filename: github.Ptr("docs/api.md")}, {Filename: github.Ptr("main.go")}},
+	}
+	frepos := &fakeReposFull{files: map[string]string{
+		".github/CODEOWNERS": "* @lead\n/api/ @o/api-team @bob\n/docs/ docs@example.com @other-org/writers\n",
+	}}
+	gh := fakeGH{pr: fpr, iss: &fakeIssuesFull{}, git: &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}, repos: frepos}
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/cafef00"}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+	if len(fpr.reviewReq) != 1 {
+		t.Fatalf("review requests = %+v; want one", fpr.reviewReq)
+	}
+	if got := fpr.reviewReq[0]; !slices.Equal(got.Reviewers, []string{"bob", "lead"}) || !slices.Equal(got.TeamReviewers, []string{"api-team"}) {
+		t.Fatalf("review request = %v / %v", got.Reviewers, got.TeamReviewers)
+	}
+}
+
+func TestRequestCodeOwners_NoFile(t *testing.T) {
+	fpr := &fakePRFull{files: []*github.CommitFile{{Filename: github.Ptr("main.go")}}}
+	gh := fakeGH{pr: fpr, repos: &fakeReposFull{}}
+	(&Processor{}).requestCodeOwners(context.Background(), gh, "o", "r", "release/1", 100)
+	if len(fpr.reviewReq) != 0 {
+		t.Fatalf("review requests = %+v; want none", fpr.reviewReq)
+	}
+}
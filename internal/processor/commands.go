@@ -0,0 +1,336 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/usage"
+)
+
+var (
+	// reCherryPickRange matches "/cherry-pick <from>..<to> to <branch>" on a line of its own.
+	reCherryPickRange = regexp.MustCompile(`(?m)^/cherry-pick[ \t]+([0-9a-fA-F]{7,40})\.\.([0-9a-fA-F]{7,40})[ \t]+to[ \t]+(\S+)[ \t]*\r?$`)
+	// reCherryPickSHA matches "/cherry-pick <sha> to <branch>" on a line of its own.
+	reCherryPickSHA = regexp.MustCompile(`(?m)^/cherry-pick[ \t]+([0-9a-fA-F]{7,40})[ \t]+to[ \t]+(\S+)[ \t]*\r?$`)
+	// reCherryPickPR matches "/cherry-pick <branch>" on a line of its own.
+	reCherryPickPR = regexp.MustCompile(`(?m)^/cherry-pick[ \t]+(\S+)[ \t]*\r?$`)
+	// reRetry matches "/retry-cherry-pick <branch>" on a line of its own.
+	reRetry = regexp.MustCompile(`(?m)^/retry-cherry-pick[ \t]+(\S+)[ \t]*\r?$`)
+)
+
+// slashCommand is a command parsed from an issue or PR comment.
+type slashCommand struct {
+	sha    string // commit to pick; "" picks the PR commented on
+	from   string // with sha: pick the series from..sha (from excluded)
+	target string // branch to pick onto
+	retry  bool   // re-run the PR's pick for target
+}
+
+// maxRangeCommits bounds "/cherry-pick <from>..<to>" series; it is also the
+// most commits the compare API lists, which the merge check relies on.
+const maxRangeCommits = 250
+
+// parseCommand returns the first command in a comment body.
+func parseCommand(body string) (slashCommand, bool) {
+	if m := reCherryPickRange.FindStringSubmatch(body); m != nil {
+		return slashCommand{from: strings.ToLower(m[1]), sha: strings.ToLower(m[2]), target: m[3]}, true
+	}
+	if m := reCherryPickSHA.FindStringSubmatch(body); m != nil {
+		return slashCommand{sha: strings.ToLower(m[1]), target: m[2]}, true
+	}
+	if m := reCherryPickPR.FindStringSubmatch(body); m != nil {
+		return slashCommand{target: strings.TrimPrefix(m[1], "refs/heads/")}, true
+	}
+	if m := reRetry.FindStringSubmatch(body); m != nil {
+		return slashCommand{target: strings.TrimPrefix(m[1], "refs/heads/"), retry: true}, true
+	}
+	return slashCommand{}, false
+}
+
+// handleIssueComment runs slash commands from newly created comments on
+// issues and PRs.
+func (p *Processor) handleIssueComment(ctx context.Context, deliveryID string, e *github.IssueCommentEvent) {
+	if e.GetAction() != "created" || e.GetComment().GetUser().GetType() == "Bot" {
+		return
+	}
+	cmd, ok := parseCommand(e.GetComment().GetBody())
+	if !ok {
+		return
+	}
+	repo := e.GetRepo()
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	inst := e.GetInstallation()
+	if inst == nil {
+		slog.Warn("command.no_installation", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name)
+		return
+	}
+	clients, err := p.buildClients(inst.GetID())
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	lctx, cancel := context.WithTimeout(ctx, p.cherryTimeout())
+	unlock, ok := p.lockPR(lctx, deliveryID, owner, name, e.GetIssue().GetNumber())
+	cancel()
+	if !ok {
+		slog.Warn("command.lock_timeout", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name, "issue", e.GetIssue().GetNumber())
+		return
+	}
+	defer unlock()
+	cctx, cancel := context.WithTimeout(ctx, p.cherryTimeout())
+	defer cancel()
+	p.runCommand(usage.WithInstallation(cctx, inst.GetID()), deliveryID, p.ghFor(clients), inst.GetID(), e, cmd)
+}
+
+// runCommand acknowledges the comment, runs cmd and reacts with the outcome.
+func (p *Processor) runCommand(ctx context.Context, deliveryID string, gh GH, instID int64, e *github.IssueCommentEvent, cmd slashCommand) {
+	owner, repo := e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName()
+	ack := p.ackCommand(ctx, gh, owner, repo, e.GetComment().GetID())
+	slog.Info("command.received",
+		"delivery", sanitizeForLog(deliveryID),
+		"repo", owner+"/"+repo,
+		"issue", e.GetIssue().GetNumber(),
+		"user", e.GetComment().GetUser().GetLogin(),
+		"sha", cmd.sha,
+		"from", cmd.from,
+		"target", sanitizeForLog(cmd.target),
+	)
+	switch {
+	case cmd.retry:
+		ack.done(ctx, p.retryCherryPick(ctx, deliveryID, gh, instID, e, cmd))
+		return
+	case cmd.sha == "":
+		ack.done(ctx, p.cherryPickPR(ctx, deliveryID, gh, e, cmd))
+		return
+	}
+	ack.done(ctx, p.cherryPickCommit(ctx, deliveryID, gh, instID, e, cmd))
+}
+
+// commitRange checks that from..to is a series of at most maxRangeCommits
+// non-merge commits, returning from's full SHA and the commit count, or why
+// the range cannot be picked.
+func commitRange(ctx context.Context, gh GH, owner, repo, from, to string) (string, int, string) {
+	cmp, _, err := gh.Repos().CompareCommits(ctx, owner, repo, from, to, nil)
+	if err != nil {
+		return "", 0, fmt.Sprintf("could not compare the commits: %v", err)
+	}
+	if cmp.GetStatus() != "ahead" {
+		return "", 0, fmt.Sprintf("`%s` is not an ancestor of `%s`.", from, to[:min(7, len(to))])
+	}
+	if n := cmp.GetTotalCommits(); n > maxRangeCommits {
+		return "", 0, fmt.Sprintf("the range has %d commits, at most %d allowed.", n, maxRangeCommits)
+	}
+	for _, c := range cmp.Commits {
+		if len(c.Parents) > 1 {
+			return "", 0, fmt.Sprintf("it contains the merge commit `%s`; pick that one on its own.", c.GetSHA()[:min(7, len(c.GetSHA()))])
+		}
+	}
+	return cmp.GetBaseCommit().GetSHA(), cmp.GetTotalCommits(), ""
+}
+
+// canWrite reports whether login may trigger picks: write, maintain or admin
+// access to the repository.
+func canWrite(ctx context.Context, gh GH, owner, repo, login string) (bool, error) {
+	lvl, _, err := gh.Repos().GetPermissionLevel(ctx, owner, repo, login)
+	if err != nil {
+		return false, err
+	}
+	switch lvl.GetPermission() {
+	case "admin", "write": // maintain reports as write
+		return true, nil
+	}
+	return false, nil
+}
+
+// cherryPickPR handles "/cherry-pick <branch>" on a PR by adding its
+// "cherry-pick to <branch>" label for the commenter, so the pick runs exactly
+// as if they had labeled it: now if the PR is merged (on the labeled event),
+// at merge otherwise, and removing the label retracts it.
+func (p *Processor) cherryPickPR(ctx context.Context, deliveryID string, gh GH, e *github.IssueCommentEvent, cmd slashCommand) bool {
+	owner, repo := e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName()
+	issue := e.GetIssue().GetNumber()
+	login := e.GetComment().GetUser().GetLogin()
+	reply := func(format string, args ...any) {
+		_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, issue, &github.IssueComment{
+			Body: github.Ptr(fmt.Sprintf(format, args...)),
+		})
+	}
+
+	if !e.GetIssue().IsPullRequest() {
+		reply("⚠️ `/cherry-pick %s` works on pull requests; on issues, name the commit: `/cherry-pick <sha> to %s`.", cmd.target, cmd.target)
+		return false
+	}
+	allowed, err := canWrite(ctx, gh, owner, repo, login)
+	if err != nil {
+		slog.Warn("command.permission_error", "delivery", sanitizeForLog(deliveryID), "user", login, "err", safeErr(err))
+	}
+	if !allowed {
+		reply("⚠️ @%s, `/cherry-pick` needs write access to this repository.", login)
+		return false
+	}
+	if _, _, err := gh.Git().GetRef(ctx, owner, repo, "refs/heads/"+cmd.target); err != nil {
+		reply("⚠️ Target branch `%s` not found; skipping auto cherry-pick.", cmd.target)
+		return false
+	}
+
+	label := "cherry-pick to " + cmd.target
+	for _, l := range e.GetIssue().Labels {
+		if l.GetName() == label {
+			reply("ℹ️ This PR is already labeled `%s`; remove and re-add the label to pick again.", label)
+			return true
+		}
+	}
+	if err := p.ensureLabel(ctx, gh, owner, repo, label); err != nil {
+		slog.Warn("labels.ensure_error", "delivery", sanitizeForLog(deliveryID), "label", label, "err", safeErr(err))
+	}
+	if _, _, err := gh.Issues().AddLabelsToIssue(ctx, owner, repo, issue, []string{label}); err != nil {
+		slog.Error("gh.add_label_error", "delivery", sanitizeForLog(deliveryID), "pr", issue, "label", label, "err", safeErr(err))
+		reply("⚠️ Could not add label `%s`: %v", label, err)
+		return false
+	}
+	slog.Info("command.labeled", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+repo, "pr", issue, "label", label)
+	return true
+}
+
+// retryCherryPick handles "/retry-cherry-pick <branch>" on a merged PR: the
+// pick for that one labeled target runs again, e.g. after a conflict was
+// fixed on the target branch.
+func (p *Processor) retryCherryPick(ctx context.Context, deliveryID string, gh GH, instID int64, e *github.IssueCommentEvent, cmd slashCommand) bool {
+	owner, repo := e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName()
+	issue := e.GetIssue().GetNumber()
+	login := e.GetComment().GetUser().GetLogin()
+	reply := func(format string, args ...any) {
+		_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, issue, &github.IssueComment{
+			Body: github.Ptr(fmt.Sprintf(format, args...)),
+		})
+	}
+
+	if !e.GetIssue().IsPullRequest() {
+		reply("⚠️ `/retry-cherry-pick` works on merged pull requests.")
+		return false
+	}
+	allowed, err := canWrite(ctx, gh, owner, repo, login)
+	if err != nil {
+		slog.Warn("command.permission_error", "delivery", sanitizeForLog(deliveryID), "user", login, "err", safeErr(err))
+	}
+	if !allowed {
+		reply("⚠️ @%s, `/retry-cherry-pick` needs write access to this repository.", login)
+		return false
+	}
+	pr, _, err := gh.PR().Get(ctx, owner, repo, issue)
+	if err != nil || pr == nil {
+		slog.Error("gh.get_pr_error", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+repo, "pr", issue, "err", safeErr(err))
+		return false
+	}
+	if !pr.GetMerged() {
+		reply("⚠️ This PR is not merged yet; its labeled targets are picked when it merges.")
+		return false
+	}
+	if !slices.Contains(cherry.ParseTargetBranches(pr.Labels), cmd.target) {
+		reply("⚠️ This PR is not labeled for `%s`; use `/cherry-pick %s` to add it.", cmd.target, cmd.target)
+		return false
+	}
+	token, ok := p.installationToken(ctx, deliveryID, instID)
+	if !ok {
+		reply("⚠️ Auto cherry-pick to `%s` failed: could not get an installation token.", cmd.target)
+		return false
+	}
+	slog.Info("command.retry", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+repo, "pr", issue, "target", sanitizeForLog(cmd.target))
+	return p.processMergedPRWith(ctx, deliveryID, gh, owner, repo, issue, []string{cmd.target}, token)
+}
+
+// cherryPickCommit handles "/cherry-pick <sha> to <branch>" and its range
+// form: the commits must be on the default branch, and the backport PR links
+// back to the comment.
+//
+//nolint:funlen // Validation steps, each reported back on the issue
+func (p *Processor) cherryPickCommit(ctx context.Context, deliveryID string, gh GH, instID int64, e *github.IssueCommentEvent, cmd slashCommand) bool {
+	owner, repo := e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName()
+	issue := e.GetIssue().GetNumber()
+	login := e.GetComment().GetUser().GetLogin()
+	reply := func(format string, args ...any) {
+		_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, issue, &github.IssueComment{
+			Body: github.Ptr(fmt.Sprintf(format, args...)),
+		})
+	}
+
+	allowed, err := canWrite(ctx, gh, owner, repo, login)
+	if err != nil {
+		slog.Warn("command.permission_error", "delivery", sanitizeForLog(deliveryID), "user", login, "err", safeErr(err))
+	}
+	if !allowed {
+		reply("⚠️ @%s, `/cherry-pick` needs write access to this repository.", login)
+		return false
+	}
+
+	rc, _, err := gh.Repos().GetCommit(ctx, owner, repo, cmd.sha, nil)
+	if err != nil || rc.GetSHA() == "" {
+		reply("⚠️ Commit `%s` not found in this repository.", cmd.sha)
+		return false
+	}
+	sha := rc.GetSHA()
+	short := sha[:min(7, len(sha))]
+	def := e.GetRepo().GetDefaultBranch()
+	cmp, _, err := gh.Repos().CompareCommits(ctx, owner, repo, def, sha, nil)
+	if err != nil || (cmp.GetStatus() != "behind" && cmp.GetStatus() != "identical") {
+		reply("⚠️ Commit `%s` is not on the default branch `%s`; only commits merged there can be cherry-picked.", short, def)
+		return false
+	}
+	var from string
+	var count int
+	if cmd.from != "" {
+		var problem string
+		from, count, problem = commitRange(ctx, gh, owner, repo, cmd.from, sha)
+		if problem != "" {
+			reply("⚠️ Cannot cherry-pick `%s..%s`: %s", cmd.from, short, problem)
+			return false
+		}
+	}
+	if _, _, err := gh.Git().GetRef(ctx, owner, repo, "refs/heads/"+cmd.target); err != nil {
+		reply("⚠️ Target branch `%s` not found; skipping auto cherry-pick.", cmd.target)
+		return false
+	}
+	if mp := p.findManualBackports(ctx, gh, owner, repo, sha, 0)[cmd.target]; mp != nil {
+		reply("ℹ️ `%s` already has a backport of `%s`: %s; skipping auto cherry-pick.", cmd.target, sha, mp.GetHTMLURL())
+		return true
+	}
+
+	token, ok := p.installationToken(ctx, deliveryID, instID)
+	if !ok {
+		reply("⚠️ Auto cherry-pick to `%s` failed: could not get an installation token.", cmd.target)
+		return false
+	}
+	repoCfg := &repocfg.Config{}
+	if p.needsRepoConfig() {
+		repoCfg = p.loadRepoConfig(ctx, gh, owner, repo, issue)
+	}
+
+	subject, _, _ := strings.Cut(rc.GetCommit().GetMessage(), "\n")
+	author := rc.GetAuthor().GetLogin()
+	src := pickSource{
+		issue: issue, sha: sha, isMerge: len(rc.Parents) > 1, author: author,
+		what:  fmt.Sprintf("commit `%s`", short),
+		title: fmt.Sprintf("Auto cherry-pick: %s — %s", short, subject), subject: subject,
+	}
+	origin := "commit " + short
+	if from != "" {
+		series := fmt.Sprintf("%s..%s", from[:min(7, len(from))], short)
+		src.isMerge, src.rangeFrom = false, from
+		src.what = fmt.Sprintf("the %d commits `%s`", count, series)
+		src.title = fmt.Sprintf("Auto cherry-pick: %s (%d commits) — %s", series, count, subject)
+		origin = "commits " + series
+	}
+	if author != "" {
+		origin += " by @" + author
+	}
+	src.footer = fmt.Sprintf("\n\n---\n_origin: %s, requested by @%s in %s_", origin, login, e.GetComment().GetHTMLURL())
+	return p.pickTarget(ctx, deliveryID, gh, owner, repo, src, cmd.target, token, repoCfg)
+}
@@ -0,0 +1,291 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+)
+
+func commentEvent(login, body string) *github.IssueCommentEvent {
+	return &github.IssueCommentEvent{
+		Action: github.Ptr("created"),
+		Repo: &github.Repository{
+			Owner: &github.User{Login: github.Ptr("o")}, Name: github.Ptr("r"), DefaultBranch: github.Ptr("main"),
+		},
+		Issue: &github.Issue{Number: github.Ptr(42)},
+		Comment: &github.IssueComment{
+			ID: github.Ptr(int64(9)), Body: github.Ptr(body), User: &github.User{Login: github.Ptr(login)},
+			HTMLURL: github.Ptr("https://github.com/o/r/issues/42#issuecomment-9"),
+		},
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	cases := []struct {
+		body   string
+		ok     bool
+		sha    string
+		from   string
+		target string
+		retry  bool
+	}{
+		{body: "/cherry-pick ABCDEF1 to devops-release/0031", ok: true, sha: "abcdef1", target: "devops-release/0031"},
+		{body: "Please backport\r\n/cherry-pick 0123456789abcdef to release/1.2\r\nthanks", ok: true, sha: "0123456789abcdef", target: "release/1.2"},
+		{body: "/cherry-pick abc to release/1"},         // too short for a SHA
+		{body: "see /cherry-pick abcdef1 to release/1"}, // not at line start
+		{body: "/cherry-pick devops-release/0031", ok: true, target: "devops-release/0031"},
+		{body: "LGTM\n/cherry-pick refs/heads/release/1.2 \n", ok: true, target: "release/1.2"},
+		{body: "/cherry-pick"},
+		{body: "/retry-cherry-pick release/1.2", ok: true, target: "release/1.2", retry: true},
+		{body: "/cherry-pick 1111111..ABCDEF1 to release/1.2", ok: true, from: "1111111", sha: "abcdef1", target: "release/1.2"},
+	}
+	for _, c := range cases {
+		cmd, ok := parseCommand(c.body)
+		if ok != c.ok || cmd.sha != c.sha || cmd.from != c.from || cmd.target != c.target || cmd.retry != c.retry {
+			t.Errorf("parseCommand(%q) = %+v, %v", c.body, cmd, ok)
+		}
+	}
+}
+
+func TestCherryPickCommit_OpensPRWithProvenance(t *testing.T) {
+	sha := "abcdef1234567890abcdef1234567890abcdef12"
+	fpr := &fakePRFull{}
+	fiss := &fakeIssuesFull{}
+	react := &fakeReactions{}
+	gh := fakeGH{
+		pr: fpr, iss: fiss, react: react,
+		git: &fakeGitFull{refs: map[string]bool{"refs/heads/release/1.2": true}},
+		repos: &fakeReposFull{
+			commit: &github.RepositoryCommit{
+				SHA:     github.Ptr(sha),
+				Commit:  &github.Commit{Message: github.Ptr("Fix flaky retry\n\nDetails")},
+				Author:  &github.User{Login: github.Ptr("dev")},
+				Parents: []*github.Commit{{}},
+			},
+			compare:     map[string]string{sha: "behind"},
+			permissions: map[string]string{"maint": "write"},
+		},
+	}
+	p := &Processor{
+		CherryRunner: fakeCherry{workBranch: "autocherry/release-1.2/abcdef1"},
+		GetToken:     func(context.Context, int64, int64, []byte) (string, error) { return "tok", nil },
+	}
+
+	p.runCommand(context.Background(), "d1", gh, 1, commentEvent("maint", "/cherry-pick abcdef1 to release/1.2"),
+		slashCommand{sha: "abcdef1", target: "release/1.2"})
+
+	if fpr.newPR == nil {
+		t.Fatalf("no PR opened; comments: %v", fiss.comments)
+	}
+	if got := fpr.newPR.GetTitle(); got != "Auto cherry-pick: abcdef1 — Fix flaky retry" {
+		t.Fatalf("title = %q", got)
+	}
+	body := fpr.newPR.GetBody()
+	if !strings.Contains(body, "Automated cherry-pick of commit `abcdef1` into `release/1.2`") ||
+		!strings.Contains(body, "requested by @maint in https://github.com/o/r/issues/42#issuecomment-9") {
+		t.Fatalf("body = %q", body)
+	}
+	if strings.Join(react.created, ",") != "eyes,rocket" {
+		t.Fatalf("reactions = %v", react.created)
+	}
+}
+
+func TestCherryPickCommit_Range(t *testing.T) {
+	sha := "abcdef1234567890abcdef1234567890abcdef12"
+	from := "1111111234567890abcdef1234567890abcdef12"
+	commit := func(parents int) *github.RepositoryCommit {
+		c := &github.RepositoryCommit{SHA: github.Ptr("c0ffee0")}
+		for range parents {
+			c.Parents = append(c.Parents, &github.Commit{})
+		}
+		return c
+	}
+	cases := []struct {
+		name string
+		cmp  *github.CommitsComparison
+		want string // comment substring when rejected
+	}{
+		{name: "series", cmp: &github.CommitsComparison{
+			Status: github.Ptr("ahead"), TotalCommits: github.Ptr(2), BaseCommit: &github.RepositoryCommit{SHA: github.Ptr(from)},
+			Commits: []*github.RepositoryCommit{commit(1), commit(1)},
+		}},
+		{name: "not an ancestor", cmp: &github.CommitsComparison{Status: github.Ptr("diverged")}, want: "is not an ancestor"},
+		{name: "contains a merge", cmp: &github.CommitsComparison{
+			Status: github.Ptr("ahead"), TotalCommits: github.Ptr(2), Commits: []*github.RepositoryCommit{commit(1), commit(2)},
+		}, want: "merge commit `c0ffee0`"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fpr := &fakePRFull{}
+			fiss := &fakeIssuesFull{}
+			gh := fakeGH{
+				pr: fpr, iss: fiss, react: &fakeReactions{},
+				git: &fakeGitFull{refs: map[string]bool{"refs/heads/release/1.2": true}},
+				repos: &fakeReposFull{
+					commit: &github.RepositoryCommit{
+						SHA: github.Ptr(sha), Commit: &github.Commit{Message: github.Ptr("Fix flaky retry")}, Parents: []*github.Commit{{}},
+					},
+					compare:     map[string]string{sha: "behind"},
+					ranges:      map[string]*github.CommitsComparison{"1111111..." + sha: c.cmp},
+					permissions: map[string]string{"maint": "write"},
+				},
+			}
+			var opts cherry.Options
+			p := &Processor{
+				CherryRunner: fakeCherry{workBranch: "autocherry/release-1.2/abcdef1", opts: &opts},
+				GetToken:     func(context.Context, int64, int64, []byte) (string, error) { return "tok", nil },
+			}
+
+			p.runCommand(context.Background(), "d1", gh, 1, commentEvent("maint", ""),
+				slashCommand{from: "1111111", sha: "abcdef1", target: "release/1.2"})
+
+			if c.want != "" {
+				if fpr.newPR != nil || len(fiss.comments) != 1 || !strings.Contains(fiss.comments[0].GetBody(), c.want) {
+					t.Fatalf("want a %q rejection, got PR %+v, comments %v", c.want, fpr.newPR, fiss.comments)
+				}
+				return
+			}
+			if opts.RangeFrom != from || fpr.newPR == nil {
+				t.Fatalf("range from %q, PR %+v, comments %v", opts.RangeFrom, fpr.newPR, fiss.comments)
+			}
+			if got := fpr.newPR.GetTitle(); got != "Auto cherry-pick: 1111111..abcdef1 (2 commits) — Fix flaky retry" {
+				t.Fatalf("title = %q", got)
+			}
+		})
+	}
+}
+
+func TestCherryPickCommit_Rejects(t *testing.T) {
+	sha := "abcdef1234567890abcdef1234567890abcdef12"
+	cases := []struct {
+		name    string
+		login   string
+		compare string
+		want    string
+	}{
+		{name: "no write access", login: "reader", compare: "behind", want: "needs write access"},
+		{name: "not on default branch", login: "maint", compare: "ahead", want: "is not on the default branch `main`"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fiss := &fakeIssuesFull{}
+			react := &fakeReactions{}
+			gh := fakeGH{
+				pr: &fakePRFull{}, iss: fiss, react: react,
+				git: &fakeGitFull{refs: map[string]bool{"refs/heads/release/1.2": true}},
+				repos: &fakeReposFull{
+					commit:      &github.RepositoryCommit{SHA: github.Ptr(sha)},
+					compare:     map[string]string{sha: c.compare},
+					permissions: map[string]string{"maint": "admin"},
+				},
+			}
+			p := &Processor{CherryRunner: fakeCherry{workBranch: "x"}}
+			p.runCommand(context.Background(), "d1", gh, 1, commentEvent(c.login, ""), slashCommand{sha: "abcdef1", target: "release/1.2"})
+			if len(fiss.comments) != 1 || !strings.Contains(fiss.comments[0].GetBody(), c.want) {
+				t.Fatalf("comments = %v", fiss.comments)
+			}
+			if strings.Join(react.created, ",") != "eyes,confused" {
+				t.Fatalf("reactions = %v", react.created)
+			}
+		})
+	}
+}
+
+func TestCherryPickPR_LabelsThePR(t *testing.T) {
+	prEvent := func(login string, labels ...string) *github.IssueCommentEvent {
+		e := commentEvent(login, "/cherry-pick release/1.2")
+		e.Issue.PullRequestLinks = &github.PullRequestLinks{URL: github.Ptr("https://api.github.com/repos/o/r/pulls/42")}
+		for _, l := range labels {
+			e.Issue.Labels = append(e.Issue.Labels, &github.Label{Name: github.Ptr(l)})
+		}
+		return e
+	}
+	cases := []struct {
+		name      string
+		event     *github.IssueCommentEvent
+		labeled   bool
+		comment   string // substring of the only reply ("" = no reply)
+		reactions string
+	}{
+		{name: "labels", event: prEvent("maint"), labeled: true, reactions: "eyes,rocket"},
+		{name: "no write access", event: prEvent("reader"), comment: "needs write access", reactions: "eyes,confused"},
+		{name: "already labeled", event: prEvent("maint", "cherry-pick to release/1.2"), comment: "already labeled", reactions: "eyes,rocket"},
+		{name: "not a PR", event: commentEvent("maint", "/cherry-pick release/1.2"), comment: "works on pull requests", reactions: "eyes,confused"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fiss := &fakeIssuesFull{}
+			react := &fakeReactions{}
+			gh := fakeGH{
+				pr: &fakePRFull{}, iss: fiss, react: react,
+				git:   &fakeGitFull{refs: map[string]bool{"refs/heads/release/1.2": true}},
+				repos: &fakeReposFull{permissions: map[string]string{"maint": "write"}},
+			}
+			p := &Processor{CherryRunner: fakeCherry{workBranch: "x"}}
+			p.runCommand(context.Background(), "d1", gh, 1, c.event, slashCommand{target: "release/1.2"})
+
+			if c.labeled != (len(fiss.addedToIssue) == 1) {
+				t.Fatalf("labels added = %v", fiss.addedToIssue)
+			}
+			if c.labeled && (fiss.addedToIssue[0].Num != 42 || fiss.addedToIssue[0].Labels[0] != "cherry-pick to release/1.2") {
+				t.Fatalf("labels added = %v", fiss.addedToIssue)
+			}
+			if c.comment == "" && len(fiss.comments) != 0 ||
+				c.comment != "" && (len(fiss.comments) != 1 || !strings.Contains(fiss.comments[0].GetBody(), c.comment)) {
+				t.Fatalf("comments = %v", fiss.comments)
+			}
+			if strings.Join(react.created, ",") != c.reactions {
+				t.Fatalf("reactions = %v", react.created)
+			}
+		})
+	}
+}
+
+func TestRetryCherryPick(t *testing.T) {
+	sha := "abcdef1234567890abcdef1234567890abcdef12"
+	cases := []struct {
+		name    string
+		pr      *github.PullRequest
+		pickErr error
+		opened  bool
+		comment string
+	}{
+		{name: "reruns the labeled target", pr: mergedPR(42, "Fix", sha, "cherry-pick to release/1.2"), opened: true},
+		{
+			name: "conflicts again", pr: mergedPR(42, "Fix", sha, "cherry-pick to release/1.2"), pickErr: errors.New("conflict"),
+			comment: "comment `/retry-cherry-pick release/1.2` to try again",
+		},
+		{name: "not labeled", pr: mergedPR(42, "Fix", sha, "cherry-pick to release/1.1"), comment: "not labeled for `release/1.2`"},
+		{name: "not merged", pr: &github.PullRequest{Number: github.Ptr(42)}, comment: "not merged yet"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fpr := &fakePRFull{prGet: c.pr}
+			fiss := &fakeIssuesFull{}
+			gh := fakeGH{
+				pr: fpr, iss: fiss, react: &fakeReactions{},
+				git:   &fakeGitFull{refs: map[string]bool{"refs/heads/release/1.2": true}},
+				repos: &fakeReposFull{commit: repoCommitWithParents(1), permissions: map[string]string{"maint": "write"}},
+			}
+			p := &Processor{
+				CherryRunner: fakeCherry{workBranch: "autocherry/release-1.2/abcdef1", err: c.pickErr},
+				GetToken:     func(context.Context, int64, int64, []byte) (string, error) { return "tok", nil },
+			}
+			e := commentEvent("maint", "/retry-cherry-pick release/1.2")
+			e.Issue.PullRequestLinks = &github.PullRequestLinks{}
+			p.runCommand(context.Background(), "d1", gh, 1, e, slashCommand{target: "release/1.2", retry: true})
+
+			if c.opened != (fpr.newPR != nil) {
+				t.Fatalf("opened = %v; comments: %v", fpr.newPR != nil, fiss.comments)
+			}
+			if c.comment != "" && (len(fiss.comments) != 1 || !strings.Contains(fiss.comments[0].GetBody(), c.comment)) {
+				t.Fatalf("comments = %v", fiss.comments)
+			}
+		})
+	}
+}
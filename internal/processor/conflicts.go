@@ -0,0 +1,44 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+)
+
+// conflictDetails renders what a conflicting pick left in the work tree (the
+// unmerged files, `git status` and the diffstat) as a collapsed section of
+// the failure comment; "" when err is no conflict or nothing was captured.
+func conflictDetails(err error) string {
+	rep, ok := cherry.Conflict(err)
+	if !ok {
+		return ""
+	}
+	return conflictSection(rep)
+}
+
+func conflictSection(rep cherry.ConflictReport) string {
+	if len(rep.Files) == 0 && rep.Status == "" && rep.Diffstat == "" {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n\n<details><summary>Conflicts (%d files)</summary>\n", len(rep.Files))
+	if len(rep.Files) > 0 {
+		sb.WriteString("\n")
+		for _, f := range rep.Files {
+			fmt.Fprintf(&sb, "- `%s`\n", strings.ReplaceAll(f, "`", "'"))
+		}
+	}
+	if rep.Status != "" {
+		fmt.Fprintf(&sb, "\n`git status`:\n\n```\n%s\n```\n", hookOutput(rep.Status))
+	}
+	if rep.Diffstat != "" {
+		// git indents every diffstat line by one space; drop it so the
+		// columns stay aligned once the output is trimmed.
+		stat := strings.ReplaceAll("\n"+rep.Diffstat, "\n ", "\n")[1:]
+		fmt.Fprintf(&sb, "\nDiffstat:\n\n```\n%s\n```\n", hookOutput(stat))
+	}
+	sb.WriteString("</details>")
+	return sb.String()
+}
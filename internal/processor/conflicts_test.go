@@ -0,0 +1,37 @@
+package processor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+)
+
+func TestConflictSection(t *testing.T) {
+	got := conflictSection(cherry.ConflictReport{
+		Files:    []string{"a.go", "b/c.go"},
+		Status:   "UU a.go\nUU b/c.go",
+		Diffstat: " a.go   | 4 ++--\n b/c.go | 2 +-\n 2 files changed",
+	})
+	for _, want := range []string{
+		"<details><summary>Conflicts (2 files)</summary>",
+		"- `a.go`\n- `b/c.go`\n",
+		"```\nUU a.go\nUU b/c.go\n```",
+		"```\na.go   | 4 ++--\nb/c.go | 2 +-\n2 files changed\n```",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("section missing %q:\n%s", want, got)
+		}
+	}
+	if !strings.HasSuffix(got, "</details>") {
+		t.Fatalf("section not closed:\n%s", got)
+	}
+
+	if got := conflictSection(cherry.ConflictReport{}); got != "" {
+		t.Fatalf("empty report = %q", got)
+	}
+	if got := conflictDetails(errors.New("clone failed")); got != "" {
+		t.Fatalf("non-conflict error = %q", got)
+	}
+}
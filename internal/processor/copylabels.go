@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+)
+
+// backportLabels are the labels a new backport PR of src gets: the static
+// BackportLabels, src's labels matching a CopyLabels pattern (path.Match
+// globs, e.g. "area/*"), orig-author:<login>, and the markers for patch
+// applied or conflicting picks. Labels naming targets ("cherry-pick to",
+// team aliases) are never copied, or the backport would fan out again once
+// merged.
+func (p *Processor) backportLabels(src pickSource, res cherry.Result) []string {
+	labels := slices.Clone(p.BackportLabels)
+	for _, l := range src.labels {
+		if targetLabel(l) {
+			continue
+		}
+		if slices.ContainsFunc(p.CopyLabels, func(pat string) bool { ok, _ := path.Match(pat, l); return ok }) {
+			labels = append(labels, l)
+		}
+	}
+	if src.author != "" {
+		labels = append(labels, "orig-author:"+src.author)
+	}
+	if res.AppliedViaPatch || res.AppliedViaAm {
+		labels = append(labels, labelAppliedViaPatch)
+	}
+	if len(res.Conflicts) > 0 {
+		labels = append(labels, labelConflicts)
+	}
+	slices.Sort(labels)
+	return slices.Compact(labels)
+}
+
+// targetLabel reports whether label asks for picks.
+func targetLabel(label string) bool {
+	return strings.HasPrefix(label, aliasLabelPrefix) || len(cherry.ParseTargetBranches([]*github.Label{{Name: github.Ptr(label)}})) > 0
+}
+
+// labelNames lists the names of pr's labels.
+func labelNames(pr *github.PullRequest) []string {
+	names := []string{}
+	for _, l := range pr.Labels {
+		if l != nil && l.Name != nil {
+			names = append(names, l.GetName())
+		}
+	}
+	return names
+}
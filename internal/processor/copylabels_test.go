@@ -0,0 +1,52 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+)
+
+func TestBackportLabels(t *testing.T) {
+	p := &Processor{BackportLabels: []string{"autocherry"}, CopyLabels: []string{"bug", "area/*"}}
+	src := pickSource{author: "alice", labels: []string{"area/api", "bug", "cherry-pick to release/1", "wontfix"}}
+
+	got := p.backportLabels(src, cherry.Result{AppliedViaAm: true})
+	want := []string{"applied-via-patch", "area/api", "autocherry", "bug", "orig-author:alice"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("labels = %v; want %v", got, want)
+	}
+	// Not even with a catch-all pattern.
+	p.CopyLabels = []string{"*"}
+	src.labels = append(src.labels, "backport: devops", "cherry-pick to release/1, release/2")
+	if got, want := p.backportLabels(src, cherry.Result{}), []string{"autocherry", "bug", "orig-author:alice", "wontfix"}; !slices.Equal(got, want) {
+		t.Fatalf("labels with * = %v; want %v", got, want)
+	}
+	if got := (&Processor{}).backportLabels(pickSource{}, cherry.Result{}); len(got) != 0 {
+		t.Fatalf("labels without config = %v; want none", got)
+	}
+}
+
+func TestProcessMergedPR_CopiesLabels(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", BackportLabels: []string{"autocherry"}, CopyLabels: []string{"security"}}
+	pr := mergedPR(11, "Fix CVE", "cafef00d1234567", "cherry-pick to devops-release/0021", "security", "needs-docs")
+	fiss := &fakeIssuesFull{}
+	gh := fakeGH{
+		pr:    &fakePRFull{prGet: pr},
+		iss:   fiss,
+		git:   &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}},
+		repos: &fakeReposFull{},
+	}
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/cafef00"}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+	var got []string
+	for _, a := range fiss.addedToIssue {
+		got = append(got, a.Labels...)
+	}
+	if !slices.Contains(got, "autocherry") || !slices.Contains(got, "security") || slices.Contains(got, "needs-docs") {
+		t.Fatalf("labels added = %v", got)
+	}
+}
@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// Markers around the list of sibling backports in backport PR bodies, so it
+// is replaced in place on each update.
+const (
+	siblingsStart = "<!-- autocherry:siblings -->"
+	siblingsEnd   = "<!-- /autocherry:siblings -->"
+)
+
+// linkBackports lists every backport PR of source PR prNum (commit short)
+// among targets in the description of each that is open, so all of them
+// and the original are one click apart. It runs once all targets are
+// picked, so concurrent picks do not overwrite each other's lists.
+func (p *Processor) linkBackports(ctx context.Context, gh GH, owner, repo string, prNum int, short string, targets []string) {
+	type backport struct {
+		target string
+		pr     *github.PullRequest
+	}
+	var found []backport
+	for _, target := range targets {
+		head := fmt.Sprintf("%s:autocherry/%s/%s", owner, strings.ReplaceAll(target, "/", "-"), short)
+		prs, _, err := gh.PR().List(ctx, owner, repo, &github.PullRequestListOptions{State: "all", Head: head, Base: target})
+		if err != nil {
+			slog.Warn("gh.list_backports_error", "repo", owner+"/"+repo, "pr", prNum, "target", target, "err", safeErr(err))
+			continue
+		}
+		if len(prs) > 0 {
+			found = append(found, backport{target: target, pr: prs[0]})
+		}
+	}
+	if len(found) < 2 {
+		return // nothing to link but the original, which the body names already
+	}
+	lines := []string{siblingsStart, fmt.Sprintf("**Backports of #%d:**", prNum)}
+	for _, b := range found {
+		lines = append(lines, fmt.Sprintf("- `%s`: #%d", b.target, b.pr.GetNumber()))
+	}
+	block := strings.Join(append(lines, siblingsEnd), "\n")
+	for _, b := range found {
+		if b.pr.GetState() != pullRequestStateOpen {
+			continue
+		}
+		body := withSiblings(b.pr.GetBody(), block)
+		if body == b.pr.GetBody() {
+			continue
+		}
+		if _, _, err := gh.PR().Edit(ctx, owner, repo, b.pr.GetNumber(), &github.PullRequest{Body: github.Ptr(body)}); err != nil {
+			slog.Warn("gh.link_backports_error", "repo", owner+"/"+repo, "pr", b.pr.GetNumber(), "err", safeErr(err))
+		}
+	}
+}
+
+// withSiblings puts block in place of body's sibling list, or after it.
+func withSiblings(body, block string) string {
+	if i := strings.Index(body, siblingsStart); i >= 0 {
+		if j := strings.Index(body[i:], siblingsEnd); j >= 0 {
+			return body[:i] + block + body[i+j+len(siblingsEnd):]
+		}
+	}
+	return strings.TrimRight(body, "\n") + "\n\n" + block
+}
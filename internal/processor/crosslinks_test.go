@@ -0,0 +1,57 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func TestWithSiblings(t *testing.T) {
+	block := siblingsStart + "\nnew\n" + siblingsEnd
+	if got := withSiblings("Body\n", block); got != "Body\n\n"+block {
+		t.Fatalf("append: %q", got)
+	}
+	old := "Body\n\n" + siblingsStart + "\nold\n" + siblingsEnd + "\n\n---\nfooter"
+	if got := withSiblings(old, block); got != "Body\n\n"+block+"\n\n---\nfooter" {
+		t.Fatalf("replace: %q", got)
+	}
+}
+
+func TestLinkBackports(t *testing.T) {
+	backport := func(num int, target, state string) *github.PullRequest {
+		return &github.PullRequest{
+			Number: github.Ptr(num), State: github.Ptr(state), Body: github.Ptr("Backport of #11"),
+			Head: &github.PullRequestBranch{Ref: github.Ptr("autocherry/" + strings.ReplaceAll(target, "/", "-") + "/cafef00")},
+		}
+	}
+	fpr := &fakePRFull{list: []*github.PullRequest{
+		backport(101, "release/1", "open"),
+		backport(102, "release/2", "closed"),
+		backport(103, "release/3", "open"),
+	}}
+	p := &Processor{}
+	p.linkBackports(context.Background(), fakeGH{pr: fpr}, "o", "r", 11, "cafef00", []string{"release/1", "release/2", "release/3", "release/4"})
+
+	if len(fpr.edited) != 2 {
+		t.Fatalf("edited %d PRs; want the 2 open ones", len(fpr.edited))
+	}
+	want := "**Backports of #11:**\n- `release/1`: #101\n- `release/2`: #102\n- `release/3`: #103\n"
+	for _, e := range fpr.edited {
+		if !strings.HasPrefix(e.GetBody(), "Backport of #11\n\n"+siblingsStart) || !strings.Contains(e.GetBody(), want) {
+			t.Fatalf("body = %q", e.GetBody())
+		}
+	}
+}
+
+func TestLinkBackports_SingleTarget(t *testing.T) {
+	fpr := &fakePRFull{list: []*github.PullRequest{{
+		Number: github.Ptr(101), State: github.Ptr("open"),
+		Head: &github.PullRequestBranch{Ref: github.Ptr("autocherry/release-1/cafef00")},
+	}}}
+	(&Processor{}).linkBackports(context.Background(), fakeGH{pr: fpr}, "o", "r", 11, "cafef00", []string{"release/1"})
+	if len(fpr.edited) != 0 {
+		t.Fatalf("edited = %+v; want none", fpr.edited)
+	}
+}
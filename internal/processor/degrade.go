@@ -0,0 +1,121 @@
+package processor
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
+)
+
+// Optional subsystems: nice-to-haves around the cherry-pick path that are
+// switched off for a cooldown when they keep failing, instead of adding
+// latency and log noise to every event.
+const (
+	subsystemSearch    = "search"     // manual-backport lookups (Search API)
+	subsystemState     = "state"      // state store recording
+	subsystemReactions = "reactions"  // slash-command acknowledgements
+	subsystemMergeBack = "merge_back" // forward-port issue detection
+)
+
+// degradeWindow is how far back errors count towards DegradeAfterErrors.
+const degradeWindow = 5 * time.Minute
+
+var (
+	subsystemErrors = metrics.Default.Counter("optional_subsystem_errors_total",
+		"Errors from optional subsystems, by subsystem.")
+	subsystemDegradations = metrics.Default.Counter("optional_subsystem_degradations_total",
+		"Times an optional subsystem was disabled after exceeding its error budget.")
+	subsystemDegraded = metrics.Default.Gauge("optional_subsystem_degraded",
+		"1 while an optional subsystem is disabled for its cooldown, else 0.")
+)
+
+// degrader tracks recent errors per optional subsystem. The zero value is
+// ready to use.
+type degrader struct {
+	mu   sync.Mutex
+	subs map[string]*subsystemHealth
+}
+
+type subsystemHealth struct {
+	errors []time.Time // within degradeWindow
+	until  time.Time   // disabled until; zero when enabled
+}
+
+func (d *degrader) health(name string) *subsystemHealth {
+	if d.subs == nil {
+		d.subs = map[string]*subsystemHealth{}
+	}
+	h := d.subs[name]
+	if h == nil {
+		h = &subsystemHealth{}
+		d.subs[name] = h
+	}
+	return h
+}
+
+// allow reports whether name may run at now, re-enabling it once its
+// cooldown has passed.
+func (d *degrader) allow(name string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	h := d.health(name)
+	if h.until.IsZero() {
+		return true
+	}
+	if now.Before(h.until) {
+		return false
+	}
+	h.until = time.Time{}
+	subsystemDegraded.Set(0, "subsystem", name)
+	slog.Info("degrade.recovered", "subsystem", name)
+	return true
+}
+
+// fail records an error and reports whether it exhausted the budget (after
+// errors within degradeWindow), disabling name for cooldown.
+func (d *degrader) fail(name string, now time.Time, after int, cooldown time.Duration) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	h := d.health(name)
+	kept := h.errors[:0]
+	for _, t := range h.errors {
+		if now.Sub(t) < degradeWindow {
+			kept = append(kept, t)
+		}
+	}
+	h.errors = append(kept, now)
+	if after <= 0 || len(h.errors) < after || !h.until.IsZero() {
+		return false
+	}
+	h.errors = nil
+	h.until = now.Add(cooldown)
+	return true
+}
+
+// optionalEnabled reports whether an optional subsystem is currently usable.
+func (p *Processor) optionalEnabled(name string) bool {
+	return p.degrade.allow(name, time.Now())
+}
+
+// optionalFailed counts an error from an optional subsystem and disables the
+// subsystem for DegradeCooldown once DegradeAfterErrors errors happened
+// within degradeWindow. The core cherry-pick path never goes through this.
+func (p *Processor) optionalFailed(name string, err error) {
+	subsystemErrors.Inc("subsystem", name)
+	cooldown := p.DegradeCooldown
+	if cooldown <= 0 {
+		cooldown = 5 * time.Minute
+	}
+	if p.degrade.fail(name, time.Now(), p.DegradeAfterErrors, cooldown) {
+		subsystemDegradations.Inc("subsystem", name)
+		subsystemDegraded.Set(1, "subsystem", name)
+		slog.Warn("degrade.engaged",
+			"subsystem", name,
+			"errors", p.DegradeAfterErrors,
+			"window", degradeWindow,
+			"cooldown", cooldown,
+			"last_err", safeErr(err),
+		)
+	}
+}
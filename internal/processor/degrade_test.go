@@ -0,0 +1,72 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+func TestDegrader_TripsAndRecovers(t *testing.T) {
+	var d degrader
+	t0 := time.Unix(1_700_000_000, 0)
+
+	if d.fail("x", t0, 3, time.Minute) || d.fail("x", t0.Add(time.Second), 3, time.Minute) {
+		t.Fatalf("tripped before the budget was used up")
+	}
+	if !d.fail("x", t0.Add(2*time.Second), 3, time.Minute) {
+		t.Fatalf("third error within the window should trip")
+	}
+	if d.allow("x", t0.Add(30*time.Second)) {
+		t.Fatalf("allowed during cooldown")
+	}
+	if !d.allow("y", t0) {
+		t.Fatalf("other subsystems must be unaffected")
+	}
+	if !d.allow("x", t0.Add(63*time.Second)) {
+		t.Fatalf("not re-enabled after cooldown")
+	}
+}
+
+func TestDegrader_OldErrorsExpire(t *testing.T) {
+	var d degrader
+	t0 := time.Unix(1_700_000_000, 0)
+	d.fail("x", t0, 2, time.Minute)
+	if d.fail("x", t0.Add(degradeWindow+time.Second), 2, time.Minute) {
+		t.Fatalf("error outside the window counted towards the budget")
+	}
+	if d.fail("x", t0, 0, time.Minute) {
+		t.Fatalf("budget 0 must never trip")
+	}
+}
+
+// flakyStore fails every Put and counts calls.
+type flakyStore struct {
+	state.Store
+	puts int
+}
+
+func (s *flakyStore) Get(ctx context.Context, repo, workBranch string) (state.Record, bool, error) {
+	return state.Record{}, false, nil
+}
+
+func (s *flakyStore) Put(ctx context.Context, r state.Record) error {
+	s.puts++
+	return errors.New("disk full")
+}
+
+func TestRecord_DisabledAfterErrorBudget(t *testing.T) {
+	st := &flakyStore{}
+	p := &Processor{State: st, DegradeAfterErrors: 2, DegradeCooldown: time.Hour}
+	for range 5 {
+		p.record(context.Background(), state.Record{Repo: "o/r", WorkBranch: "autocherry/x"})
+	}
+	if st.puts != 2 {
+		t.Fatalf("puts = %d, want 2 (then degraded)", st.puts)
+	}
+	if v := subsystemDegraded.Value("subsystem", subsystemState); v != 1 {
+		t.Fatalf("degraded gauge = %v, want 1", v)
+	}
+}
@@ -0,0 +1,64 @@
+package processor
+
+import (
+	"context"
+	"sync"
+)
+
+// inflight tracks background work so shutdown can wait for it. The zero
+// value is ready to use.
+type inflight struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{} // closed and reset whenever n drops to 0
+}
+
+func (f *inflight) add() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n++
+}
+
+func (f *inflight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n--
+	if f.n == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+// wait returns a channel closed once no work is running.
+func (f *inflight) wait() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.n == 0 {
+		ch := make(chan struct{})
+		close(ch)
+		return ch
+	}
+	if f.idle == nil {
+		f.idle = make(chan struct{})
+	}
+	return f.idle
+}
+
+func (f *inflight) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.n
+}
+
+// Drain waits until background work handed off by earlier deliveries (picks,
+// pushes, PR creation) has finished or ctx is done, and returns how many
+// tasks were still running. Stop the ingest sources and the webhook server
+// first so no new work starts meanwhile.
+func (p *Processor) Drain(ctx context.Context) int {
+	select {
+	case <-p.inflight.wait():
+		return 0
+	case <-ctx.Done():
+		return p.inflight.count()
+	}
+}
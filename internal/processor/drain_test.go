@@ -0,0 +1,44 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDrain_WaitsForBackgroundWork(t *testing.T) {
+	p := &Processor{}
+	if left := p.Drain(context.Background()); left != 0 {
+		t.Fatalf("idle Drain = %d", left)
+	}
+
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	p.runWork(false, "d-1", func() { <-release; close(finished) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if left := p.Drain(ctx); left != 1 {
+		t.Fatalf("Drain past deadline = %d, want 1 abandoned", left)
+	}
+
+	close(release)
+	if left := p.Drain(context.Background()); left != 0 {
+		t.Fatalf("Drain = %d after work finished", left)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Fatalf("Drain returned before the work finished")
+	}
+}
+
+func TestDrain_CountsPanickedWorkAsDone(t *testing.T) {
+	p := &Processor{}
+	p.runWork(false, "d-1", func() { panic("boom") })
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if left := p.Drain(ctx); left != 0 {
+		t.Fatalf("Drain = %d after a panic", left)
+	}
+}
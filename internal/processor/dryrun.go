@@ -0,0 +1,196 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/githubapp"
+)
+
+// ghFor wraps installation clients, discarding writes when DryRun is set.
+func (p *Processor) ghFor(clients *githubapp.Clients) GH {
+	gh := GH(realGH{c: clients.REST})
+	if p.DryRun {
+		return dryRunGH{gh}
+	}
+	return gh
+}
+
+// dryRunGH passes reads through and logs writes instead of sending them, so
+// a staging deployment can watch real traffic without touching repositories.
+type dryRunGH struct{ GH }
+
+func (d dryRunGH) PR() PullRequestsAPI     { return dryRunPRs{d.GH.PR()} }
+func (d dryRunGH) Issues() IssuesAPI       { return dryRunIssues{d.GH.Issues()} }
+func (d dryRunGH) Git() GitAPI             { return dryRunGit{d.GH.Git()} }
+func (d dryRunGH) Repos() RepositoriesAPI  { return dryRunRepos{d.GH.Repos()} }
+func (d dryRunGH) Reactions() ReactionsAPI { return dryRunReactions{d.GH.Reactions()} }
+func (d dryRunGH) GraphQL() GraphQLAPI     { return dryRunGraphQL{d.GH.GraphQL()} }
+func (d dryRunGH) Checks() ChecksAPI       { return dryRunChecks{d.GH.Checks()} }
+
+func skipWrite(op, owner, repo string, attrs ...any) {
+	slog.Info("dry_run.skip", append([]any{"op", op, "repo", owner + "/" + repo}, attrs...)...)
+}
+
+type dryRunPRs struct{ PullRequestsAPI }
+
+func (d dryRunPRs) Create(_ context.Context, owner, repo string, pr *github.NewPullRequest) (*github.PullRequest, *github.Response, error) {
+	skipWrite("create_pr", owner, repo, "head", pr.GetHead(), "base", pr.GetBase())
+	return &github.PullRequest{Title: pr.Title, Head: &github.PullRequestBranch{Ref: pr.Head}, Base: &github.PullRequestBranch{Ref: pr.Base}}, nil, nil
+}
+
+func (d dryRunPRs) Edit(_ context.Context, owner, repo string, number int, pr *github.PullRequest) (*github.PullRequest, *github.Response, error) {
+	skipWrite("edit_pr", owner, repo, "pr", number, "state", pr.GetState())
+	return pr, nil, nil
+}
+
+func (d dryRunPRs) Merge(
+	_ context.Context, owner, repo string, number int, _ string, opts *github.PullRequestOptions,
+) (*github.PullRequestMergeResult, *github.Response, error) {
+	skipWrite("merge_pr", owner, repo, "pr", number, "method", opts.MergeMethod)
+	return &github.PullRequestMergeResult{Merged: github.Ptr(true)}, nil, nil
+}
+
+func (d dryRunPRs) RequestReviewers(
+	_ context.Context, owner, repo string, number int, req github.ReviewersRequest,
+) (*github.PullRequest, *github.Response, error) {
+	skipWrite("request_reviewers", owner, repo, "pr", number, "reviewers", req.Reviewers, "teams", req.TeamReviewers)
+	return &github.PullRequest{Number: github.Ptr(number)}, nil, nil
+}
+
+type dryRunIssues struct{ IssuesAPI }
+
+func (d dryRunIssues) Create(_ context.Context, owner, repo string, issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	skipWrite("create_issue", owner, repo, "title", issue.GetTitle())
+	return &github.Issue{Title: issue.Title, Body: issue.Body}, nil, nil
+}
+
+func (d dryRunIssues) CreateComment(_ context.Context, owner, repo string, number int, c *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	skipWrite("create_comment", owner, repo, "issue", number, "body", sanitizeForLog(c.GetBody()))
+	return c, nil, nil
+}
+
+func (d dryRunIssues) EditComment(_ context.Context, owner, repo string, id int64, c *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	skipWrite("edit_comment", owner, repo, "comment", id, "body", sanitizeForLog(c.GetBody()))
+	return c, nil, nil
+}
+
+func (d dryRunIssues) RemoveLabelForIssue(_ context.Context, owner, repo string, number int, label string) (*github.Response, error) {
+	skipWrite("remove_label", owner, repo, "issue", number, "label", label)
+	return nil, nil
+}
+
+func (d dryRunIssues) CreateLabel(_ context.Context, owner, repo string, label *github.Label) (*github.Label, *github.Response, error) {
+	skipWrite("create_label", owner, repo, "label", label.GetName())
+	return label, nil, nil
+}
+
+func (d dryRunIssues) DeleteLabel(_ context.Context, owner, repo, name string) (*github.Response, error) {
+	skipWrite("delete_label", owner, repo, "label", name)
+	return nil, nil
+}
+
+func (d dryRunIssues) AddLabelsToIssue(_ context.Context, owner, repo string, number int, labels []string) ([]*github.Label, *github.Response, error) {
+	skipWrite("add_labels", owner, repo, "issue", number, "labels", labels)
+	out := make([]*github.Label, 0, len(labels))
+	for _, l := range labels {
+		out = append(out, &github.Label{Name: github.Ptr(l)})
+	}
+	return out, nil, nil
+}
+
+func (d dryRunIssues) Edit(_ context.Context, owner, repo string, number int, issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	skipWrite("edit_issue", owner, repo, "issue", number, "milestone", issue.GetMilestone())
+	return &github.Issue{Number: github.Ptr(number)}, nil, nil
+}
+
+func (d dryRunIssues) AddAssignees(_ context.Context, owner, repo string, number int, assignees []string) (*github.Issue, *github.Response, error) {
+	skipWrite("add_assignees", owner, repo, "issue", number, "assignees", assignees)
+	return &github.Issue{Number: github.Ptr(number)}, nil, nil
+}
+
+type dryRunGit struct{ GitAPI }
+
+func (d dryRunGit) DeleteRef(_ context.Context, owner, repo, ref string) (*github.Response, error) {
+	skipWrite("delete_ref", owner, repo, "ref", ref)
+	return nil, nil
+}
+
+func (d dryRunGit) CreateRef(_ context.Context, owner, repo string, ref github.CreateRef) (*github.Reference, *github.Response, error) {
+	skipWrite("create_ref", owner, repo, "ref", ref.Ref, "sha", ref.SHA)
+	return &github.Reference{Ref: github.Ptr(ref.Ref)}, nil, nil
+}
+
+func (d dryRunGit) UpdateRef(_ context.Context, owner, repo, ref string, update github.UpdateRef) (*github.Reference, *github.Response, error) {
+	skipWrite("update_ref", owner, repo, "ref", ref, "sha", update.SHA)
+	return &github.Reference{Ref: github.Ptr(ref)}, nil, nil
+}
+
+type dryRunRepos struct{ RepositoriesAPI }
+
+func (d dryRunRepos) CreateComment(_ context.Context, owner, repo, sha string, c *github.RepositoryComment) (*github.RepositoryComment, *github.Response, error) {
+	skipWrite("create_commit_comment", owner, repo, "sha", sha, "body", sanitizeForLog(c.GetBody()))
+	return c, nil, nil
+}
+
+func (d dryRunRepos) CreateStatus(_ context.Context, owner, repo, ref string, st *github.RepoStatus) (*github.RepoStatus, *github.Response, error) {
+	skipWrite("create_status", owner, repo, "sha", ref, "context", st.GetContext(), "state", st.GetState())
+	return st, nil, nil
+}
+
+func (d dryRunRepos) CreateFile(
+	_ context.Context, owner, repo, path string, opts *github.RepositoryContentFileOptions,
+) (*github.RepositoryContentResponse, *github.Response, error) {
+	skipWrite("create_file", owner, repo, "path", path, "bytes", len(opts.Content))
+	return &github.RepositoryContentResponse{}, nil, nil
+}
+
+type dryRunReactions struct{ ReactionsAPI }
+
+func (d dryRunReactions) CreateIssueCommentReaction(_ context.Context, owner, repo string, id int64, content string) (*github.Reaction, *github.Response, error) {
+	skipWrite("create_reaction", owner, repo, "comment", id, "content", content)
+	return &github.Reaction{Content: github.Ptr(content)}, nil, nil
+}
+
+func (d dryRunReactions) DeleteIssueCommentReaction(_ context.Context, owner, repo string, commentID, _ int64) (*github.Response, error) {
+	skipWrite("delete_reaction", owner, repo, "comment", commentID)
+	return nil, nil
+}
+
+func (d dryRunReactions) CreateIssueReaction(_ context.Context, owner, repo string, number int, content string) (*github.Reaction, *github.Response, error) {
+	skipWrite("create_reaction", owner, repo, "issue", number, "content", content)
+	return &github.Reaction{Content: github.Ptr(content)}, nil, nil
+}
+
+func (d dryRunReactions) DeleteIssueReaction(_ context.Context, owner, repo string, number int, _ int64) (*github.Response, error) {
+	skipWrite("delete_reaction", owner, repo, "issue", number)
+	return nil, nil
+}
+
+// dryRunGraphQL passes queries through and skips mutations.
+type dryRunGraphQL struct{ GraphQLAPI }
+
+func (d dryRunGraphQL) Do(ctx context.Context, query string, vars map[string]any, out any) error {
+	if strings.HasPrefix(strings.TrimSpace(query), "mutation") {
+		slog.Info("dry_run.skip", "op", "graphql_mutation", "vars", vars)
+		return nil
+	}
+	return d.GraphQLAPI.Do(ctx, query, vars, out)
+}
+
+type dryRunChecks struct{ ChecksAPI }
+
+func (d dryRunChecks) CreateCheckRun(_ context.Context, owner, repo string, opts github.CreateCheckRunOptions) (*github.CheckRun, *github.Response, error) {
+	skipWrite("create_check_run", owner, repo, "sha", opts.HeadSHA, "status", opts.GetStatus())
+	return &github.CheckRun{Name: github.Ptr(opts.Name), HeadSHA: github.Ptr(opts.HeadSHA)}, nil, nil
+}
+
+func (d dryRunChecks) UpdateCheckRun(
+	_ context.Context, owner, repo string, id int64, opts github.UpdateCheckRunOptions,
+) (*github.CheckRun, *github.Response, error) {
+	skipWrite("update_check_run", owner, repo, "check_run", id, "status", opts.GetStatus())
+	return &github.CheckRun{ID: github.Ptr(id), Name: github.Ptr(opts.Name)}, nil, nil
+}
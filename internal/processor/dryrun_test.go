@@ -0,0 +1,40 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+)
+
+type pickSpy struct{ called *bool }
+
+func (s pickSpy) Pick(ctx context.Context, owner, repo, token, target, sha string, isMerge bool, opts cherry.Options) (cherry.Result, error) {
+	*s.called = true
+	return cherry.Result{}, nil
+}
+
+func TestProcessMergedPR_DryRunWritesNothing(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", DryRun: true}
+
+	pr := mergedPR(7, "Fix bug", "abc123456789", "cherry-pick to devops-release/0021", "cherry-pick to devops-release/0099")
+	fpr := &fakePRFull{prGet: pr}
+	fiss := &fakeIssuesFull{}
+	gh := fakeGH{
+		pr:    fpr,
+		iss:   fiss,
+		git:   &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}, // 0099 is missing
+		repos: &fakeReposFull{commit: repoCommitWithParents(1)},
+	}
+	picked := false
+	p.CherryRunner = pickSpy{&picked}
+
+	p.processMergedPRWith(context.Background(), "d", dryRunGH{gh}, "o", "r", 7, nil, "tok")
+
+	if picked {
+		t.Fatalf("cherry-pick ran in dry-run mode")
+	}
+	if fpr.createdPR != nil || len(fiss.comments) != 0 {
+		t.Fatalf("dry run wrote to GitHub: pr=%v comments=%d", fpr.createdPR, len(fiss.comments))
+	}
+}
@@ -0,0 +1,172 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
+)
+
+// The Search API allows only 30 requests/minute per installation, so manual
+// backport detection makes one query per (repo, sha) for all targets, caches
+// it, and stops searching near the limit instead of competing with itself.
+const (
+	searchCacheTTL = 10 * time.Minute
+	searchCacheMax = 1024
+	searchReserve  = 3 // keep a few requests for concurrent deliveries
+)
+
+var (
+	searchLookups = metrics.Default.Counter("github_search_lookups_total",
+		"Manual-backport lookups by result: hit, miss, cached, skipped (quota), degraded, error.")
+	searchRemaining = metrics.Default.Gauge("github_search_rate_remaining",
+		"Search API requests left in the current window, as last reported by GitHub, per owner.")
+)
+
+// searchCache holds recent search results and the last known search quota
+// per owner (installation). The zero value is ready to use.
+type searchCache struct {
+	mu      sync.Mutex
+	entries map[string]searchEntry // owner/repo@sha -> PR numbers
+	quota   map[string]github.Rate // owner -> last search rate
+}
+
+type searchEntry struct {
+	at  time.Time
+	prs []int
+}
+
+func (c *searchCache) get(key string, now time.Time) ([]int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || now.Sub(e.at) > searchCacheTTL {
+		return nil, false
+	}
+	return e.prs, true
+}
+
+func (c *searchCache) put(key string, prs []int, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]searchEntry{}
+	}
+	if len(c.entries) >= searchCacheMax {
+		for k, e := range c.entries {
+			if now.Sub(e.at) > searchCacheTTL {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= searchCacheMax {
+			c.entries = map[string]searchEntry{}
+		}
+	}
+	c.entries[key] = searchEntry{at: now, prs: prs}
+}
+
+// exhausted reports whether owner's search quota is (nearly) used up.
+func (c *searchCache) exhausted(owner string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.quota[owner]
+	return ok && r.Remaining <= searchReserve && now.Before(r.Reset.Time)
+}
+
+func (c *searchCache) setQuota(owner string, r github.Rate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.quota == nil {
+		c.quota = map[string]github.Rate{}
+	}
+	c.quota[owner] = r
+	searchRemaining.Set(float64(r.Remaining), "owner", owner)
+}
+
+// findManualBackports returns, per base branch, an open or merged PR other
+// than the source PR (and not one of ours) that references sha — i.e. a
+// backport someone already did by hand. It is best-effort: on errors or when
+// the search quota is low it returns nil and the pick goes ahead.
+func (p *Processor) findManualBackports(ctx context.Context, gh GH, owner, repo, sha string, sourcePR int) map[string]*github.PullRequest {
+	if !p.SearchDedupe {
+		return nil
+	}
+	now := time.Now()
+	key := owner + "/" + repo + "@" + sha
+	nums, ok := p.search.get(key, now)
+	switch {
+	case ok:
+		searchLookups.Inc("result", "cached")
+	case p.search.exhausted(owner, now):
+		searchLookups.Inc("result", "skipped")
+		slog.Info("search.quota_low_skip", "repo", owner+"/"+repo, "sha", sha)
+		return nil
+	case !p.optionalEnabled(subsystemSearch):
+		searchLookups.Inc("result", "degraded")
+		return nil
+	default:
+		var err error
+		if nums, err = p.searchPRsReferencing(ctx, gh, owner, repo, sha); err != nil {
+			searchLookups.Inc("result", "error")
+			slog.Warn("search.error", "repo", owner+"/"+repo, "sha", sha, "err", safeErr(err))
+			// Rate limiting is already handled by the quota tracking above.
+			var rle *github.RateLimitError
+			if !errors.As(err, &rle) {
+				p.optionalFailed(subsystemSearch, err)
+			}
+			return nil
+		}
+		p.search.put(key, nums, now)
+		if len(nums) == 0 {
+			searchLookups.Inc("result", "miss")
+		} else {
+			searchLookups.Inc("result", "hit")
+		}
+	}
+
+	out := map[string]*github.PullRequest{}
+	for _, n := range nums {
+		if n == sourcePR {
+			continue
+		}
+		pr, _, err := gh.PR().Get(ctx, owner, repo, n)
+		if err != nil || pr == nil || strings.HasPrefix(pr.GetHead().GetRef(), workBranchPrefix) {
+			continue
+		}
+		if pr.GetState() == pullRequestStateOpen || pr.GetMerged() {
+			if base := pr.GetBase().GetRef(); base != "" && out[base] == nil {
+				out[base] = pr
+			}
+		}
+	}
+	return out
+}
+
+func (p *Processor) searchPRsReferencing(ctx context.Context, gh GH, owner, repo, sha string) ([]int, error) {
+	q := fmt.Sprintf("repo:%s/%s is:pr %s", owner, repo, sha)
+	res, resp, err := gh.Search().Issues(ctx, q, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 20}})
+	var rle *github.RateLimitError
+	switch {
+	case errors.As(err, &rle):
+		p.search.setQuota(owner, rle.Rate)
+	case resp != nil && resp.Rate.Limit > 0:
+		p.search.setQuota(owner, resp.Rate)
+	}
+	if err != nil {
+		return nil, err
+	}
+	nums := make([]int, 0, len(res.Issues))
+	for _, is := range res.Issues {
+		if is != nil && is.IsPullRequest() {
+			nums = append(nums, is.GetNumber())
+		}
+	}
+	return nums, nil
+}
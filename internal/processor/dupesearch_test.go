@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// prByNumber serves PR().Get from a map (fakePRFull.Get ignores the number).
+type prByNumber struct {
+	*fakePRFull
+	byNum map[int]*github.PullRequest
+}
+
+func (f prByNumber) Get(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+	if pr, ok := f.byNum[number]; ok {
+		return pr, nil, nil
+	}
+	return f.fakePRFull.Get(ctx, owner, repo, number)
+}
+
+type ghWithPRs struct {
+	fakeGH
+	prs PullRequestsAPI
+}
+
+func (g ghWithPRs) PR() PullRequestsAPI { return g.prs }
+
+func TestProcessMergedPR_SkipsTargetWithManualBackport(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", SearchDedupe: true}
+
+	src := mergedPR(7, "Fix bug", "abc123456789", "cherry-pick to devops-release/0021", "cherry-pick to devops-release/0022")
+	fpr := &fakePRFull{prGet: src}
+	manual := &github.PullRequest{
+		Number: github.Ptr(50), State: github.Ptr("open"), HTMLURL: github.Ptr("https://example.com/50"),
+		Head: &github.PullRequestBranch{Ref: github.Ptr("alice/backport-fix")},
+		Base: &github.PullRequestBranch{Ref: github.Ptr("devops-release/0021")},
+	}
+	fsearch := &fakeSearch{
+		issues: []*github.Issue{
+			{Number: github.Ptr(7), PullRequestLinks: &github.PullRequestLinks{}},  // the source PR itself
+			{Number: github.Ptr(50), PullRequestLinks: &github.PullRequestLinks{}}, // manual backport
+		},
+		rate: github.Rate{Limit: 30, Remaining: 29, Reset: github.Timestamp{Time: time.Now().Add(time.Minute)}},
+	}
+	fiss := &fakeIssuesFull{}
+	gh := ghWithPRs{
+		fakeGH: fakeGH{
+			pr: fpr, iss: fiss, search: fsearch, repos: &fakeReposFull{commit: repoCommitWithParents(1)},
+			git: &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true, "refs/heads/devops-release/0022": true}},
+		},
+		prs: prByNumber{fakePRFull: fpr, byNum: map[int]*github.PullRequest{50: manual}},
+	}
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0022/abc1234"}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 7, nil, "tok")
+
+	if len(fsearch.queries) != 1 || fsearch.queries[0] != "repo:o/r is:pr abc123456789" {
+		t.Fatalf("search queries = %q, want one batched query", fsearch.queries)
+	}
+	var skipped, opened bool
+	for _, c := range fiss.comments {
+		skipped = skipped || strings.Contains(c.GetBody(), "`devops-release/0021` already has a backport")
+		opened = opened || strings.Contains(c.GetBody(), "✅ Auto cherry-pick to `devops-release/0022`")
+	}
+	if !skipped || !opened {
+		t.Fatalf("want 0021 skipped and 0022 opened; comments: %+v", fiss.comments)
+	}
+
+	// Same (repo, sha) again is served from the cache.
+	p.findManualBackports(context.Background(), gh, "o", "r", "abc123456789", 7)
+	if len(fsearch.queries) != 1 {
+		t.Fatalf("expected cached result, got %d queries", len(fsearch.queries))
+	}
+}
+
+func TestFindManualBackports_SkipsWhenQuotaLow(t *testing.T) {
+	p := &Processor{SearchDedupe: true}
+	p.search.setQuota("o", github.Rate{Limit: 30, Remaining: 1, Reset: github.Timestamp{Time: time.Now().Add(time.Minute)}})
+	fsearch := &fakeSearch{}
+	gh := fakeGH{pr: &fakePRFull{}, search: fsearch}
+
+	if got := p.findManualBackports(context.Background(), gh, "o", "r", "abc", 1); got != nil {
+		t.Fatalf("got %v, want nil when quota is low", got)
+	}
+	if len(fsearch.queries) != 0 {
+		t.Fatalf("searched despite low quota")
+	}
+	if v := searchRemaining.Value("owner", "o"); v != 1 {
+		t.Fatalf("github_search_rate_remaining = %v, want 1", v)
+	}
+
+	// An expired window allows searching again.
+	p.search.setQuota("o", github.Rate{Remaining: 0, Reset: github.Timestamp{Time: time.Now().Add(-time.Second)}})
+	p.findManualBackports(context.Background(), gh, "o", "r", "abc", 1)
+	if len(fsearch.queries) != 1 {
+		t.Fatalf("expected a search after the window reset")
+	}
+}
@@ -0,0 +1,35 @@
+package processor
+
+import (
+	"strconv"
+	"strings"
+)
+
+// footerFor renders CommitFooter for the picks of src onto target; nil (git's
+// plain -x line) when no template is set. Placeholders: {x} (git's own
+// "(cherry picked from commit <sha>)" line), {sha}, {short_sha}, {pr} (the
+// source PR number, empty for bare commits), {author} (its author's login)
+// and {target}.
+func (p *Processor) footerFor(src pickSource, target string) func(sha string) string {
+	if p.CommitFooter == "" {
+		return nil
+	}
+	pr := ""
+	if src.issue > 0 {
+		pr = strconv.Itoa(src.issue)
+	}
+	return func(sha string) string {
+		short := sha
+		if len(short) > 7 {
+			short = sha[:7]
+		}
+		return strings.NewReplacer(
+			"{x}", "(cherry picked from commit "+sha+")",
+			"{sha}", sha,
+			"{short_sha}", short,
+			"{pr}", pr,
+			"{author}", src.author,
+			"{target}", target,
+		).Replace(p.CommitFooter)
+	}
+}
@@ -0,0 +1,40 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+)
+
+func TestFooterFor(t *testing.T) {
+	p := &Processor{}
+	if p.footerFor(pickSource{issue: 12}, "release/1") != nil {
+		t.Fatal("no template should keep git's -x line")
+	}
+	p.CommitFooter = "{x}\nBackport-of: #{pr} by @{author} to {target} ({short_sha})"
+	got := p.footerFor(pickSource{issue: 12, author: "dev"}, "release/1")("abcdef123456")
+	if want := "(cherry picked from commit abcdef123456)\nBackport-of: #12 by @dev to release/1 (abcdef1)"; got != want {
+		t.Fatalf("footer = %q; want %q", got, want)
+	}
+	if got := p.footerFor(pickSource{}, "release/1")("abc"); got != "(cherry picked from commit abc)\nBackport-of: # by @ to release/1 (abc)" {
+		t.Fatalf("footer without a PR = %q", got)
+	}
+}
+
+func TestProcessMergedPR_CommitFooter(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", CommitFooter: "Backport-of: #{pr}"}
+	pr := mergedPR(11, "Fix", "abc123456789", "cherry-pick to devops-release/0021")
+	fpr := &fakePRFull{prGet: pr}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	gh := fakeGH{pr: fpr, iss: &fakeIssuesFull{}, git: fgit, repos: &fakeReposFull{commit: repoCommitWithParents(1)}}
+
+	var opts cherry.Options
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234", opts: &opts}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+	if opts.Footer == nil || opts.Footer("abc123456789") != "Backport-of: #11" {
+		t.Fatalf("opts.Footer not set from the template")
+	}
+}
@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/gitexec"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+// FromConfig builds the Processor both entrypoints run: its settings, state
+// store and git caches from cfg. What only one entrypoint has (ingest mode,
+// leases, usage metering, support bundles) is left to the caller.
+func FromConfig(ctx context.Context, cfg *config.Config) (*Processor, error) {
+	p := &Processor{
+		AppID:              cfg.AppID,
+		PrivateKeyPEM:      cfg.PrivateKeyPEM,
+		WebhookSecret:      cfg.WebhookSecret,
+		GitUserName:        cfg.GitUserName,
+		GitUserEmail:       cfg.GitUserEmail,
+		CherryTimeout:      time.Duration(cfg.CherryTimeoutSeconds) * time.Second,
+		PatchFallback:      cfg.PatchFallback,
+		AmFallback:         cfg.AmFallback,
+		CreditAuthors:      cfg.CreditAuthors,
+		Signoff:            cfg.Signoff,
+		PickEmpty:          cfg.PickEmpty,
+		PickRedundant:      cfg.PickRedundant,
+		CommitFooter:       cfg.CommitFooter,
+		PushRetry:          cfg.PushRetry,
+		SSHKeyDir:          cfg.SSHKeyDir,
+		SSHKnownHosts:      cfg.SSHKnownHosts,
+		APIPicks:           cfg.APIPicks,
+		FullCloneFallback:  cfg.FullCloneFallback,
+		ParallelTargets:    cfg.ParallelTargets,
+		LFS:                cfg.LFS,
+		ConflictPRs:        cfg.ConflictPRs,
+		PickStrategies:     cfg.PickStrategies,
+		PickPRCommits:      cfg.PickPRCommits,
+		PostPickHooks:      cfg.PostPickHooks,
+		PickVerify:         cfg.PickVerify,
+		PrePushChecks:      cfg.PrePushChecks,
+		MergeBackDetector:  cfg.MergeBackDetector,
+		TrailerBackports:   cfg.TrailerBackports,
+		AutoMergeApproved:  cfg.AutoMergeApproved,
+		AutoMergeMethod:    cfg.AutoMergeMethod,
+		EnableAutoMerge:    cfg.EnableAutoMerge,
+		BootstrapLabels:    cfg.RepoBootstrapLabels,
+		BootstrapConfig:    cfg.RepoBootstrapConfig,
+		BackportLabels:     cfg.BackportLabels,
+		CopyLabels:         cfg.CopyLabels,
+		AssignAuthor:       cfg.AssignAuthor,
+		ReviewAuthor:       cfg.ReviewAuthor,
+		ReviewTeams:        cfg.ReviewTeams,
+		CodeOwnerReviews:   cfg.CodeOwnerReviews,
+		CopyMilestone:      cfg.CopyMilestone,
+		DraftPRs:           cfg.DraftPRs,
+		RepoDrafts:         cfg.RepoDrafts,
+		PRTitleTemplate:    cfg.PRTitleTemplate,
+		PRBodyTemplate:     cfg.PRBodyTemplate,
+		RepoPRTemplates:    cfg.RepoPRTemplates,
+		LinkBackports:      cfg.LinkBackports,
+		MilestoneTemplate:  cfg.MilestoneTargetTemplate,
+		BodyDirective:      cfg.BackportDirective,
+		MergeTrailers:      cfg.MergeCommitTrailers,
+		CommitStatuses:     cfg.CommitStatuses,
+		CheckRuns:          cfg.CheckRuns,
+		StatusComment:      cfg.StatusComment,
+		DeleteWorkBranches: cfg.DeleteWorkBranches,
+		CloseSuperseded:    cfg.CloseSuperseded,
+		TrainWindow:        time.Duration(cfg.TrainWindowMinutes) * time.Minute,
+		ProjectID:          cfg.ProjectID,
+		ProjectStatus:      cfg.ProjectStatus,
+		RevertBackports:    cfg.RevertBackports,
+		ReleaseTagPattern:  cfg.ReleaseTagPattern,
+		ReleaseTagBranch:   cfg.ReleaseTagBranch,
+		ForkToken:          cfg.ForkPushToken,
+		DryRun:             cfg.DryRun,
+		SearchDedupe:       cfg.SearchDedupe,
+		RecheckLabels:      cfg.LabelRecheck,
+		PickAddedTargets:   cfg.LabelRecheckAdd,
+		DegradeAfterErrors: cfg.DegradeAfterErrors,
+		DegradeCooldown:    time.Duration(cfg.DegradeCooldownSeconds) * time.Second,
+	}
+
+	var err error
+	if p.State, err = state.Open(ctx, StateSettings(cfg)); err != nil {
+		return nil, err
+	}
+	if cfg.GitMirrorDir != "" {
+		if p.Mirrors, err = gitexec.NewMirrorCache(cfg.GitMirrorDir); err != nil {
+			return nil, fmt.Errorf("GIT_MIRROR_DIR: %w", err)
+		}
+	}
+	p.Workspace = &gitexec.Workspace{Dir: cfg.WorkspaceDir, Quota: int64(cfg.WorkspaceQuotaMB) << 20}
+	if cfg.GitWorktreeDir != "" {
+		if p.SharedClones, err = gitexec.NewSharedClones(cfg.GitWorktreeDir); err != nil {
+			return nil, fmt.Errorf("GIT_WORKTREE_DIR: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// StateSettings picks the state store's settings out of cfg.
+func StateSettings(cfg *config.Config) state.Settings {
+	return state.Settings{
+		Backend:       cfg.StateBackend,
+		File:          cfg.StateFile,
+		SQLitePath:    cfg.StateSQLitePath,
+		DynamoDBTable: cfg.StateDynamoDBTable,
+		AWSRegion:     cfg.AWSRegion,
+	}
+}
@@ -0,0 +1,34 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/config"
+)
+
+func TestFromConfig(t *testing.T) {
+	cfg := &config.Config{
+		AppID: 42, GitUserName: "bot", CherryTimeoutSeconds: 90, TrainWindowMinutes: 30,
+		DeleteWorkBranches: true, WorkspaceDir: t.TempDir(), WorkspaceQuotaMB: 2,
+	}
+	p, err := FromConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.AppID != 42 || p.GitUserName != "bot" || !p.DeleteWorkBranches {
+		t.Errorf("settings = %d, %q, %v", p.AppID, p.GitUserName, p.DeleteWorkBranches)
+	}
+	if p.CherryTimeout != 90*time.Second || p.TrainWindow != 30*time.Minute {
+		t.Errorf("durations = %v, %v", p.CherryTimeout, p.TrainWindow)
+	}
+	if p.Workspace == nil || p.Workspace.Quota != 2<<20 || p.State != nil || p.Mirrors != nil {
+		t.Errorf("workspace = %+v, state = %v, mirrors = %v", p.Workspace, p.State, p.Mirrors)
+	}
+
+	cfg.StateBackend = "nope"
+	if _, err := FromConfig(context.Background(), cfg); err == nil {
+		t.Fatal("want an error for an unknown STATE_BACKEND")
+	}
+}
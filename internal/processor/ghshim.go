@@ -2,6 +2,8 @@ package processor
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 
 	github "github.com/google/go-github/v75/github"
@@ -15,10 +17,23 @@ type PullRequestsAPI interface {
 	ListCommits(ctx context.Context, owner, repo string, number int, opt *github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error)
 	Create(ctx context.Context, owner, repo string, pr *github.NewPullRequest) (*github.PullRequest, *github.Response, error)
 	Edit(ctx context.Context, owner, repo string, number int, pr *github.PullRequest) (*github.PullRequest, *github.Response, error)
+	// ListPullRequestsWithCommit tells PR merge commits from direct pushes.
+	ListPullRequestsWithCommit(ctx context.Context, owner, repo, sha string, opts *github.ListOptions) ([]*github.PullRequest, *github.Response, error)
+	ListReviews(ctx context.Context, owner, repo string, number int, opts *github.ListOptions) ([]*github.PullRequestReview, *github.Response, error)
+	ListFiles(ctx context.Context, owner, repo string, number int, opts *github.ListOptions) ([]*github.CommitFile, *github.Response, error)
+	RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers github.ReviewersRequest) (*github.PullRequest, *github.Response, error)
+	// GetRaw fetches a PR's .patch mailbox for the git am fallback.
+	GetRaw(ctx context.Context, owner, repo string, number int, opts github.RawOptions) (string, *github.Response, error)
+	Merge(
+		ctx context.Context, owner, repo string, number int, commitMessage string, opts *github.PullRequestOptions,
+	) (*github.PullRequestMergeResult, *github.Response, error)
 }
 
 type IssuesAPI interface {
+	Create(ctx context.Context, owner, repo string, issue *github.IssueRequest) (*github.Issue, *github.Response, error)
 	CreateComment(ctx context.Context, owner, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error)
+	ListComments(ctx context.Context, owner, repo string, number int, opts *github.IssueListCommentsOptions) ([]*github.IssueComment, *github.Response, error)
+	EditComment(ctx context.Context, owner, repo string, commentID int64, comment *github.IssueComment) (*github.IssueComment, *github.Response, error)
 	ListByRepo(ctx context.Context, owner, repo string, opt *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error)
 	RemoveLabelForIssue(ctx context.Context, owner, repo string, number int, label string) (*github.Response, error)
 
@@ -28,15 +43,66 @@ type IssuesAPI interface {
 
 	// NEW: needed so we can attach "orig-author:<login>" to the cherry-pick PR.
 	AddLabelsToIssue(ctx context.Context, owner, repo string, number int, labels []string) ([]*github.Label, *github.Response, error)
+	Edit(ctx context.Context, owner, repo string, number int, issue *github.IssueRequest) (*github.Issue, *github.Response, error)
+	ListMilestones(ctx context.Context, owner, repo string, opts *github.MilestoneListOptions) ([]*github.Milestone, *github.Response, error)
+	AddAssignees(ctx context.Context, owner, repo string, number int, assignees []string) (*github.Issue, *github.Response, error)
 }
 
 type GitAPI interface {
 	GetRef(ctx context.Context, owner, repo, ref string) (*github.Reference, *github.Response, error)
 	DeleteRef(ctx context.Context, owner, repo, ref string) (*github.Response, error)
+	CreateRef(ctx context.Context, owner, repo string, ref github.CreateRef) (*github.Reference, *github.Response, error)
+	UpdateRef(ctx context.Context, owner, repo, ref string, update github.UpdateRef) (*github.Reference, *github.Response, error)
+	ListMatchingRefs(ctx context.Context, owner, repo string, opts *github.ReferenceListOptions) ([]*github.Reference, *github.Response, error)
+	GetCommit(ctx context.Context, owner, repo, sha string) (*github.Commit, *github.Response, error)
+	GetTree(ctx context.Context, owner, repo, sha string, recursive bool) (*github.Tree, *github.Response, error)
+	CreateTree(ctx context.Context, owner, repo, baseTree string, entries []*github.TreeEntry) (*github.Tree, *github.Response, error)
+	CreateCommit(ctx context.Context, owner, repo string, commit github.Commit, opts *github.CreateCommitOptions) (*github.Commit, *github.Response, error)
 }
 
 type RepositoriesAPI interface {
 	GetCommit(ctx context.Context, owner, repo, sha string, opts *github.ListOptions) (*github.RepositoryCommit, *github.Response, error)
+	CompareCommits(ctx context.Context, owner, repo, base, head string, opts *github.ListOptions) (*github.CommitsComparison, *github.Response, error)
+	GetCommitRaw(ctx context.Context, owner, repo, sha string, opts github.RawOptions) (string, *github.Response, error)
+	GetContents(
+		ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions,
+	) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error)
+	// GetPermissionLevel gates slash commands on the commenter's access.
+	GetPermissionLevel(ctx context.Context, owner, repo, user string) (*github.RepositoryPermissionLevel, *github.Response, error)
+	// CreateComment reports on commits that have no PR (trailer backports).
+	CreateComment(ctx context.Context, owner, repo, sha string, comment *github.RepositoryComment) (*github.RepositoryComment, *github.Response, error)
+	// CreateStatus reports each target's backport on the picked commit.
+	CreateStatus(ctx context.Context, owner, repo, ref string, status *github.RepoStatus) (*github.RepoStatus, *github.Response, error)
+	// CreateFile commits the starter config to new repositories.
+	CreateFile(
+		ctx context.Context, owner, repo, path string, opts *github.RepositoryContentFileOptions,
+	) (*github.RepositoryContentResponse, *github.Response, error)
+}
+
+// ChecksAPI keeps the "cherry-pick" check run on picked commits.
+type ChecksAPI interface {
+	ListCheckRunsForRef(ctx context.Context, owner, repo, ref string, opts *github.ListCheckRunsOptions) (*github.ListCheckRunsResults, *github.Response, error)
+	CreateCheckRun(ctx context.Context, owner, repo string, opts github.CreateCheckRunOptions) (*github.CheckRun, *github.Response, error)
+	UpdateCheckRun(ctx context.Context, owner, repo string, checkRunID int64, opts github.UpdateCheckRunOptions) (*github.CheckRun, *github.Response, error)
+}
+
+// ReactionsAPI is used to acknowledge slash-command comments.
+type ReactionsAPI interface {
+	CreateIssueCommentReaction(ctx context.Context, owner, repo string, id int64, content string) (*github.Reaction, *github.Response, error)
+	DeleteIssueCommentReaction(ctx context.Context, owner, repo string, commentID, reactionID int64) (*github.Response, error)
+	CreateIssueReaction(ctx context.Context, owner, repo string, number int, content string) (*github.Reaction, *github.Response, error)
+	DeleteIssueReaction(ctx context.Context, owner, repo string, issueNumber int, reactionID int64) (*github.Response, error)
+}
+
+// SearchAPI finds PRs referencing a commit (manual backport detection).
+type SearchAPI interface {
+	Issues(ctx context.Context, query string, opts *github.SearchOptions) (*github.IssuesSearchResult, *github.Response, error)
+}
+
+// GraphQLAPI runs GraphQL documents for what REST lacks (enabling
+// auto-merge). out receives the "data" object.
+type GraphQLAPI interface {
+	Do(ctx context.Context, query string, vars map[string]any, out any) error
 }
 
 type GH interface {
@@ -44,15 +110,50 @@ type GH interface {
 	Issues() IssuesAPI
 	Git() GitAPI
 	Repos() RepositoriesAPI
+	Reactions() ReactionsAPI
+	Search() SearchAPI
+	GraphQL() GraphQLAPI
+	Checks() ChecksAPI
 }
 
 // real wrapper used in production
 type realGH struct{ c *github.Client }
 
-func (r realGH) PR() PullRequestsAPI    { return r.c.PullRequests }
-func (r realGH) Issues() IssuesAPI      { return r.c.Issues }
-func (r realGH) Git() GitAPI            { return r.c.Git }
-func (r realGH) Repos() RepositoriesAPI { return r.c.Repositories }
+func (r realGH) PR() PullRequestsAPI     { return r.c.PullRequests }
+func (r realGH) Issues() IssuesAPI       { return r.c.Issues }
+func (r realGH) Git() GitAPI             { return r.c.Git }
+func (r realGH) Repos() RepositoriesAPI  { return r.c.Repositories }
+func (r realGH) Reactions() ReactionsAPI { return r.c.Reactions }
+func (r realGH) Search() SearchAPI       { return r.c.Search }
+func (r realGH) GraphQL() GraphQLAPI     { return graphQL{r.c} }
+func (r realGH) Checks() ChecksAPI       { return r.c.Checks }
+
+// graphQL posts to the client's /graphql endpoint, so it shares its auth
+// and transport.
+type graphQL struct{ c *github.Client }
+
+func (g graphQL) Do(ctx context.Context, query string, vars map[string]any, out any) error {
+	req, err := g.c.NewRequest(http.MethodPost, "graphql", map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := g.c.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("graphql: %s", resp.Errors[0].Message)
+	}
+	if out == nil || len(resp.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, out)
+}
 
 // Optional compile-time assertions
 var (
@@ -60,5 +161,7 @@ var (
 	_ IssuesAPI       = (*github.IssuesService)(nil)
 	_ GitAPI          = (*github.GitService)(nil)
 	_ RepositoriesAPI = (*github.RepositoriesService)(nil)
+	_ ReactionsAPI    = (*github.ReactionsService)(nil)
+	_ SearchAPI       = (*github.SearchService)(nil)
 	_ *http.Client    // keep import
 )
@@ -0,0 +1,44 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// GiveUp implements ingest.GiveUpHandler: when a queue backend stops retrying
+// a pull_request delivery, the PR gets a comment so its author knows no
+// backport is coming. Other events are only logged.
+func (p *Processor) GiveUp(ctx context.Context, event, delivery string, payload []byte, attempts int, lastErr error) {
+	slog.Error("webhook.gave_up", "delivery", sanitizeForLog(delivery), "event", event, "attempts", attempts, "err", safeErr(lastErr))
+	if event != "pull_request" {
+		return
+	}
+	var e github.PullRequestEvent
+	if err := json.Unmarshal(payload, &e); err != nil || e.GetInstallation().GetID() == 0 || e.GetNumber() == 0 {
+		return
+	}
+	clients, err := p.buildClients(e.GetInstallation().GetID())
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(delivery), "err", safeErr(err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	p.commentGaveUp(ctx, p.ghFor(clients), e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName(), e.GetNumber(), attempts, lastErr)
+}
+
+func (p *Processor) commentGaveUp(ctx context.Context, gh GH, owner, repo string, prNum, attempts int, lastErr error) {
+	body := fmt.Sprintf("⚠️ Auto cherry-pick gave up on this PR after %d delivery attempts.", attempts)
+	if lastErr != nil {
+		body += fmt.Sprintf("\n\nLast error: `%s`", safeErr(lastErr))
+	}
+	body += "\n\nRe-apply the `cherry-pick to` label to try again, or cherry-pick manually."
+	if _, _, err := gh.Issues().CreateComment(ctx, owner, repo, prNum, &github.IssueComment{Body: github.Ptr(body)}); err != nil {
+		slog.Warn("gh.comment_error", "repo", owner+"/"+repo, "pr", prNum, "err", safeErr(err))
+	}
+}
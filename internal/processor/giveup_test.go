@@ -0,0 +1,36 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/githubapp"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/ingest"
+)
+
+var _ ingest.GiveUpHandler = (*Processor)(nil)
+
+func TestCommentGaveUp(t *testing.T) {
+	fiss := &fakeIssuesFull{}
+	p := &Processor{}
+	p.commentGaveUp(context.Background(), fakeGH{iss: fiss}, "o", "r", 7, 5, errors.New("clone timed out"))
+
+	if len(fiss.comments) != 1 {
+		t.Fatalf("comments = %d, want 1", len(fiss.comments))
+	}
+	body := fiss.comments[0].GetBody()
+	if !strings.Contains(body, "after 5 delivery attempts") || !strings.Contains(body, "clone timed out") {
+		t.Fatalf("comment = %q", body)
+	}
+}
+
+func TestGiveUp_IgnoresOtherEvents(t *testing.T) {
+	p := &Processor{NewClients: func(appID, installationID int64, pem []byte) (*githubapp.Clients, error) {
+		t.Fatalf("no GitHub client expected")
+		return nil, nil
+	}}
+	p.GiveUp(context.Background(), "create", "d", []byte(`{"ref":"x"}`), 5, nil)
+	p.GiveUp(context.Background(), "pull_request", "d", []byte(`{"number":0}`), 5, nil)
+}
@@ -11,21 +11,42 @@ import (
 	"log/slog"
 	"net/http"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	github "github.com/google/go-github/v75/github"
 
 	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/gitexec"
 	"github.com/ealebed/gh-app-cherry-pick-poc/internal/githubapp"
 	qenv "github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/shard"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/usage"
 )
 
+// reReleaseBranch matches release branches, e.g. devops-release/0021.
+var reReleaseBranch = regexp.MustCompile(`^([a-z0-9-]+-release)/(\d{4})$`)
+
 // pullRequestStateOpen is GitHub's API value for an open pull request.
 const pullRequestStateOpen = "open"
 
+// pullRequestStateClosed is GitHub's API value for a closed (or merged) pull request.
+const pullRequestStateClosed = "closed"
+
+// labelAppliedViaPatch marks cherry-pick PRs produced by the diff or git am
+// fallback.
+const labelAppliedViaPatch = "applied-via-patch"
+
+// labelConflicts marks draft cherry-pick PRs committed with conflict markers.
+const labelConflicts = "conflicts"
+
 // Processor handles GitHub events from queue envelopes.
 type Processor struct {
 	AppID         int64
@@ -37,12 +58,288 @@ type Processor struct {
 	// Configurable timeout for a single merged-PR processing (clone/fetch/cherry/push).
 	CherryTimeout time.Duration
 
+	// PatchFallback applies the commit's API diff with `git apply --3way` when
+	// `git cherry-pick` conflicts (typically on renamed files).
+	PatchFallback bool
+
+	// AmFallback applies the source PR's .patch with `git am -3` when the
+	// cherry-pick conflicts or its commit cannot be fetched, before trying
+	// PatchFallback.
+	AmFallback bool
+
+	// CreditAuthors makes a merged PR's author the author of its picked merge
+	// commit, instead of whoever merged it, and adds Co-authored-by trailers
+	// for the other authors of its commits.
+	CreditAuthors bool
+
+	// FullCloneFallback retries a conflicting pick with a complete
+	// (non-shallow, non-partial) clone before giving up.
+	FullCloneFallback bool
+
+	// Mirrors, when set, caches a bare mirror of each repository that picks
+	// refresh and fetch from instead of cloning from GitHub every time.
+	Mirrors *gitexec.MirrorCache
+
+	// SharedClones, when set, runs each pick in a git worktree of a shared
+	// clone of its repository instead of a fresh clone, so concurrent picks
+	// of one repository do not clone it once each.
+	SharedClones *gitexec.SharedClones
+
+	// Workspace, when set, holds picks' work trees within a disk quota and
+	// sweeps those crashed runs leave behind.
+	Workspace *gitexec.Workspace
+
+	// LFS sets up git-lfs for picks onto branches that use Git LFS, so LFS
+	// pointers are picked without smudging and their objects still pushed.
+	LFS bool
+
+	// ParallelTargets picks up to this many targets of one PR at once
+	// (<= 1 = one after the other); best with SharedClones.
+	ParallelTargets int
+
+	// Signoff adds the bot's Signed-off-by trailer to the commits of every
+	// pick, so backports pass DCO checks on target branches.
+	Signoff bool
+
+	// PickEmpty picks commits that are empty to begin with (version bump
+	// markers and the like) into backport PRs instead of reporting them as
+	// already on the target; PickRedundant does so even for commits whose
+	// change the target already has.
+	PickEmpty     bool
+	PickRedundant bool
+
+	// CommitFooter, when set, replaces git's "(cherry picked from commit
+	// <sha>)" line in picked commits, e.g. "Backport-of: #{pr}" for tooling
+	// that wants its own trailers (see footerFor for the placeholders).
+	CommitFooter string
+
+	// PushRetry is what picks do when the work branch push is rejected
+	// because the branch already has other commits (e.g. another replica
+	// pushed it): cherry.PushRetryRebase, cherry.PushRetryForce, or "" to
+	// fail the pick.
+	PushRetry string
+
+	// SSHKeyDir, when set, holds deploy keys (one per installation ID, or
+	// "default") that picks clone and push with over SSH instead of the
+	// installation token (see sshFor); SSHKnownHosts pins GitHub's host keys.
+	SSHKeyDir     string
+	SSHKnownHosts string
+
+	// APIPicks picks through the GitHub Git Data API instead of git when no
+	// CherryRunner is set, for deployments without a git binary or disk
+	// (e.g. Lambda). Only changes to files the target has not also changed
+	// apply; hooks, verification and pre-push checks (which fail the pick
+	// here), ranges and the fallbacks need git.
+	APIPicks bool
+
+	// ConflictPRs commits conflicting picks with their markers and opens
+	// them as draft PRs to resolve, instead of asking for a manual pick.
+	ConflictPRs bool
+
+	// PickStrategies applies the repository's pick_strategy setting
+	// (--strategy / -X per target) to cherry-picks.
+	PickStrategies bool
+
+	// PickPRCommits picks a merged PR's own commits one by one, keeping its
+	// history and authorship, instead of its merge or squash commit.
+	PickPRCommits bool
+
+	// PostPickHooks allows repositories to run the post_pick commands from
+	// .github/cherry-pick.yml in the work tree before push. Off by default:
+	// it executes repository-defined commands inside this service.
+	PostPickHooks bool
+
+	// PickVerify runs the repository's verify command (e.g. `go build
+	// {packages}`) before push and reports the result in the PR body. Off by
+	// default for the same reason as PostPickHooks.
+	PickVerify bool
+
+	// PrePushChecks runs the repository's pre_push command before push and
+	// fails the pick, with the command's output, when it fails, so broken
+	// backports get no PR. Off by default for the same reason as
+	// PostPickHooks.
+	PrePushChecks bool
+
+	// Synchronous makes HandleEvent run event work inline instead of in a
+	// background goroutine after returning 202. Needed where the runtime
+	// freezes once the handler returns (AWS Lambda). HandleFromEnvelope, used
+	// for direct webhooks that GitHub times out after 10s, stays async.
+	Synchronous bool
+
+	// MergeBackDetector opens a "forward-port needed" issue when humans push
+	// commits straight to a release branch that are not on the default branch.
+	MergeBackDetector bool
+
+	// TrailerBackports picks commits pushed directly to the default branch
+	// onto the branches named by their "Cherry-pick-to:" trailers.
+	TrailerBackports bool
+
+	// AutoMergeApproved merges autocherry PRs with AutoMergeMethod (default
+	// squash) once they are approved and their checks pass.
+	AutoMergeApproved bool
+	AutoMergeMethod   string
+
+	// EnableAutoMerge turns on GitHub's own auto-merge (AutoMergeMethod) for
+	// autocherry PRs once their branch has checks, so they merge when green.
+	EnableAutoMerge bool
+
+	// BootstrapLabels are created in new repositories, and BootstrapConfig
+	// (when set) is committed to them as .github/cherry-pick.yml.
+	BootstrapLabels []string
+	BootstrapConfig []byte
+
+	// BackportLabels are added to every backport PR, and so are the source
+	// PR's labels that match a CopyLabels glob (see backportLabels).
+	BackportLabels []string
+	CopyLabels     []string
+
+	// AssignAuthor assigns backport PRs to the source's author, and
+	// ReviewAuthor requests their review; ReviewTeams (team slugs) are
+	// requested as reviewers of every backport PR.
+	AssignAuthor bool
+	ReviewAuthor bool
+	ReviewTeams  []string
+
+	// CodeOwnerReviews requests reviews from the owners the target branch's
+	// CODEOWNERS gives the backport PR's files.
+	CodeOwnerReviews bool
+
+	// CopyMilestone puts backport PRs in the source PR's milestone, or the
+	// one the repository config maps their target to.
+	CopyMilestone bool
+
+	// DraftPRs opens every backport PR as a draft, and RepoDrafts those onto
+	// the targets a repository's config lists under drafts, so release
+	// managers mark them ready instead of them being mergeable at once.
+	DraftPRs   bool
+	RepoDrafts bool
+
+	// PRTitleTemplate and PRBodyTemplate (text/template, see prText) replace
+	// the default backport PR title and body intro; RepoPRTemplates lets a
+	// repository's config override them.
+	PRTitleTemplate string
+	PRBodyTemplate  string
+	RepoPRTemplates bool
+
+	// LinkBackports keeps a list of all backport PRs of a merged PR in the
+	// description of each (see linkBackports).
+	LinkBackports bool
+
+	// MilestoneTemplate targets merged PRs at the release branch named by
+	// their milestone, e.g. "devops-release/{milestone}" ("" = labels only).
+	MilestoneTemplate string
+
+	// BodyDirective is the keyword of "<keyword>: a, b" lines in PR
+	// descriptions that add targets like labels do ("" = off).
+	BodyDirective string
+
+	// MergeTrailers adds the targets of "Cherry-pick-to:" trailers in
+	// a merged PR's merge or squash commit message.
+	MergeTrailers bool
+
+	// ReleaseTagPattern, when set, makes tags it matches cut a release
+	// branch at the tagged commit, named by ReleaseTagBranch with the
+	// pattern's groups expanded ($1, ${name}).
+	ReleaseTagPattern *regexp.Regexp
+	ReleaseTagBranch  string
+
+	// ForkToken can push to the forks repositories name as `fork` in their
+	// config; work branches go there when a repository refuses them.
+	ForkToken string
+
+	// RevertBackports picks a merged revert onto every target that got a
+	// merged backport of the PR it reverts.
+	RevertBackports bool
+
+	// CommitStatuses sets an "autocherry/<target>" status on each picked
+	// commit: pending while picking and while the backport PR is open,
+	// then success or failure.
+	CommitStatuses bool
+
+	// CheckRuns keeps a "cherry-pick" check run on each picked commit with a
+	// row per target, so backports show in the checks UI (see
+	// updateCheckRun).
+	CheckRuns bool
+
+	// StatusComment reports on a source PR in one comment, with a row per
+	// target edited in place as targets are processed, instead of a comment
+	// per update (see updateStatusComment).
+	StatusComment bool
+
+	// DeleteWorkBranches deletes a backport PR's work branch once the PR
+	// is merged or closed (see backportBranchClosed).
+	DeleteWorkBranches bool
+
+	// CloseSuperseded closes a source PR's older open backports onto a
+	// target once a newer one is opened (see closeSuperseded).
+	CloseSuperseded bool
+
+	// TrainWindow (0 = off) batches picks onto a target into release
+	// trains: within each window they share one work branch and PR (see
+	// trainFor).
+	TrainWindow time.Duration
+
+	// ProjectID ("" = off) is the node ID of a GitHub Projects (v2) board
+	// backport PRs are added to, in the ProjectStatus column ("" = the
+	// board's default) of its Status field.
+	ProjectID     string
+	ProjectStatus string
+
+	// SearchDedupe skips targets that already have a hand-made backport: an
+	// open or merged PR into the target referencing the merge commit, found
+	// with the (tightly rate-limited) Search API.
+	SearchDedupe bool
+	search       searchCache
+
+	// RecheckLabels re-reads the PR's labels before each target and skips
+	// targets whose label was removed since the event was queued.
+	// PickAddedTargets additionally picks targets labeled meanwhile.
+	RecheckLabels    bool
+	PickAddedTargets bool
+
+	// DryRun logs GitHub writes instead of sending them and skips the
+	// cherry-pick itself (which would push a branch). Reads still happen.
+	DryRun bool
+
+	// State records each backport's outcome. nil disables recording.
+	State state.Store
+
+	// Envelopes keeps verified deliveries for support bundles (optional).
+	Envelopes EnvelopeRecorder
+
+	// DegradeAfterErrors disables an optional subsystem (search dedupe, state
+	// recording, reactions, merge-back detection) for DegradeCooldown after
+	// that many errors within 5 minutes. 0 never disables.
+	DegradeAfterErrors int
+	DegradeCooldown    time.Duration
+	degrade            degrader
+
+	// inflight counts background work started by runWork, for Drain.
+	inflight inflight
+
+	// PRLockStore shares the per-PR event lock and the release train locks
+	// across replicas, as leases held by PRLockOwner (the replica ID). nil
+	// keeps the locks in-process.
+	PRLockStore shard.LeaseStore
+	PRLockOwner string
+	prLocks     keyedMutex
+	trainLocks  keyedMutex // per train branch, see trainFor
+
+	// Usage meters API calls, clones, pick time and fetched bytes per
+	// installation for chargeback reports. nil disables metering.
+	Usage *usage.Meter
+
 	// Test seams
 	NewClients   func(appID, installationID int64, pem []byte) (*githubapp.Clients, error)
 	GetToken     func(ctx context.Context, appID, installationID int64, pem []byte) (string, error)
 	CherryRunner CherryPickRunner
 }
 
+// EnvelopeRecorder remembers deliveries; implemented by *support.Recorder.
+type EnvelopeRecorder interface {
+	RecordEnvelope(delivery, event string, headers map[string]string, body []byte)
+}
+
 // sanitizeForLog removes control characters that could break log lines and
 // caps the length to prevent log flooding. Keep tabs for readability.
 func sanitizeForLog(s string) string {
@@ -98,20 +395,45 @@ func (p *Processor) HandleEvent(ctx context.Context, event, delivery string, bod
 	mac := hmac.New(sha256.New, p.WebhookSecret)
 	mac.Write(body)
 	sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
-	return p.HandleFromEnvelope(ctx, qenv.Envelope{
+	return p.handleEnvelope(ctx, qenv.Envelope{
 		Headers: map[string]string{
 			"X-GitHub-Event":      event,
 			"X-GitHub-Delivery":   delivery,
 			"X-Hub-Signature-256": sig,
 		},
 		Body: body,
-	})
+	}, p.Synchronous)
 }
 
 // HandleFromEnvelope processes one queue envelope (from internal/queue.Parser).
-//
-//nolint:gocyclo,funlen // Complex event routing with multiple event types
 func (p *Processor) HandleFromEnvelope(ctx context.Context, env qenv.Envelope) (int, error) {
+	return p.handleEnvelope(ctx, env, false)
+}
+
+// runWork runs fn after the 202 response (or inline when sync). Work must not
+// use the request context, which is canceled on client disconnect.
+func (p *Processor) runWork(sync bool, deliveryID string, fn func()) {
+	guarded := func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("webhook.panic", "delivery", sanitizeForLog(deliveryID), "panic", r)
+			}
+		}()
+		fn()
+	}
+	if sync {
+		guarded()
+		return
+	}
+	p.inflight.add()
+	go func() { // #nosec G118
+		defer p.inflight.done()
+		guarded()
+	}()
+}
+
+//nolint:gocyclo,funlen // Complex event routing with multiple event types
+func (p *Processor) handleEnvelope(ctx context.Context, env qenv.Envelope, sync bool) (int, error) {
 	deliveryID := env.Headers["X-GitHub-Delivery"]
 	event := env.Headers["X-GitHub-Event"]
 	body := []byte(env.Body)
@@ -121,6 +443,9 @@ func (p *Processor) HandleFromEnvelope(ctx context.Context, env qenv.Envelope) (
 		return http.StatusUnauthorized, fmt.Errorf("signature mismatch")
 	}
 	slog.Debug("webhook.received", "delivery", sanitizeForLog(deliveryID), "event", event)
+	if p.Envelopes != nil {
+		p.Envelopes.RecordEnvelope(deliveryID, event, env.Headers, body)
+	}
 
 	switch event {
 	case "pull_request":
@@ -129,15 +454,23 @@ func (p *Processor) HandleFromEnvelope(ctx context.Context, env qenv.Envelope) (
 			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
 			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
 		}
-		// Work runs after 202 response; must not use request context (would cancel on client disconnect).
-		go func() { // #nosec G118
-			defer func() {
-				if r := recover(); r != nil {
-					slog.Error("webhook.panic", "delivery", sanitizeForLog(deliveryID), "panic", r)
-				}
-			}()
+		p.runWork(sync, deliveryID, func() {
 			p.handlePREvent(context.Background(), deliveryID, &e)
-		}()
+		})
+		return http.StatusAccepted, nil
+
+	case "issue_comment":
+		var e github.IssueCommentEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
+		}
+		if _, ok := parseCommand(e.GetComment().GetBody()); !ok {
+			return http.StatusNoContent, nil
+		}
+		p.runWork(sync, deliveryID, func() {
+			p.handleIssueComment(context.Background(), deliveryID, &e)
+		})
 		return http.StatusAccepted, nil
 
 	case "create":
@@ -146,26 +479,160 @@ func (p *Processor) HandleFromEnvelope(ctx context.Context, env qenv.Envelope) (
 			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
 			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
 		}
-		// Work runs after 202 response; must not use request context.
-		go func() { // #nosec G118
+		p.runWork(sync, deliveryID, func() {
 			ctx2, cancel := context.WithTimeout(context.Background(), 90*time.Second)
 			defer cancel()
 			p.handleCreateEvent(ctx2, deliveryID, &e)
-		}()
+		})
 		return http.StatusAccepted, nil
 
+	case "delete":
+		var e github.DeleteEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
+		}
+		p.runWork(sync, deliveryID, func() {
+			ctx2, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+			defer cancel()
+			p.handleDeleteEvent(ctx2, deliveryID, &e)
+		})
+		return http.StatusAccepted, nil
+
+	case "push":
+		if !p.MergeBackDetector && !p.TrailerBackports {
+			return http.StatusNoContent, nil
+		}
+		var e github.PushEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
+		}
+		p.runWork(sync, deliveryID, func() {
+			ctx2, cancel := context.WithTimeout(context.Background(), max(90*time.Second, p.cherryTimeout()))
+			defer cancel()
+			p.handlePushEvent(ctx2, deliveryID, &e)
+		})
+		return http.StatusAccepted, nil
+
+	case "pull_request_review":
+		if !p.AutoMergeApproved {
+			return http.StatusNoContent, nil
+		}
+		var e github.PullRequestReviewEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
+		}
+		p.runWork(sync, deliveryID, func() {
+			ctx2, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+			defer cancel()
+			p.handlePullRequestReview(ctx2, deliveryID, &e)
+		})
+		return http.StatusAccepted, nil
+
+	case "workflow_run":
+		var e github.WorkflowRunEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
+		}
+		p.runWork(sync, deliveryID, func() {
+			ctx2, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+			defer cancel()
+			p.handleWorkflowRun(ctx2, deliveryID, &e)
+		})
+		return http.StatusAccepted, nil
+
+	case "check_suite":
+		var e github.CheckSuiteEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
+		}
+		p.runWork(sync, deliveryID, func() {
+			ctx2, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+			defer cancel()
+			p.handleCheckSuite(ctx2, deliveryID, &e)
+		})
+		return http.StatusAccepted, nil
+
+	case "installation":
+		var e github.InstallationEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
+		}
+		p.runWork(sync, deliveryID, func() {
+			ctx2, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+			p.handleInstallationEvent(ctx2, deliveryID, &e)
+		})
+		return http.StatusAccepted, nil
+
+	case "installation_repositories":
+		var e github.InstallationRepositoriesEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
+		}
+		p.runWork(sync, deliveryID, func() {
+			ctx2, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+			p.handleInstallationRepositoriesEvent(ctx2, deliveryID, &e)
+		})
+		return http.StatusAccepted, nil
+
+	case "repository":
+		var e github.RepositoryEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
+		}
+		p.runWork(sync, deliveryID, func() {
+			ctx2, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+			defer cancel()
+			p.handleRepositoryEvent(ctx2, deliveryID, &e)
+		})
+		return http.StatusAccepted, nil
+
+	case "status":
+		if !p.EnableAutoMerge {
+			return http.StatusNoContent, nil
+		}
+		var e github.StatusEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
+		}
+		p.runWork(sync, deliveryID, func() {
+			ctx2, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+			defer cancel()
+			p.handleStatus(ctx2, deliveryID, &e)
+		})
+		return http.StatusAccepted, nil
+
+	case qenv.EventScheduledTask:
+		return p.handleScheduledTask(body, deliveryID, sync)
+
 	case "label":
 		// Repo-level label delete: remove that label from open PRs
 		// and ALSO clean up autocherry artifacts for that target.
+		// Renames re-pick onto the new target, hence the pick timeout.
 		var e github.LabelEvent
 		if err := json.Unmarshal(body, &e); err != nil {
 			slog.Error("webhook.bad_payload", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
 			return http.StatusBadRequest, fmt.Errorf("bad payload: %w", err)
 		}
-		// Work runs after 202 response; must not use request context.
-		go func() { // #nosec G118
-			ctx2, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+		p.runWork(sync, deliveryID, func() {
+			ctx2, cancel := context.WithTimeout(context.Background(), max(90*time.Second, p.cherryTimeout()))
 			defer cancel()
+			if e.GetAction() == "edited" {
+				var edit labelEdit
+				_ = json.Unmarshal(body, &edit)
+				p.handleLabelRenamed(ctx2, deliveryID, &e, edit.Changes.Name.From)
+				return
+			}
 			if e.GetAction() != "deleted" || e.GetRepo() == nil || e.GetLabel() == nil {
 				return
 			}
@@ -185,7 +652,7 @@ func (p *Processor) HandleFromEnvelope(ctx context.Context, env qenv.Envelope) (
 				slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
 				return
 			}
-			gh := realGH{c: clients.REST}
+			gh := p.ghFor(clients)
 
 			// 1) Detach from OPEN PRs (in case UI still shows it lingering).
 			_ = p.removeLabelFromOpenPRs(ctx2, gh, owner, name, labelName)
@@ -200,7 +667,7 @@ func (p *Processor) HandleFromEnvelope(ctx context.Context, env qenv.Envelope) (
 			} else {
 				slog.Info("labels.cleanup_fallback_done", "delivery", sanitizeForLog(deliveryID), "label", labelName)
 			}
-		}()
+		})
 		return http.StatusAccepted, nil
 
 	default:
@@ -232,23 +699,62 @@ func (p *Processor) handlePREvent(ctx context.Context, deliveryID string, e *git
 		"inst", instID,
 	)
 
+	// A backport PR closing settles its commit status.
+	if action == "closed" && (p.CommitStatuses || p.CheckRuns) && reWorkBranch.MatchString(e.GetPullRequest().GetHead().GetRef()) {
+		p.backportClosed(ctx, deliveryID, instID, owner, name, e.GetPullRequest())
+	}
+	if action == "closed" && p.DeleteWorkBranches && reWorkBranch.MatchString(e.GetPullRequest().GetHead().GetRef()) {
+		p.backportBranchClosed(ctx, deliveryID, instID, e)
+	}
+
+	// Pushes to an open backport (e.g. a hand-resolved conflict) are reported on its source PR.
+	if action == "synchronize" && reWorkBranch.MatchString(e.GetPullRequest().GetHead().GetRef()) {
+		p.handleBackportSync(ctx, deliveryID, instID, owner, name, e)
+		return
+	}
+
 	// If labeled after merge, process only that label.
 	var targetsOverride []string
 	if action == "labeled" && merged && e.Label != nil {
 		targetsOverride = cherry.ParseTargetBranches([]*github.Label{e.Label})
+		if strings.HasPrefix(e.Label.GetName(), aliasLabelPrefix) {
+			targetsOverride = []string{e.Label.GetName()}
+		}
+	}
+	// Likewise for a milestone set after merge (with MilestoneTemplate).
+	milestoned := action == "milestoned" && merged && p.milestoneTarget(e.GetPullRequest()) != ""
+	if milestoned {
+		targetsOverride = []string{p.milestoneTarget(e.GetPullRequest())}
 	}
 
-	switch {
-	case (action == "closed" && merged) || (action == "labeled" && merged):
-		// Use configurable timeout; default to 2m if not set.
-		to := p.CherryTimeout
-		if to <= 0 {
-			to = 2 * time.Minute
+	pick := merged && (action == "closed" || action == "labeled" || milestoned)
+	closedUnmerged := action == "closed" && !merged && len(p.prTargets(e.GetPullRequest())) > 0
+	if pick || closedUnmerged || (merged && action == "unlabeled" && e.Label != nil) {
+		// One event per PR at a time; wait at most one pick's duration.
+		lctx, cancel := context.WithTimeout(ctx, p.cherryTimeout())
+		unlock, ok := p.lockPR(lctx, deliveryID, owner, name, prNum)
+		cancel()
+		if !ok {
+			slog.Warn("pr.lock_timeout", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name, "pr", prNum)
+			return
 		}
-		cctx, cancel := context.WithTimeout(ctx, to)
+		defer unlock()
+	}
+
+	switch {
+	case pick:
+		cctx, cancel := context.WithTimeout(ctx, p.cherryTimeout())
 		defer cancel()
 		p.processMergedPR(cctx, deliveryID, instID, owner, name, prNum, targetsOverride)
 
+	case closedUnmerged:
+		clients, err := p.buildClients(instID)
+		if err != nil {
+			slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+			return
+		}
+		p.processClosedUnmerged(ctx, deliveryID, p.ghFor(clients), owner, name, e.GetPullRequest())
+
 	case action == "unlabeled" && merged && e.Label != nil:
 		targets := cherry.ParseTargetBranches([]*github.Label{e.Label})
 		if len(targets) == 0 {
@@ -259,7 +765,7 @@ func (p *Processor) handlePREvent(ctx context.Context, deliveryID string, e *git
 			slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
 			return
 		}
-		gh := realGH{c: clients.REST}
+		gh := p.ghFor(clients)
 
 		// Determine merge SHA (fallback to last commit).
 		pr, _, err := gh.PR().Get(ctx, owner, name, prNum)
@@ -287,9 +793,8 @@ func (p *Processor) handlePREvent(ctx context.Context, deliveryID string, e *git
 			if err := p.processUnlabeled(ctx, gh, owner, name, prNum, target, workBranch); err != nil {
 				slog.Error("unlabeled.cleanup_error", "delivery", sanitizeForLog(deliveryID), "target", target, "err", safeErr(err))
 			} else {
-				_, _, _ = gh.Issues().CreateComment(ctx, owner, name, prNum, &github.IssueComment{
-					Body: github.Ptr(fmt.Sprintf("ℹ️ Removed label for `%s`: closed any open auto-cherry-pick PR and deleted work branch `%s`.", target, workBranch)),
-				})
+				p.notify(ctx, gh, owner, name, pickSource{issue: prNum}, target,
+					fmt.Sprintf("ℹ️ Removed label for `%s`: closed any open auto-cherry-pick PR and deleted work branch `%s`.", target, workBranch))
 			}
 		}
 	default:
@@ -297,14 +802,65 @@ func (p *Processor) handlePREvent(ctx context.Context, deliveryID string, e *git
 	}
 }
 
-func (p *Processor) cherryRunner() CherryPickRunner {
+// cherryTimeout bounds one event's pick work; CherryTimeout, default 2m.
+func (p *Processor) cherryTimeout() time.Duration {
+	if p.CherryTimeout <= 0 {
+		return 2 * time.Minute
+	}
+	return p.CherryTimeout
+}
+
+func (p *Processor) cherryRunner(gh GH) CherryPickRunner {
 	if p.CherryRunner != nil {
 		return p.CherryRunner
 	}
-	return realCherryRunner{actor: cherry.GitActor{
+	actor := cherry.GitActor{
 		Name:  p.GitUserName,
 		Email: p.GitUserEmail,
-	}}
+	}
+	if p.APIPicks {
+		return apiCherryRunner{gh: gh, actor: actor}
+	}
+	return realCherryRunner{actor: actor}
+}
+
+// pickOptions builds the per-pick options; the patch fallback fetches the
+// commit diff lazily, only when the cherry-pick actually conflicts.
+func (p *Processor) pickOptions(gh GH, owner, repo, sha, target string, rc *repocfg.Config) cherry.Options {
+	var opts cherry.Options
+	if p.PostPickHooks {
+		opts.Hooks = hooksFor(rc)
+	}
+	if p.PickVerify {
+		opts.Verify = verifyFor(rc.Verify)
+	}
+	if p.PrePushChecks {
+		opts.PrePush = verifyFor(rc.PrePush)
+	}
+	opts.Fork = p.forkFor(rc)
+	opts.CommitConflicts = p.ConflictPRs
+	opts.Signoff = p.Signoff
+	opts.PushRetry = p.PushRetry
+	opts.AllowEmpty = p.PickEmpty
+	opts.KeepRedundant = p.PickRedundant
+	opts.FullCloneRetry = p.FullCloneFallback
+	opts.Mirror = p.Mirrors
+	opts.SharedClones = p.SharedClones
+	opts.LFS = p.LFS
+	opts.Workspace = p.Workspace
+	if p.PickStrategies {
+		opts.Strategy = strategyFor(rc, target)
+	}
+	if p.PatchFallback {
+		opts.PatchFallback = func(ctx context.Context) ([]byte, error) {
+			diff, _, err := gh.Repos().GetCommitRaw(ctx, owner, repo, sha, github.RawOptions{Type: github.Diff})
+			if err != nil {
+				return nil, fmt.Errorf("get commit diff: %w", err)
+			}
+			return []byte(diff), nil
+		}
+	}
+	return opts
 }
 
 func (p *Processor) processMergedPR(ctx context.Context, deliveryID string, installationID int64, owner, repo string, prNum int, targetsOverride []string) {
@@ -314,34 +870,73 @@ func (p *Processor) processMergedPR(ctx context.Context, deliveryID string, inst
 		return
 	}
 
-	// Installation token for git push.
+	token, ok := p.installationToken(ctx, deliveryID, installationID)
+	if !ok {
+		return
+	}
+
+	gh := p.ghFor(clients)
+	p.processMergedPRWith(withPRAck(usage.WithInstallation(ctx, installationID)), deliveryID, gh, owner, repo, prNum, targetsOverride, token)
+}
+
+// installationToken returns an installation token for git push, logging
+// failures.
+func (p *Processor) installationToken(ctx context.Context, deliveryID string, installationID int64) (string, bool) {
 	var token string
+	var err error
 	if p.GetToken != nil {
 		token, err = p.GetToken(ctx, p.AppID, installationID, p.PrivateKeyPEM)
 	} else {
 		itr, ierr := ghinstallation.New(http.DefaultTransport, p.AppID, installationID, p.PrivateKeyPEM)
 		if ierr != nil {
 			slog.Error("gh.installation_transport_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(ierr))
-			return
+			return "", false
 		}
 		token, err = itr.Token(ctx)
 	}
 	if err != nil || token == "" {
 		slog.Error("gh.installation_token_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
-		return
+		return "", false
 	}
+	return token, true
+}
 
-	gh := realGH{c: clients.REST}
-	p.processMergedPRWith(ctx, deliveryID, gh, owner, repo, prNum, targetsOverride, token)
+// currentTargets returns the targets from the PR's labels (and milestone) as
+// they are now. Alias labels still present map through aliases, as resolved
+// when processing started.
+func (p *Processor) currentTargets(ctx context.Context, gh GH, owner, repo string, prNum int, aliases map[string][]string) ([]string, bool) {
+	pr, _, err := gh.PR().Get(ctx, owner, repo, prNum)
+	if err != nil || pr == nil {
+		slog.Warn("pr.recheck_labels_error", "repo", owner+"/"+repo, "pr", prNum, "err", safeErr(err))
+		return nil, false
+	}
+	targets := p.prTargets(pr)
+	for _, l := range pr.Labels {
+		for _, t := range aliases[l.GetName()] {
+			if !slices.Contains(targets, t) {
+				targets = append(targets, t)
+			}
+		}
+	}
+	return targets, true
 }
 
 func (p *Processor) buildClients(installationID int64) (*githubapp.Clients, error) {
+	newClients := githubapp.NewClients
 	if p.NewClients != nil {
-		return p.NewClients(p.AppID, installationID, p.PrivateKeyPEM)
+		newClients = p.NewClients
 	}
-	return githubapp.NewClients(p.AppID, installationID, p.PrivateKeyPEM)
+	c, err := newClients(p.AppID, installationID, p.PrivateKeyPEM)
+	if err == nil && p.Usage != nil && c.HTTP != nil {
+		// REST shares this *http.Client, so its calls are counted too.
+		c.HTTP.Transport = p.Usage.Transport(installationID, c.HTTP.Transport)
+	}
+	return c, err
 }
 
+// processMergedPRWith picks merged PR prNum onto its targets (or
+// targetsOverride), reporting whether every target was picked or is open.
+//
 //nolint:gocyclo,funlen // Complex cherry-pick processing with multiple branches and error handling
 func (p *Processor) processMergedPRWith(
 	ctx context.Context,
@@ -351,12 +946,12 @@ func (p *Processor) processMergedPRWith(
 	prNum int,
 	targetsOverride []string,
 	token string,
-) {
+) bool {
 	// Load PR
 	pr, _, err := gh.PR().Get(ctx, owner, repo, prNum)
 	if err != nil {
 		slog.Error("gh.get_pr_error", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+repo, "pr", prNum, "err", safeErr(err))
-		return
+		return false
 	}
 
 	// Resolve original author login (best-effort).
@@ -365,24 +960,43 @@ func (p *Processor) processMergedPRWith(
 		origAuthor = pr.User.GetLogin()
 	}
 
-	// Targets: override or parse labels.
-	var targets []string
+	// Targets: override or parse labels (and the milestone, description,
+	// merge commit trailers and, for reverts, the reverted PR's backports).
+	// Alias labels, in either, are resolved to their branches now.
+	var targets, aliased []string
+	fromCommit := map[string]bool{} // merge commit trailers and reverts, not subject to label rechecks
 	if len(targetsOverride) > 0 {
-		targets = targetsOverride
-	} else {
-		lbls := []string{}
-		for _, l := range pr.Labels {
-			if l != nil && l.Name != nil {
-				lbls = append(lbls, l.GetName())
+		aliased = aliasLabels(targetsOverride)
+		for _, t := range targetsOverride {
+			if !slices.Contains(aliased, t) {
+				targets = append(targets, t)
 			}
 		}
+	} else {
+		lbls := labelNames(pr)
 		slog.Debug("pr.labels", "delivery", sanitizeForLog(deliveryID), "pr", prNum, "labels", lbls)
-		targets = cherry.ParseTargetBranches(pr.Labels)
+		aliased = aliasLabels(lbls)
+		targets = p.prTargets(pr)
+		for _, t := range append(p.mergeCommitTargets(ctx, gh, owner, repo, pr), p.revertTargets(ctx, gh, owner, repo, pr)...) {
+			if !slices.Contains(targets, t) {
+				fromCommit[t] = true
+				targets = append(targets, t)
+			}
+		}
+	}
+	aliases := p.aliasTargets(ctx, gh, owner, repo, prNum, aliased)
+	for _, label := range aliased {
+		for _, t := range aliases[label] {
+			if !slices.Contains(targets, t) {
+				targets = append(targets, t)
+			}
+		}
 	}
 	slog.Info("pr.targets", "delivery", sanitizeForLog(deliveryID), "pr", prNum, "targets", targets)
 	if len(targets) == 0 {
-		return
+		return true
 	}
+	ack := p.ackPR(ctx, gh, owner, repo, prNum)
 
 	// Determine merged commit SHA.
 	mergeSHA := pr.GetMergeCommitSHA()
@@ -392,7 +1006,8 @@ func (p *Processor) processMergedPRWith(
 			_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, prNum, &github.IssueComment{
 				Body: github.Ptr(fmt.Sprintf("⚠️ Could not determine merged commit SHA for PR #%d: %v", prNum, listErr)),
 			})
-			return
+			ack.done(ctx, false)
+			return false
 		}
 		mergeSHA = commits[len(commits)-1].GetSHA()
 	}
@@ -405,105 +1020,408 @@ func (p *Processor) processMergedPRWith(
 		slog.Info("pr.merge_sha_is_merge_commit", "delivery", sanitizeForLog(deliveryID), "sha", mergeSHA, "parents", len(rc.Parents))
 	}
 
+	repoCfg := &repocfg.Config{}
+	// Merge commits heed the repository's mainline setting.
+	if p.needsRepoConfig() || isMerge {
+		repoCfg = p.loadRepoConfig(ctx, gh, owner, repo, prNum)
+	}
+	mainline := 0
+	if isMerge {
+		mainline = mainlineFor(ctx, gh, owner, repo, pr, rc, repoCfg)
+		slog.Info("pr.mainline", "delivery", sanitizeForLog(deliveryID), "sha", mergeSHA, "mainline", mainline)
+	}
+
+	manual := p.findManualBackports(ctx, gh, owner, repo, mergeSHA, prNum)
+
+	var commits []string
+	var gitAuthor string
+	var coAuthors []string
+	if p.PickPRCommits || p.CreditAuthors {
+		prc, err := listPRCommits(ctx, gh, owner, repo, prNum)
+		if err != nil {
+			slog.Warn("pr.commits_error", "delivery", sanitizeForLog(deliveryID), "pr", prNum, "err", safeErr(err))
+		}
+		if err == nil && p.PickPRCommits {
+			commits = prCommits(ctx, prc, owner, repo, prNum)
+		}
+		if err == nil && p.CreditAuthors {
+			gitAuthor, coAuthors = prAuthors(prc)
+			if !isMerge {
+				// Squash and rebase commits are already authored by the PR author.
+				gitAuthor = ""
+			}
+		}
+	}
+
 	// Short SHA for branch name suffix.
 	short := mergeSHA
 	if len(short) > 7 {
 		short = mergeSHA[:7]
 	}
 
-	for _, target := range targets {
+	// Picks overlap up to ParallelTargets; the checks before each stay in order.
+	var picks sync.WaitGroup
+	var failed atomic.Bool
+	slots := make(chan struct{}, max(p.ParallelTargets, 1))
+
+	// targets may grow while we go (RecheckLabels + PickAddedTargets).
+	queued := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		queued[t] = true
+	}
+	for i := 0; i < len(targets); i++ {
+		target := targets[i]
+		if p.RecheckLabels && !fromCommit[target] {
+			current, ok := p.currentTargets(ctx, gh, owner, repo, prNum, aliases)
+			if ok && !slices.Contains(current, target) {
+				slog.Info("cherry.label_removed_skip", "delivery", sanitizeForLog(deliveryID), "pr", prNum, "target", target)
+				continue
+			}
+			if ok && p.PickAddedTargets {
+				for _, t := range current {
+					if !queued[t] {
+						queued[t] = true
+						targets = append(targets, t)
+						slog.Info("cherry.label_added_pick", "delivery", sanitizeForLog(deliveryID), "pr", prNum, "target", t)
+					}
+				}
+			}
+		}
+
 		// Ensure target branch exists.
 		if _, _, err := gh.Git().GetRef(ctx, owner, repo, "refs/heads/"+target); err != nil {
-			_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, prNum, &github.IssueComment{
-				Body: github.Ptr(fmt.Sprintf("⚠️ Target branch `%s` not found; skipping auto cherry-pick.", target)),
-			})
+			p.notify(ctx, gh, owner, repo, pickSource{issue: prNum}, target, fmt.Sprintf("⚠️ Target branch `%s` not found; skipping auto cherry-pick.", target))
+			failed.Store(true)
 			continue
 		}
 
-		safeTarget := strings.ReplaceAll(target, "/", "-")
-		workBranch := fmt.Sprintf("autocherry/%s/%s", safeTarget, short)
-
-		// Idempotency: work branch already exists?
-		if _, _, err := gh.Git().GetRef(ctx, owner, repo, "refs/heads/"+workBranch); err == nil {
-			prs, _, _ := gh.PR().List(ctx, owner, repo, &github.PullRequestListOptions{
-				State:       pullRequestStateOpen,
-				Head:        fmt.Sprintf("%s:%s", owner, workBranch),
-				Base:        target,
-				ListOptions: github.ListOptions{PerPage: 1},
-			})
-			if len(prs) > 0 {
-				_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, prNum, &github.IssueComment{
-					Body: github.Ptr(fmt.Sprintf("ℹ️ Auto cherry-pick to `%s` is already open: %s", target, prs[0].GetHTMLURL())),
-				})
-				continue
-			}
-			_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, prNum, &github.IssueComment{
-				Body: github.Ptr(fmt.Sprintf("ℹ️ Work branch `%s` already exists for `%s`; skipping duplicate cherry-pick.", workBranch, target)),
-			})
+		if mp := manual[target]; mp != nil {
+			p.notify(ctx, gh, owner, repo, pickSource{issue: prNum}, target,
+				fmt.Sprintf("ℹ️ `%s` already has a backport of `%s`: %s; skipping auto cherry-pick.", target, mergeSHA, mp.GetHTMLURL()))
+			slog.Info("cherry.manual_backport_exists", "delivery", sanitizeForLog(deliveryID), "target", target, "pr", mp.GetNumber())
 			continue
 		}
 
-		slog.Info("cherry.start", "delivery", sanitizeForLog(deliveryID), "target", target, "sha", mergeSHA, "isMerge", isMerge)
-
-		// Run cherry-pick via injected runner.
-		workBranchOut, cpErr := p.cherryRunner().Pick(ctx, owner, repo, token, target, mergeSHA, isMerge)
-		if cpErr != nil {
-			if errors.Is(cpErr, cherry.ErrNoopCherryPick) {
-				_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, prNum, &github.IssueComment{
-					Body: github.Ptr(fmt.Sprintf("ℹ️ Auto cherry-pick to `%s`: no changes needed on target (commit already present or empty diff). Skipping PR.", target)),
-				})
-				slog.Info("cherry.noop", "delivery", sanitizeForLog(deliveryID), "target", target, "sha", mergeSHA)
-				continue
+		src := pickSource{
+			issue: prNum, sha: mergeSHA, isMerge: isMerge, mainline: mainline, author: origAuthor,
+			what:  fmt.Sprintf("PR #%d", pr.GetNumber()),
+			title: fmt.Sprintf("Auto cherry-pick: PR #%d — %s", pr.GetNumber(), pr.GetTitle()),
+			retry: true, commits: commits, patchPR: prNum, subject: pr.GetTitle(),
+			gitAuthor: gitAuthor, coAuthors: coAuthors, labels: labelNames(pr),
+			milestone: pr.GetMilestone().GetNumber(),
+		}
+		if origAuthor != "" {
+			src.footer = fmt.Sprintf("\n\n---\n_origin: PR #%d by @%s (commit %s)_", pr.GetNumber(), origAuthor, short)
+		}
+		if p.ParallelTargets <= 1 {
+			if !p.pickTarget(ctx, deliveryID, gh, owner, repo, src, target, token, repoCfg) {
+				failed.Store(true)
 			}
-			slog.Warn("cherry.conflict", "delivery", sanitizeForLog(deliveryID), "target", target, "err", safeErr(cpErr))
-			_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, prNum, &github.IssueComment{
-				Body: github.Ptr(fmt.Sprintf(
-					"⚠️ Auto cherry-pick to `%s` failed. Please create a patch branch from `%s` and cherry-pick `%s` manually.\n\nDetails: `%v`",
-					target, target, mergeSHA, cpErr)),
-			})
 			continue
 		}
+		slots <- struct{}{}
+		picks.Add(1)
+		go func() {
+			defer func() { <-slots; picks.Done() }()
+			if !p.pickTarget(ctx, deliveryID, gh, owner, repo, src, target, token, repoCfg) {
+				failed.Store(true)
+			}
+		}()
+	}
+	picks.Wait()
+	if p.LinkBackports {
+		p.linkBackports(ctx, gh, owner, repo, prNum, short, targets)
+	}
+	ack.done(ctx, !failed.Load())
+	return !failed.Load()
+}
 
-		slog.Info("cherry.pushed", "delivery", sanitizeForLog(deliveryID), "work_branch", workBranchOut, "target", target)
+// pickSource is what a backport is made from: a merged PR's merge commit, or
+// a commit named by a slash command.
+type pickSource struct {
+	issue   int // PR (or issue) that gets progress comments, 0 = the commit; recorded as SourcePR
+	sha     string
+	isMerge bool
+	what    string // "PR #12" / "commit `abc1234`", for the backport PR body
+	title   string // backport PR title
+	subject string // source PR title or commit subject, for PR templates
+	footer  string // provenance appended to the backport PR body
+	author  string // original author login for the orig-author label ("" = none)
+	retry   bool   // conflicts can be retried with /retry-cherry-pick on src.issue
+
+	// mainline is the parent a merge commit is picked against (0 = 1).
+	mainline int
+	// commits are the PR's own commits, picked one by one instead of sha
+	// (nil = sha).
+	commits []string
+	// rangeFrom, when set, makes this the series rangeFrom..sha.
+	rangeFrom string
+	// patchPR is the PR whose .patch is this change, for the git am
+	// fallback (0 = none).
+	patchPR int
+	// gitAuthor ("Name <email>") replaces the picked commit's author, and
+	// coAuthors get Co-authored-by trailers (CreditAuthors).
+	gitAuthor string
+	coAuthors []string
+	// labels are the source PR's, some of which CopyLabels copies.
+	labels []string
+	// milestone is the source PR's milestone number, for CopyMilestone.
+	milestone int
+}
 
-		// Open PR into target — include a footer with the original author (if available).
-		title := fmt.Sprintf("Auto cherry-pick: PR #%d — %s", pr.GetNumber(), pr.GetTitle())
-		body := fmt.Sprintf("Automated cherry-pick of PR #%d into `%s`.\n\nCommit: `%s`", pr.GetNumber(), target, mergeSHA)
-		if origAuthor != "" {
-			body += fmt.Sprintf("\n\n---\n_origin: PR #%d by @%s (commit %s)_", pr.GetNumber(), origAuthor, short)
+// pickTarget runs the pick of src onto target (which must exist) and opens
+// the backport PR, reporting on src.issue. It reports whether target is now
+// covered: PR opened or already open, or nothing to pick.
+//
+//nolint:gocyclo,funlen // Sequential pipeline with an early exit per outcome
+func (p *Processor) pickTarget(
+	ctx context.Context,
+	deliveryID string,
+	gh GH,
+	owner, repo string,
+	src pickSource,
+	target, token string,
+	repoCfg *repocfg.Config,
+) bool {
+	short := src.sha
+	if len(short) > 7 {
+		short = src.sha[:7]
+	}
+	safeTarget := strings.ReplaceAll(target, "/", "-")
+	workBranch := fmt.Sprintf("autocherry/%s/%s", safeTarget, short)
+
+	// A release train (TrainWindow) collects the window's picks onto target.
+	tr, unlockTrain := p.trainFor(ctx, deliveryID, gh, owner, repo, target)
+	defer unlockTrain()
+	if tr != nil {
+		workBranch = tr.branch
+		// Idempotency: a redelivery of a pick already on the train.
+		if tr.pr != nil && tr.has(src.sha) {
+			p.notify(ctx, gh, owner, repo, src, target, fmt.Sprintf("ℹ️ Auto cherry-pick to `%s` is already in release train %s", target, tr.pr.GetHTMLURL()))
+			return true
 		}
-
-		newPR, _, err := gh.PR().Create(ctx, owner, repo, &github.NewPullRequest{
-			Title: github.Ptr(title),
-			Head:  github.Ptr(workBranchOut),
-			Base:  github.Ptr(target),
-			Body:  github.Ptr(body),
+	} else if _, _, err := gh.Git().GetRef(ctx, owner, repo, "refs/heads/"+workBranch); err == nil {
+		// Idempotency: work branch already exists?
+		prs, _, _ := gh.PR().List(ctx, owner, repo, &github.PullRequestListOptions{
+			State:       pullRequestStateOpen,
+			Head:        fmt.Sprintf("%s:%s", owner, workBranch),
+			Base:        target,
+			ListOptions: github.ListOptions{PerPage: 1},
 		})
-		if err != nil {
-			slog.Error("gh.create_pr_error", "delivery", sanitizeForLog(deliveryID), "target", target, "err", safeErr(err))
-			_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, prNum, &github.IssueComment{
-				Body: github.Ptr(fmt.Sprintf("⚠️ Auto cherry-pick to `%s`: failed to open PR: %v", target, err)),
-			})
-			continue
+		if len(prs) > 0 {
+			p.notify(ctx, gh, owner, repo, src, target, fmt.Sprintf("ℹ️ Auto cherry-pick to `%s` is already open: %s", target, prs[0].GetHTMLURL()))
+			return true
+		}
+		// A backport closed unmerged leaves its branch behind; re-adding the
+		// label asks for a fresh pick, so the stale branch is replaced.
+		closed := closedBackport(ctx, gh, owner, repo, workBranch, target)
+		if closed == nil {
+			p.notify(ctx, gh, owner, repo, src, target,
+				fmt.Sprintf("ℹ️ Work branch `%s` already exists for `%s`; skipping duplicate cherry-pick.", workBranch, target))
+			return false
 		}
-		slog.Info("gh.pr_opened", "delivery", sanitizeForLog(deliveryID), "url", newPR.GetHTMLURL(), "target", target)
+		if err := p.deleteWorkBranch(ctx, gh, owner, repo, workBranch); err != nil {
+			slog.Warn("cherry.repick_cleanup_error", "delivery", sanitizeForLog(deliveryID), "work_branch", workBranch, "err", safeErr(err))
+			p.notify(ctx, gh, owner, repo, src, target,
+				fmt.Sprintf("ℹ️ Work branch `%s` already exists for `%s`; skipping duplicate cherry-pick.", workBranch, target))
+			return false
+		}
+		slog.Info("cherry.repick", "delivery", sanitizeForLog(deliveryID), "target", target, "work_branch", workBranch, "closed_pr", closed.GetNumber())
+	}
 
-		// Add a machine-readable label for automation: "orig-author:<login>"
-		if origAuthor != "" && newPR.Number != nil {
-			label := "orig-author:" + origAuthor
-			if _, _, lerr := gh.Issues().AddLabelsToIssue(ctx, owner, repo, newPR.GetNumber(), []string{label}); lerr != nil {
-				slog.Warn("gh.add_label_error", "delivery", sanitizeForLog(deliveryID), "pr", newPR.GetNumber(), "label", label, "err", safeErr(lerr))
+	if p.DryRun {
+		slog.Info("cherry.dry_run", "delivery", sanitizeForLog(deliveryID), "target", target, "sha", src.sha, "work_branch", workBranch)
+		return true
+	}
+
+	slog.Info("cherry.start", "delivery", sanitizeForLog(deliveryID), "target", target, "sha", src.sha, "isMerge", src.isMerge)
+	p.setPickStatus(ctx, gh, owner, repo, src.sha, target, "pending", "Cherry-picking onto "+target, "")
+
+	// Run cherry-pick via injected runner.
+	opts := p.pickOptions(gh, owner, repo, src.sha, target, repoCfg)
+	if len(src.commits) > 0 {
+		opts.Commits = src.commits
+		opts.CommitsRef = fmt.Sprintf("refs/pull/%d/head", src.issue)
+	}
+	opts.RangeFrom = src.rangeFrom
+	opts.Author, opts.CoAuthors = src.gitAuthor, src.coAuthors
+	opts.Mainline = src.mainline
+	opts.Footer = p.footerFor(src, target)
+	if tr != nil {
+		tr.options(&opts)
+	}
+	if p.AmFallback && src.patchPR != 0 {
+		opts.MailboxFallback = func(ctx context.Context) ([]byte, error) {
+			mbox, _, err := gh.PR().GetRaw(ctx, owner, repo, src.patchPR, github.RawOptions{Type: github.Patch})
+			if err != nil {
+				return nil, fmt.Errorf("get PR patch: %w", err)
 			}
+			return []byte(mbox), nil
+		}
+	}
+	inst := usage.Installation(ctx)
+	opts.SSH = p.sshFor(inst)
+	if p.Usage != nil {
+		opts.Fetched = func(n int64) { p.Usage.Fetched(inst, n) }
+	}
+	started := time.Now()
+	res, cpErr := p.cherryRunner(gh).Pick(ctx, owner, repo, token, target, src.sha, src.isMerge, opts)
+	p.Usage.Pick(inst, time.Since(started))
+	workBranchOut := res.WorkBranch
+	if cpErr != nil {
+		if errors.Is(cpErr, cherry.ErrNoopCherryPick) {
+			p.notify(ctx, gh, owner, repo, src, target, fmt.Sprintf(
+				"ℹ️ Auto cherry-pick to `%s`: no changes needed on target (commit already present or empty diff). Skipping PR.", target))
+			slog.Info("cherry.noop", "delivery", sanitizeForLog(deliveryID), "target", target, "sha", src.sha)
+			p.record(ctx, state.Record{Repo: owner + "/" + repo, WorkBranch: workBranch, Target: target, SHA: src.sha, SourcePR: src.issue, Status: state.StatusNoop})
+			p.setPickStatus(ctx, gh, owner, repo, src.sha, target, "success", "Already on "+target, "")
+			return true
+		}
+		slog.Warn("cherry.conflict", "delivery", sanitizeForLog(deliveryID), "target", target, "err", safeErr(cpErr))
+		what := src.sha
+		if src.rangeFrom != "" {
+			what = src.rangeFrom + ".." + src.sha
+		}
+		msg := fmt.Sprintf(
+			"⚠️ Auto cherry-pick to `%s` failed. Please create a patch branch from `%s` and cherry-pick `%s` manually.\n\nDetails: `%v`",
+			target, target, what, cpErr)
+		msg += conflictDetails(cpErr)
+		if errors.Is(cpErr, cherry.ErrPrePushFailed) {
+			msg += prePushReport(res.PrePush)
 		}
+		if src.retry {
+			msg += fmt.Sprintf("\n\nOnce `%s` is fixed, comment `/retry-cherry-pick %s` to try again.", target, target)
+		}
+		p.notify(ctx, gh, owner, repo, src, target, msg)
+		p.record(ctx, state.Record{Repo: owner + "/" + repo, WorkBranch: workBranch, Target: target, SHA: src.sha, SourcePR: src.issue, Status: state.StatusConflict})
+		p.setPickStatus(ctx, gh, owner, repo, src.sha, target, "failure", "Cherry-pick failed; pick manually", "")
+		return false
+	}
 
-		_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, prNum, &github.IssueComment{
-			Body: github.Ptr(fmt.Sprintf("✅ Auto cherry-pick to `%s` opened: %s", target, newPR.GetHTMLURL())),
-		})
+	slog.Info("cherry.pushed", "delivery", sanitizeForLog(deliveryID), "work_branch", workBranchOut, "target", target)
+	if tr != nil && tr.pr != nil {
+		return p.joinTrain(ctx, deliveryID, gh, owner, repo, src, target, tr, res)
+	}
+
+	// Open PR into target — include a footer with the provenance (if available).
+	title := src.title
+	body := fmt.Sprintf("Automated cherry-pick of %s into `%s`.\n\nCommit: `%s`", src.what, target, src.sha)
+	if len(src.commits) > 0 {
+		body += fmt.Sprintf(" (its %d commits picked one by one)", len(src.commits))
+	}
+	title, body = p.prText(src, target, repoCfg, title, body)
+	if tr != nil {
+		title = tr.title(target)
+		body += tr.intro(target, src.sha, p.TrainWindow)
+	}
+	if src.patchPR != 0 {
+		body += fmt.Sprintf("\n\nBackport of #%d", src.patchPR)
+	}
+	if res.AppliedViaAm {
+		body += fmt.Sprintf("\n\n> [!NOTE]\n> `git cherry-pick` could not apply this commit, so the %s patch was applied with `git am -3`, "+
+			"keeping its commits. Please review carefully.", src.what)
+	}
+	if res.AppliedViaPatch {
+		body += "\n\n> [!NOTE]\n> `git cherry-pick` conflicted, so this commit was applied from its diff with `git apply --3way`. Please review carefully."
+	}
+	if len(res.Conflicts) > 0 {
+		body += fmt.Sprintf("\n\n> [!WARNING]\n> The cherry-pick conflicted and was committed with conflict markers in %s. "+
+			"Resolve them on this branch (e.g. with the web editor), then mark the PR ready for review.", codeList(res.Conflicts))
+	}
+	body += hookReport(res.HookRuns)
+	body += verifyReport(res.Verify)
+	body += src.footer
+
+	head := workBranchOut
+	if res.HeadOwner != "" {
+		// Pushed to the fork: a cross-fork PR, which maintainers may update.
+		head = res.HeadOwner + ":" + workBranchOut
+		body += fmt.Sprintf("\n\n> [!NOTE]\n> `%s` refused the work branch, so it was pushed to the fork `%s`.", owner+"/"+repo, res.HeadOwner)
+	}
+	draft := p.DraftPRs || (p.RepoDrafts && repoCfg.Draft(target))
+	newPR, _, err := gh.PR().Create(ctx, owner, repo, &github.NewPullRequest{
+		Title:               github.Ptr(title),
+		Head:                github.Ptr(head),
+		Base:                github.Ptr(target),
+		Body:                github.Ptr(body),
+		MaintainerCanModify: github.Ptr(res.HeadOwner != ""),
+		Draft:               github.Ptr(len(res.Conflicts) > 0 || draft),
+	})
+	if err != nil {
+		slog.Error("gh.create_pr_error", "delivery", sanitizeForLog(deliveryID), "target", target, "err", safeErr(err))
+		p.notify(ctx, gh, owner, repo, src, target, fmt.Sprintf("⚠️ Auto cherry-pick to `%s`: failed to open PR: %v", target, err))
+		p.setPickStatus(ctx, gh, owner, repo, src.sha, target, "error", "Could not open the backport PR", "")
+		return false
+	}
+	slog.Info("gh.pr_opened", "delivery", sanitizeForLog(deliveryID), "url", newPR.GetHTMLURL(), "target", target)
+	p.record(ctx, state.Record{
+		Repo: owner + "/" + repo, WorkBranch: workBranchOut, Target: target, SHA: src.sha, SourcePR: src.issue,
+		PRNumber: newPR.GetNumber(), PRURL: newPR.GetHTMLURL(), Status: state.StatusOpen,
+	})
+
+	// Machine-readable labels for automation, e.g. "orig-author:<login>".
+	if labels := p.backportLabels(src, res); len(labels) > 0 && newPR.Number != nil {
+		if _, _, lerr := gh.Issues().AddLabelsToIssue(ctx, owner, repo, newPR.GetNumber(), labels); lerr != nil {
+			slog.Warn("gh.add_label_error", "delivery", sanitizeForLog(deliveryID), "pr", newPR.GetNumber(), "labels", labels, "err", safeErr(lerr))
+		}
+	}
+
+	if newPR.Number != nil {
+		p.assignBackport(ctx, gh, owner, repo, newPR.GetNumber(), src)
+		if p.CodeOwnerReviews {
+			p.requestCodeOwners(ctx, gh, owner, repo, target, newPR.GetNumber())
+		}
+		if p.CopyMilestone {
+			p.setBackportMilestone(ctx, gh, owner, repo, newPR.GetNumber(), src, target, repoCfg)
+		}
+		if p.CloseSuperseded {
+			p.closeSuperseded(ctx, deliveryID, gh, owner, repo, src, target, newPR)
+		}
+		if p.ProjectID != "" {
+			p.addToProject(ctx, gh, owner, repo, newPR)
+		}
+	}
+
+	if len(res.Conflicts) > 0 {
+		p.notify(ctx, gh, owner, repo, src, target, fmt.Sprintf(
+			"⚠️ Auto cherry-pick to `%s` conflicted in %s. Opened draft PR %s with the conflict markers committed; resolve them there.",
+			target, codeList(res.Conflicts), newPR.GetHTMLURL()))
+		p.setPickStatus(ctx, gh, owner, repo, src.sha, target, "pending", fmt.Sprintf("Conflicts to resolve in #%d", newPR.GetNumber()), newPR.GetHTMLURL())
+		return true
+	}
+
+	msg := fmt.Sprintf("✅ Auto cherry-pick to `%s` opened: %s", target, newPR.GetHTMLURL())
+	if draft {
+		msg += " (a draft: mark it ready for review to merge it)"
+	}
+	p.notify(ctx, gh, owner, repo, src, target, msg)
+	p.setPickStatus(ctx, gh, owner, repo, src.sha, target, "pending", fmt.Sprintf("Backport #%d open", newPR.GetNumber()), newPR.GetHTMLURL())
+	return true
+}
+
+// notify reports body about target on src.issue: as target's row of the
+// status comment when StatusComment is on, else as a comment of its own. With
+// no PR or issue to report to it comments on the commit itself.
+func (p *Processor) notify(ctx context.Context, gh GH, owner, repo string, src pickSource, target, body string) {
+	if src.issue != 0 && p.StatusComment {
+		p.notifyStatus(ctx, gh, owner, repo, src.issue, target, body)
+		return
+	}
+	if src.issue != 0 {
+		_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, src.issue, &github.IssueComment{Body: github.Ptr(body)})
+		return
+	}
+	if _, _, err := gh.Repos().CreateComment(ctx, owner, repo, src.sha, &github.RepositoryComment{Body: github.Ptr(body)}); err != nil {
+		slog.Warn("gh.commit_comment_error", "repo", owner+"/"+repo, "sha", src.sha, "err", safeErr(err))
 	}
 }
 
 // Branch create: ensure label + enforce retention.
 func (p *Processor) handleCreateEvent(ctx context.Context, deliveryID string, e *github.CreateEvent) {
+	if e.GetRefType() == "tag" && p.ReleaseTagPattern != nil && e.GetRepo() != nil {
+		p.handleReleaseTag(ctx, deliveryID, e)
+		return
+	}
 	if e.GetRefType() != "branch" || e.GetRepo() == nil {
 		return
 	}
@@ -511,8 +1429,7 @@ func (p *Processor) handleCreateEvent(ctx context.Context, deliveryID string, e
 	repo := e.GetRepo()
 	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
 
-	re := regexp.MustCompile(`^([a-z0-9-]+-release)/(\d{4})$`)
-	m := re.FindStringSubmatch(ref)
+	m := reReleaseBranch.FindStringSubmatch(ref)
 	if len(m) != 3 {
 		slog.Debug("create.ignore_branch", "delivery", sanitizeForLog(deliveryID), "ref", ref)
 		return
@@ -528,9 +1445,13 @@ func (p *Processor) handleCreateEvent(ctx context.Context, deliveryID string, e
 		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
 		return
 	}
-	gh := realGH{c: clients.REST}
+	p.releaseBranchCreated(ctx, deliveryID, p.ghFor(clients), owner, name, ref)
+}
 
-	label := "cherry-pick to " + ref
+// releaseBranchCreated ensures the release branch's label and enforces
+// label retention.
+func (p *Processor) releaseBranchCreated(ctx context.Context, deliveryID string, gh GH, owner, name, branch string) {
+	label := "cherry-pick to " + branch
 	if err := p.ensureLabel(ctx, gh, owner, name, label); err != nil {
 		slog.Error("labels.ensure_error", "delivery", sanitizeForLog(deliveryID), "label", label, "err", safeErr(err))
 	} else {
@@ -538,11 +1459,56 @@ func (p *Processor) handleCreateEvent(ctx context.Context, deliveryID string, e
 	}
 
 	// Retain only latest 5 labels per family, with pre-deletion cleanup.
-	if err := p.enforceLabelRetention(ctx, gh, owner, name, 5); err != nil {
+	if err := p.enforceLabelRetention(ctx, gh, owner, name, labelsKeptPerFamily); err != nil {
 		slog.Error("labels.retention_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
 	}
 }
 
+// Branch delete: the inverse of create. Backports onto the deleted release
+// branch can no longer merge, so close them (deleting their work branches),
+// then detach and delete its label.
+func (p *Processor) handleDeleteEvent(ctx context.Context, deliveryID string, e *github.DeleteEvent) {
+	if e.GetRefType() != "branch" || e.GetRepo() == nil {
+		return
+	}
+	ref := e.GetRef()
+	repo := e.GetRepo()
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+
+	if !reReleaseBranch.MatchString(ref) {
+		slog.Debug("delete.ignore_branch", "delivery", sanitizeForLog(deliveryID), "ref", ref)
+		return
+	}
+
+	inst := e.GetInstallation()
+	if inst == nil {
+		slog.Warn("delete.no_installation", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name)
+		return
+	}
+	clients, err := p.buildClients(inst.GetID())
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	p.retireTarget(ctx, deliveryID, p.ghFor(clients), owner, name, ref)
+}
+
+// retireTarget closes the open backports onto target and removes its label.
+func (p *Processor) retireTarget(ctx context.Context, deliveryID string, gh GH, owner, repo, target string) {
+	if err := p.cleanupOpenAutoCherryForTarget(ctx, gh, owner, repo, target); err != nil {
+		slog.Error("delete.cleanup_error", "delivery", sanitizeForLog(deliveryID), "target", target, "err", safeErr(err))
+	}
+	label := "cherry-pick to " + target
+	if err := p.removeLabelFromOpenPRs(ctx, gh, owner, repo, label); err != nil {
+		slog.Error("labels.detach_error", "delivery", sanitizeForLog(deliveryID), "label", label, "err", safeErr(err))
+	}
+	if _, err := gh.Issues().DeleteLabel(ctx, owner, repo, label); err != nil && !isNotFound(err) {
+		slog.Error("labels.delete_error", "delivery", sanitizeForLog(deliveryID), "label", label, "err", safeErr(err))
+		return
+	}
+	slog.Info("labels.deleted_for_branch", "delivery", sanitizeForLog(deliveryID), "label", label)
+}
+
 func (p *Processor) ensureLabel(ctx context.Context, gh GH, owner, repo, name string) error {
 	labels, _, err := gh.Issues().ListLabels(ctx, owner, repo, &github.ListOptions{PerPage: 100})
 	if err != nil {
@@ -707,8 +1673,8 @@ func (p *Processor) cleanupOpenAutoCherryForTarget(ctx context.Context, gh GH, o
 		_, _, _ = gh.PR().Edit(ctx, owner, repo, pr.GetNumber(), &github.PullRequest{
 			State: github.Ptr("closed"),
 		})
-		// Delete branch (best-effort)
-		_, _ = gh.Git().DeleteRef(ctx, owner, repo, "refs/heads/"+headRef)
+		// Delete branch (best-effort; the guard logs refusals)
+		_ = p.deleteWorkBranch(ctx, gh, owner, repo, headRef)
 	}
 	return nil
 }
@@ -749,9 +1715,8 @@ func (p *Processor) processUnlabeled(ctx context.Context, gh GH, owner, repo str
 			State: github.Ptr("closed"),
 		})
 	}
-	_, derr := gh.Git().DeleteRef(ctx, owner, repo, "refs/heads/"+workBranch)
-	if derr != nil && !isNotFound(derr) {
-		return derr
+	if err := p.deleteWorkBranch(ctx, gh, owner, repo, workBranch); err != nil && !errors.Is(err, errNotOurBranch) {
+		return err
 	}
 	return nil
 }
@@ -779,16 +1744,32 @@ func isNotFound(err error) bool {
 // ---- Cherry-pick runner seam ----
 
 type CherryPickRunner interface {
-	Pick(ctx context.Context, owner, repo, token, target, sha string, isMerge bool) (workBranch string, err error)
+	Pick(ctx context.Context, owner, repo, token, target, sha string, isMerge bool, opts cherry.Options) (cherry.Result, error)
 }
 
 type realCherryRunner struct {
 	actor cherry.GitActor
 }
 
-func (r realCherryRunner) Pick(ctx context.Context, owner, repo, token, target, sha string, isMerge bool) (string, error) {
+func (r realCherryRunner) Pick(ctx context.Context, owner, repo, token, target, sha string, isMerge bool, opts cherry.Options) (cherry.Result, error) {
+	mainline := 0
 	if isMerge {
-		return cherry.DoCherryPickWithMainline(ctx, owner, repo, token, target, sha, 1, r.actor)
+		mainline = max(opts.Mainline, 1)
+	}
+	return cherry.Pick(ctx, owner, repo, token, target, sha, mainline, r.actor, opts)
+}
+
+// closedBackport returns the latest PR from workBranch into target when it was
+// closed without merging; nil when there is none or it was merged.
+func closedBackport(ctx context.Context, gh GH, owner, repo, workBranch, target string) *github.PullRequest {
+	prs, _, err := gh.PR().List(ctx, owner, repo, &github.PullRequestListOptions{
+		State:       pullRequestStateClosed,
+		Head:        fmt.Sprintf("%s:%s", owner, workBranch),
+		Base:        target,
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil || len(prs) == 0 || prs[0].MergedAt != nil {
+		return nil
 	}
-	return cherry.DoCherryPick(ctx, owner, repo, token, target, sha, r.actor)
+	return prs[0]
 }
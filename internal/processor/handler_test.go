@@ -7,8 +7,13 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -16,6 +21,10 @@ import (
 	github "github.com/google/go-github/v75/github"
 
 	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/gitexec"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/githubapp"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/usage"
 )
 
 //
@@ -67,18 +76,61 @@ func env(headers map[string]string, body []byte) qenv.Envelope {
 type fakePRFull struct {
 	// inputs/fixtures
 	prGet      *github.PullRequest
+	byNumber   map[int]*github.PullRequest // Get results other than prGet
 	commits    []*github.RepositoryCommit
 	list       []*github.PullRequest
 	createErr  error
 	listErr    error
 	commitsErr error
 
+	reviews    []*github.PullRequestReview
+	patch      string                           // GetRaw result
+	withCommit map[string][]*github.PullRequest // sha -> PRs, for ListPullRequestsWithCommit
+
 	// outputs/observations
 	createdPR *github.PullRequest
+	newPR     *github.NewPullRequest
 	edited    []*github.PullRequest
+	merged    []int
+	mergeOpts *github.PullRequestOptions
+	reviewReq []github.ReviewersRequest
+	files     []*github.CommitFile // ListFiles result
+}
+
+func (f *fakePRFull) ListFiles(ctx context.Context, owner, repo string, number int, opts *github.ListOptions) ([]*github.CommitFile, *github.Response, error) {
+	return f.files, nil, nil
+}
+
+func (f *fakePRFull) RequestReviewers(
+	ctx context.Context, owner, repo string, number int, req github.ReviewersRequest,
+) (*github.PullRequest, *github.Response, error) {
+	f.reviewReq = append(f.reviewReq, req)
+	return &github.PullRequest{Number: github.Ptr(number)}, nil, nil
+}
+
+func (f *fakePRFull) ListPullRequestsWithCommit(
+	ctx context.Context, owner, repo, sha string, opts *github.ListOptions,
+) ([]*github.PullRequest, *github.Response, error) {
+	return f.withCommit[sha], nil, nil
+}
+func (f *fakePRFull) ListReviews(ctx context.Context, owner, repo string, number int, opts *github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+	return f.reviews, nil, nil
+}
+func (f *fakePRFull) GetRaw(ctx context.Context, owner, repo string, number int, opts github.RawOptions) (string, *github.Response, error) {
+	return f.patch, nil, nil
+}
+func (f *fakePRFull) Merge(
+	ctx context.Context, owner, repo string, number int, msg string, opts *github.PullRequestOptions,
+) (*github.PullRequestMergeResult, *github.Response, error) {
+	f.merged = append(f.merged, number)
+	f.mergeOpts = opts
+	return &github.PullRequestMergeResult{Merged: github.Ptr(true)}, nil, nil
 }
 
 func (f *fakePRFull) Get(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+	if pr, ok := f.byNumber[number]; ok {
+		return pr, nil, nil
+	}
 	return f.prGet, nil, nil
 }
 func (f *fakePRFull) ListCommits(ctx context.Context, owner, repo string, number int, opt *github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error) {
@@ -89,15 +141,30 @@ func (f *fakePRFull) ListCommits(ctx context.Context, owner, repo string, number
 	return []*github.RepositoryCommit{{SHA: f.prGet.MergeCommitSHA}}, nil, f.commitsErr
 }
 func (f *fakePRFull) List(ctx context.Context, owner, repo string, opts *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
-	return f.list, nil, f.listErr
+	if opts == nil || opts.State == "" {
+		return f.list, nil, f.listErr
+	}
+	// PRs without a state (or head ref) match any query, as before those were modeled.
+	var out []*github.PullRequest
+	for _, pr := range f.list {
+		if head := pr.GetHead().GetRef(); head != "" && opts.Head != "" && !strings.HasSuffix(opts.Head, ":"+head) {
+			continue
+		}
+		if pr.State == nil || opts.State == "all" || pr.GetState() == opts.State {
+			out = append(out, pr)
+		}
+	}
+	return out, nil, f.listErr
 }
 func (f *fakePRFull) Create(ctx context.Context, owner, repo string, pr *github.NewPullRequest) (*github.PullRequest, *github.Response, error) {
 	if f.createErr != nil {
 		return nil, nil, f.createErr
 	}
+	f.newPR = pr
 	f.createdPR = &github.PullRequest{
 		HTMLURL: github.Ptr("https://example.com/newpr"),
-		// Number intentionally omitted; tests don't rely on it
+		Number:  github.Ptr(100),
+		NodeID:  github.Ptr("PR_100"),
 	}
 	return f.createdPR, nil, nil
 }
@@ -118,7 +185,10 @@ type fakeIssuesFull struct {
 	listErr   error
 
 	// observations
-	comments []*github.IssueComment
+	mu       sync.Mutex // CreateComment may be called by parallel picks
+	issues   []*github.IssueRequest
+	comments []*github.IssueComment // IDs assigned in creation order
+	edited   []*github.IssueComment
 	removed  []struct {
 		Num  int
 		Name string
@@ -129,12 +199,36 @@ type fakeIssuesFull struct {
 		Num    int
 		Labels []string
 	}
+	assignees  []string
+	milestones []*github.Milestone // ListMilestones result
+	editedIss  []*github.IssueRequest
 }
 
+func (f *fakeIssuesFull) Create(ctx context.Context, owner, repo string, issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	f.issues = append(f.issues, issue)
+	return &github.Issue{Number: github.Ptr(200 + len(f.issues)), Title: issue.Title}, nil, nil
+}
 func (f *fakeIssuesFull) CreateComment(ctx context.Context, owner, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if comment.ID == nil {
+		comment.ID = github.Ptr(int64(len(f.comments) + 1))
+	}
 	f.comments = append(f.comments, comment)
 	return comment, nil, nil
 }
+func (f *fakeIssuesFull) ListComments(ctx context.Context, owner, repo string, number int, opts *github.IssueListCommentsOptions) ([]*github.IssueComment, *github.Response, error) {
+	return f.comments, &github.Response{Response: &http.Response{StatusCode: 200}}, nil
+}
+func (f *fakeIssuesFull) EditComment(ctx context.Context, owner, repo string, commentID int64, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	for _, c := range f.comments {
+		if c.GetID() == commentID {
+			c.Body = comment.Body
+		}
+	}
+	f.edited = append(f.edited, comment)
+	return comment, nil, nil
+}
 func (f *fakeIssuesFull) ListByRepo(ctx context.Context, owner, repo string, opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
 	var out []*github.Issue
 	for _, is := range f.listByRepo {
@@ -212,28 +306,176 @@ func (f *fakeIssuesFull) AddLabelsToIssue(ctx context.Context, owner, repo strin
 	return out, &github.Response{Response: &http.Response{StatusCode: 200}}, nil
 }
 
+func (f *fakeIssuesFull) Edit(ctx context.Context, owner, repo string, number int, issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	f.editedIss = append(f.editedIss, issue)
+	return &github.Issue{Number: github.Ptr(number)}, nil, nil
+}
+func (f *fakeIssuesFull) ListMilestones(
+	ctx context.Context, owner, repo string, opts *github.MilestoneListOptions,
+) ([]*github.Milestone, *github.Response, error) {
+	return f.milestones, nil, nil
+}
+func (f *fakeIssuesFull) AddAssignees(ctx context.Context, owner, repo string, number int, assignees []string) (*github.Issue, *github.Response, error) {
+	f.assignees = append(f.assignees, assignees...)
+	return &github.Issue{Number: github.Ptr(number)}, nil, nil
+}
+
 type fakeGitFull struct {
 	refs        map[string]bool // existing refs, e.g. "refs/heads/devops-release/0021"
+	refErr      error           // GetRef fails with this when set
 	deletedRefs []string
+	createdRefs []github.CreateRef
+	updatedRefs []github.UpdateRef
+
+	// Git Data fixtures: commits and trees by SHA (trees as flat file lists).
+	commits        map[string]*github.Commit
+	trees          map[string]*github.Tree
+	createdCommits []github.Commit
+}
+
+func (f *fakeGitFull) CreateRef(ctx context.Context, owner, repo string, ref github.CreateRef) (*github.Reference, *github.Response, error) {
+	f.createdRefs = append(f.createdRefs, ref)
+	if f.refs == nil {
+		f.refs = map[string]bool{}
+	}
+	f.refs[ref.Ref] = true
+	return &github.Reference{Ref: github.Ptr(ref.Ref), Object: &github.GitObject{SHA: github.Ptr(ref.SHA)}}, nil, nil
+}
+
+func (f *fakeGitFull) UpdateRef(ctx context.Context, owner, repo, ref string, update github.UpdateRef) (*github.Reference, *github.Response, error) {
+	f.updatedRefs = append(f.updatedRefs, update)
+	return &github.Reference{Ref: github.Ptr(ref), Object: &github.GitObject{SHA: github.Ptr(update.SHA)}}, nil, nil
 }
 
 func (f *fakeGitFull) GetRef(ctx context.Context, owner, repo, ref string) (*github.Reference, *github.Response, error) {
+	if f.refErr != nil {
+		return nil, nil, f.refErr
+	}
 	if f.refs[ref] {
-		return &github.Reference{Ref: github.Ptr(ref)}, nil, nil
+		return &github.Reference{Ref: github.Ptr(ref), Object: &github.GitObject{SHA: github.Ptr("tip:" + ref)}}, nil, nil
 	}
 	return nil, nil, &github.ErrorResponse{Response: &http.Response{StatusCode: 404}} // not found
 }
+func (f *fakeGitFull) ListMatchingRefs(ctx context.Context, owner, repo string, opts *github.ReferenceListOptions) ([]*github.Reference, *github.Response, error) {
+	prefix := "refs/" + opts.Ref
+	var out []*github.Reference
+	for ref := range f.refs {
+		if strings.HasPrefix(ref, prefix) {
+			out = append(out, &github.Reference{Ref: github.Ptr(ref)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].GetRef() < out[j].GetRef() })
+	return out, nil, nil
+}
 func (f *fakeGitFull) DeleteRef(ctx context.Context, owner, repo, ref string) (*github.Response, error) {
 	f.deletedRefs = append(f.deletedRefs, ref)
 	return &github.Response{Response: &http.Response{StatusCode: 204}}, nil
 }
+func (f *fakeGitFull) GetCommit(ctx context.Context, owner, repo, sha string) (*github.Commit, *github.Response, error) {
+	if c := f.commits[sha]; c != nil {
+		return c, nil, nil
+	}
+	return nil, nil, &github.ErrorResponse{Response: &http.Response{StatusCode: 404}}
+}
+func (f *fakeGitFull) GetTree(ctx context.Context, owner, repo, sha string, recursive bool) (*github.Tree, *github.Response, error) {
+	if t := f.trees[sha]; t != nil {
+		return t, nil, nil
+	}
+	return nil, nil, &github.ErrorResponse{Response: &http.Response{StatusCode: 404}}
+}
+
+// CreateTree applies entries over baseTree's files (a nil SHA deletes).
+func (f *fakeGitFull) CreateTree(ctx context.Context, owner, repo, baseTree string, entries []*github.TreeEntry) (*github.Tree, *github.Response, error) {
+	files := map[string]*github.TreeEntry{}
+	for _, e := range f.trees[baseTree].Entries {
+		files[e.GetPath()] = e
+	}
+	for _, e := range entries {
+		if e.SHA == nil {
+			delete(files, e.GetPath())
+		} else {
+			files[e.GetPath()] = e
+		}
+	}
+	t := &github.Tree{SHA: github.Ptr(fmt.Sprintf("tree-%d", len(f.trees)))}
+	for _, e := range files {
+		t.Entries = append(t.Entries, e)
+	}
+	f.trees[t.GetSHA()] = t
+	return t, nil, nil
+}
+func (f *fakeGitFull) CreateCommit(ctx context.Context, owner, repo string, commit github.Commit, opts *github.CreateCommitOptions) (*github.Commit, *github.Response, error) {
+	commit.SHA = github.Ptr(fmt.Sprintf("commit-%d", len(f.createdCommits)))
+	f.createdCommits = append(f.createdCommits, commit)
+	f.commits[commit.GetSHA()] = &commit
+	return &commit, nil, nil
+}
 
 type fakeReposFull struct {
 	// fixtures
 	commit *github.RepositoryCommit
+	diff   string
+	files  map[string]string // path -> content for GetContents
+	// CompareCommits status per head sha ("ahead", "identical", ...); missing = "ahead"
+	compare map[string]string
+	// full comparisons per "base...head", checked before compare
+	ranges map[string]*github.CommitsComparison
+	// committer email per sha (fakeGitFull tips are "tip:<ref>")
+	committers map[string]string
+	// permission per login for GetPermissionLevel; missing = "read"
+	permissions map[string]string
+
+	// observations
+	commitComments map[string][]string // sha -> comment bodies
+	statuses       []*github.RepoStatus
+}
+
+func (f *fakeReposFull) CreateComment(ctx context.Context, owner, repo, sha string, c *github.RepositoryComment) (*github.RepositoryComment, *github.Response, error) {
+	if f.commitComments == nil {
+		f.commitComments = map[string][]string{}
+	}
+	f.commitComments[sha] = append(f.commitComments[sha], c.GetBody())
+	return c, nil, nil
+}
+
+func (f *fakeReposFull) CreateStatus(ctx context.Context, owner, repo, ref string, st *github.RepoStatus) (*github.RepoStatus, *github.Response, error) {
+	f.statuses = append(f.statuses, st)
+	return st, nil, nil
+}
+
+func (f *fakeReposFull) CreateFile(
+	ctx context.Context, owner, repo, path string, opts *github.RepositoryContentFileOptions,
+) (*github.RepositoryContentResponse, *github.Response, error) {
+	if f.files == nil {
+		f.files = map[string]string{}
+	}
+	f.files[path] = string(opts.Content)
+	return &github.RepositoryContentResponse{}, nil, nil
+}
+
+func (f *fakeReposFull) GetPermissionLevel(ctx context.Context, owner, repo, user string) (*github.RepositoryPermissionLevel, *github.Response, error) {
+	perm, ok := f.permissions[user]
+	if !ok {
+		perm = "read"
+	}
+	return &github.RepositoryPermissionLevel{Permission: github.Ptr(perm)}, nil, nil
+}
+
+func (f *fakeReposFull) CompareCommits(ctx context.Context, owner, repo, base, head string, opts *github.ListOptions) (*github.CommitsComparison, *github.Response, error) {
+	if cmp, ok := f.ranges[base+"..."+head]; ok {
+		return cmp, nil, nil
+	}
+	st, ok := f.compare[head]
+	if !ok {
+		st = "ahead"
+	}
+	return &github.CommitsComparison{Status: github.Ptr(st)}, nil, nil
 }
 
 func (f *fakeReposFull) GetCommit(ctx context.Context, owner, repo, sha string, opts *github.ListOptions) (*github.RepositoryCommit, *github.Response, error) {
+	if email, ok := f.committers[sha]; ok {
+		return &github.RepositoryCommit{SHA: github.Ptr(sha), Commit: &github.Commit{Committer: &github.CommitAuthor{Email: github.Ptr(email)}}}, nil, nil
+	}
 	if f.commit != nil {
 		return f.commit, nil, nil
 	}
@@ -241,25 +483,170 @@ func (f *fakeReposFull) GetCommit(ctx context.Context, owner, repo, sha string,
 	return repoCommitWithParents(1), nil, nil
 }
 
+func (f *fakeReposFull) GetCommitRaw(ctx context.Context, owner, repo, sha string, opts github.RawOptions) (string, *github.Response, error) {
+	return f.diff, nil, nil
+}
+
+func (f *fakeReposFull) GetContents(
+	ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions,
+) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error) {
+	c, ok := f.files[path]
+	if !ok {
+		return nil, nil, nil, &github.ErrorResponse{Response: &http.Response{StatusCode: 404}}
+	}
+	return &github.RepositoryContent{Content: github.Ptr(c)}, nil, nil, nil
+}
+
+type fakeReactions struct {
+	created []string // reaction contents in call order
+	deleted []int64
+	nextID  int64
+	onIssue []int // issues of CreateIssueReaction calls
+}
+
+func (f *fakeReactions) CreateIssueCommentReaction(ctx context.Context, owner, repo string, id int64, content string) (*github.Reaction, *github.Response, error) {
+	f.created = append(f.created, content)
+	f.nextID++
+	return &github.Reaction{ID: github.Ptr(f.nextID), Content: github.Ptr(content)}, nil, nil
+}
+func (f *fakeReactions) DeleteIssueCommentReaction(ctx context.Context, owner, repo string, commentID, reactionID int64) (*github.Response, error) {
+	f.deleted = append(f.deleted, reactionID)
+	return &github.Response{Response: &http.Response{StatusCode: 204}}, nil
+}
+func (f *fakeReactions) CreateIssueReaction(ctx context.Context, owner, repo string, number int, content string) (*github.Reaction, *github.Response, error) {
+	f.onIssue = append(f.onIssue, number)
+	return f.CreateIssueCommentReaction(ctx, owner, repo, 0, content)
+}
+func (f *fakeReactions) DeleteIssueReaction(ctx context.Context, owner, repo string, number int, reactionID int64) (*github.Response, error) {
+	return f.DeleteIssueCommentReaction(ctx, owner, repo, 0, reactionID)
+}
+
+type fakeSearch struct {
+	issues  []*github.Issue
+	rate    github.Rate
+	err     error
+	queries []string
+}
+
+func (f *fakeSearch) Issues(ctx context.Context, query string, opts *github.SearchOptions) (*github.IssuesSearchResult, *github.Response, error) {
+	f.queries = append(f.queries, query)
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+	return &github.IssuesSearchResult{Issues: f.issues}, &github.Response{Rate: f.rate}, nil
+}
+
 type fakeGH struct {
-	pr    *fakePRFull
-	iss   *fakeIssuesFull
-	git   *fakeGitFull
-	repos *fakeReposFull
+	pr     *fakePRFull
+	iss    *fakeIssuesFull
+	git    *fakeGitFull
+	repos  *fakeReposFull
+	react  *fakeReactions
+	search *fakeSearch
+	gql    *fakeGraphQL
+	checks *fakeChecks
+}
+
+func (f fakeGH) Checks() ChecksAPI {
+	if f.checks == nil {
+		return &fakeChecks{}
+	}
+	return f.checks
+}
+
+// fakeChecks keeps check runs in memory, as GitHub would for one commit.
+type fakeChecks struct {
+	runs    []*github.CheckRun
+	updates int
+}
+
+func (f *fakeChecks) ListCheckRunsForRef(
+	ctx context.Context, owner, repo, ref string, opts *github.ListCheckRunsOptions,
+) (*github.ListCheckRunsResults, *github.Response, error) {
+	var out []*github.CheckRun
+	for _, r := range f.runs {
+		if r.GetName() == opts.GetCheckName() && r.GetHeadSHA() == ref {
+			out = append(out, r)
+		}
+	}
+	return &github.ListCheckRunsResults{Total: github.Ptr(len(out)), CheckRuns: out}, nil, nil
+}
+func (f *fakeChecks) CreateCheckRun(ctx context.Context, owner, repo string, opts github.CreateCheckRunOptions) (*github.CheckRun, *github.Response, error) {
+	r := &github.CheckRun{
+		ID: github.Ptr(int64(len(f.runs) + 1)), Name: github.Ptr(opts.Name), HeadSHA: github.Ptr(opts.HeadSHA),
+		Status: opts.Status, Conclusion: opts.Conclusion, Output: opts.Output,
+	}
+	f.runs = append(f.runs, r)
+	return r, nil, nil
+}
+func (f *fakeChecks) UpdateCheckRun(
+	ctx context.Context, owner, repo string, id int64, opts github.UpdateCheckRunOptions,
+) (*github.CheckRun, *github.Response, error) {
+	f.updates++
+	r := f.runs[id-1]
+	r.Status, r.Conclusion, r.Output = opts.Status, opts.Conclusion, opts.Output
+	return r, nil, nil
 }
 
 func (f fakeGH) PR() PullRequestsAPI    { return f.pr }
 func (f fakeGH) Issues() IssuesAPI      { return f.iss }
 func (f fakeGH) Git() GitAPI            { return f.git }
 func (f fakeGH) Repos() RepositoriesAPI { return f.repos }
+func (f fakeGH) Search() SearchAPI {
+	if f.search == nil {
+		return &fakeSearch{}
+	}
+	return f.search
+}
+func (f fakeGH) Reactions() ReactionsAPI {
+	if f.react == nil {
+		return &fakeReactions{}
+	}
+	return f.react
+}
+func (f fakeGH) GraphQL() GraphQLAPI {
+	if f.gql == nil {
+		return &fakeGraphQL{}
+	}
+	return f.gql
+}
+
+// fakeGraphQL records documents and their variables.
+type fakeGraphQL struct {
+	queries []string
+	vars    []map[string]any
+	data    []string // JSON data of each call, in order ("" = none)
+	err     error
+}
+
+func (f *fakeGraphQL) Do(_ context.Context, query string, vars map[string]any, out any) error {
+	f.queries = append(f.queries, query)
+	f.vars = append(f.vars, vars)
+	if n := len(f.queries); f.err == nil && out != nil && n <= len(f.data) && f.data[n-1] != "" {
+		return json.Unmarshal([]byte(f.data[n-1]), out)
+	}
+	return f.err
+}
 
 type fakeCherry struct {
 	workBranch string
 	err        error
+	viaPatch   bool
+	viaAm      bool
+	headOwner  string
+	conflicts  []string
+	hookRuns   []cherry.HookRun
+	verify     *cherry.VerifyRun
+	prePush    *cherry.VerifyRun
+	// filled by Pick
+	opts *cherry.Options
 }
 
-func (f fakeCherry) Pick(ctx context.Context, owner, repo, token, target, sha string, isMerge bool) (string, error) {
-	return f.workBranch, f.err
+func (f fakeCherry) Pick(ctx context.Context, owner, repo, token, target, sha string, isMerge bool, opts cherry.Options) (cherry.Result, error) {
+	if f.opts != nil {
+		*f.opts = opts
+	}
+	return cherry.Result{WorkBranch: f.workBranch, AppliedViaPatch: f.viaPatch, AppliedViaAm: f.viaAm, HeadOwner: f.headOwner, Conflicts: f.conflicts, HookRuns: f.hookRuns, Verify: f.verify, PrePush: f.prePush}, f.err
 }
 
 //
@@ -295,6 +682,75 @@ func TestHandleFromEnvelope_PullRequestAccepted(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 }
 
+func TestHandleEvent_SynchronousRunsInline(t *testing.T) {
+	called := false
+	p := &Processor{
+		WebhookSecret: []byte("secret"),
+		Synchronous:   true,
+		NewClients: func(appID, installationID int64, pem []byte) (*githubapp.Clients, error) {
+			called = true
+			return nil, errors.New("no clients in test")
+		},
+	}
+	body := []byte(`{"ref":"devops-release/0021","ref_type":"branch","installation":{"id":1},"repository":{"owner":{"login":"o"},"name":"r"}}`)
+
+	code, err := p.HandleEvent(context.Background(), "create", "d1", body)
+	if err != nil || code != http.StatusAccepted {
+		t.Fatalf("got code=%d err=%v, want %d", code, err, http.StatusAccepted)
+	}
+	if !called {
+		t.Fatalf("expected work to have run before HandleEvent returned")
+	}
+}
+
+func TestHandleDeleteEvent_IgnoresNonReleaseBranches(t *testing.T) {
+	p := &Processor{NewClients: func(int64, int64, []byte) (*githubapp.Clients, error) {
+		t.Fatal("clients built for an ignored delete")
+		return nil, nil
+	}}
+	for _, e := range []*github.DeleteEvent{
+		{Ref: github.Ptr("feature/x"), RefType: github.Ptr("branch")},
+		{Ref: github.Ptr("devops-release/0021"), RefType: github.Ptr("tag")},
+	} {
+		e.Repo = &github.Repository{Owner: &github.User{Login: github.Ptr("o")}, Name: github.Ptr("r")}
+		e.Installation = &github.Installation{ID: github.Ptr(int64(1))}
+		p.handleDeleteEvent(context.Background(), "d1", e)
+	}
+}
+
+func TestRetireTarget_ClosesBackportsAndDeletesLabel(t *testing.T) {
+	const (
+		target = "devops-release/0021"
+		label  = "cherry-pick to " + target
+		work   = "autocherry/devops-release-0021/abc1234"
+	)
+	fpr := &fakePRFull{list: []*github.PullRequest{
+		{Number: github.Ptr(11), Head: &github.PullRequestBranch{Ref: github.Ptr(work)}},
+		{Number: github.Ptr(12), Head: &github.PullRequestBranch{Ref: github.Ptr("hotfix/manual")}},
+	}}
+	fiss := &fakeIssuesFull{listByRepo: []*github.Issue{
+		{Number: github.Ptr(5), State: github.Ptr("open"), Labels: []*github.Label{{Name: github.Ptr(label)}}},
+	}}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/" + work: true}}
+	gh := fakeGH{pr: fpr, iss: fiss, git: fgit, repos: &fakeReposFull{committers: map[string]string{"tip:refs/heads/" + work: "bot@noreply"}}}
+	p := &Processor{GitUserEmail: "bot@noreply"}
+
+	p.retireTarget(context.Background(), "d1", gh, "o", "r", target)
+
+	if len(fpr.edited) != 1 || fpr.edited[0].GetState() != "closed" {
+		t.Fatalf("edited = %+v, want only the backport closed", fpr.edited)
+	}
+	if len(fgit.deletedRefs) != 1 || fgit.deletedRefs[0] != "refs/heads/"+work {
+		t.Fatalf("deleted refs = %v", fgit.deletedRefs)
+	}
+	if len(fiss.removed) != 1 || fiss.removed[0].Num != 5 {
+		t.Fatalf("label removals = %+v", fiss.removed)
+	}
+	if len(fiss.deleted) != 1 || fiss.deleted[0] != label {
+		t.Fatalf("deleted labels = %v", fiss.deleted)
+	}
+}
+
 func TestHandleFromEnvelope_IgnoresOtherEvents(t *testing.T) {
 	p := &Processor{WebhookSecret: []byte("secret")}
 	body := []byte(`{}`)
@@ -378,6 +834,210 @@ func TestProcessMergedPR_SuccessCreatesPR(t *testing.T) {
 	}
 }
 
+func TestProcessMergedPR_MetersUsage(t *testing.T) {
+	m, _ := usage.Open("")
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", Usage: m}
+
+	pr := mergedPR(7, "Fix bug", "abc123456789", "cherry-pick to devops-release/0021")
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	gh := fakeGH{pr: &fakePRFull{prGet: pr}, iss: &fakeIssuesFull{}, git: fgit, repos: &fakeReposFull{commit: repoCommitWithParents(1)}}
+	var opts cherry.Options
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234", opts: &opts}
+
+	p.processMergedPRWith(usage.WithInstallation(context.Background(), 42), "d", gh, "o", "r", 7, nil, "tok")
+	if opts.Fetched == nil {
+		t.Fatal("expected Fetched to be wired when metering")
+	}
+	opts.Fetched(2048)
+
+	rows := m.Report("")
+	if len(rows) != 1 || rows[0].Installation != 42 || rows[0].Clones != 1 || rows[0].BytesFetched != 2048 {
+		t.Fatalf("usage = %+v", rows)
+	}
+}
+
+func TestProcessMergedPR_ForkFallbackOpensCrossForkPR(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", ForkToken: "pat"}
+
+	pr := mergedPR(7, "Fix bug", "abc123456789", "cherry-pick to devops-release/0021")
+	fpr := &fakePRFull{prGet: pr}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	frepos := &fakeReposFull{files: map[string]string{repocfg.Path: "fork: cherry-bot/r\n"}}
+	gh := fakeGH{pr: fpr, iss: &fakeIssuesFull{}, git: fgit, repos: frepos}
+	var opts cherry.Options
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234", headOwner: "cherry-bot", opts: &opts}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 7, nil, "tok")
+
+	if opts.Fork == nil || *opts.Fork != (cherry.Fork{Owner: "cherry-bot", Repo: "r", Token: "pat"}) {
+		t.Fatalf("fork option = %+v", opts.Fork)
+	}
+	if fpr.newPR == nil || fpr.newPR.GetHead() != "cherry-bot:autocherry/devops-release-0021/abc1234" || !fpr.newPR.GetMaintainerCanModify() {
+		t.Fatalf("expected a cross-fork PR, got %+v", fpr.newPR)
+	}
+	if !strings.Contains(fpr.newPR.GetBody(), "pushed to the fork `cherry-bot`") {
+		t.Fatalf("body = %q", fpr.newPR.GetBody())
+	}
+}
+
+func TestProcessMergedPR_AmFallbackUsesPRPatch(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", AmFallback: true}
+
+	pr := mergedPR(7, "Fix bug", "abc123456789", "cherry-pick to devops-release/0021")
+	fpr := &fakePRFull{prGet: pr, patch: "From abc123456789 Mon Sep 17 00:00:00 2001\n"}
+	fiss := &fakeIssuesFull{}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	gh := fakeGH{pr: fpr, iss: fiss, git: fgit, repos: &fakeReposFull{}}
+	var opts cherry.Options
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234", viaAm: true, opts: &opts}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 7, nil, "tok")
+
+	if opts.MailboxFallback == nil {
+		t.Fatalf("expected a mailbox fallback")
+	}
+	if mbox, err := opts.MailboxFallback(context.Background()); err != nil || string(mbox) != fpr.patch {
+		t.Fatalf("mailbox = %q, %v", mbox, err)
+	}
+	if fpr.newPR == nil || !strings.Contains(fpr.newPR.GetBody(), "PR #7 patch was applied with `git am -3`") {
+		t.Fatalf("body = %q", fpr.newPR.GetBody())
+	}
+	if len(fiss.addedToIssue) == 0 || !slices.Contains(fiss.addedToIssue[len(fiss.addedToIssue)-1].Labels, labelAppliedViaPatch) {
+		t.Fatalf("expected the %q label, got %+v", labelAppliedViaPatch, fiss.addedToIssue)
+	}
+}
+
+func TestPickOptions_Flags(t *testing.T) {
+	for _, on := range []bool{false, true} {
+		p := &Processor{Signoff: on, FullCloneFallback: on, LFS: on, PickEmpty: on, PickRedundant: on}
+		if on {
+			p.Mirrors = &gitexec.MirrorCache{Dir: t.TempDir()}
+			p.SharedClones = &gitexec.SharedClones{Dir: t.TempDir()}
+			p.Workspace = &gitexec.Workspace{Dir: t.TempDir()}
+		}
+		opts := p.pickOptions(fakeGH{}, "o", "r", "abc1234", "release/1", &repocfg.Config{})
+		got := map[string]bool{
+			"Signoff":        opts.Signoff,
+			"FullCloneRetry": opts.FullCloneRetry,
+			"LFS":            opts.LFS,
+			"AllowEmpty":     opts.AllowEmpty,
+			"KeepRedundant":  opts.KeepRedundant,
+			"Mirror":         opts.Mirror != nil,
+			"SharedClones":   opts.SharedClones != nil,
+			"Workspace":      opts.Workspace != nil,
+		}
+		for name, v := range got {
+			if v != on {
+				t.Errorf("flags %v: %s = %v", on, name, v)
+			}
+		}
+	}
+}
+
+func TestProcessMergedPR_ParallelTargets(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", ParallelTargets: 2}
+	p.CherryRunner = barrierCherry{want: 2, running: &atomic.Int32{}}
+
+	pr := mergedPR(7, "Fix bug", "abc123456789", "cherry-pick to devops-release/0021", "cherry-pick to devops-release/0022")
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true, "refs/heads/devops-release/0022": true}}
+	fiss := &fakeIssuesFull{}
+	gh := fakeGH{pr: &fakePRFull{prGet: pr}, iss: fiss, git: fgit, repos: &fakeReposFull{}}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 7, nil, "tok")
+
+	if len(fiss.comments) != 2 {
+		t.Fatalf("comments = %d, want one per target", len(fiss.comments))
+	}
+	for _, c := range fiss.comments {
+		if strings.Contains(c.GetBody(), "did not overlap") {
+			t.Fatalf("targets were picked one after the other: %s", c.GetBody())
+		}
+	}
+}
+
+func TestProcessMergedPR_PickStrategyPerTarget(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", PickStrategies: true}
+
+	pr := mergedPR(7, "Fix bug", "abc123456789", "cherry-pick to devops-release/0021")
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	frepos := &fakeReposFull{files: map[string]string{
+		repocfg.Path: "pick_strategy:\n  option: ours\n  targets:\n    \"devops-release/*\": {option: theirs}\n",
+	}}
+	gh := fakeGH{pr: &fakePRFull{prGet: pr}, iss: &fakeIssuesFull{}, git: fgit, repos: frepos}
+	var opts cherry.Options
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234", opts: &opts}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 7, nil, "tok")
+
+	if opts.Strategy != (cherry.Strategy{Option: "theirs"}) {
+		t.Fatalf("strategy = %+v", opts.Strategy)
+	}
+}
+
+func TestProcessMergedPR_ConflictOpensDraftPR(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", ConflictPRs: true}
+
+	pr := mergedPR(7, "Fix bug", "abc123456789", "cherry-pick to devops-release/0021")
+	fpr := &fakePRFull{prGet: pr}
+	fiss := &fakeIssuesFull{}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	gh := fakeGH{pr: fpr, iss: fiss, git: fgit, repos: &fakeReposFull{}}
+	var opts cherry.Options
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234", conflicts: []string{"a.go"}, opts: &opts}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 7, nil, "tok")
+
+	if !opts.CommitConflicts {
+		t.Fatalf("expected CommitConflicts to be requested")
+	}
+	if fpr.newPR == nil || !fpr.newPR.GetDraft() || !strings.Contains(fpr.newPR.GetBody(), "conflict markers in `a.go`") {
+		t.Fatalf("expected a draft PR listing the conflicts, got %+v", fpr.newPR)
+	}
+	if len(fiss.addedToIssue) == 0 || !slices.Contains(fiss.addedToIssue[len(fiss.addedToIssue)-1].Labels, labelConflicts) {
+		t.Fatalf("expected the %q label, got %+v", labelConflicts, fiss.addedToIssue)
+	}
+	if got := fiss.comments[len(fiss.comments)-1].GetBody(); !strings.HasPrefix(got, "⚠️") || !strings.Contains(got, "draft PR") {
+		t.Fatalf("comment = %q", got)
+	}
+}
+
+func TestProcessMergedPR_AppliedViaPatchLabelsPR(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", PatchFallback: true}
+
+	pr := mergedPR(9, "Move files", "abc123456789", "cherry-pick to devops-release/0021")
+	fpr := &fakePRFull{prGet: pr}
+	fiss := &fakeIssuesFull{}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	frepos := &fakeReposFull{commit: repoCommitWithParents(1), diff: "diff --git a/a b/a\n"}
+	gh := fakeGH{pr: fpr, iss: fiss, git: fgit, repos: frepos}
+
+	var opts cherry.Options
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234", viaPatch: true, opts: &opts}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 9, nil, "tok")
+
+	if opts.PatchFallback == nil {
+		t.Fatalf("expected PatchFallback to be wired when enabled")
+	}
+	if diff, err := opts.PatchFallback(context.Background()); err != nil || string(diff) != frepos.diff {
+		t.Fatalf("PatchFallback = %q, %v", diff, err)
+	}
+	found := false
+	for _, a := range fiss.addedToIssue {
+		for _, l := range a.Labels {
+			if l == labelAppliedViaPatch && a.Num == 100 {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected %q label on new PR, got %+v", labelAppliedViaPatch, fiss.addedToIssue)
+	}
+	if fpr.createdPR == nil {
+		t.Fatalf("expected a new PR to be created")
+	}
+}
+
 func TestProcessMergedPR_NoOpCherryPick(t *testing.T) {
 	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply"}
 
@@ -460,6 +1120,45 @@ func TestProcessMergedPR_Idempotent_WorkBranchExistsNoOpenPR(t *testing.T) {
 	}
 }
 
+func TestProcessMergedPR_RepicksAfterClosedBackport(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply"}
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/ddd4444"}
+
+	pr := mergedPR(12, "Fix again", "ddd4444555", "cherry-pick to devops-release/0021")
+	fpr := &fakePRFull{prGet: pr, list: []*github.PullRequest{{Number: github.Ptr(90), State: github.Ptr("closed")}}}
+	fiss := &fakeIssuesFull{}
+	fgit := &fakeGitFull{refs: map[string]bool{
+		"refs/heads/devops-release/0021":                    true,
+		"refs/heads/autocherry/devops-release-0021/ddd4444": true,
+	}}
+	frepos := &fakeReposFull{committers: map[string]string{"tip:refs/heads/autocherry/devops-release-0021/ddd4444": "bot@noreply"}}
+	gh := fakeGH{pr: fpr, iss: fiss, git: fgit, repos: frepos}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 12, nil, "tok")
+
+	if len(fgit.deletedRefs) != 1 || fgit.deletedRefs[0] != "refs/heads/autocherry/devops-release-0021/ddd4444" {
+		t.Fatalf("expected stale work branch deleted, got %v", fgit.deletedRefs)
+	}
+	if fpr.createdPR == nil {
+		t.Fatalf("expected a fresh backport PR")
+	}
+
+	// A merged backport is not re-picked.
+	merged := &fakePRFull{prGet: pr, list: []*github.PullRequest{{Number: github.Ptr(90), State: github.Ptr("closed"), MergedAt: &github.Timestamp{}}}}
+	fgit.deletedRefs = nil
+	p.processMergedPRWith(context.Background(), "d", fakeGH{pr: merged, iss: &fakeIssuesFull{}, git: fgit, repos: frepos}, "o", "r", 12, nil, "tok")
+	if merged.createdPR != nil || len(fgit.deletedRefs) != 0 {
+		t.Fatalf("did not expect a re-pick over a merged backport")
+	}
+
+	// A branch the guard refuses to delete keeps the old skip.
+	guarded := &fakePRFull{prGet: pr, list: []*github.PullRequest{{Number: github.Ptr(90), State: github.Ptr("closed")}}}
+	p.processMergedPRWith(context.Background(), "d", fakeGH{pr: guarded, iss: &fakeIssuesFull{}, git: fgit, repos: &fakeReposFull{}}, "o", "r", 12, nil, "tok")
+	if guarded.createdPR != nil || len(fgit.deletedRefs) != 0 {
+		t.Fatalf("did not expect a re-pick over a human branch")
+	}
+}
+
 func TestProcessMergedPR_TargetMissing(t *testing.T) {
 	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply"}
 
@@ -752,4 +1451,98 @@ var (
 	_ IssuesAPI       = (*github.IssuesService)(nil)
 	_ GitAPI          = (*github.GitService)(nil)
 	_ RepositoriesAPI = (*github.RepositoriesService)(nil)
+	_ ReactionsAPI    = (*github.ReactionsService)(nil)
 )
+
+// relabeledPRs serves the original PR on the first Get and `later` afterwards,
+// as if labels changed while the event sat in the queue.
+type relabeledPRs struct {
+	*fakePRFull
+	later *github.PullRequest
+	gets  int
+}
+
+func (f *relabeledPRs) Get(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+	f.gets++
+	if f.gets == 1 {
+		return f.fakePRFull.Get(ctx, owner, repo, number)
+	}
+	return f.later, nil, nil
+}
+
+func TestProcessMergedPR_RecheckLabels(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		pickAdded bool
+		want      []string
+	}{
+		{name: "removed label skipped", want: []string{"devops-release/0022"}},
+		{name: "added label picked", pickAdded: true, want: []string{"devops-release/0022", "devops-release/0023"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", RecheckLabels: true, PickAddedTargets: tc.pickAdded}
+			orig := mergedPR(7, "Fix", "abc123456789", "cherry-pick to devops-release/0021", "cherry-pick to devops-release/0022")
+			later := mergedPR(7, "Fix", "abc123456789", "cherry-pick to devops-release/0022", "cherry-pick to devops-release/0023")
+			fpr := &fakePRFull{prGet: orig}
+			fiss := &fakeIssuesFull{}
+			gh := ghWithPRs{
+				fakeGH: fakeGH{pr: fpr, iss: fiss, repos: &fakeReposFull{commit: repoCommitWithParents(1)}, git: &fakeGitFull{refs: map[string]bool{
+					"refs/heads/devops-release/0021": true, "refs/heads/devops-release/0022": true, "refs/heads/devops-release/0023": true,
+				}}},
+				prs: &relabeledPRs{fakePRFull: fpr, later: later},
+			}
+			var picked []string
+			p.CherryRunner = recordingCherry{targets: &picked}
+
+			p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 7, nil, "tok")
+
+			if strings.Join(picked, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("picked %v, want %v", picked, tc.want)
+			}
+		})
+	}
+}
+
+type recordingCherry struct{ targets *[]string }
+
+// barrierCherry finds every pick a no-op once want picks run at the same time.
+type barrierCherry struct {
+	want    int32
+	running *atomic.Int32
+}
+
+func (b barrierCherry) Pick(ctx context.Context, owner, repo, token, target, sha string, isMerge bool, opts cherry.Options) (cherry.Result, error) {
+	b.running.Add(1)
+	deadline := time.Now().Add(5 * time.Second)
+	for b.running.Load() < b.want {
+		if time.Now().After(deadline) {
+			return cherry.Result{}, errors.New("picks did not overlap")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return cherry.Result{}, cherry.ErrNoopCherryPick
+}
+
+func (r recordingCherry) Pick(ctx context.Context, owner, repo, token, target, sha string, isMerge bool, opts cherry.Options) (cherry.Result, error) {
+	*r.targets = append(*r.targets, target)
+	return cherry.Result{WorkBranch: "autocherry/" + strings.ReplaceAll(target, "/", "-") + "/abc1234"}, nil
+}
+
+type envelopeSpy struct{ deliveries []string }
+
+func (s *envelopeSpy) RecordEnvelope(delivery, event string, headers map[string]string, body []byte) {
+	s.deliveries = append(s.deliveries, delivery+"/"+event)
+}
+
+func TestHandleEvent_RecordsVerifiedEnvelopes(t *testing.T) {
+	spy := &envelopeSpy{}
+	p := &Processor{WebhookSecret: []byte("s"), Envelopes: spy}
+	_, _ = p.HandleEvent(context.Background(), "ping", "d-1", []byte(`{"zen":"x"}`))
+	_, _ = p.HandleFromEnvelope(context.Background(), qenv.Envelope{
+		Headers: map[string]string{"X-GitHub-Event": "ping", "X-GitHub-Delivery": "d-2", "X-Hub-Signature-256": "sha256=bad"},
+		Body:    []byte(`{}`),
+	})
+	if strings.Join(spy.deliveries, ",") != "d-1/ping" {
+		t.Fatalf("recorded = %v, want only the verified delivery", spy.deliveries)
+	}
+}
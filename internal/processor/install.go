@@ -0,0 +1,197 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+)
+
+// labelsKeptPerFamily is how many "cherry-pick to <family>/NNNN" labels are
+// kept per release family; older ones are retired.
+const labelsKeptPerFamily = 5
+
+// handleInstallationEvent bootstraps labels when the app is installed, so
+// release branches that already exist can be targeted right away instead of
+// after the next branch creation.
+func (p *Processor) handleInstallationEvent(ctx context.Context, deliveryID string, e *github.InstallationEvent) {
+	if e.GetAction() != "created" {
+		return
+	}
+	inst := e.GetInstallation()
+	clients, err := p.buildClients(inst.GetID())
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	p.bootstrapRepos(ctx, deliveryID, p.ghFor(clients), e.Repositories)
+}
+
+// handleInstallationRepositoriesEvent does the same for repositories added
+// to an existing installation.
+func (p *Processor) handleInstallationRepositoriesEvent(ctx context.Context, deliveryID string, e *github.InstallationRepositoriesEvent) {
+	if e.GetAction() != "added" || len(e.RepositoriesAdded) == 0 {
+		return
+	}
+	clients, err := p.buildClients(e.GetInstallation().GetID())
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	p.bootstrapRepos(ctx, deliveryID, p.ghFor(clients), e.RepositoriesAdded)
+}
+
+// handleRepositoryEvent sets up repositories created in an installation: the
+// release labels of any branches it starts with (e.g. from a template),
+// BootstrapLabels, and BootstrapConfig as the repository config.
+func (p *Processor) handleRepositoryEvent(ctx context.Context, deliveryID string, e *github.RepositoryEvent) {
+	if e.GetAction() != "created" || e.GetRepo() == nil {
+		return
+	}
+	owner, name := e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName()
+	inst := e.GetInstallation()
+	if inst == nil {
+		slog.Warn("repository.no_installation", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name)
+		return
+	}
+	clients, err := p.buildClients(inst.GetID())
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	gh := p.ghFor(clients)
+
+	p.bootstrapRepos(ctx, deliveryID, gh, []*github.Repository{e.GetRepo()})
+	for _, label := range p.BootstrapLabels {
+		if err := p.ensureLabel(ctx, gh, owner, name, label); err != nil {
+			slog.Error("labels.ensure_error", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name, "label", label, "err", safeErr(err))
+		}
+	}
+	if len(p.BootstrapConfig) > 0 {
+		if err := p.bootstrapConfig(ctx, gh, owner, name); err != nil {
+			slog.Error("repository.config_error", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name, "err", safeErr(err))
+		}
+	}
+}
+
+// bootstrapConfig commits BootstrapConfig as the repository config on the
+// default branch unless the repository already has one.
+func (p *Processor) bootstrapConfig(ctx context.Context, gh GH, owner, repo string) error {
+	if _, _, _, err := gh.Repos().GetContents(ctx, owner, repo, repocfg.Path, nil); err == nil {
+		return nil
+	} else if !isNotFound(err) {
+		return fmt.Errorf("get %s: %w", repocfg.Path, err)
+	}
+	if _, _, err := gh.Repos().CreateFile(ctx, owner, repo, repocfg.Path, &github.RepositoryContentFileOptions{
+		Message: github.Ptr("Add cherry-pick configuration"),
+		Content: p.BootstrapConfig,
+	}); err != nil {
+		return fmt.Errorf("create %s: %w", repocfg.Path, err)
+	}
+	slog.Info("repository.config_created", "repo", owner+"/"+repo, "path", repocfg.Path)
+	return nil
+}
+
+// bootstrapRepos runs bootstrapLabels, then label retention as for a created
+// branch, for each repository of an installation payload; those only carry
+// full names.
+func (p *Processor) bootstrapRepos(ctx context.Context, deliveryID string, gh GH, repos []*github.Repository) {
+	for _, r := range repos {
+		owner, name, ok := strings.Cut(r.GetFullName(), "/")
+		if !ok {
+			continue
+		}
+		created, err := p.bootstrapLabels(ctx, gh, owner, name)
+		if err != nil {
+			slog.Error("install.bootstrap_error", "delivery", sanitizeForLog(deliveryID), "repo", r.GetFullName(), "err", safeErr(err))
+			continue
+		}
+		slog.Info("install.labels_bootstrapped", "delivery", sanitizeForLog(deliveryID), "repo", r.GetFullName(), "created", created)
+		// Labels left from an earlier installation may be past retention.
+		if err := p.enforceLabelRetention(ctx, gh, owner, name, labelsKeptPerFamily); err != nil {
+			slog.Error("labels.retention_error", "delivery", sanitizeForLog(deliveryID), "repo", r.GetFullName(), "err", safeErr(err))
+		}
+	}
+}
+
+// bootstrapLabels creates the "cherry-pick to" labels for the newest
+// labelsKeptPerFamily release branches of each family, returning how many
+// were missing.
+func (p *Processor) bootstrapLabels(ctx context.Context, gh GH, owner, repo string) (int, error) {
+	var branches []string
+	opts := &github.ReferenceListOptions{Ref: "heads/", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		refs, resp, err := gh.Git().ListMatchingRefs(ctx, owner, repo, opts)
+		if err != nil {
+			return 0, fmt.Errorf("list branches: %w", err)
+		}
+		for _, ref := range refs {
+			if b := strings.TrimPrefix(ref.GetRef(), "refs/heads/"); reReleaseBranch.MatchString(b) {
+				branches = append(branches, b)
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if len(branches) == 0 {
+		return 0, nil
+	}
+
+	existing := map[string]bool{}
+	lopts := &github.ListOptions{PerPage: 100}
+	for {
+		labels, resp, err := gh.Issues().ListLabels(ctx, owner, repo, lopts)
+		if err != nil {
+			return 0, fmt.Errorf("list repo labels: %w", err)
+		}
+		for _, l := range labels {
+			existing[l.GetName()] = true
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		lopts.Page = resp.NextPage
+	}
+
+	created := 0
+	for _, b := range latestReleaseBranches(branches, labelsKeptPerFamily) {
+		label := "cherry-pick to " + b
+		if existing[label] {
+			continue
+		}
+		if _, _, err := gh.Issues().CreateLabel(ctx, owner, repo, &github.Label{Name: github.Ptr(label), Color: github.Ptr("ededed")}); err != nil {
+			return created, fmt.Errorf("create label %q: %w", label, err)
+		}
+		created++
+	}
+	return created, nil
+}
+
+// latestReleaseBranches keeps the keep highest-numbered branches of each
+// release family, sorted by name.
+func latestReleaseBranches(branches []string, keep int) []string {
+	families := map[string][]string{}
+	for _, b := range branches {
+		if m := reReleaseBranch.FindStringSubmatch(b); m != nil {
+			families[m[1]] = append(families[m[1]], b)
+		}
+	}
+	var out []string
+	for _, bs := range families {
+		// Numbers are zero-padded to four digits, so names sort numerically.
+		sort.Strings(bs)
+		if len(bs) > keep {
+			bs = bs[len(bs)-keep:]
+		}
+		out = append(out, bs...)
+	}
+	sort.Strings(out)
+	return out
+}
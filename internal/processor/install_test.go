@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+)
+
+func TestLatestReleaseBranches(t *testing.T) {
+	got := latestReleaseBranches([]string{
+		"devops-release/0003", "devops-release/0010", "devops-release/0001", "devops-release/0002", "web-release/0001",
+	}, 2)
+	want := []string{"devops-release/0003", "devops-release/0010", "web-release/0001"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestBootstrapLabels_CreatesMissingReleaseLabels(t *testing.T) {
+	refs := map[string]bool{"refs/heads/main": true, "refs/heads/feature/x": true}
+	for _, b := range []string{"0001", "0002", "0003", "0004", "0005", "0006"} {
+		refs["refs/heads/devops-release/"+b] = true
+	}
+	iss := &fakeIssuesFull{labels: []*github.Label{{Name: github.Ptr("cherry-pick to devops-release/0006")}}}
+	gh := fakeGH{iss: iss, git: &fakeGitFull{refs: refs}}
+
+	n, err := (&Processor{}).bootstrapLabels(context.Background(), gh, "o", "r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, l := range iss.created {
+		names = append(names, l.GetName())
+	}
+	want := []string{
+		"cherry-pick to devops-release/0002", "cherry-pick to devops-release/0003",
+		"cherry-pick to devops-release/0004", "cherry-pick to devops-release/0005",
+	}
+	if n != 4 || !slices.Equal(names, want) {
+		t.Fatalf("created %d: %v, want %v", n, names, want)
+	}
+}
+
+func TestBootstrapRepos_AppliesRetention(t *testing.T) {
+	var labels []*github.Label
+	for _, n := range []string{"0001", "0002", "0003", "0004", "0005", "0006"} {
+		labels = append(labels, &github.Label{Name: github.Ptr("cherry-pick to devops-release/" + n)})
+	}
+	iss := &fakeIssuesFull{labels: labels}
+	gh := fakeGH{pr: &fakePRFull{}, iss: iss, git: &fakeGitFull{refs: map[string]bool{}}}
+
+	(&Processor{}).bootstrapRepos(context.Background(), "d1", gh, []*github.Repository{{FullName: github.Ptr("o/r")}})
+	if !slices.Equal(iss.deleted, []string{"cherry-pick to devops-release/0001"}) {
+		t.Fatalf("deleted = %v", iss.deleted)
+	}
+}
+
+func TestBootstrapConfig(t *testing.T) {
+	starter := []byte("post_pick:\n  commands: [\"go mod tidy\"]\n")
+	p := &Processor{BootstrapConfig: starter}
+
+	repos := &fakeReposFull{}
+	if err := p.bootstrapConfig(context.Background(), fakeGH{repos: repos}, "o", "r"); err != nil {
+		t.Fatal(err)
+	}
+	if got := repos.files[repocfg.Path]; got != string(starter) {
+		t.Fatalf("created %q", got)
+	}
+
+	// An existing config (e.g. from a template) is kept.
+	repos = &fakeReposFull{files: map[string]string{repocfg.Path: "verify: {}\n"}}
+	if err := p.bootstrapConfig(context.Background(), fakeGH{repos: repos}, "o", "r"); err != nil {
+		t.Fatal(err)
+	}
+	if got := repos.files[repocfg.Path]; got != "verify: {}\n" {
+		t.Fatalf("overwrote config with %q", got)
+	}
+}
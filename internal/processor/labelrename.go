@@ -0,0 +1,132 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// labelEdit is the "changes" object of label edited events, which
+// github.LabelEvent does not model.
+type labelEdit struct {
+	Changes struct {
+		Name struct {
+			From string `json:"from"`
+		} `json:"name"`
+	} `json:"changes"`
+}
+
+// handleLabelRenamed follows a "cherry-pick to X" label renamed to
+// "cherry-pick to Y": the open backports onto X no longer match any label, so
+// they are closed (their work branches deleted), and their source PRs, which
+// now carry the new label, are picked onto Y when it is a branch. Renames to
+// anything else leave a comment on the source PRs instead.
+func (p *Processor) handleLabelRenamed(ctx context.Context, deliveryID string, e *github.LabelEvent, from string) {
+	to := e.GetLabel().GetName()
+	oldTarget, ok := strings.CutPrefix(from, "cherry-pick to ")
+	if !ok || from == to || e.GetRepo() == nil {
+		return
+	}
+	oldTarget = strings.TrimSpace(oldTarget)
+	owner, name := e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName()
+	inst := e.GetInstallation()
+	if inst == nil {
+		slog.Warn("label.no_installation", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name)
+		return
+	}
+	clients, err := p.buildClients(inst.GetID())
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	gh := p.ghFor(clients)
+
+	newTarget, ok := strings.CutPrefix(to, "cherry-pick to ")
+	newTarget = strings.TrimSpace(newTarget)
+	if ok && newTarget != "" {
+		if _, _, err := gh.Git().GetRef(ctx, owner, name, "refs/heads/"+newTarget); err != nil {
+			slog.Info("label.rename_unknown_branch", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name, "target", sanitizeForLog(newTarget))
+			newTarget = ""
+		}
+	} else {
+		newTarget = ""
+	}
+
+	sources, err := p.closeRenamedBackports(ctx, gh, owner, name, oldTarget, from, to)
+	if err != nil {
+		slog.Error("label.rename_cleanup_error", "delivery", sanitizeForLog(deliveryID), "label", sanitizeForLog(from), "err", safeErr(err))
+		return
+	}
+	slog.Info("label.renamed", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name,
+		"from", sanitizeForLog(from), "to", sanitizeForLog(to), "sources", sources)
+	if len(sources) == 0 {
+		return
+	}
+
+	if newTarget == "" {
+		for _, src := range sources {
+			_, _, _ = gh.Issues().CreateComment(ctx, owner, name, src, &github.IssueComment{Body: github.Ptr(fmt.Sprintf(
+				"ℹ️ The label `%s` was renamed to `%s`, which names no release branch, so the auto cherry-pick to `%s` was closed.",
+				from, to, oldTarget))})
+		}
+		return
+	}
+	token, ok := p.installationToken(ctx, deliveryID, inst.GetID())
+	if !ok {
+		return
+	}
+	for _, src := range sources {
+		p.processMergedPRWith(ctx, deliveryID, gh, owner, name, src, []string{newTarget}, token)
+	}
+}
+
+// closeRenamedBackports closes the open autocherry PRs onto target with a
+// comment, deletes their work branches, and returns their source PRs.
+func (p *Processor) closeRenamedBackports(ctx context.Context, gh GH, owner, repo, target, from, to string) ([]int, error) {
+	prefix := workBranchPrefix + strings.ReplaceAll(target, "/", "-") + "/"
+	prs, _, err := gh.PR().List(ctx, owner, repo, &github.PullRequestListOptions{
+		State:       pullRequestStateOpen,
+		Base:        target,
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list PRs onto %s: %w", target, err)
+	}
+	var sources []int
+	seen := map[int]bool{}
+	for _, pr := range prs {
+		head := pr.GetHead().GetRef()
+		if !strings.HasPrefix(head, prefix) {
+			continue
+		}
+		_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, pr.GetNumber(), &github.IssueComment{Body: github.Ptr(fmt.Sprintf(
+			"ℹ️ Closing: the label `%s` was renamed to `%s`.", from, to))})
+		_, _, _ = gh.PR().Edit(ctx, owner, repo, pr.GetNumber(), &github.PullRequest{State: github.Ptr("closed")})
+		_ = p.deleteWorkBranch(ctx, gh, owner, repo, head)
+		if src := p.sourcePR(ctx, owner, repo, pr); src != 0 && !seen[src] {
+			seen[src] = true
+			sources = append(sources, src)
+		}
+	}
+	return sources, nil
+}
+
+// sourcePR is the PR a backport was picked from: from the state store when
+// it tracks the work branch, else from the backport body (0 = none, e.g.
+// picks of bare commits).
+func (p *Processor) sourcePR(ctx context.Context, owner, repo string, backport *github.PullRequest) int {
+	if p.State != nil {
+		if rec, ok, err := p.State.Get(ctx, owner+"/"+repo, backport.GetHead().GetRef()); err == nil && ok && rec.SourcePR != 0 {
+			return rec.SourcePR
+		}
+	}
+	if m := reBodySourcePR.FindStringSubmatch(backport.GetBody()); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return 0
+}
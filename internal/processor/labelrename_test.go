@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func TestCloseRenamedBackports(t *testing.T) {
+	fpr := &fakePRFull{list: []*github.PullRequest{
+		{
+			Number: github.Ptr(31),
+			Body:   github.Ptr("Automated cherry-pick of PR #12 into `devops-release/0021`."),
+			Head:   &github.PullRequestBranch{Ref: github.Ptr("autocherry/devops-release-0021/abc1234")},
+		},
+		{Number: github.Ptr(32), Head: &github.PullRequestBranch{Ref: github.Ptr("fix/typo")}},
+	}}
+	iss := &fakeIssuesFull{}
+	gh := fakeGH{pr: fpr, iss: iss, git: &fakeGitFull{refs: map[string]bool{}}, repos: &fakeReposFull{}}
+
+	sources, err := (&Processor{}).closeRenamedBackports(context.Background(), gh, "o", "r",
+		"devops-release/0021", "cherry-pick to devops-release/0021", "cherry-pick to devops-release/0022")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(sources, []int{12}) {
+		t.Fatalf("sources = %v, want [12]", sources)
+	}
+	if len(fpr.edited) != 1 || fpr.edited[0].GetState() != "closed" {
+		t.Fatalf("edited = %v, want only #31 closed", fpr.edited)
+	}
+	if len(iss.comments) != 1 || !strings.Contains(iss.comments[0].GetBody(), "renamed to `cherry-pick to devops-release/0022`") {
+		t.Fatalf("comments = %v", iss.comments)
+	}
+}
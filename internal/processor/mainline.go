@@ -0,0 +1,54 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+)
+
+// mainlineFor picks the parent of merge commit rc (of pr) to cherry-pick
+// against: the one on the PR's base branch, so the pick replays the PR's
+// change rather than reversing it. Usually that is the first parent, but
+// merges made outside GitHub (e.g. `git merge main` on the PR branch,
+// pushed to the base) have it second. The repository's mainline setting
+// wins; otherwise a parent that is the PR's base commit is the mainline,
+// one that is its head is not, and failing both, the first parent the PR
+// head does not contain is. 1 when nothing tells.
+func mainlineFor(ctx context.Context, gh GH, owner, repo string, pr *github.PullRequest, rc *github.RepositoryCommit, cfg *repocfg.Config) int {
+	parents := rc.Parents
+	if n := cfg.Mainline; n > 0 {
+		if n <= len(parents) {
+			return n
+		}
+		slog.Warn("mainline.config_out_of_range", "repo", owner+"/"+repo, "sha", rc.GetSHA(), "mainline", n, "parents", len(parents))
+	}
+	base, head := pr.GetBase().GetSHA(), pr.GetHead().GetSHA()
+	for i, par := range parents {
+		if par.GetSHA() == base {
+			return i + 1
+		}
+	}
+	if len(parents) == 2 {
+		switch head {
+		case parents[0].GetSHA():
+			return 2
+		case parents[1].GetSHA():
+			return 1
+		}
+	}
+	for i, par := range parents {
+		cmp, _, err := gh.Repos().CompareCommits(ctx, owner, repo, par.GetSHA(), head, &github.ListOptions{PerPage: 1})
+		if err != nil {
+			slog.Warn("mainline.compare_error", "repo", owner+"/"+repo, "sha", rc.GetSHA(), "err", safeErr(err))
+			break
+		}
+		// The PR head contains its own side's parents.
+		if st := cmp.GetStatus(); st != "ahead" && st != "identical" {
+			return i + 1
+		}
+	}
+	return 1
+}
@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+)
+
+func TestMainlineFor(t *testing.T) {
+	merge := &github.RepositoryCommit{SHA: github.Ptr("m"), Parents: []*github.Commit{{SHA: github.Ptr("p1")}, {SHA: github.Ptr("p2")}}}
+	pr := func(base, head string) *github.PullRequest {
+		return &github.PullRequest{Base: &github.PullRequestBranch{SHA: github.Ptr(base)}, Head: &github.PullRequestBranch{SHA: github.Ptr(head)}}
+	}
+	cases := []struct {
+		name     string
+		pr       *github.PullRequest
+		cfg      repocfg.Config
+		compare  map[string]string // "parent...head" -> status
+		mainline int
+	}{
+		{name: "github merge", pr: pr("p1", "p2"), mainline: 1},
+		{name: "base is second parent", pr: pr("p2", "x"), mainline: 2},
+		{name: "head is first parent", pr: pr("x", "p1"), mainline: 2},
+		{name: "head contains first parent", pr: pr("x", "h"), compare: map[string]string{"p1...h": "ahead", "p2...h": "diverged"}, mainline: 2},
+		{name: "nothing tells", pr: pr("x", "h"), mainline: 1},
+		{name: "config wins", pr: pr("p1", "p2"), cfg: repocfg.Config{Mainline: 2}, mainline: 2},
+		{name: "config out of range", pr: pr("p2", "x"), cfg: repocfg.Config{Mainline: 3}, mainline: 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			frepos := &fakeReposFull{ranges: map[string]*github.CommitsComparison{}}
+			for k, st := range tc.compare {
+				frepos.ranges[k] = &github.CommitsComparison{Status: github.Ptr(st)}
+			}
+			if got := mainlineFor(context.Background(), fakeGH{repos: frepos}, "o", "r", tc.pr, merge, &tc.cfg); got != tc.mainline {
+				t.Fatalf("mainline = %d; want %d", got, tc.mainline)
+			}
+		})
+	}
+}
+
+func TestProcessMergedPR_ReversedMergeMainline(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply"}
+
+	// The PR head was merged into the base as the first parent.
+	pr := mergedPR(11, "Merge-y fix", "cafef00d1234567", "cherry-pick to devops-release/0021")
+	pr.Head = &github.PullRequestBranch{SHA: github.Ptr("prhead")}
+	fpr := &fakePRFull{prGet: pr}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	frepos := &fakeReposFull{commit: &github.RepositoryCommit{Parents: []*github.Commit{{SHA: github.Ptr("prhead")}, {SHA: github.Ptr("main")}}}}
+	gh := fakeGH{pr: fpr, iss: &fakeIssuesFull{}, git: fgit, repos: frepos}
+
+	var opts cherry.Options
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/cafef00", opts: &opts}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+	if opts.Mainline != 2 {
+		t.Fatalf("mainline = %d; want 2", opts.Mainline)
+	}
+}
@@ -0,0 +1,132 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// labelForwardPort marks issues tracking release-branch commits missing on the
+// default branch.
+const labelForwardPort = "forward-port needed"
+
+// cherryPickedMarker is the trailer `git cherry-pick -x` (and this app) leaves;
+// such commits came from another branch and need no forward-port.
+const cherryPickedMarker = "(cherry picked from commit "
+
+// handlePushEvent routes pushes to the default branch to trailer backports
+// (TrailerBackports) and pushes to release branches to the inverse of the
+// cherry-pick flow: commits pushed by a human directly to a release branch
+// that are not on the default branch get listed on a per-branch
+// "Forward-port needed" issue (created or updated).
+func (p *Processor) handlePushEvent(ctx context.Context, deliveryID string, e *github.PushEvent) {
+	branch, ok := strings.CutPrefix(e.GetRef(), "refs/heads/")
+	if !ok || e.GetDeleted() || e.GetRepo() == nil {
+		return
+	}
+	if p.TrailerBackports && branch == e.GetRepo().GetDefaultBranch() {
+		p.handleTrailerPush(ctx, deliveryID, e, branch)
+		return
+	}
+	if !reReleaseBranch.MatchString(branch) {
+		return
+	}
+	if s := e.GetSender(); s.GetType() == "Bot" || strings.HasSuffix(s.GetLogin(), "[bot]") {
+		slog.Debug("mergeback.skip_bot", "delivery", sanitizeForLog(deliveryID), "sender", s.GetLogin())
+		return
+	}
+	inst := e.GetInstallation()
+	if inst == nil {
+		slog.Warn("push.no_installation", "delivery", sanitizeForLog(deliveryID))
+		return
+	}
+	clients, err := p.buildClients(inst.GetID())
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	if !p.optionalEnabled(subsystemMergeBack) {
+		return
+	}
+	if err := p.detectMergeBack(ctx, p.ghFor(clients), e, branch); err != nil {
+		slog.Error("mergeback.error", "delivery", sanitizeForLog(deliveryID), "branch", branch, "err", safeErr(err))
+		p.optionalFailed(subsystemMergeBack, err)
+	}
+}
+
+func (p *Processor) detectMergeBack(ctx context.Context, gh GH, e *github.PushEvent, branch string) error {
+	repo := e.GetRepo()
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	if owner == "" {
+		owner = repo.GetOwner().GetName() // push payloads fill name, not always login
+	}
+	def := repo.GetDefaultBranch()
+	if def == "" {
+		def = "main"
+	}
+
+	var missing []*github.HeadCommit
+	for _, c := range e.Commits {
+		if c == nil || !c.GetDistinct() || strings.Contains(c.GetMessage(), cherryPickedMarker) {
+			continue
+		}
+		cmp, _, err := gh.Repos().CompareCommits(ctx, owner, name, def, c.GetID(), &github.ListOptions{PerPage: 1})
+		if err != nil {
+			return fmt.Errorf("compare %s...%s: %w", def, c.GetID(), err)
+		}
+		// behind/identical: the commit is already reachable from the default branch.
+		if st := cmp.GetStatus(); st == "behind" || st == "identical" {
+			continue
+		}
+		missing = append(missing, c)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var list strings.Builder
+	for _, c := range missing {
+		subject, _, _ := strings.Cut(c.GetMessage(), "\n")
+		fmt.Fprintf(&list, "- [ ] %s %s (@%s)\n", c.GetID(), sanitizeForLog(subject), e.GetSender().GetLogin())
+	}
+
+	title := fmt.Sprintf("Forward-port needed: %s → %s", branch, def)
+	issues, _, err := gh.Issues().ListByRepo(ctx, owner, name, &github.IssueListByRepoOptions{
+		State:       pullRequestStateOpen,
+		Labels:      []string{labelForwardPort},
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return fmt.Errorf("list forward-port issues: %w", err)
+	}
+	for _, is := range issues {
+		if is.GetTitle() == title && is.PullRequestLinks == nil {
+			_, _, err := gh.Issues().CreateComment(ctx, owner, name, is.GetNumber(), &github.IssueComment{
+				Body: github.Ptr(fmt.Sprintf("More commits pushed directly to `%s`:\n\n%s", branch, list.String())),
+			})
+			slog.Info("mergeback.issue_updated", "repo", owner+"/"+name, "issue", is.GetNumber(), "commits", len(missing))
+			return err
+		}
+	}
+
+	if err := p.ensureLabel(ctx, gh, owner, name, labelForwardPort); err != nil {
+		slog.Warn("labels.ensure_error", "label", labelForwardPort, "err", safeErr(err))
+	}
+	body := fmt.Sprintf(
+		"These commits were pushed directly to `%s` and are not on `%s`. "+
+			"Please forward-port them (e.g. `git cherry-pick -x <sha>` onto a branch from `%s`) or tick them off if not needed.\n\n%s",
+		branch, def, def, list.String())
+	is, _, err := gh.Issues().Create(ctx, owner, name, &github.IssueRequest{
+		Title:  github.Ptr(title),
+		Body:   github.Ptr(body),
+		Labels: &[]string{labelForwardPort},
+	})
+	if err != nil {
+		return fmt.Errorf("create forward-port issue: %w", err)
+	}
+	slog.Info("mergeback.issue_opened", "repo", owner+"/"+name, "issue", is.GetNumber(), "commits", len(missing))
+	return nil
+}
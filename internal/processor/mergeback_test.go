@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/githubapp"
+)
+
+func pushEvent(ref, sender, senderType string, commits ...*github.HeadCommit) *github.PushEvent {
+	return &github.PushEvent{
+		Ref:     github.Ptr(ref),
+		Commits: commits,
+		Sender:  &github.User{Login: github.Ptr(sender), Type: github.Ptr(senderType)},
+		Repo: &github.PushEventRepository{
+			Name:          github.Ptr("r"),
+			DefaultBranch: github.Ptr("main"),
+			Owner:         &github.User{Login: github.Ptr("o")},
+		},
+	}
+}
+
+func headCommit(id, msg string) *github.HeadCommit {
+	return &github.HeadCommit{ID: github.Ptr(id), Message: github.Ptr(msg), Distinct: github.Ptr(true)}
+}
+
+func TestDetectMergeBack_OpensIssueForMissingCommits(t *testing.T) {
+	p := &Processor{}
+	fiss := &fakeIssuesFull{}
+	gh := fakeGH{pr: &fakePRFull{}, iss: fiss, git: &fakeGitFull{}, repos: &fakeReposFull{compare: map[string]string{"bbb": "behind"}}}
+
+	e := pushEvent("refs/heads/devops-release/0021", "alice", "User",
+		headCommit("aaa", "Hotfix crash\n\ndetails"),
+		headCommit("bbb", "Already on main"),
+		headCommit("ccc", "Backport\n\n(cherry picked from commit 123abc)"),
+	)
+	if err := p.detectMergeBack(context.Background(), gh, e, "devops-release/0021"); err != nil {
+		t.Fatalf("detectMergeBack: %v", err)
+	}
+	if len(fiss.issues) != 1 {
+		t.Fatalf("issues created = %d, want 1", len(fiss.issues))
+	}
+	is := fiss.issues[0]
+	if is.GetTitle() != "Forward-port needed: devops-release/0021 → main" {
+		t.Fatalf("title = %q", is.GetTitle())
+	}
+	if b := is.GetBody(); !strings.Contains(b, "aaa Hotfix crash (@alice)") || strings.Contains(b, "bbb") || strings.Contains(b, "ccc") {
+		t.Fatalf("body should list only aaa: %q", b)
+	}
+	if len(fiss.created) != 1 || fiss.created[0].GetName() != labelForwardPort {
+		t.Fatalf("forward-port label not ensured: %+v", fiss.created)
+	}
+}
+
+func TestDetectMergeBack_CommentsOnExistingIssue(t *testing.T) {
+	p := &Processor{}
+	fiss := &fakeIssuesFull{listByRepo: []*github.Issue{{
+		Number: github.Ptr(42),
+		State:  github.Ptr("open"),
+		Title:  github.Ptr("Forward-port needed: devops-release/0021 → main"),
+		Labels: []*github.Label{{Name: github.Ptr(labelForwardPort)}},
+	}}}
+	gh := fakeGH{pr: &fakePRFull{}, iss: fiss, git: &fakeGitFull{}, repos: &fakeReposFull{}}
+
+	e := pushEvent("refs/heads/devops-release/0021", "alice", "User", headCommit("ddd", "Another fix"))
+	if err := p.detectMergeBack(context.Background(), gh, e, "devops-release/0021"); err != nil {
+		t.Fatalf("detectMergeBack: %v", err)
+	}
+	if len(fiss.issues) != 0 {
+		t.Fatalf("should not open a second issue")
+	}
+	if len(fiss.comments) != 1 || !strings.Contains(fiss.comments[0].GetBody(), "ddd Another fix") {
+		t.Fatalf("comments = %+v", fiss.comments)
+	}
+}
+
+func TestHandlePushEvent_IgnoresBotsAndOtherBranches(t *testing.T) {
+	built := 0
+	p := &Processor{NewClients: func(int64, int64, []byte) (*githubapp.Clients, error) {
+		built++
+		return nil, errors.New("should not be called")
+	}}
+	for _, e := range []*github.PushEvent{
+		pushEvent("refs/heads/main", "alice", "User", headCommit("a", "x")),
+		pushEvent("refs/heads/devops-release/0021", "cherry-bot[bot]", "Bot", headCommit("a", "x")),
+		pushEvent("refs/tags/devops-release/0021", "alice", "User", headCommit("a", "x")),
+	} {
+		e.Installation = &github.Installation{ID: github.Ptr(int64(1))}
+		p.handlePushEvent(context.Background(), "d", e)
+	}
+	if built != 0 {
+		t.Fatalf("clients built %d times for ignored pushes", built)
+	}
+}
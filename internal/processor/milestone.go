@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+)
+
+// setBackportMilestone puts backport PR number of src onto target in the
+// milestone the repository config maps target to, else src's own, so
+// release tracking counts backports. A mapped milestone that does not exist
+// (or is closed) is logged and skipped.
+func (p *Processor) setBackportMilestone(ctx context.Context, gh GH, owner, repo string, number int, src pickSource, target string, rc *repocfg.Config) {
+	n := src.milestone
+	if title := rc.MilestoneFor(target); title != "" {
+		if n = milestoneNumber(ctx, gh, owner, repo, title); n == 0 {
+			slog.Warn("milestone.not_found", "repo", owner+"/"+repo, "target", target, "milestone", title)
+			return
+		}
+	}
+	if n == 0 {
+		return
+	}
+	if _, _, err := gh.Issues().Edit(ctx, owner, repo, number, &github.IssueRequest{Milestone: github.Ptr(n)}); err != nil {
+		slog.Warn("gh.set_milestone_error", "repo", owner+"/"+repo, "pr", number, "milestone", n, "err", safeErr(err))
+	}
+}
+
+// milestoneNumber finds the open milestone titled title (0 = none).
+func milestoneNumber(ctx context.Context, gh GH, owner, repo, title string) int {
+	opts := &github.MilestoneListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		ms, resp, err := gh.Issues().ListMilestones(ctx, owner, repo, opts)
+		if err != nil {
+			slog.Warn("gh.list_milestones_error", "repo", owner+"/"+repo, "err", safeErr(err))
+			return 0
+		}
+		for _, m := range ms {
+			if m.GetTitle() == title {
+				return m.GetNumber()
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return 0
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,44 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func TestProcessMergedPR_CopiesMilestone(t *testing.T) {
+	cases := []struct {
+		name      string
+		config    string // .github/cherry-pick.yml
+		milestone int    // set on the backport PR (0 = none)
+	}{
+		{name: "source milestone", milestone: 7},
+		{name: "mapped milestone", config: "milestones:\n  \"devops-release/*\": \"0021.x\"\n", milestone: 21},
+		{name: "mapped milestone missing", config: "milestones:\n  \"devops-release/*\": \"0022.x\"\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", CopyMilestone: true}
+			pr := mergedPR(11, "Fix", "cafef00d1234567", "cherry-pick to devops-release/0021")
+			pr.Milestone = &github.Milestone{Number: github.Ptr(7), Title: github.Ptr("v2.0")}
+			fiss := &fakeIssuesFull{milestones: []*github.Milestone{{Number: github.Ptr(21), Title: github.Ptr("0021.x")}}}
+			frepos := &fakeReposFull{files: map[string]string{}}
+			if tc.config != "" {
+				frepos.files[".github/cherry-pick.yml"] = tc.config
+			}
+			gh := fakeGH{pr: &fakePRFull{prGet: pr}, iss: fiss, git: &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}, repos: frepos}
+			p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/cafef00"}
+
+			p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+			got := 0
+			if len(fiss.editedIss) == 1 {
+				got = fiss.editedIss[0].GetMilestone()
+			}
+			if got != tc.milestone || len(fiss.editedIss) > 1 {
+				t.Fatalf("milestone edits = %+v; want milestone %d", fiss.editedIss, tc.milestone)
+			}
+		})
+	}
+}
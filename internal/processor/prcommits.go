@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// maxPRCommits is what the PR commits API returns at most; longer PRs are
+// picked as their merge commit.
+const maxPRCommits = 250
+
+// reCoAuthor matches Co-authored-by trailers ("Name <email>").
+var reCoAuthor = regexp.MustCompile(`(?mi)^Co-authored-by:[ \t]*(.+<[^<>\s]+>)[ \t]*\r?$`)
+
+// listPRCommits returns PR prNum's commits, oldest first.
+func listPRCommits(ctx context.Context, gh GH, owner, repo string, prNum int) ([]*github.RepositoryCommit, error) {
+	var out []*github.RepositoryCommit
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		commits, resp, err := gh.PR().ListCommits(ctx, owner, repo, prNum, opts)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, commits...)
+		if resp == nil || resp.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// prCommits returns the SHAs of PR prNum's own commits, oldest first, for
+// picking one by one (PickPRCommits). It returns nil, meaning "pick the merge
+// commit", when the list cannot be read, may be truncated, or contains merge
+// commits (e.g. the default branch merged into the PR), which cannot be
+// replayed individually.
+func prCommits(ctx context.Context, commits []*github.RepositoryCommit, owner, repo string, prNum int) []string {
+	var shas []string
+	for _, c := range commits {
+		if len(c.Parents) > 1 {
+			slog.Info("pr.commits_has_merge", "repo", owner+"/"+repo, "pr", prNum, "sha", c.GetSHA())
+			return nil
+		}
+		shas = append(shas, c.GetSHA())
+	}
+	if len(shas) >= maxPRCommits {
+		return nil
+	}
+	return shas
+}
+
+// prAuthors returns the git author ("Name <email>") of a PR's first commit and
+// everyone else who wrote or co-authored its commits, for crediting them on
+// the backport (CreditAuthors) rather than whoever merged it.
+func prAuthors(commits []*github.RepositoryCommit) (author string, coAuthors []string) {
+	add := func(who string) {
+		if who != "" && !strings.EqualFold(who, author) && !slices.ContainsFunc(coAuthors, func(c string) bool { return strings.EqualFold(c, who) }) {
+			coAuthors = append(coAuthors, who)
+		}
+	}
+	for _, c := range commits {
+		a := c.GetCommit().GetAuthor()
+		who := ""
+		if a.GetName() != "" && a.GetEmail() != "" {
+			who = fmt.Sprintf("%s <%s>", a.GetName(), a.GetEmail())
+		}
+		if author == "" {
+			author = who
+		} else {
+			add(who)
+		}
+		for _, m := range reCoAuthor.FindAllStringSubmatch(c.GetCommit().GetMessage(), -1) {
+			add(strings.TrimSpace(m[1]))
+		}
+	}
+	return author, coAuthors
+}
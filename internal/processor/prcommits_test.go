@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+)
+
+func TestPRCommits(t *testing.T) {
+	commit := func(sha string, parents int) *github.RepositoryCommit {
+		c := &github.RepositoryCommit{SHA: github.Ptr(sha)}
+		for range parents {
+			c.Parents = append(c.Parents, &github.Commit{})
+		}
+		return c
+	}
+	cases := []struct {
+		name    string
+		commits []*github.RepositoryCommit
+		want    []string
+	}{
+		{name: "linear", commits: []*github.RepositoryCommit{commit("c1", 1), commit("c2", 1)}, want: []string{"c1", "c2"}},
+		{name: "contains a merge", commits: []*github.RepositoryCommit{commit("c1", 1), commit("m", 2)}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := prCommits(context.Background(), c.commits, "o", "r", 7); !slices.Equal(got, c.want) {
+				t.Fatalf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestPRAuthors(t *testing.T) {
+	commit := func(name, email, msg string) *github.RepositoryCommit {
+		return &github.RepositoryCommit{Commit: &github.Commit{
+			Author: &github.CommitAuthor{Name: github.Ptr(name), Email: github.Ptr(email)}, Message: github.Ptr(msg),
+		}}
+	}
+	author, co := prAuthors([]*github.RepositoryCommit{
+		commit("Bob", "bob@example.com", "Add retry"),
+		commit("Carol", "carol@example.com", "Fix test\n\nCo-authored-by: Dave <dave@example.com>\nCo-authored-by: bob <BOB@example.com>"),
+		commit("Bob", "bob@example.com", "Review fixes"),
+	})
+	if author != "Bob <bob@example.com>" {
+		t.Fatalf("author = %q", author)
+	}
+	if want := []string{"Carol <carol@example.com>", "Dave <dave@example.com>"}; !slices.Equal(co, want) {
+		t.Fatalf("co-authors = %v, want %v", co, want)
+	}
+}
+
+func TestProcessMergedPR_CreditsPRAuthorOnMergeCommit(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", CreditAuthors: true}
+
+	pr := mergedPR(7, "Fix bug", "abc123456789", "cherry-pick to devops-release/0021")
+	fpr := &fakePRFull{prGet: pr, commits: []*github.RepositoryCommit{
+		{Commit: &github.Commit{Author: &github.CommitAuthor{Name: github.Ptr("Bob"), Email: github.Ptr("bob@example.com")}}},
+		{Commit: &github.Commit{Author: &github.CommitAuthor{Name: github.Ptr("Carol"), Email: github.Ptr("carol@example.com")}}},
+	}}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	frepos := &fakeReposFull{commit: repoCommitWithParents(2)}
+	gh := fakeGH{pr: fpr, iss: &fakeIssuesFull{}, git: fgit, repos: frepos}
+	var opts cherry.Options
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234", opts: &opts}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 7, nil, "tok")
+
+	if opts.Author != "Bob <bob@example.com>" || !slices.Equal(opts.CoAuthors, []string{"Carol <carol@example.com>"}) {
+		t.Fatalf("author %q, co-authors %v", opts.Author, opts.CoAuthors)
+	}
+
+	// A squash commit is the PR author's already; only co-authors are added.
+	frepos.commit = repoCommitWithParents(1)
+	fgit.refs = map[string]bool{"refs/heads/devops-release/0021": true}
+	fpr.newPR = nil
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 7, nil, "tok")
+	if opts.Author != "" || len(opts.CoAuthors) != 1 {
+		t.Fatalf("squash: author %q, co-authors %v", opts.Author, opts.CoAuthors)
+	}
+}
+
+func TestProcessMergedPR_PicksPRCommits(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", PickPRCommits: true}
+
+	pr := mergedPR(7, "Fix bug", "abc123456789", "cherry-pick to devops-release/0021")
+	fpr := &fakePRFull{prGet: pr, commits: []*github.RepositoryCommit{
+		{SHA: github.Ptr("c1"), Parents: []*github.Commit{{}}},
+		{SHA: github.Ptr("c2"), Parents: []*github.Commit{{}}},
+	}}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	gh := fakeGH{pr: fpr, iss: &fakeIssuesFull{}, git: fgit, repos: &fakeReposFull{}}
+	var opts cherry.Options
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234", opts: &opts}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 7, nil, "tok")
+
+	if !slices.Equal(opts.Commits, []string{"c1", "c2"}) || opts.CommitsRef != "refs/pull/7/head" {
+		t.Fatalf("commits = %v from %q", opts.Commits, opts.CommitsRef)
+	}
+	if fpr.newPR == nil || !strings.Contains(fpr.newPR.GetBody(), "its 2 commits picked one by one") {
+		t.Fatalf("expected the PR body to say so, got %+v", fpr.newPR)
+	}
+}
@@ -0,0 +1,132 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
+)
+
+// prLockPoll is how often a waiter retries a distributed PR lock held by
+// another replica.
+const prLockPoll = 500 * time.Millisecond
+
+var prLockContended = metrics.Default.Counter("pr_lock_contended_total",
+	"Events that waited for another event on the same pull request, by scope (local, distributed).")
+
+// keyedMutex serializes work per key within this process. The zero value is
+// ready to use; idle keys are dropped.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	token chan struct{} // capacity 1; holding the token is holding the lock
+	refs  int           // holders and waiters
+}
+
+// lock waits for key until ctx is done. It reports whether it had to wait
+// and whether the lock was taken; unlock must be called exactly once when ok.
+func (k *keyedMutex) lock(ctx context.Context, key string) (unlock func(), waited, ok bool) {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = map[string]*keyedLock{}
+	}
+	l := k.locks[key]
+	if l == nil {
+		l = &keyedLock{token: make(chan struct{}, 1)}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	select {
+	case l.token <- struct{}{}:
+	default:
+		waited = true
+		select {
+		case l.token <- struct{}{}:
+		case <-ctx.Done():
+			k.release(key, l)
+			return nil, true, false
+		}
+	}
+	return func() {
+		<-l.token
+		k.release(key, l)
+	}, waited, true
+}
+
+func (k *keyedMutex) release(key string, l *keyedLock) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l.refs--
+	if l.refs == 0 {
+		delete(k.locks, key)
+	}
+}
+
+// lockPR serializes events for one pull request (e.g. "closed" and
+// "labeled" arriving together), so they don't both push the same work
+// branch. The lock is always held in-process and, with PRLockStore, also as a
+// lease shared by all replicas. It returns false if ctx ends while waiting.
+// A failing lease store is logged and skipped: the PR idempotency checks
+// still apply, so falling back to the local lock beats dropping the event.
+func (p *Processor) lockPR(ctx context.Context, deliveryID, owner, repo string, num int) (func(), bool) {
+	key := fmt.Sprintf("%s/%s#%d", owner, repo, num)
+	return p.lockShared(ctx, deliveryID, &p.prLocks, "prlock/", key, func(scope string) { prLockContended.Inc("scope", scope) })
+}
+
+// lockShared takes key in local and, with PRLockStore, the lease prefix+key
+// held by this replica for deliveryID, as for lockPR. contended is called
+// once per scope ("local", "distributed") that had to wait.
+func (p *Processor) lockShared(
+	ctx context.Context, deliveryID string, local *keyedMutex, prefix, key string, contended func(scope string),
+) (func(), bool) {
+	unlock, waited, ok := local.lock(ctx, key)
+	if !ok {
+		return nil, false
+	}
+	if waited {
+		contended("local")
+	}
+	if p.PRLockStore == nil {
+		return unlock, true
+	}
+
+	lease, holder := prefix+key, p.PRLockOwner+"/"+deliveryID
+	ttl := p.cherryTimeout() + 30*time.Second // outlive the work it guards
+	t := time.NewTicker(prLockPoll)
+	defer t.Stop()
+	for first := true; ; first = false {
+		got, err := p.PRLockStore.Acquire(ctx, lease, holder, ttl)
+		if err != nil {
+			slog.Warn("lock.lease_error", "delivery", sanitizeForLog(deliveryID), "lease", lease, "err", safeErr(err))
+			return unlock, true
+		}
+		if got {
+			break
+		}
+		if first {
+			contended("distributed")
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			unlock()
+			return nil, false
+		}
+	}
+	return func() {
+		rctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := p.PRLockStore.Release(rctx, lease, holder); err != nil {
+			slog.Warn("lock.release_error", "delivery", sanitizeForLog(deliveryID), "lease", lease, "err", safeErr(err))
+		}
+		unlock()
+	}, true
+}
@@ -0,0 +1,101 @@
+package processor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/shard"
+)
+
+func TestKeyedMutex_SerializesPerKey(t *testing.T) {
+	var k keyedMutex
+	ctx := context.Background()
+
+	unlock, waited, ok := k.lock(ctx, "o/r#1")
+	if !ok || waited {
+		t.Fatalf("first lock: ok=%v waited=%v", ok, waited)
+	}
+	// Another key is independent.
+	other, waited, ok := k.lock(ctx, "o/r#2")
+	if !ok || waited {
+		t.Fatalf("other key: ok=%v waited=%v", ok, waited)
+	}
+	other()
+
+	got := make(chan bool)
+	go func() {
+		u, waited, ok := k.lock(ctx, "o/r#1")
+		if ok {
+			u()
+		}
+		got <- ok && waited
+	}()
+	select {
+	case <-got:
+		t.Fatal("second lock on the same key did not wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	if !<-got {
+		t.Fatal("second lock should succeed after waiting")
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.locks) != 0 {
+		t.Fatalf("idle keys kept: %v", k.locks)
+	}
+}
+
+func TestKeyedMutex_GivesUpWithContext(t *testing.T) {
+	var k keyedMutex
+	unlock, _, _ := k.lock(context.Background(), "o/r#1")
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, ok := k.lock(ctx, "o/r#1"); ok {
+		t.Fatal("lock should fail once ctx is done")
+	}
+}
+
+func TestLockPR_SerializesAcrossReplicas(t *testing.T) {
+	leases := &shard.MemoryLeases{}
+	a := &Processor{PRLockStore: leases, PRLockOwner: "a"}
+	b := &Processor{PRLockStore: leases, PRLockOwner: "b"}
+	ctx := context.Background()
+
+	unlockA, ok := a.lockPR(ctx, "d1", "o", "r", 7)
+	if !ok {
+		t.Fatal("replica a should take the lock")
+	}
+
+	var mu sync.Mutex
+	order := []string{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		unlockB, ok := b.lockPR(ctx, "d2", "o", "r", 7)
+		if !ok {
+			t.Error("replica b should take the lock after a releases")
+			return
+		}
+		mu.Lock()
+		order = append(order, "b")
+		mu.Unlock()
+		unlockB()
+	}()
+
+	time.Sleep(2 * prLockPoll)
+	mu.Lock()
+	order = append(order, "a")
+	mu.Unlock()
+	unlockA()
+	<-done
+
+	if len(order) != 2 || order[0] != "a" || order[1] != "b" {
+		t.Fatalf("order = %v, want [a b]", order)
+	}
+}
@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+const addProjectItemMutation = `mutation($project: ID!, $content: ID!) {
+  addProjectV2ItemById(input: {projectId: $project, contentId: $content}) { item { id } }
+}`
+
+const projectStatusFieldQuery = `query($project: ID!) {
+  node(id: $project) { ... on ProjectV2 { field(name: "Status") { ... on ProjectV2SingleSelectField { id options { id name } } } } }
+}`
+
+const setProjectStatusMutation = `mutation($project: ID!, $item: ID!, $field: ID!, $option: String!) {
+  updateProjectV2ItemFieldValue(input: {projectId: $project, itemId: $item, fieldId: $field, value: {singleSelectOptionId: $option}}) {
+    projectV2Item { id }
+  }
+}`
+
+// addToProject adds a backport PR to the ProjectID board and, with
+// ProjectStatus, puts it in that column of the board's Status field.
+// Failures are logged only: the board is a convenience.
+func (p *Processor) addToProject(ctx context.Context, gh GH, owner, repo string, pr *github.PullRequest) {
+	if err := p.addProjectItem(ctx, gh, pr.GetNodeID()); err != nil {
+		slog.Warn("gh.project_error", "repo", owner+"/"+repo, "pr", pr.GetNumber(), "err", safeErr(err))
+		return
+	}
+	slog.Info("gh.project_added", "repo", owner+"/"+repo, "pr", pr.GetNumber())
+}
+
+func (p *Processor) addProjectItem(ctx context.Context, gh GH, content string) error {
+	var added struct {
+		AddProjectV2ItemByID struct {
+			Item struct {
+				ID string `json:"id"`
+			} `json:"item"`
+		} `json:"addProjectV2ItemById"`
+	}
+	if err := gh.GraphQL().Do(ctx, addProjectItemMutation, map[string]any{"project": p.ProjectID, "content": content}, &added); err != nil {
+		return fmt.Errorf("add to project: %w", err)
+	}
+	item := added.AddProjectV2ItemByID.Item.ID
+	if p.ProjectStatus == "" || item == "" {
+		return nil
+	}
+
+	var fields struct {
+		Node struct {
+			Field struct {
+				ID      string `json:"id"`
+				Options []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"options"`
+			} `json:"field"`
+		} `json:"node"`
+	}
+	if err := gh.GraphQL().Do(ctx, projectStatusFieldQuery, map[string]any{"project": p.ProjectID}, &fields); err != nil {
+		return fmt.Errorf("get project Status field: %w", err)
+	}
+	for _, o := range fields.Node.Field.Options {
+		if !strings.EqualFold(o.Name, p.ProjectStatus) {
+			continue
+		}
+		vars := map[string]any{"project": p.ProjectID, "item": item, "field": fields.Node.Field.ID, "option": o.ID}
+		if err := gh.GraphQL().Do(ctx, setProjectStatusMutation, vars, nil); err != nil {
+			return fmt.Errorf("set project status: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("project has no Status option %q", p.ProjectStatus)
+}
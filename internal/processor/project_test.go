@@ -0,0 +1,47 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestProcessMergedPR_AddsToProject(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", ProjectID: "PVT_1", ProjectStatus: "todo"}
+	gql := &fakeGraphQL{data: []string{
+		`{"addProjectV2ItemById": {"item": {"id": "PVTI_1"}}}`,
+		`{"node": {"field": {"id": "PVTSSF_1", "options": [{"id": "OPT_done", "name": "Done"}, {"id": "OPT_todo", "name": "Todo"}]}}}`,
+	}}
+	gh := fakeGH{
+		pr:    &fakePRFull{prGet: mergedPR(11, "Fix", "cafef00d1234567", "cherry-pick to devops-release/0021")},
+		iss:   &fakeIssuesFull{},
+		git:   &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}},
+		repos: &fakeReposFull{},
+		gql:   gql,
+	}
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/cafef00"}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+	if len(gql.queries) != 3 {
+		t.Fatalf("graphql calls = %d; want 3", len(gql.queries))
+	}
+	if !strings.Contains(gql.queries[0], "addProjectV2ItemById") || gql.vars[0]["project"] != "PVT_1" || gql.vars[0]["content"] != "PR_100" {
+		t.Fatalf("add = %v", gql.vars[0])
+	}
+	if v := gql.vars[2]; !strings.Contains(gql.queries[2], "updateProjectV2ItemFieldValue") ||
+		v["item"] != "PVTI_1" || v["field"] != "PVTSSF_1" || v["option"] != "OPT_todo" {
+		t.Fatalf("set status = %v", v)
+	}
+}
+
+func TestAddProjectItem_UnknownStatus(t *testing.T) {
+	p := &Processor{ProjectID: "PVT_1", ProjectStatus: "Backlog"}
+	gql := &fakeGraphQL{data: []string{
+		`{"addProjectV2ItemById": {"item": {"id": "PVTI_1"}}}`,
+		`{"node": {"field": {"id": "PVTSSF_1", "options": [{"id": "OPT_todo", "name": "Todo"}]}}}`,
+	}}
+	if err := p.addProjectItem(context.Background(), fakeGH{gql: gql}, "PR_1"); err == nil || !strings.Contains(err.Error(), `"Backlog"`) {
+		t.Fatalf("err = %v; want unknown Status option", err)
+	}
+}
@@ -0,0 +1,67 @@
+package processor
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+)
+
+// maxPRTitle is GitHub's limit on pull request titles.
+const maxPRTitle = 256
+
+// prTemplateData is what backport PR title and body templates see.
+type prTemplateData struct {
+	PR       int    // source PR number (0 for bare commits)
+	Title    string // source PR title, or the commit's subject
+	Author   string // source author login
+	Target   string
+	SHA      string
+	ShortSHA string
+	What     string   // "PR #12", "commit `abc1234`", ...
+	Labels   []string // source PR labels
+}
+
+// prText renders the backport PR title and body intro for src onto target
+// from the repository's pr templates (RepoPRTemplates), else
+// PRTitleTemplate and PRBodyTemplate, falling back to title and body when
+// unset or broken. Notes about how the pick went follow the body anyway.
+func (p *Processor) prText(src pickSource, target string, rc *repocfg.Config, title, body string) (string, string) {
+	tt, bt := p.PRTitleTemplate, p.PRBodyTemplate
+	if p.RepoPRTemplates && rc.PR.Title != "" {
+		tt = rc.PR.Title
+	}
+	if p.RepoPRTemplates && rc.PR.Body != "" {
+		bt = rc.PR.Body
+	}
+	data := prTemplateData{
+		PR: src.patchPR, Title: src.subject, Author: src.author, Target: target,
+		SHA: src.sha, ShortSHA: src.sha[:min(7, len(src.sha))], What: src.what, Labels: src.labels,
+	}
+	title = strings.Join(strings.Fields(renderPR("pr.title", tt, data, title)), " ")
+	if len(title) > maxPRTitle {
+		title = strings.ToValidUTF8(title[:maxPRTitle-3], "") + "..."
+	}
+	return title, renderPR("pr.body", bt, data, body)
+}
+
+// renderPR executes template text on data; def when text is unset, broken
+// or renders to nothing.
+func renderPR(name, text string, data prTemplateData, def string) string {
+	if text == "" {
+		return def
+	}
+	t, err := repocfg.ParseTemplate(name, text)
+	var b strings.Builder
+	if err == nil {
+		err = t.Execute(&b, data)
+	}
+	if err != nil {
+		slog.Warn("pr.template_error", "template", name, "err", safeErr(err))
+		return def
+	}
+	if strings.TrimSpace(b.String()) == "" {
+		return def
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,75 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+)
+
+func TestPRText(t *testing.T) {
+	src := pickSource{
+		sha: "cafef00d1234567", patchPR: 11, subject: "Fix the\nthing", author: "alice",
+		what: "PR #11", labels: []string{"bug", "security"},
+	}
+	cases := []struct {
+		name        string
+		p           *Processor
+		rc          repocfg.Config
+		title, body string
+	}{
+		{name: "defaults", p: &Processor{}, title: "def title", body: "def body"},
+		{
+			name:  "global",
+			p:     &Processor{PRTitleTemplate: "[{{.Target}}] {{.Title}} (#{{.PR}})", PRBodyTemplate: "Backport of {{.What}} by @{{.Author}} ({{join .Labels \", \"}}), {{.ShortSHA}}"},
+			title: "[release/1] Fix the thing (#11)",
+			body:  "Backport of PR #11 by @alice (bug, security), cafef00",
+		},
+		{
+			name:  "repo overrides",
+			p:     &Processor{PRTitleTemplate: "global", RepoPRTemplates: true},
+			rc:    repocfg.Config{PR: repocfg.PRTemplate{Title: "{{.Target}}: {{.Title}}"}},
+			title: "release/1: Fix the thing",
+			body:  "def body",
+		},
+		{
+			name:  "repo overrides off",
+			p:     &Processor{PRTitleTemplate: "global"},
+			rc:    repocfg.Config{PR: repocfg.PRTemplate{Title: "{{.Target}}"}},
+			title: "global",
+			body:  "def body",
+		},
+		{name: "broken", p: &Processor{PRTitleTemplate: "{{.Nope}}", PRBodyTemplate: "{{if}}"}, title: "def title", body: "def body"},
+		{name: "empty result", p: &Processor{PRTitleTemplate: "{{if false}}x{{end}}"}, title: "def title", body: "def body"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			title, body := tc.p.prText(src, "release/1", &tc.rc, "def title", "def body")
+			if title != tc.title || body != tc.body {
+				t.Fatalf("got %q / %q; want %q / %q", title, body, tc.title, tc.body)
+			}
+		})
+	}
+
+	long, _ := (&Processor{PRTitleTemplate: strings.Repeat("é", 200)}).prText(src, "release/1", &repocfg.Config{}, "", "")
+	if len(long) > maxPRTitle || !strings.HasSuffix(long, "...") {
+		t.Fatalf("long title = %d bytes: %q", len(long), long)
+	}
+}
+
+func TestProcessMergedPR_TemplatedPR(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", PRTitleTemplate: "[{{.Target}}] {{.Title}}", PRBodyTemplate: "Backport of #{{.PR}}."}
+	fpr := &fakePRFull{prGet: mergedPR(11, "Fix", "cafef00d1234567", "cherry-pick to devops-release/0021")}
+	gh := fakeGH{pr: fpr, iss: &fakeIssuesFull{}, git: &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}, repos: &fakeReposFull{}}
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/cafef00"}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+	if got := fpr.newPR.GetTitle(); got != "[devops-release/0021] Fix" {
+		t.Fatalf("title = %q", got)
+	}
+	if got := fpr.newPR.GetBody(); !strings.HasPrefix(got, "Backport of #11.\n\nBackport of #11") {
+		t.Fatalf("body = %q", got)
+	}
+}
@@ -0,0 +1,107 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+)
+
+// GitHub reaction contents used to acknowledge slash commands and labels.
+// GitHub has no ✅/❌ reactions, so 🚀/😕 stand in for them.
+const (
+	reactionReceived = "eyes"
+	reactionSuccess  = "rocket"
+	reactionFailure  = "confused"
+)
+
+// commandAck tracks the 👀 reaction placed on a command comment (or, for
+// label events, on the PR itself) so it can be swapped for a final outcome
+// once the (slow) processing completes.
+type commandAck struct {
+	p          *Processor
+	gh         GH
+	owner      string
+	repo       string
+	commentID  int64
+	issue      int // set instead of commentID for reactions on the PR
+	reactionID int64
+}
+
+// prAckKey marks contexts whose PR processing acknowledges on the PR.
+type prAckKey struct{}
+
+// withPRAck makes processMergedPRWith react on the PR it picks, for events
+// (labels, merges) that have no comment to react on.
+func withPRAck(ctx context.Context) context.Context {
+	return context.WithValue(ctx, prAckKey{}, true)
+}
+
+// ackCommand reacts with 👀 on the comment right away. Failures are logged
+// and never block command processing.
+func (p *Processor) ackCommand(ctx context.Context, gh GH, owner, repo string, commentID int64) *commandAck {
+	a := &commandAck{p: p, gh: gh, owner: owner, repo: repo, commentID: commentID}
+	if commentID == 0 || !p.optionalEnabled(subsystemReactions) {
+		return a
+	}
+	r, _, err := gh.Reactions().CreateIssueCommentReaction(ctx, owner, repo, commentID, reactionReceived)
+	if err != nil {
+		slog.Warn("gh.reaction_error", "comment", commentID, "content", reactionReceived, "err", safeErr(err))
+		p.optionalFailed(subsystemReactions, err)
+		return a
+	}
+	a.reactionID = r.GetID()
+	return a
+}
+
+// ackPR reacts with 👀 on PR issue when ctx asks for it (see withPRAck); the
+// returned ack does nothing otherwise.
+func (p *Processor) ackPR(ctx context.Context, gh GH, owner, repo string, issue int) *commandAck {
+	if ctx.Value(prAckKey{}) == nil {
+		return nil
+	}
+	a := &commandAck{p: p, gh: gh, owner: owner, repo: repo, issue: issue}
+	if !p.optionalEnabled(subsystemReactions) {
+		return a
+	}
+	r, _, err := gh.Reactions().CreateIssueReaction(ctx, owner, repo, issue, reactionReceived)
+	if err != nil {
+		slog.Warn("gh.reaction_error", "issue", issue, "content", reactionReceived, "err", safeErr(err))
+		p.optionalFailed(subsystemReactions, err)
+		return a
+	}
+	a.reactionID = r.GetID()
+	return a
+}
+
+// done replaces the 👀 reaction with the final outcome (best-effort).
+func (a *commandAck) done(ctx context.Context, success bool) {
+	if a == nil || (a.commentID == 0 && a.issue == 0) || !a.p.optionalEnabled(subsystemReactions) {
+		return
+	}
+	reactions := a.gh.Reactions()
+	if a.reactionID != 0 {
+		var err error
+		if a.commentID != 0 {
+			_, err = reactions.DeleteIssueCommentReaction(ctx, a.owner, a.repo, a.commentID, a.reactionID)
+		} else {
+			_, err = reactions.DeleteIssueReaction(ctx, a.owner, a.repo, a.issue, a.reactionID)
+		}
+		if err != nil {
+			slog.Warn("gh.reaction_delete_error", "comment", a.commentID, "issue", a.issue, "err", safeErr(err))
+			a.p.optionalFailed(subsystemReactions, err)
+		}
+	}
+	content := reactionSuccess
+	if !success {
+		content = reactionFailure
+	}
+	var err error
+	if a.commentID != 0 {
+		_, _, err = reactions.CreateIssueCommentReaction(ctx, a.owner, a.repo, a.commentID, content)
+	} else {
+		_, _, err = reactions.CreateIssueReaction(ctx, a.owner, a.repo, a.issue, content)
+	}
+	if err != nil {
+		slog.Warn("gh.reaction_error", "comment", a.commentID, "issue", a.issue, "content", content, "err", safeErr(err))
+		a.p.optionalFailed(subsystemReactions, err)
+	}
+}
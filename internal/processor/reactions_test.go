@@ -0,0 +1,87 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCommandAck_SwapsEyesForOutcome(t *testing.T) {
+	cases := []struct {
+		name    string
+		success bool
+		want    string
+	}{
+		{name: "success", success: true, want: reactionSuccess},
+		{name: "failure", success: false, want: reactionFailure},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fr := &fakeReactions{}
+			gh := fakeGH{react: fr}
+			p := &Processor{}
+
+			a := p.ackCommand(context.Background(), gh, "o", "r", 42)
+			if len(fr.created) != 1 || fr.created[0] != reactionReceived {
+				t.Fatalf("expected immediate eyes reaction, got %v", fr.created)
+			}
+			a.done(context.Background(), tc.success)
+
+			if len(fr.deleted) != 1 || fr.deleted[0] != 1 {
+				t.Fatalf("expected eyes reaction (id=1) to be removed, got %v", fr.deleted)
+			}
+			if len(fr.created) != 2 || fr.created[1] != tc.want {
+				t.Fatalf("expected final reaction %q, got %v", tc.want, fr.created)
+			}
+		})
+	}
+}
+
+func TestCommandAck_NoComment_NoCalls(t *testing.T) {
+	fr := &fakeReactions{}
+	p := &Processor{}
+	a := p.ackCommand(context.Background(), fakeGH{react: fr}, "o", "r", 0)
+	a.done(context.Background(), true)
+	if len(fr.created) != 0 || len(fr.deleted) != 0 {
+		t.Fatalf("expected no reaction calls without a comment id, got created=%v deleted=%v", fr.created, fr.deleted)
+	}
+}
+
+func TestProcessMergedPR_ReactsOnPR(t *testing.T) {
+	cases := []struct {
+		name    string
+		ctx     context.Context
+		missing bool // a target branch that does not exist
+		want    string
+	}{
+		{name: "picked", ctx: withPRAck(context.Background()), want: "eyes,rocket"},
+		{name: "target missing", ctx: withPRAck(context.Background()), missing: true, want: "eyes,confused"},
+		{name: "not asked", ctx: context.Background()},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			labels := []string{"cherry-pick to devops-release/0021"}
+			if tc.missing {
+				labels = append(labels, "cherry-pick to devops-release/0099")
+			}
+			fr := &fakeReactions{}
+			gh := fakeGH{
+				pr:    &fakePRFull{prGet: mergedPR(11, "Fix", "cafef00d1234567", labels...)},
+				iss:   &fakeIssuesFull{},
+				git:   &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}},
+				repos: &fakeReposFull{},
+				react: fr,
+			}
+			p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", CherryRunner: fakeCherry{workBranch: "autocherry/devops-release-0021/cafef00"}}
+
+			p.processMergedPRWith(tc.ctx, "d", gh, "o", "r", 11, nil, "tok")
+
+			if got := strings.Join(fr.created, ","); got != tc.want {
+				t.Fatalf("reactions = %q; want %q", got, tc.want)
+			}
+			if tc.want != "" && (len(fr.onIssue) != 2 || fr.onIssue[0] != 11 || len(fr.deleted) != 1) {
+				t.Fatalf("issue reactions on %v, deleted %v", fr.onIssue, fr.deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// handleReleaseTag cuts the release branch for a tag matching
+// ReleaseTagPattern, then labels it like any new release branch, so a
+// release needs only its tag before backports can target it.
+func (p *Processor) handleReleaseTag(ctx context.Context, deliveryID string, e *github.CreateEvent) {
+	tag := e.GetRef()
+	branch, ok := p.releaseTagBranch(tag)
+	if !ok {
+		slog.Debug("create.ignore_tag", "delivery", sanitizeForLog(deliveryID), "tag", sanitizeForLog(tag))
+		return
+	}
+	owner, name := e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName()
+	if !reReleaseBranch.MatchString(branch) {
+		slog.Warn("release_tag.bad_branch", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name,
+			"tag", sanitizeForLog(tag), "branch", sanitizeForLog(branch))
+		return
+	}
+
+	inst := e.GetInstallation()
+	if inst == nil {
+		slog.Warn("create.no_installation", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name)
+		return
+	}
+	clients, err := p.buildClients(inst.GetID())
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	gh := p.ghFor(clients)
+
+	created, err := p.cutReleaseBranch(ctx, gh, owner, name, tag, branch)
+	if err != nil {
+		slog.Error("release_tag.branch_error", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name, "branch", branch, "err", safeErr(err))
+		return
+	}
+	slog.Info("release_tag.branch", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name,
+		"tag", sanitizeForLog(tag), "branch", branch, "created", created)
+	// The branch's own create event does the same; this does not wait for it.
+	p.releaseBranchCreated(ctx, deliveryID, gh, owner, name, branch)
+}
+
+// releaseTagBranch names the release branch for tag; false when the tag does
+// not match ReleaseTagPattern.
+func (p *Processor) releaseTagBranch(tag string) (string, bool) {
+	m := p.ReleaseTagPattern.FindStringSubmatchIndex(tag)
+	if m == nil {
+		return "", false
+	}
+	return string(p.ReleaseTagPattern.ExpandString(nil, p.ReleaseTagBranch, tag, m)), true
+}
+
+// cutReleaseBranch creates branch at the commit tag points to, reporting
+// whether it was created; an existing branch is left alone.
+func (p *Processor) cutReleaseBranch(ctx context.Context, gh GH, owner, repo, tag, branch string) (bool, error) {
+	if _, _, err := gh.Git().GetRef(ctx, owner, repo, "refs/heads/"+branch); err == nil {
+		return false, nil
+	} else if !isNotFound(err) {
+		return false, fmt.Errorf("get ref %s: %w", branch, err)
+	}
+	// The commits API peels annotated tags to their commit.
+	rc, _, err := gh.Repos().GetCommit(ctx, owner, repo, "refs/tags/"+tag, nil)
+	if err != nil {
+		return false, fmt.Errorf("get commit of tag %s: %w", tag, err)
+	}
+	if _, _, err := gh.Git().CreateRef(ctx, owner, repo, github.CreateRef{Ref: "refs/heads/" + branch, SHA: rc.GetSHA()}); err != nil {
+		return false, fmt.Errorf("create branch %s: %w", branch, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,45 @@
+package processor
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func TestReleaseTagBranch(t *testing.T) {
+	p := &Processor{ReleaseTagPattern: regexp.MustCompile(`^(?P<team>[a-z]+)-v(\d{4})\.0$`), ReleaseTagBranch: "${team}-release/$2"}
+	cases := map[string]string{
+		"devops-v0031.0": "devops-release/0031",
+		"devops-v0031.1": "", // patch releases reuse the branch
+		"v1.28.0":        "",
+	}
+	for tag, want := range cases {
+		got, ok := p.releaseTagBranch(tag)
+		if got != want || ok != (want != "") {
+			t.Errorf("releaseTagBranch(%q) = %q, %v; want %q", tag, got, ok, want)
+		}
+	}
+}
+
+func TestCutReleaseBranch(t *testing.T) {
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0030": true}}
+	frepos := &fakeReposFull{commit: &github.RepositoryCommit{SHA: github.Ptr("c0ffee0123")}}
+	gh := fakeGH{git: fgit, repos: frepos}
+	p := &Processor{}
+
+	created, err := p.cutReleaseBranch(context.Background(), gh, "o", "r", "devops-v0031.0", "devops-release/0031")
+	if err != nil || !created {
+		t.Fatalf("created = %v, err = %v", created, err)
+	}
+	if len(fgit.createdRefs) != 1 || fgit.createdRefs[0] != (github.CreateRef{Ref: "refs/heads/devops-release/0031", SHA: "c0ffee0123"}) {
+		t.Fatalf("created refs = %+v", fgit.createdRefs)
+	}
+
+	// An existing branch is left alone.
+	created, err = p.cutReleaseBranch(context.Background(), gh, "o", "r", "devops-v0030.0", "devops-release/0030")
+	if err != nil || created || len(fgit.createdRefs) != 1 {
+		t.Fatalf("created = %v, err = %v, refs = %+v", created, err, fgit.createdRefs)
+	}
+}
@@ -0,0 +1,136 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+)
+
+// maxHookOutputInBody keeps a post-pick hook's output readable in the PR body;
+// GitHub rejects bodies over 65536 characters.
+const maxHookOutputInBody = 4000
+
+// loadRepoConfig fetches .github/cherry-pick.yml from the default branch.
+// A missing file is an empty config; a broken one is reported on the PR and
+// ignored so the pick still happens (without hooks).
+func (p *Processor) loadRepoConfig(ctx context.Context, gh GH, owner, repo string, prNum int) *repocfg.Config {
+	fc, _, _, err := gh.Repos().GetContents(ctx, owner, repo, repocfg.Path, nil)
+	if err != nil {
+		if !isNotFound(err) {
+			slog.Warn("repocfg.fetch_error", "repo", owner+"/"+repo, "err", safeErr(err))
+		}
+		return &repocfg.Config{}
+	}
+	raw, err := fc.GetContent()
+	if err != nil {
+		slog.Warn("repocfg.decode_error", "repo", owner+"/"+repo, "err", safeErr(err))
+		return &repocfg.Config{}
+	}
+	cfg, err := repocfg.Parse([]byte(raw))
+	if err != nil && prNum == 0 {
+		slog.Warn("repocfg.invalid", "repo", owner+"/"+repo, "err", safeErr(err))
+		return &repocfg.Config{}
+	}
+	if err != nil {
+		_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, prNum, &github.IssueComment{
+			Body: github.Ptr(fmt.Sprintf("⚠️ Ignoring invalid `%s`: %v", repocfg.Path, err)),
+		})
+		return &repocfg.Config{}
+	}
+	return cfg
+}
+
+// needsRepoConfig reports whether picks use settings from the repository
+// config, so it is worth fetching.
+func (p *Processor) needsRepoConfig() bool {
+	return p.PostPickHooks || p.PickVerify || p.PrePushChecks || p.PickStrategies || p.ForkToken != "" || p.CopyMilestone || p.RepoDrafts || p.RepoPRTemplates
+}
+
+// forkFor maps the repo's fork setting onto cherry.Fork (nil = none, or no
+// ForkToken to push with).
+func (p *Processor) forkFor(cfg *repocfg.Config) *cherry.Fork {
+	owner, repo, ok := strings.Cut(cfg.Fork, "/")
+	if !ok || p.ForkToken == "" {
+		return nil
+	}
+	return &cherry.Fork{Owner: owner, Repo: repo, Token: p.ForkToken}
+}
+
+// strategyFor maps the repo's pick_strategy for target onto cherry.Strategy.
+func strategyFor(cfg *repocfg.Config, target string) cherry.Strategy {
+	s := cfg.PickStrategy.StrategyFor(target)
+	return cherry.Strategy{Name: s.Strategy, Option: s.Option, FindRenames: s.FindRenames}
+}
+
+// hooksFor maps the repo's post_pick section onto cherry.Hooks.
+func hooksFor(cfg *repocfg.Config) cherry.Hooks {
+	return cherry.Hooks{
+		Commands: cfg.PostPick.Commands,
+		Timeout:  cfg.PostPick.Timeout,
+		Env:      cfg.PostPick.Env,
+	}
+}
+
+// verifyFor maps the repo's verify (or pre_push) section onto cherry.Verify.
+func verifyFor(v repocfg.Verify) cherry.Verify {
+	return cherry.Verify{
+		Command: v.Command,
+		Timeout: v.Timeout,
+		Env:     v.Env,
+	}
+}
+
+// verifyReport renders the verification outcome for the PR body.
+func verifyReport(v *cherry.VerifyRun) string {
+	if v == nil {
+		return ""
+	}
+	cmd := strings.ReplaceAll(v.Command, "`", "'")
+	switch {
+	case v.Skipped:
+		return fmt.Sprintf("\n\n### Verification\n⏭️ `%s` skipped: no relevant files changed.", cmd)
+	case v.Passed:
+		return fmt.Sprintf("\n\n### Verification\n✅ `%s` passed (%s).", cmd, v.Duration)
+	}
+	return fmt.Sprintf("\n\n### Verification\n> [!WARNING]\n> `%s` failed (%s): the pick applied cleanly but may not build on this branch.\n\n"+
+		"<details><summary>Output</summary>\n\n```\n%s\n```\n</details>\n", cmd, v.Duration, hookOutput(v.Output))
+}
+
+// prePushReport renders a failed pre-push check for the failure comment.
+func prePushReport(v *cherry.VerifyRun) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("\n\nThe pre-push check `%s` failed (%s), so nothing was pushed:\n\n<details><summary>Output</summary>\n\n```\n%s\n```\n</details>",
+		strings.ReplaceAll(v.Command, "`", "'"), v.Duration, hookOutput(v.Output))
+}
+
+// hookOutput trims command output to what fits a comment or PR body, with
+// code fences neutralized.
+func hookOutput(out string) string {
+	out = strings.TrimSpace(out)
+	if len(out) > maxHookOutputInBody {
+		out = "…\n" + out[len(out)-maxHookOutputInBody:]
+	}
+	return strings.ReplaceAll(out, "```", "` ` `")
+}
+
+// hookReport renders post-pick hook output as collapsible PR body sections.
+func hookReport(runs []cherry.HookRun) string {
+	if len(runs) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n### Post-pick hooks\n")
+	for _, r := range runs {
+		fmt.Fprintf(&sb, "\n<details><summary><code>%s</code> (%s)</summary>\n\n```\n%s\n```\n</details>\n",
+			strings.ReplaceAll(r.Command, "<", "&lt;"), r.Duration, hookOutput(r.Output))
+	}
+	return sb.String()
+}
@@ -0,0 +1,177 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+)
+
+func TestProcessMergedPR_PostPickHooks(t *testing.T) {
+	cfgFile := "post_pick:\n  commands:\n    - make generate\n"
+	cases := []struct {
+		name      string
+		enabled   bool
+		files     map[string]string
+		wantHooks []string
+		wantWarn  bool
+	}{
+		{name: "disabled ignores config", files: map[string]string{repocfg.Path: cfgFile}},
+		{name: "enabled passes commands", enabled: true, files: map[string]string{repocfg.Path: cfgFile}, wantHooks: []string{"make generate"}},
+		{name: "enabled without config", enabled: true},
+		{name: "invalid config warns", enabled: true, files: map[string]string{repocfg.Path: "bogus: 1\n"}, wantWarn: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", PostPickHooks: tc.enabled}
+			pr := mergedPR(11, "Codegen", "abc123456789", "cherry-pick to devops-release/0021")
+			fpr := &fakePRFull{prGet: pr}
+			fiss := &fakeIssuesFull{}
+			fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+			gh := fakeGH{pr: fpr, iss: fiss, git: fgit, repos: &fakeReposFull{files: tc.files}}
+
+			var opts cherry.Options
+			runs := []cherry.HookRun{{Command: "make generate", Output: "generated 3 files\n", Duration: 1500 * time.Millisecond}}
+			p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234", hookRuns: runs, opts: &opts}
+
+			p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+			if strings.Join(opts.Hooks.Commands, ",") != strings.Join(tc.wantHooks, ",") {
+				t.Fatalf("hooks = %v, want %v", opts.Hooks.Commands, tc.wantHooks)
+			}
+			warned := false
+			for _, c := range fiss.comments {
+				if strings.Contains(c.GetBody(), "Ignoring invalid") {
+					warned = true
+				}
+			}
+			if warned != tc.wantWarn {
+				t.Fatalf("invalid-config warning = %v, want %v", warned, tc.wantWarn)
+			}
+			if fpr.newPR == nil || !strings.Contains(fpr.newPR.GetBody(), "generated 3 files") {
+				t.Fatalf("PR body missing hook output: %v", fpr.newPR)
+			}
+		})
+	}
+}
+
+func TestHookReport(t *testing.T) {
+	if got := hookReport(nil); got != "" {
+		t.Fatalf("empty runs should render nothing, got %q", got)
+	}
+	long := strings.Repeat("x", maxHookOutputInBody+100)
+	got := hookReport([]cherry.HookRun{{Command: "gen <all>", Output: "```" + long, Duration: time.Second}})
+	if !strings.Contains(got, "<code>gen &lt;all></code> (1s)") {
+		t.Fatalf("summary not rendered/escaped: %q", got[:120])
+	}
+	if strings.Count(got, "```") != 2 {
+		t.Fatalf("output fence not neutralised")
+	}
+	if len(got) > maxHookOutputInBody+200 {
+		t.Fatalf("output not truncated: %d bytes", len(got))
+	}
+}
+
+func TestProcessMergedPR_VerifyReportedInBody(t *testing.T) {
+	cfgFile := "verify:\n  command: go build {packages}\n"
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", PickVerify: true}
+	pr := mergedPR(11, "Refactor", "abc123456789", "cherry-pick to devops-release/0021")
+	fpr := &fakePRFull{prGet: pr}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	gh := fakeGH{pr: fpr, iss: &fakeIssuesFull{}, git: fgit, repos: &fakeReposFull{files: map[string]string{repocfg.Path: cfgFile}}}
+
+	var opts cherry.Options
+	run := &cherry.VerifyRun{HookRun: cherry.HookRun{Command: "go build ./pkg/a", Output: "undefined: Foo\n", Duration: time.Second}}
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234", verify: run, opts: &opts}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+	if opts.Verify.Command != "go build {packages}" || len(opts.Hooks.Commands) != 0 {
+		t.Fatalf("opts = %+v", opts)
+	}
+	body := fpr.newPR.GetBody()
+	if !strings.Contains(body, "`go build ./pkg/a` failed") || !strings.Contains(body, "undefined: Foo") {
+		t.Fatalf("PR body missing verification failure:\n%s", body)
+	}
+}
+
+func TestVerifyReport(t *testing.T) {
+	if verifyReport(nil) != "" {
+		t.Fatalf("nil run should render nothing")
+	}
+	ok := verifyReport(&cherry.VerifyRun{HookRun: cherry.HookRun{Command: "make", Duration: time.Second}, Passed: true})
+	if !strings.Contains(ok, "✅ `make` passed") {
+		t.Fatalf("passed report = %q", ok)
+	}
+	skipped := verifyReport(&cherry.VerifyRun{HookRun: cherry.HookRun{Command: "go build {packages}"}, Skipped: true})
+	if !strings.Contains(skipped, "skipped") {
+		t.Fatalf("skipped report = %q", skipped)
+	}
+}
+
+func TestProcessMergedPR_PrePushFailureComment(t *testing.T) {
+	cfgFile := "pre_push:\n  command: make verify\n"
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", PrePushChecks: true}
+	pr := mergedPR(11, "Refactor", "abc123456789", "cherry-pick to devops-release/0021")
+	fpr := &fakePRFull{prGet: pr}
+	fiss := &fakeIssuesFull{}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	gh := fakeGH{pr: fpr, iss: fiss, git: fgit, repos: &fakeReposFull{files: map[string]string{repocfg.Path: cfgFile}}}
+
+	var opts cherry.Options
+	run := &cherry.VerifyRun{HookRun: cherry.HookRun{Command: "make verify", Output: "undefined: Foo\n", Duration: time.Second}}
+	p.CherryRunner = fakeCherry{err: fmt.Errorf("%w: make verify", cherry.ErrPrePushFailed), prePush: run, opts: &opts}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+	if opts.PrePush.Command != "make verify" {
+		t.Fatalf("opts = %+v", opts)
+	}
+	if fpr.createdPR != nil {
+		t.Fatalf("no PR expected after a failed pre-push check")
+	}
+	got := fiss.comments[len(fiss.comments)-1].GetBody()
+	if !strings.Contains(got, "`make verify` failed") || !strings.Contains(got, "undefined: Foo") {
+		t.Fatalf("failure comment missing the check output:\n%s", got)
+	}
+}
+
+func TestProcessMergedPR_Drafts(t *testing.T) {
+	cases := []struct {
+		name   string
+		p      func(p *Processor)
+		config string
+		draft  bool
+	}{
+		{name: "default", p: func(p *Processor) {}, config: "drafts: [\"devops-release/*\"]\n"},
+		{name: "all drafts", p: func(p *Processor) { p.DraftPRs = true }, draft: true},
+		{name: "repo target", p: func(p *Processor) { p.RepoDrafts = true }, config: "drafts: [\"devops-release/*\"]\n", draft: true},
+		{name: "repo other target", p: func(p *Processor) { p.RepoDrafts = true }, config: "drafts: [release/*]\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply"}
+			tc.p(p)
+			fpr := &fakePRFull{prGet: mergedPR(11, "Fix", "cafef00d1234567", "cherry-pick to devops-release/0021")}
+			fiss := &fakeIssuesFull{}
+			gh := fakeGH{
+				pr: fpr, iss: fiss, git: &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}},
+				repos: &fakeReposFull{files: map[string]string{".github/cherry-pick.yml": tc.config}},
+			}
+			p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/cafef00"}
+
+			p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+			if fpr.newPR == nil || fpr.newPR.GetDraft() != tc.draft {
+				t.Fatalf("new PR = %+v; want draft %v", fpr.newPR, tc.draft)
+			}
+			if last := fiss.comments[len(fiss.comments)-1].GetBody(); strings.Contains(last, "a draft") != tc.draft {
+				t.Fatalf("comment = %q", last)
+			}
+		})
+	}
+}
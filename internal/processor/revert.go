@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+var (
+	// reRevertsPR matches the body GitHub's Revert button writes: "Reverts o/r#12".
+	reRevertsPR = regexp.MustCompile(`(?m)^Reverts ([\w.-]+/[\w.-]+)?#(\d+)\b`)
+	// reRevertsCommit matches git revert's message: "This reverts commit <sha>."
+	reRevertsCommit = regexp.MustCompile(`This reverts commit ([0-9a-f]{7,40})`)
+)
+
+// revertTargets returns, when pr reverts a merged PR, the targets that got a
+// merged backport of it, so release branches do not keep a change that was
+// taken back. Without RevertBackports, or for backports, it returns nil.
+func (p *Processor) revertTargets(ctx context.Context, gh GH, owner, repo string, pr *github.PullRequest) []string {
+	if !p.RevertBackports || reWorkBranch.MatchString(pr.GetHead().GetRef()) {
+		return nil
+	}
+	orig := revertedPR(ctx, gh, owner, repo, pr)
+	if orig == nil || orig.GetMergeCommitSHA() == "" {
+		return nil
+	}
+	candidates := p.prTargets(orig)
+	for _, t := range p.mergeCommitTargets(ctx, gh, owner, repo, orig) {
+		if !slices.Contains(candidates, t) {
+			candidates = append(candidates, t)
+		}
+	}
+	var targets []string
+	for _, t := range candidates {
+		if mergedBackport(ctx, gh, owner, repo, t, orig.GetMergeCommitSHA()) {
+			targets = append(targets, t)
+		}
+	}
+	slog.Info("pr.revert", "repo", owner+"/"+repo, "pr", pr.GetNumber(), "reverts", orig.GetNumber(), "targets", targets)
+	return targets
+}
+
+// revertedPR returns the merged PR that pr reverts, named by a "Reverts #N"
+// line or, for a `Revert "..."` title, by the commit its body says it reverts.
+func revertedPR(ctx context.Context, gh GH, owner, repo string, pr *github.PullRequest) *github.PullRequest {
+	if m := reRevertsPR.FindStringSubmatch(pr.GetBody()); m != nil {
+		if m[1] != "" && !strings.EqualFold(m[1], owner+"/"+repo) {
+			return nil
+		}
+		n, _ := strconv.Atoi(m[2])
+		orig, _, err := gh.PR().Get(ctx, owner, repo, n)
+		if err != nil || !orig.GetMerged() {
+			return nil
+		}
+		return orig
+	}
+	m := reRevertsCommit.FindStringSubmatch(pr.GetBody())
+	if !strings.HasPrefix(pr.GetTitle(), `Revert "`) || m == nil {
+		return nil
+	}
+	prs, _, err := gh.PR().ListPullRequestsWithCommit(ctx, owner, repo, m[1], &github.ListOptions{PerPage: 10})
+	if err != nil {
+		slog.Warn("pr.revert_lookup_error", "repo", owner+"/"+repo, "sha", m[1], "err", safeErr(err))
+		return nil
+	}
+	for _, c := range prs {
+		if c.GetNumber() != pr.GetNumber() && c.MergedAt != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// mergedBackport reports whether the work branch picking sha onto target had
+// a PR merged into it.
+func mergedBackport(ctx context.Context, gh GH, owner, repo, target, sha string) bool {
+	short := sha
+	if len(short) > 7 {
+		short = sha[:7]
+	}
+	workBranch := fmt.Sprintf("autocherry/%s/%s", strings.ReplaceAll(target, "/", "-"), short)
+	prs, _, err := gh.PR().List(ctx, owner, repo, &github.PullRequestListOptions{
+		State:       pullRequestStateClosed,
+		Head:        owner + ":" + workBranch,
+		Base:        target,
+		ListOptions: github.ListOptions{PerPage: 10},
+	})
+	if err != nil {
+		slog.Warn("pr.revert_backports_error", "repo", owner+"/"+repo, "work_branch", workBranch, "err", safeErr(err))
+		return false
+	}
+	return slices.ContainsFunc(prs, func(pr *github.PullRequest) bool { return pr.MergedAt != nil })
+}
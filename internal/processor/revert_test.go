@@ -0,0 +1,101 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func TestRevertTargets(t *testing.T) {
+	orig := mergedPR(12, "Fix flaky login", "abc1234def", "cherry-pick to devops-release/0021", "cherry-pick to devops-release/0022")
+	backport := func(branch, base string, merged bool) *github.PullRequest {
+		pr := &github.PullRequest{
+			State: github.Ptr("closed"),
+			Head:  &github.PullRequestBranch{Ref: github.Ptr(branch)},
+			Base:  &github.PullRequestBranch{Ref: github.Ptr(base)},
+		}
+		if merged {
+			pr.MergedAt = &github.Timestamp{}
+		}
+		return pr
+	}
+	// Only 0021 got its backport merged; the 0022 one was closed.
+	list := []*github.PullRequest{
+		backport("autocherry/devops-release-0021/abc1234", "devops-release/0021", true),
+		backport("autocherry/devops-release-0022/abc1234", "devops-release/0022", false),
+	}
+
+	cases := []struct {
+		name    string
+		off     bool
+		revert  *github.PullRequest
+		commits map[string][]*github.PullRequest
+		want    []string
+	}{
+		{
+			name:   "revert button body",
+			revert: &github.PullRequest{Number: github.Ptr(20), Title: github.Ptr(`Revert "Fix flaky login"`), Body: github.Ptr("Reverts o/r#12")},
+			want:   []string{"devops-release/0021"},
+		},
+		{
+			name:    "git revert message",
+			revert:  &github.PullRequest{Number: github.Ptr(20), Title: github.Ptr(`Revert "Fix flaky login"`), Body: github.Ptr("This reverts commit abc1234def.")},
+			commits: map[string][]*github.PullRequest{"abc1234def": {{Number: github.Ptr(12), MergedAt: &github.Timestamp{}, MergeCommitSHA: orig.MergeCommitSHA, Labels: orig.Labels}}},
+			want:    []string{"devops-release/0021"},
+		},
+		{
+			name:   "other repository",
+			revert: &github.PullRequest{Number: github.Ptr(20), Body: github.Ptr("Reverts other/repo#12")},
+		},
+		{
+			name:   "not a revert",
+			revert: &github.PullRequest{Number: github.Ptr(20), Title: github.Ptr("Fix more"), Body: github.Ptr("This reverts commit abc1234def.")},
+		},
+		{
+			name:   "backport of a revert",
+			revert: &github.PullRequest{Number: github.Ptr(21), Body: github.Ptr("Reverts o/r#12"), Head: &github.PullRequestBranch{Ref: github.Ptr("autocherry/devops-release-0021/fff0000")}},
+		},
+		{
+			name:   "disabled",
+			off:    true,
+			revert: &github.PullRequest{Number: github.Ptr(20), Body: github.Ptr("Reverts #12")},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := &Processor{RevertBackports: !c.off}
+			fpr := &fakePRFull{byNumber: map[int]*github.PullRequest{12: orig}, list: list, withCommit: c.commits}
+			got := p.revertTargets(context.Background(), fakeGH{pr: fpr}, "o", "r", c.revert)
+			if !slices.Equal(got, c.want) {
+				t.Fatalf("targets = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestProcessMergedPR_RevertFollowsBackports(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", RevertBackports: true}
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/fff0000"}
+
+	revert := mergedPR(20, `Revert "Fix flaky login"`, "fff0000111")
+	revert.Body = github.Ptr("Reverts o/r#12")
+	fpr := &fakePRFull{
+		prGet:    revert,
+		byNumber: map[int]*github.PullRequest{12: mergedPR(12, "Fix flaky login", "abc1234def", "cherry-pick to devops-release/0021")},
+		list: []*github.PullRequest{{
+			State:    github.Ptr("closed"),
+			MergedAt: &github.Timestamp{},
+			Head:     &github.PullRequestBranch{Ref: github.Ptr("autocherry/devops-release-0021/abc1234")},
+		}},
+	}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	gh := fakeGH{pr: fpr, iss: &fakeIssuesFull{}, git: fgit, repos: &fakeReposFull{}}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 20, nil, "tok")
+
+	if fpr.newPR == nil || fpr.newPR.GetBase() != "devops-release/0021" || fpr.newPR.GetTitle() == "" {
+		t.Fatalf("expected the revert picked onto devops-release/0021, got %+v", fpr.newPR)
+	}
+}
@@ -0,0 +1,221 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/metrics"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+// ScheduledTask is a maintenance trigger placed on the ingest queue by a
+// scheduler (e.g. an EventBridge Scheduler schedule with an SQS target and
+// this as its input), so periodic jobs need no in-process tickers:
+//
+//	{"scheduled_task": {"name": "janitor", "installation_id": 123, "repos": ["owner/name"]}}
+type ScheduledTask struct {
+	Name           string            `json:"name"`
+	InstallationID int64             `json:"installation_id"`
+	Repos          []string          `json:"repos"`
+	Args           map[string]string `json:"args,omitempty"`
+}
+
+// taskFunc runs a scheduled task against one repository.
+type taskFunc func(p *Processor, ctx context.Context, gh GH, owner, repo string, args map[string]string) error
+
+// scheduledTasks are the tasks a schedule can name.
+var scheduledTasks = map[string]taskFunc{
+	"janitor":   (*Processor).taskJanitor,
+	"reconcile": (*Processor).taskReconcile,
+	"sla_scan":  (*Processor).taskSLAScan,
+}
+
+// defaultSLA is how long a cherry-pick PR may stay open before sla_scan
+// reports it (args: max_age, a Go duration).
+const defaultSLA = 72 * time.Hour
+
+var (
+	scheduledRuns = metrics.Default.Counter("scheduled_task_runs_total",
+		"Scheduled task runs per repository, by task and result (ok, error).")
+	overduePRs = metrics.Default.Gauge("backport_prs_overdue",
+		"Open cherry-pick PRs older than the sla_scan max_age, per repository.")
+)
+
+// decodeScheduledTask validates a scheduled_task payload; errors are 400s.
+func decodeScheduledTask(body []byte) (*ScheduledTask, error) {
+	var wrapper struct {
+		Task *ScheduledTask `json:"scheduled_task"`
+	}
+	if err := json.Unmarshal(body, &wrapper); err != nil {
+		return nil, fmt.Errorf("bad scheduled task: %w", err)
+	}
+	t := wrapper.Task
+	switch {
+	case t == nil:
+		return nil, fmt.Errorf("bad scheduled task: missing scheduled_task")
+	case scheduledTasks[t.Name] == nil:
+		names := make([]string, 0, len(scheduledTasks))
+		for n := range scheduledTasks {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown scheduled task %q (available: %s)", t.Name, strings.Join(names, ", "))
+	case t.InstallationID <= 0 || len(t.Repos) == 0:
+		return nil, fmt.Errorf("scheduled task %q: installation_id and repos are required", t.Name)
+	}
+	for _, r := range t.Repos {
+		if o, n, ok := strings.Cut(r, "/"); !ok || o == "" || n == "" {
+			return nil, fmt.Errorf("scheduled task %q: repo %q is not owner/name", t.Name, r)
+		}
+	}
+	return t, nil
+}
+
+// handleScheduledTask validates the trigger and runs it in the background like
+// any other event.
+func (p *Processor) handleScheduledTask(body []byte, deliveryID string, sync bool) (int, error) {
+	t, err := decodeScheduledTask(body)
+	if err != nil {
+		slog.Error("scheduled.bad_task", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return http.StatusBadRequest, err
+	}
+	p.runWork(sync, deliveryID, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		clients, err := p.buildClients(t.InstallationID)
+		if err != nil {
+			slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+			return
+		}
+		p.runScheduledTask(ctx, p.ghFor(clients), t)
+	})
+	return http.StatusAccepted, nil
+}
+
+// runScheduledTask runs t for every repo; one failing repo does not stop the rest.
+func (p *Processor) runScheduledTask(ctx context.Context, gh GH, t *ScheduledTask) {
+	fn := scheduledTasks[t.Name]
+	for _, full := range t.Repos {
+		owner, repo, _ := strings.Cut(full, "/")
+		start := time.Now()
+		if err := fn(p, ctx, gh, owner, repo, t.Args); err != nil {
+			scheduledRuns.Inc("task", t.Name, "result", "error")
+			slog.Error("scheduled.task_error", "task", t.Name, "repo", full, "err", safeErr(err))
+			continue
+		}
+		scheduledRuns.Inc("task", t.Name, "result", "ok")
+		slog.Info("scheduled.task_done", "task", t.Name, "repo", full, "duration", time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// taskJanitor deletes autocherry/* work branches whose PR was merged or
+// closed. Branches without any PR are left alone: they may be mid-flight.
+// Deletion goes through the cleanup guard (deleteWorkBranch).
+func (p *Processor) taskJanitor(ctx context.Context, gh GH, owner, repo string, _ map[string]string) error {
+	refs, _, err := gh.Git().ListMatchingRefs(ctx, owner, repo, &github.ReferenceListOptions{
+		Ref:         "heads/" + workBranchPrefix,
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return fmt.Errorf("list work branches: %w", err)
+	}
+	deleted := 0
+	for _, ref := range refs {
+		branch := strings.TrimPrefix(ref.GetRef(), "refs/heads/")
+		prs, _, err := gh.PR().List(ctx, owner, repo, &github.PullRequestListOptions{
+			State:       "all",
+			Head:        owner + ":" + branch,
+			ListOptions: github.ListOptions{PerPage: 10},
+		})
+		if err != nil {
+			return fmt.Errorf("list PRs for %s: %w", branch, err)
+		}
+		if len(prs) == 0 || slices.ContainsFunc(prs, func(pr *github.PullRequest) bool { return pr.GetState() == pullRequestStateOpen }) {
+			continue
+		}
+		if err := p.deleteWorkBranch(ctx, gh, owner, repo, branch); err != nil {
+			if errors.Is(err, errNotOurBranch) {
+				continue
+			}
+			return err
+		}
+		deleted++
+	}
+	slog.Info("janitor.done", "repo", owner+"/"+repo, "branches", len(refs), "deleted", deleted)
+	return nil
+}
+
+// taskReconcile refreshes open state-store records from their PRs, catching
+// merges and closes whose events were missed.
+func (p *Processor) taskReconcile(ctx context.Context, gh GH, owner, repo string, _ map[string]string) error {
+	if p.State == nil {
+		return fmt.Errorf("reconcile: no state store configured")
+	}
+	recs, err := p.State.List(ctx, owner+"/"+repo)
+	if err != nil {
+		return err
+	}
+	for _, r := range recs {
+		if r.Status != state.StatusOpen || r.PRNumber == 0 {
+			continue
+		}
+		pr, _, err := gh.PR().Get(ctx, owner, repo, r.PRNumber)
+		if err != nil {
+			return fmt.Errorf("get PR #%d: %w", r.PRNumber, err)
+		}
+		switch {
+		case pr.GetMerged():
+			r.Status = state.StatusMerged
+		case pr.GetState() == "closed":
+			r.Status = state.StatusClosed
+		default:
+			continue
+		}
+		r.UpdatedAt = time.Now().UTC()
+		if err := p.State.Put(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// taskSLAScan reports open cherry-pick PRs older than max_age.
+func (p *Processor) taskSLAScan(ctx context.Context, gh GH, owner, repo string, args map[string]string) error {
+	maxAge := defaultSLA
+	if v := args["max_age"]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("sla_scan: bad max_age %q: %w", v, err)
+		}
+		maxAge = d
+	}
+	prs, _, err := gh.PR().List(ctx, owner, repo, &github.PullRequestListOptions{
+		State:       pullRequestStateOpen,
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return fmt.Errorf("list open PRs: %w", err)
+	}
+	overdue := 0
+	for _, pr := range prs {
+		if !strings.HasPrefix(pr.GetHead().GetRef(), workBranchPrefix) {
+			continue
+		}
+		if age := time.Since(pr.GetCreatedAt().Time); age > maxAge {
+			overdue++
+			slog.Warn("sla.overdue_backport", "repo", owner+"/"+repo, "pr", pr.GetNumber(), "base", pr.GetBase().GetRef(), "age", age.Round(time.Hour))
+		}
+	}
+	overduePRs.Set(float64(overdue), "repo", owner+"/"+repo)
+	return nil
+}
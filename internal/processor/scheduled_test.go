@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+// prsByHead answers PR().List by the Head filter ("owner:branch").
+type prsByHead struct {
+	*fakePRFull
+	byHead map[string][]*github.PullRequest
+}
+
+func (f prsByHead) List(ctx context.Context, owner, repo string, opts *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
+	return f.byHead[opts.Head], nil, nil
+}
+
+func TestDecodeScheduledTask(t *testing.T) {
+	ok := `{"scheduled_task":{"name":"janitor","installation_id":1,"repos":["o/r"]}}`
+	if task, err := decodeScheduledTask([]byte(ok)); err != nil || task.Name != "janitor" {
+		t.Fatalf("decode valid task: %+v, %v", task, err)
+	}
+	for name, body := range map[string]string{
+		"unknown task":    `{"scheduled_task":{"name":"nope","installation_id":1,"repos":["o/r"]}}`,
+		"no installation": `{"scheduled_task":{"name":"janitor","repos":["o/r"]}}`,
+		"bad repo":        `{"scheduled_task":{"name":"janitor","installation_id":1,"repos":["o"]}}`,
+		"not json":        `{`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeScheduledTask([]byte(body)); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+func TestHandleEvent_ScheduledTaskBadRequest(t *testing.T) {
+	p := &Processor{WebhookSecret: []byte("s")}
+	code, err := p.HandleEvent(context.Background(), "scheduled_task", "d", []byte(`{"scheduled_task":{"name":"nope"}}`))
+	if code != http.StatusBadRequest || err == nil {
+		t.Fatalf("code=%d err=%v, want 400", code, err)
+	}
+}
+
+func TestTaskJanitor_DeletesBranchesOfClosedPRs(t *testing.T) {
+	fgit := &fakeGitFull{refs: map[string]bool{
+		"refs/heads/autocherry/rel-1/aaa1111": true, // merged PR -> delete
+		"refs/heads/autocherry/rel-1/bbb2222": true, // open PR -> keep
+		"refs/heads/autocherry/rel-1/ccc3333": true, // no PR -> keep
+	}}
+	gh := ghWithPRs{
+		fakeGH: fakeGH{pr: &fakePRFull{}, iss: &fakeIssuesFull{}, git: fgit, repos: &fakeReposFull{committers: map[string]string{
+			"tip:refs/heads/autocherry/rel-1/aaa1111": "bot@noreply",
+		}}},
+		prs: prsByHead{byHead: map[string][]*github.PullRequest{
+			"o:autocherry/rel-1/aaa1111": {{State: github.Ptr("closed"), Merged: github.Ptr(true)}},
+			"o:autocherry/rel-1/bbb2222": {{State: github.Ptr("open")}},
+		}},
+	}
+	p := &Processor{GitUserEmail: "bot@noreply"}
+	p.runScheduledTask(context.Background(), gh, &ScheduledTask{Name: "janitor", Repos: []string{"o/r"}})
+
+	if strings.Join(fgit.deletedRefs, ",") != "refs/heads/autocherry/rel-1/aaa1111" {
+		t.Fatalf("deleted = %v", fgit.deletedRefs)
+	}
+}
+
+func TestTaskReconcile_UpdatesOpenRecords(t *testing.T) {
+	ctx := context.Background()
+	st := state.NewMemory()
+	_ = st.Put(ctx, state.Record{Repo: "o/r", WorkBranch: "autocherry/rel-1/aaa1111", PRNumber: 11, Status: state.StatusOpen})
+	p := &Processor{State: st}
+	fpr := &fakePRFull{prGet: &github.PullRequest{Number: github.Ptr(11), State: github.Ptr("closed"), Merged: github.Ptr(true)}}
+
+	if err := p.taskReconcile(ctx, fakeGH{pr: fpr}, "o", "r", nil); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if r, _, _ := st.Get(ctx, "o/r", "autocherry/rel-1/aaa1111"); r.Status != state.StatusMerged {
+		t.Fatalf("status = %q, want merged", r.Status)
+	}
+}
+
+func TestTaskSLAScan_CountsOverdue(t *testing.T) {
+	old := &github.PullRequest{
+		Number: github.Ptr(1), Head: &github.PullRequestBranch{Ref: github.Ptr("autocherry/rel-1/aaa1111")},
+		CreatedAt: &github.Timestamp{Time: time.Now().Add(-100 * time.Hour)},
+	}
+	fresh := &github.PullRequest{
+		Number: github.Ptr(2), Head: &github.PullRequestBranch{Ref: github.Ptr("autocherry/rel-1/bbb2222")},
+		CreatedAt: &github.Timestamp{Time: time.Now().Add(-time.Hour)},
+	}
+	human := &github.PullRequest{
+		Number: github.Ptr(3), Head: &github.PullRequestBranch{Ref: github.Ptr("feature/x")},
+		CreatedAt: &github.Timestamp{Time: time.Now().Add(-1000 * time.Hour)},
+	}
+	gh := fakeGH{pr: &fakePRFull{list: []*github.PullRequest{old, fresh, human}}}
+	p := &Processor{}
+	if err := p.taskSLAScan(context.Background(), gh, "o", "sla", nil); err != nil {
+		t.Fatalf("sla_scan: %v", err)
+	}
+	if v := overduePRs.Value("repo", "o/sla"); v != 1 {
+		t.Fatalf("overdue = %v, want 1", v)
+	}
+	if err := p.taskSLAScan(context.Background(), gh, "o", "sla", map[string]string{"max_age": "30m"}); err != nil {
+		t.Fatalf("sla_scan: %v", err)
+	}
+	if v := overduePRs.Value("repo", "o/sla"); v != 2 {
+		t.Fatalf("overdue with max_age=30m = %v, want 2", v)
+	}
+}
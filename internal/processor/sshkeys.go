@@ -0,0 +1,27 @@
+package processor
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/gitexec"
+)
+
+// sshFor is the deploy key picks for installation inst use from SSHKeyDir:
+// the file named after the installation ID, else "default". nil (HTTPS with
+// the installation token) when SSHKeyDir is unset or holds neither.
+func (p *Processor) sshFor(inst int64) *gitexec.SSHAuth {
+	if p.SSHKeyDir == "" {
+		return nil
+	}
+	for _, name := range []string{strconv.FormatInt(inst, 10), "default"} {
+		key := filepath.Join(p.SSHKeyDir, name)
+		if fi, err := os.Stat(key); err == nil && fi.Mode().IsRegular() {
+			return &gitexec.SSHAuth{Key: key, KnownHosts: p.SSHKnownHosts}
+		}
+	}
+	slog.Warn("ssh.no_key", "installation", inst, "dir", p.SSHKeyDir)
+	return nil
+}
@@ -0,0 +1,31 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSSHFor(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "42"), []byte("key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := (&Processor{}).sshFor(42); got != nil {
+		t.Fatalf("sshFor without SSHKeyDir = %+v; want nil", got)
+	}
+	p := &Processor{SSHKeyDir: dir, SSHKnownHosts: "/etc/gh_known_hosts"}
+	if got := p.sshFor(42); got == nil || got.Key != filepath.Join(dir, "42") || got.KnownHosts != "/etc/gh_known_hosts" {
+		t.Fatalf("sshFor(42) = %+v", got)
+	}
+	if got := p.sshFor(7); got != nil {
+		t.Fatalf("sshFor(7) without a default key = %+v; want nil", got)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "default"), []byte("key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.sshFor(7); got == nil || got.Key != filepath.Join(dir, "default") {
+		t.Fatalf("sshFor(7) = %+v; want the default key", got)
+	}
+}
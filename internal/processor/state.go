@@ -0,0 +1,174 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+const workBranchPrefix = "autocherry/"
+
+var (
+	reBodySourcePR = regexp.MustCompile(`(?m)(?:(?:Automated cherry-pick of|_origin:) PR |^Backport of )#(\d+)`)
+	reBodyCommit   = regexp.MustCompile("Commit: `([0-9a-f]{7,40})`")
+)
+
+// record upserts a backport record, keeping the original creation time.
+// Failures are logged only: the store must never block a backport.
+func (p *Processor) record(ctx context.Context, r state.Record) {
+	if p.State == nil || !p.optionalEnabled(subsystemState) {
+		return
+	}
+	now := time.Now().UTC()
+	r.CreatedAt, r.UpdatedAt = now, now
+	if prev, ok, err := p.State.Get(ctx, r.Repo, r.WorkBranch); err == nil && ok {
+		r.CreatedAt = prev.CreatedAt
+	}
+	if err := p.State.Put(ctx, r); err != nil {
+		slog.Warn("state.put_error", "repo", r.Repo, "work_branch", r.WorkBranch, "err", safeErr(err))
+		p.optionalFailed(subsystemState, err)
+	}
+}
+
+// BackfillResult summarizes a Backfill run.
+type BackfillResult struct {
+	Imported int // records written (new or refreshed backfilled ones)
+	Skipped  int // records already written live, left untouched
+}
+
+// Backfill reconstructs state records for a repo from its autocherry/* PRs
+// (any state) and work branches without a PR, so activity predating the
+// state store shows up in it. Records written live are never overwritten;
+// rerunning refreshes previously backfilled ones.
+func (p *Processor) Backfill(ctx context.Context, installationID int64, owner, repo string) (BackfillResult, error) {
+	if p.State == nil {
+		return BackfillResult{}, errors.New("backfill: no state store configured")
+	}
+	clients, err := p.buildClients(installationID)
+	if err != nil {
+		return BackfillResult{}, fmt.Errorf("backfill: build clients: %w", err)
+	}
+	return p.backfill(ctx, realGH{c: clients.REST}, owner, repo)
+}
+
+func (p *Processor) backfill(ctx context.Context, gh GH, owner, repo string) (BackfillResult, error) {
+	full := owner + "/" + repo
+	var recs []state.Record
+	seen := map[string]bool{}
+
+	opts := &github.PullRequestListOptions{State: "all", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		prs, resp, err := gh.PR().List(ctx, owner, repo, opts)
+		if err != nil {
+			return BackfillResult{}, fmt.Errorf("backfill: list PRs: %w", err)
+		}
+		for _, pr := range prs {
+			r, ok := recordFromPR(full, pr)
+			if !ok || seen[r.WorkBranch] {
+				continue
+			}
+			seen[r.WorkBranch] = true
+			recs = append(recs, r)
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	refOpts := &github.ReferenceListOptions{Ref: "heads/" + workBranchPrefix, ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		refs, resp, err := gh.Git().ListMatchingRefs(ctx, owner, repo, refOpts)
+		if err != nil {
+			return BackfillResult{}, fmt.Errorf("backfill: list work branches: %w", err)
+		}
+		for _, ref := range refs {
+			branch := strings.TrimPrefix(ref.GetRef(), "refs/heads/")
+			if seen[branch] {
+				continue
+			}
+			seen[branch] = true
+			now := time.Now().UTC()
+			recs = append(recs, state.Record{
+				Repo: full, WorkBranch: branch, SHA: branch[strings.LastIndex(branch, "/")+1:],
+				Status: state.StatusOrphaned, CreatedAt: now, UpdatedAt: now, Backfilled: true,
+			})
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		refOpts.Page = resp.NextPage
+	}
+
+	var res BackfillResult
+	for _, r := range recs {
+		prev, ok, err := p.State.Get(ctx, r.Repo, r.WorkBranch)
+		if err != nil {
+			return res, fmt.Errorf("backfill: read state: %w", err)
+		}
+		if ok && !prev.Backfilled {
+			res.Skipped++
+			continue
+		}
+		if err := p.State.Put(ctx, r); err != nil {
+			return res, fmt.Errorf("backfill: write state: %w", err)
+		}
+		res.Imported++
+	}
+	slog.Info("state.backfill_done", "repo", full, "imported", res.Imported, "skipped", res.Skipped)
+	return res, nil
+}
+
+// recordFromPR maps an autocherry PR opened by this app back onto a record;
+// source PR and commit come from the body the app writes.
+func recordFromPR(full string, pr *github.PullRequest) (state.Record, bool) {
+	if pr == nil || pr.Head == nil {
+		return state.Record{}, false
+	}
+	branch := pr.Head.GetRef()
+	if !strings.HasPrefix(branch, workBranchPrefix) {
+		return state.Record{}, false
+	}
+	if hr := pr.Head.GetRepo(); hr != nil && hr.GetFullName() != "" && !strings.EqualFold(hr.GetFullName(), full) {
+		return state.Record{}, false // same-named branch on a fork
+	}
+
+	r := state.Record{
+		Repo:       full,
+		WorkBranch: branch,
+		Target:     pr.GetBase().GetRef(),
+		PRNumber:   pr.GetNumber(),
+		PRURL:      pr.GetHTMLURL(),
+		Status:     state.StatusOpen,
+		CreatedAt:  pr.GetCreatedAt().Time,
+		UpdatedAt:  pr.GetUpdatedAt().Time,
+		Backfilled: true,
+	}
+	switch {
+	case pr.MergedAt != nil:
+		r.Status, r.UpdatedAt = state.StatusMerged, pr.GetMergedAt().Time
+	case pr.GetState() == "closed":
+		r.Status = state.StatusClosed
+		if pr.ClosedAt != nil {
+			r.UpdatedAt = pr.GetClosedAt().Time
+		}
+	}
+	body := pr.GetBody()
+	if m := reBodySourcePR.FindStringSubmatch(body); m != nil {
+		r.SourcePR = parseInt(m[1])
+	}
+	if m := reBodyCommit.FindStringSubmatch(body); m != nil {
+		r.SHA = m[1]
+	} else {
+		r.SHA = branch[strings.LastIndex(branch, "/")+1:]
+	}
+	return r, true
+}
@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+func autocherryPR(num int, head, base, body, st string, merged bool) *github.PullRequest {
+	pr := &github.PullRequest{
+		Number:  github.Ptr(num),
+		State:   github.Ptr(st),
+		Body:    github.Ptr(body),
+		HTMLURL: github.Ptr("https://example.com/pr"),
+		Head:    &github.PullRequestBranch{Ref: github.Ptr(head)},
+		Base:    &github.PullRequestBranch{Ref: github.Ptr(base)},
+	}
+	if merged {
+		pr.MergedAt = &github.Timestamp{Time: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)}
+	}
+	return pr
+}
+
+func TestProcessMergedPR_RecordsState(t *testing.T) {
+	st := state.NewMemory()
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", State: st}
+
+	pr := mergedPR(7, "Fix bug", "abc123456789", "cherry-pick to devops-release/0021")
+	gh := fakeGH{
+		pr:    &fakePRFull{prGet: pr},
+		iss:   &fakeIssuesFull{},
+		git:   &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}},
+		repos: &fakeReposFull{commit: repoCommitWithParents(1)},
+	}
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234"}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 7, nil, "tok")
+
+	r, ok, _ := st.Get(context.Background(), "o/r", "autocherry/devops-release-0021/abc1234")
+	if !ok {
+		t.Fatalf("no state record written")
+	}
+	if r.Status != state.StatusOpen || r.SourcePR != 7 || r.PRNumber != 100 || r.Target != "devops-release/0021" || r.Backfilled {
+		t.Fatalf("record = %+v", r)
+	}
+}
+
+func TestBackfill_ReconstructsRecords(t *testing.T) {
+	ctx := context.Background()
+	st := state.NewMemory()
+	live := state.Record{Repo: "o/r", WorkBranch: "autocherry/rel-0002/bbb2222", Status: state.StatusOpen, SourcePR: 2}
+	_ = st.Put(ctx, live)
+	p := &Processor{State: st}
+
+	gh := fakeGH{
+		pr: &fakePRFull{list: []*github.PullRequest{
+			autocherryPR(11, "autocherry/rel-0001/aaa1111", "rel/0001",
+				"Automated cherry-pick of PR #5 into `rel/0001`.\n\nCommit: `aaa1111deadbeef`", "closed", true),
+			autocherryPR(12, "autocherry/rel-0002/bbb2222", "rel/0002", "Automated cherry-pick of PR #2", "open", false),
+			autocherryPR(13, "feature/x", "main", "", "open", false), // not ours
+		}},
+		iss: &fakeIssuesFull{},
+		git: &fakeGitFull{refs: map[string]bool{
+			"refs/heads/autocherry/rel-0001/aaa1111": true, // has a PR
+			"refs/heads/autocherry/rel-0003/ccc3333": true, // orphaned
+			"refs/heads/main":                        true,
+		}},
+		repos: &fakeReposFull{},
+	}
+
+	res, err := p.backfill(ctx, gh, "o", "r")
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+	if res.Imported != 2 || res.Skipped != 1 {
+		t.Fatalf("result = %+v, want 2 imported, 1 skipped", res)
+	}
+
+	merged, _, _ := st.Get(ctx, "o/r", "autocherry/rel-0001/aaa1111")
+	if merged.Status != state.StatusMerged || merged.SourcePR != 5 || merged.SHA != "aaa1111deadbeef" || merged.PRNumber != 11 || !merged.Backfilled {
+		t.Fatalf("merged record = %+v", merged)
+	}
+	orphan, _, _ := st.Get(ctx, "o/r", "autocherry/rel-0003/ccc3333")
+	if orphan.Status != state.StatusOrphaned || orphan.SHA != "ccc3333" {
+		t.Fatalf("orphan record = %+v", orphan)
+	}
+	if got, _, _ := st.Get(ctx, "o/r", live.WorkBranch); got != live {
+		t.Fatalf("live record overwritten: %+v", got)
+	}
+
+	// Rerun refreshes backfilled records only.
+	if res, _ := p.backfill(ctx, gh, "o", "r"); res.Imported != 2 || res.Skipped != 1 {
+		t.Fatalf("rerun result = %+v", res)
+	}
+}
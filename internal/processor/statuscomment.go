@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// statusMarker tags the single comment on a source PR that StatusComment
+// keeps up to date in place of one comment per update.
+const statusMarker = "<!-- autocherry-status -->"
+
+// statusCommentMu serializes read-modify-write updates of status comments,
+// so parallel targets of one PR do not drop each other's rows.
+var statusCommentMu sync.Mutex
+
+// reStatusRow parses a row of the status comment's table back; see
+// statusCommentBody.
+var reStatusRow = regexp.MustCompile("^\\| `([^`]+)` \\| (.*) \\|$")
+
+// updateStatusComment sets target's row of the status comment on issue to
+// msg, creating the comment on first use.
+func (p *Processor) updateStatusComment(ctx context.Context, gh GH, owner, repo string, issue int, target, msg string) error {
+	statusCommentMu.Lock()
+	defer statusCommentMu.Unlock()
+
+	existing, err := findComment(ctx, gh, owner, repo, issue, statusMarker)
+	if err != nil {
+		return err
+	}
+	rows := map[string]string{}
+	if existing != nil {
+		for _, l := range strings.Split(existing.GetBody(), "\n") {
+			if m := reStatusRow.FindStringSubmatch(l); m != nil {
+				rows[m[1]] = m[2]
+			}
+		}
+	}
+	rows[target] = strings.NewReplacer("\r", "", "\n", " ", "|", "\\|").Replace(msg)
+	body := statusCommentBody(rows)
+
+	if existing != nil {
+		if _, _, err := gh.Issues().EditComment(ctx, owner, repo, existing.GetID(), &github.IssueComment{Body: github.Ptr(body)}); err != nil {
+			return fmt.Errorf("edit status comment on #%d: %w", issue, err)
+		}
+		return nil
+	}
+	if _, _, err := gh.Issues().CreateComment(ctx, owner, repo, issue, &github.IssueComment{Body: github.Ptr(body)}); err != nil {
+		return fmt.Errorf("status comment on #%d: %w", issue, err)
+	}
+	return nil
+}
+
+// statusCommentBody renders the status comment: one row per target, sorted.
+func statusCommentBody(rows map[string]string) string {
+	targets := make([]string, 0, len(rows))
+	for t := range rows {
+		targets = append(targets, t)
+	}
+	slices.Sort(targets)
+	var b strings.Builder
+	b.WriteString(statusMarker + "\n### Auto cherry-pick status\n\n| Target | Status |\n|---|---|\n")
+	for _, t := range targets {
+		fmt.Fprintf(&b, "| `%s` | %s |\n", t, rows[t])
+	}
+	return b.String()
+}
+
+// notifyStatus reports msg for target in the status comment, falling back to
+// a comment of its own when that fails.
+func (p *Processor) notifyStatus(ctx context.Context, gh GH, owner, repo string, issue int, target, msg string) {
+	if err := p.updateStatusComment(ctx, gh, owner, repo, issue, target, msg); err != nil {
+		slog.Warn("gh.status_comment_error", "repo", owner+"/"+repo, "issue", issue, "target", target, "err", safeErr(err))
+		_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, issue, &github.IssueComment{Body: github.Ptr(msg)})
+	}
+}
@@ -0,0 +1,48 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestProcessMergedPR_StatusComment(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", StatusComment: true}
+	fiss := &fakeIssuesFull{}
+	gh := fakeGH{
+		pr:    &fakePRFull{prGet: mergedPR(11, "Fix", "cafef00d1234567", "cherry-pick to release/1", "cherry-pick to release/2", "cherry-pick to release/3")},
+		iss:   fiss,
+		git:   &fakeGitFull{refs: map[string]bool{"refs/heads/release/1": true, "refs/heads/release/2": true}},
+		repos: &fakeReposFull{},
+	}
+	p.CherryRunner = fakeCherry{workBranch: "autocherry/release-1/cafef00"}
+
+	p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 11, nil, "tok")
+
+	if len(fiss.comments) != 1 {
+		t.Fatalf("comments = %d; want 1", len(fiss.comments))
+	}
+	body := fiss.comments[0].GetBody()
+	for _, want := range []string{
+		statusMarker,
+		"| `release/1` | ✅ Auto cherry-pick to `release/1` opened: https://example.com/newpr |",
+		"| `release/2` | ✅ Auto cherry-pick to `release/2` opened: https://example.com/newpr |",
+		"| `release/3` | ⚠️ Target branch `release/3` not found; skipping auto cherry-pick. |",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("status comment missing %q:\n%s", want, body)
+		}
+	}
+	if len(fiss.edited) != 2 {
+		t.Fatalf("edits = %d; want 2", len(fiss.edited))
+	}
+
+	// A later update replaces its target's row and keeps the others.
+	if err := p.updateStatusComment(context.Background(), gh, "o", "r", 11, "release/2", "ℹ️ a | b"); err != nil {
+		t.Fatal(err)
+	}
+	body = fiss.comments[0].GetBody()
+	if !strings.Contains(body, "| `release/2` | ℹ️ a \\| b |") || !strings.Contains(body, "| `release/1` | ✅") || strings.Count(body, "release/2` |") != 1 {
+		t.Fatalf("status comment after update:\n%s", body)
+	}
+}
@@ -0,0 +1,52 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// setPickStatus sets the "autocherry/<target>" commit status on the picked
+// commit when CommitStatuses is on, so each target's backport shows in the
+// commit history, and target's row of its check run when CheckRuns is on.
+// state is pending, success, failure or error.
+func (p *Processor) setPickStatus(ctx context.Context, gh GH, owner, repo, sha, target, state, description, url string) {
+	if p.CheckRuns && sha != "" {
+		p.updateCheckRun(ctx, gh, owner, repo, sha, checkRow{target: target, state: state, desc: description, url: url})
+	}
+	if !p.CommitStatuses || sha == "" {
+		return
+	}
+	st := &github.RepoStatus{
+		State:       github.Ptr(state),
+		Context:     github.Ptr(workBranchPrefix + target),
+		Description: github.Ptr(description),
+	}
+	if url != "" {
+		st.TargetURL = github.Ptr(url)
+	}
+	if _, _, err := gh.Repos().CreateStatus(ctx, owner, repo, sha, st); err != nil {
+		slog.Warn("gh.status_error", "repo", owner+"/"+repo, "sha", sha, "target", target, "err", safeErr(err))
+	}
+}
+
+// backportClosed settles the status of a backport PR's commit: success once
+// merged, failure when closed without merging.
+func (p *Processor) backportClosed(ctx context.Context, deliveryID string, instID int64, owner, repo string, pr *github.PullRequest) {
+	m := reBodyCommit.FindStringSubmatch(pr.GetBody())
+	if m == nil {
+		return
+	}
+	clients, err := p.buildClients(instID)
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	state, desc := "success", fmt.Sprintf("Backport #%d merged", pr.GetNumber())
+	if !pr.GetMerged() {
+		state, desc = "failure", fmt.Sprintf("Backport #%d closed without merging", pr.GetNumber())
+	}
+	p.setPickStatus(ctx, p.ghFor(clients), owner, repo, m[1], pr.GetBase().GetRef(), state, desc, pr.GetHTMLURL())
+}
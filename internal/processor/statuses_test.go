@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPickTarget_CommitStatuses(t *testing.T) {
+	cases := []struct {
+		name   string
+		cherry fakeCherry
+		want   []string // state: description
+	}{
+		{
+			name:   "opened",
+			cherry: fakeCherry{workBranch: "autocherry/devops-release-0021/abc1234"},
+			want:   []string{"pending: Cherry-picking onto devops-release/0021", "pending: Backport #100 open"},
+		},
+		{
+			name:   "conflict",
+			cherry: fakeCherry{err: errors.New("conflict in a.go")},
+			want:   []string{"pending: Cherry-picking onto devops-release/0021", "failure: Cherry-pick failed; pick manually"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repos := &fakeReposFull{commit: repoCommitWithParents(1)}
+			gh := fakeGH{
+				pr:  &fakePRFull{prGet: mergedPR(7, "Fix bug", "abc123456789", "cherry-pick to devops-release/0021")},
+				iss: &fakeIssuesFull{}, repos: repos,
+				git: &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}},
+			}
+			p := &Processor{CommitStatuses: true, CherryRunner: c.cherry}
+			p.processMergedPRWith(context.Background(), "d", gh, "o", "r", 7, nil, "tok")
+
+			var got []string
+			for _, st := range repos.statuses {
+				if st.GetContext() != "autocherry/devops-release/0021" {
+					t.Fatalf("context = %q", st.GetContext())
+				}
+				got = append(got, st.GetState()+": "+st.GetDescription())
+			}
+			if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+				t.Fatalf("statuses:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestSetPickStatus_Off(t *testing.T) {
+	repos := &fakeReposFull{}
+	(&Processor{}).setPickStatus(context.Background(), fakeGH{repos: repos}, "o", "r", "abc", "t", "pending", "x", "")
+	if len(repos.statuses) != 0 {
+		t.Fatalf("statuses = %v", repos.statuses)
+	}
+}
@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// closeSuperseded closes the open backports of src.issue onto target other
+// than newPR (e.g. of an earlier commit, before a forced re-run), deleting
+// their work branches and pointing each at newPR. Release trains carry other
+// PRs' picks too and are left open.
+func (p *Processor) closeSuperseded(
+	ctx context.Context, deliveryID string, gh GH, owner, repo string, src pickSource, target string, newPR *github.PullRequest,
+) {
+	if src.issue == 0 {
+		return
+	}
+	prefix := workBranchPrefix + strings.ReplaceAll(target, "/", "-") + "/"
+	opts := &github.PullRequestListOptions{State: pullRequestStateOpen, Base: target, ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		prs, resp, err := gh.PR().List(ctx, owner, repo, opts)
+		if err != nil {
+			slog.Warn("supersede.list_error", "delivery", sanitizeForLog(deliveryID), "target", target, "err", safeErr(err))
+			return
+		}
+		for _, old := range prs {
+			branch := old.GetHead().GetRef()
+			if old.GetNumber() == newPR.GetNumber() || !strings.HasPrefix(branch, prefix) || strings.HasPrefix(branch, prefix+"train-") ||
+				!reWorkBranch.MatchString(branch) || p.sourcePR(ctx, owner, repo, old) != src.issue {
+				continue
+			}
+			_, _, _ = gh.Issues().CreateComment(ctx, owner, repo, old.GetNumber(), &github.IssueComment{Body: github.Ptr(fmt.Sprintf(
+				"ℹ️ Superseded by %s, a newer auto cherry-pick of #%d to `%s`; closing this one and deleting `%s`.",
+				newPR.GetHTMLURL(), src.issue, target, branch))})
+			if err := p.processUnlabeled(ctx, gh, owner, repo, src.issue, target, branch); err != nil {
+				slog.Warn("supersede.close_error", "delivery", sanitizeForLog(deliveryID), "pr", old.GetNumber(), "err", safeErr(err))
+				continue
+			}
+			slog.Info("supersede.closed", "delivery", sanitizeForLog(deliveryID), "target", target, "pr", old.GetNumber(), "by", newPR.GetNumber())
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return
+		}
+		opts.Page = resp.NextPage
+	}
+}
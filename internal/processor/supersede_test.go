@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func TestCloseSuperseded(t *testing.T) {
+	backport := func(num int, branch, body string) *github.PullRequest {
+		return &github.PullRequest{
+			Number: github.Ptr(num), State: github.Ptr("open"), Body: github.Ptr(body),
+			Head: &github.PullRequestBranch{Ref: github.Ptr(branch)},
+		}
+	}
+	const (
+		older = "autocherry/release-1/1111111"
+		other = "autocherry/release-1/2222222"
+	)
+	fpr := &fakePRFull{list: []*github.PullRequest{
+		backport(90, older, "Automated cherry-pick of PR #11 into `release/1`.\n\nCommit: `1111111`"),
+		backport(91, other, "Automated cherry-pick of PR #12 into `release/1`.\n\nCommit: `2222222`"),
+		backport(92, "autocherry/release-2/1111111", "Backport of #11"),
+		backport(100, "autocherry/release-1/cafef00", "Backport of #11"),
+	}}
+	fiss := &fakeIssuesFull{}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/" + older: true, "refs/heads/" + other: true}}
+	repos := &fakeReposFull{committers: map[string]string{"tip:refs/heads/" + older: "bot@noreply", "tip:refs/heads/" + other: "bot@noreply"}}
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply"}
+	newPR := &github.PullRequest{Number: github.Ptr(100), HTMLURL: github.Ptr("https://example.com/newpr")}
+
+	p.closeSuperseded(context.Background(), "d", fakeGH{pr: fpr, iss: fiss, git: fgit, repos: repos}, "o", "r", pickSource{issue: 11}, "release/1", newPR)
+
+	if len(fpr.edited) != 1 || fpr.edited[0].GetState() != "closed" {
+		t.Fatalf("edited = %+v; want the older backport closed", fpr.edited)
+	}
+	if !slices.Equal(fgit.deletedRefs, []string{"refs/heads/" + older}) {
+		t.Fatalf("deleted = %v", fgit.deletedRefs)
+	}
+	if len(fiss.comments) != 1 || !strings.Contains(fiss.comments[0].GetBody(), "Superseded by https://example.com/newpr") {
+		t.Fatalf("comments = %+v", fiss.comments)
+	}
+}
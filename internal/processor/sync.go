@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+// syncMarker tags the source PR comment tracking pushes to one work branch,
+// so later pushes edit it instead of adding another.
+const syncMarker = "<!-- autocherry-sync:%s -->"
+
+// handleBackportSync handles pushes by people to an open backport PR (e.g. a
+// conflict resolved by hand), reporting them on the source PR. The app's own
+// pushes are skipped.
+func (p *Processor) handleBackportSync(ctx context.Context, deliveryID string, instID int64, owner, repo string, e *github.PullRequestEvent) {
+	if e.GetSender().GetType() == "Bot" {
+		return
+	}
+	clients, err := p.buildClients(instID)
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	if err := p.reportBackportSync(ctx, p.ghFor(clients), owner, repo, e.GetPullRequest(), e.GetSender().GetLogin()); err != nil {
+		slog.Warn("sync.report_error", "delivery", sanitizeForLog(deliveryID), "pr", e.GetPullRequest().GetNumber(), "err", safeErr(err))
+	}
+}
+
+// reportBackportSync creates or updates the sync comment on the source PR of
+// backport. Backports of bare commits have no source PR and are skipped.
+func (p *Processor) reportBackportSync(ctx context.Context, gh GH, owner, repo string, backport *github.PullRequest, pusher string) error {
+	source := p.sourcePR(ctx, owner, repo, backport)
+	if source == 0 {
+		slog.Debug("sync.no_source_pr", "repo", owner+"/"+repo, "pr", backport.GetNumber())
+		return nil
+	}
+	branch := backport.GetHead().GetRef()
+	short := backport.GetHead().GetSHA()
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	marker := fmt.Sprintf(syncMarker, branch)
+	body := fmt.Sprintf("%s\n🔧 @%s pushed `%s` to the auto cherry-pick to `%s` (%s): conflict resolved manually, awaiting review.",
+		marker, pusher, short, backport.GetBase().GetRef(), backport.GetHTMLURL())
+
+	existing, err := findComment(ctx, gh, owner, repo, source, marker)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if _, _, err := gh.Issues().EditComment(ctx, owner, repo, existing.GetID(), &github.IssueComment{Body: github.Ptr(body)}); err != nil {
+			return fmt.Errorf("edit comment on PR #%d: %w", source, err)
+		}
+	} else if _, _, err := gh.Issues().CreateComment(ctx, owner, repo, source, &github.IssueComment{Body: github.Ptr(body)}); err != nil {
+		return fmt.Errorf("comment on PR #%d: %w", source, err)
+	}
+	slog.Info("sync.reported", "repo", owner+"/"+repo, "pr", source, "backport", backport.GetNumber(), "pusher", sanitizeForLog(pusher))
+	return nil
+}
+
+// findComment returns the first comment on issue containing marker, or nil.
+func findComment(ctx context.Context, gh GH, owner, repo string, issue int, marker string) (*github.IssueComment, error) {
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := gh.Issues().ListComments(ctx, owner, repo, issue, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list comments on #%d: %w", issue, err)
+		}
+		for _, c := range comments {
+			if strings.Contains(c.GetBody(), marker) {
+				return c, nil
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,48 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func TestReportBackportSync_UpdatesOneComment(t *testing.T) {
+	backport := &github.PullRequest{
+		Number:  github.Ptr(31),
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/31"),
+		Body:    github.Ptr("Automated cherry-pick of PR #12 into `devops-release/0021`."),
+		Base:    &github.PullRequestBranch{Ref: github.Ptr("devops-release/0021")},
+		Head:    &github.PullRequestBranch{Ref: github.Ptr("autocherry/devops-release-0021/abc1234"), SHA: github.Ptr("fix1111222")},
+	}
+	fiss := &fakeIssuesFull{}
+	fiss.comments = []*github.IssueComment{{ID: github.Ptr(int64(7)), Body: github.Ptr("unrelated")}}
+	gh := fakeGH{iss: fiss}
+	p := &Processor{}
+
+	if err := p.reportBackportSync(context.Background(), gh, "o", "r", backport, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if len(fiss.comments) != 2 || !strings.Contains(fiss.comments[1].GetBody(), "@alice pushed `fix1111` to the auto cherry-pick to `devops-release/0021`") {
+		t.Fatalf("comments = %v", fiss.comments)
+	}
+
+	backport.Head.SHA = github.Ptr("fix3333444")
+	if err := p.reportBackportSync(context.Background(), gh, "o", "r", backport, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if len(fiss.comments) != 2 || len(fiss.edited) != 1 {
+		t.Fatalf("expected the sync comment edited in place, comments = %v, edited = %v", fiss.comments, fiss.edited)
+	}
+	if body := fiss.comments[1].GetBody(); !strings.Contains(body, "@bob pushed `fix3333`") || strings.Contains(body, "alice") {
+		t.Fatalf("body = %q", body)
+	}
+
+	// A backport of a bare commit has no source PR to report on.
+	fiss = &fakeIssuesFull{}
+	commitOnly := &github.PullRequest{Number: github.Ptr(32), Body: github.Ptr("Automated cherry-pick of commit `abc1234`."), Head: backport.Head}
+	if err := p.reportBackportSync(context.Background(), fakeGH{iss: fiss}, "o", "r", commitOnly, "alice"); err != nil || len(fiss.comments) != 0 {
+		t.Fatalf("err = %v, comments = %v", err, fiss.comments)
+	}
+}
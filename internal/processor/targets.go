@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+)
+
+// milestonePlaceholder is replaced by the milestone title in MilestoneTemplate.
+const milestonePlaceholder = "{milestone}"
+
+// milestoneTarget maps the PR's milestone onto a release branch through
+// MilestoneTarget ("" when unset or the PR has no milestone).
+func (p *Processor) milestoneTarget(pr *github.PullRequest) string {
+	title := strings.TrimSpace(pr.GetMilestone().GetTitle())
+	if p.MilestoneTemplate == "" || title == "" {
+		return ""
+	}
+	return strings.ReplaceAll(p.MilestoneTemplate, milestonePlaceholder, title)
+}
+
+// prTargets is where a merged PR should be picked to: its "cherry-pick to"
+// labels, plus the branch of its milestone and those its description names
+// after BodyDirective (e.g. "Backport-to: a, b"). Backports themselves only
+// go by labels, so whatever they inherit cannot fan them out again.
+func (p *Processor) prTargets(pr *github.PullRequest) []string {
+	targets := cherry.ParseTargetBranches(pr.Labels)
+	if reWorkBranch.MatchString(pr.GetHead().GetRef()) {
+		return targets
+	}
+	extra := cherry.ParseDirectiveTargets(pr.GetBody(), p.BodyDirective)
+	if t := p.milestoneTarget(pr); t != "" {
+		extra = append(extra, t)
+	}
+	for _, t := range extra {
+		if !slices.Contains(targets, t) {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// mergeCommitTargets reads "Cherry-pick-to:" trailers from the message of the
+// PR's merge (or squash) commit, for automation that writes commit messages
+// rather than labels. Backports and picked commits carry their origin's
+// trailers, so they are skipped.
+func (p *Processor) mergeCommitTargets(ctx context.Context, gh GH, owner, repo string, pr *github.PullRequest) []string {
+	sha := pr.GetMergeCommitSHA()
+	if !p.MergeTrailers || sha == "" || reWorkBranch.MatchString(pr.GetHead().GetRef()) {
+		return nil
+	}
+	rc, _, err := gh.Repos().GetCommit(ctx, owner, repo, sha, nil)
+	if err != nil {
+		slog.Warn("pr.merge_commit_error", "repo", owner+"/"+repo, "pr", pr.GetNumber(), "sha", sha, "err", safeErr(err))
+		return nil
+	}
+	msg := rc.GetCommit().GetMessage()
+	if strings.Contains(msg, cherryPickedMarker) {
+		return nil
+	}
+	return cherry.ParseTrailerTargets(msg)
+}
+
+// mergedViaPR reports whether sha is the merge commit of a merged PR, whose
+// trailers mergeCommitTargets handles.
+func mergedViaPR(ctx context.Context, gh GH, owner, repo, sha string) bool {
+	prs, _, err := gh.PR().ListPullRequestsWithCommit(ctx, owner, repo, sha, &github.ListOptions{PerPage: 10})
+	if err != nil {
+		slog.Warn("push.commit_prs_error", "repo", owner+"/"+repo, "sha", sha, "err", safeErr(err))
+		return false
+	}
+	for _, pr := range prs {
+		if pr.GetMergeCommitSHA() == sha {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+)
+
+func TestPRTargets_BodyDirective(t *testing.T) {
+	p := &Processor{BodyDirective: "Backport-to"}
+	pr := mergedPR(12, "fix", "abc", "cherry-pick to a-release/0001")
+	pr.Body = github.Ptr("Fix.\n\nBackport-to: a-release/0001, b-release/0002\n")
+	if got := p.prTargets(pr); !slices.Equal(got, []string{"a-release/0001", "b-release/0002"}) {
+		t.Fatalf("prTargets = %v", got)
+	}
+
+	// A backport keeps only its labels, whatever its body says.
+	pr.Head = &github.PullRequestBranch{Ref: github.Ptr("autocherry/a-release-0001/abc1234")}
+	if got := p.prTargets(pr); !slices.Equal(got, []string{"a-release/0001"}) {
+		t.Fatalf("backport prTargets = %v", got)
+	}
+}
+
+func TestPRTargets_Milestone(t *testing.T) {
+	pr := func(milestone string, labels ...string) *github.PullRequest {
+		p := mergedPR(12, "fix", "abc", labels...)
+		if milestone != "" {
+			p.Milestone = &github.Milestone{Title: github.Ptr(milestone)}
+		}
+		return p
+	}
+	withTemplate := &Processor{MilestoneTemplate: "devops-release/{milestone}"}
+	cases := []struct {
+		name string
+		p    *Processor
+		pr   *github.PullRequest
+		want []string
+	}{
+		{name: "milestone only", p: withTemplate, pr: pr("0031"), want: []string{"devops-release/0031"}},
+		{name: "labels and milestone", p: withTemplate, pr: pr("0031", "cherry-pick to web-release/0007"),
+			want: []string{"web-release/0007", "devops-release/0031"}},
+		{name: "label names the same branch", p: withTemplate, pr: pr("0031", "cherry-pick to devops-release/0031"),
+			want: []string{"devops-release/0031"}},
+		{name: "no milestone", p: withTemplate, pr: pr("", "cherry-pick to web-release/0007"), want: []string{"web-release/0007"}},
+		{name: "no template", p: &Processor{}, pr: pr("0031")},
+	}
+	for _, c := range cases {
+		if got := c.p.prTargets(c.pr); !slices.Equal(got, c.want) {
+			t.Errorf("%s: prTargets = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestMergeCommitTargets(t *testing.T) {
+	commit := func(msg string) *fakeReposFull {
+		return &fakeReposFull{commit: &github.RepositoryCommit{Commit: &github.Commit{Message: github.Ptr(msg)}}}
+	}
+	pr := mergedPR(12, "Fix", "abc")
+	p := &Processor{MergeTrailers: true}
+
+	got := p.mergeCommitTargets(context.Background(), fakeGH{repos: commit("Fix (#12)\n\nCherry-pick-to: a-release/0001")}, "o", "r", pr)
+	if !slices.Equal(got, []string{"a-release/0001"}) {
+		t.Fatalf("targets = %v", got)
+	}
+	picked := commit("Fix (#12)\n\nCherry-pick-to: a-release/0001\n(cherry picked from commit abc)")
+	if got := p.mergeCommitTargets(context.Background(), fakeGH{repos: picked}, "o", "r", pr); len(got) != 0 {
+		t.Fatalf("picked commit targets = %v", got)
+	}
+	off := &Processor{}
+	if got := off.mergeCommitTargets(context.Background(), fakeGH{repos: commit("Cherry-pick-to: a-release/0001")}, "o", "r", pr); len(got) != 0 {
+		t.Fatalf("disabled targets = %v", got)
+	}
+}
@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/repocfg"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/usage"
+)
+
+// handleTrailerPush backports commits pushed to the default branch that name
+// their targets in "Cherry-pick-to:" trailers, for hotfixes that never had a
+// PR to label.
+func (p *Processor) handleTrailerPush(ctx context.Context, deliveryID string, e *github.PushEvent, branch string) {
+	if !hasTrailerTargets(e.Commits) {
+		return
+	}
+	inst := e.GetInstallation()
+	if inst == nil {
+		slog.Warn("push.no_installation", "delivery", sanitizeForLog(deliveryID))
+		return
+	}
+	clients, err := p.buildClients(inst.GetID())
+	if err != nil {
+		slog.Error("gh.client_error", "delivery", sanitizeForLog(deliveryID), "err", safeErr(err))
+		return
+	}
+	token, ok := p.installationToken(ctx, deliveryID, inst.GetID())
+	if !ok {
+		return
+	}
+	p.pickTrailers(usage.WithInstallation(ctx, inst.GetID()), deliveryID, p.ghFor(clients), e, branch, token)
+}
+
+// hasTrailerTargets reports whether any commit asks for a backport.
+func hasTrailerTargets(commits []*github.HeadCommit) bool {
+	for _, c := range commits {
+		if len(trailerTargets(c)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// trailerTargets are the targets of a newly pushed commit. Commits that are
+// themselves cherry-picks (forward-ports) keep the trailers of their origin
+// and are skipped.
+func trailerTargets(c *github.HeadCommit) []string {
+	if c == nil || !c.GetDistinct() || strings.Contains(c.GetMessage(), cherryPickedMarker) {
+		return nil
+	}
+	return cherry.ParseTrailerTargets(c.GetMessage())
+}
+
+// pickTrailers runs the pick flow for each trailer target. There is no PR to
+// comment on, so outcomes are reported as commit comments.
+func (p *Processor) pickTrailers(ctx context.Context, deliveryID string, gh GH, e *github.PushEvent, branch, token string) {
+	repo := e.GetRepo()
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+	if owner == "" {
+		owner = repo.GetOwner().GetName() // push payloads fill name, not always login
+	}
+	repoCfg := &repocfg.Config{}
+	if p.needsRepoConfig() {
+		repoCfg = p.loadRepoConfig(ctx, gh, owner, name, 0)
+	}
+
+	for _, c := range e.Commits {
+		targets := trailerTargets(c)
+		if len(targets) == 0 {
+			continue
+		}
+		sha := c.GetID()
+		if p.MergeTrailers && mergedViaPR(ctx, gh, owner, name, sha) {
+			slog.Debug("push.trailer_skip", "delivery", sanitizeForLog(deliveryID), "sha", sha, "reason", "pr_merge_commit")
+			continue
+		}
+		short := sha[:min(7, len(sha))]
+		slog.Info("push.trailer_targets", "delivery", sanitizeForLog(deliveryID), "repo", owner+"/"+name, "sha", sha, "targets", targets)
+
+		rc, _, err := gh.Repos().GetCommit(ctx, owner, name, sha, nil)
+		subject, _, _ := strings.Cut(c.GetMessage(), "\n")
+		src := pickSource{
+			sha: sha, isMerge: err == nil && len(rc.Parents) > 1, author: c.GetAuthor().GetLogin(),
+			what:  fmt.Sprintf("commit `%s`", short),
+			title: fmt.Sprintf("Auto cherry-pick: %s — %s", short, subject), subject: subject,
+		}
+		src.footer = fmt.Sprintf("\n\n---\n_origin: commit %s pushed to `%s` by @%s (`Cherry-pick-to:` trailer)_", short, branch, e.GetSender().GetLogin())
+
+		manual := p.findManualBackports(ctx, gh, owner, name, sha, 0)
+		for _, target := range targets {
+			if _, _, err := gh.Git().GetRef(ctx, owner, name, "refs/heads/"+target); err != nil {
+				p.notify(ctx, gh, owner, name, src, target, fmt.Sprintf("⚠️ Target branch `%s` not found; skipping auto cherry-pick.", target))
+				continue
+			}
+			if mp := manual[target]; mp != nil {
+				p.notify(ctx, gh, owner, name, src, target,
+					fmt.Sprintf("ℹ️ `%s` already has a backport of `%s`: %s; skipping auto cherry-pick.", target, sha, mp.GetHTMLURL()))
+				continue
+			}
+			p.pickTarget(ctx, deliveryID, gh, owner, name, src, target, token, repoCfg)
+		}
+	}
+}
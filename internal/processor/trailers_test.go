@@ -0,0 +1,95 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/githubapp"
+)
+
+func trailerPush(commits ...*github.HeadCommit) *github.PushEvent {
+	return &github.PushEvent{
+		Ref: github.Ptr("refs/heads/main"),
+		Repo: &github.PushEventRepository{
+			Owner: &github.User{Login: github.Ptr("o")}, Name: github.Ptr("r"), DefaultBranch: github.Ptr("main"),
+		},
+		Sender:       &github.User{Login: github.Ptr("alice")},
+		Installation: &github.Installation{ID: github.Ptr(int64(1))},
+		Commits:      commits,
+	}
+}
+
+func pushedCommit(sha, msg string) *github.HeadCommit {
+	return &github.HeadCommit{ID: github.Ptr(sha), Message: github.Ptr(msg), Distinct: github.Ptr(true)}
+}
+
+func TestPickTrailers_PicksNamedTargets(t *testing.T) {
+	const sha = "abcdef1234567890"
+	fpr := &fakePRFull{}
+	repos := &fakeReposFull{}
+	gh := fakeGH{
+		pr: fpr, iss: &fakeIssuesFull{}, repos: repos,
+		git: &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}},
+	}
+	p := &Processor{CherryRunner: fakeCherry{workBranch: "autocherry/devops-release-0021/abcdef1"}}
+
+	e := trailerPush(
+		pushedCommit(sha, "Hotfix crash\n\nCherry-pick-to: devops-release/0021, devops-release/0099"),
+		pushedCommit("1111111aaaa", "Unrelated change"),
+		pushedCommit("2222222bbbb", "Forward-port\n\nCherry-pick-to: devops-release/0021\n(cherry picked from commit 3333333)"),
+	)
+	p.pickTrailers(context.Background(), "d1", gh, e, "main", "tok")
+
+	if fpr.newPR == nil || fpr.newPR.GetBase() != "devops-release/0021" {
+		t.Fatalf("PR = %+v", fpr.newPR)
+	}
+	if got := fpr.newPR.GetTitle(); got != "Auto cherry-pick: abcdef1 — Hotfix crash" {
+		t.Fatalf("title = %q", got)
+	}
+	if !strings.Contains(fpr.newPR.GetBody(), "pushed to `main` by @alice (`Cherry-pick-to:` trailer)") {
+		t.Fatalf("body = %q", fpr.newPR.GetBody())
+	}
+	got := strings.Join(repos.commitComments[sha], "\n")
+	if !strings.Contains(got, "`devops-release/0099` not found") || !strings.Contains(got, "✅ Auto cherry-pick to `devops-release/0021` opened") {
+		t.Fatalf("commit comments = %v", repos.commitComments)
+	}
+	if len(repos.commitComments) != 1 {
+		t.Fatalf("only the trailer commit should be reported on: %v", repos.commitComments)
+	}
+}
+
+func TestHandlePushEvent_TrailersNeedFlagAndDefaultBranch(t *testing.T) {
+	built := 0
+	newClients := func(int64, int64, []byte) (*githubapp.Clients, error) {
+		built++
+		return &githubapp.Clients{REST: github.NewClient(nil)}, nil
+	}
+	msg := "Fix\n\nCherry-pick-to: devops-release/0021"
+
+	(&Processor{NewClients: newClients}).handlePushEvent(context.Background(), "d1", trailerPush(pushedCommit("abc1234", msg)))
+	other := trailerPush(pushedCommit("abc1234", msg))
+	other.Ref = github.Ptr("refs/heads/feature")
+	(&Processor{NewClients: newClients, TrailerBackports: true}).handlePushEvent(context.Background(), "d1", other)
+	(&Processor{NewClients: newClients, TrailerBackports: true}).handlePushEvent(context.Background(), "d1", trailerPush(pushedCommit("abc1234", "No trailer")))
+	if built != 0 {
+		t.Fatalf("clients built %d times", built)
+	}
+}
+
+func TestPickTrailers_LeavesPRMergeCommitsToThePRFlow(t *testing.T) {
+	const sha = "abcdef1234567890"
+	fpr := &fakePRFull{withCommit: map[string][]*github.PullRequest{sha: {mergedPR(12, "Fix", sha)}}}
+	gh := fakeGH{
+		pr: fpr, iss: &fakeIssuesFull{}, repos: &fakeReposFull{},
+		git: &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}},
+	}
+	p := &Processor{MergeTrailers: true, CherryRunner: fakeCherry{workBranch: "autocherry/devops-release-0021/abcdef1"}}
+
+	p.pickTrailers(context.Background(), "d1", gh, trailerPush(pushedCommit(sha, "Fix (#12)\n\nCherry-pick-to: devops-release/0021")), "main", "tok")
+	if fpr.newPR != nil {
+		t.Fatalf("picked a PR merge commit from the push: %+v", fpr.newPR)
+	}
+}
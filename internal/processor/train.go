@@ -0,0 +1,148 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+)
+
+// trainStamp formats the start of a train's window in its branch name.
+const trainStamp = "200601021504"
+
+// trainHeader starts the list of picks that joined a train PR after the
+// one that opened it.
+const trainHeader = "\n\n### Also in this train\n"
+
+// train is the release train of one target and TrainWindow: a shared work
+// branch (autocherry/<target>/train-<window start>) and its PR, which every
+// pick onto the target within the window is appended to.
+type train struct {
+	branch string
+	since  time.Time
+	pr     *github.PullRequest // open train PR; nil when the window has none yet
+}
+
+// trainIntro starts the paragraph naming the pick that opened a train.
+const trainIntro = "Release train, opened by the pick of `%s`:"
+
+// trainFor returns the train picks onto target join now; nil when trains are
+// off, when the window's branch exists without an open PR (its PR was
+// closed), or when GitHub can't say which, so the pick gets a PR of its own.
+// A train's picks are serialized, from opening its PR to adding each later
+// pick to it, in this process and, with PRLockStore, across replicas as the
+// lease "train/<owner>/<repo>:<branch>": the caller holds the train until it
+// calls unlock.
+func (p *Processor) trainFor(ctx context.Context, deliveryID string, gh GH, owner, repo, target string) (tr *train, unlock func()) {
+	unlock = func() {}
+	if p.TrainWindow <= 0 {
+		return nil, unlock
+	}
+	since := time.Now().UTC().Truncate(p.TrainWindow)
+	tr = &train{
+		branch: fmt.Sprintf("%s%s/train-%s", workBranchPrefix, strings.ReplaceAll(target, "/", "-"), since.Format(trainStamp)),
+		since:  since,
+	}
+	release, ok := p.lockShared(ctx, deliveryID, &p.trainLocks, "train/", owner+"/"+repo+":"+tr.branch, func(string) {})
+	if !ok {
+		return nil, unlock
+	}
+	if _, _, err := gh.Git().GetRef(ctx, owner, repo, "refs/heads/"+tr.branch); isNotFound(err) {
+		return tr, release
+	} else if err != nil {
+		slog.Warn("train.ref_error", "delivery", sanitizeForLog(deliveryID), "branch", tr.branch, "err", safeErr(err))
+		release()
+		return nil, unlock
+	}
+	prs, _, err := gh.PR().List(ctx, owner, repo, &github.PullRequestListOptions{
+		State:       pullRequestStateOpen,
+		Head:        owner + ":" + tr.branch,
+		Base:        target,
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil || len(prs) == 0 {
+		release()
+		return nil, unlock
+	}
+	tr.pr = prs[0]
+	return tr, release
+}
+
+// has reports whether the train PR already carries sha: its intro names the
+// pick that opened it, and the lines under trainHeader each later one.
+func (tr *train) has(sha string) bool {
+	body := tr.pr.GetBody()
+	intro, list, _ := strings.Cut(body, trainHeader)
+	for _, line := range strings.Split(intro, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasPrefix(line, fmt.Sprintf(trainIntro, sha)) {
+			return true
+		}
+	}
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasPrefix(line, "- ") && strings.HasSuffix(line, " (commit `"+sha+"`)") {
+			return true
+		}
+	}
+	return false
+}
+
+// options points opts at the train's branch. Concurrent picks of other PRs
+// may push to it first, so rejected pushes rebase; conflicts are never
+// committed onto it, as later picks would build on them.
+func (tr *train) options(opts *cherry.Options) {
+	opts.WorkBranch, opts.Append = tr.branch, tr.pr != nil
+	opts.CommitConflicts = false
+	if opts.PushRetry == "" {
+		opts.PushRetry = cherry.PushRetryRebase
+	}
+}
+
+// title and intro are the title and a body paragraph of the PR opening the
+// train of target.
+func (tr *train) title(target string) string {
+	return fmt.Sprintf("Auto cherry-pick train: %s (%s)", target, tr.since.Format("2006-01-02 15:04 MST"))
+}
+
+func (tr *train) intro(target, sha string, window time.Duration) string {
+	return fmt.Sprintf("\n\n"+trainIntro+" picks onto `%s` until %s are added to this PR.",
+		sha, target, tr.since.Add(window).Format("2006-01-02 15:04 MST"))
+}
+
+// joinTrain reports a pick pushed onto the open train PR: the PR lists it and
+// gets its labels, and the source hears where it went. The train's state
+// record stays with the pick that opened it. The caller holds the train (see
+// trainFor), so joins don't lose each other's body lines.
+func (p *Processor) joinTrain(
+	ctx context.Context, deliveryID string, gh GH, owner, repo string, src pickSource, target string, tr *train, res cherry.Result,
+) bool {
+	num := tr.pr.GetNumber()
+	// Other picks may have joined since the PR was listed.
+	pr, _, err := gh.PR().Get(ctx, owner, repo, num)
+	if err != nil {
+		pr = tr.pr
+	}
+	body := pr.GetBody()
+	if !strings.Contains(body, trainHeader) {
+		body += trainHeader
+	}
+	body += fmt.Sprintf("- %s — %s (commit `%s`)\n", src.what, src.subject, src.sha)
+	if _, _, err := gh.PR().Edit(ctx, owner, repo, num, &github.PullRequest{Body: github.Ptr(body)}); err != nil {
+		slog.Warn("train.edit_error", "delivery", sanitizeForLog(deliveryID), "pr", num, "err", safeErr(err))
+	}
+	if labels := p.backportLabels(src, res); len(labels) > 0 {
+		if _, _, lerr := gh.Issues().AddLabelsToIssue(ctx, owner, repo, num, labels); lerr != nil {
+			slog.Warn("gh.add_label_error", "delivery", sanitizeForLog(deliveryID), "pr", num, "labels", labels, "err", safeErr(lerr))
+		}
+	}
+	slog.Info("train.joined", "delivery", sanitizeForLog(deliveryID), "target", target, "pr", num, "sha", src.sha)
+	p.notify(ctx, gh, owner, repo, src, target, fmt.Sprintf("✅ Auto cherry-pick to `%s` added to release train %s", target, tr.pr.GetHTMLURL()))
+	p.setPickStatus(ctx, gh, owner, repo, src.sha, target, "pending", fmt.Sprintf("Backport #%d open", num), tr.pr.GetHTMLURL())
+	return true
+}
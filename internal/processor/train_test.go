@@ -0,0 +1,142 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	github "github.com/google/go-github/v75/github"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/cherry"
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/shard"
+)
+
+func TestProcessMergedPR_TrainOpensAndJoins(t *testing.T) {
+	p := &Processor{GitUserName: "bot", GitUserEmail: "bot@noreply", TrainWindow: time.Hour}
+	branch := "autocherry/devops-release-0021/train-" + time.Now().UTC().Truncate(time.Hour).Format(trainStamp)
+
+	// The window's first pick opens the train PR.
+	fpr := &fakePRFull{prGet: mergedPR(11, "Fix A", "cafef00d1234567", "cherry-pick to devops-release/0021")}
+	fgit := &fakeGitFull{refs: map[string]bool{"refs/heads/devops-release/0021": true}}
+	var opts cherry.Options
+	p.CherryRunner = fakeCherry{workBranch: branch, opts: &opts}
+	p.processMergedPRWith(context.Background(), "d", fakeGH{pr: fpr, iss: &fakeIssuesFull{}, git: fgit, repos: &fakeReposFull{}}, "o", "r", 11, nil, "tok")
+
+	if opts.WorkBranch != branch || opts.Append {
+		t.Fatalf("opts = %q, append %v; want %q, a new branch", opts.WorkBranch, opts.Append, branch)
+	}
+	if fpr.newPR == nil || !strings.HasPrefix(fpr.newPR.GetTitle(), "Auto cherry-pick train: devops-release/0021") ||
+		!strings.Contains(fpr.newPR.GetBody(), "opened by the pick of `cafef00d1234567`") {
+		t.Fatalf("new PR = %+v; want the train PR", fpr.newPR)
+	}
+
+	// A later pick in the window is appended to it.
+	train := &github.PullRequest{
+		Number: github.Ptr(100), State: github.Ptr("open"), HTMLURL: github.Ptr("https://example.com/newpr"),
+		Head: &github.PullRequestBranch{Ref: github.Ptr(branch)}, Body: github.Ptr(fpr.newPR.GetBody()),
+	}
+	fpr = &fakePRFull{
+		prGet:    mergedPR(12, "Fix B", "beefcafe1234567", "cherry-pick to devops-release/0021"),
+		byNumber: map[int]*github.PullRequest{100: train},
+		list:     []*github.PullRequest{train},
+	}
+	fgit.refs["refs/heads/"+branch] = true
+	fiss := &fakeIssuesFull{}
+	p.processMergedPRWith(context.Background(), "d", fakeGH{pr: fpr, iss: fiss, git: fgit, repos: &fakeReposFull{}}, "o", "r", 12, nil, "tok")
+
+	if opts.WorkBranch != branch || !opts.Append || opts.PushRetry != cherry.PushRetryRebase {
+		t.Fatalf("opts = %q, append %v, retry %q; want appended with rebase", opts.WorkBranch, opts.Append, opts.PushRetry)
+	}
+	if fpr.newPR != nil {
+		t.Fatalf("opened %+v; want the pick added to the train", fpr.newPR)
+	}
+	if len(fpr.edited) != 1 || !strings.Contains(fpr.edited[0].GetBody(), trainHeader+"- PR #12") {
+		t.Fatalf("edited = %+v; want PR #12 listed", fpr.edited)
+	}
+	if len(fiss.comments) != 1 || !strings.Contains(fiss.comments[0].GetBody(), "added to release train https://example.com/newpr") {
+		t.Fatalf("comments = %+v", fiss.comments)
+	}
+
+	// A redelivery of that pick finds it on the train.
+	train.Body = fpr.edited[0].Body
+	fiss = &fakeIssuesFull{}
+	opts = cherry.Options{}
+	p.processMergedPRWith(context.Background(), "d", fakeGH{pr: fpr, iss: fiss, git: fgit, repos: &fakeReposFull{}}, "o", "r", 12, nil, "tok")
+	if opts.WorkBranch != "" || len(fpr.edited) != 1 {
+		t.Fatalf("re-picked onto the train: opts = %+v, edited = %d", opts, len(fpr.edited))
+	}
+	if len(fiss.comments) != 1 || !strings.Contains(fiss.comments[0].GetBody(), "already in release train") {
+		t.Fatalf("comments = %+v", fiss.comments)
+	}
+	if !reWorkBranch.MatchString(branch) {
+		t.Fatalf("reWorkBranch does not match %q", branch)
+	}
+}
+
+func TestTrainFor_Serialized(t *testing.T) {
+	p := &Processor{TrainWindow: time.Hour}
+	gh := fakeGH{pr: &fakePRFull{}, git: &fakeGitFull{}}
+	tr, unlock := p.trainFor(context.Background(), "d", gh, "o", "r", "release/1")
+	if tr == nil {
+		t.Fatal("no train")
+	}
+
+	// Another pick onto the train waits for this one.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if other, _ := p.trainFor(ctx, "d", gh, "o", "r", "release/1"); other != nil {
+		t.Fatal("got the train while it was held")
+	}
+	unlock()
+	other, unlock := p.trainFor(context.Background(), "d", gh, "o", "r", "release/1")
+	defer unlock()
+	if other == nil || other.branch != tr.branch {
+		t.Fatalf("train = %+v; want %s", other, tr.branch)
+	}
+}
+
+func TestTrainFor_RefErrorSkipsTrain(t *testing.T) {
+	p := &Processor{TrainWindow: time.Hour}
+	gh := fakeGH{pr: &fakePRFull{}, git: &fakeGitFull{refErr: errors.New("502 bad gateway")}}
+	tr, unlock := p.trainFor(context.Background(), "d", gh, "o", "r", "release/1")
+	defer unlock()
+	if tr != nil {
+		t.Fatalf("train = %+v; want none when the branch can't be looked up", tr)
+	}
+}
+
+func TestTrainFor_SerializedAcrossReplicas(t *testing.T) {
+	leases := &shard.MemoryLeases{}
+	a := &Processor{TrainWindow: time.Hour, PRLockStore: leases, PRLockOwner: "a"}
+	b := &Processor{TrainWindow: time.Hour, PRLockStore: leases, PRLockOwner: "b"}
+	gh := fakeGH{pr: &fakePRFull{}, git: &fakeGitFull{}}
+	tr, unlock := a.trainFor(context.Background(), "d1", gh, "o", "r", "release/1")
+	if tr == nil {
+		t.Fatal("no train")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*prLockPoll)
+	defer cancel()
+	if other, _ := b.trainFor(ctx, "d2", gh, "o", "r", "release/1"); other != nil {
+		t.Fatal("replica b got the train while a held it")
+	}
+	unlock()
+	other, unlock := b.trainFor(context.Background(), "d2", gh, "o", "r", "release/1")
+	defer unlock()
+	if other == nil {
+		t.Fatal("replica b should get the train after a releases it")
+	}
+}
+
+func TestTrainHas_OnlyListedPicks(t *testing.T) {
+	intro := (&train{}).intro("release/1", "aaaa111", time.Hour)
+	tr := &train{pr: &github.PullRequest{Body: github.Ptr("Fixes a crash (see `cccc333`)." + intro + trainHeader +
+		"- PR #12 — Revert `dddd444` (commit `bbbb222`)\r\n")}}
+	for sha, want := range map[string]bool{"aaaa111": true, "bbbb222": true, "cccc333": false, "dddd444": false} {
+		if got := tr.has(sha); got != want {
+			t.Errorf("has(%s) = %v, want %v", sha, got, want)
+		}
+	}
+}
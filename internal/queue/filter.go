@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Filter is an ingest-time allowlist of GitHub events and, optionally, their
+// actions. It lets workers drop deliveries the processor would ignore anyway
+// (e.g. pull_request/synchronize) before signature verification and the full
+// payload unmarshal. A nil Filter allows everything.
+type Filter map[string]map[string]bool // event -> allowed actions (empty = any)
+
+// ParseFilter parses a spec like
+//
+//	pull_request:closed|labeled|unlabeled,create,label:deleted
+//
+// Entries are comma-separated; an event without ":" allows all its actions.
+// An empty spec returns a nil Filter.
+func ParseFilter(spec string) (Filter, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	f := Filter{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		event, actions, hasActions := strings.Cut(entry, ":")
+		event = strings.TrimSpace(event)
+		if event == "" {
+			return nil, fmt.Errorf("event filter %q: missing event name", entry)
+		}
+		set := f[event]
+		if set == nil {
+			set = map[string]bool{}
+			f[event] = set
+		}
+		if !hasActions {
+			continue
+		}
+		for _, a := range strings.Split(actions, "|") {
+			if a = strings.TrimSpace(a); a != "" {
+				set[a] = true
+			}
+		}
+		if len(set) == 0 {
+			return nil, fmt.Errorf("event filter %q: empty action list", entry)
+		}
+	}
+	return f, nil
+}
+
+// Allows reports whether event (with payload's top-level action) passes.
+// The action is only looked at when the event restricts actions.
+func (f Filter) Allows(event string, payload []byte) bool {
+	if f == nil {
+		return true
+	}
+	actions, ok := f[event]
+	if !ok {
+		return false
+	}
+	if len(actions) == 0 {
+		return true
+	}
+	return actions[PeekAction(payload)]
+}
+
+// PeekAction returns the top-level "action" string of a webhook payload
+// without decoding the rest of it. GitHub puts "action" first, so this
+// usually reads only a few bytes. It returns "" when absent or malformed.
+func PeekAction(payload []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return ""
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		key, _ := t.(string)
+		if key == "action" {
+			var a string
+			if dec.Decode(&a) != nil {
+				return ""
+			}
+			return a
+		}
+		if !skipValue(dec) {
+			return ""
+		}
+	}
+	return ""
+}
+
+// skipValue consumes one JSON value (scalar, object or array) token by token.
+func skipValue(dec *json.Decoder) bool {
+	depth := 0
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return false
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return true
+		}
+	}
+}
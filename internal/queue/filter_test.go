@@ -0,0 +1,49 @@
+package queue
+
+import "testing"
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter(" pull_request:closed|labeled , create,label:deleted ")
+	if err != nil {
+		t.Fatalf("ParseFilter: %v", err)
+	}
+	if len(f) != 3 || len(f["create"]) != 0 || !f["pull_request"]["labeled"] || !f["label"]["deleted"] {
+		t.Fatalf("unexpected filter: %#v", f)
+	}
+
+	if f, err := ParseFilter(""); err != nil || f != nil {
+		t.Fatalf("empty spec = %#v, %v; want nil, nil", f, err)
+	}
+	for _, bad := range []string{":closed", "pull_request:", "pull_request:|"} {
+		if _, err := ParseFilter(bad); err == nil {
+			t.Fatalf("ParseFilter(%q) = nil error", bad)
+		}
+	}
+}
+
+func TestFilter_Allows(t *testing.T) {
+	f, _ := ParseFilter("pull_request:closed|labeled,create")
+	cases := []struct {
+		name    string
+		filter  Filter
+		event   string
+		payload string
+		want    bool
+	}{
+		{name: "nil allows all", filter: nil, event: "push", payload: `{}`, want: true},
+		{name: "allowed action", filter: f, event: "pull_request", payload: `{"action":"closed","pull_request":{}}`, want: true},
+		{name: "action after other keys", filter: f, event: "pull_request", payload: `{"number":1,"pull_request":{"labels":[{"name":"x"}]},"action":"labeled"}`, want: true},
+		{name: "dropped action", filter: f, event: "pull_request", payload: `{"action":"synchronize"}`},
+		{name: "missing action", filter: f, event: "pull_request", payload: `{"pull_request":{}}`},
+		{name: "any action", filter: f, event: "create", payload: `{"ref":"x"}`, want: true},
+		{name: "unlisted event", filter: f, event: "push", payload: `{}`},
+		{name: "malformed payload", filter: f, event: "pull_request", payload: `{"action":`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.filter.Allows(tc.event, []byte(tc.payload)); got != tc.want {
+				t.Fatalf("Allows = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
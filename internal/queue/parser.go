@@ -8,6 +8,10 @@ import (
 	"strings"
 )
 
+// EventScheduledTask is the synthetic event for scheduled maintenance
+// messages, e.g. from EventBridge Scheduler: {"scheduled_task": {...}}.
+const EventScheduledTask = "scheduled_task"
+
 // ErrUnknownEvent is returned when we cannot determine the GitHub event type
 // from either an envelope's headers or the raw payload.
 var ErrUnknownEvent = errors.New("unknown event")
@@ -34,6 +38,8 @@ type Envelope struct {
 // - If the message body is an Envelope with headers, we prefer headers.
 // - If headers don’t include X-GitHub-Event, we try to infer from payload.
 // - If body is raw GH JSON (no envelope), we infer from payload.
+// - SNS notifications are unwrapped first; string message attributes act as headers.
+// - EventBridge events (detail-type/detail) are unwrapped to their detail.
 // - If we can’t infer, we return ErrUnknownEvent.
 func ParseSQSBody(body []byte) (event, delivery string, payload []byte, err error) {
 	b := bytes.TrimSpace(body)
@@ -41,36 +47,29 @@ func ParseSQSBody(body []byte) (event, delivery string, payload []byte, err erro
 		return "", "", nil, errors.New("empty message body")
 	}
 
-	// Try to parse as Envelope first.
-	var env Envelope
-	if json.Unmarshal(b, &env) == nil && (env.Headers != nil || len(env.Body) > 0) {
-		// Extract payload from env.Body, which might be:
-		//  - a JSON string containing the GH JSON
-		//  - a JSON object that IS the GH payload
-		var s string
-		if len(env.Body) > 0 && json.Unmarshal(env.Body, &s) == nil {
-			// body is a JSON string containing the GH JSON
-			payload = []byte(s)
-		} else {
-			// body is likely the GH JSON object already
-			payload = env.Body
+	// SNS→SQS fan-out (without raw message delivery) wraps the original
+	// message in a notification; unwrap and parse what was published.
+	if env, ok := unwrapSNS(b); ok {
+		b = env.Body
+		if inner, ok := asEnvelope(b); ok {
+			return fromEnvelope(inner)
 		}
-
-		// Prefer headers if available
-		if env.Headers != nil {
-			event = trim(env.Headers["X-GitHub-Event"])
-			delivery = trim(env.Headers["X-GitHub-Delivery"])
+		if len(env.Headers) > 0 {
+			return fromEnvelope(env)
 		}
+	}
 
-		// If event is still empty, infer from payload
-		if event == "" {
-			ev, derr := detectEventFromPayload(payload)
-			if derr != nil {
-				return "", "", nil, derr
-			}
-			event = ev
+	// EventBridge (rule → SQS target): the GitHub payload sits under "detail".
+	if env, ok := unwrapEventBridge(b); ok {
+		if inner, ok := asEnvelope(env.Body); ok {
+			return fromEnvelope(inner)
 		}
-		return event, delivery, payload, nil
+		return fromEnvelope(env)
+	}
+
+	// Try to parse as Envelope first.
+	if env, ok := asEnvelope(b); ok {
+		return fromEnvelope(env)
 	}
 
 	// Not an Envelope: treat as raw GH JSON and try to detect the event.
@@ -81,6 +80,110 @@ func ParseSQSBody(body []byte) (event, delivery string, payload []byte, err erro
 	return ev, "", b, nil
 }
 
+func asEnvelope(b []byte) (Envelope, bool) {
+	var env Envelope
+	if json.Unmarshal(b, &env) == nil && (env.Headers != nil || len(env.Body) > 0) {
+		return env, true
+	}
+	return Envelope{}, false
+}
+
+func fromEnvelope(env Envelope) (event, delivery string, payload []byte, err error) {
+	// Extract payload from env.Body, which might be:
+	//  - a JSON string containing the GH JSON
+	//  - a JSON object that IS the GH payload
+	var s string
+	if len(env.Body) > 0 && json.Unmarshal(env.Body, &s) == nil {
+		// body is a JSON string containing the GH JSON
+		payload = []byte(s)
+	} else {
+		// body is likely the GH JSON object already
+		payload = env.Body
+	}
+
+	// Prefer headers if available
+	if env.Headers != nil {
+		event = trim(env.Headers["X-GitHub-Event"])
+		delivery = trim(env.Headers["X-GitHub-Delivery"])
+	}
+
+	// If event is still empty, infer from payload
+	if event == "" {
+		ev, derr := detectEventFromPayload(payload)
+		if derr != nil {
+			return "", "", nil, derr
+		}
+		event = ev
+	}
+	return event, delivery, payload, nil
+}
+
+// snsNotification is the SNS envelope delivered to SQS subscribers when raw
+// message delivery is off.
+type snsNotification struct {
+	Type              string `json:"Type"`
+	Message           string `json:"Message"`
+	MessageAttributes map[string]struct {
+		Type  string `json:"Type"`
+		Value string `json:"Value"`
+	} `json:"MessageAttributes"`
+}
+
+// unwrapSNS returns the published message as Body, with string message
+// attributes (e.g. X-GitHub-Event set by the publisher) as Headers.
+func unwrapSNS(b []byte) (Envelope, bool) {
+	var n snsNotification
+	if json.Unmarshal(b, &n) != nil || n.Type != "Notification" || n.Message == "" {
+		return Envelope{}, false
+	}
+	env := Envelope{Body: json.RawMessage(bytes.TrimSpace([]byte(n.Message)))}
+	for k, v := range n.MessageAttributes {
+		if v.Type == "String" {
+			if env.Headers == nil {
+				env.Headers = map[string]string{}
+			}
+			env.Headers[k] = v.Value
+		}
+	}
+	return env, true
+}
+
+// eventBridgeEvent is the EventBridge event structure; GitHub integrations set
+// detail-type to the webhook event name and detail to its payload.
+type eventBridgeEvent struct {
+	ID         string          `json:"id"`
+	DetailType string          `json:"detail-type"`
+	Source     string          `json:"source"`
+	Detail     json.RawMessage `json:"detail"`
+}
+
+// unwrapEventBridge maps an EventBridge event onto an Envelope: detail-type
+// becomes X-GitHub-Event when it looks like an event name ("pull_request",
+// not "GitHub Webhook"), and the event id stands in for the delivery ID.
+func unwrapEventBridge(b []byte) (Envelope, bool) {
+	var e eventBridgeEvent
+	if json.Unmarshal(b, &e) != nil || e.DetailType == "" || e.Source == "" || len(e.Detail) == 0 {
+		return Envelope{}, false
+	}
+	env := Envelope{Headers: map[string]string{}, Body: e.Detail}
+	if isEventName(e.DetailType) {
+		env.Headers["X-GitHub-Event"] = e.DetailType
+	}
+	if e.ID != "" {
+		env.Headers["X-GitHub-Delivery"] = e.ID
+	}
+	return env, true
+}
+
+func isEventName(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && r != '_' {
+			return false
+		}
+	}
+	return s != ""
+}
+
 // detectEventFromPayload looks at top-level fields of the GitHub webhook JSON
 // to infer the event type. It’s intentionally conservative/tolerant.
 func detectEventFromPayload(p []byte) (string, error) {
@@ -89,18 +192,70 @@ func detectEventFromPayload(p []byte) (string, error) {
 		return "", err
 	}
 
+	// Scheduled maintenance triggers put on the queue by a scheduler.
+	if _, ok := m[EventScheduledTask]; ok {
+		return EventScheduledTask, nil
+	}
+
+	// pull_request_review events carry the PR too; tell them apart first.
+	if _, ok := m["review"]; ok {
+		if _, ok := m["pull_request"]; ok {
+			return "pull_request_review", nil
+		}
+	}
+
 	// pull_request events have a top-level "pull_request" object.
 	if _, ok := m["pull_request"]; ok {
 		return "pull_request", nil
 	}
 
-	// create events have "ref_type": "branch" (or "tag")
+	// CI completion events are named after their top-level object.
+	for _, ev := range []string{"workflow_run", "check_suite"} {
+		if _, ok := m[ev]; ok {
+			return ev, nil
+		}
+	}
+
+	// status events carry a commit "sha", a "state" and the status "context".
+	if _, ok := m["context"]; ok {
+		if _, ok := m["sha"]; ok {
+			return "status", nil
+		}
+	}
+
+	// issue_comment events have top-level "comment" and "issue" objects
+	// (on PRs the PR link sits inside "issue").
+	if _, ok := m["comment"]; ok {
+		if _, ok := m["issue"]; ok {
+			return "issue_comment", nil
+		}
+	}
+
+	// create and delete events have "ref_type": "branch" (or "tag"); only
+	// create carries "master_branch", while both carry "pusher_type".
 	if v, ok := m["ref_type"]; ok {
 		if s, ok := v.(string); ok && s != "" {
+			_, pusher := m["pusher_type"]
+			if _, master := m["master_branch"]; pusher && !master {
+				return "delete", nil
+			}
 			return "create", nil
 		}
 	}
 
+	// installation_repositories events list what changed.
+	if _, ok := m["repositories_added"]; ok {
+		return "installation_repositories", nil
+	}
+
+	// installation events list the repositories but, unlike repository
+	// events, carry no "repository".
+	if _, ok := m["repositories"]; ok {
+		if _, ok := m["repository"]; !ok {
+			return "installation", nil
+		}
+	}
+
 	// label events have "label" and an "action".
 	if _, hasLabel := m["label"]; hasLabel {
 		if _, hasAction := m["action"]; hasAction {
@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"testing"
@@ -67,6 +68,18 @@ func Test_detectEventFromPayload(t *testing.T) {
 			wantEvent: "pull_request",
 			wantErr:   false,
 		},
+		{
+			name:      "issue_comment event",
+			payload:   `{"action": "created", "issue": {"number": 7, "pull_request": {}}, "comment": {"body": "/cherry-pick"}}`,
+			wantEvent: "issue_comment",
+			wantErr:   false,
+		},
+		{
+			name:      "scheduled task",
+			payload:   `{"scheduled_task": {"name": "janitor", "installation_id": 1, "repos": ["o/r"]}}`,
+			wantEvent: EventScheduledTask,
+			wantErr:   false,
+		},
 		{
 			name:      "create event with branch",
 			payload:   `{"ref_type": "branch", "ref": "devops-release/0021"}`,
@@ -79,6 +92,48 @@ func Test_detectEventFromPayload(t *testing.T) {
 			wantEvent: "create",
 			wantErr:   false,
 		},
+		{
+			name:      "create event with pusher_type and master_branch",
+			payload:   `{"ref_type": "branch", "ref": "devops-release/0021", "master_branch": "main", "pusher_type": "user"}`,
+			wantEvent: "create",
+			wantErr:   false,
+		},
+		{
+			name:      "pull_request_review event",
+			payload:   `{"action": "submitted", "review": {"state": "approved"}, "pull_request": {"number": 1}}`,
+			wantEvent: "pull_request_review",
+			wantErr:   false,
+		},
+		{
+			name:      "workflow_run event",
+			payload:   `{"action": "completed", "workflow_run": {"head_branch": "autocherry/x/abc1234"}}`,
+			wantEvent: "workflow_run",
+			wantErr:   false,
+		},
+		{
+			name:      "installation event",
+			payload:   `{"action": "created", "installation": {"id": 1}, "repositories": [{"full_name": "o/r"}]}`,
+			wantEvent: "installation",
+			wantErr:   false,
+		},
+		{
+			name:      "installation_repositories event",
+			payload:   `{"action": "added", "installation": {"id": 1}, "repositories_added": [{"full_name": "o/r"}], "repositories_removed": []}`,
+			wantEvent: "installation_repositories",
+			wantErr:   false,
+		},
+		{
+			name:      "status event",
+			payload:   `{"sha": "abc1234", "state": "pending", "context": "ci/jenkins", "branches": [{"name": "autocherry/x/abc1234"}]}`,
+			wantEvent: "status",
+			wantErr:   false,
+		},
+		{
+			name:      "delete event",
+			payload:   `{"ref_type": "branch", "ref": "devops-release/0021", "pusher_type": "user"}`,
+			wantEvent: "delete",
+			wantErr:   false,
+		},
 		{
 			name:      "label event",
 			payload:   `{"label": {"name": "bug"}, "action": "created"}`,
@@ -264,3 +319,91 @@ func TestParseSQSBody_EnvelopeBodyAsString(t *testing.T) {
 		t.Errorf("ParseSQSBody() payload is not valid JSON: %v", err)
 	}
 }
+
+func TestParseSQSBody_SNSNotification(t *testing.T) {
+	wrap := func(message, attrs string) []byte {
+		m, _ := json.Marshal(message)
+		return []byte(`{"Type":"Notification","MessageId":"sns-1","TopicArn":"arn:aws:sns:eu-north-1:1:gh","Message":` +
+			string(m) + `,"MessageAttributes":{` + attrs + `}}`)
+	}
+	const ghPR = `{"action":"closed","pull_request":{"number":7}}`
+	tests := []struct {
+		name         string
+		body         []byte
+		wantEvent    string
+		wantDelivery string
+	}{
+		{
+			name:      "raw payload, event inferred",
+			body:      wrap(ghPR, ""),
+			wantEvent: "pull_request",
+		},
+		{
+			name:         "raw payload, headers from message attributes",
+			body:         wrap(`{"ref":"x"}`, `"X-GitHub-Event":{"Type":"String","Value":"push"},"X-GitHub-Delivery":{"Type":"String","Value":"d-1"}`),
+			wantEvent:    "push",
+			wantDelivery: "d-1",
+		},
+		{
+			name:         "envelope inside notification",
+			body:         wrap(`{"headers":{"X-GitHub-Event":"pull_request","X-GitHub-Delivery":"d-2"},"body":`+ghPR+`}`, ""),
+			wantEvent:    "pull_request",
+			wantDelivery: "d-2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, delivery, payload, err := ParseSQSBody(tt.body)
+			if err != nil {
+				t.Fatalf("ParseSQSBody() error = %v", err)
+			}
+			if event != tt.wantEvent || delivery != tt.wantDelivery {
+				t.Fatalf("event/delivery = %q/%q, want %q/%q", event, delivery, tt.wantEvent, tt.wantDelivery)
+			}
+			var m map[string]any
+			if err := json.Unmarshal(payload, &m); err != nil {
+				t.Fatalf("payload is not the inner JSON: %v (%s)", err, payload)
+			}
+			if _, isSNS := m["TopicArn"]; isSNS {
+				t.Fatalf("payload still wrapped: %s", payload)
+			}
+		})
+	}
+}
+
+func TestParseSQSBody_EventBridge(t *testing.T) {
+	const ghPR = `{"action":"closed","pull_request":{"number":7}}`
+	eb := func(detailType, detail string) []byte {
+		return []byte(`{"version":"0","id":"eb-123","detail-type":"` + detailType +
+			`","source":"github.com","account":"1","time":"2025-01-01T00:00:00Z","region":"eu-north-1","resources":[],"detail":` + detail + `}`)
+	}
+	tests := []struct {
+		name         string
+		body         []byte
+		wantEvent    string
+		wantDelivery string
+	}{
+		{name: "detail-type is the event", body: eb("push", `{"ref":"refs/heads/x"}`), wantEvent: "push", wantDelivery: "eb-123"},
+		{name: "generic detail-type, event inferred", body: eb("GitHub Webhook", ghPR), wantEvent: "pull_request", wantDelivery: "eb-123"},
+		{
+			name:         "detail is an envelope",
+			body:         eb("GitHub Webhook", `{"headers":{"X-GitHub-Event":"pull_request","X-GitHub-Delivery":"d-9"},"body":`+ghPR+`}`),
+			wantEvent:    "pull_request",
+			wantDelivery: "d-9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, delivery, payload, err := ParseSQSBody(tt.body)
+			if err != nil {
+				t.Fatalf("ParseSQSBody() error = %v", err)
+			}
+			if event != tt.wantEvent || delivery != tt.wantDelivery {
+				t.Fatalf("event/delivery = %q/%q, want %q/%q", event, delivery, tt.wantEvent, tt.wantDelivery)
+			}
+			if bytes.Contains(payload, []byte("detail-type")) {
+				t.Fatalf("payload still wrapped: %s", payload)
+			}
+		})
+	}
+}
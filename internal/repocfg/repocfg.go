@@ -0,0 +1,288 @@
+// Package repocfg reads per-repository settings from .github/cherry-pick.yml
+// on the default branch.
+package repocfg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Path is where a repository keeps its cherry-pick settings.
+const Path = ".github/cherry-pick.yml"
+
+// Config is the file's schema. Unknown keys are rejected so typos surface
+// instead of being silently ignored.
+//
+//	post_pick:
+//	  timeout: 5m
+//	  env:
+//	    GOFLAGS: -mod=mod
+//	  commands:
+//	    - make generate
+//	    - go mod tidy
+//	verify:
+//	  command: go build {packages}
+//	  timeout: 2m
+//	aliases:
+//	  devops:
+//	    branches: [devops-release/*]
+//	    latest: 2
+//	pick_strategy:
+//	  option: theirs
+//	  targets:
+//	    "devops-release/*": {strategy: ort, option: ours}
+//	milestones:
+//	  "release/1.4": "1.4.x"
+//	drafts: ["release/*"]
+//	pr:
+//	  title: "[{{.Target}}] {{.Title}}"
+type Config struct {
+	PostPick PostPick         `yaml:"post_pick"`
+	Verify   Verify           `yaml:"verify"`
+	PrePush  Verify           `yaml:"pre_push"` // like verify, but a failure fails the pick
+	Aliases  map[string]Alias `yaml:"aliases"`
+	// Fork ("owner/name") receives work branches when this repository
+	// refuses them, e.g. protected autocherry/* namespaces; backports are
+	// then opened from it.
+	Fork string `yaml:"fork"`
+	// PickStrategy resolves conflicts automatically with a merge strategy
+	// and option, per repository or per target branch.
+	PickStrategy PickStrategy `yaml:"pick_strategy"`
+	// Mainline, when set, is the parent (1-based) merge commits are picked
+	// against, for merge topologies the app cannot work out; 0 = detect.
+	Mainline int `yaml:"mainline"`
+	// Milestones maps target branch globs onto the milestone (title) their
+	// backport PRs get, instead of the source PR's.
+	Milestones map[string]string `yaml:"milestones"`
+	// Drafts are target branch globs whose backport PRs open as drafts, to
+	// be marked ready by hand ("*" = every target).
+	Drafts []string `yaml:"drafts"`
+	// PR overrides the backport PR title and body templates.
+	PR PRTemplate `yaml:"pr"`
+}
+
+// PRTemplate holds text/template sources for backport PRs (see
+// ParseTemplate); "" keeps the default.
+type PRTemplate struct {
+	Title string `yaml:"title"`
+	Body  string `yaml:"body"`
+}
+
+// ParseTemplate parses a backport PR title or body template; besides the
+// builtins it has join (strings.Join). Unknown fields are errors.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(template.FuncMap{"join": strings.Join}).Option("missingkey=error").Parse(text)
+}
+
+// PickStrategy is the merge strategy and -X options for the cherry-pick.
+// The first (by sorted glob) of Targets matching a target branch replaces
+// the repository-wide Strategy, Option and FindRenames.
+type PickStrategy struct {
+	Strategy    string              `yaml:"strategy"`
+	Option      string              `yaml:"option"`
+	FindRenames string              `yaml:"find_renames"`
+	Targets     map[string]Strategy `yaml:"targets"`
+}
+
+// Strategy is one merge strategy setting; empty fields keep git's defaults.
+// FindRenames is the rename similarity threshold ("40%", -X find-renames),
+// or "off" to turn rename detection off (-X no-renames).
+type Strategy struct {
+	Strategy    string `yaml:"strategy"`
+	Option      string `yaml:"option"`
+	FindRenames string `yaml:"find_renames"`
+}
+
+// PostPick lists commands run in the work tree after a pick, before push.
+type PostPick struct {
+	Commands []string          `yaml:"commands"`
+	Timeout  time.Duration     `yaml:"timeout"` // per command; 0 = default
+	Env      map[string]string `yaml:"env"`
+}
+
+// Verify is a quick check run after the pick and post_pick commands, before
+// push; its result is reported in the PR body. {packages} and {files} in
+// Command expand to the changed Go package directories and files.
+type Verify struct {
+	Command string            `yaml:"command"`
+	Timeout time.Duration     `yaml:"timeout"` // 0 = default
+	Env     map[string]string `yaml:"env"`
+}
+
+// Alias is the branch set a "backport: <name>" label stands for, resolved
+// when the label is processed. Branches are names or path.Match globs;
+// Latest keeps only the newest (highest-sorting) matches of each glob
+// (0 = all).
+type Alias struct {
+	Branches []string `yaml:"branches"`
+	Latest   int      `yaml:"latest"`
+}
+
+// strategies are the merge strategies cherry-pick accepts.
+var strategies = []string{"ort", "recursive", "resolve", "subtree"}
+
+// reStrategyOption bounds -X values to git's option syntax (theirs,
+// rename-threshold=50%, ...), so they cannot smuggle other flags.
+var reStrategyOption = regexp.MustCompile(`^[a-z][a-z0-9-]*(=[A-Za-z0-9%.-]+)?$`)
+
+// reFindRenames matches a rename similarity threshold: 0-100, optionally %.
+var reFindRenames = regexp.MustCompile(`^(100|[1-9]?[0-9])%?$`)
+
+// validate checks the strategy and option names; where labels the setting.
+func (s Strategy) validate(where string) error {
+	if s.Strategy != "" && !slices.Contains(strategies, s.Strategy) {
+		return fmt.Errorf("%s: %s.strategy must be one of %s, got %q", Path, where, strings.Join(strategies, ", "), s.Strategy)
+	}
+	if s.Option != "" && !reStrategyOption.MatchString(s.Option) {
+		return fmt.Errorf("%s: %s.option: invalid strategy option %q", Path, where, s.Option)
+	}
+	if s.FindRenames != "" && s.FindRenames != "off" && !reFindRenames.MatchString(s.FindRenames) {
+		return fmt.Errorf("%s: %s.find_renames must be a similarity like 40%% or off, got %q", Path, where, s.FindRenames)
+	}
+	return nil
+}
+
+// maxCommands bounds how much work one repository can schedule per pick.
+const maxCommands = 10
+
+// Parse decodes and validates a config file. An empty file is a zero Config.
+func Parse(b []byte) (*Config, error) {
+	var c Config
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", Path, err)
+	}
+	if n := len(c.PostPick.Commands); n > maxCommands {
+		return nil, fmt.Errorf("%s: post_pick has %d commands, at most %d allowed", Path, n, maxCommands)
+	}
+	for i, cmd := range c.PostPick.Commands {
+		if cmd == "" {
+			return nil, fmt.Errorf("%s: post_pick.commands[%d] is empty", Path, i)
+		}
+	}
+	if c.PostPick.Timeout < 0 {
+		return nil, fmt.Errorf("%s: post_pick.timeout must not be negative", Path)
+	}
+	if c.Verify.Timeout < 0 {
+		return nil, fmt.Errorf("%s: verify.timeout must not be negative", Path)
+	}
+	if c.PrePush.Timeout < 0 {
+		return nil, fmt.Errorf("%s: pre_push.timeout must not be negative", Path)
+	}
+	if owner, name, ok := strings.Cut(c.Fork, "/"); c.Fork != "" && (!ok || owner == "" || name == "" || strings.Contains(name, "/")) {
+		return nil, fmt.Errorf("%s: fork must be owner/name, got %q", Path, c.Fork)
+	}
+	if c.Mainline < 0 {
+		return nil, fmt.Errorf("%s: mainline must not be negative", Path)
+	}
+	for name, a := range c.Aliases {
+		if len(a.Branches) == 0 {
+			return nil, fmt.Errorf("%s: aliases.%s has no branches", Path, name)
+		}
+		for _, b := range a.Branches {
+			if _, err := path.Match(b, ""); b == "" || err != nil {
+				return nil, fmt.Errorf("%s: aliases.%s: invalid branch pattern %q", Path, name, b)
+			}
+		}
+		if a.Latest < 0 {
+			return nil, fmt.Errorf("%s: aliases.%s.latest must not be negative", Path, name)
+		}
+	}
+	if err := validateMilestones(c.Milestones); err != nil {
+		return nil, err
+	}
+	if _, err := ParseTemplate("pr.title", c.PR.Title); err != nil {
+		return nil, fmt.Errorf("%s: %w", Path, err)
+	}
+	if _, err := ParseTemplate("pr.body", c.PR.Body); err != nil {
+		return nil, fmt.Errorf("%s: %w", Path, err)
+	}
+	for _, glob := range c.Drafts {
+		if _, err := path.Match(glob, ""); glob == "" || err != nil {
+			return nil, fmt.Errorf("%s: drafts: invalid branch pattern %q", Path, glob)
+		}
+	}
+	ps := c.PickStrategy
+	if err := ps.repoWide().validate("pick_strategy"); err != nil {
+		return nil, err
+	}
+	for glob, s := range ps.Targets {
+		if _, err := path.Match(glob, ""); glob == "" || err != nil {
+			return nil, fmt.Errorf("%s: pick_strategy.targets: invalid branch pattern %q", Path, glob)
+		}
+		if err := s.validate("pick_strategy.targets." + glob); err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+// validateMilestones checks the milestones section's globs and titles.
+func validateMilestones(m map[string]string) error {
+	for glob, title := range m {
+		if _, err := path.Match(glob, ""); glob == "" || err != nil {
+			return fmt.Errorf("%s: milestones: invalid branch pattern %q", Path, glob)
+		}
+		if strings.TrimSpace(title) == "" {
+			return fmt.Errorf("%s: milestones.%s is empty", Path, glob)
+		}
+	}
+	return nil
+}
+
+// MilestoneFor returns the milestone title Milestones gives target's
+// backports: its exact entry, else the first matching glob in sorted order
+// ("" = none).
+func (c *Config) MilestoneFor(target string) string {
+	if title, ok := c.Milestones[target]; ok {
+		return title
+	}
+	globs := make([]string, 0, len(c.Milestones))
+	for g := range c.Milestones {
+		globs = append(globs, g)
+	}
+	slices.Sort(globs)
+	for _, g := range globs {
+		if ok, _ := path.Match(g, target); ok {
+			return c.Milestones[g]
+		}
+	}
+	return ""
+}
+
+// Draft reports whether target's backport PRs open as drafts.
+func (c *Config) Draft(target string) bool {
+	return slices.ContainsFunc(c.Drafts, func(g string) bool { ok, _ := path.Match(g, target); return ok })
+}
+
+// StrategyFor returns the pick strategy for target: the first matching
+// Targets entry in sorted glob order, else the repository-wide setting.
+func (ps PickStrategy) StrategyFor(target string) Strategy {
+	globs := make([]string, 0, len(ps.Targets))
+	for g := range ps.Targets {
+		globs = append(globs, g)
+	}
+	slices.Sort(globs)
+	for _, g := range globs {
+		if ok, _ := path.Match(g, target); ok {
+			return ps.Targets[g]
+		}
+	}
+	return ps.repoWide()
+}
+
+// repoWide is the setting for targets no Targets glob matches.
+func (ps PickStrategy) repoWide() Strategy {
+	return Strategy{Strategy: ps.Strategy, Option: ps.Option, FindRenames: ps.FindRenames}
+}
@@ -0,0 +1,167 @@
+package repocfg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		wantErr string
+		check   func(t *testing.T, c *Config)
+	}{
+		{
+			name: "empty file",
+			in:   "",
+			check: func(t *testing.T, c *Config) {
+				if len(c.PostPick.Commands) != 0 {
+					t.Fatalf("want no commands, got %v", c.PostPick.Commands)
+				}
+			},
+		},
+		{
+			name: "post_pick",
+			in:   "post_pick:\n  timeout: 90s\n  env:\n    GOFLAGS: -mod=mod\n  commands:\n    - make generate\n    - go mod tidy\n",
+			check: func(t *testing.T, c *Config) {
+				p := c.PostPick
+				if len(p.Commands) != 2 || p.Commands[0] != "make generate" {
+					t.Fatalf("commands = %v", p.Commands)
+				}
+				if p.Timeout != 90*time.Second || p.Env["GOFLAGS"] != "-mod=mod" {
+					t.Fatalf("timeout/env = %v/%v", p.Timeout, p.Env)
+				}
+			},
+		},
+		{
+			name: "verify",
+			in:   "verify:\n  command: go build {packages}\n  timeout: 1m\n",
+			check: func(t *testing.T, c *Config) {
+				if c.Verify.Command != "go build {packages}" || c.Verify.Timeout != time.Minute {
+					t.Fatalf("verify = %+v", c.Verify)
+				}
+			},
+		},
+		{
+			name: "aliases",
+			in:   "aliases:\n  devops:\n    branches: [devops-release/*, main-lts]\n    latest: 2\n",
+			check: func(t *testing.T, c *Config) {
+				a := c.Aliases["devops"]
+				if len(a.Branches) != 2 || a.Branches[0] != "devops-release/*" || a.Latest != 2 {
+					t.Fatalf("aliases = %+v", c.Aliases)
+				}
+			},
+		},
+		{
+			name: "fork",
+			in:   "fork: cherry-bot/app\n",
+			check: func(t *testing.T, c *Config) {
+				if c.Fork != "cherry-bot/app" {
+					t.Fatalf("fork = %q", c.Fork)
+				}
+			},
+		},
+		{
+			name: "pick_strategy",
+			in:   "pick_strategy:\n  option: theirs\n  find_renames: 30%\n  targets:\n    \"devops-release/*\": {strategy: ort, option: ours}\n    \"devops-release/00*\": {option: ours, find_renames: \"off\"}\n",
+			check: func(t *testing.T, c *Config) {
+				ps := c.PickStrategy
+				if got := ps.StrategyFor("devops-release/0021"); got != (Strategy{Strategy: "ort", Option: "ours"}) {
+					t.Fatalf("sorted first match: got %+v", got)
+				}
+				delete(ps.Targets, "devops-release/*")
+				if got := ps.StrategyFor("devops-release/0021"); got != (Strategy{Option: "ours", FindRenames: "off"}) {
+					t.Fatalf("target: got %+v", got)
+				}
+				if got := ps.StrategyFor("main-lts"); got != (Strategy{Option: "theirs", FindRenames: "30%"}) {
+					t.Fatalf("default: got %+v", got)
+				}
+			},
+		},
+		{
+			name: "mainline",
+			in:   "mainline: 2\n",
+			check: func(t *testing.T, c *Config) {
+				if c.Mainline != 2 {
+					t.Fatalf("mainline = %d", c.Mainline)
+				}
+			},
+		},
+		{
+			name: "milestones",
+			in:   "milestones:\n  \"release/*\": \"1.x\"\n  \"release/1.4\": \"1.4.x\"\n",
+			check: func(t *testing.T, c *Config) {
+				if got := c.MilestoneFor("release/1.4"); got != "1.4.x" {
+					t.Fatalf("release/1.4: milestone %q", got)
+				}
+				if got := c.MilestoneFor("release/2.0"); got != "1.x" {
+					t.Fatalf("release/2.0: milestone %q", got)
+				}
+				if got := c.MilestoneFor("main"); got != "" {
+					t.Fatalf("main: milestone %q", got)
+				}
+			},
+		},
+		{
+			name: "drafts",
+			in:   "drafts: [\"release/*\", main-lts]\n",
+			check: func(t *testing.T, c *Config) {
+				if !c.Draft("release/1.4") || !c.Draft("main-lts") || c.Draft("devops-release/0021") {
+					t.Fatalf("drafts = %v", c.Drafts)
+				}
+			},
+		},
+		{
+			name: "pr templates",
+			in:   "pr:\n  title: \"[{{.Target}}] {{.Title}}\"\n",
+			check: func(t *testing.T, c *Config) {
+				if c.PR.Title != "[{{.Target}}] {{.Title}}" || c.PR.Body != "" {
+					t.Fatalf("pr = %+v", c.PR)
+				}
+			},
+		},
+		{name: "bad pr template", in: "pr:\n  body: \"{{.Title\"\n", wantErr: "pr.body"},
+		{name: "bad drafts pattern", in: "drafts: [\"[\"]\n", wantErr: "drafts: invalid branch pattern"},
+		{name: "bad milestone pattern", in: "milestones:\n  \"[\": \"1.x\"\n", wantErr: "invalid branch pattern"},
+		{name: "empty milestone", in: "milestones:\n  main: \"\"\n", wantErr: "is empty"},
+		{name: "negative mainline", in: "mainline: -1\n", wantErr: "mainline must not"},
+		{name: "bad strategy", in: "pick_strategy:\n  strategy: octopus\n", wantErr: "must be one of"},
+		{name: "bad strategy option", in: "pick_strategy:\n  targets:\n    main: {option: \"ours --exec=x\"}\n", wantErr: "invalid strategy option"},
+		{name: "bad find_renames", in: "pick_strategy:\n  find_renames: 150%\n", wantErr: "find_renames must be"},
+		{name: "bad strategy target", in: "pick_strategy:\n  targets:\n    \"[\": {option: ours}\n", wantErr: "invalid branch pattern"},
+		{name: "bad fork", in: "fork: cherry-bot\n", wantErr: "owner/name"},
+		{name: "alias without branches", in: "aliases:\n  devops: {latest: 1}\n", wantErr: "no branches"},
+		{name: "bad alias pattern", in: "aliases:\n  devops:\n    branches: [\"devops-release/[\"]\n", wantErr: "invalid branch pattern"},
+		{
+			name: "pre_push",
+			in:   "pre_push:\n  command: make verify\n",
+			check: func(t *testing.T, c *Config) {
+				if c.PrePush.Command != "make verify" {
+					t.Fatalf("pre_push = %+v", c.PrePush)
+				}
+			},
+		},
+		{name: "negative pre_push timeout", in: "pre_push:\n  command: make\n  timeout: -1s\n", wantErr: "pre_push.timeout"},
+		{name: "negative verify timeout", in: "verify:\n  command: make\n  timeout: -1s\n", wantErr: "verify.timeout"},
+		{name: "unknown key", in: "post_pik:\n  commands: [x]\n", wantErr: "post_pik"},
+		{name: "empty command", in: "post_pick:\n  commands: [\"\"]\n", wantErr: "is empty"},
+		{name: "too many commands", in: "post_pick:\n  commands: [a,b,c,d,e,f,g,h,i,j,k]\n", wantErr: "at most"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := Parse([]byte(tc.in))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("want error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			tc.check(t, c)
+		})
+	}
+}
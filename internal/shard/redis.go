@@ -0,0 +1,51 @@
+package shard
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisLeases stores leases as Redis keys (SET NX PX).
+type RedisLeases struct {
+	Client *redis.Client
+	Prefix string // key namespace, default "cherrypick:"
+}
+
+func (r *RedisLeases) key(k string) string {
+	p := r.Prefix
+	if p == "" {
+		p = "cherrypick:"
+	}
+	return p + k
+}
+
+// Renew-or-claim atomically: succeed when free or already ours.
+var acquireScript = redis.NewScript(`
+local cur = redis.call("GET", KEYS[1])
+if cur == false or cur == ARGV[1] then
+  redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+  return 1
+end
+return 0`)
+
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Acquire implements LeaseStore.
+func (r *RedisLeases) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	n, err := acquireScript.Run(ctx, r.Client, []string{r.key(key)}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// Release implements LeaseStore.
+func (r *RedisLeases) Release(ctx context.Context, key, owner string) error {
+	return releaseScript.Run(ctx, r.Client, []string{r.key(key)}, owner).Err()
+}
@@ -0,0 +1,61 @@
+// Package shard holds leases shared by replicas, such as the per-PR event
+// lock (PR_LOCK_DISTRIBUTED).
+package shard
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LeaseStore is the distributed backend holding leases.
+type LeaseStore interface {
+	// Acquire claims key for owner or renews it when owner already holds it.
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Release drops key if (and only if) owner holds it.
+	Release(ctx context.Context, key, owner string) error
+}
+
+// MemoryLeases is an in-process LeaseStore (tests, single node).
+type MemoryLeases struct {
+	mu     sync.Mutex
+	leases map[string]lease
+	now    func() time.Time
+}
+
+type lease struct {
+	owner   string
+	expires time.Time
+}
+
+func (m *MemoryLeases) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+// Acquire implements LeaseStore.
+func (m *MemoryLeases) Acquire(_ context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.leases == nil {
+		m.leases = map[string]lease{}
+	}
+	now := m.clock()
+	if l, ok := m.leases[key]; ok && l.owner != owner && now.Before(l.expires) {
+		return false, nil
+	}
+	m.leases[key] = lease{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// Release implements LeaseStore.
+func (m *MemoryLeases) Release(_ context.Context, key, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if l, ok := m.leases[key]; ok && l.owner == owner {
+		delete(m.leases, key)
+	}
+	return nil
+}
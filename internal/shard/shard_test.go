@@ -0,0 +1,24 @@
+package shard
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLeases_ExpiredLeaseCanBeTaken(t *testing.T) {
+	now := time.Unix(1000, 0)
+	m := &MemoryLeases{now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	if ok, _ := m.Acquire(ctx, "k", "a", time.Second); !ok {
+		t.Fatalf("a should acquire free lease")
+	}
+	if ok, _ := m.Acquire(ctx, "k", "b", time.Second); ok {
+		t.Fatalf("b must not steal a live lease")
+	}
+	now = now.Add(2 * time.Second)
+	if ok, _ := m.Acquire(ctx, "k", "b", time.Second); !ok {
+		t.Fatalf("b should take over an expired lease")
+	}
+}
@@ -0,0 +1,145 @@
+// Package dynamodb is a state.Store in a DynamoDB table, for multi-replica
+// and Lambda deployments. It registers itself as STATE_BACKEND=dynamodb.
+//
+// The table needs a string hash key "repo" and a string range key
+// "work_branch"; each item also carries the status and the full record as
+// JSON in "record".
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	aws "github.com/aws/aws-sdk-go-v2/aws"
+	awscfg "github.com/aws/aws-sdk-go-v2/config"
+	awsddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+func init() {
+	state.Register("dynamodb", func(ctx context.Context, s state.Settings) (state.Store, error) {
+		if s.DynamoDBTable == "" {
+			return nil, errors.New("state: STATE_DYNAMODB_TABLE is required for STATE_BACKEND=dynamodb")
+		}
+		awsCfg, err := awscfg.LoadDefaultConfig(ctx, awscfg.WithRegion(s.AWSRegion))
+		if err != nil {
+			return nil, fmt.Errorf("load AWS config: %w", err)
+		}
+		return &Store{Client: awsddb.NewFromConfig(awsCfg), Table: s.DynamoDBTable}, nil
+	})
+}
+
+// ddbAPI is the subset of the DynamoDB client the store needs.
+type ddbAPI interface {
+	PutItem(ctx context.Context, in *awsddb.PutItemInput, optFns ...func(*awsddb.Options)) (*awsddb.PutItemOutput, error)
+	GetItem(ctx context.Context, in *awsddb.GetItemInput, optFns ...func(*awsddb.Options)) (*awsddb.GetItemOutput, error)
+	Query(ctx context.Context, in *awsddb.QueryInput, optFns ...func(*awsddb.Options)) (*awsddb.QueryOutput, error)
+	Scan(ctx context.Context, in *awsddb.ScanInput, optFns ...func(*awsddb.Options)) (*awsddb.ScanOutput, error)
+}
+
+// Store is a state.Store backed by one DynamoDB table.
+type Store struct {
+	Client ddbAPI
+	Table  string
+}
+
+func str(v string) types.AttributeValue { return &types.AttributeValueMemberS{Value: v} }
+
+func (s *Store) Put(ctx context.Context, r state.Record) error {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = s.Client.PutItem(ctx, &awsddb.PutItemInput{
+		TableName: aws.String(s.Table),
+		Item: map[string]types.AttributeValue{
+			"repo":        str(r.Repo),
+			"work_branch": str(r.WorkBranch),
+			"status":      str(string(r.Status)),
+			"record":      str(string(raw)),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("put state record: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) Get(ctx context.Context, repo, workBranch string) (state.Record, bool, error) {
+	out, err := s.Client.GetItem(ctx, &awsddb.GetItemInput{
+		TableName:      aws.String(s.Table),
+		Key:            map[string]types.AttributeValue{"repo": str(repo), "work_branch": str(workBranch)},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return state.Record{}, false, fmt.Errorf("get state record: %w", err)
+	}
+	if out.Item == nil {
+		return state.Record{}, false, nil
+	}
+	r, err := decode(out.Item)
+	return r, err == nil, err
+}
+
+// List returns the repo's records ordered by work branch; repo "" scans the
+// whole table.
+func (s *Store) List(ctx context.Context, repo string) ([]state.Record, error) {
+	var (
+		out   []state.Record
+		start map[string]types.AttributeValue
+	)
+	for {
+		var (
+			items []map[string]types.AttributeValue
+			next  map[string]types.AttributeValue
+		)
+		if repo == "" {
+			page, err := s.Client.Scan(ctx, &awsddb.ScanInput{TableName: aws.String(s.Table), ExclusiveStartKey: start})
+			if err != nil {
+				return nil, fmt.Errorf("list state records: %w", err)
+			}
+			items, next = page.Items, page.LastEvaluatedKey
+		} else {
+			page, err := s.Client.Query(ctx, &awsddb.QueryInput{
+				TableName:                 aws.String(s.Table),
+				KeyConditionExpression:    aws.String("repo = :repo"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{":repo": str(repo)},
+				ExclusiveStartKey:         start,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("list state records: %w", err)
+			}
+			items, next = page.Items, page.LastEvaluatedKey
+		}
+		for _, it := range items {
+			r, err := decode(it)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, r)
+		}
+		if len(next) == 0 {
+			break
+		}
+		start = next
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key() < out[j].Key() })
+	return out, nil
+}
+
+func decode(item map[string]types.AttributeValue) (state.Record, error) {
+	v, ok := item["record"].(*types.AttributeValueMemberS)
+	if !ok {
+		return state.Record{}, errors.New("decode state record: missing record attribute")
+	}
+	var r state.Record
+	if err := json.Unmarshal([]byte(v.Value), &r); err != nil {
+		return state.Record{}, fmt.Errorf("decode state record: %w", err)
+	}
+	return r, nil
+}
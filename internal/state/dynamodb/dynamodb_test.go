@@ -0,0 +1,93 @@
+package dynamodb
+
+import (
+	"context"
+	"testing"
+
+	awsddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+// fakeDDB keeps items by repo and work branch and pages Query/Scan one item
+// at a time to exercise pagination.
+type fakeDDB struct {
+	items map[string]map[string]types.AttributeValue
+}
+
+func s(item map[string]types.AttributeValue, k string) string {
+	v, _ := item[k].(*types.AttributeValueMemberS)
+	if v == nil {
+		return ""
+	}
+	return v.Value
+}
+
+func (f *fakeDDB) key(item map[string]types.AttributeValue) string {
+	return s(item, "repo") + " " + s(item, "work_branch")
+}
+
+func (f *fakeDDB) PutItem(_ context.Context, in *awsddb.PutItemInput, _ ...func(*awsddb.Options)) (*awsddb.PutItemOutput, error) {
+	if f.items == nil {
+		f.items = map[string]map[string]types.AttributeValue{}
+	}
+	f.items[f.key(in.Item)] = in.Item
+	return &awsddb.PutItemOutput{}, nil
+}
+
+func (f *fakeDDB) GetItem(_ context.Context, in *awsddb.GetItemInput, _ ...func(*awsddb.Options)) (*awsddb.GetItemOutput, error) {
+	return &awsddb.GetItemOutput{Item: f.items[f.key(in.Key)]}, nil
+}
+
+func (f *fakeDDB) page(repo string, start map[string]types.AttributeValue) (items []map[string]types.AttributeValue, next map[string]types.AttributeValue) {
+	after := ""
+	if start != nil {
+		after = f.key(start)
+	}
+	best := ""
+	for k, it := range f.items {
+		if (repo == "" || s(it, "repo") == repo) && k > after && (best == "" || k < best) {
+			best = k
+		}
+	}
+	if best == "" {
+		return nil, nil
+	}
+	it := f.items[best]
+	return []map[string]types.AttributeValue{it}, map[string]types.AttributeValue{"repo": it["repo"], "work_branch": it["work_branch"]}
+}
+
+func (f *fakeDDB) Query(_ context.Context, in *awsddb.QueryInput, _ ...func(*awsddb.Options)) (*awsddb.QueryOutput, error) {
+	repo := s(in.ExpressionAttributeValues, ":repo")
+	items, next := f.page(repo, in.ExclusiveStartKey)
+	return &awsddb.QueryOutput{Items: items, LastEvaluatedKey: next}, nil
+}
+
+func (f *fakeDDB) Scan(_ context.Context, in *awsddb.ScanInput, _ ...func(*awsddb.Options)) (*awsddb.ScanOutput, error) {
+	items, next := f.page("", in.ExclusiveStartKey)
+	return &awsddb.ScanOutput{Items: items, LastEvaluatedKey: next}, nil
+}
+
+func TestStore_PutGetList(t *testing.T) {
+	ctx := context.Background()
+	st := &Store{Client: &fakeDDB{}, Table: "backports"}
+	_ = st.Put(ctx, state.Record{Repo: "o/r", WorkBranch: "autocherry/rel-1/abc1234", Status: state.StatusOpen})
+	_ = st.Put(ctx, state.Record{Repo: "o/r", WorkBranch: "autocherry/rel-1/abc1234", Status: state.StatusMerged}) // upsert
+	_ = st.Put(ctx, state.Record{Repo: "o/r", WorkBranch: "autocherry/rel-2/abc1234", Status: state.StatusOpen})
+	_ = st.Put(ctx, state.Record{Repo: "o/other", WorkBranch: "autocherry/rel-1/def5678", Status: state.StatusOpen})
+
+	r, ok, err := st.Get(ctx, "o/r", "autocherry/rel-1/abc1234")
+	if err != nil || !ok || r.Status != state.StatusMerged {
+		t.Fatalf("Get = %+v, %v, %v; want merged record", r, ok, err)
+	}
+	if _, ok, _ := st.Get(ctx, "o/r", "missing"); ok {
+		t.Fatalf("Get(missing) found a record")
+	}
+	if got, err := st.List(ctx, "o/r"); err != nil || len(got) != 2 || got[1].WorkBranch != "autocherry/rel-2/abc1234" {
+		t.Fatalf("List(o/r) = %+v, %v", got, err)
+	}
+	if got, _ := st.List(ctx, ""); len(got) != 3 {
+		t.Fatalf("List(all) = %d records, want 3", len(got))
+	}
+}
@@ -0,0 +1,71 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File is a Store kept in a single JSON file, rewritten atomically on every
+// Put. Good enough for one replica and thousands of records.
+type File struct {
+	path string
+	mu   sync.Mutex // serializes writes to path
+	mem  *Memory
+}
+
+// OpenFile loads the store at path, creating it on first Put.
+func OpenFile(path string) (*File, error) {
+	f := &File{path: path, mem: NewMemory()}
+	raw, err := os.ReadFile(path) // #nosec G304 -- path is operator configuration (STATE_FILE)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read state file: %w", err)
+	}
+	var recs []Record
+	if err := json.Unmarshal(raw, &recs); err != nil {
+		return nil, fmt.Errorf("parse state file %s: %w", path, err)
+	}
+	for _, r := range recs {
+		f.mem.records[r.Key()] = r
+	}
+	return f, nil
+}
+
+func (f *File) Put(ctx context.Context, r Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_ = f.mem.Put(ctx, r)
+	all, _ := f.mem.List(ctx, "")
+	raw, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".state-*.json")
+	if err != nil {
+		return fmt.Errorf("write state file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write state file: %w", err)
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func (f *File) Get(ctx context.Context, repo, workBranch string) (Record, bool, error) {
+	return f.mem.Get(ctx, repo, workBranch)
+}
+
+func (f *File) List(ctx context.Context, repo string) ([]Record, error) {
+	return f.mem.List(ctx, repo)
+}
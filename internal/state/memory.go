@@ -0,0 +1,44 @@
+package state
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Memory is an in-process Store, lost on restart.
+type Memory struct {
+	mu      sync.RWMutex
+	records map[string]Record
+}
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() *Memory { return &Memory{records: map[string]Record{}} }
+
+func (m *Memory) Put(_ context.Context, r Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[r.Key()] = r
+	return nil
+}
+
+func (m *Memory) Get(_ context.Context, repo, workBranch string) (Record, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.records[Record{Repo: repo, WorkBranch: workBranch}.Key()]
+	return r, ok, nil
+}
+
+// List returns the repo's records ordered by work branch; repo "" lists all.
+func (m *Memory) List(_ context.Context, repo string) ([]Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]Record, 0, len(m.records))
+	for _, r := range m.records {
+		if repo == "" || r.Repo == repo {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key() < out[j].Key() })
+	return out, nil
+}
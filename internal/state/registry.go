@@ -0,0 +1,78 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Settings are what the drivers read. Only the selected driver's fields
+// matter; the caller fills them from its own configuration.
+type Settings struct {
+	Backend       string // driver name (STATE_BACKEND); "" disables the store
+	File          string // file: JSON file holding all records
+	SQLitePath    string // sqlite: database file
+	DynamoDBTable string // dynamodb: table keyed by repo (hash) and work_branch (range)
+	AWSRegion     string // dynamodb
+}
+
+// Driver opens a Store from s. It should validate its own settings and
+// connect, so misconfiguration fails at startup.
+type Driver func(ctx context.Context, s Settings) (Store, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{}
+)
+
+func init() {
+	Register("memory", func(context.Context, Settings) (Store, error) { return NewMemory(), nil })
+	Register("file", func(_ context.Context, s Settings) (Store, error) {
+		if s.File == "" {
+			return nil, errors.New("state: STATE_FILE is required for STATE_BACKEND=file")
+		}
+		return OpenFile(s.File)
+	})
+}
+
+// Register makes a driver selectable by name (STATE_BACKEND). Drivers call it
+// from init; registering the same name twice panics, as with database/sql.
+func Register(name string, d Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	name = strings.ToLower(name)
+	if _, dup := drivers[name]; dup {
+		panic("state: Register called twice for " + name)
+	}
+	drivers[name] = d
+}
+
+// Open returns the store selected by s.Backend, or nil when the state store
+// is disabled.
+func Open(ctx context.Context, s Settings) (Store, error) {
+	if s.Backend == "" {
+		return nil, nil
+	}
+	driversMu.RLock()
+	d, ok := drivers[strings.ToLower(s.Backend)]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("state: unknown STATE_BACKEND %q (available: %s)", s.Backend, strings.Join(Drivers(), ", "))
+	}
+	return d(ctx, s)
+}
+
+// Drivers lists registered drivers, sorted.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	out := make([]string, 0, len(drivers))
+	for n := range drivers {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,121 @@
+// Package sqlite is a state.Store in a single SQLite database file, for
+// single-node installs that want durable records without a cloud database.
+// It registers itself as STATE_BACKEND=sqlite.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // pure-Go driver; the binaries are built with CGO_ENABLED=0
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+func init() {
+	state.Register("sqlite", func(ctx context.Context, s state.Settings) (state.Store, error) {
+		if s.SQLitePath == "" {
+			return nil, errors.New("state: STATE_SQLITE_PATH is required for STATE_BACKEND=sqlite")
+		}
+		return Open(ctx, s.SQLitePath)
+	})
+}
+
+// Records are stored as JSON, with the key and a few columns copied out for
+// ad-hoc queries (sqlite3 state.db 'select * from backports where status=...').
+const schema = `
+CREATE TABLE IF NOT EXISTS backports (
+	repo        TEXT NOT NULL,
+	work_branch TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	source_pr   INTEGER NOT NULL DEFAULT 0,
+	updated_at  TEXT NOT NULL,
+	record      TEXT NOT NULL,
+	PRIMARY KEY (repo, work_branch)
+)`
+
+// Store is a state.Store backed by SQLite.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens (creating if needed) the database at path.
+func Open(ctx context.Context, path string) (*Store, error) {
+	// WAL lets readers (dashboards, support bundles) run alongside writes;
+	// busy_timeout absorbs the brief lock of concurrent Puts.
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite %s: %w", path, err)
+	}
+	db.SetMaxOpenConns(1) // one writer; SQLite serializes anyway
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("init sqlite %s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close releases the database.
+func (s *Store) Close() error { return s.db.Close() }
+
+func (s *Store) Put(ctx context.Context, r state.Record) error {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+INSERT INTO backports (repo, work_branch, status, source_pr, updated_at, record)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (repo, work_branch) DO UPDATE SET
+	status = excluded.status, source_pr = excluded.source_pr,
+	updated_at = excluded.updated_at, record = excluded.record`,
+		r.Repo, r.WorkBranch, string(r.Status), r.SourcePR, r.UpdatedAt.UTC().Format(time.RFC3339), string(raw))
+	if err != nil {
+		return fmt.Errorf("put state record: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) Get(ctx context.Context, repo, workBranch string) (state.Record, bool, error) {
+	var raw string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT record FROM backports WHERE repo = ? AND work_branch = ?`, repo, workBranch).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return state.Record{}, false, nil
+	}
+	if err != nil {
+		return state.Record{}, false, fmt.Errorf("get state record: %w", err)
+	}
+	var r state.Record
+	if err := json.Unmarshal([]byte(raw), &r); err != nil {
+		return state.Record{}, false, fmt.Errorf("decode state record: %w", err)
+	}
+	return r, true, nil
+}
+
+// List returns the repo's records ordered by work branch; repo "" lists all.
+func (s *Store) List(ctx context.Context, repo string) ([]state.Record, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT record FROM backports WHERE ? = '' OR repo = ? ORDER BY repo, work_branch`, repo, repo)
+	if err != nil {
+		return nil, fmt.Errorf("list state records: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var out []state.Record
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("list state records: %w", err)
+		}
+		var r state.Record
+		if err := json.Unmarshal([]byte(raw), &r); err != nil {
+			return nil, fmt.Errorf("decode state record: %w", err)
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+func TestStore_PutGetListAcrossOpen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.db")
+
+	s, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	_ = s.Put(ctx, state.Record{Repo: "o/r", WorkBranch: "autocherry/rel-1/abc1234", Status: state.StatusOpen, UpdatedAt: now})
+	_ = s.Put(ctx, state.Record{Repo: "o/r", WorkBranch: "autocherry/rel-1/abc1234", SourcePR: 7, Status: state.StatusMerged, UpdatedAt: now}) // upsert
+	if err := s.Put(ctx, state.Record{Repo: "o/other", WorkBranch: "autocherry/rel-1/def5678", Status: state.StatusOpen}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	_ = s.Close()
+
+	s, err = Open(ctx, path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	r, ok, err := s.Get(ctx, "o/r", "autocherry/rel-1/abc1234")
+	if err != nil || !ok || r.Status != state.StatusMerged || r.SourcePR != 7 || !r.UpdatedAt.Equal(now) {
+		t.Fatalf("Get = %+v, %v, %v; want merged record", r, ok, err)
+	}
+	if _, ok, _ := s.Get(ctx, "o/r", "missing"); ok {
+		t.Fatalf("Get(missing) found a record")
+	}
+	if got, _ := s.List(ctx, "o/r"); len(got) != 1 {
+		t.Fatalf("List(o/r) = %d records, want 1", len(got))
+	}
+	if got, _ := s.List(ctx, ""); len(got) != 2 || got[0].Repo != "o/other" {
+		t.Fatalf("List(all) = %+v", got)
+	}
+}
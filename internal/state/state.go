@@ -0,0 +1,51 @@
+// Package state records what the app did for each backport (one record per
+// repo and work branch), for dashboards, dedupe and SLA tracking.
+//
+// It holds backport records only. Delivery dedupe (ingest/sqs) and the PR
+// lock leases (shard) keep their own stores, and there is no audit log. The
+// package reads no app config: callers pass drivers their Settings.
+package state
+
+import (
+	"context"
+	"time"
+)
+
+// Status is the lifecycle state of a backport.
+type Status string
+
+const (
+	StatusOpen     Status = "open"     // cherry-pick PR is open
+	StatusMerged   Status = "merged"   // cherry-pick PR was merged
+	StatusClosed   Status = "closed"   // cherry-pick PR was closed without merge
+	StatusConflict Status = "conflict" // pick failed; manual backport requested
+	StatusNoop     Status = "noop"     // nothing to pick on the target
+	StatusOrphaned Status = "orphaned" // work branch exists without a PR
+)
+
+// Record is one backport of a source PR's commit onto a target branch.
+type Record struct {
+	Repo       string    `json:"repo"` // owner/name
+	WorkBranch string    `json:"work_branch"`
+	Target     string    `json:"target,omitempty"`
+	SHA        string    `json:"sha,omitempty"`
+	SourcePR   int       `json:"source_pr,omitempty"`
+	PRNumber   int       `json:"pr_number,omitempty"`
+	PRURL      string    `json:"pr_url,omitempty"`
+	Status     Status    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// Backfilled marks records reconstructed from GitHub rather than
+	// written while the app processed the event.
+	Backfilled bool `json:"backfilled,omitempty"`
+}
+
+// Key identifies a record; the work branch already encodes target and SHA.
+func (r Record) Key() string { return r.Repo + " " + r.WorkBranch }
+
+// Store persists backport records. Put upserts by Key.
+type Store interface {
+	Put(ctx context.Context, r Record) error
+	Get(ctx context.Context, repo, workBranch string) (Record, bool, error)
+	List(ctx context.Context, repo string) ([]Record, error)
+}
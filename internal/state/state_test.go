@@ -0,0 +1,71 @@
+package state
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMemory_PutGetList(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	_ = m.Put(ctx, Record{Repo: "o/r", WorkBranch: "autocherry/rel-1/abc1234", Status: StatusOpen})
+	_ = m.Put(ctx, Record{Repo: "o/r", WorkBranch: "autocherry/rel-1/abc1234", Status: StatusMerged}) // upsert
+	_ = m.Put(ctx, Record{Repo: "o/other", WorkBranch: "autocherry/rel-1/def5678", Status: StatusOpen})
+
+	r, ok, _ := m.Get(ctx, "o/r", "autocherry/rel-1/abc1234")
+	if !ok || r.Status != StatusMerged {
+		t.Fatalf("Get = %+v, %v; want merged record", r, ok)
+	}
+	if _, ok, _ := m.Get(ctx, "o/r", "missing"); ok {
+		t.Fatalf("Get(missing) found a record")
+	}
+	if got, _ := m.List(ctx, "o/r"); len(got) != 1 {
+		t.Fatalf("List(o/r) = %d records, want 1", len(got))
+	}
+	if got, _ := m.List(ctx, ""); len(got) != 2 {
+		t.Fatalf("List(all) = %d records, want 2", len(got))
+	}
+}
+
+func TestFile_PersistsAcrossOpen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile (new): %v", err)
+	}
+	if err := f.Put(ctx, Record{Repo: "o/r", WorkBranch: "autocherry/rel-1/abc1234", SourcePR: 7, Status: StatusOpen}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	g, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile (reload): %v", err)
+	}
+	r, ok, _ := g.Get(ctx, "o/r", "autocherry/rel-1/abc1234")
+	if !ok || r.SourcePR != 7 || r.Status != StatusOpen {
+		t.Fatalf("reloaded record = %+v, %v", r, ok)
+	}
+}
+
+func TestOpen_SelectsDriver(t *testing.T) {
+	ctx := context.Background()
+	if st, err := Open(ctx, Settings{}); err != nil || st != nil {
+		t.Fatalf("empty backend: got %v, %v; want disabled", st, err)
+	}
+	if st, err := Open(ctx, Settings{Backend: "memory"}); err != nil {
+		t.Fatalf("memory: %v", err)
+	} else if _, ok := st.(*Memory); !ok {
+		t.Fatalf("memory: got %T", st)
+	}
+	if _, err := Open(ctx, Settings{Backend: "file"}); err == nil {
+		t.Fatalf("file without STATE_FILE: want error")
+	}
+	_, err := Open(ctx, Settings{Backend: "etcd"})
+	if err == nil || !strings.Contains(err.Error(), "available: file, memory") {
+		t.Fatalf("unknown backend: got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package support
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/state"
+)
+
+// Query selects what a bundle is about: one delivery, or every delivery
+// seen for a pull request.
+type Query struct {
+	Delivery string
+	Repo     string // owner/name, with PR
+	PR       int
+}
+
+// Builder assembles bundles from a Recorder's history and the state store.
+type Builder struct {
+	Recorder *Recorder
+	State    state.Store // optional
+}
+
+// Manifest describes a bundle's contents; it is the bundle's manifest.json.
+type Manifest struct {
+	Query       Query     `json:"query"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Deliveries  []string  `json:"deliveries"`
+	LogLines    int       `json:"log_lines"`
+	GitLines    int       `json:"git_lines"`
+	APICalls    int       `json:"api_calls"`
+	Records     int       `json:"state_records"`
+	Notes       []string  `json:"notes,omitempty"`
+}
+
+// apiCall aggregates gh.api log lines per method and path.
+type apiCall struct {
+	Method   string         `json:"method"`
+	Path     string         `json:"path"`
+	Count    int            `json:"count"`
+	Statuses map[string]int `json:"statuses"`
+}
+
+// transcriptPrefixes mark log lines without a delivery that belong to the
+// work of whichever delivery was running at the time (git, cherry, API).
+var transcriptPrefixes = []string{"git.", "cherry."}
+
+// Write streams a zip bundle for q to w and returns its manifest. Git and API
+// lines carry no delivery ID, so they are matched by time window; with
+// concurrent processing they may include other deliveries' work.
+func (b *Builder) Write(ctx context.Context, w io.Writer, q Query) (Manifest, error) {
+	m := Manifest{Query: q, GeneratedAt: time.Now().UTC()}
+	logs, envs := b.Recorder.entries(), b.Recorder.envelopes()
+
+	deliveries := map[string]bool{}
+	if q.Delivery != "" {
+		deliveries[q.Delivery] = true
+		for _, e := range envs {
+			if e.Delivery == q.Delivery && q.PR == 0 {
+				q.Repo, q.PR = subjectOf(e.Body)
+			}
+		}
+	}
+	if q.PR != 0 {
+		want := strconv.Itoa(q.PR)
+		for _, e := range envs {
+			if repo, pr := subjectOf(e.Body); pr == q.PR && (q.Repo == "" || repo == q.Repo) {
+				deliveries[e.Delivery] = true
+			}
+		}
+		for _, l := range logs {
+			if d := l.Attrs["delivery"]; d != "" && l.Attrs["pr"] == want && (l.Attrs["repo"] == "" || q.Repo == "" || l.Attrs["repo"] == q.Repo) {
+				deliveries[d] = true
+			}
+		}
+	}
+	for d := range deliveries {
+		m.Deliveries = append(m.Deliveries, d)
+	}
+	sort.Strings(m.Deliveries)
+
+	var own, transcript []Entry
+	var from, to time.Time
+	for _, l := range logs {
+		if deliveries[l.Attrs["delivery"]] {
+			own = append(own, l)
+			if from.IsZero() || l.Time.Before(from) {
+				from = l.Time
+			}
+			if l.Time.After(to) {
+				to = l.Time
+			}
+		}
+	}
+	calls := map[string]*apiCall{}
+	if !from.IsZero() {
+		to = to.Add(time.Second)
+		for _, l := range logs {
+			if l.Attrs["delivery"] != "" || l.Time.Before(from) || l.Time.After(to) {
+				continue
+			}
+			if l.Msg == "gh.api" {
+				key := l.Attrs["method"] + " " + l.Attrs["path"]
+				c := calls[key]
+				if c == nil {
+					c = &apiCall{Method: l.Attrs["method"], Path: l.Attrs["path"], Statuses: map[string]int{}}
+					calls[key] = c
+				}
+				c.Count++
+				c.Statuses[l.Attrs["status"]]++
+				m.APICalls++
+				continue
+			}
+			for _, p := range transcriptPrefixes {
+				if strings.HasPrefix(l.Msg, p) {
+					transcript = append(transcript, l)
+					break
+				}
+			}
+		}
+		m.Notes = append(m.Notes, "git.jsonl and api_summary.json are matched by time window and may include concurrent work")
+	}
+	m.LogLines, m.GitLines = len(own), len(transcript)
+
+	var records []state.Record
+	if b.State != nil && q.Repo != "" && q.PR != 0 {
+		all, err := b.State.List(ctx, q.Repo)
+		if err != nil {
+			m.Notes = append(m.Notes, "state: "+Redact(err.Error()))
+		}
+		for _, r := range all {
+			if r.SourcePR == q.PR || r.PRNumber == q.PR {
+				records = append(records, r)
+			}
+		}
+	}
+	m.Records = len(records)
+	if len(m.Deliveries) == 0 {
+		m.Notes = append(m.Notes, "no matching deliveries in memory; they may be older than the buffer or handled by another replica")
+	}
+
+	zw := zip.NewWriter(w)
+	if err := writeJSON(zw, "manifest.json", m); err != nil {
+		return m, err
+	}
+	if err := writeLines(zw, "logs.jsonl", own); err != nil {
+		return m, err
+	}
+	if err := writeLines(zw, "git.jsonl", transcript); err != nil {
+		return m, err
+	}
+	summary := make([]*apiCall, 0, len(calls))
+	for _, c := range calls {
+		summary = append(summary, c)
+	}
+	sort.Slice(summary, func(i, j int) bool { return summary[i].Count > summary[j].Count })
+	if err := writeJSON(zw, "api_summary.json", summary); err != nil {
+		return m, err
+	}
+	if err := writeJSON(zw, "state.json", records); err != nil {
+		return m, err
+	}
+	for _, e := range envs {
+		if !deliveries[e.Delivery] {
+			continue
+		}
+		if err := writeJSON(zw, "envelopes/"+fileSafe(e.Delivery)+".json", scrubEnvelope(e)); err != nil {
+			return m, err
+		}
+	}
+	return m, zw.Close()
+}
+
+// subjectOf extracts the repository and PR/issue number from a payload.
+func subjectOf(body []byte) (repo string, pr int) {
+	var p struct {
+		Number      int `json:"number"`
+		PullRequest struct {
+			Number int `json:"number"`
+		} `json:"pull_request"`
+		Issue struct {
+			Number int `json:"number"`
+		} `json:"issue"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if json.Unmarshal(body, &p) != nil {
+		return "", 0
+	}
+	pr = p.Number
+	if pr == 0 {
+		pr = p.PullRequest.Number
+	}
+	if pr == 0 {
+		pr = p.Issue.Number
+	}
+	return p.Repository.FullName, pr
+}
+
+func scrubEnvelope(e Envelope) any {
+	body := json.RawMessage(Redact(string(e.Body)))
+	if !json.Valid(body) {
+		body, _ = json.Marshal(string(body))
+	}
+	return struct {
+		Delivery string            `json:"delivery"`
+		Event    string            `json:"event"`
+		Received time.Time         `json:"received"`
+		Headers  map[string]string `json:"headers,omitempty"`
+		Body     json.RawMessage   `json:"body"`
+	}{e.Delivery, e.Event, e.Received, redactHeaders(e.Headers), body}
+}
+
+var reUnsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+func fileSafe(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return reUnsafeName.ReplaceAllString(s, "_")
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write([]byte(Redact(string(b)) + "\n"))
+	return err
+}
+
+func writeLines(zw *zip.Writer, name string, entries []Entry) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if _, err := f.Write([]byte(Redact(string(b)) + "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package support
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Handler serves GET ?delivery=<id> or ?repo=owner/name&pr=<n> as a zip
+// bundle. Requests must carry "Authorization: Bearer <Token>"; an empty
+// Token rejects everything.
+type Handler struct {
+	Builder *Builder
+	Token   string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if h.Token == "" || !ok || subtle.ConstantTimeCompare([]byte(got), []byte(h.Token)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	q := Query{Delivery: r.URL.Query().Get("delivery"), Repo: r.URL.Query().Get("repo")}
+	if s := r.URL.Query().Get("pr"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			http.Error(w, "pr must be a positive number", http.StatusBadRequest)
+			return
+		}
+		q.PR = n
+	}
+	if q.Delivery == "" && (q.PR == 0 || !strings.Contains(q.Repo, "/")) {
+		http.Error(w, "want ?delivery=<id> or ?repo=owner/name&pr=<n>", http.StatusBadRequest)
+		return
+	}
+
+	var buf bytes.Buffer
+	m, err := h.Builder.Write(r.Context(), &buf, q)
+	if err != nil {
+		slog.Error("support.bundle_error", "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	name := "support-" + fileSafe(q.Delivery) + ".zip"
+	if q.Delivery == "" {
+		name = fmt.Sprintf("support-%s-%d.zip", fileSafe(q.Repo), q.PR)
+	}
+	slog.Info("support.bundle", "deliveries", len(m.Deliveries), "log_lines", m.LogLines, "bytes", buf.Len())
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	_, _ = w.Write(buf.Bytes())
+}
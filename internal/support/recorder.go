@@ -0,0 +1,178 @@
+// Package support keeps a short in-memory history of logs and deliveries and
+// turns it into redacted support bundles for bug reports about missed or
+// wrong backports.
+package support
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Entry is one captured log record with its attributes flattened to strings
+// (groups become dotted keys).
+type Entry struct {
+	Time  time.Time         `json:"time"`
+	Level string            `json:"level"`
+	Msg   string            `json:"msg"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
+
+// Envelope is a delivery as the processor saw it.
+type Envelope struct {
+	Delivery string            `json:"delivery"`
+	Event    string            `json:"event"`
+	Received time.Time         `json:"received"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     []byte            `json:"-"`
+}
+
+// history is the ring buffers shared by a Recorder and its derived handlers.
+type history struct {
+	mu      sync.Mutex
+	logs    []Entry
+	nextLog int
+	envs    []Envelope
+	nextEnv int
+}
+
+// Recorder is a slog.Handler that keeps the last records (at every level,
+// including debug git transcripts) in memory and passes them on to next,
+// which still applies its own level. It also keeps the last envelopes.
+type Recorder struct {
+	next   slog.Handler
+	h      *history
+	attrs  []slog.Attr
+	groups []string
+}
+
+// NewRecorder keeps up to logs records and envelopes deliveries.
+func NewRecorder(next slog.Handler, logs, envelopes int) *Recorder {
+	return &Recorder{next: next, h: &history{
+		logs: make([]Entry, 0, max(logs, 1)),
+		envs: make([]Envelope, 0, max(envelopes, 1)),
+	}}
+}
+
+// Enabled captures everything; next decides what is actually written.
+func (r *Recorder) Enabled(context.Context, slog.Level) bool { return true }
+
+func (r *Recorder) Handle(ctx context.Context, rec slog.Record) error {
+	e := Entry{Time: rec.Time, Level: rec.Level.String(), Msg: rec.Message, Attrs: map[string]string{}}
+	prefix := ""
+	for _, g := range r.groups {
+		prefix += g + "."
+	}
+	for _, a := range r.attrs {
+		flatten(e.Attrs, "", a)
+	}
+	rec.Attrs(func(a slog.Attr) bool {
+		flatten(e.Attrs, prefix, a)
+		return true
+	})
+	r.h.addLog(e)
+
+	if r.next.Enabled(ctx, rec.Level) {
+		return r.next.Handle(ctx, rec)
+	}
+	return nil
+}
+
+func (r *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	prefix := ""
+	for _, g := range r.groups {
+		prefix += g + "."
+	}
+	flat := make([]slog.Attr, 0, len(r.attrs)+len(attrs))
+	flat = append(flat, r.attrs...)
+	for _, a := range attrs {
+		flat = append(flat, slog.Attr{Key: prefix + a.Key, Value: a.Value})
+	}
+	return &Recorder{next: r.next.WithAttrs(attrs), h: r.h, attrs: flat, groups: r.groups}
+}
+
+func (r *Recorder) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return r
+	}
+	return &Recorder{next: r.next.WithGroup(name), h: r.h, attrs: r.attrs, groups: append(append([]string(nil), r.groups...), name)}
+}
+
+func flatten(dst map[string]string, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		p := prefix
+		if a.Key != "" {
+			p += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			flatten(dst, p, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	switch v.Kind() {
+	case slog.KindAny:
+		dst[prefix+a.Key] = fmt.Sprint(v.Any())
+	default:
+		dst[prefix+a.Key] = v.String()
+	}
+}
+
+// RecordEnvelope remembers a delivery (implements processor.EnvelopeRecorder).
+func (r *Recorder) RecordEnvelope(delivery, event string, headers map[string]string, body []byte) {
+	hs := make(map[string]string, len(headers))
+	for k, v := range headers {
+		hs[k] = v
+	}
+	r.h.addEnvelope(Envelope{
+		Delivery: delivery, Event: event, Received: time.Now().UTC(),
+		Headers: hs, Body: append([]byte(nil), body...),
+	})
+}
+
+func (h *history) addLog(e Entry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.logs) < cap(h.logs) {
+		h.logs = append(h.logs, e)
+		return
+	}
+	h.logs[h.nextLog] = e
+	h.nextLog = (h.nextLog + 1) % len(h.logs)
+}
+
+func (h *history) addEnvelope(env Envelope) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.envs) < cap(h.envs) {
+		h.envs = append(h.envs, env)
+		return
+	}
+	h.envs[h.nextEnv] = env
+	h.nextEnv = (h.nextEnv + 1) % len(h.envs)
+}
+
+// entries returns the captured records, oldest first.
+func (r *Recorder) entries() []Entry {
+	h := r.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]Entry, 0, len(h.logs))
+	out = append(out, h.logs[h.nextLog:]...)
+	return append(out, h.logs[:h.nextLog]...)
+}
+
+// envelopes returns the kept envelopes, oldest first.
+func (r *Recorder) envelopes() []Envelope {
+	h := r.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]Envelope, 0, len(h.envs))
+	out = append(out, h.envs[h.nextEnv:]...)
+	return append(out, h.envs[:h.nextEnv]...)
+}
@@ -0,0 +1,42 @@
+package support
+
+import (
+	"regexp"
+	"strings"
+)
+
+// secretPatterns match credentials that may show up in logs or payloads.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`x-access-token:[^@\s]+@`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{20,}\b`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{20,}\b`),
+	regexp.MustCompile(`(?i)\b(bearer|token)\s+[A-Za-z0-9._\-]{20,}`),
+	regexp.MustCompile(`sha256=[0-9a-fA-F]{64}`),
+	regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// droppedHeaders are envelope headers never included in a bundle.
+var droppedHeaders = map[string]bool{
+	"x-hub-signature":     true,
+	"x-hub-signature-256": true,
+	"authorization":       true,
+}
+
+// Redact replaces anything that looks like a credential with "[REDACTED]".
+func Redact(s string) string {
+	for _, re := range secretPatterns {
+		s = re.ReplaceAllString(s, "[REDACTED]")
+	}
+	return s
+}
+
+func redactHeaders(h map[string]string) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if droppedHeaders[strings.ToLower(k)] {
+			continue
+		}
+		out[k] = Redact(v)
+	}
+	return out
+}
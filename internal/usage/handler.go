@@ -0,0 +1,66 @@
+package usage
+
+import (
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Path is where the report endpoint is mounted.
+const Path = "/admin/usage"
+
+// Handler serves GET ?month=YYYY-MM (default: current month) as JSON, or as
+// CSV with format=csv. Requests must carry "Authorization: Bearer <Token>";
+// an empty Token rejects everything.
+type Handler struct {
+	Meter *Meter
+	Token string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if h.Token == "" || !ok || subtle.ConstantTimeCompare([]byte(got), []byte(h.Token)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	month := r.URL.Query().Get("month")
+	if month != "" {
+		if _, err := time.Parse(monthLayout, month); err != nil {
+			http.Error(w, "month must be YYYY-MM", http.StatusBadRequest)
+			return
+		}
+	}
+	rows := h.Meter.Report(month)
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"month", "installation", "clones", "api_calls", "compute_seconds", "bytes_fetched"})
+		for _, row := range rows {
+			_ = cw.Write([]string{
+				row.Month,
+				strconv.FormatInt(row.Installation, 10),
+				strconv.FormatInt(row.Clones, 10),
+				strconv.FormatInt(row.APICalls, 10),
+				strconv.FormatFloat(row.ComputeSeconds, 'f', 1, 64),
+				strconv.FormatInt(row.BytesFetched, 10),
+			})
+		}
+		cw.Flush()
+		return
+	}
+	if rows == nil {
+		rows = []Row{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rows)
+}
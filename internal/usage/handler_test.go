@@ -0,0 +1,51 @@
+package usage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	m, _ := Open("")
+	m.now = fixedClock(time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC))
+	m.Pick(3, 2*time.Second)
+	h := &Handler{Meter: m, Token: "s3cret"}
+
+	get := func(url, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get(Path, "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bad token: %d", rec.Code)
+	}
+	if rec := get(Path+"?month=October", "s3cret"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad month: %d", rec.Code)
+	}
+
+	rec := get(Path, "s3cret")
+	var rows []Row
+	if err := json.Unmarshal(rec.Body.Bytes(), &rows); err != nil || len(rows) != 1 || rows[0].Installation != 3 {
+		t.Fatalf("json = %s (%v)", rec.Body, err)
+	}
+	if rec := get(Path+"?month=2026-09", "s3cret"); strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("empty month = %s", rec.Body)
+	}
+
+	rec = get(Path+"?format=csv", "s3cret")
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Fatalf("content type = %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "2026-10,3,1,0,2.0,0") {
+		t.Fatalf("csv = %s", rec.Body)
+	}
+}
@@ -0,0 +1,126 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Slack posts monthly summaries to an incoming webhook (Slack, or anything
+// accepting its {"text": ...} payload such as Mattermost).
+type Slack struct {
+	WebhookURL string
+	Client     *http.Client // default: 10s timeout
+}
+
+// Send posts the report for month.
+func (s *Slack) Send(ctx context.Context, month string, rows []Row) error {
+	payload, err := json.Marshal(map[string]string{"text": Summary(month, rows)})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("usage summary: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c := s.Client
+	if c == nil {
+		c = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("usage summary: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("usage summary: webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Summary renders rows as a plain-text table inside a code block.
+func Summary(month string, rows []Row) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Cherry-pick app usage for %s (%d installations)\n```\n", month, len(rows))
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "installation\tclones\tapi calls\tcompute s\tfetched MiB\t")
+	var total Counts
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%.0f\t%.1f\t\n", r.Installation, r.Clones, r.APICalls, r.ComputeSeconds, mib(r.BytesFetched))
+		total.Clones += r.Clones
+		total.APICalls += r.APICalls
+		total.ComputeSeconds += r.ComputeSeconds
+		total.BytesFetched += r.BytesFetched
+	}
+	fmt.Fprintf(tw, "total\t%d\t%d\t%.0f\t%.1f\t\n", total.Clones, total.APICalls, total.ComputeSeconds, mib(total.BytesFetched))
+	_ = tw.Flush()
+	sb.WriteString("```")
+	return sb.String()
+}
+
+func mib(n int64) float64 { return float64(n) / (1 << 20) }
+
+// previousMonth is the month before t's (UTC).
+func previousMonth(t time.Time) string {
+	t = t.UTC()
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return first.AddDate(0, 0, -1).Format(monthLayout)
+}
+
+// Run flushes the snapshot every interval until ctx is done (and once more
+// then). With notify, it also sends the previous month's summary once that
+// month is over; the sent month is part of the snapshot, so restarts neither
+// repeat nor skip it.
+func (m *Meter) Run(ctx context.Context, interval time.Duration, notify *Slack) {
+	if m == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if notify != nil {
+			m.summarize(ctx, notify)
+		}
+		if err := m.Flush(); err != nil {
+			slog.Warn("usage.flush_error", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			if err := m.Flush(); err != nil {
+				slog.Warn("usage.flush_error", "err", err)
+			}
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (m *Meter) summarize(ctx context.Context, notify *Slack) {
+	m.mu.Lock()
+	month, done := previousMonth(m.clock()), m.summarized
+	m.mu.Unlock()
+	if done >= month {
+		return
+	}
+	rows := m.Report(month)
+	if len(rows) > 0 {
+		if err := notify.Send(ctx, month, rows); err != nil {
+			slog.Warn("usage.summary_error", "month", month, "err", err)
+			return // retried next interval
+		}
+		slog.Info("usage.summary_sent", "month", month, "installations", len(rows))
+	}
+	m.mu.Lock()
+	m.summarized, m.dirty = month, true
+	m.mu.Unlock()
+}
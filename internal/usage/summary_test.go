@@ -0,0 +1,86 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreviousMonth(t *testing.T) {
+	for in, want := range map[time.Time]string{
+		time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC): "2026-02",
+		time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC):   "2025-12",
+	} {
+		if got := previousMonth(in); got != want {
+			t.Errorf("previousMonth(%v) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestSummary_Table(t *testing.T) {
+	out := Summary("2026-09", []Row{
+		{Month: "2026-09", Installation: 1, Counts: Counts{Clones: 2, APICalls: 10, ComputeSeconds: 30, BytesFetched: 1 << 20}},
+		{Month: "2026-09", Installation: 2, Counts: Counts{Clones: 1, APICalls: 5}},
+	})
+	for _, want := range []string{"2026-09 (2 installations)", "installation", "total", "1.0"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSummarize_SendsPreviousMonthOnce(t *testing.T) {
+	var texts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		texts = append(texts, body["text"])
+	}))
+	defer srv.Close()
+	notify := &Slack{WebhookURL: srv.URL}
+
+	m, _ := Open("")
+	m.now = fixedClock(time.Date(2026, 9, 20, 0, 0, 0, 0, time.UTC))
+	m.Pick(1, time.Second)
+	m.summarized = "2026-08"
+	m.summarize(context.Background(), notify)
+	if len(texts) != 0 {
+		t.Fatalf("sent during the month: %v", texts)
+	}
+
+	m.now = fixedClock(time.Date(2026, 10, 1, 0, 1, 0, 0, time.UTC))
+	m.summarize(context.Background(), notify)
+	m.summarize(context.Background(), notify)
+	if len(texts) != 1 || !strings.Contains(texts[0], "2026-09") {
+		t.Fatalf("texts = %v", texts)
+	}
+}
+
+func TestSummarize_RetriesAfterError(t *testing.T) {
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	m, _ := Open("")
+	m.now = fixedClock(time.Date(2026, 9, 20, 0, 0, 0, 0, time.UTC))
+	m.Pick(1, time.Second)
+	m.now = fixedClock(time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC))
+
+	m.summarize(context.Background(), &Slack{WebhookURL: srv.URL})
+	if m.summarized == "2026-09" {
+		t.Fatal("failed send must not mark the month as summarized")
+	}
+	fail = false
+	m.summarize(context.Background(), &Slack{WebhookURL: srv.URL})
+	if m.summarized != "2026-09" {
+		t.Fatalf("summarized = %q after successful retry", m.summarized)
+	}
+}
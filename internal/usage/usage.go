@@ -0,0 +1,225 @@
+// Package usage meters what each installation costs to serve (repository
+// clones, GitHub API calls, pick compute time, bytes fetched) per calendar
+// month, for chargeback in deployments shared by several organizations.
+//
+// Counts are kept per replica; sum the reports of all replicas for a
+// deployment-wide figure.
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// monthLayout formats report months (UTC), e.g. 2026-10.
+const monthLayout = "2006-01"
+
+// Counts are the metered quantities for one installation and month.
+type Counts struct {
+	Clones         int64   `json:"clones"`
+	APICalls       int64   `json:"api_calls"`
+	ComputeSeconds float64 `json:"compute_seconds"` // wall time spent in picks (clone to push)
+	BytesFetched   int64   `json:"bytes_fetched"`   // size of the fetched git objects
+}
+
+// Row is one line of a report.
+type Row struct {
+	Month        string `json:"month"`
+	Installation int64  `json:"installation"`
+	Counts
+}
+
+type key struct {
+	month string
+	inst  int64
+}
+
+// Meter aggregates usage in memory and, with a path, in a JSON snapshot
+// written by Flush. All methods are no-ops on a nil *Meter, so callers need
+// not check whether metering is enabled.
+type Meter struct {
+	path string
+
+	mu         sync.Mutex
+	counts     map[key]*Counts
+	summarized string // last month whose summary was sent
+	dirty      bool
+	now        func() time.Time // test seam
+}
+
+type snapshot struct {
+	Summarized string `json:"summarized,omitempty"`
+	Rows       []Row  `json:"rows"`
+}
+
+// Open returns a Meter persisted at path ("" keeps it in memory only),
+// loading earlier counts when the file exists.
+func Open(path string) (*Meter, error) {
+	m := &Meter{path: path, counts: map[key]*Counts{}}
+	if path == "" {
+		return m, nil
+	}
+	raw, err := os.ReadFile(path) // #nosec G304 -- path is operator configuration (USAGE_FILE)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read usage file: %w", err)
+	}
+	var s snapshot
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("parse usage file %s: %w", path, err)
+	}
+	m.summarized = s.Summarized
+	for _, r := range s.Rows {
+		c := r.Counts
+		m.counts[key{r.Month, r.Installation}] = &c
+	}
+	return m, nil
+}
+
+func (m *Meter) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+// add applies fn to the current month's counts for inst.
+func (m *Meter) add(inst int64, fn func(*Counts)) {
+	if m == nil || inst == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := key{m.clock().UTC().Format(monthLayout), inst}
+	c := m.counts[k]
+	if c == nil {
+		c = &Counts{}
+		m.counts[k] = c
+	}
+	fn(c)
+	m.dirty = true
+}
+
+// APICall counts one GitHub API request made for inst.
+func (m *Meter) APICall(inst int64) { m.add(inst, func(c *Counts) { c.APICalls++ }) }
+
+// Pick counts one clone and d of compute for inst.
+func (m *Meter) Pick(inst int64, d time.Duration) {
+	m.add(inst, func(c *Counts) {
+		c.Clones++
+		c.ComputeSeconds += d.Seconds()
+	})
+}
+
+// Fetched counts n bytes of git objects fetched for inst.
+func (m *Meter) Fetched(inst int64, n int64) { m.add(inst, func(c *Counts) { c.BytesFetched += n }) }
+
+// Report returns month's rows (YYYY-MM, "" = current month) ordered by
+// installation.
+func (m *Meter) Report(month string) []Row {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if month == "" {
+		month = m.clock().UTC().Format(monthLayout)
+	}
+	var out []Row
+	for k, c := range m.counts {
+		if k.month == month {
+			out = append(out, Row{Month: k.month, Installation: k.inst, Counts: *c})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Installation < out[j].Installation })
+	return out
+}
+
+// Flush writes the snapshot if anything changed since the last one.
+func (m *Meter) Flush() error {
+	if m == nil || m.path == "" {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.dirty {
+		return nil
+	}
+	s := snapshot{Summarized: m.summarized, Rows: make([]Row, 0, len(m.counts))}
+	for k, c := range m.counts {
+		s.Rows = append(s.Rows, Row{Month: k.month, Installation: k.inst, Counts: *c})
+	}
+	sort.Slice(s.Rows, func(i, j int) bool {
+		if s.Rows[i].Month != s.Rows[j].Month {
+			return s.Rows[i].Month < s.Rows[j].Month
+		}
+		return s.Rows[i].Installation < s.Rows[j].Installation
+	})
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), ".usage-*.json")
+	if err != nil {
+		return fmt.Errorf("write usage file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write usage file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write usage file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return fmt.Errorf("write usage file: %w", err)
+	}
+	m.dirty = false
+	return nil
+}
+
+// Transport counts every request sent through next as an API call for inst.
+func (m *Meter) Transport(inst int64, next http.RoundTripper) http.RoundTripper {
+	if m == nil {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return meteredTransport{m: m, inst: inst, next: next}
+}
+
+type meteredTransport struct {
+	m    *Meter
+	inst int64
+	next http.RoundTripper
+}
+
+func (t meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.m.APICall(t.inst)
+	return t.next.RoundTrip(req)
+}
+
+type ctxKey struct{}
+
+// WithInstallation attributes usage metered under ctx (git work deep in the
+// pick path) to inst.
+func WithInstallation(ctx context.Context, inst int64) context.Context {
+	return context.WithValue(ctx, ctxKey{}, inst)
+}
+
+// Installation returns the installation set by WithInstallation, or 0.
+func Installation(ctx context.Context) int64 {
+	id, _ := ctx.Value(ctxKey{}).(int64)
+	return id
+}
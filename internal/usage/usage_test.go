@@ -0,0 +1,101 @@
+package usage
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func fixedClock(t time.Time) func() time.Time { return func() time.Time { return t } }
+
+func TestMeter_AggregatesPerInstallationAndMonth(t *testing.T) {
+	m, _ := Open("")
+	m.now = fixedClock(time.Date(2026, 9, 30, 23, 0, 0, 0, time.UTC))
+	m.Pick(1, 90*time.Second)
+	m.APICall(1)
+	m.Fetched(1, 100)
+	m.APICall(0) // unattributed: dropped
+
+	m.now = fixedClock(time.Date(2026, 10, 1, 1, 0, 0, 0, time.UTC))
+	m.Pick(2, time.Second)
+	m.Pick(1, time.Second)
+
+	sep := m.Report("2026-09")
+	if len(sep) != 1 || sep[0].Clones != 1 || sep[0].APICalls != 1 || sep[0].ComputeSeconds != 90 || sep[0].BytesFetched != 100 {
+		t.Fatalf("september = %+v", sep)
+	}
+	oct := m.Report("")
+	if len(oct) != 2 || oct[0].Installation != 1 || oct[1].Installation != 2 {
+		t.Fatalf("october = %+v", oct)
+	}
+}
+
+func TestMeter_NilIsNoop(t *testing.T) {
+	var m *Meter
+	m.Pick(1, time.Second)
+	m.APICall(1)
+	if m.Report("") != nil || m.Flush() != nil {
+		t.Fatal("nil meter should report nothing")
+	}
+	if m.Transport(1, http.DefaultTransport) != http.DefaultTransport {
+		t.Fatal("nil meter should not wrap transports")
+	}
+}
+
+func TestMeter_FlushAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	m, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.now = fixedClock(time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC))
+	m.Pick(7, 3*time.Second)
+	m.summarized = "2026-09"
+	if err := m.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := again.Report("2026-10")
+	if len(rows) != 1 || rows[0].Installation != 7 || rows[0].ComputeSeconds != 3 {
+		t.Fatalf("reopened = %+v", rows)
+	}
+	if again.summarized != "2026-09" {
+		t.Fatalf("summarized = %q", again.summarized)
+	}
+}
+
+type okTransport struct{}
+
+func (okTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: 200, Body: http.NoBody, Request: req}, nil
+}
+
+func TestTransport_CountsAPICalls(t *testing.T) {
+	m, _ := Open("")
+	c := &http.Client{Transport: m.Transport(5, okTransport{})}
+	for range 3 {
+		resp, err := c.Get("https://api.github.com/repos/o/r")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+	if rows := m.Report(""); len(rows) != 1 || rows[0].APICalls != 3 {
+		t.Fatalf("rows = %+v", rows)
+	}
+}
+
+func TestInstallationContext(t *testing.T) {
+	if got := Installation(context.Background()); got != 0 {
+		t.Fatalf("empty ctx = %d", got)
+	}
+	if got := Installation(WithInstallation(context.Background(), 9)); got != 9 {
+		t.Fatalf("got %d, want 9", got)
+	}
+}
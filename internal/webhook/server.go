@@ -0,0 +1,78 @@
+// Package webhook receives GitHub webhook deliveries directly over HTTP, so
+// the app can run as a plain webhook receiver without any queue in front.
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+)
+
+// EnvelopeHandler is implemented by *processor.Processor. Unlike the queue
+// path, the envelope carries GitHub's own X-Hub-Signature-256, so the
+// signature is verified against the webhook secret.
+type EnvelopeHandler interface {
+	HandleFromEnvelope(ctx context.Context, env queue.Envelope) (int, error)
+}
+
+// defaultMaxBody matches GitHub's 25 MB cap on webhook payloads.
+const defaultMaxBody = 25 << 20
+
+// Server is an http.Handler for the GitHub App webhook URL.
+type Server struct {
+	Processor    EnvelopeHandler
+	MaxBodyBytes int64 // default 25 MiB
+}
+
+// forwardedHeaders are the delivery headers the processor looks at.
+var forwardedHeaders = []string{"X-GitHub-Event", "X-GitHub-Delivery", "X-Hub-Signature-256"}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	limit := s.MaxBodyBytes
+	if limit <= 0 {
+		limit = defaultMaxBody
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	headers := make(map[string]string, len(forwardedHeaders))
+	for _, h := range forwardedHeaders {
+		if v := r.Header.Get(h); v != "" {
+			headers[h] = v
+		}
+	}
+
+	code, procErr := s.Processor.HandleFromEnvelope(r.Context(), queue.Envelope{Headers: headers, Body: body})
+	if code == 0 {
+		code = http.StatusOK
+		if procErr != nil {
+			code = http.StatusInternalServerError
+		}
+	}
+	if procErr != nil {
+		slog.Warn("webhook.http.error", "status", code, "delivery", headers["X-GitHub-Delivery"], "err", procErr)
+	}
+	if code >= 400 {
+		// Never echo processing details back to the caller.
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+	w.WriteHeader(code)
+}
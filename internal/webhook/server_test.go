@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ealebed/gh-app-cherry-pick-poc/internal/queue"
+)
+
+type fakeProcessor struct {
+	code int
+	err  error
+	got  queue.Envelope
+}
+
+func (f *fakeProcessor) HandleFromEnvelope(ctx context.Context, env queue.Envelope) (int, error) {
+	f.got = env
+	return f.code, f.err
+}
+
+func TestServer_ForwardsDelivery(t *testing.T) {
+	fp := &fakeProcessor{code: http.StatusAccepted}
+	s := &Server{Processor: fp}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"action":"closed"}`))
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	req.Header.Set("X-GitHub-Delivery", "d-1")
+	req.Header.Set("X-Hub-Signature-256", "sha256=abc")
+	req.Header.Set("Authorization", "Bearer nope")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", rec.Code)
+	}
+	if string(fp.got.Body) != `{"action":"closed"}` {
+		t.Fatalf("body = %s", fp.got.Body)
+	}
+	want := map[string]string{"X-GitHub-Event": "pull_request", "X-GitHub-Delivery": "d-1", "X-Hub-Signature-256": "sha256=abc"}
+	if len(fp.got.Headers) != len(want) {
+		t.Fatalf("headers = %v, want only %v", fp.got.Headers, want)
+	}
+	for k, v := range want {
+		if fp.got.Headers[k] != v {
+			t.Fatalf("header %s = %q, want %q", k, fp.got.Headers[k], v)
+		}
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		body   string
+		proc   *fakeProcessor
+		max    int64
+		want   int
+	}{
+		{name: "GET rejected", method: http.MethodGet, proc: &fakeProcessor{}, want: http.StatusMethodNotAllowed},
+		{name: "body too large", method: http.MethodPost, body: "0123456789", max: 4, proc: &fakeProcessor{}, want: http.StatusRequestEntityTooLarge},
+		{name: "bad signature", method: http.MethodPost, body: "{}", proc: &fakeProcessor{code: 401, err: errors.New("signature mismatch")}, want: http.StatusUnauthorized},
+		{name: "error without code", method: http.MethodPost, body: "{}", proc: &fakeProcessor{err: errors.New("boom")}, want: http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Server{Processor: tc.proc, MaxBodyBytes: tc.max}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest(tc.method, "/webhook", strings.NewReader(tc.body)))
+			if rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.want)
+			}
+			if strings.Contains(rec.Body.String(), "mismatch") || strings.Contains(rec.Body.String(), "boom") {
+				t.Fatalf("error details leaked: %q", rec.Body.String())
+			}
+		})
+	}
+}